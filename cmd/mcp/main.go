@@ -40,10 +40,16 @@ var (
 	newCandleRepoFunc        = repository.NewCandleRepository
 	newSignalRepoFunc        = repository.NewSignalRepository
 	newSignalImageRepoFunc   = repository.NewSignalImageRepository
+	newHoldingsRepoFunc      = repository.NewHoldingsRepository
+	newPriceAlertRepoFunc    = repository.NewPriceAlertRepository
 	newMCPServerFunc         = mcpserver.NewServer
 	newMCPHandlerFunc        = mcpserver.NewHTTPTransportHandler
 	newPriceServiceFunc      = service.NewPriceService
 	newSignalServiceFunc     = service.NewSignalServiceWithImages
+	newHoldingsServiceFunc   = service.NewHoldingsService
+	newSizingServiceFunc     = service.NewPositionSizingService
+	newPriceAlertSvcFunc     = service.NewPriceAlertService
+	newChartServiceFunc      = service.NewChartService
 	newSignalEngineFunc      = signalengine.NewEngine
 	newChartRendererFunc     = chart.NewRenderer
 	newSignalImageJobFunc    = job.NewSignalImageMaintenance
@@ -90,11 +96,21 @@ func main() {
 	signalEngine := newSignalEngineFunc(nil)
 	chartRenderer := newChartRendererFunc()
 	signalService := newSignalServiceFunc(tracer, candleRepo, signalRepo, signalEngine, signalImageRepo, chartRenderer)
+	signalService.SetSizing(cfg.Sizing())
 	imageJob := newSignalImageJobFunc(tracer, signalService)
 	startSignalImageJobFunc(imageJob, ctx)
+	holdingsService := newHoldingsServiceFunc(tracer, newHoldingsRepoFunc(db.Pool, tracer), priceService)
+	sizingService := newSizingServiceFunc(tracer, cfg.Sizing(), signalService, candleRepo, priceService)
+	// Rules created here are evaluated and delivered by the server's price
+	// poller.
+	priceAlertService := newPriceAlertSvcFunc(tracer, newPriceAlertRepoFunc(db.Pool, tracer), priceService)
 
 	mcpSrv := newMCPServerFunc(tracer, priceService, signalService, mcpserver.ServerConfig{
 		RequestTimeout: time.Duration(cfg.MCPRequestTimeoutSecs) * time.Second,
+		Holdings:       holdingsService,
+		Sizer:          sizingService,
+		PriceAlerts:    priceAlertService,
+		Charts:         newChartServiceFunc(tracer, candleRepo, chartRenderer, cache.Client),
 	})
 
 	transport := strings.ToLower(strings.TrimSpace(cfg.MCPTransport))
@@ -152,7 +152,7 @@ func stubMCPDeps(t *testing.T, transport string) func() {
 		service.SignalImageRepository,
 		service.SignalChartRenderer,
 	) *service.SignalService {
-		return nil
+		return &service.SignalService{}
 	}
 	newChartRendererFunc = func() *chart.Renderer { return nil }
 	newSignalImageJobFunc = func(trace.Tracer, job.SignalImageMaintainer) *job.SignalImageMaintenance { return nil }
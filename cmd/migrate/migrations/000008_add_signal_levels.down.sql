@@ -0,0 +1,6 @@
+ALTER TABLE signals
+    DROP COLUMN IF EXISTS level_basis,
+    DROP COLUMN IF EXISTS reward_risk,
+    DROP COLUMN IF EXISTS targets_json,
+    DROP COLUMN IF EXISTS stop_price,
+    DROP COLUMN IF EXISTS entry_price;
@@ -0,0 +1,6 @@
+ALTER TABLE signals
+    ADD COLUMN IF NOT EXISTS entry_price  DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS stop_price   DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS targets_json TEXT NOT NULL DEFAULT '[]',
+    ADD COLUMN IF NOT EXISTS reward_risk  DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS level_basis  TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS backtest_trades;
+DROP TABLE IF EXISTS backtest_runs;
@@ -0,0 +1,48 @@
+CREATE TABLE IF NOT EXISTS backtest_runs (
+    id                BIGSERIAL PRIMARY KEY,
+    symbol            TEXT             NOT NULL,
+    interval          TEXT             NOT NULL,
+    window_from       TIMESTAMPTZ      NOT NULL,
+    window_to         TIMESTAMPTZ      NOT NULL,
+    engine_version    TEXT             NOT NULL,
+    params_json       TEXT             NOT NULL DEFAULT '{}',
+    trades            INT              NOT NULL DEFAULT 0,
+    wins              INT              NOT NULL DEFAULT 0,
+    losses            INT              NOT NULL DEFAULT 0,
+    win_rate          DOUBLE PRECISION NOT NULL DEFAULT 0,
+    profit_factor     DOUBLE PRECISION NOT NULL DEFAULT 0,
+    total_return      DOUBLE PRECISION NOT NULL DEFAULT 0,
+    avg_trade_return  DOUBLE PRECISION NOT NULL DEFAULT 0,
+    sharpe            DOUBLE PRECISION NOT NULL DEFAULT 0,
+    sortino           DOUBLE PRECISION NOT NULL DEFAULT 0,
+    max_drawdown      DOUBLE PRECISION NOT NULL DEFAULT 0,
+    final_equity      DOUBLE PRECISION NOT NULL DEFAULT 0,
+    equity_json       TEXT             NOT NULL DEFAULT '[]',
+    created_at        TIMESTAMPTZ      NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_backtest_runs_created
+    ON backtest_runs (created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_backtest_runs_symbol_interval
+    ON backtest_runs (symbol, interval, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS backtest_trades (
+    run_id        BIGINT           NOT NULL REFERENCES backtest_runs(id) ON DELETE CASCADE,
+    seq           INT              NOT NULL,
+    indicator     TEXT             NOT NULL,
+    direction     TEXT             NOT NULL,
+    risk          SMALLINT         NOT NULL,
+    entry_time    TIMESTAMPTZ      NOT NULL,
+    exit_time     TIMESTAMPTZ      NOT NULL,
+    entry_price   DOUBLE PRECISION NOT NULL,
+    exit_price    DOUBLE PRECISION NOT NULL,
+    stop_price    DOUBLE PRECISION,
+    target_price  DOUBLE PRECISION,
+    exit_reason   TEXT             NOT NULL,
+    bars          INT              NOT NULL,
+    return_pct    DOUBLE PRECISION NOT NULL,
+    pnl           DOUBLE PRECISION NOT NULL,
+    fees          DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY (run_id, seq)
+);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS signal_param_sets (
+    id           BIGSERIAL PRIMARY KEY,
+    params_json  TEXT        NOT NULL,
+    source       TEXT        NOT NULL DEFAULT '',
+    active       BOOLEAN     NOT NULL DEFAULT FALSE,
+    promoted_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_signal_param_sets_active
+    ON signal_param_sets (active) WHERE active;
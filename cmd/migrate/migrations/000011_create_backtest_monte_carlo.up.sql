@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS backtest_monte_carlo (
+    run_id       BIGINT      PRIMARY KEY REFERENCES backtest_runs(id) ON DELETE CASCADE,
+    config_json  TEXT        NOT NULL,
+    result_json  TEXT        NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS paper_subscriptions;
+DROP TABLE IF EXISTS paper_orders;
+DROP TABLE IF EXISTS paper_positions;
+DROP TABLE IF EXISTS paper_accounts;
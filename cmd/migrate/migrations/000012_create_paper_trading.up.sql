@@ -0,0 +1,50 @@
+CREATE TABLE IF NOT EXISTS paper_accounts (
+    id                BIGSERIAL PRIMARY KEY,
+    name              TEXT             NOT NULL,
+    owner             TEXT             NOT NULL DEFAULT '',
+    starting_balance  DOUBLE PRECISION NOT NULL,
+    cash              DOUBLE PRECISION NOT NULL,
+    slippage_bps      DOUBLE PRECISION NOT NULL DEFAULT 0,
+    created_at        TIMESTAMPTZ      NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_paper_accounts_owner
+    ON paper_accounts (owner) WHERE owner <> '';
+
+CREATE TABLE IF NOT EXISTS paper_positions (
+    account_id    BIGINT           NOT NULL REFERENCES paper_accounts(id) ON DELETE CASCADE,
+    symbol        TEXT             NOT NULL,
+    quantity      DOUBLE PRECISION NOT NULL,
+    avg_price     DOUBLE PRECISION NOT NULL,
+    realized_pnl  DOUBLE PRECISION NOT NULL DEFAULT 0,
+    updated_at    TIMESTAMPTZ      NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (account_id, symbol)
+);
+
+CREATE TABLE IF NOT EXISTS paper_orders (
+    id            BIGSERIAL PRIMARY KEY,
+    account_id    BIGINT           NOT NULL REFERENCES paper_accounts(id) ON DELETE CASCADE,
+    symbol        TEXT             NOT NULL,
+    side          TEXT             NOT NULL,
+    quantity      DOUBLE PRECISION NOT NULL,
+    market_price  DOUBLE PRECISION NOT NULL,
+    fill_price    DOUBLE PRECISION NOT NULL,
+    notional      DOUBLE PRECISION NOT NULL,
+    realized_pnl  DOUBLE PRECISION NOT NULL DEFAULT 0,
+    source        TEXT             NOT NULL DEFAULT 'manual',
+    signal_id     BIGINT,
+    created_at    TIMESTAMPTZ      NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_paper_orders_account
+    ON paper_orders (account_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS paper_subscriptions (
+    id              BIGSERIAL PRIMARY KEY,
+    account_id      BIGINT           NOT NULL REFERENCES paper_accounts(id) ON DELETE CASCADE,
+    symbol          TEXT             NOT NULL DEFAULT '',
+    indicator       TEXT             NOT NULL DEFAULT '',
+    max_risk        SMALLINT         NOT NULL DEFAULT 0,
+    trade_notional  DOUBLE PRECISION NOT NULL,
+    created_at      TIMESTAMPTZ      NOT NULL DEFAULT NOW()
+);
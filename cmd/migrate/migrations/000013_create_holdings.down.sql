@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS holding_preferences;
+DROP TABLE IF EXISTS holding_trades;
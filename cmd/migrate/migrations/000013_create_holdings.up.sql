@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS holding_trades (
+    id           BIGSERIAL PRIMARY KEY,
+    chat_id      BIGINT           NOT NULL,
+    symbol       TEXT             NOT NULL,
+    side         TEXT             NOT NULL,
+    quantity     DOUBLE PRECISION NOT NULL,
+    price        DOUBLE PRECISION NOT NULL,
+    fee          DOUBLE PRECISION NOT NULL DEFAULT 0,
+    executed_at  TIMESTAMPTZ      NOT NULL,
+    source       TEXT             NOT NULL DEFAULT 'manual',
+    external_id  TEXT             NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ      NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_holding_trades_chat
+    ON holding_trades (chat_id, executed_at, id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_holding_trades_external
+    ON holding_trades (chat_id, external_id) WHERE external_id <> '';
+
+CREATE TABLE IF NOT EXISTS holding_preferences (
+    chat_id             BIGINT      PRIMARY KEY,
+    share_with_advisor  BOOLEAN     NOT NULL DEFAULT FALSE,
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
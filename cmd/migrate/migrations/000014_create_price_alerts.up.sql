@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS price_alert_rules (
+    id                 BIGSERIAL PRIMARY KEY,
+    chat_id            BIGINT           NOT NULL,
+    symbol             TEXT             NOT NULL,
+    type               TEXT             NOT NULL,
+    direction          TEXT             NOT NULL,
+    threshold          DOUBLE PRECISION NOT NULL,
+    window_mins        INTEGER          NOT NULL DEFAULT 0,
+    hysteresis         DOUBLE PRECISION NOT NULL,
+    recurring          BOOLEAN          NOT NULL DEFAULT FALSE,
+    active             BOOLEAN          NOT NULL DEFAULT TRUE,
+    armed              BOOLEAN          NOT NULL DEFAULT TRUE,
+    trigger_count      INTEGER          NOT NULL DEFAULT 0,
+    last_triggered_at  TIMESTAMPTZ,
+    created_at         TIMESTAMPTZ      NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_alert_rules_chat
+    ON price_alert_rules (chat_id, id);
+
+CREATE INDEX IF NOT EXISTS idx_price_alert_rules_active
+    ON price_alert_rules (symbol) WHERE active;
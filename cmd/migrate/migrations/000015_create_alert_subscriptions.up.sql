@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS alert_subscriptions (
+    chat_id      BIGINT      PRIMARY KEY,
+    enabled      BOOLEAN     NOT NULL DEFAULT TRUE,
+    symbols      TEXT        NOT NULL DEFAULT '',
+    intervals    TEXT        NOT NULL DEFAULT '',
+    indicators   TEXT        NOT NULL DEFAULT '',
+    min_risk     SMALLINT    NOT NULL DEFAULT 0,
+    max_risk     SMALLINT    NOT NULL DEFAULT 0,
+    direction    TEXT        NOT NULL DEFAULT '',
+    quiet_start  SMALLINT    NOT NULL DEFAULT 0,
+    quiet_end    SMALLINT    NOT NULL DEFAULT 0,
+    timezone     TEXT        NOT NULL DEFAULT 'UTC',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS alert_deliveries (
+    id               BIGSERIAL   PRIMARY KEY,
+    chat_id          BIGINT      NOT NULL,
+    kind             TEXT        NOT NULL,
+    signal_id        BIGINT      NOT NULL DEFAULT 0,
+    text             TEXT        NOT NULL,
+    status           TEXT        NOT NULL DEFAULT 'pending',
+    attempts         INTEGER     NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error       TEXT        NOT NULL DEFAULT '',
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    sent_at          TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_deliveries_due
+    ON alert_deliveries (next_attempt_at, id) WHERE status IN ('pending', 'sending');
+
+CREATE INDEX IF NOT EXISTS idx_alert_deliveries_chat
+    ON alert_deliveries (chat_id, id);
@@ -0,0 +1,3 @@
+ALTER TABLE alert_deliveries DROP COLUMN IF EXISTS image;
+
+DROP TABLE IF EXISTS digest_subscriptions;
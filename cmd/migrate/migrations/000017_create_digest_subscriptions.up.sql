@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS digest_subscriptions (
+    chat_id       BIGINT      PRIMARY KEY,
+    period        TEXT        NOT NULL,
+    send_minute   SMALLINT    NOT NULL,
+    weekday       SMALLINT    NOT NULL DEFAULT 0,
+    timezone      TEXT        NOT NULL DEFAULT 'UTC',
+    last_sent_at  TIMESTAMPTZ,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Digests carry a chart image through the alert outbox.
+ALTER TABLE alert_deliveries ADD COLUMN IF NOT EXISTS image BYTEA;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS telegram_invites;
+DROP TABLE IF EXISTS telegram_users;
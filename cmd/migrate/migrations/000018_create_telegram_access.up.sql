@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS telegram_users (
+    chat_id     BIGINT      PRIMARY KEY,
+    username    TEXT        NOT NULL DEFAULT '',
+    role        TEXT        NOT NULL,
+    invited_by  BIGINT      NOT NULL DEFAULT 0,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Invite codes are single use; used_by and used_at are set on redemption.
+CREATE TABLE IF NOT EXISTS telegram_invites (
+    code        TEXT        PRIMARY KEY,
+    role        TEXT        NOT NULL,
+    created_by  BIGINT      NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at  TIMESTAMPTZ NOT NULL,
+    used_by     BIGINT,
+    used_at     TIMESTAMPTZ
+);
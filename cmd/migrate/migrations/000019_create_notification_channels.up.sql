@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS notification_channels (
+    id           BIGSERIAL   PRIMARY KEY,
+    name         TEXT        NOT NULL,
+    type         TEXT        NOT NULL,
+    target       TEXT        NOT NULL,
+    secret       TEXT        NOT NULL DEFAULT '',
+    kinds        TEXT        NOT NULL DEFAULT 'signal',
+    symbols      TEXT        NOT NULL DEFAULT '',
+    intervals    TEXT        NOT NULL DEFAULT '',
+    indicators   TEXT        NOT NULL DEFAULT '',
+    min_risk     SMALLINT    NOT NULL DEFAULT 0,
+    max_risk     SMALLINT    NOT NULL DEFAULT 0,
+    direction    TEXT        NOT NULL DEFAULT '',
+    enabled      BOOLEAN     NOT NULL DEFAULT TRUE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    id               BIGSERIAL   PRIMARY KEY,
+    channel_id       BIGINT      NOT NULL REFERENCES notification_channels (id) ON DELETE CASCADE,
+    kind             TEXT        NOT NULL,
+    payload          JSONB       NOT NULL,
+    status           TEXT        NOT NULL DEFAULT 'pending',
+    attempts         INTEGER     NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error       TEXT        NOT NULL DEFAULT '',
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    sent_at          TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_due
+    ON notification_deliveries (next_attempt_at, id) WHERE status IN ('pending', 'sending');
+
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_channel
+    ON notification_deliveries (channel_id, id);
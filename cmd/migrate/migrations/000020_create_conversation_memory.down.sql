@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_conversation_messages_created;
+DROP TABLE IF EXISTS conversation_memory;
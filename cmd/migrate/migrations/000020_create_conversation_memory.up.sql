@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS conversation_memory (
+    chat_id            BIGINT      PRIMARY KEY,
+    summary            TEXT        NOT NULL DEFAULT '',
+    compacted_through  BIGINT      NOT NULL DEFAULT 0,
+    facts              JSONB       NOT NULL DEFAULT '[]',
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_conversation_messages_created
+    ON conversation_messages (created_at);
@@ -0,0 +1,3 @@
+ALTER TABLE signals
+    DROP COLUMN IF EXISTS level_exit_price,
+    DROP COLUMN IF EXISTS level_outcome;
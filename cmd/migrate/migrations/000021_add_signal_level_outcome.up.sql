@@ -0,0 +1,3 @@
+ALTER TABLE signals
+    ADD COLUMN IF NOT EXISTS level_outcome    TEXT,
+    ADD COLUMN IF NOT EXISTS level_exit_price DOUBLE PRECISION;
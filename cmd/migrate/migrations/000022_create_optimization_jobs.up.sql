@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS optimization_jobs (
+    id            BIGSERIAL PRIMARY KEY,
+    status        TEXT        NOT NULL,
+    request_json  TEXT        NOT NULL,
+    done          INTEGER     NOT NULL DEFAULT 0,
+    total         INTEGER     NOT NULL DEFAULT 0,
+    error         TEXT        NOT NULL DEFAULT '',
+    result_json   TEXT,
+    promoted_set  BIGINT      NOT NULL DEFAULT 0,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at    TIMESTAMPTZ,
+    finished_at   TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_optimization_jobs_created
+    ON optimization_jobs (created_at DESC);
@@ -16,8 +16,10 @@ import (
 	"bug-free-umbrella/internal/chart"
 	"bug-free-umbrella/internal/config"
 	"bug-free-umbrella/internal/db"
+	"bug-free-umbrella/internal/delivery"
 	"bug-free-umbrella/internal/handler"
 	"bug-free-umbrella/internal/job"
+	"bug-free-umbrella/internal/llm"
 	"bug-free-umbrella/internal/marketintel"
 	"bug-free-umbrella/internal/ml/ensemble"
 	"bug-free-umbrella/internal/ml/features"
@@ -25,6 +27,7 @@ import (
 	"bug-free-umbrella/internal/ml/predictions"
 	"bug-free-umbrella/internal/ml/registry"
 	"bug-free-umbrella/internal/ml/training"
+	"bug-free-umbrella/internal/notify"
 	"bug-free-umbrella/internal/provider"
 	"bug-free-umbrella/internal/repository"
 	"bug-free-umbrella/internal/service"
@@ -44,31 +47,62 @@ import (
 )
 
 var (
-	loadEnvFunc              = godotenv.Load
-	loadConfigFunc           = config.Load
-	initPostgresFunc         = db.InitPostgres
-	initRedisFunc            = cache.InitRedis
-	initTracerFunc           = tracing.InitTracer
-	newCandleRepoFunc        = repository.NewCandleRepository
-	newSignalRepoFunc        = repository.NewSignalRepository
-	newSignalImageRepoFunc   = repository.NewSignalImageRepository
-	newBacktestRepoFunc      = repository.NewBacktestRepository
-	newCoinGeckoProviderFunc = func(tracer trace.Tracer) service.PriceProvider {
+	loadEnvFunc                     = godotenv.Load
+	loadConfigFunc                  = config.Load
+	initPostgresFunc                = db.InitPostgres
+	initRedisFunc                   = cache.InitRedis
+	initTracerFunc                  = tracing.InitTracer
+	newCandleRepoFunc               = repository.NewCandleRepository
+	newSignalRepoFunc               = repository.NewSignalRepository
+	newSignalImageRepoFunc          = repository.NewSignalImageRepository
+	newBacktestRepoFunc             = repository.NewBacktestRepository
+	newBacktestRunRepoFunc          = repository.NewBacktestRunRepository
+	newSignalParamsRepoFunc         = repository.NewSignalParamsRepository
+	newOptimizationJobRepoFunc      = repository.NewOptimizationJobRepository
+	newPaperRepoFunc                = repository.NewPaperRepository
+	newHoldingsRepoFunc             = repository.NewHoldingsRepository
+	newPriceAlertRepoFunc           = repository.NewPriceAlertRepository
+	newAlertSubRepoFunc             = repository.NewAlertSubscriptionRepository
+	newAlertDeliveryRepoFunc        = repository.NewAlertDeliveryRepository
+	newDigestRepoFunc               = repository.NewDigestRepository
+	newAccessRepoFunc               = repository.NewAccessRepository
+	newNotificationChannelRepoFunc  = repository.NewNotificationChannelRepository
+	newNotificationDeliveryRepoFunc = repository.NewNotificationDeliveryRepository
+	newCoinGeckoProviderFunc        = func(tracer trace.Tracer) service.PriceProvider {
 		return provider.NewCoinGeckoProvider(tracer)
 	}
 	newSignalEngineFunc            = signalengine.NewEngine
 	newPriceServiceFunc            = service.NewPriceService
 	newSignalServiceWithImagesFunc = service.NewSignalServiceWithImages
 	newBacktestServiceFunc         = service.NewBacktestService
+	newStrategyBacktestServiceFunc = service.NewStrategyBacktestService
+	newStrategyOptimizerFunc       = service.NewStrategyOptimizerService
+	newMLAnalyticsServiceFunc      = service.NewMLAnalyticsService
+	newPaperTradingServiceFunc     = service.NewPaperTradingService
+	newHoldingsServiceFunc         = service.NewHoldingsService
+	newSizingServiceFunc           = service.NewPositionSizingService
+	newPriceAlertServiceFunc       = service.NewPriceAlertService
+	newDigestServiceFunc           = service.NewDigestService
+	newAccessServiceFunc           = service.NewAccessService
+	newNotificationServiceFunc     = service.NewNotificationService
 	newChartRendererFunc           = chart.NewRenderer
+	newChartServiceFunc            = service.NewChartService
 	newPricePollerFunc             = job.NewPricePoller
 	newSignalPollerFunc            = job.NewSignalPoller
 	newSignalImageJobFunc          = job.NewSignalImageMaintenance
+	newSignalParamsSyncFunc        = job.NewSignalParamsSync
+	startSignalParamsSyncFunc      = func(j *job.SignalParamsSync, ctx context.Context) { go j.Start(ctx) }
 	startPollerFunc                = func(p *job.PricePoller, ctx context.Context) { go p.Start(ctx) }
 	startSignalPollerFunc          = func(p *job.SignalPoller, ctx context.Context) { go p.Start(ctx) }
 	startSignalImageJobFunc        = func(j *job.SignalImageMaintenance, ctx context.Context) { go j.Start(ctx) }
+	newDigestJobFunc               = job.NewDigestJob
+	startDigestJobFunc             = func(j *job.DigestJob, ctx context.Context) { go j.Start(ctx) }
+	newConversationRetentionFunc   = job.NewConversationRetention
+	startConversationRetentionFunc = func(j *job.ConversationRetention, ctx context.Context) { go j.Start(ctx) }
+	newDeliveryWorkerFunc          = delivery.NewWorker
+	startDeliveryWorkerFunc        = func(w *delivery.Worker, ctx context.Context) { go w.Start(ctx) }
 	newConversationRepoFunc        = repository.NewConversationRepository
-	newOpenAIClientFunc            = advisor.NewOpenAIClient
+	newLLMClientFunc               = llm.New
 	newAdvisorServiceFunc          = advisor.NewAdvisorService
 	startTelegramBotFunc           = bot.StartTelegramBot
 	newWorkServiceFunc             = service.NewWorkService
@@ -83,6 +117,9 @@ var (
 	waitForSignalFunc              = func(quit <-chan os.Signal) { <-quit }
 	startHTTPServerFunc            = func(srv *http.Server) error { return srv.ListenAndServe() }
 	shutdownHTTPServerFunc         = func(srv *http.Server, ctx context.Context) error { return srv.Shutdown(ctx) }
+	restoreSignalParamsFunc        = func(ctx context.Context, svc *service.StrategyOptimizerService) error {
+		return svc.RestoreLiveParams(ctx)
+	}
 )
 
 // @title           Bug Free Umbrella API
@@ -125,35 +162,78 @@ func main() {
 	signalRepo := newSignalRepoFunc(db.Pool, tracer)
 	signalImageRepo := newSignalImageRepoFunc(db.Pool, tracer)
 	backtestRepo := newBacktestRepoFunc(db.Pool, tracer)
+	backtestRunRepo := newBacktestRunRepoFunc(db.Pool, tracer)
+	signalParamsRepo := newSignalParamsRepoFunc(db.Pool, tracer)
+	paperRepo := newPaperRepoFunc(db.Pool, tracer)
+	holdingsRepo := newHoldingsRepoFunc(db.Pool, tracer)
+	priceAlertRepo := newPriceAlertRepoFunc(db.Pool, tracer)
 
 	// Create providers and services
 	cgProvider := newCoinGeckoProviderFunc(tracer)
 	priceService := newPriceServiceFunc(tracer, cgProvider, candleRepo, cache.Client)
 	signalEngine := newSignalEngineFunc(nil)
+	strategyOptimizer := newStrategyOptimizerFunc(tracer, candleRepo, signalParamsRepo, signalEngine)
+	strategyOptimizer.SetContext(ctx)
+	strategyOptimizer.SetJobStore(newOptimizationJobRepoFunc(db.Pool, tracer))
+	if err := restoreSignalParamsFunc(ctx, strategyOptimizer); err != nil {
+		log.Printf("Failed to restore promoted signal params, using defaults: %v", err)
+	}
 	chartRenderer := newChartRendererFunc()
 	signalService := newSignalServiceWithImagesFunc(tracer, candleRepo, signalRepo, signalEngine, signalImageRepo, chartRenderer)
+	paperService := newPaperTradingServiceFunc(tracer, paperRepo, priceService)
+	holdingsService := newHoldingsServiceFunc(tracer, holdingsRepo, priceService)
+	signalService.SetSizing(cfg.Sizing())
+	sizingService := newSizingServiceFunc(tracer, cfg.Sizing(), signalService, candleRepo, priceService)
+	priceAlertService := newPriceAlertServiceFunc(tracer, priceAlertRepo, priceService)
+	mlAnalyticsService := newMLAnalyticsServiceFunc(tracer, backtestRepo)
+	backtestService := newBacktestServiceFunc(tracer, backtestRepo)
+	marketIntelRepo := marketintel.NewRepository(db.Pool, tracer)
+	digestService := newDigestServiceFunc(tracer, newDigestRepoFunc(db.Pool, tracer), service.DigestSources{
+		Prices:      priceService,
+		Candles:     candleRepo,
+		Signals:     signalService,
+		ML:          mlAnalyticsService,
+		MarketIntel: marketIntelRepo,
+		Chart:       chartRenderer,
+	})
+	chartService := newChartServiceFunc(tracer, candleRepo, chartRenderer, cache.Client)
+
+	// Notification channels get signals and price alerts whether or not the
+	// Telegram bot runs; their own outbox worker retries and logs sends.
+	notificationChannelRepo := newNotificationChannelRepoFunc(db.Pool, tracer)
+	notificationDeliveryRepo := newNotificationDeliveryRepoFunc(db.Pool, tracer)
+	notifiers := notify.NewMux(nil, cfg.SMTP())
+	notificationService := newNotificationServiceFunc(tracer, notificationChannelRepo, notificationDeliveryRepo, notifiers)
+	priceAlertService.AddNotifier(notificationService)
+	startDeliveryWorkerFunc(newDeliveryWorkerFunc(tracer, notificationDeliveryRepo,
+		notify.NewSender(notificationChannelRepo, notifiers), cfg.NotificationDelivery()), ctx)
 
 	// Create conversation repository and advisor
 	convRepo := newConversationRepoFunc(db.Pool, tracer)
 	var advisorSvc *advisor.AdvisorService
-	if cfg.OpenAIAPIKey != "" {
-		llmClient := newOpenAIClientFunc(cfg.OpenAIAPIKey)
+	if advisorLLM := cfg.AdvisorLLM(); advisorLLM.Enabled() {
+		llmClient, err := newLLMClientFunc(advisorLLM)
+		if err != nil {
+			log.Fatalf("Failed to create advisor LLM client: %v", err)
+		}
 		advisorSvc = newAdvisorServiceFunc(tracer, llmClient, priceService, signalService,
-			convRepo, cfg.OpenAIModel, cfg.AdvisorMaxHistory)
-		log.Println("Advisor service enabled")
+			convRepo, advisorLLM.Model, cfg.AdvisorMaxHistory)
+		advisorSvc.SetHoldings(holdingsService)
+		advisorSvc.SetToolSources(advisor.ToolSources{
+			Candles:     candleRepo,
+			Params:      signalEngine,
+			Backtests:   backtestService,
+			MLAnalytics: mlAnalyticsService,
+			MarketIntel: marketIntelRepo,
+		})
+		advisorSvc.SetToolLimits(cfg.AdvisorMaxToolRounds, time.Duration(cfg.AdvisorTimeoutSecs)*time.Second)
+		advisorSvc.SetHistoryBudget(cfg.AdvisorHistoryTokens, cfg.AdvisorContextTokens)
+		advisorSvc.SetMemory(convRepo, cfg.AdvisorMemoryFacts)
+		advisorSvc.SetVerification(cfg.AdvisorVerifyMode, cfg.AdvisorVerifyTolerancePct/100)
+		digestService.SetSummarizer(advisorSvc)
+		log.Printf("Advisor service enabled (%s, %s)", advisorLLM.Provider, advisorLLM.Model)
 	}
 
-	// Start Telegram bot
-	os.Setenv("TELEGRAM_BOT_TOKEN", cfg.TelegramBotToken)
-	alertDispatcher := startTelegramBotFunc(priceService, signalService, advisorSvc)
-
-	// Start background pollers (stopped by ctx cancel)
-	poller := newPricePollerFunc(tracer, priceService, cfg.CoinGeckoPollSecs)
-	startPollerFunc(poller, ctx)
-	signalPoller := newSignalPollerFunc(tracer, signalService, alertDispatcher)
-	startSignalPollerFunc(signalPoller, ctx)
-	signalImageJob := newSignalImageJobFunc(tracer, signalService)
-	startSignalImageJobFunc(signalImageJob, ctx)
 	var mlService *service.MLSignalService
 	if cfg.MLEnabled {
 		if db.Pool == nil {
@@ -176,7 +256,7 @@ func main() {
 				mlFeatureRepo,
 				mlRegistryRepo,
 				mlPredictionRepo,
-				signalRepo,
+				service.NewLevelingSignalStore(tracer, candleRepo, signalRepo),
 				ensemble.NewService(),
 				inference.Config{
 					Interval:         cfg.MLInterval,
@@ -204,18 +284,21 @@ func main() {
 					TrainWindowDays: cfg.MLTrainWindowDays,
 				},
 			)
-			go job.NewMLFeatureInferenceJob(
-				tracer,
-				mlService,
-				time.Duration(cfg.MLInferPollSecs)*time.Second,
-			).Start(ctx)
-			go job.NewMLTrainingJob(tracer, mlService, cfg.MLTrainHourUTC).Start(ctx)
-			go job.NewMLOutcomeResolverJob(
-				tracer,
-				mlService,
-				time.Duration(cfg.MLResolvePollSecs)*time.Second,
-				200,
-			).Start(ctx)
+			mlService.SetLevelOutcomes(signalRepo)
+			if cfg.RunJobs {
+				go job.NewMLFeatureInferenceJob(
+					tracer,
+					mlService,
+					time.Duration(cfg.MLInferPollSecs)*time.Second,
+				).Start(ctx)
+				go job.NewMLTrainingJob(tracer, mlService, cfg.MLTrainHourUTC).Start(ctx)
+				go job.NewMLOutcomeResolverJob(
+					tracer,
+					mlService,
+					time.Duration(cfg.MLResolvePollSecs)*time.Second,
+					200,
+				).Start(ctx)
+			}
 			log.Printf(
 				"ML jobs enabled intervals=%v directional_interval=%s target_hours=%d train_window_days=%d iforest=%v",
 				cfg.MLIntervals, cfg.MLInterval, cfg.MLTargetHours, cfg.MLTrainWindowDays, cfg.MLEnableIForest,
@@ -228,9 +311,16 @@ func main() {
 		if db.Pool == nil {
 			log.Println("Market intel job disabled: DATABASE_URL is required")
 		} else {
-			marketIntelRepo := marketintel.NewRepository(db.Pool, tracer)
+			var scoringClient llm.Client
+			if scoringLLM := cfg.SentimentLLM(); scoringLLM.Enabled() {
+				client, err := newLLMClientFunc(scoringLLM)
+				if err != nil {
+					log.Printf("Market intel LLM scoring disabled: %v", err)
+				}
+				scoringClient = client
+			}
 			marketIntelScorer := marketintel.NewScorer(
-				marketintel.NewOpenAIScorer(cfg.OpenAIAPIKey, cfg.MarketIntelScoringModel),
+				marketintel.NewLLMScorer(scoringClient, cfg.MarketIntelScoringModel),
 				cfg.MarketIntelScoringBatchSize,
 			)
 			onChainProviders := map[string]marketintel.OnChainReader{
@@ -243,7 +333,7 @@ func main() {
 				tracer,
 				marketIntelRepo,
 				marketIntelScorer,
-				signalRepo,
+				service.NewLevelingSignalStore(tracer, candleRepo, signalRepo),
 				provider.NewFearGreedProvider(tracer),
 				provider.NewRedditProvider(tracer),
 				provider.NewRSSProvider(tracer),
@@ -265,11 +355,13 @@ func main() {
 				},
 			)
 			marketIntelService = service.NewMarketIntelService(tracer, rawMarketIntelSvc)
-			go job.NewMarketIntelJob(
-				tracer,
-				marketIntelService,
-				time.Duration(cfg.MarketIntelPollSecs)*time.Second,
-			).Start(ctx)
+			if cfg.RunJobs {
+				go job.NewMarketIntelJob(
+					tracer,
+					marketIntelService,
+					time.Duration(cfg.MarketIntelPollSecs)*time.Second,
+				).Start(ctx)
+			}
 			log.Printf(
 				"Market intel job enabled intervals=%v poll_secs=%d onchain=%v symbols=%v",
 				cfg.MarketIntelIntervals,
@@ -280,11 +372,74 @@ func main() {
 		}
 	}
 
+	// Start Telegram bot
+	accessService := newAccessServiceFunc(tracer, newAccessRepoFunc(db.Pool, tracer), cfg.TelegramAdminIDs)
+	if err := accessService.EnsureAdmins(ctx); err != nil {
+		log.Printf("Failed to store Telegram admins: %v", err)
+	}
+	adminTools := bot.AdminTools{Jobs: job.Statuses}
+	if mlService != nil {
+		adminTools.Training = mlService
+	}
+	if marketIntelService != nil {
+		adminTools.MarketIntel = marketIntelService
+	}
+	var telegramWebhook *bot.WebhookPoller
+	if cfg.TelegramBotToken != "" && cfg.TelegramWebhookURL != "" {
+		telegramWebhook = bot.NewWebhookPoller(cfg.TelegramWebhookURL, cfg.TelegramWebhookSecret, bot.NewRedisUpdateDeduper(cache.Client))
+	}
+	os.Setenv("TELEGRAM_BOT_TOKEN", cfg.TelegramBotToken)
+	alertDispatcher := startTelegramBotFunc(priceService, signalService, advisorSvc, paperService, holdingsService, sizingService, priceAlertService, newAlertSubRepoFunc(db.Pool, tracer), digestService, chartService, bot.NewRedisCallbackStore(cache.Client), accessService, adminTools, telegramWebhook)
+	if alertDispatcher != nil {
+		priceAlertService.AddNotifier(alertDispatcher)
+		digestService.SetSender(alertDispatcher)
+		// Alerts go through the outbox so a burst of signals is sent within
+		// Telegram's rate limits and failed sends are retried. Every replica
+		// enqueues, but the limits are per process, so only the RUN_JOBS
+		// replica sends.
+		deliveryRepo := newAlertDeliveryRepoFunc(db.Pool, tracer)
+		alertDispatcher.UseQueue(deliveryRepo)
+		if cfg.RunJobs {
+			startDeliveryWorkerFunc(newDeliveryWorkerFunc(tracer, deliveryRepo, alertDispatcher, cfg.AlertDelivery()), ctx)
+		}
+	}
+
+	// Start background pollers (stopped by ctx cancel). With RUN_JOBS=false
+	// this replica only serves requests; the notification channel outbox
+	// worker above still runs on every replica since it claims deliveries
+	// with row locks.
+	if cfg.RunJobs {
+		poller := newPricePollerFunc(tracer, priceService, cfg.CoinGeckoPollSecs)
+		poller.AddRefreshSink(priceAlertService)
+		startPollerFunc(poller, ctx)
+		signalPoller := newSignalPollerFunc(tracer, signalService, alertDispatcher)
+		signalPoller.AddAlertSink(paperService)
+		signalPoller.AddAlertSink(notificationService)
+		startSignalPollerFunc(signalPoller, ctx)
+		signalImageJob := newSignalImageJobFunc(tracer, signalService)
+		startSignalImageJobFunc(signalImageJob, ctx)
+		// Promotions may be served by any replica; reload the active set
+		// so the signal poller here follows them.
+		startSignalParamsSyncFunc(newSignalParamsSyncFunc(tracer, strategyOptimizer), ctx)
+		startDigestJobFunc(newDigestJobFunc(tracer, digestService), ctx)
+		startConversationRetentionFunc(newConversationRetentionFunc(tracer, convRepo, cfg.AdvisorRetentionDays), ctx)
+	} else {
+		log.Println("RUN_JOBS=false: background pollers and scheduled jobs are off on this replica")
+	}
+
 	// Create handlers and routes
 	workService := newWorkServiceFunc(tracer)
 	h := newHandlerFunc(tracer, workService, priceService, signalService)
-	backtestService := newBacktestServiceFunc(tracer, backtestRepo)
 	h.SetBacktestService(backtestService)
+	strategyBacktestService := newStrategyBacktestServiceFunc(tracer, candleRepo, backtestRunRepo, chartRenderer, signalEngine)
+	h.SetStrategyBacktestRunner(strategyBacktestService)
+	h.SetStrategyOptimizer(strategyOptimizer)
+	h.SetMLAnalytics(mlAnalyticsService)
+	h.SetPaperTrading(paperService)
+	h.SetHoldings(holdingsService)
+	h.SetPriceAlerts(priceAlertService)
+	h.SetCharts(chartService)
+	h.SetNotifications(notificationService)
 	if mlService != nil {
 		h.SetMLTrainingRunner(mlService)
 	}
@@ -311,6 +466,10 @@ func main() {
 	// Public routes — no auth required
 	r.GET("/health", h.Health)
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	if telegramWebhook != nil {
+		// Authenticated by Telegram's secret token header instead.
+		r.POST(bot.WebhookPath, gin.WrapH(telegramWebhook))
+	}
 
 	// Protected routes — require X-API-Key header
 	protected := r.Group("")
@@ -323,6 +482,8 @@ func main() {
 		authSvc := newWebConsoleAuthFunc(cache.Client, sessionTTL, cfg.WebConsoleCookieSecret)
 		sessionMgr := newWebConsoleSessionFunc(cache.Client, sessionTTL)
 		webConsoleService := newWebConsoleServiceFunc(priceService, signalService, backtestService, advisorSvc)
+		webConsoleService.SetStrategyBacktest(strategyBacktestService)
+		webConsoleService.SetPaperTrading(paperService)
 		webConsoleHandler := newWebConsoleHandlerFunc(tracer, authSvc, sessionMgr, webConsoleService, webconsole.HandlerConfig{
 			ExpectedAPIKey: cfg.RESTAPIKey,
 			Heartbeat:      heartbeat,
@@ -348,6 +509,9 @@ func main() {
 	log.Println("Shutting down server...")
 
 	cancel()
+	if telegramWebhook != nil {
+		telegramWebhook.Stop()
+	}
 
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer shutdownCancel()
@@ -355,6 +519,7 @@ func main() {
 	if err := shutdownHTTPServerFunc(srv, shutdownCtx); err != nil {
 		log.Fatal("Server forced to shutdown:", err)
 	}
+	strategyOptimizer.Wait()
 
 	log.Println("Server exiting")
 }
@@ -11,8 +11,10 @@ import (
 	"bug-free-umbrella/internal/bot"
 	"bug-free-umbrella/internal/chart"
 	"bug-free-umbrella/internal/config"
+	"bug-free-umbrella/internal/delivery"
 	"bug-free-umbrella/internal/domain"
 	"bug-free-umbrella/internal/job"
+	"bug-free-umbrella/internal/llm"
 	"bug-free-umbrella/internal/repository"
 	"bug-free-umbrella/internal/service"
 	signalengine "bug-free-umbrella/internal/signal"
@@ -40,6 +42,37 @@ func TestMainBootstrap(t *testing.T) {
 	}
 }
 
+func TestMainSkipsJobsWhenRunJobsOff(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, runJobs := range []bool{true, false} {
+		restore := stubServerDeps()
+		loadConfigFunc = func() *config.Config {
+			return &config.Config{CoinGeckoPollSecs: 1, RunJobs: runJobs}
+		}
+		started := 0
+		startPollerFunc = func(*job.PricePoller, context.Context) { started++ }
+		startSignalPollerFunc = func(*job.SignalPoller, context.Context) { started++ }
+		startDigestJobFunc = func(*job.DigestJob, context.Context) { started++ }
+		startSignalParamsSyncFunc = func(*job.SignalParamsSync, context.Context) { started++ }
+		startTelegramBotFunc = func(bot.PriceQuerier, bot.SignalLister, bot.Advisor, bot.PaperTrader, bot.HoldingsTracker, bot.PositionSizer, bot.PriceAlertManager, bot.AlertSubscriptionStore, bot.DigestManager, bot.ChartRenderer, bot.CallbackStateStore, bot.AccessManager, bot.AdminTools, *bot.WebhookPoller) *bot.AlertDispatcher {
+			return bot.NewAlertDispatcher(nil, nil)
+		}
+		workers := 0
+		startDeliveryWorkerFunc = func(*delivery.Worker, context.Context) { workers++ }
+
+		main()
+		restore()
+		if want := map[bool]int{true: 4, false: 0}[runJobs]; started != want {
+			t.Fatalf("RunJobs=%v: expected %d jobs started, got %d", runJobs, want, started)
+		}
+		// The notification channel worker runs everywhere; the Telegram
+		// worker only where RUN_JOBS is set.
+		if want := map[bool]int{true: 2, false: 1}[runJobs]; workers != want {
+			t.Fatalf("RunJobs=%v: expected %d delivery workers, got %d", runJobs, want, workers)
+		}
+	}
+}
+
 func TestHTTPAddrFromEnv(t *testing.T) {
 	t.Setenv("PORT", "")
 	if got := httpAddrFromEnv(); got != ":8080" {
@@ -67,6 +100,7 @@ func stubServerDeps() func() {
 	origNewSignalImageRepo := newSignalImageRepoFunc
 	origNewProvider := newCoinGeckoProviderFunc
 	origNewSignalEngine := newSignalEngineFunc
+	origRestoreSignalParams := restoreSignalParamsFunc
 	origNewSignalService := newSignalServiceWithImagesFunc
 	origNewChartRenderer := newChartRendererFunc
 	origStartPoller := startPollerFunc
@@ -74,8 +108,13 @@ func stubServerDeps() func() {
 	origStartSignalPoller := startSignalPollerFunc
 	origNewSignalImageJob := newSignalImageJobFunc
 	origStartSignalImageJob := startSignalImageJobFunc
+	origNewSignalParamsSync := newSignalParamsSyncFunc
+	origStartSignalParamsSync := startSignalParamsSyncFunc
+	origStartDigestJob := startDigestJobFunc
+	origStartConversationRetention := startConversationRetentionFunc
+	origStartDeliveryWorker := startDeliveryWorkerFunc
 	origNewConvRepo := newConversationRepoFunc
-	origNewOpenAIClient := newOpenAIClientFunc
+	origNewLLMClient := newLLMClientFunc
 	origNewAdvisor := newAdvisorServiceFunc
 	origStartTelegram := startTelegramBotFunc
 	origNewRouter := newRouterFunc
@@ -86,7 +125,7 @@ func stubServerDeps() func() {
 
 	loadEnvFunc = func(...string) error { return nil }
 	loadConfigFunc = func() *config.Config {
-		return &config.Config{RedisURL: "", DatabaseURL: "", CoinGeckoPollSecs: 1}
+		return &config.Config{RedisURL: "", DatabaseURL: "", CoinGeckoPollSecs: 1, RunJobs: true}
 	}
 	initPostgresFunc = func(context.Context) {}
 	initRedisFunc = func(context.Context) {}
@@ -102,6 +141,7 @@ func stubServerDeps() func() {
 	}
 	newCoinGeckoProviderFunc = func(trace.Tracer) service.PriceProvider { return stubPriceProvider{} }
 	newSignalEngineFunc = func(func() time.Time) *signalengine.Engine { return signalengine.NewEngine(nil) }
+	restoreSignalParamsFunc = func(context.Context, *service.StrategyOptimizerService) error { return nil }
 	newSignalServiceWithImagesFunc = func(
 		trace.Tracer,
 		service.SignalCandleRepository,
@@ -110,27 +150,34 @@ func stubServerDeps() func() {
 		service.SignalImageRepository,
 		service.SignalChartRenderer,
 	) *service.SignalService {
-		return nil
+		return &service.SignalService{}
 	}
 	newChartRendererFunc = func() *chart.Renderer { return nil }
 	startPollerFunc = func(*job.PricePoller, context.Context) {}
 	newSignalPollerFunc = func(trace.Tracer, job.SignalGenerator, job.SignalAlertSink) *job.SignalPoller {
-		return nil
+		return &job.SignalPoller{}
 	}
 	startSignalPollerFunc = func(*job.SignalPoller, context.Context) {}
 	newSignalImageJobFunc = func(trace.Tracer, job.SignalImageMaintainer) *job.SignalImageMaintenance { return nil }
 	startSignalImageJobFunc = func(*job.SignalImageMaintenance, context.Context) {}
+	newSignalParamsSyncFunc = func(trace.Tracer, job.LiveParamsRestorer) *job.SignalParamsSync { return nil }
+	startSignalParamsSyncFunc = func(*job.SignalParamsSync, context.Context) {}
+	startDigestJobFunc = func(*job.DigestJob, context.Context) {}
+	startConversationRetentionFunc = func(*job.ConversationRetention, context.Context) {}
+	startDeliveryWorkerFunc = func(*delivery.Worker, context.Context) {}
 	newConversationRepoFunc = func(repository.PgxPool, trace.Tracer) *repository.ConversationRepository {
 		return nil
 	}
-	newOpenAIClientFunc = func(string) advisor.LLMClient { return nil }
+	newLLMClientFunc = func(llm.Config) (llm.Client, error) { return llm.NewStub(), nil }
 	newAdvisorServiceFunc = func(
 		trace.Tracer, advisor.LLMClient, advisor.PriceQuerier, advisor.SignalQuerier,
 		advisor.ConversationStore, string, int,
 	) *advisor.AdvisorService {
 		return nil
 	}
-	startTelegramBotFunc = func(bot.PriceQuerier, bot.SignalLister, bot.Advisor) *bot.AlertDispatcher { return nil }
+	startTelegramBotFunc = func(bot.PriceQuerier, bot.SignalLister, bot.Advisor, bot.PaperTrader, bot.HoldingsTracker, bot.PositionSizer, bot.PriceAlertManager, bot.AlertSubscriptionStore, bot.DigestManager, bot.ChartRenderer, bot.CallbackStateStore, bot.AccessManager, bot.AdminTools, *bot.WebhookPoller) *bot.AlertDispatcher {
+		return nil
+	}
 	newRouterFunc = func(...gin.OptionFunc) *gin.Engine { return gin.New() }
 	setupSignalNotify = func(c chan<- os.Signal, sig ...os.Signal) {}
 	waitForSignalFunc = func(<-chan os.Signal) {}
@@ -147,6 +194,7 @@ func stubServerDeps() func() {
 		newSignalImageRepoFunc = origNewSignalImageRepo
 		newCoinGeckoProviderFunc = origNewProvider
 		newSignalEngineFunc = origNewSignalEngine
+		restoreSignalParamsFunc = origRestoreSignalParams
 		newSignalServiceWithImagesFunc = origNewSignalService
 		newChartRendererFunc = origNewChartRenderer
 		startPollerFunc = origStartPoller
@@ -154,8 +202,13 @@ func stubServerDeps() func() {
 		startSignalPollerFunc = origStartSignalPoller
 		newSignalImageJobFunc = origNewSignalImageJob
 		startSignalImageJobFunc = origStartSignalImageJob
+		newSignalParamsSyncFunc = origNewSignalParamsSync
+		startSignalParamsSyncFunc = origStartSignalParamsSync
+		startDigestJobFunc = origStartDigestJob
+		startConversationRetentionFunc = origStartConversationRetention
+		startDeliveryWorkerFunc = origStartDeliveryWorker
 		newConversationRepoFunc = origNewConvRepo
-		newOpenAIClientFunc = origNewOpenAIClient
+		newLLMClientFunc = origNewLLMClient
 		newAdvisorServiceFunc = origNewAdvisor
 		startTelegramBotFunc = origStartTelegram
 		newRouterFunc = origNewRouter
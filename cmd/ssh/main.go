@@ -13,6 +13,7 @@ import (
 	"bug-free-umbrella/internal/cache"
 	"bug-free-umbrella/internal/config"
 	"bug-free-umbrella/internal/db"
+	"bug-free-umbrella/internal/llm"
 	"bug-free-umbrella/internal/provider"
 	"bug-free-umbrella/internal/repository"
 	"bug-free-umbrella/internal/service"
@@ -45,14 +46,22 @@ var (
 	newSignalRepoFunc        = repository.NewSignalRepository
 	newSSHUserRepoFunc       = repository.NewSSHUserRepository
 	newBacktestRepoFunc      = repository.NewBacktestRepository
+	newBacktestRunRepoFunc   = repository.NewBacktestRunRepository
 	newConversationRepoFunc  = repository.NewConversationRepository
+	newPaperRepoFunc         = repository.NewPaperRepository
+	newHoldingsRepoFunc      = repository.NewHoldingsRepository
+	newPriceAlertRepoFunc    = repository.NewPriceAlertRepository
 	newCoinGeckoProviderFunc = func(tracer trace.Tracer) service.PriceProvider {
 		return provider.NewCoinGeckoProvider(tracer)
 	}
 	newSignalEngineFunc            = signalengine.NewEngine
 	newPriceServiceFunc            = service.NewPriceService
 	newSignalServiceWithImagesFunc = service.NewSignalServiceWithImages
-	newOpenAIClientFunc            = advisor.NewOpenAIClient
+	newMLAnalyticsServiceFunc      = service.NewMLAnalyticsService
+	newPaperTradingServiceFunc     = service.NewPaperTradingService
+	newHoldingsServiceFunc         = service.NewHoldingsService
+	newPriceAlertServiceFunc       = service.NewPriceAlertService
+	newLLMClientFunc               = llm.New
 	newAdvisorServiceFunc          = advisor.NewAdvisorService
 	newWishServerFunc              = wish.NewServer
 	setupSignalNotify              = ossignal.Notify
@@ -88,21 +97,43 @@ func main() {
 	signalRepo := newSignalRepoFunc(db.Pool, tracer)
 	sshUserRepo := newSSHUserRepoFunc(db.Pool, tracer)
 	backtestRepo := newBacktestRepoFunc(db.Pool, tracer)
+	backtestRunRepo := newBacktestRunRepoFunc(db.Pool, tracer)
 	convRepo := newConversationRepoFunc(db.Pool, tracer)
+	paperRepo := newPaperRepoFunc(db.Pool, tracer)
+	holdingsRepo := newHoldingsRepoFunc(db.Pool, tracer)
+	priceAlertRepo := newPriceAlertRepoFunc(db.Pool, tracer)
 
 	// Create services
 	cgProvider := newCoinGeckoProviderFunc(tracer)
 	priceService := newPriceServiceFunc(tracer, cgProvider, candleRepo, cache.Client)
 	signalEngine := newSignalEngineFunc(nil)
 	signalService := newSignalServiceWithImagesFunc(tracer, candleRepo, signalRepo, signalEngine, nil, nil)
+	analyticsService := newMLAnalyticsServiceFunc(tracer, backtestRepo)
+	paperService := newPaperTradingServiceFunc(tracer, paperRepo, priceService)
+	holdingsService := newHoldingsServiceFunc(tracer, holdingsRepo, priceService)
+	priceAlertService := newPriceAlertServiceFunc(tracer, priceAlertRepo, priceService)
 
 	// Advisor (optional)
 	var advisorSvc *advisor.AdvisorService
-	if cfg.OpenAIAPIKey != "" {
-		llmClient := newOpenAIClientFunc(cfg.OpenAIAPIKey)
+	if advisorLLM := cfg.AdvisorLLM(); advisorLLM.Enabled() {
+		llmClient, err := newLLMClientFunc(advisorLLM)
+		if err != nil {
+			log.Fatalf("Failed to create advisor LLM client: %v", err)
+		}
 		advisorSvc = newAdvisorServiceFunc(tracer, llmClient, priceService, signalService,
-			convRepo, cfg.OpenAIModel, cfg.AdvisorMaxHistory)
-		log.Println("SSH advisor service enabled")
+			convRepo, advisorLLM.Model, cfg.AdvisorMaxHistory)
+		advisorSvc.SetHoldings(holdingsService)
+		advisorSvc.SetToolSources(advisor.ToolSources{
+			Candles:     candleRepo,
+			Params:      signalEngine,
+			Backtests:   service.NewBacktestService(tracer, backtestRepo),
+			MLAnalytics: analyticsService,
+		})
+		advisorSvc.SetToolLimits(cfg.AdvisorMaxToolRounds, time.Duration(cfg.AdvisorTimeoutSecs)*time.Second)
+		advisorSvc.SetHistoryBudget(cfg.AdvisorHistoryTokens, cfg.AdvisorContextTokens)
+		advisorSvc.SetMemory(convRepo, cfg.AdvisorMemoryFacts)
+		advisorSvc.SetVerification(cfg.AdvisorVerifyMode, cfg.AdvisorVerifyTolerancePct/100)
+		log.Printf("SSH advisor service enabled (%s, %s)", advisorLLM.Provider, advisorLLM.Model)
 	}
 
 	// Build Wish SSH server
@@ -140,12 +171,17 @@ func main() {
 				}
 
 				svc := tui.Services{
-					Prices:   priceService,
-					Signals:  signalService,
-					Advisor:  advisorQ,
-					Backtest: backtestRepo,
-					UserID:   userID,
-					Username: username,
+					Prices:    priceService,
+					Signals:   signalService,
+					Advisor:   advisorQ,
+					Backtest:  backtestRepo,
+					Analytics: analyticsService,
+					Runs:      backtestRunRepo,
+					Paper:     paperService,
+					Holdings:  holdingsService,
+					Alerts:    priceAlertService,
+					UserID:    userID,
+					Username:  username,
 				}
 
 				model := tui.NewAppModel(svc)
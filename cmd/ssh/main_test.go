@@ -8,6 +8,7 @@ import (
 
 	"bug-free-umbrella/internal/advisor"
 	"bug-free-umbrella/internal/config"
+	"bug-free-umbrella/internal/llm"
 	"bug-free-umbrella/internal/repository"
 	"bug-free-umbrella/internal/service"
 	signalengine "bug-free-umbrella/internal/signal"
@@ -49,7 +50,7 @@ func stubSSHDeps() func() {
 	origNewSignalEngine := newSignalEngineFunc
 	origNewPriceService := newPriceServiceFunc
 	origNewSignalService := newSignalServiceWithImagesFunc
-	origNewOpenAIClient := newOpenAIClientFunc
+	origNewLLMClient := newLLMClientFunc
 	origNewAdvisor := newAdvisorServiceFunc
 	origNewWishServer := newWishServerFunc
 	origSetupSignal := setupSignalNotify
@@ -105,7 +106,7 @@ func stubSSHDeps() func() {
 	) *service.SignalService {
 		return nil
 	}
-	newOpenAIClientFunc = func(string) advisor.LLMClient { return nil }
+	newLLMClientFunc = func(llm.Config) (llm.Client, error) { return llm.NewStub(), nil }
 	newAdvisorServiceFunc = func(
 		trace.Tracer, advisor.LLMClient, advisor.PriceQuerier, advisor.SignalQuerier,
 		advisor.ConversationStore, string, int,
@@ -133,7 +134,7 @@ func stubSSHDeps() func() {
 		newSignalEngineFunc = origNewSignalEngine
 		newPriceServiceFunc = origNewPriceService
 		newSignalServiceWithImagesFunc = origNewSignalService
-		newOpenAIClientFunc = origNewOpenAIClient
+		newLLMClientFunc = origNewLLMClient
 		newAdvisorServiceFunc = origNewAdvisor
 		newWishServerFunc = origNewWishServer
 		setupSignalNotify = origSetupSignal
@@ -2,20 +2,29 @@ package advisor
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/llm"
 
-	"github.com/openai/openai-go"
-	"github.com/openai/openai-go/option"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
 
-// LLMClient abstracts the OpenAI chat completions API for testability.
+// LLMClient is the chat model behind the advisor; see package llm for the
+// available backends.
 type LLMClient interface {
-	CreateChatCompletion(ctx context.Context, params openai.ChatCompletionNewParams) (*openai.ChatCompletion, error)
+	Complete(ctx context.Context, req llm.Request) (*llm.Response, error)
+}
+
+// streamingLLM is an LLMClient that can stream its replies, as
+// llm.StreamClient does.
+type streamingLLM interface {
+	Stream(ctx context.Context, req llm.Request, onDelta func(string)) (*llm.Response, error)
 }
 
 // PriceQuerier provides current price data for the advisor's context.
@@ -35,14 +44,41 @@ type ConversationStore interface {
 	RecentMessages(ctx context.Context, chatID int64, limit int) ([]domain.ConversationMessage, error)
 }
 
+// HoldingsProvider returns a user's portfolio for the advisor's context, or
+// nil when the user has not opted in to sharing it.
+type HoldingsProvider interface {
+	AdvisorHoldings(ctx context.Context, chatID int64) (*domain.HoldingsValuation, error)
+}
+
+const (
+	// defaultMaxToolRounds bounds how many times the model may call tools
+	// before it has to answer.
+	defaultMaxToolRounds = 5
+	// defaultAskTimeout bounds a whole answer, tool calls included.
+	defaultAskTimeout = 60 * time.Second
+)
+
 type AdvisorService struct {
-	tracer     trace.Tracer
-	llm        LLMClient
-	prices     PriceQuerier
-	signals    SignalQuerier
-	convStore  ConversationStore
-	model      string
-	maxHistory int
+	tracer        trace.Tracer
+	llm           LLMClient
+	prices        PriceQuerier
+	signals       SignalQuerier
+	convStore     ConversationStore
+	holdings      HoldingsProvider
+	memory        MemoryStore
+	rememberFacts bool
+	sources       ToolSources
+	model         string
+	maxHistory    int
+	historyTokens int
+	contextTokens int
+	maxToolRounds int
+	askTimeout    time.Duration
+	now           func() time.Time
+
+	verifyMode      string
+	verifyTolerance float64
+	grounding       groundingMetrics
 }
 
 func NewAdvisorService(
@@ -58,57 +94,110 @@ func NewAdvisorService(
 		maxHistory = 20
 	}
 	return &AdvisorService{
-		tracer:     tracer,
-		llm:        llm,
-		prices:     prices,
-		signals:    signals,
-		convStore:  convStore,
-		model:      model,
-		maxHistory: maxHistory,
+		tracer:        tracer,
+		llm:           llm,
+		prices:        prices,
+		signals:       signals,
+		convStore:     convStore,
+		model:         model,
+		maxHistory:    maxHistory,
+		historyTokens: defaultHistoryTokens,
+		maxToolRounds: defaultMaxToolRounds,
+		askTimeout:    defaultAskTimeout,
+		now:           time.Now,
+
+		verifyMode:      VerifyOff,
+		verifyTolerance: defaultVerifyTolerance,
+		grounding:       newGroundingMetrics(),
+	}
+}
+
+// SetHoldings lets the advisor see the holdings of users who opted in.
+func (s *AdvisorService) SetHoldings(holdings HoldingsProvider) {
+	s.holdings = holdings
+}
+
+// SetToolSources enables the tools backed by candles, ML accuracy and market
+// intel.
+func (s *AdvisorService) SetToolSources(sources ToolSources) {
+	s.sources = sources
+}
+
+// SetToolLimits bounds the tool-calling loop: at most rounds rounds of tool
+// calls, and timeout for the whole answer. Non-positive values keep the
+// defaults.
+func (s *AdvisorService) SetToolLimits(rounds int, timeout time.Duration) {
+	if rounds > 0 {
+		s.maxToolRounds = rounds
+	}
+	if timeout > 0 {
+		s.askTimeout = timeout
 	}
 }
 
+// Ask answers a user's message. The model looks up the data it needs with
+// tools; every call and its result is recorded in the conversation store
+// with role "tool" for auditing.
 func (s *AdvisorService) Ask(ctx context.Context, chatID int64, userMessage string) (string, error) {
+	return s.AskStream(ctx, chatID, userMessage, nil)
+}
+
+// AskStream is Ask with the reply streamed: onDelta receives the reply
+// text as the model writes it, from the caller's goroutine. Cancelling ctx
+// stops the answer. Text the model writes before calling tools is streamed
+// too, so the returned reply, which is what gets stored, is the final word.
+func (s *AdvisorService) AskStream(ctx context.Context, chatID int64, userMessage string, onDelta func(string)) (string, error) {
 	ctx, span := s.tracer.Start(ctx, "advisor.ask")
 	defer span.End()
-	span.SetAttributes(attribute.Int64("chat_id", chatID))
+	span.SetAttributes(
+		attribute.Int64("chat_id", chatID),
+		attribute.Bool("streaming", onDelta != nil),
+	)
+
+	ctx, cancel := context.WithTimeout(ctx, s.askTimeout)
+	defer cancel()
 
 	// 1. Persist the user message
 	if err := s.convStore.AppendMessage(ctx, chatID, "user", userMessage); err != nil {
 		log.Printf("failed to store user message: %v", err)
 	}
 
-	// 2. Extract mentioned symbols for targeted context
-	mentionedSymbols := ExtractSymbols(userMessage)
+	// 2. Build the system prompt; market data is fetched by tool calls
+	mem := s.loadMemory(ctx, chatID)
+	userContext := s.userContext(ctx, chatID, userMessage)
+	systemPrompt := BuildSystemPrompt(s.now(), userContext+FormatMemoryContext(mem))
 
-	// 3. Gather market context
-	marketContext, err := s.gatherContext(ctx, mentionedSymbols)
-	if err != nil {
-		log.Printf("failed to gather market context: %v", err)
-		marketContext = "Market data temporarily unavailable."
-	}
-
-	// 4. Build system prompt with live data
-	systemPrompt := BuildSystemPrompt(marketContext)
-
-	// 5. Load conversation history
-	history, err := s.convStore.RecentMessages(ctx, chatID, s.maxHistory)
+	// 3. Load conversation history that fits the token budget; older turns
+	// are folded into the running summary
+	history, err := s.convStore.RecentMessages(ctx, chatID, s.historyLimit())
 	if err != nil {
 		log.Printf("failed to load conversation history: %v", err)
 		history = nil
 	}
+	history, overflow := s.selectHistory(history, mem, s.historyBudget(systemPrompt))
+	if len(overflow) > 0 && s.memory != nil {
+		if next, err := s.compact(ctx, chatID, mem, overflow); err != nil {
+			log.Printf("failed to compact conversation for chat %d: %v", chatID, err)
+		} else {
+			systemPrompt = BuildSystemPrompt(s.now(), userContext+FormatMemoryContext(next))
+		}
+	}
+	span.SetAttributes(
+		attribute.Int("advisor.history_messages", len(history)),
+		attribute.Int("advisor.overflow_messages", len(overflow)),
+	)
 
-	// 6. Construct messages array
-	messages := s.buildMessages(systemPrompt, history)
-
-	// 7. Call LLM
-	reply, err := s.callLLM(ctx, messages)
+	// 4. Let the model call tools until it answers
+	reply, messages, err := s.converse(ctx, chatID, s.buildMessages(systemPrompt, history), onDelta)
 	if err != nil {
 		span.RecordError(err)
 		return "", fmt.Errorf("advisor unavailable: %w", err)
 	}
 
-	// 8. Persist the assistant reply
+	// 5. Check the reply's figures against the data the model was given
+	reply = s.verifyReply(ctx, chatID, messages, reply)
+
+	// 6. Persist the assistant reply
 	if err := s.convStore.AppendMessage(ctx, chatID, "assistant", reply); err != nil {
 		log.Printf("failed to store assistant reply: %v", err)
 	}
@@ -116,68 +205,135 @@ func (s *AdvisorService) Ask(ctx context.Context, chatID int64, userMessage stri
 	return reply, nil
 }
 
-func (s *AdvisorService) gatherContext(ctx context.Context, symbols []string) (string, error) {
-	ctx, span := s.tracer.Start(ctx, "advisor.gather-context")
+// SummarizeDigest writes a short overview of a market digest from its facts.
+// Nothing is stored in the conversation history.
+func (s *AdvisorService) SummarizeDigest(ctx context.Context, facts string) (string, error) {
+	ctx, span := s.tracer.Start(ctx, "advisor.summarize-digest")
 	defer span.End()
 
-	var prices []*domain.PriceSnapshot
-	var signals []domain.Signal
+	msg, err := s.callLLM(ctx, []llm.Message{
+		llm.System(digestPrompt),
+		llm.User(facts),
+	}, nil, nil)
+	if err != nil {
+		span.RecordError(err)
+		return "", fmt.Errorf("advisor unavailable: %w", err)
+	}
+	return msg.Content, nil
+}
 
-	if len(symbols) > 0 {
-		for _, sym := range symbols {
-			p, err := s.prices.GetCurrentPrice(ctx, sym)
-			if err == nil {
-				prices = append(prices, p)
-			}
-			sigs, err := s.signals.ListSignals(ctx, domain.SignalFilter{Symbol: sym, Limit: 5})
-			if err == nil {
-				signals = append(signals, sigs...)
-			}
-			composite, err := s.signals.ListSignals(ctx, domain.SignalFilter{
-				Symbol:    sym,
-				Indicator: domain.IndicatorFundSentimentComposite,
-				Limit:     3,
-			})
-			if err == nil {
-				signals = append(signals, composite...)
-			}
+// converse runs the tool-calling loop. Tools are offered for at most
+// maxToolRounds rounds; the last request offers none, so the model has to
+// answer with what it has. It returns the reply and the messages it
+// answered, tool results included.
+func (s *AdvisorService) converse(
+	ctx context.Context,
+	chatID int64,
+	messages []llm.Message,
+	onDelta func(string),
+) (string, []llm.Message, error) {
+	tools := s.tools(chatID)
+	params := make([]llm.Tool, 0, len(tools))
+	for _, t := range tools {
+		params = append(params, t.param())
+	}
+
+	for round := 0; ; round++ {
+		offered := params
+		if round >= s.maxToolRounds {
+			offered = nil
 		}
-	} else {
-		var err error
-		prices, err = s.prices.GetCurrentPrices(ctx)
+		msg, err := s.callLLM(ctx, messages, offered, onDelta)
 		if err != nil {
-			return "", err
+			return "", nil, err
 		}
-		signals, _ = s.signals.ListSignals(ctx, domain.SignalFilter{Limit: 10})
-		composite, err := s.signals.ListSignals(ctx, domain.SignalFilter{
-			Indicator: domain.IndicatorFundSentimentComposite,
-			Limit:     10,
-		})
-		if err == nil {
-			signals = append(signals, composite...)
+		if len(msg.ToolCalls) == 0 || offered == nil {
+			if msg.Content == "" {
+				return "", nil, fmt.Errorf("empty reply after %d tool rounds", round)
+			}
+			return msg.Content, messages, nil
+		}
+
+		messages = append(messages, *msg)
+		for _, call := range msg.ToolCalls {
+			result, err := s.runTool(ctx, tools, call.Name, call.Arguments)
+			s.recordToolCall(ctx, chatID, call.Name, call.Arguments, result, err)
+			messages = append(messages, llm.ToolResult(call.ID, result))
 		}
 	}
+}
+
+// toolCallRecord is how a tool call is stored in the conversation store.
+type toolCallRecord struct {
+	Tool      string          `json:"tool"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	Result    string          `json:"result"`
+	Error     string          `json:"error,omitempty"`
+}
+
+func (s *AdvisorService) recordToolCall(ctx context.Context, chatID int64, name, args, result string, callErr error) {
+	rec := toolCallRecord{Tool: name, Result: result}
+	if json.Valid([]byte(args)) {
+		rec.Arguments = json.RawMessage(args)
+	}
+	if len(rec.Result) > maxRecordedResult {
+		rec.Result = rec.Result[:maxRecordedResult] + "…"
+	}
+	if callErr != nil {
+		rec.Error = callErr.Error()
+	}
+	raw, err := json.Marshal(rec)
+	if err != nil {
+		log.Printf("failed to encode tool call %s: %v", name, err)
+		return
+	}
+	if err := s.convStore.AppendMessage(ctx, chatID, "tool", string(raw)); err != nil {
+		log.Printf("failed to store tool call %s: %v", name, err)
+	}
+}
 
-	signals = uniqueSignals(signals)
-	return FormatMarketContext(prices, signals), nil
+// userContext is what the system prompt says about the user: symbols their
+// message mentions and, if shared, their holdings.
+func (s *AdvisorService) userContext(ctx context.Context, chatID int64, userMessage string) string {
+	var sb strings.Builder
+	if symbols := ExtractSymbols(userMessage); len(symbols) > 0 {
+		sb.WriteString("\nSymbols mentioned: ")
+		sb.WriteString(strings.Join(symbols, ", "))
+		sb.WriteString("\n")
+	}
+	sb.WriteString(s.holdingsContext(ctx, chatID))
+	return sb.String()
+}
+
+func (s *AdvisorService) holdingsContext(ctx context.Context, chatID int64) string {
+	if s.holdings == nil {
+		return ""
+	}
+	v, err := s.holdings.AdvisorHoldings(ctx, chatID)
+	if err != nil {
+		log.Printf("failed to load holdings for chat %d: %v", chatID, err)
+		return ""
+	}
+	return FormatHoldingsContext(v)
 }
 
 func (s *AdvisorService) buildMessages(
 	systemPrompt string,
 	history []domain.ConversationMessage,
-) []openai.ChatCompletionMessageParamUnion {
-	messages := make([]openai.ChatCompletionMessageParamUnion, 0, len(history)+1)
+) []llm.Message {
+	messages := make([]llm.Message, 0, len(history)+1)
 
 	// System prompt always first
-	messages = append(messages, openai.SystemMessage(systemPrompt))
+	messages = append(messages, llm.System(systemPrompt))
 
-	// Conversation history (already limited by RecentMessages query)
+	// Conversation history (already fitted to the budget). Tool calls are
+	// stored for auditing only and are not replayed.
 	for _, msg := range history {
 		switch msg.Role {
 		case "user":
-			messages = append(messages, openai.UserMessage(msg.Content))
+			messages = append(messages, llm.User(msg.Content))
 		case "assistant":
-			messages = append(messages, openai.AssistantMessage(msg.Content))
+			messages = append(messages, llm.Assistant(msg.Content))
 		}
 	}
 
@@ -186,69 +342,41 @@ func (s *AdvisorService) buildMessages(
 
 func (s *AdvisorService) callLLM(
 	ctx context.Context,
-	messages []openai.ChatCompletionMessageParamUnion,
-) (string, error) {
+	messages []llm.Message,
+	tools []llm.Tool,
+	onDelta func(string),
+) (*llm.Message, error) {
 	ctx, span := s.tracer.Start(ctx, "advisor.llm-call")
 	defer span.End()
 	span.SetAttributes(
 		attribute.String("llm.model", s.model),
 		attribute.Int("llm.message_count", len(messages)),
+		attribute.Int("llm.tool_count", len(tools)),
 	)
 
-	completion, err := s.llm.CreateChatCompletion(ctx, openai.ChatCompletionNewParams{
+	req := llm.Request{
 		Model:    s.model,
 		Messages: messages,
-	})
-	if err != nil {
-		return "", err
+		Tools:    tools,
 	}
-	if len(completion.Choices) == 0 {
-		return "", fmt.Errorf("no choices in LLM response")
-	}
-
-	reply := completion.Choices[0].Message.Content
-	span.SetAttributes(attribute.Int("llm.reply_length", len(reply)))
-	return reply, nil
-}
-
-func uniqueSignals(in []domain.Signal) []domain.Signal {
-	if len(in) <= 1 {
-		return in
-	}
-	out := make([]domain.Signal, 0, len(in))
-	seen := make(map[string]struct{}, len(in))
-	for _, sig := range in {
-		key := fmt.Sprintf(
-			"%d|%s|%s|%s|%s|%d",
-			sig.ID,
-			sig.Symbol,
-			sig.Interval,
-			sig.Indicator,
-			sig.Direction,
-			sig.Timestamp.UTC().Unix(),
-		)
-		if _, ok := seen[key]; ok {
-			continue
+	var resp *llm.Response
+	var err error
+	if streamer, ok := s.llm.(streamingLLM); ok && onDelta != nil {
+		resp, err = streamer.Stream(ctx, req, onDelta)
+	} else {
+		resp, err = s.llm.Complete(ctx, req)
+		if err == nil && onDelta != nil && resp.Message.Content != "" {
+			onDelta(resp.Message.Content)
 		}
-		seen[key] = struct{}{}
-		out = append(out, sig)
 	}
-	return out
-}
-
-// openaiClient wraps the official SDK's chat completions service.
-type openaiClient struct {
-	client openai.Client
-}
-
-func NewOpenAIClient(apiKey string) LLMClient {
-	client := openai.NewClient(option.WithAPIKey(apiKey))
-	return &openaiClient{client: client}
-}
+	if err != nil {
+		return nil, err
+	}
 
-func (c *openaiClient) CreateChatCompletion(
-	ctx context.Context,
-	params openai.ChatCompletionNewParams,
-) (*openai.ChatCompletion, error) {
-	return c.client.Chat.Completions.New(ctx, params)
+	msg := resp.Message
+	span.SetAttributes(
+		attribute.Int("llm.reply_length", len(msg.Content)),
+		attribute.Int("llm.tool_calls", len(msg.ToolCalls)),
+	)
+	return &msg, nil
 }
@@ -2,24 +2,20 @@ package advisor
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/llm"
 
-	"github.com/openai/openai-go"
 	"go.opentelemetry.io/otel/trace"
 )
 
 func TestAskHappyPath(t *testing.T) {
-	llm := &stubLLMClient{
-		response: &openai.ChatCompletion{
-			Choices: []openai.ChatCompletionChoice{
-				{Message: openai.ChatCompletionMessage{Content: "BTC looks bullish"}},
-			},
-		},
-	}
+	stub := llm.NewStub(textResponse("BTC looks bullish"))
 	store := &stubConvStore{}
 	prices := &stubPrices{
 		price: &domain.PriceSnapshot{Symbol: "BTC", PriceUSD: 50000},
@@ -28,7 +24,7 @@ func TestAskHappyPath(t *testing.T) {
 
 	svc := NewAdvisorService(
 		trace.NewNoopTracerProvider().Tracer("test"),
-		llm, prices, signals, store, "gpt-4o-mini", 20,
+		stub, prices, signals, store, "gpt-4o-mini", 20,
 	)
 
 	reply, err := svc.Ask(context.Background(), 123, "What about BTC?")
@@ -51,14 +47,15 @@ func TestAskHappyPath(t *testing.T) {
 }
 
 func TestAskLLMError(t *testing.T) {
-	llm := &stubLLMClient{err: errors.New("api down")}
+	stub := llm.NewStub()
+	stub.SetError(errors.New("api down"))
 	store := &stubConvStore{}
 	prices := &stubPrices{allPrices: []*domain.PriceSnapshot{}}
 	signals := &stubSignals{}
 
 	svc := NewAdvisorService(
 		trace.NewNoopTracerProvider().Tracer("test"),
-		llm, prices, signals, store, "gpt-4o-mini", 20,
+		stub, prices, signals, store, "gpt-4o-mini", 20,
 	)
 
 	_, err := svc.Ask(context.Background(), 123, "What looks good?")
@@ -72,20 +69,14 @@ func TestAskLLMError(t *testing.T) {
 }
 
 func TestAskConversationStoreFailureNonFatal(t *testing.T) {
-	llm := &stubLLMClient{
-		response: &openai.ChatCompletion{
-			Choices: []openai.ChatCompletionChoice{
-				{Message: openai.ChatCompletionMessage{Content: "response"}},
-			},
-		},
-	}
+	stub := llm.NewStub(textResponse("response"))
 	store := &stubConvStore{appendErr: errors.New("db down")}
 	prices := &stubPrices{allPrices: []*domain.PriceSnapshot{}}
 	signals := &stubSignals{}
 
 	svc := NewAdvisorService(
 		trace.NewNoopTracerProvider().Tracer("test"),
-		llm, prices, signals, store, "gpt-4o-mini", 20,
+		stub, prices, signals, store, "gpt-4o-mini", 20,
 	)
 
 	reply, err := svc.Ask(context.Background(), 123, "test")
@@ -97,47 +88,214 @@ func TestAskConversationStoreFailureNonFatal(t *testing.T) {
 	}
 }
 
-func TestAskContextGatheringFailure(t *testing.T) {
-	llm := &stubLLMClient{
-		response: &openai.ChatCompletion{
-			Choices: []openai.ChatCompletionChoice{
-				{Message: openai.ChatCompletionMessage{Content: "no data available"}},
-			},
-		},
-	}
+func TestAskToolFailureIsReportedToModel(t *testing.T) {
+	stub := llm.NewStub(
+		toolCallResponse("", toolCall("call_1", "get_prices", `{"symbols":["BTC"]}`)),
+		textResponse("no data available"),
+	)
 	store := &stubConvStore{}
 	prices := &stubPrices{err: errors.New("price service down")}
-	signals := &stubSignals{}
 
 	svc := NewAdvisorService(
 		trace.NewNoopTracerProvider().Tracer("test"),
-		llm, prices, signals, store, "gpt-4o-mini", 20,
+		stub, prices, &stubSignals{}, store, "gpt-4o-mini", 20,
 	)
 
 	reply, err := svc.Ask(context.Background(), 123, "What looks good?")
 	if err != nil {
-		t.Fatalf("context failure should be non-fatal, got: %v", err)
+		t.Fatalf("tool failure should be non-fatal, got: %v", err)
 	}
 	if reply != "no data available" {
 		t.Fatalf("expected 'no data available', got %q", reply)
 	}
+	if got := marshalParam(t, lastMessage(stub.Requests()[1])); !strings.Contains(got, "price service down") {
+		t.Fatalf("expected the tool error to be sent back to the model, got %s", got)
+	}
+	if len(store.messages) != 3 || store.messages[1].role != "tool" || !strings.Contains(store.messages[1].content, `"error":"BTC: price service down"`) {
+		t.Fatalf("expected the failed call to be recorded, got %+v", store.messages)
+	}
 }
 
-func TestAskNoHistory(t *testing.T) {
-	llm := &stubLLMClient{
-		response: &openai.ChatCompletion{
-			Choices: []openai.ChatCompletionChoice{
-				{Message: openai.ChatCompletionMessage{Content: "fresh start"}},
-			},
-		},
+func TestAskRunsToolCallsAndRecordsThem(t *testing.T) {
+	now := time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC)
+	stub := llm.NewStub(
+		toolCallResponse("",
+			toolCall("call_1", "get_candles", `{"symbol":"btc","interval":"4h","from":"2025-03-04","to":"2025-03-05"}`),
+			toolCall("call_2", "list_signals", `{"symbol":"BTC","limit":3}`),
+		),
+		textResponse("BTC's 4h RSI closed Tuesday at 41."),
+	)
+	store := &stubConvStore{}
+	candles := &stubCandles{candles: rampCandles("BTC", "4h", time.Date(2025, 2, 20, 0, 0, 0, 0, time.UTC), 100)}
+	signals := &stubSignals{signals: []domain.Signal{{Symbol: "BTC", Interval: "4h", Indicator: "rsi", Direction: domain.DirectionLong, Risk: 2}}}
+
+	svc := NewAdvisorService(
+		trace.NewNoopTracerProvider().Tracer("test"),
+		stub, &stubPrices{}, signals, store, "gpt-4o-mini", 20,
+	)
+	svc.SetToolSources(ToolSources{Candles: candles})
+	svc.now = func() time.Time { return now }
+
+	reply, err := svc.Ask(context.Background(), 5, "what was BTC's 4h RSI last Tuesday")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reply != "BTC's 4h RSI closed Tuesday at 41." {
+		t.Fatalf("unexpected reply %q", reply)
+	}
+
+	calls := stub.Requests()
+	if len(calls) != 2 || len(calls[0].Tools) != 3 {
+		t.Fatalf("expected 2 calls offering prices, signals and candles, got %d calls", len(calls))
+	}
+	if got := calls[0].Messages[0].Content; !strings.Contains(got, "Friday 2025-03-07") || !strings.Contains(got, "Symbols mentioned: BTC") {
+		t.Fatalf("expected current time and mentioned symbols in system prompt, got %s", got)
+	}
+	followUp := calls[1].Messages
+	if len(followUp) != 5 { // system, user, assistant tool calls, two tool results
+		t.Fatalf("expected 5 messages in follow-up, got %d", len(followUp))
+	}
+	candleResult := marshalParam(t, followUp[3])
+	if !strings.Contains(candleResult, `\"rsi\":`) || !strings.Contains(candleResult, "call_1") {
+		t.Fatalf("expected candles with RSI for call_1, got %s", candleResult)
+	}
+	if from := candles.from; !from.Before(time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected candles loaded before the range for warm-up, got %s", from)
+	}
+
+	roles := make([]string, 0, len(store.messages))
+	for _, m := range store.messages {
+		roles = append(roles, m.role)
+	}
+	if strings.Join(roles, ",") != "user,tool,tool,assistant" {
+		t.Fatalf("unexpected stored roles %v", roles)
+	}
+	var rec toolCallRecord
+	if err := json.Unmarshal([]byte(store.messages[1].content), &rec); err != nil {
+		t.Fatalf("decode tool record: %v", err)
+	}
+	if rec.Tool != "get_candles" || !strings.Contains(string(rec.Arguments), `"interval":"4h"`) || rec.Result == "" || rec.Error != "" {
+		t.Fatalf("unexpected tool record %+v", rec)
+	}
+}
+
+func TestAskBoundsToolRounds(t *testing.T) {
+	loop := toolCallResponse("Best I can tell from the data so far.", toolCall("call", "get_prices", `{}`))
+	stub := llm.NewStub(loop, loop, loop, loop)
+	svc := NewAdvisorService(
+		trace.NewNoopTracerProvider().Tracer("test"),
+		stub, &stubPrices{}, &stubSignals{}, &stubConvStore{}, "gpt-4o-mini", 20,
+	)
+	svc.SetToolLimits(2, 0)
+
+	reply, err := svc.Ask(context.Background(), 1, "hi")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reply != "Best I can tell from the data so far." {
+		t.Fatalf("unexpected reply %q", reply)
+	}
+	calls := stub.Requests()
+	if len(calls) != 3 {
+		t.Fatalf("expected 2 tool rounds and a final answer, got %d calls", len(calls))
+	}
+	if len(calls[2].Tools) != 0 {
+		t.Fatal("expected no tools offered on the final round")
+	}
+
+	silent := toolCallResponse("", toolCall("call", "get_prices", `{}`))
+	svc = NewAdvisorService(
+		trace.NewNoopTracerProvider().Tracer("test"),
+		llm.NewStub(silent, silent), &stubPrices{}, &stubSignals{}, &stubConvStore{}, "gpt-4o-mini", 20,
+	)
+	svc.SetToolLimits(1, 0)
+	if _, err := svc.Ask(context.Background(), 1, "hi"); err == nil {
+		t.Fatal("expected an error when the model never answers")
+	}
+}
+
+func TestAskTimesOut(t *testing.T) {
+	svc := NewAdvisorService(
+		trace.NewNoopTracerProvider().Tracer("test"),
+		blockingLLM{}, &stubPrices{}, &stubSignals{}, &stubConvStore{}, "gpt-4o-mini", 20,
+	)
+	svc.SetToolLimits(0, 10*time.Millisecond)
+
+	_, err := svc.Ask(context.Background(), 1, "hi")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestAskStreamSendsDeltasAndStoresReply(t *testing.T) {
+	stub := llm.NewStub(
+		toolCallResponse("", toolCall("call_1", "get_prices", `{"symbols":["BTC"]}`)),
+		textResponse("BTC is holding 60k."),
+	)
+	store := &stubConvStore{}
+	prices := &stubPrices{price: &domain.PriceSnapshot{Symbol: "BTC", PriceUSD: 60000}}
+	svc := NewAdvisorService(
+		trace.NewNoopTracerProvider().Tracer("test"),
+		stub, prices, &stubSignals{}, store, "gpt-4o-mini", 20,
+	)
+
+	var deltas []string
+	reply, err := svc.AskStream(context.Background(), 3, "BTC?", func(d string) { deltas = append(deltas, d) })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reply != "BTC is holding 60k." || strings.Join(deltas, "|") != "BTC |is |holding |60k." {
+		t.Fatalf("unexpected reply %q or deltas %q", reply, deltas)
+	}
+	if last := store.messages[len(store.messages)-1]; last.role != "assistant" || last.content != reply {
+		t.Fatalf("expected the full reply to be stored, got %+v", last)
+	}
+}
+
+func TestAskStreamWithoutStreamingClient(t *testing.T) {
+	svc := NewAdvisorService(
+		trace.NewNoopTracerProvider().Tracer("test"),
+		completeOnlyLLM{reply: "Markets are quiet."}, &stubPrices{}, &stubSignals{}, &stubConvStore{}, "gpt-4o-mini", 20,
+	)
+
+	var deltas []string
+	reply, err := svc.AskStream(context.Background(), 3, "hi", func(d string) { deltas = append(deltas, d) })
+	if err != nil || reply != "Markets are quiet." {
+		t.Fatalf("unexpected reply %q (%v)", reply, err)
+	}
+	if len(deltas) != 1 || deltas[0] != reply {
+		t.Fatalf("expected the whole reply as one delta, got %q", deltas)
+	}
+}
+
+func TestAskStreamCancelled(t *testing.T) {
+	store := &stubConvStore{}
+	svc := NewAdvisorService(
+		trace.NewNoopTracerProvider().Tracer("test"),
+		blockingLLM{}, &stubPrices{}, &stubSignals{}, store, "gpt-4o-mini", 20,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go cancel()
+	if _, err := svc.AskStream(ctx, 1, "hi", func(string) {}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
 	}
+	for _, m := range store.messages {
+		if m.role == "assistant" {
+			t.Fatalf("expected no stored reply after cancelling, got %+v", store.messages)
+		}
+	}
+}
+
+func TestAskNoHistory(t *testing.T) {
+	stub := llm.NewStub(textResponse("fresh start"))
 	store := &stubConvStore{}
 	prices := &stubPrices{allPrices: []*domain.PriceSnapshot{}}
 	signals := &stubSignals{}
 
 	svc := NewAdvisorService(
 		trace.NewNoopTracerProvider().Tracer("test"),
-		llm, prices, signals, store, "gpt-4o-mini", 20,
+		stub, prices, signals, store, "gpt-4o-mini", 20,
 	)
 
 	reply, err := svc.Ask(context.Background(), 999, "Hello")
@@ -152,7 +310,7 @@ func TestAskNoHistory(t *testing.T) {
 func TestAskDefaultMaxHistory(t *testing.T) {
 	svc := NewAdvisorService(
 		trace.NewNoopTracerProvider().Tracer("test"),
-		&stubLLMClient{}, &stubPrices{}, &stubSignals{}, &stubConvStore{},
+		llm.NewStub(), &stubPrices{}, &stubSignals{}, &stubConvStore{},
 		"gpt-4o-mini", 0,
 	)
 	if svc.maxHistory != 20 {
@@ -160,15 +318,109 @@ func TestAskDefaultMaxHistory(t *testing.T) {
 	}
 }
 
+func TestAskIncludesSharedHoldings(t *testing.T) {
+	stub := llm.NewStub(textResponse("Your BTC is up"))
+	svc := NewAdvisorService(
+		trace.NewNoopTracerProvider().Tracer("test"),
+		stub, &stubPrices{}, &stubSignals{}, &stubConvStore{}, "gpt-4o-mini", 20,
+	)
+	svc.SetHoldings(&stubHoldings{byChat: map[int64]*domain.HoldingsValuation{
+		7: {Holdings: []domain.Holding{{Symbol: "BTC", Quantity: 0.5, CostBasis: 15000, MarketValue: 25000, Allocation: 1}}},
+	}})
+
+	systemPrompt := func() string {
+		calls := stub.Requests()
+		return calls[len(calls)-1].Messages[0].Content
+	}
+
+	if _, err := svc.Ask(context.Background(), 7, "how is my bag doing"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(systemPrompt(), "User Holdings") || !strings.Contains(systemPrompt(), "BTC: 0.5 units") {
+		t.Fatalf("expected holdings in system prompt, got %s", systemPrompt())
+	}
+
+	if _, err := svc.Ask(context.Background(), 8, "how is my bag doing"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(systemPrompt(), "User Holdings") {
+		t.Fatalf("expected no holdings for a user who did not opt in, got %s", systemPrompt())
+	}
+}
+
+func TestSummarizeDigestSkipsHistory(t *testing.T) {
+	stub := llm.NewStub(textResponse("Markets rose."))
+	store := &stubConvStore{}
+	svc := NewAdvisorService(
+		trace.NewNoopTracerProvider().Tracer("test"),
+		stub, &stubPrices{}, &stubSignals{}, store, "gpt-4o-mini", 20,
+	)
+
+	summary, err := svc.SummarizeDigest(context.Background(), "- BTC +2.00%")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary != "Markets rose." {
+		t.Fatalf("unexpected summary %q", summary)
+	}
+	if msgs := stub.Requests()[0].Messages; len(msgs) != 2 || len(store.messages) != 0 {
+		t.Fatalf("expected a standalone prompt, got %d messages and %d stored", len(msgs), len(store.messages))
+	}
+
+	stub.SetError(errors.New("api down"))
+	if _, err := svc.SummarizeDigest(context.Background(), "- BTC +2.00%"); err == nil {
+		t.Fatal("expected error from LLM failure")
+	}
+}
+
 // --- stubs ---
 
-type stubLLMClient struct {
-	response *openai.ChatCompletion
-	err      error
+// blockingLLM waits for the context to end.
+type blockingLLM struct{}
+
+func (blockingLLM) Complete(ctx context.Context, req llm.Request) (*llm.Response, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// completeOnlyLLM cannot stream.
+type completeOnlyLLM struct{ reply string }
+
+func (c completeOnlyLLM) Complete(ctx context.Context, req llm.Request) (*llm.Response, error) {
+	return &llm.Response{Message: llm.Assistant(c.reply)}, nil
 }
 
-func (s *stubLLMClient) CreateChatCompletion(ctx context.Context, params openai.ChatCompletionNewParams) (*openai.ChatCompletion, error) {
-	return s.response, s.err
+func textResponse(content string) llm.Response {
+	return llm.Response{Message: llm.Assistant(content)}
+}
+
+func toolCallResponse(content string, calls ...llm.ToolCall) llm.Response {
+	return llm.Response{Message: llm.Message{Content: content, ToolCalls: calls}}
+}
+
+func toolCall(id, name, args string) llm.ToolCall {
+	return llm.ToolCall{ID: id, Name: name, Arguments: args}
+}
+
+func lastMessage(req llm.Request) llm.Message {
+	return req.Messages[len(req.Messages)-1]
+}
+
+func marshalParam(t *testing.T, v any) string {
+	t.Helper()
+	raw, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return string(raw)
+}
+
+type stubHoldings struct {
+	byChat map[int64]*domain.HoldingsValuation
+}
+
+func (s *stubHoldings) AdvisorHoldings(ctx context.Context, chatID int64) (*domain.HoldingsValuation, error) {
+	return s.byChat[chatID], nil
 }
 
 type storedMsg struct {
@@ -198,9 +450,10 @@ func (s *stubConvStore) RecentMessages(ctx context.Context, chatID int64, limit
 	}
 	// Return stored messages as history (simulates reading back what was appended)
 	var msgs []domain.ConversationMessage
-	for _, m := range s.messages {
+	for i, m := range s.messages {
 		if m.chatID == chatID {
 			msgs = append(msgs, domain.ConversationMessage{
+				ID:        int64(i + 1),
 				Role:      m.role,
 				Content:   m.content,
 				CreatedAt: time.Now(),
@@ -247,3 +500,37 @@ func (s *stubSignals) ListSignals(ctx context.Context, filter domain.SignalFilte
 	}
 	return s.signals, nil
 }
+
+type stubCandles struct {
+	candles  []*domain.Candle
+	err      error
+	from, to time.Time
+}
+
+func (s *stubCandles) GetCandlesInRange(ctx context.Context, symbol, interval string, from, to time.Time) ([]*domain.Candle, error) {
+	s.from, s.to = from, to
+	if s.err != nil {
+		return nil, s.err
+	}
+	var out []*domain.Candle
+	for _, c := range s.candles {
+		if c.Symbol == symbol && c.Interval == interval && !c.OpenTime.Before(from) && !c.OpenTime.After(to) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+// rampCandles builds n candles from start whose closes zig-zag upward.
+func rampCandles(symbol, interval string, start time.Time, n int) []*domain.Candle {
+	step := domain.IntervalDuration(interval)
+	out := make([]*domain.Candle, 0, n)
+	for i := 0; i < n; i++ {
+		c := 100 + float64(i) + float64(i%3)*2
+		out = append(out, &domain.Candle{
+			Symbol: symbol, Interval: interval, OpenTime: start.Add(time.Duration(i) * step),
+			Open: c - 1, High: c + 1, Low: c - 2, Close: c, Volume: 1000 + float64(i%7)*10,
+		})
+	}
+	return out
+}
@@ -0,0 +1,272 @@
+package advisor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/llm"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// MemoryStore keeps what the advisor remembers about a chat beyond its
+// recent messages: a running summary of older turns and remembered facts.
+type MemoryStore interface {
+	GetMemory(ctx context.Context, chatID int64) (*domain.ConversationMemory, error)
+	SaveMemory(ctx context.Context, mem domain.ConversationMemory) error
+	ResetConversation(ctx context.Context, chatID int64) error
+	ForgetConversation(ctx context.Context, chatID int64) error
+}
+
+const (
+	// defaultHistoryTokens bounds the conversation history sent with each
+	// question.
+	defaultHistoryTokens = 4000
+	// maxFacts caps the facts remembered per chat; the oldest go first.
+	maxFacts = 20
+	// maxFactLength caps one fact, in characters.
+	maxFactLength = 200
+	// messageOverheadTokens approximates the per-message framing a chat
+	// model adds on top of the content.
+	messageOverheadTokens = 4
+)
+
+// ErrMemoryDisabled is returned by Reset and Forget when the advisor has no
+// memory store.
+var ErrMemoryDisabled = errors.New("conversation memory is not configured")
+
+const compactPrompt = `You maintain a running summary of a chat between a crypto trading advisor and a user. Merge the earlier summary and the new messages into one summary of at most 150 words. Keep the user's goals, positions, preferences, questions still open and conclusions reached. Leave out greetings and live numbers such as current prices, which will be stale. Reply with the summary only.`
+
+// SetMemory gives the advisor a store for its per-chat running summary.
+// Older turns that no longer fit the history budget are summarized into it
+// instead of being dropped. With facts set, the model is also offered a
+// remember tool for lasting facts such as the user's preferred coins or
+// risk tolerance.
+func (s *AdvisorService) SetMemory(store MemoryStore, facts bool) {
+	s.memory = store
+	s.rememberFacts = facts
+}
+
+// SetHistoryBudget bounds the conversation history sent with each question
+// to historyTokens. contextTokens is the model's context window; zero looks
+// it up from the model name. Non-positive historyTokens keeps the default.
+func (s *AdvisorService) SetHistoryBudget(historyTokens, contextTokens int) {
+	if historyTokens > 0 {
+		s.historyTokens = historyTokens
+	}
+	if contextTokens > 0 {
+		s.contextTokens = contextTokens
+	}
+}
+
+// Reset starts a chat's conversation afresh. Earlier messages and the
+// running summary are no longer sent to the model; remembered facts stay.
+func (s *AdvisorService) Reset(ctx context.Context, chatID int64) error {
+	ctx, span := s.tracer.Start(ctx, "advisor.reset")
+	defer span.End()
+
+	if s.memory == nil {
+		return ErrMemoryDisabled
+	}
+	return s.memory.ResetConversation(ctx, chatID)
+}
+
+// Forget deletes everything the advisor stored for a chat: messages, tool
+// calls, the running summary and remembered facts.
+func (s *AdvisorService) Forget(ctx context.Context, chatID int64) error {
+	ctx, span := s.tracer.Start(ctx, "advisor.forget")
+	defer span.End()
+
+	if s.memory == nil {
+		return ErrMemoryDisabled
+	}
+	return s.memory.ForgetConversation(ctx, chatID)
+}
+
+func (s *AdvisorService) loadMemory(ctx context.Context, chatID int64) *domain.ConversationMemory {
+	if s.memory == nil {
+		return nil
+	}
+	mem, err := s.memory.GetMemory(ctx, chatID)
+	if err != nil {
+		log.Printf("failed to load conversation memory for chat %d: %v", chatID, err)
+		return nil
+	}
+	return mem
+}
+
+// historyLimit is how many messages to load. With a memory store twice the
+// history length is loaded, so turns that overflow it get summarized.
+func (s *AdvisorService) historyLimit() int {
+	if s.memory != nil {
+		return 2 * s.maxHistory
+	}
+	return s.maxHistory
+}
+
+// historyBudget is how many tokens of history fit beside systemPrompt: the
+// configured budget, shrunk if the model's context window needs room for
+// the prompt, tool results and the reply.
+func (s *AdvisorService) historyBudget(systemPrompt string) int {
+	window := s.contextTokens
+	if window <= 0 {
+		window = llm.ContextWindow(s.model)
+	}
+	// A quarter of the window is left for tool results and the reply.
+	room := window - window/4 - estimateTokens(systemPrompt)
+	return max(min(s.historyTokens, room), 0)
+}
+
+// selectHistory splits history, oldest first, into the messages to send and
+// the older ones that did not fit. Messages already covered by the running
+// summary are left out of both. The newest message is always sent.
+func (s *AdvisorService) selectHistory(
+	history []domain.ConversationMessage,
+	mem *domain.ConversationMemory,
+	budget int,
+) (keep, overflow []domain.ConversationMessage) {
+	if mem != nil && mem.CompactedThrough > 0 {
+		fresh := history[:0:0]
+		for _, m := range history {
+			if m.ID > mem.CompactedThrough {
+				fresh = append(fresh, m)
+			}
+		}
+		history = fresh
+	}
+
+	start, used := len(history), 0
+	for i := len(history) - 1; i >= 0; i-- {
+		cost := messageTokens(history[i])
+		if start < len(history) && (len(history)-i > s.maxHistory || used+cost > budget) {
+			break
+		}
+		start, used = i, used+cost
+	}
+	return history[start:], history[:start]
+}
+
+// compact folds overflow into the chat's running summary and moves the
+// watermark past it, so those messages are not sent verbatim again.
+func (s *AdvisorService) compact(
+	ctx context.Context,
+	chatID int64,
+	mem *domain.ConversationMemory,
+	overflow []domain.ConversationMessage,
+) (*domain.ConversationMemory, error) {
+	ctx, span := s.tracer.Start(ctx, "advisor.compact")
+	defer span.End()
+	span.SetAttributes(attribute.Int("advisor.compacted_messages", len(overflow)))
+
+	next := domain.ConversationMemory{ChatID: chatID}
+	if mem != nil {
+		next = *mem
+	}
+
+	var sb strings.Builder
+	if next.Summary != "" {
+		sb.WriteString("Earlier summary:\n")
+		sb.WriteString(next.Summary)
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString("New messages:\n")
+	for _, m := range overflow {
+		fmt.Fprintf(&sb, "%s: %s\n", m.Role, m.Content)
+	}
+
+	msg, err := s.callLLM(ctx, []llm.Message{
+		llm.System(compactPrompt),
+		llm.User(sb.String()),
+	}, nil, nil)
+	if err != nil {
+		span.RecordError(err)
+		return mem, err
+	}
+	if summary := strings.TrimSpace(msg.Content); summary != "" {
+		next.Summary = summary
+	}
+	next.CompactedThrough = overflow[len(overflow)-1].ID
+	if err := s.memory.SaveMemory(ctx, next); err != nil {
+		span.RecordError(err)
+		return mem, err
+	}
+	return &next, nil
+}
+
+type rememberArgs struct {
+	Fact string `json:"fact"`
+}
+
+// rememberTool stores a lasting fact about the user of chatID.
+func (s *AdvisorService) rememberTool(chatID int64) tool {
+	return tool{
+		name: "remember",
+		description: fmt.Sprintf("Remember a lasting fact about the user across conversations, such as their preferred coins, risk tolerance or "+
+			"trading horizon. Use it only when the user states such a fact or asks you to remember something. At most %d facts are kept.", maxFacts),
+		parameters: object(map[string]any{
+			"fact": map[string]any{"type": "string", "maxLength": maxFactLength, "description": "One short sentence, e.g. \"Prefers ETH and SOL\"."},
+		}, "fact"),
+		run: func(ctx context.Context, raw json.RawMessage) (any, error) {
+			var args rememberArgs
+			if err := decodeArgs(raw, &args); err != nil {
+				return nil, err
+			}
+			fact := truncateRunes(strings.TrimSpace(args.Fact), maxFactLength)
+			if fact == "" {
+				return nil, errors.New("fact is required")
+			}
+			mem, err := s.memory.GetMemory(ctx, chatID)
+			if err != nil {
+				return nil, err
+			}
+			next := domain.ConversationMemory{ChatID: chatID}
+			if mem != nil {
+				next = *mem
+			}
+			next.Facts = addFact(next.Facts, fact)
+			if err := s.memory.SaveMemory(ctx, next); err != nil {
+				return nil, err
+			}
+			return map[string]any{"remembered": fact, "facts": len(next.Facts)}, nil
+		},
+	}
+}
+
+// addFact appends fact, replacing an equal one, and drops the oldest facts
+// beyond maxFacts.
+func addFact(facts []string, fact string) []string {
+	out := make([]string, 0, len(facts)+1)
+	for _, f := range facts {
+		if !strings.EqualFold(f, fact) {
+			out = append(out, f)
+		}
+	}
+	out = append(out, fact)
+	if len(out) > maxFacts {
+		out = out[len(out)-maxFacts:]
+	}
+	return out
+}
+
+// estimateTokens approximates how many tokens s takes, at about four
+// characters a token. It only has to be close enough to budget with.
+func estimateTokens(s string) int {
+	return (utf8.RuneCountInString(s) + 3) / 4
+}
+
+func messageTokens(m domain.ConversationMessage) int {
+	return estimateTokens(m.Content) + messageOverheadTokens
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,270 @@
+package advisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/llm"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newMemoryTestService(client LLMClient, store *stubConvStore, maxHistory int) *AdvisorService {
+	return NewAdvisorService(
+		trace.NewNoopTracerProvider().Tracer("test"),
+		client, &stubPrices{}, &stubSignals{}, store, "gpt-4o-mini", maxHistory,
+	)
+}
+
+// seedChat stores n alternating user and assistant messages of about
+// tokens tokens each.
+func seedChat(store *stubConvStore, chatID int64, n, tokens int) {
+	for i := 0; i < n; i++ {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		content := fmt.Sprintf("msg%d %s", i, strings.Repeat("x", tokens*4))
+		store.messages = append(store.messages, storedMsg{chatID: chatID, role: role, content: content})
+	}
+}
+
+func TestAskFitsHistoryToTokenBudget(t *testing.T) {
+	stub := llm.NewStub(textResponse("ok"))
+	store := &stubConvStore{}
+	seedChat(store, 1, 10, 100)
+	svc := newMemoryTestService(stub, store, 20)
+	svc.SetHistoryBudget(350, 0)
+
+	if _, err := svc.Ask(context.Background(), 1, "latest question"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	msgs := stub.Requests()[0].Messages
+	// System prompt, the three newest messages that fit and the question.
+	if len(msgs) != 5 {
+		t.Fatalf("expected 5 messages within the budget, got %d", len(msgs))
+	}
+	if !strings.HasPrefix(msgs[1].Content, "msg7") || lastMessage(stub.Requests()[0]).Content != "latest question" {
+		t.Fatalf("expected the newest messages to be kept, got %q ... %q", msgs[1].Content, lastMessage(stub.Requests()[0]).Content)
+	}
+}
+
+func TestAskAlwaysSendsLatestMessage(t *testing.T) {
+	stub := llm.NewStub(textResponse("ok"))
+	store := &stubConvStore{}
+	svc := newMemoryTestService(stub, store, 20)
+	svc.SetHistoryBudget(1, 0)
+
+	long := strings.Repeat("y", 4000)
+	if _, err := svc.Ask(context.Background(), 1, long); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := lastMessage(stub.Requests()[0]); got.Content != long {
+		t.Fatalf("expected the question despite the budget, got %d chars", len(got.Content))
+	}
+}
+
+func TestHistoryBudgetRespectsContextWindow(t *testing.T) {
+	svc := newMemoryTestService(llm.NewStub(), &stubConvStore{}, 20)
+	if got := svc.historyBudget(""); got != defaultHistoryTokens {
+		t.Fatalf("expected the configured budget for a large model, got %d", got)
+	}
+	svc.SetHistoryBudget(0, 2000)
+	if got := svc.historyBudget(strings.Repeat("z", 2000)); got != 1000 {
+		t.Fatalf("expected the budget shrunk to fit a 2000 token window, got %d", got)
+	}
+	if got := svc.historyBudget(strings.Repeat("z", 8000)); got != 0 {
+		t.Fatalf("expected no room for history, got %d", got)
+	}
+}
+
+func TestAskCompactsOverflowIntoSummary(t *testing.T) {
+	stub := llm.NewStub(textResponse("User is bullish on SOL."), textResponse("answer"))
+	store := &stubConvStore{}
+	seedChat(store, 1, 4, 10)
+	mem := &stubMemory{}
+	svc := newMemoryTestService(stub, store, 2)
+	svc.SetMemory(mem, false)
+
+	if _, err := svc.Ask(context.Background(), 1, "and now?"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reqs := stub.Requests()
+	if len(reqs) != 2 || reqs[0].Messages[0].Content != compactPrompt {
+		t.Fatalf("expected a compaction call before the answer, got %d requests", len(reqs))
+	}
+	if in := reqs[0].Messages[1].Content; !strings.Contains(in, "assistant: msg1") || !strings.Contains(in, "user: msg2") || strings.Contains(in, "msg3") {
+		t.Fatalf("expected the overflowed messages to be summarized, got %s", in)
+	}
+	saved := mem.byChat[1]
+	if saved == nil || saved.Summary != "User is bullish on SOL." || saved.CompactedThrough != 3 {
+		t.Fatalf("unexpected saved memory %+v", saved)
+	}
+	answer := reqs[1]
+	if !strings.Contains(answer.Messages[0].Content, "Earlier conversation (summary):\nUser is bullish on SOL.") {
+		t.Fatalf("expected the new summary in the system prompt, got %s", answer.Messages[0].Content)
+	}
+	if len(answer.Messages) != 3 || !strings.HasPrefix(answer.Messages[1].Content, "msg3") || lastMessage(answer).Content != "and now?" {
+		t.Fatalf("expected the last two messages beside the prompt, got %d messages", len(answer.Messages))
+	}
+}
+
+func TestAskSkipsCompactedMessages(t *testing.T) {
+	stub := llm.NewStub(textResponse("answer"))
+	store := &stubConvStore{}
+	seedChat(store, 1, 4, 10)
+	mem := &stubMemory{byChat: map[int64]*domain.ConversationMemory{
+		1: {ChatID: 1, Summary: "old summary", CompactedThrough: 3, Facts: []string{"Prefers ETH"}},
+	}}
+	svc := newMemoryTestService(stub, store, 20)
+	svc.SetMemory(mem, true)
+
+	if _, err := svc.Ask(context.Background(), 1, "hi"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req := stub.Requests()[0]
+	if len(req.Messages) != 3 || !strings.HasPrefix(req.Messages[1].Content, "msg3") {
+		t.Fatalf("expected only messages after the watermark, got %d", len(req.Messages))
+	}
+	if !strings.Contains(req.Messages[0].Content, "old summary") || !strings.Contains(req.Messages[0].Content, "  - Prefers ETH") {
+		t.Fatalf("expected summary and facts in the system prompt, got %s", req.Messages[0].Content)
+	}
+}
+
+func TestAskCompactionFailureStillAnswers(t *testing.T) {
+	store := &stubConvStore{}
+	seedChat(store, 1, 6, 10)
+	mem := &stubMemory{}
+	svc := newMemoryTestService(failCompactionLLM{}, store, 2)
+	svc.SetMemory(mem, false)
+
+	reply, err := svc.Ask(context.Background(), 1, "still there?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reply != "answer" {
+		t.Fatalf("unexpected reply %q", reply)
+	}
+	if len(mem.byChat) != 0 {
+		t.Fatalf("expected nothing saved after a failed compaction, got %+v", mem.byChat)
+	}
+}
+
+func TestRememberToolOnlyWithFacts(t *testing.T) {
+	svc := newMemoryTestService(llm.NewStub(), &stubConvStore{}, 20)
+	svc.SetMemory(&stubMemory{}, false)
+	if got := toolNames(svc.tools(1)); strings.Contains(got, "remember") {
+		t.Fatalf("expected no remember tool with facts off, got %s", got)
+	}
+	svc.SetMemory(&stubMemory{}, true)
+	if got := toolNames(svc.tools(1)); !strings.HasSuffix(got, ",remember") {
+		t.Fatalf("expected remember tool, got %s", got)
+	}
+}
+
+func TestRememberToolStoresCappedFacts(t *testing.T) {
+	mem := &stubMemory{byChat: map[int64]*domain.ConversationMemory{
+		1: {ChatID: 1, Summary: "kept", CompactedThrough: 9},
+	}}
+	svc := newMemoryTestService(llm.NewStub(), &stubConvStore{}, 20)
+	svc.SetMemory(mem, true)
+	tools := svc.tools(1)
+
+	for i := 0; i < maxFacts+2; i++ {
+		if _, err := svc.runTool(context.Background(), tools, "remember", fmt.Sprintf(`{"fact":"fact %d"}`, i)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if _, err := svc.runTool(context.Background(), tools, "remember", `{"fact":"FACT 5"}`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := mem.byChat[1]
+	if len(got.Facts) != maxFacts || got.Facts[0] != "fact 2" || got.Facts[maxFacts-1] != "FACT 5" {
+		t.Fatalf("expected oldest dropped and the repeat moved last, got %#v", got.Facts)
+	}
+	if got.Summary != "kept" || got.CompactedThrough != 9 {
+		t.Fatalf("expected the summary untouched, got %+v", got)
+	}
+
+	if _, err := svc.runTool(context.Background(), tools, "remember", `{"fact":"  "}`); err == nil {
+		t.Fatal("expected an empty fact to be rejected")
+	}
+	long := strings.Repeat("é", maxFactLength+50)
+	if _, err := svc.runTool(context.Background(), tools, "remember", `{"fact":"`+long+`"}`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if last := mem.byChat[1].Facts[maxFacts-1]; len([]rune(last)) != maxFactLength {
+		t.Fatalf("expected the fact truncated to %d characters, got %d", maxFactLength, len([]rune(last)))
+	}
+}
+
+func TestResetAndForget(t *testing.T) {
+	svc := newMemoryTestService(llm.NewStub(), &stubConvStore{}, 20)
+	if err := svc.Reset(context.Background(), 1); !errors.Is(err, ErrMemoryDisabled) {
+		t.Fatalf("expected ErrMemoryDisabled, got %v", err)
+	}
+	if err := svc.Forget(context.Background(), 1); !errors.Is(err, ErrMemoryDisabled) {
+		t.Fatalf("expected ErrMemoryDisabled, got %v", err)
+	}
+
+	mem := &stubMemory{}
+	svc.SetMemory(mem, true)
+	if err := svc.Reset(context.Background(), 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := svc.Forget(context.Background(), 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mem.resets) != 1 || mem.resets[0] != 1 || len(mem.forgets) != 1 || mem.forgets[0] != 2 {
+		t.Fatalf("expected one reset of chat 1 and one forget of chat 2, got %v %v", mem.resets, mem.forgets)
+	}
+}
+
+// --- stubs ---
+
+type stubMemory struct {
+	byChat  map[int64]*domain.ConversationMemory
+	resets  []int64
+	forgets []int64
+}
+
+func (s *stubMemory) GetMemory(ctx context.Context, chatID int64) (*domain.ConversationMemory, error) {
+	mem, ok := s.byChat[chatID]
+	if !ok {
+		return nil, nil
+	}
+	cp := *mem
+	return &cp, nil
+}
+
+func (s *stubMemory) SaveMemory(ctx context.Context, mem domain.ConversationMemory) error {
+	if s.byChat == nil {
+		s.byChat = map[int64]*domain.ConversationMemory{}
+	}
+	s.byChat[mem.ChatID] = &mem
+	return nil
+}
+
+func (s *stubMemory) ResetConversation(ctx context.Context, chatID int64) error {
+	s.resets = append(s.resets, chatID)
+	return nil
+}
+
+func (s *stubMemory) ForgetConversation(ctx context.Context, chatID int64) error {
+	s.forgets = append(s.forgets, chatID)
+	return nil
+}
+
+// failCompactionLLM fails summarization requests and answers the rest.
+type failCompactionLLM struct{}
+
+func (failCompactionLLM) Complete(ctx context.Context, req llm.Request) (*llm.Response, error) {
+	if req.Messages[0].Content == compactPrompt {
+		return nil, errors.New("summarizer down")
+	}
+	return &llm.Response{Message: llm.Assistant("answer")}, nil
+}
@@ -10,6 +10,11 @@ import (
 
 const tradingPhilosophy = `You are a crypto trading advisor bot. Your role is to interpret technical analysis signals and market data, NOT to generate signals yourself.
 
+Tools:
+- Look up the data you need with the tools before answering: current prices, historical candles with indicator values, signals, ML model accuracy and news/social sentiment.
+- Never answer from memory when a tool can provide the number. Resolve relative dates like "last Tuesday" from the current time below; all times are UTC.
+- If a tool returns an error or no data, say so rather than guessing.
+
 Risk Framework:
 - Risk 1-2: Conservative plays. Suitable for larger positions. Multiple confirming indicators.
 - Risk 3: Moderate. Standard position sizing. At least one strong indicator alignment.
@@ -24,42 +29,60 @@ Rules:
 - Do not provide financial advice disclaimers on every message. The user understands this is informational.
 - When asked about an asset, summarize: current price, recent signals, and your interpretation.
 - If no signals exist for an asset, say so honestly rather than speculating.
-- If fundamentals/sentiment composite signals are present, include them in your interpretation.`
+- When sentiment or fundamentals/sentiment composite data is relevant, include it in your interpretation.
+- If the user's holdings are listed, use them when asked about their portfolio. Do not mention holdings that are not listed.
+- A summary of the earlier conversation and facts the user asked you to remember may be listed. Treat them as background; take numbers from the tools.`
+
+const digestPrompt = `You summarise a crypto market digest for a Telegram user. Write 2-3 plain sentences covering the overall direction, the standout movers and anything notable in signals, ML accuracy or sentiment. Use only the facts given; do not add prices, predictions or advice.`
 
-func BuildSystemPrompt(marketContext string) string {
+// BuildSystemPrompt combines the trading philosophy with the current time
+// and what is known about the user.
+func BuildSystemPrompt(now time.Time, userContext string) string {
 	var sb strings.Builder
 	sb.WriteString(tradingPhilosophy)
-	sb.WriteString("\n\n--- LIVE MARKET DATA (as of ")
-	sb.WriteString(time.Now().UTC().Format(time.RFC822))
-	sb.WriteString(") ---\n")
-	sb.WriteString(marketContext)
+	sb.WriteString("\n\n--- CONTEXT ---\nCurrent time: ")
+	sb.WriteString(now.UTC().Format("Monday 2006-01-02 15:04 MST"))
+	sb.WriteString("\n")
+	sb.WriteString(userContext)
 	return sb.String()
 }
 
-func FormatMarketContext(prices []*domain.PriceSnapshot, signals []domain.Signal) string {
+// FormatHoldingsContext renders a user's holdings for the system prompt. It
+// returns an empty string when there is nothing to share.
+func FormatHoldingsContext(v *domain.HoldingsValuation) string {
+	if v == nil || len(v.Holdings) == 0 {
+		return ""
+	}
 	var sb strings.Builder
-
-	if len(prices) > 0 {
-		sb.WriteString("\nCurrent Prices:\n")
-		for _, p := range prices {
-			sb.WriteString(fmt.Sprintf("  %s: $%.2f (24h: %+.2f%%, vol: $%.0f)\n",
-				p.Symbol, p.PriceUSD, p.Change24hPct, p.Volume24h))
-		}
+	sb.WriteString("\nUser Holdings (FIFO cost basis):\n")
+	for _, h := range v.Holdings {
+		sb.WriteString(fmt.Sprintf("  %s: %.6g units, cost $%.2f (avg $%.2f), value $%.2f, unrealized %+.2f, %.1f%% of portfolio\n",
+			h.Symbol, h.Quantity, h.CostBasis, h.AvgCost, h.MarketValue, h.UnrealizedPnL, h.Allocation*100))
 	}
+	sb.WriteString(fmt.Sprintf("  Total: value $%.2f, cost $%.2f, unrealized %+.2f, realized %+.2f\n",
+		v.MarketValue, v.CostBasis, v.UnrealizedPnL, v.RealizedPnL))
+	return sb.String()
+}
 
-	if len(signals) > 0 {
-		sb.WriteString("\nActive Signals:\n")
-		for _, s := range signals {
-			sb.WriteString(fmt.Sprintf("  %s %s %s %s risk=%d %s\n",
-				s.Symbol, s.Interval,
-				strings.ToUpper(s.Indicator),
-				strings.ToUpper(string(s.Direction)),
-				s.Risk, s.Details))
+// FormatMemoryContext renders a chat's running summary and remembered facts
+// for the system prompt. It returns an empty string when there are none.
+func FormatMemoryContext(mem *domain.ConversationMemory) string {
+	if mem == nil || (mem.Summary == "" && len(mem.Facts) == 0) {
+		return ""
+	}
+	var sb strings.Builder
+	if len(mem.Facts) > 0 {
+		sb.WriteString("\nRemembered about the user:\n")
+		for _, f := range mem.Facts {
+			sb.WriteString("  - ")
+			sb.WriteString(f)
+			sb.WriteString("\n")
 		}
 	}
-
-	if sb.Len() == 0 {
-		return "No market data currently available."
+	if mem.Summary != "" {
+		sb.WriteString("\nEarlier conversation (summary):\n")
+		sb.WriteString(mem.Summary)
+		sb.WriteString("\n")
 	}
 	return sb.String()
 }
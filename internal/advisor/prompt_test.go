@@ -3,65 +3,61 @@ package advisor
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"bug-free-umbrella/internal/domain"
 )
 
 func TestBuildSystemPromptContainsPhilosophy(t *testing.T) {
-	prompt := BuildSystemPrompt("some context")
+	now := time.Date(2025, 3, 4, 9, 30, 0, 0, time.UTC)
+	prompt := BuildSystemPrompt(now, "some context")
 	if !strings.Contains(prompt, "crypto trading advisor") {
 		t.Fatal("expected trading philosophy in prompt")
 	}
 	if !strings.Contains(prompt, "Risk Framework") {
 		t.Fatal("expected risk framework in prompt")
 	}
-	if !strings.Contains(prompt, "LIVE MARKET DATA") {
-		t.Fatal("expected market data header in prompt")
+	if !strings.Contains(prompt, "with the tools") {
+		t.Fatal("expected tool guidance in prompt")
+	}
+	if !strings.Contains(prompt, "Current time: Tuesday 2025-03-04 09:30 UTC") {
+		t.Fatalf("expected current time with weekday in prompt, got %s", prompt)
 	}
 	if !strings.Contains(prompt, "some context") {
-		t.Fatal("expected market context in prompt")
+		t.Fatal("expected user context in prompt")
 	}
 }
 
-func TestFormatMarketContextWithPricesAndSignals(t *testing.T) {
-	prices := []*domain.PriceSnapshot{
-		{Symbol: "BTC", PriceUSD: 50000, Change24hPct: 2.5, Volume24h: 1e9},
-	}
-	signals := []domain.Signal{
-		{Symbol: "BTC", Interval: "1h", Indicator: "rsi", Direction: domain.DirectionLong, Risk: 2, Details: "oversold"},
-	}
-
-	ctx := FormatMarketContext(prices, signals)
-	if !strings.Contains(ctx, "BTC: $50000.00") {
-		t.Fatal("expected BTC price in context")
-	}
-	if !strings.Contains(ctx, "RSI") {
-		t.Fatal("expected RSI indicator in context")
-	}
-	if !strings.Contains(ctx, "LONG") {
-		t.Fatal("expected LONG direction in context")
-	}
-	if !strings.Contains(ctx, "risk=2") {
-		t.Fatal("expected risk level in context")
+func TestFormatHoldingsContext(t *testing.T) {
+	if FormatHoldingsContext(nil) != "" {
+		t.Fatal("expected empty context without holdings")
+	}
+	ctx := FormatHoldingsContext(&domain.HoldingsValuation{
+		Holdings: []domain.Holding{
+			{Symbol: "ETH", Quantity: 2, CostBasis: 4000, AvgCost: 2000, MarketValue: 6000, UnrealizedPnL: 2000, Allocation: 0.6},
+		},
+		MarketValue: 10000, CostBasis: 8000, UnrealizedPnL: 2000, RealizedPnL: -150,
+	})
+	if !strings.Contains(ctx, "ETH: 2 units") || !strings.Contains(ctx, "60.0% of portfolio") {
+		t.Fatalf("expected ETH holding line, got: %s", ctx)
+	}
+	if !strings.Contains(ctx, "realized -150.00") {
+		t.Fatalf("expected totals line, got: %s", ctx)
 	}
 }
 
-func TestFormatMarketContextEmpty(t *testing.T) {
-	ctx := FormatMarketContext(nil, nil)
-	if ctx != "No market data currently available." {
-		t.Fatalf("expected fallback text, got: %s", ctx)
+func TestFormatMemoryContext(t *testing.T) {
+	if got := FormatMemoryContext(nil); got != "" {
+		t.Fatalf("expected empty context without memory, got %q", got)
 	}
-}
-
-func TestFormatMarketContextPricesOnly(t *testing.T) {
-	prices := []*domain.PriceSnapshot{
-		{Symbol: "ETH", PriceUSD: 3000, Change24hPct: -1.2, Volume24h: 5e8},
+	if got := FormatMemoryContext(&domain.ConversationMemory{CompactedThrough: 5}); got != "" {
+		t.Fatalf("expected empty context for a reset chat, got %q", got)
 	}
-	ctx := FormatMarketContext(prices, nil)
-	if !strings.Contains(ctx, "ETH: $3000.00") {
-		t.Fatal("expected ETH price")
+	got := FormatMemoryContext(&domain.ConversationMemory{Summary: "Asked about SOL staking.", Facts: []string{"Risk tolerance: low"}})
+	if !strings.Contains(got, "Remembered about the user:\n  - Risk tolerance: low\n") {
+		t.Fatalf("expected facts listed, got %q", got)
 	}
-	if strings.Contains(ctx, "Active Signals") {
-		t.Fatal("should not contain signals section when no signals")
+	if !strings.Contains(got, "Earlier conversation (summary):\nAsked about SOL staking.\n") {
+		t.Fatalf("expected summary, got %q", got)
 	}
 }
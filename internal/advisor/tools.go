@@ -0,0 +1,605 @@
+package advisor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/llm"
+	"bug-free-umbrella/internal/marketintel"
+	"bug-free-umbrella/internal/repository"
+	"bug-free-umbrella/internal/signal"
+)
+
+const (
+	// toolTimeout bounds a single tool call.
+	toolTimeout = 10 * time.Second
+	// maxToolCandles caps the candles one get_candles call returns; the most
+	// recent ones are kept.
+	maxToolCandles = 100
+	// indicatorWarmup is how many candles before the requested range are
+	// loaded so indicators are warmed up at its start.
+	indicatorWarmup = 60
+	// maxToolSignals caps list_signals.
+	maxToolSignals = 50
+	// maxIntelHours caps how far back get_market_intel looks.
+	maxIntelHours = 24 * 30
+	// maxRecordedResult caps the tool result kept in the conversation store.
+	maxRecordedResult = 4000
+)
+
+// CandleQuerier loads historical candles for the get_candles tool.
+type CandleQuerier interface {
+	GetCandlesInRange(ctx context.Context, symbol, interval string, from, to time.Time) ([]*domain.Candle, error)
+}
+
+// SignalParamsProvider returns the signal engine's current rule parameters,
+// so indicators match what generated the signals.
+type SignalParamsProvider interface {
+	Params() domain.SignalParams
+}
+
+// AccuracyQuerier reports the backtested daily accuracy of the ML models.
+type AccuracyQuerier interface {
+	GetSummary(ctx context.Context) ([]repository.DailyAccuracy, error)
+	GetDaily(ctx context.Context, modelKey string, days int) ([]repository.DailyAccuracy, error)
+}
+
+// MLAnalyticsQuerier scores resolved ML predictions by model, symbol and
+// interval.
+type MLAnalyticsQuerier interface {
+	Analyze(ctx context.Context, q domain.MLAnalyticsQuery) (*domain.MLAnalyticsReport, error)
+}
+
+// MarketIntelQuerier reads news, social sentiment and composite scores.
+type MarketIntelQuerier interface {
+	GetSentimentAverages(ctx context.Context, symbol string, from, to time.Time) (map[string]marketintel.SourceSentimentStats, error)
+	ListTopHeadlines(ctx context.Context, from, to time.Time, limit int) ([]domain.MarketIntelItem, error)
+	ListCompositeScores(ctx context.Context, interval string, from, to time.Time) ([]domain.MarketCompositeSnapshot, error)
+}
+
+// ToolSources back the advisor's optional tools. A tool is only offered to
+// the model when its source is set; prices and signals are always available.
+type ToolSources struct {
+	Candles     CandleQuerier
+	Params      SignalParamsProvider
+	Backtests   AccuracyQuerier
+	MLAnalytics MLAnalyticsQuerier
+	MarketIntel MarketIntelQuerier
+}
+
+// tool is a function the model may call. run receives the raw JSON
+// arguments and returns a value that is sent back as JSON.
+type tool struct {
+	name        string
+	description string
+	parameters  map[string]any
+	run         func(ctx context.Context, args json.RawMessage) (any, error)
+}
+
+func (t tool) param() llm.Tool {
+	return llm.Tool{Name: t.name, Description: t.description, Parameters: t.parameters}
+}
+
+// tools lists the tools available to chatID with the configured sources.
+func (s *AdvisorService) tools(chatID int64) []tool {
+	tools := []tool{
+		{
+			name:        "get_prices",
+			description: "Current USD price, 24h change and 24h volume. Omit symbols for every tracked asset.",
+			parameters: object(map[string]any{
+				"symbols": map[string]any{"type": "array", "items": symbolSchema()},
+			}),
+			run: s.runGetPrices,
+		},
+		{
+			name:        "list_signals",
+			description: "Most recent technical and ML signals, newest first, optionally filtered by symbol, interval, indicator and start time.",
+			parameters: object(map[string]any{
+				"symbol":    symbolSchema(),
+				"interval":  intervalSchema(),
+				"indicator": map[string]any{"type": "string", "description": "e.g. rsi, macd, bollinger, volume_zscore, ml_xgboost_up4h, fund_sentiment_composite"},
+				"since":     timeSchema("Only signals at or after this time."),
+				"limit":     map[string]any{"type": "integer", "minimum": 1, "maximum": maxToolSignals},
+			}),
+			run: s.runListSignals,
+		},
+	}
+	if s.sources.Candles != nil {
+		tools = append(tools, tool{
+			name: "get_candles",
+			description: fmt.Sprintf("Historical OHLCV candles with RSI, MACD, Bollinger bands and volume z-score at each candle, oldest first. "+
+				"Returns at most %d candles, the most recent in the range. Use this for past prices and indicator values.", maxToolCandles),
+			parameters: object(map[string]any{
+				"symbol":   symbolSchema(),
+				"interval": intervalSchema(),
+				"from":     timeSchema("Start of the range. Defaults to 50 candles before to."),
+				"to":       timeSchema("End of the range. Defaults to now."),
+			}, "symbol", "interval"),
+			run: s.runGetCandles,
+		})
+	}
+	if s.sources.Backtests != nil || s.sources.MLAnalytics != nil {
+		tools = append(tools, tool{
+			name: "get_ml_accuracy",
+			description: "How accurate the ML models' predictions have been. Without symbol or interval it returns daily backtest accuracy per model; " +
+				"with them it returns accuracy, precision, recall and returns for that slice.",
+			parameters: object(map[string]any{
+				"model_key": map[string]any{"type": "string", "description": "e.g. xgboost, logreg, ensemble_v1"},
+				"symbol":    symbolSchema(),
+				"interval":  intervalSchema(),
+				"days":      map[string]any{"type": "integer", "minimum": 1, "maximum": 90},
+			}),
+			run: s.runGetMLAccuracy,
+		})
+	}
+	if s.sources.MarketIntel != nil {
+		tools = append(tools, tool{
+			name:        "get_market_intel",
+			description: "News and social sentiment: per-source average sentiment for a symbol, the strongest headlines and fundamentals/sentiment composite scores.",
+			parameters: object(map[string]any{
+				"symbol":   symbolSchema(),
+				"hours":    map[string]any{"type": "integer", "minimum": 1, "maximum": maxIntelHours, "description": "How far back to look. Defaults to 24."},
+				"interval": intervalSchema(),
+			}),
+			run: s.runGetMarketIntel,
+		})
+	}
+	if s.memory != nil && s.rememberFacts {
+		tools = append(tools, s.rememberTool(chatID))
+	}
+	return tools
+}
+
+// runTool executes one tool call and returns its JSON result. Failures are
+// returned to the model as {"error": ...} so it can recover or say so.
+func (s *AdvisorService) runTool(ctx context.Context, tools []tool, name, args string) (string, error) {
+	ctx, span := s.tracer.Start(ctx, "advisor.tool-call")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, toolTimeout)
+	defer cancel()
+
+	var result any
+	var err error
+	i := slices.IndexFunc(tools, func(t tool) bool { return t.name == name })
+	if i < 0 {
+		err = fmt.Errorf("unknown tool %q", name)
+	} else {
+		if strings.TrimSpace(args) == "" {
+			args = "{}"
+		}
+		result, err = tools[i].run(ctx, json.RawMessage(args))
+	}
+	if err != nil {
+		span.RecordError(err)
+		result = map[string]string{"error": err.Error()}
+	}
+	raw, mErr := json.Marshal(result)
+	if mErr != nil {
+		return `{"error":"result could not be encoded"}`, mErr
+	}
+	return string(raw), err
+}
+
+type pricesArgs struct {
+	Symbols []string `json:"symbols"`
+}
+
+func (s *AdvisorService) runGetPrices(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args pricesArgs
+	if err := decodeArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	if len(args.Symbols) == 0 {
+		return s.prices.GetCurrentPrices(ctx)
+	}
+	out := make([]*domain.PriceSnapshot, 0, len(args.Symbols))
+	for _, sym := range args.Symbols {
+		sym, err := toolSymbol(sym)
+		if err != nil {
+			return nil, err
+		}
+		p, err := s.prices.GetCurrentPrice(ctx, sym)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", sym, err)
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+type signalsArgs struct {
+	Symbol    string `json:"symbol"`
+	Interval  string `json:"interval"`
+	Indicator string `json:"indicator"`
+	Since     string `json:"since"`
+	Limit     int    `json:"limit"`
+}
+
+type toolSignal struct {
+	ID        int64                  `json:"id"`
+	Symbol    string                 `json:"symbol"`
+	Interval  string                 `json:"interval"`
+	Indicator string                 `json:"indicator"`
+	Direction domain.SignalDirection `json:"direction"`
+	Risk      domain.RiskLevel       `json:"risk"`
+	Timestamp time.Time              `json:"timestamp"`
+	Details   string                 `json:"details,omitempty"`
+}
+
+func (s *AdvisorService) runListSignals(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args signalsArgs
+	if err := decodeArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	filter := domain.SignalFilter{
+		Indicator: strings.ToLower(strings.TrimSpace(args.Indicator)),
+		Limit:     args.Limit,
+	}
+	if filter.Limit <= 0 || filter.Limit > maxToolSignals {
+		filter.Limit = 10
+	}
+	var err error
+	if args.Symbol != "" {
+		if filter.Symbol, err = toolSymbol(args.Symbol); err != nil {
+			return nil, err
+		}
+	}
+	if args.Interval != "" {
+		if filter.Interval, err = toolInterval(args.Interval); err != nil {
+			return nil, err
+		}
+	}
+	if args.Since != "" {
+		if filter.Since, err = parseToolTime(args.Since); err != nil {
+			return nil, err
+		}
+	}
+	signals, err := s.signals.ListSignals(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]toolSignal, 0, len(signals))
+	for _, sig := range signals {
+		out = append(out, toolSignal{
+			ID:        sig.ID,
+			Symbol:    sig.Symbol,
+			Interval:  sig.Interval,
+			Indicator: sig.Indicator,
+			Direction: sig.Direction,
+			Risk:      sig.Risk,
+			Timestamp: sig.Timestamp.UTC(),
+			Details:   sig.Details,
+		})
+	}
+	return out, nil
+}
+
+type candlesArgs struct {
+	Symbol   string `json:"symbol"`
+	Interval string `json:"interval"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+}
+
+type toolCandle struct {
+	signal.IndicatorReading
+	Open   float64 `json:"open"`
+	High   float64 `json:"high"`
+	Low    float64 `json:"low"`
+	Volume float64 `json:"volume"`
+}
+
+type candlesResult struct {
+	Symbol    string       `json:"symbol"`
+	Interval  string       `json:"interval"`
+	Candles   []toolCandle `json:"candles"`
+	Truncated bool         `json:"truncated,omitempty"`
+}
+
+func (s *AdvisorService) runGetCandles(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args candlesArgs
+	if err := decodeArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	symbol, err := toolSymbol(args.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	interval, err := toolInterval(args.Interval)
+	if err != nil {
+		return nil, err
+	}
+	step := domain.IntervalDuration(interval)
+
+	to := s.now().UTC()
+	if args.To != "" {
+		if to, err = parseToolTime(args.To); err != nil {
+			return nil, err
+		}
+	}
+	from := to.Add(-50 * step)
+	if args.From != "" {
+		if from, err = parseToolTime(args.From); err != nil {
+			return nil, err
+		}
+	}
+	if !from.Before(to) {
+		return nil, errors.New("from must be before to")
+	}
+
+	candles, err := s.sources.Candles.GetCandlesInRange(ctx, symbol, interval, from.Add(-indicatorWarmup*step), to)
+	if err != nil {
+		return nil, err
+	}
+	candles = slices.DeleteFunc(candles, func(c *domain.Candle) bool { return c == nil })
+	sort.Slice(candles, func(i, j int) bool { return candles[i].OpenTime.Before(candles[j].OpenTime) })
+	params := signal.DefaultParams()
+	if s.sources.Params != nil {
+		params = s.sources.Params.Params()
+	}
+	readings := signal.Indicators(candles, params)
+
+	out := candlesResult{Symbol: symbol, Interval: interval, Candles: make([]toolCandle, 0)}
+	for i, r := range readings {
+		if r.OpenTime.Before(from) {
+			continue
+		}
+		c := candles[i]
+		out.Candles = append(out.Candles, toolCandle{IndicatorReading: r, Open: c.Open, High: c.High, Low: c.Low, Volume: c.Volume})
+	}
+	if len(out.Candles) > maxToolCandles {
+		out.Candles = out.Candles[len(out.Candles)-maxToolCandles:]
+		out.Truncated = true
+	}
+	return out, nil
+}
+
+type mlAccuracyArgs struct {
+	ModelKey string `json:"model_key"`
+	Symbol   string `json:"symbol"`
+	Interval string `json:"interval"`
+	Days     int    `json:"days"`
+}
+
+type toolAccuracy struct {
+	ModelKey string  `json:"model_key"`
+	Day      string  `json:"day"`
+	Total    int     `json:"total"`
+	Correct  int     `json:"correct"`
+	Accuracy float64 `json:"accuracy"`
+}
+
+type toolMLGroup struct {
+	Key     string           `json:"key"`
+	Metrics domain.MLMetrics `json:"metrics"`
+}
+
+func (s *AdvisorService) runGetMLAccuracy(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args mlAccuracyArgs
+	if err := decodeArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	if args.Days <= 0 || args.Days > 90 {
+		args.Days = 30
+	}
+	modelKey := strings.ToLower(strings.TrimSpace(args.ModelKey))
+
+	if args.Symbol != "" || args.Interval != "" || s.sources.Backtests == nil {
+		if s.sources.MLAnalytics == nil {
+			return nil, errors.New("per-symbol ML analytics are not available")
+		}
+		q := domain.MLAnalyticsQuery{
+			GroupBy:  []string{domain.MLGroupModel},
+			ModelKey: modelKey,
+			To:       s.now().UTC(),
+		}
+		q.From = q.To.AddDate(0, 0, -args.Days)
+		var err error
+		if args.Symbol != "" {
+			if q.Symbol, err = toolSymbol(args.Symbol); err != nil {
+				return nil, err
+			}
+			q.GroupBy = append(q.GroupBy, domain.MLGroupSymbol)
+		}
+		if args.Interval != "" {
+			if q.Interval, err = toolInterval(args.Interval); err != nil {
+				return nil, err
+			}
+			q.GroupBy = append(q.GroupBy, domain.MLGroupInterval)
+		}
+		report, err := s.sources.MLAnalytics.Analyze(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		groups := make([]toolMLGroup, 0, len(report.Groups))
+		for _, g := range report.Groups {
+			groups = append(groups, toolMLGroup{Key: g.Key, Metrics: g.Metrics})
+		}
+		return map[string]any{"overall": report.Overall.Metrics, "groups": groups}, nil
+	}
+
+	var rows []repository.DailyAccuracy
+	var err error
+	if modelKey == "" {
+		rows, err = s.sources.Backtests.GetSummary(ctx)
+	} else {
+		rows, err = s.sources.Backtests.GetDaily(ctx, modelKey, args.Days)
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := make([]toolAccuracy, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, toolAccuracy{
+			ModelKey: r.ModelKey,
+			Day:      r.DayUTC.UTC().Format(time.DateOnly),
+			Total:    r.Total,
+			Correct:  r.Correct,
+			Accuracy: r.Accuracy,
+		})
+	}
+	return out, nil
+}
+
+type marketIntelArgs struct {
+	Symbol   string `json:"symbol"`
+	Hours    int    `json:"hours"`
+	Interval string `json:"interval"`
+}
+
+type toolHeadline struct {
+	Title       string    `json:"title"`
+	Source      string    `json:"source"`
+	PublishedAt time.Time `json:"published_at"`
+	Sentiment   *float64  `json:"sentiment,omitempty"`
+	Symbols     []string  `json:"symbols,omitempty"`
+}
+
+type toolComposite struct {
+	Symbol    string                 `json:"symbol"`
+	OpenTime  time.Time              `json:"open_time"`
+	Score     float64                `json:"score"`
+	Direction domain.SignalDirection `json:"direction"`
+}
+
+type marketIntelResult struct {
+	Sentiment  map[string]marketintel.SourceSentimentStats `json:"sentiment_by_source,omitempty"`
+	Headlines  []toolHeadline                              `json:"headlines"`
+	Composites []toolComposite                             `json:"composites"`
+}
+
+func (s *AdvisorService) runGetMarketIntel(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args marketIntelArgs
+	if err := decodeArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	if args.Hours <= 0 || args.Hours > maxIntelHours {
+		args.Hours = 24
+	}
+	interval := "4h"
+	var err error
+	if args.Interval != "" {
+		if interval, err = toolInterval(args.Interval); err != nil {
+			return nil, err
+		}
+	}
+	symbol := ""
+	if args.Symbol != "" {
+		if symbol, err = toolSymbol(args.Symbol); err != nil {
+			return nil, err
+		}
+	}
+	to := s.now().UTC()
+	from := to.Add(-time.Duration(args.Hours) * time.Hour)
+	intel := s.sources.MarketIntel
+
+	out := marketIntelResult{Headlines: make([]toolHeadline, 0), Composites: make([]toolComposite, 0)}
+	if symbol != "" {
+		if out.Sentiment, err = intel.GetSentimentAverages(ctx, symbol, from, to); err != nil {
+			return nil, err
+		}
+	}
+	items, err := intel.ListTopHeadlines(ctx, from, to, 20)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if symbol != "" && !slices.Contains(item.Symbols, symbol) {
+			continue
+		}
+		out.Headlines = append(out.Headlines, toolHeadline{
+			Title:       item.Title,
+			Source:      item.Source,
+			PublishedAt: item.PublishedAt.UTC(),
+			Sentiment:   item.SentimentScore,
+			Symbols:     item.Symbols,
+		})
+		if len(out.Headlines) == 5 {
+			break
+		}
+	}
+	snaps, err := intel.ListCompositeScores(ctx, interval, from, to)
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[string]toolComposite)
+	for _, snap := range snaps {
+		if symbol != "" && snap.Symbol != symbol {
+			continue
+		}
+		latest[snap.Symbol] = toolComposite{
+			Symbol:    snap.Symbol,
+			OpenTime:  snap.OpenTime.UTC(),
+			Score:     snap.CompositeScore,
+			Direction: snap.Direction,
+		}
+	}
+	for _, c := range latest {
+		out.Composites = append(out.Composites, c)
+	}
+	sort.Slice(out.Composites, func(i, j int) bool { return out.Composites[i].Symbol < out.Composites[j].Symbol })
+	return out, nil
+}
+
+func decodeArgs(raw json.RawMessage, dst any) error {
+	if err := json.Unmarshal(raw, dst); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+func toolSymbol(v string) (string, error) {
+	sym := strings.ToUpper(strings.TrimSpace(v))
+	if _, ok := domain.CoinGeckoID[sym]; !ok {
+		return "", fmt.Errorf("unsupported symbol %q (use %s)", v, strings.Join(domain.SupportedSymbols, ", "))
+	}
+	return sym, nil
+}
+
+func toolInterval(v string) (string, error) {
+	interval := strings.ToLower(strings.TrimSpace(v))
+	if !slices.Contains(domain.SupportedIntervals, interval) {
+		return "", fmt.Errorf("unsupported interval %q (use %s)", v, strings.Join(domain.SupportedIntervals, ", "))
+	}
+	return interval, nil
+}
+
+// parseToolTime accepts RFC 3339 timestamps and UTC dates with an optional
+// time of day.
+func parseToolTime(v string) (time.Time, error) {
+	v = strings.TrimSpace(v)
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04", time.DateOnly} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (use RFC 3339 or YYYY-MM-DD)", v)
+}
+
+func object(properties map[string]any, required ...string) map[string]any {
+	params := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		params["required"] = required
+	}
+	return params
+}
+
+func symbolSchema() map[string]any {
+	return map[string]any{"type": "string", "enum": domain.SupportedSymbols}
+}
+
+func intervalSchema() map[string]any {
+	return map[string]any{"type": "string", "enum": domain.SupportedIntervals}
+}
+
+func timeSchema(description string) map[string]any {
+	return map[string]any{"type": "string", "description": description + " RFC 3339 or YYYY-MM-DD, UTC."}
+}
@@ -0,0 +1,309 @@
+package advisor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/llm"
+	"bug-free-umbrella/internal/marketintel"
+	"bug-free-umbrella/internal/repository"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newToolTestService(signals SignalQuerier, sources ToolSources) *AdvisorService {
+	if signals == nil {
+		signals = &stubSignals{}
+	}
+	svc := NewAdvisorService(
+		trace.NewNoopTracerProvider().Tracer("test"),
+		llm.NewStub(), &stubPrices{}, signals, &stubConvStore{}, "gpt-4o-mini", 20,
+	)
+	svc.SetToolSources(sources)
+	svc.now = func() time.Time { return time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC) }
+	return svc
+}
+
+func toolNames(tools []tool) string {
+	names := make([]string, 0, len(tools))
+	for _, t := range tools {
+		names = append(names, t.name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestToolsOfferedOnlyWithSources(t *testing.T) {
+	svc := newToolTestService(nil, ToolSources{})
+	if got := toolNames(svc.tools(0)); got != "get_prices,list_signals" {
+		t.Fatalf("expected only prices and signals, got %s", got)
+	}
+
+	svc.SetToolSources(ToolSources{
+		Candles:     &stubCandles{},
+		Backtests:   &stubAccuracy{},
+		MarketIntel: &stubIntel{},
+	})
+	if got := toolNames(svc.tools(0)); got != "get_prices,list_signals,get_candles,get_ml_accuracy,get_market_intel" {
+		t.Fatalf("unexpected tools %s", got)
+	}
+	for _, tl := range svc.tools(0) {
+		if p := tl.param(); p.Name != tl.name || p.Description == "" || p.Parameters["type"] != "object" {
+			t.Fatalf("expected a described tool with an object schema, got %+v", p)
+		}
+	}
+}
+
+func TestRunToolValidatesArguments(t *testing.T) {
+	svc := newToolTestService(nil, ToolSources{Candles: &stubCandles{}})
+	tools := svc.tools(0)
+
+	cases := []struct {
+		name, args, want string
+	}{
+		{"nope", `{}`, `unknown tool \"nope\"`},
+		{"get_candles", `{"symbol":"FOO","interval":"4h"}`, `unsupported symbol \"FOO\"`},
+		{"get_candles", `{"symbol":"BTC","interval":"2h"}`, `unsupported interval \"2h\"`},
+		{"get_candles", `{"symbol":"BTC","interval":"1h","from":"last week"}`, `invalid time \"last week\"`},
+		{"get_candles", `{"symbol":"BTC","interval":"1h","from":"2025-03-05","to":"2025-03-04"}`, "from must be before to"},
+		{"list_signals", `not json`, "invalid arguments"},
+	}
+	for _, tc := range cases {
+		got, err := svc.runTool(context.Background(), tools, tc.name, tc.args)
+		if err == nil || !strings.Contains(got, tc.want) {
+			t.Fatalf("%s %s: expected error containing %q, got %s (%v)", tc.name, tc.args, tc.want, got, err)
+		}
+	}
+}
+
+func TestListSignalsToolBuildsFilter(t *testing.T) {
+	signals := &recordingSignals{}
+	svc := newToolTestService(signals, ToolSources{})
+
+	if _, err := svc.runTool(context.Background(), svc.tools(0), "list_signals",
+		`{"symbol":"sol","interval":"1H","indicator":"ML_XGBOOST_UP4H","since":"2025-03-01T08:00:00Z","limit":500}`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f := signals.filter
+	if f.Symbol != "SOL" || f.Interval != "1h" || f.Indicator != "ml_xgboost_up4h" || f.Limit != 10 ||
+		!f.Since.Equal(time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected filter %+v", f)
+	}
+}
+
+func TestGetCandlesToolCapsAndWarmsUp(t *testing.T) {
+	candles := &stubCandles{candles: rampCandles("ETH", "1h", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), 24*40)}
+	svc := newToolTestService(nil, ToolSources{Candles: candles})
+
+	raw, err := svc.runTool(context.Background(), svc.tools(0), "get_candles", `{"symbol":"ETH","interval":"1h","from":"2025-02-20","to":"2025-03-05"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var res candlesResult
+	if err := json.Unmarshal([]byte(raw), &res); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !res.Truncated || len(res.Candles) != maxToolCandles {
+		t.Fatalf("expected %d truncated candles, got %d (truncated=%v)", maxToolCandles, len(res.Candles), res.Truncated)
+	}
+	last := res.Candles[len(res.Candles)-1]
+	if !last.OpenTime.Equal(time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the most recent candles to be kept, last is %s", last.OpenTime)
+	}
+	if last.RSI == nil || last.MACD == nil || last.BollingerUpper == nil || last.High != last.Close+1 {
+		t.Fatalf("expected indicators and OHLCV on each candle, got %+v", last)
+	}
+	if want := time.Date(2025, 2, 20, 0, 0, 0, 0, time.UTC).Add(-indicatorWarmup * time.Hour); !candles.from.Equal(want) {
+		t.Fatalf("expected warm-up from %s, got %s", want, candles.from)
+	}
+
+	raw, err = svc.runTool(context.Background(), svc.tools(0), "get_candles", `{"symbol":"ETH","interval":"1h","from":"2025-03-01T00:00:00Z","to":"2025-03-01T05:00:00Z"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	res = candlesResult{}
+	if err := json.Unmarshal([]byte(raw), &res); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(res.Candles) != 6 || res.Truncated || res.Candles[0].RSI == nil {
+		t.Fatalf("expected 6 warmed-up candles, got %d", len(res.Candles))
+	}
+}
+
+func TestGetMLAccuracyToolRoutesBySlice(t *testing.T) {
+	backtests := &stubAccuracy{rows: []repository.DailyAccuracy{
+		{ModelKey: "xgboost", DayUTC: time.Date(2025, 3, 6, 0, 0, 0, 0, time.UTC), Total: 20, Correct: 13, Accuracy: 0.65},
+	}}
+	analytics := &stubAnalytics{report: &domain.MLAnalyticsReport{
+		Overall: domain.MLAnalyticsGroup{Metrics: domain.MLMetrics{Predictions: 40, Accuracy: 0.55}},
+		Groups:  []domain.MLAnalyticsGroup{{Key: "xgboost|SOL", Metrics: domain.MLMetrics{Predictions: 40, Accuracy: 0.55}}},
+	}}
+	svc := newToolTestService(nil, ToolSources{Backtests: backtests, MLAnalytics: analytics})
+	tools := svc.tools(0)
+
+	raw, err := svc.runTool(context.Background(), tools, "get_ml_accuracy", `{"model_key":"XGBoost","days":7}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if backtests.modelKey != "xgboost" || backtests.days != 7 || !strings.Contains(raw, `"day":"2025-03-06"`) {
+		t.Fatalf("expected daily backtest accuracy, got %s (model=%q days=%d)", raw, backtests.modelKey, backtests.days)
+	}
+
+	if _, err := svc.runTool(context.Background(), tools, "get_ml_accuracy", `{}`); err != nil || !backtests.summary {
+		t.Fatalf("expected the summary without a model, got %v", err)
+	}
+
+	raw, err = svc.runTool(context.Background(), tools, "get_ml_accuracy", `{"model_key":"xgboost","symbol":"sol"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	q := analytics.query
+	if q.Symbol != "SOL" || q.ModelKey != "xgboost" || strings.Join(q.GroupBy, ",") != "model,symbol" ||
+		q.To.Sub(q.From) != 30*24*time.Hour {
+		t.Fatalf("unexpected analytics query %+v", q)
+	}
+	if !strings.Contains(raw, `"key":"xgboost|SOL"`) || strings.Contains(raw, "calibration") {
+		t.Fatalf("expected compact per-slice metrics, got %s", raw)
+	}
+
+	svc.SetToolSources(ToolSources{Backtests: backtests})
+	if got, err := svc.runTool(context.Background(), svc.tools(0), "get_ml_accuracy", `{"symbol":"SOL"}`); err == nil {
+		t.Fatalf("expected an error without ML analytics, got %s", got)
+	}
+}
+
+func TestGetMarketIntelToolFiltersBySymbol(t *testing.T) {
+	now := time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC)
+	score := 0.8
+	intel := &stubIntel{
+		sentiment: map[string]marketintel.SourceSentimentStats{"news": {Score: 0.4, Confidence: 0.7, Count: 12}},
+		headlines: []domain.MarketIntelItem{
+			{Title: "ETH upgrade ships", Source: "news", Symbols: []string{"ETH"}, PublishedAt: now.Add(-time.Hour)},
+			{Title: "BTC ETF inflows", Source: "news", Symbols: []string{"BTC"}, PublishedAt: now.Add(-2 * time.Hour), SentimentScore: &score},
+		},
+		composites: []domain.MarketCompositeSnapshot{
+			{Symbol: "BTC", Interval: "4h", OpenTime: now.Add(-8 * time.Hour), CompositeScore: 0.1},
+			{Symbol: "BTC", Interval: "4h", OpenTime: now.Add(-4 * time.Hour), CompositeScore: 0.3, Direction: domain.DirectionLong},
+			{Symbol: "ETH", Interval: "4h", OpenTime: now.Add(-4 * time.Hour), CompositeScore: -0.2},
+		},
+	}
+	svc := newToolTestService(nil, ToolSources{MarketIntel: intel})
+
+	raw, err := svc.runTool(context.Background(), svc.tools(0), "get_market_intel", `{"symbol":"BTC","hours":48}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var res marketIntelResult
+	if err := json.Unmarshal([]byte(raw), &res); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !intel.from.Equal(now.Add(-48*time.Hour)) || intel.symbol != "BTC" || intel.interval != "4h" {
+		t.Fatalf("unexpected window %s symbol %q interval %q", intel.from, intel.symbol, intel.interval)
+	}
+	if res.Sentiment["news"].Count != 12 {
+		t.Fatalf("expected sentiment by source, got %+v", res.Sentiment)
+	}
+	if len(res.Headlines) != 1 || res.Headlines[0].Title != "BTC ETF inflows" || *res.Headlines[0].Sentiment != 0.8 {
+		t.Fatalf("expected only BTC headlines, got %+v", res.Headlines)
+	}
+	if len(res.Composites) != 1 || res.Composites[0].Score != 0.3 || res.Composites[0].Direction != domain.DirectionLong {
+		t.Fatalf("expected the latest BTC composite, got %+v", res.Composites)
+	}
+
+	raw, err = svc.runTool(context.Background(), svc.tools(0), "get_market_intel", `{}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	res = marketIntelResult{}
+	if err := json.Unmarshal([]byte(raw), &res); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if res.Sentiment != nil || len(res.Headlines) != 2 || len(res.Composites) != 2 || !intel.from.Equal(now.Add(-24*time.Hour)) {
+		t.Fatalf("expected market-wide intel for the last day, got %+v", res)
+	}
+
+	intel.err = errors.New("db down")
+	if got, err := svc.runTool(context.Background(), svc.tools(0), "get_market_intel", `{}`); err == nil || !strings.Contains(got, "db down") {
+		t.Fatalf("expected the source error, got %s", got)
+	}
+}
+
+func TestParseToolTime(t *testing.T) {
+	want := time.Date(2025, 3, 4, 16, 30, 0, 0, time.UTC)
+	for _, v := range []string{"2025-03-04T16:30:00Z", "2025-03-04T18:30:00+02:00", "2025-03-04T16:30", "2025-03-04 16:30"} {
+		got, err := parseToolTime(v)
+		if err != nil || !got.Equal(want) {
+			t.Fatalf("%s: expected %s, got %s (%v)", v, want, got, err)
+		}
+	}
+	if got, err := parseToolTime("2025-03-04"); err != nil || !got.Equal(time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected midnight for a date, got %s (%v)", got, err)
+	}
+}
+
+// --- stubs ---
+
+type recordingSignals struct {
+	filter domain.SignalFilter
+}
+
+func (s *recordingSignals) ListSignals(ctx context.Context, filter domain.SignalFilter) ([]domain.Signal, error) {
+	s.filter = filter
+	return nil, nil
+}
+
+type stubAccuracy struct {
+	rows     []repository.DailyAccuracy
+	summary  bool
+	modelKey string
+	days     int
+}
+
+func (s *stubAccuracy) GetSummary(ctx context.Context) ([]repository.DailyAccuracy, error) {
+	s.summary = true
+	return s.rows, nil
+}
+
+func (s *stubAccuracy) GetDaily(ctx context.Context, modelKey string, days int) ([]repository.DailyAccuracy, error) {
+	s.modelKey, s.days = modelKey, days
+	return s.rows, nil
+}
+
+type stubAnalytics struct {
+	report *domain.MLAnalyticsReport
+	query  domain.MLAnalyticsQuery
+}
+
+func (s *stubAnalytics) Analyze(ctx context.Context, q domain.MLAnalyticsQuery) (*domain.MLAnalyticsReport, error) {
+	s.query = q
+	return s.report, nil
+}
+
+type stubIntel struct {
+	sentiment  map[string]marketintel.SourceSentimentStats
+	headlines  []domain.MarketIntelItem
+	composites []domain.MarketCompositeSnapshot
+	err        error
+
+	symbol, interval string
+	from             time.Time
+}
+
+func (s *stubIntel) GetSentimentAverages(ctx context.Context, symbol string, from, to time.Time) (map[string]marketintel.SourceSentimentStats, error) {
+	s.symbol = symbol
+	return s.sentiment, s.err
+}
+
+func (s *stubIntel) ListTopHeadlines(ctx context.Context, from, to time.Time, limit int) ([]domain.MarketIntelItem, error) {
+	s.from = from
+	return s.headlines, s.err
+}
+
+func (s *stubIntel) ListCompositeScores(ctx context.Context, interval string, from, to time.Time) ([]domain.MarketCompositeSnapshot, error) {
+	s.interval = interval
+	return s.composites, s.err
+}
@@ -0,0 +1,586 @@
+package advisor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/llm"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// What the advisor does with figures in a reply that the data it was given
+// does not back.
+const (
+	VerifyOff = "off"
+	// VerifyAnnotate appends a note listing the unverified figures.
+	VerifyAnnotate = "annotate"
+	// VerifyCorrect replaces quoted prices that disagree with the data and
+	// annotates the rest.
+	VerifyCorrect = "correct"
+	// VerifyRegenerate asks the model once to rewrite the answer, then
+	// annotates whatever is still unverified.
+	VerifyRegenerate = "regenerate"
+)
+
+// VerifyModes lists every verification mode.
+var VerifyModes = []string{VerifyOff, VerifyAnnotate, VerifyCorrect, VerifyRegenerate}
+
+// defaultVerifyTolerance is how far, relatively, a figure may be from the
+// data and still count as backed by it.
+const defaultVerifyTolerance = 0.02
+
+// Kinds of grounding issue.
+const (
+	issuePriceMismatch = "price_mismatch"
+	issueUnsupported   = "unsupported"
+	issueUnknownSignal = "unknown_signal"
+)
+
+// Kinds of claim found in a reply.
+const (
+	claimPrice     = "price"
+	claimAmount    = "amount"
+	claimPercent   = "percent"
+	claimIndicator = "indicator"
+	claimSignal    = "signal"
+)
+
+const regeneratePrompt = `Some figures in your answer are not in the data you were given:
+%s
+Rewrite the answer using only figures from the tool results and context above. Where you do not have a number, say so instead of estimating.`
+
+var (
+	dollarPattern    = regexp.MustCompile(`\$\s?(\d+(?:,\d{3})*(?:\.\d+)?)(?:\s?([kKmMbB])\b)?|\b(\d+(?:,\d{3})*(?:\.\d+)?)\s?USDT?\b`)
+	percentPattern   = regexp.MustCompile(`([+-]?\d+(?:\.\d+)?)\s?%`)
+	rsiPattern       = regexp.MustCompile(`\bRSI\b(?:\s*\(\d+\))?[^0-9\n.$%]{0,20}?(\d+(?:\.\d+)?)\b`)
+	signalRefPattern = regexp.MustCompile(`(?i)\bsignal\s+(?:id\s*)?#?(\d+)\b|#(\d+)\b`)
+	numberPattern    = regexp.MustCompile(`-?\d+(?:,\d{3})*(?:\.\d+)?`)
+	// levelWords mark a dollar figure as a level, cost or value rather
+	// than a quoted price.
+	levelWords = regexp.MustCompile(`(?i)\b(support|resistance|target|stop|entry|level|above|below|cost|value|worth|high|low|break|from|to|between|under|over|toward|towards)\b`)
+)
+
+// groundingMetrics count verified figures and the issues found.
+type groundingMetrics struct {
+	claims metric.Int64Counter
+	issues metric.Int64Counter
+}
+
+func newGroundingMetrics() groundingMetrics {
+	meter := otel.Meter("bug-free-umbrella/internal/advisor")
+	claims, err := meter.Int64Counter("advisor.grounding.claims",
+		metric.WithDescription("Figures in advisor replies checked against the supplied data"))
+	if err != nil {
+		log.Printf("failed to create grounding claims counter: %v", err)
+	}
+	issues, err := meter.Int64Counter("advisor.grounding.failures",
+		metric.WithDescription("Figures in advisor replies the supplied data does not back"))
+	if err != nil {
+		log.Printf("failed to create grounding failures counter: %v", err)
+	}
+	return groundingMetrics{claims: claims, issues: issues}
+}
+
+// SetVerification checks each reply's prices, percentages, RSI values and
+// signal IDs against the data the model was given, and handles figures it
+// cannot back according to mode. tolerance is the relative difference still
+// accepted, e.g. 0.02; non-positive keeps the default.
+func (s *AdvisorService) SetVerification(mode string, tolerance float64) {
+	if !slices.Contains(VerifyModes, mode) {
+		mode = VerifyOff
+	}
+	s.verifyMode = mode
+	if tolerance > 0 {
+		s.verifyTolerance = tolerance
+	}
+}
+
+// verifyReply checks reply against the data in messages, the conversation
+// the model answered, and returns the reply to store.
+func (s *AdvisorService) verifyReply(ctx context.Context, chatID int64, messages []llm.Message, reply string) string {
+	if s.verifyMode == "" || s.verifyMode == VerifyOff {
+		return reply
+	}
+	ctx, span := s.tracer.Start(ctx, "advisor.verify")
+	defer span.End()
+	span.SetAttributes(attribute.String("advisor.verify_mode", s.verifyMode))
+
+	g := newGrounding(messages)
+	claims := extractClaims(reply)
+	issues := g.check(claims, s.verifyTolerance)
+	s.recordIssues(ctx, span, chatID, len(claims), issues)
+	if len(issues) == 0 {
+		return reply
+	}
+
+	switch s.verifyMode {
+	case VerifyCorrect:
+		var corrected []groundingIssue
+		reply, corrected, issues = correctPrices(reply, issues)
+		return reply + formatCorrections(corrected) + formatIssues(issues)
+	case VerifyRegenerate:
+		retry := append(slices.Clip(messages), llm.Assistant(reply), llm.User(fmt.Sprintf(regeneratePrompt, issueList(issues))))
+		msg, err := s.callLLM(ctx, retry, nil, nil)
+		if err != nil || strings.TrimSpace(msg.Content) == "" {
+			if err != nil {
+				span.RecordError(err)
+				log.Printf("failed to regenerate advisor reply for chat %d: %v", chatID, err)
+			}
+			break
+		}
+		reply = msg.Content
+		claims = extractClaims(reply)
+		issues = g.check(claims, s.verifyTolerance)
+		span.AddEvent("advisor.grounding.regenerated", trace.WithAttributes(attribute.Int("issues", len(issues))))
+		if len(issues) == 0 {
+			return reply
+		}
+	}
+	return reply + formatIssues(issues)
+}
+
+func (s *AdvisorService) recordIssues(ctx context.Context, span trace.Span, chatID int64, claims int, issues []groundingIssue) {
+	mode := attribute.String("mode", s.verifyMode)
+	if s.grounding.claims != nil {
+		s.grounding.claims.Add(ctx, int64(claims), metric.WithAttributes(mode))
+	}
+	span.SetAttributes(
+		attribute.Int("advisor.grounding.claims", claims),
+		attribute.Int("advisor.grounding.issues", len(issues)),
+	)
+	for _, is := range issues {
+		if s.grounding.issues != nil {
+			s.grounding.issues.Add(ctx, 1, metric.WithAttributes(mode, attribute.String("kind", is.kind)))
+		}
+		span.AddEvent("advisor.grounding.failure", trace.WithAttributes(
+			attribute.String("kind", is.kind),
+			attribute.String("claim", is.claim.text),
+			attribute.String("symbol", is.claim.symbol),
+			attribute.Float64("expected", is.expected),
+		))
+	}
+	if len(issues) > 0 {
+		log.Printf("advisor grounding: chat %d: %d of %d figures unverified: %s", chatID, len(issues), claims, issueList(issues))
+	}
+}
+
+// claim is a figure or reference in a reply.
+type claim struct {
+	kind   string
+	text   string
+	start  int
+	end    int
+	value  float64
+	symbol string
+}
+
+// groundingIssue is a claim the supplied data does not back. expected is
+// the supplied price for price mismatches.
+type groundingIssue struct {
+	kind     string
+	claim    claim
+	expected float64
+}
+
+// grounding is the data the model was given for one answer: tool results,
+// the system prompt and the user's messages. percents holds the values a
+// percentage may be quoted from: percent fields in tool data (changes,
+// *_pct, accuracy, win rates) and figures written with %.
+type grounding struct {
+	numbers   []float64
+	percents  []float64
+	bySymbol  map[string][]float64
+	prices    map[string]float64
+	closes    map[string]float64
+	signalIDs map[int64]bool
+}
+
+func newGrounding(messages []llm.Message) *grounding {
+	g := &grounding{
+		bySymbol:  map[string][]float64{},
+		prices:    map[string]float64{},
+		closes:    map[string]float64{},
+		signalIDs: map[int64]bool{},
+	}
+	toolNames := map[string]string{}
+	for _, m := range messages {
+		switch m.Role {
+		case llm.RoleSystem, llm.RoleUser:
+			g.addText(m.Content, "")
+		case llm.RoleAssistant:
+			// Earlier replies are not evidence; only note which tool each
+			// call was.
+			for _, call := range m.ToolCalls {
+				toolNames[call.ID] = call.Name
+			}
+		case llm.RoleTool:
+			var v any
+			if err := json.Unmarshal([]byte(m.Content), &v); err != nil {
+				g.addText(m.Content, "")
+				continue
+			}
+			g.addJSON(v, "", toolNames[m.ToolCallID] == "list_signals")
+		}
+	}
+	return g
+}
+
+// addText adds every number in text, tying each line's numbers to the
+// symbols it mentions, or to symbol.
+func (g *grounding) addText(text, symbol string) {
+	for _, line := range strings.Split(text, "\n") {
+		symbols := symbolsIn(line)
+		if symbol != "" {
+			symbols = append(symbols, symbol)
+		}
+		for _, raw := range numberPattern.FindAllString(line, -1) {
+			v, ok := parseFigure(raw)
+			if !ok {
+				continue
+			}
+			g.add(v, symbols...)
+		}
+		for _, m := range percentPattern.FindAllStringSubmatch(line, -1) {
+			if v, ok := parseFigure(m[1]); ok {
+				g.percents = append(g.percents, v)
+			}
+		}
+		for _, m := range signalRefPattern.FindAllStringSubmatch(line, -1) {
+			if id, err := strconv.ParseInt(m[1]+m[2], 10, 64); err == nil {
+				g.signalIDs[id] = true
+			}
+		}
+	}
+}
+
+// addJSON adds the numbers in a decoded tool result. Values inside an
+// object with a symbol belong to that symbol; ids inside list_signals
+// results are signal IDs.
+func (g *grounding) addJSON(v any, symbol string, signals bool) {
+	switch v := v.(type) {
+	case map[string]any:
+		if sym, ok := v["symbol"].(string); ok && sym != "" {
+			symbol = strings.ToUpper(sym)
+		}
+		for k, val := range v {
+			switch val := val.(type) {
+			case float64:
+				g.add(val, symbol)
+				if pct, ok := percentField(k, val); ok {
+					g.percents = append(g.percents, pct)
+				}
+				switch {
+				case k == "id" && signals:
+					g.signalIDs[int64(val)] = true
+				case k == "price_usd" && symbol != "":
+					g.prices[symbol] = val
+				}
+			case string:
+				if k != "symbol" {
+					g.addText(val, symbol)
+				}
+			default:
+				g.addJSON(val, symbol, signals)
+			}
+		}
+		// Candles come oldest first, so the last close seen is the latest.
+		if c, ok := v["close"].(float64); ok && symbol != "" {
+			g.closes[symbol] = c
+		}
+	case []any:
+		for _, item := range v {
+			g.addJSON(item, symbol, signals)
+		}
+	case float64:
+		g.add(v, symbol)
+	}
+}
+
+// percentField reports whether a tool result field holds a percentage and
+// returns it in percent. Changes and *_pct fields already are; accuracy and
+// win rates come as fractions.
+func percentField(key string, v float64) (float64, bool) {
+	switch {
+	case strings.HasSuffix(key, "_pct"), strings.HasPrefix(key, "change"):
+		return v, true
+	case key == "accuracy", key == "win_rate":
+		if math.Abs(v) <= 1 {
+			return v * 100, true
+		}
+		return v, true
+	}
+	return 0, false
+}
+
+func (g *grounding) add(v float64, symbols ...string) {
+	g.numbers = append(g.numbers, v)
+	for _, sym := range symbols {
+		if sym != "" {
+			g.bySymbol[sym] = append(g.bySymbol[sym], v)
+		}
+	}
+}
+
+// reference is the latest price supplied for symbol, or zero.
+func (g *grounding) reference(symbol string) float64 {
+	if p, ok := g.prices[symbol]; ok {
+		return p
+	}
+	return g.closes[symbol]
+}
+
+// check returns the claims the data does not back.
+func (g *grounding) check(claims []claim, tolerance float64) []groundingIssue {
+	var issues []groundingIssue
+	seen := map[string]bool{}
+	for _, c := range claims {
+		is, ok := g.checkClaim(c, tolerance)
+		if ok || seen[c.kind+c.symbol+c.text] {
+			continue
+		}
+		seen[c.kind+c.symbol+c.text] = true
+		issues = append(issues, is)
+	}
+	return issues
+}
+
+func (g *grounding) checkClaim(c claim, tolerance float64) (groundingIssue, bool) {
+	unsupported := groundingIssue{kind: issueUnsupported, claim: c}
+	switch c.kind {
+	case claimSignal:
+		if g.signalIDs[int64(c.value)] {
+			return groundingIssue{}, true
+		}
+		return groundingIssue{kind: issueUnknownSignal, claim: c}, false
+	case claimPercent:
+		for _, v := range g.percents {
+			if near(math.Abs(c.value), math.Abs(v), tolerance, 0.1) {
+				return groundingIssue{}, true
+			}
+		}
+		return unsupported, false
+	case claimIndicator:
+		if nearAny(c.value, g.numbers, tolerance, 0.5) {
+			return groundingIssue{}, true
+		}
+		return unsupported, false
+	case claimPrice:
+		values := g.bySymbol[c.symbol]
+		if nearAny(c.value, values, tolerance, 0) {
+			return groundingIssue{}, true
+		}
+		if ref := g.reference(c.symbol); ref > 0 {
+			return groundingIssue{kind: issuePriceMismatch, claim: c, expected: ref}, false
+		}
+		return unsupported, false
+	}
+	if nearAny(c.value, g.bySymbol[c.symbol], tolerance, 0) || nearAny(c.value, g.numbers, tolerance, 0) {
+		return groundingIssue{}, true
+	}
+	return unsupported, false
+}
+
+func near(a, b, tolerance, minAbs float64) bool {
+	return math.Abs(a-b) <= max(tolerance*math.Abs(b), minAbs)
+}
+
+func nearAny(v float64, values []float64, tolerance, minAbs float64) bool {
+	for _, x := range values {
+		if near(v, x, tolerance, minAbs) {
+			return true
+		}
+	}
+	return false
+}
+
+// extractClaims finds the dollar figures, percentages, RSI values and
+// signal references in a reply, in order.
+func extractClaims(reply string) []claim {
+	var claims []claim
+	for _, m := range dollarPattern.FindAllStringSubmatchIndex(reply, -1) {
+		raw, suffix := "", ""
+		if m[2] >= 0 {
+			raw = reply[m[2]:m[3]]
+			if m[4] >= 0 {
+				suffix = reply[m[4]:m[5]]
+			}
+		} else {
+			raw = reply[m[6]:m[7]]
+		}
+		v, ok := parseFigure(raw)
+		if !ok || v <= 0 {
+			continue
+		}
+		switch strings.ToLower(suffix) {
+		case "k":
+			v *= 1e3
+		case "m":
+			v *= 1e6
+		case "b":
+			v *= 1e9
+		}
+		c := claim{kind: claimAmount, text: reply[m[0]:m[1]], start: m[0], end: m[1], value: v}
+		symbol, gap := symbolBefore(reply, m[0])
+		c.symbol = symbol
+		if symbol != "" && len(gap) <= 30 && !levelWords.MatchString(gap) {
+			c.kind = claimPrice
+		}
+		claims = append(claims, c)
+	}
+	for _, m := range percentPattern.FindAllStringSubmatchIndex(reply, -1) {
+		if v, ok := parseFigure(reply[m[2]:m[3]]); ok {
+			claims = append(claims, claim{kind: claimPercent, text: reply[m[0]:m[1]], start: m[0], end: m[1], value: v})
+		}
+	}
+	for _, m := range rsiPattern.FindAllStringSubmatchIndex(reply, -1) {
+		if v, ok := parseFigure(reply[m[2]:m[3]]); ok && v <= 100 {
+			symbol, _ := symbolBefore(reply, m[0])
+			claims = append(claims, claim{kind: claimIndicator, text: reply[m[2]:m[3]], start: m[2], end: m[3], value: v, symbol: symbol})
+		}
+	}
+	for _, m := range signalRefPattern.FindAllStringSubmatch(reply, -1) {
+		if id, err := strconv.ParseInt(m[1]+m[2], 10, 64); err == nil {
+			claims = append(claims, claim{kind: claimSignal, text: "#" + strconv.FormatInt(id, 10), value: float64(id)})
+		}
+	}
+	return claims
+}
+
+// symbolBefore returns the last symbol mentioned in the sentence before pos
+// and the text between it and pos.
+func symbolBefore(text string, pos int) (string, string) {
+	start := 0
+	for _, sep := range []string{". ", "! ", "? ", "\n"} {
+		if i := strings.LastIndex(text[:pos], sep); i >= 0 && i+len(sep) > start {
+			start = i + len(sep)
+		}
+	}
+	sentence := text[start:pos]
+	best, bestEnd := "", -1
+	for _, loc := range wordPattern.FindAllStringIndex(sentence, -1) {
+		if sym := symbolOf(sentence[loc[0]:loc[1]]); sym != "" && loc[1] > bestEnd {
+			best, bestEnd = sym, loc[1]
+		}
+	}
+	if best == "" {
+		return "", ""
+	}
+	return best, sentence[bestEnd:]
+}
+
+var wordPattern = regexp.MustCompile(`[A-Za-z]+`)
+
+// symbolOf maps a word to a tracked symbol: the symbol itself in capitals,
+// or the coin's name.
+func symbolOf(word string) string {
+	if _, ok := domain.CoinGeckoID[word]; ok {
+		return word
+	}
+	return domain.CoinGeckoIDToSymbol[strings.ToLower(word)]
+}
+
+func symbolsIn(line string) []string {
+	var out []string
+	for _, w := range wordPattern.FindAllString(line, -1) {
+		if sym := symbolOf(w); sym != "" && !slices.Contains(out, sym) {
+			out = append(out, sym)
+		}
+	}
+	return out
+}
+
+func parseFigure(raw string) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.ReplaceAll(raw, ",", ""), 64)
+	return v, err == nil
+}
+
+// correctPrices replaces mismatched quoted prices with the supplied ones
+// and returns the reply, the corrected issues and those left.
+func correctPrices(reply string, issues []groundingIssue) (string, []groundingIssue, []groundingIssue) {
+	var corrected, rest []groundingIssue
+	for _, is := range issues {
+		if is.kind == issuePriceMismatch {
+			corrected = append(corrected, is)
+		} else {
+			rest = append(rest, is)
+		}
+	}
+	// Replace from the end so earlier offsets stay valid. Repeated quotes
+	// of the same figure were deduplicated, so replace each occurrence.
+	for _, c := range slices.Backward(extractClaims(reply)) {
+		for _, is := range corrected {
+			if c.kind == claimPrice && c.symbol == is.claim.symbol && c.text == is.claim.text {
+				reply = reply[:c.start] + formatUSD(is.expected) + reply[c.end:]
+				break
+			}
+		}
+	}
+	return reply, corrected, rest
+}
+
+func formatCorrections(corrected []groundingIssue) string {
+	if len(corrected) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(corrected))
+	for _, is := range corrected {
+		parts = append(parts, fmt.Sprintf("%s %s to %s", is.claim.symbol, is.claim.text, formatUSD(is.expected)))
+	}
+	return "\n\nCorrected from the latest data: " + strings.Join(parts, "; ") + "."
+}
+
+func formatIssues(issues []groundingIssue) string {
+	if len(issues) == 0 {
+		return ""
+	}
+	return "\n\nNote: I could not verify these figures against the data I looked up: " + issueList(issues) + ". Check them before acting."
+}
+
+func issueList(issues []groundingIssue) string {
+	parts := make([]string, 0, len(issues))
+	for _, is := range issues {
+		switch {
+		case is.kind == issuePriceMismatch:
+			parts = append(parts, fmt.Sprintf("%s %s (data shows %s)", is.claim.symbol, is.claim.text, formatUSD(is.expected)))
+		case is.kind == issueUnknownSignal:
+			parts = append(parts, fmt.Sprintf("signal %s (not found)", is.claim.text))
+		case is.claim.kind == claimIndicator:
+			parts = append(parts, strings.TrimSpace(is.claim.symbol+" RSI "+is.claim.text))
+		case is.claim.symbol != "" && is.claim.kind != claimPercent:
+			parts = append(parts, is.claim.symbol+" "+is.claim.text)
+		default:
+			parts = append(parts, is.claim.text)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatUSD writes a price with thousands separators, two decimals above a
+// dollar and four below.
+func formatUSD(v float64) string {
+	if v < 1 {
+		return fmt.Sprintf("$%.4f", v)
+	}
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	whole, frac, _ := strings.Cut(s, ".")
+	var sb strings.Builder
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(r)
+	}
+	return "$" + sb.String() + "." + frac
+}
@@ -0,0 +1,238 @@
+package advisor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/llm"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestExtractClaims(t *testing.T) {
+	reply := "BTC is trading at $67,412, up 3.2% today. Ethereum trades at $3.1k. " +
+		"Signal #42 fired; BTC support sits near $60,000. The 4h RSI is 71.5."
+	claims := extractClaims(reply)
+
+	want := []struct {
+		kind   string
+		text   string
+		value  float64
+		symbol string
+	}{
+		{claimPrice, "$67,412", 67412, "BTC"},
+		{claimPrice, "$3.1k", 3100, "ETH"},
+		{claimAmount, "$60,000", 60000, "BTC"},
+		{claimPercent, "3.2%", 3.2, ""},
+		{claimIndicator, "71.5", 71.5, ""},
+		{claimSignal, "#42", 42, ""},
+	}
+	if len(claims) != len(want) {
+		t.Fatalf("expected %d claims, got %+v", len(want), claims)
+	}
+	for i, w := range want {
+		c := claims[i]
+		if c.kind != w.kind || c.text != w.text || c.value != w.value || c.symbol != w.symbol {
+			t.Fatalf("claim %d: expected %+v, got %+v", i, w, c)
+		}
+	}
+}
+
+func TestGroundingCheck(t *testing.T) {
+	messages := []llm.Message{
+		llm.System("User Holdings:\n  SOL: 10 units, value $1500.00\n"),
+		llm.User("what about BTC and signal #7?"),
+		{Role: llm.RoleAssistant, ToolCalls: []llm.ToolCall{
+			{ID: "c1", Name: "get_prices"},
+			{ID: "c2", Name: "list_signals"},
+			{ID: "c3", Name: "get_candles"},
+		}},
+		llm.ToolResult("c1", `[{"symbol":"BTC","price_usd":67000,"change_24h_pct":-3.24}]`),
+		llm.ToolResult("c2", `[{"id":42,"symbol":"BTC","details":"RSI 28.4 crossed 30"}]`),
+		llm.ToolResult("c3", `{"symbol":"ETH","candles":[{"close":3000,"rsi":55.2},{"close":3100,"rsi":61}]}`),
+	}
+	g := newGrounding(messages)
+	if g.reference("BTC") != 67000 || g.reference("ETH") != 3100 {
+		t.Fatalf("unexpected reference prices BTC=%v ETH=%v", g.reference("BTC"), g.reference("ETH"))
+	}
+
+	cases := []struct {
+		reply string
+		kind  string
+	}{
+		{"BTC is at $67,100.", ""},
+		{"BTC is at $72,000.", issuePriceMismatch},
+		{"ETH closed at $3,000 yesterday.", ""},
+		{"ETH is at $3,500.", issuePriceMismatch},
+		{"DOGE is at $0.15.", issueUnsupported},
+		{"Your SOL is worth $1,500.", ""},
+		{"BTC is down 3.2% today.", ""},
+		{"BTC is down 7% today.", issueUnsupported},
+		{"ETH's RSI is 61 and the signal RSI was 28.4.", ""},
+		{"ETH's RSI is 80.", issueUnsupported},
+		{"Signal #42 and signal #7 both fired.", ""},
+		{"Signal #99 fired.", issueUnknownSignal},
+	}
+	for _, tc := range cases {
+		issues := g.check(extractClaims(tc.reply), defaultVerifyTolerance)
+		switch {
+		case tc.kind == "" && len(issues) != 0:
+			t.Fatalf("%q: expected no issues, got %+v", tc.reply, issues)
+		case tc.kind != "" && (len(issues) != 1 || issues[0].kind != tc.kind):
+			t.Fatalf("%q: expected one %s issue, got %+v", tc.reply, tc.kind, issues)
+		}
+	}
+}
+
+func TestGroundingPercentsComeFromPercentFields(t *testing.T) {
+	g := newGrounding([]llm.Message{
+		{Role: llm.RoleAssistant, ToolCalls: []llm.ToolCall{{ID: "c1", Name: "get_ml_accuracy"}}},
+		llm.ToolResult("c1", `{"symbol":"BTC","price_usd":45,"rsi":38,"accuracy":0.62,"win_rate":0.4,"return_pct":5.5,"samples":12,"note":"drawdown 8% last week"}`),
+	})
+	cases := []struct {
+		reply string
+		ok    bool
+	}{
+		{"The model is right 62% of the time.", true},
+		{"Win rate is 40%.", true},
+		{"Returns were 5.5%.", true},
+		{"The drawdown was 8%.", true},
+		{"BTC fell 45%.", false},
+		{"RSI moved 38%.", false},
+		{"That is 12% of the sample.", false},
+		{"Accuracy improved 0.6%.", false},
+	}
+	for _, tc := range cases {
+		issues := g.check(extractClaims(tc.reply), defaultVerifyTolerance)
+		if (len(issues) == 0) != tc.ok {
+			t.Fatalf("%q: expected grounded=%v, got issues %+v", tc.reply, tc.ok, issues)
+		}
+	}
+}
+
+func newVerifyTestService(stub *llm.Stub, mode string) (*AdvisorService, *stubConvStore) {
+	store := &stubConvStore{}
+	svc := NewAdvisorService(
+		trace.NewNoopTracerProvider().Tracer("test"),
+		stub, &stubPrices{allPrices: []*domain.PriceSnapshot{{Symbol: "BTC", PriceUSD: 67000}}},
+		&stubSignals{}, store, "gpt-4o-mini", 20,
+	)
+	svc.SetVerification(mode, 0)
+	return svc, store
+}
+
+func TestAskVerificationOffKeepsReply(t *testing.T) {
+	stub := llm.NewStub(textResponse("BTC is at $72,000."))
+	svc, _ := newVerifyTestService(stub, "bogus")
+
+	reply, err := svc.Ask(context.Background(), 1, "btc?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reply != "BTC is at $72,000." {
+		t.Fatalf("expected the reply untouched, got %q", reply)
+	}
+}
+
+func TestAskAnnotatesUnverifiedFigures(t *testing.T) {
+	stub := llm.NewStub(
+		toolCallResponse("", toolCall("c1", "get_prices", `{}`)),
+		textResponse("BTC is at $72,000 and signal #7 fired."),
+	)
+	svc, store := newVerifyTestService(stub, VerifyAnnotate)
+
+	reply, err := svc.Ask(context.Background(), 1, "btc?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(reply, "BTC is at $72,000 and signal #7 fired.\n\nNote:") ||
+		!strings.Contains(reply, "BTC $72,000 (data shows $67,000.00)") || !strings.Contains(reply, "signal #7 (not found)") {
+		t.Fatalf("expected an annotated reply, got %q", reply)
+	}
+	if stored := store.messages[len(store.messages)-1]; stored.role != "assistant" || stored.content != reply {
+		t.Fatalf("expected the annotated reply stored, got %+v", stored)
+	}
+}
+
+func TestAskCorrectsQuotedPrice(t *testing.T) {
+	stub := llm.NewStub(
+		toolCallResponse("", toolCall("c1", "get_prices", `{}`)),
+		textResponse("BTC is at $72,000. It could retest $72,000 resistance."),
+	)
+	svc, _ := newVerifyTestService(stub, VerifyCorrect)
+
+	reply, err := svc.Ask(context.Background(), 1, "btc?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "BTC is at $67,000.00. It could retest $72,000 resistance.\n\n" +
+		"Corrected from the latest data: BTC $72,000 to $67,000.00.\n\n" +
+		"Note: I could not verify these figures against the data I looked up: $72,000. Check them before acting."
+	if reply != want {
+		t.Fatalf("unexpected corrected reply:\n%q\nwant\n%q", reply, want)
+	}
+}
+
+func TestAskRegeneratesUngroundedReply(t *testing.T) {
+	stub := llm.NewStub(
+		toolCallResponse("", toolCall("c1", "get_prices", `{}`)),
+		textResponse("BTC is at $72,000."),
+		textResponse("BTC is at $67,000."),
+	)
+	svc, _ := newVerifyTestService(stub, VerifyRegenerate)
+
+	reply, err := svc.Ask(context.Background(), 1, "btc?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reply != "BTC is at $67,000." {
+		t.Fatalf("expected the regenerated reply, got %q", reply)
+	}
+	reqs := stub.Requests()
+	if len(reqs) != 3 || len(reqs[2].Tools) != 0 {
+		t.Fatalf("expected one regeneration request without tools, got %d requests", len(reqs))
+	}
+	if feedback := lastMessage(reqs[2]).Content; !strings.Contains(feedback, "BTC $72,000 (data shows $67,000.00)") {
+		t.Fatalf("expected the issues in the feedback, got %q", feedback)
+	}
+
+	stub = llm.NewStub(
+		toolCallResponse("", toolCall("c1", "get_prices", `{}`)),
+		textResponse("BTC is at $72,000."),
+		textResponse("BTC is at $71,000."),
+	)
+	svc, _ = newVerifyTestService(stub, VerifyRegenerate)
+	reply, err = svc.Ask(context.Background(), 1, "btc?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(reply, "BTC is at $71,000.\n\nNote:") {
+		t.Fatalf("expected the still-wrong regeneration annotated, got %q", reply)
+	}
+}
+
+func TestListSignalsToolIncludesIDs(t *testing.T) {
+	svc := newToolTestService(&stubSignals{signals: []domain.Signal{{ID: 42, Symbol: "BTC"}}}, ToolSources{})
+	raw, err := svc.runTool(context.Background(), svc.tools(0), "list_signals", `{}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(raw, `"id":42`) {
+		t.Fatalf("expected signal IDs in the result, got %s", raw)
+	}
+}
+
+func TestFormatUSD(t *testing.T) {
+	for v, want := range map[float64]string{
+		67412.5:   "$67,412.50",
+		1234567.0: "$1,234,567.00",
+		3.1:       "$3.10",
+		0.15342:   "$0.1534",
+	} {
+		if got := formatUSD(v); got != want {
+			t.Fatalf("formatUSD(%v) = %q, want %q", v, got, want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package backtest
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+// CompareRuns diffs two runs metric by metric, in a fixed order, and lists
+// the parameters that differ between them.
+func CompareRuns(a, b domain.BacktestRun) domain.BacktestRunComparison {
+	ma, mb := metricValues(a.Metrics), metricValues(b.Metrics)
+	metrics := make([]domain.MetricDiff, 0, len(ma))
+	for i := range ma {
+		metrics = append(metrics, domain.MetricDiff{
+			Metric: ma[i].name,
+			A:      ma[i].value,
+			B:      mb[i].value,
+			Delta:  mb[i].value - ma[i].value,
+		})
+	}
+
+	pa, pb := paramValues(a), paramValues(b)
+	params := make([]domain.ParamDiff, 0)
+	for i := range pa {
+		if pa[i].value != pb[i].value {
+			params = append(params, domain.ParamDiff{Param: pa[i].name, A: pa[i].value, B: pb[i].value})
+		}
+	}
+
+	a.Equity, a.Trades = nil, nil
+	b.Equity, b.Trades = nil, nil
+	return domain.BacktestRunComparison{A: a, B: b, Metrics: metrics, Params: params}
+}
+
+type namedMetric struct {
+	name  string
+	value float64
+}
+
+func metricValues(m domain.BacktestMetrics) []namedMetric {
+	return []namedMetric{
+		{"trades", float64(m.Trades)},
+		{"wins", float64(m.Wins)},
+		{"losses", float64(m.Losses)},
+		{"win_rate", m.WinRate},
+		{"profit_factor", m.ProfitFactor},
+		{"total_return", m.TotalReturn},
+		{"avg_trade_return", m.AvgTradeReturn},
+		{"sharpe", m.Sharpe},
+		{"sortino", m.Sortino},
+		{"max_drawdown", m.MaxDrawdown},
+		{"final_equity", m.FinalEquity},
+	}
+}
+
+type namedParam struct {
+	name  string
+	value string
+}
+
+func paramValues(r domain.BacktestRun) []namedParam {
+	c := r.Config
+	float := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	out := []namedParam{
+		{"engine_version", r.EngineVersion},
+		{"symbol", c.Symbol},
+		{"interval", c.Interval},
+		{"from", c.From.UTC().Format(time.RFC3339)},
+		{"to", c.To.UTC().Format(time.RFC3339)},
+		{"indicators", strings.Join(c.Indicators, ",")},
+		{"max_risk", strconv.Itoa(int(c.MaxRisk))},
+		{"fee_bps", float(c.FeeBps)},
+		{"slippage_bps", float(c.SlippageBps)},
+		{"hold_bars", strconv.Itoa(c.HoldBars)},
+		{"use_stops", strconv.FormatBool(c.UseStops)},
+		{"allow_short", strconv.FormatBool(c.AllowShort)},
+		{"initial_equity", float(c.InitialEquity)},
+	}
+	// Signal parameters are listed one by one; runs stored before they were
+	// recorded show them as empty.
+	var values map[string]float64
+	if c.Params != nil {
+		values = c.Params.Values()
+	}
+	for _, name := range domain.SignalParamNames() {
+		value := ""
+		if values != nil {
+			value = float(values[name])
+		}
+		out = append(out, namedParam{"params." + name, value})
+	}
+	return out
+}
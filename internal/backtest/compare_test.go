@@ -0,0 +1,69 @@
+package backtest
+
+import (
+	"strings"
+	"testing"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+func TestCompareRunsDiffsMetricsAndParams(t *testing.T) {
+	a := domain.BacktestRun{
+		ID: 1, EngineVersion: "engine/1",
+		Config:  domain.BacktestConfig{Symbol: "BTC", Interval: "1h", FeeBps: 10, HoldBars: 24, UseStops: true},
+		Metrics: domain.BacktestMetrics{Trades: 10, WinRate: 0.5, TotalReturn: 0.04, MaxDrawdown: 0.1},
+		Equity:  []domain.EquityPoint{{Equity: 1}},
+	}
+	b := a
+	b.ID = 2
+	b.Config.HoldBars = 12
+	b.Metrics = domain.BacktestMetrics{Trades: 14, WinRate: 0.6, TotalReturn: 0.02, MaxDrawdown: 0.05}
+
+	cmp := CompareRuns(a, b)
+	if cmp.A.ID != 1 || cmp.B.ID != 2 {
+		t.Fatalf("unexpected run ids: %d %d", cmp.A.ID, cmp.B.ID)
+	}
+	if cmp.A.Equity != nil {
+		t.Fatal("expected equity stripped from comparison")
+	}
+	byName := make(map[string]domain.MetricDiff, len(cmp.Metrics))
+	for _, d := range cmp.Metrics {
+		byName[d.Metric] = d
+	}
+	if d := byName["trades"]; d.A != 10 || d.B != 14 || d.Delta != 4 {
+		t.Fatalf("unexpected trades diff: %+v", d)
+	}
+	if d := byName["total_return"]; d.Delta > -0.019 || d.Delta < -0.021 {
+		t.Fatalf("unexpected total_return diff: %+v", d)
+	}
+	if cmp.Metrics[0].Metric != "trades" || len(cmp.Metrics) != 11 {
+		t.Fatalf("expected fixed metric order, got %+v", cmp.Metrics)
+	}
+	if len(cmp.Params) != 1 || cmp.Params[0].Param != "hold_bars" || cmp.Params[0].A != "24" || cmp.Params[0].B != "12" {
+		t.Fatalf("expected only hold_bars to differ, got %+v", cmp.Params)
+	}
+}
+
+func TestCompareRunsDiffsSignalParams(t *testing.T) {
+	pa := domain.SignalParams{RSIPeriod: 14, MACDFast: 12}
+	pb := pa
+	pb.RSIPeriod = 10
+	a := domain.BacktestRun{Config: domain.BacktestConfig{Symbol: "BTC", Params: &pa}}
+	b := domain.BacktestRun{Config: domain.BacktestConfig{Symbol: "BTC", Params: &pb}}
+
+	cmp := CompareRuns(a, b)
+	if len(cmp.Params) != 1 || cmp.Params[0].Param != "params.rsi_period" || cmp.Params[0].A != "14" || cmp.Params[0].B != "10" {
+		t.Fatalf("expected only rsi_period to differ, got %+v", cmp.Params)
+	}
+
+	b.Config.Params = nil
+	if cmp := CompareRuns(a, b); len(cmp.Params) != len(domain.SignalParamNames()) || cmp.Params[0].B != "" {
+		t.Fatalf("expected every param to differ from an unrecorded set, got %+v", cmp.Params)
+	}
+}
+
+func TestVersionIncludesEngineVersion(t *testing.T) {
+	if v := Version(); !strings.HasPrefix(v, "engine/"+EngineVersion) {
+		t.Fatalf("unexpected version %q", v)
+	}
+}
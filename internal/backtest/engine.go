@@ -0,0 +1,278 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+const (
+	// DefaultLookback matches the candle window the live signal poller hands
+	// to the engine, so simulated signals see the same history they would in
+	// production.
+	DefaultLookback = 250
+
+	defaultHoldBars      = 24
+	defaultInitialEquity = 10_000
+)
+
+// SignalGenerator is the slice of signal.Engine the simulator needs.
+type SignalGenerator interface {
+	Generate(candles []*domain.Candle) []domain.Signal
+}
+
+// Runner replays candles through a signal generator and simulates one
+// position at a time.
+type Runner struct {
+	generator SignalGenerator
+	lookback  int
+}
+
+func NewRunner(generator SignalGenerator, lookback int) *Runner {
+	if lookback <= 0 {
+		lookback = DefaultLookback
+	}
+	return &Runner{generator: generator, lookback: lookback}
+}
+
+type position struct {
+	signal     domain.Signal
+	entryIdx   int
+	entryPrice float64
+	units      float64
+	entryFee   float64
+}
+
+// Run simulates cfg over candles. Candles before cfg.From are only used as
+// indicator warm-up. At each bar the generator sees candles up to and
+// including that bar's close; any entry fills at the next bar's open, so no
+// decision uses data it could not have had.
+func (r *Runner) Run(candles []*domain.Candle, cfg domain.BacktestConfig) (*domain.BacktestResult, error) {
+	if r.generator == nil {
+		return nil, fmt.Errorf("backtest runner has no signal generator")
+	}
+	cfg = applyDefaults(cfg)
+	series := sortedCandles(candles)
+
+	start := 0
+	for start < len(series) && !cfg.From.IsZero() && series[start].OpenTime.Before(cfg.From) {
+		start++
+	}
+	end := len(series)
+	for end > start && !cfg.To.IsZero() && series[end-1].OpenTime.After(cfg.To) {
+		end--
+	}
+	if end-start < 2 {
+		return nil, fmt.Errorf("not enough candles in backtest window: %d", end-start)
+	}
+
+	fee := cfg.FeeBps / 10_000
+	slip := cfg.SlippageBps / 10_000
+	cash := cfg.InitialEquity
+
+	result := &domain.BacktestResult{
+		EngineVersion: Version(),
+		Config:        cfg,
+		Trades:        make([]domain.BacktestTrade, 0),
+		Equity:        make([]domain.EquityPoint, 0, end-start),
+	}
+
+	var open *position
+	var pending *domain.Signal
+
+	closePosition := func(idx int, price float64, reason string) {
+		p := open
+		exitFee := p.units * price * fee
+		sign := directionSign(p.signal.Direction)
+		pnl := sign*p.units*(price-p.entryPrice) - p.entryFee - exitFee
+		cash += pnl
+		trade := domain.BacktestTrade{
+			Indicator:  p.signal.Indicator,
+			Direction:  p.signal.Direction,
+			Risk:       p.signal.Risk,
+			EntryTime:  series[p.entryIdx].OpenTime,
+			ExitTime:   series[idx].OpenTime,
+			EntryPrice: p.entryPrice,
+			ExitPrice:  price,
+			ExitReason: reason,
+			Bars:       idx - p.entryIdx + 1,
+			ReturnPct:  pnl / (p.units * p.entryPrice),
+			PnL:        pnl,
+			Fees:       p.entryFee + exitFee,
+		}
+		if l := p.signal.Levels; cfg.UseStops && l != nil {
+			trade.Stop = l.Stop
+			if len(l.Targets) > 0 {
+				trade.Target = l.Targets[0]
+			}
+		}
+		result.Trades = append(result.Trades, trade)
+		open = nil
+	}
+
+	for t := start; t < end; t++ {
+		bar := series[t]
+
+		if pending != nil && open == nil {
+			price := bar.Open
+			if price <= 0 {
+				price = series[t-1].Close
+			}
+			sign := directionSign(pending.Direction)
+			price *= 1 + sign*slip
+			units := cash / price
+			open = &position{
+				signal:     *pending,
+				entryIdx:   t,
+				entryPrice: price,
+				units:      units,
+				entryFee:   units * price * fee,
+			}
+		}
+		pending = nil
+
+		if open != nil {
+			sign := directionSign(open.signal.Direction)
+			if price, reason, ok := levelExit(open, bar, cfg.UseStops); ok {
+				closePosition(t, price*(1-sign*slip), reason)
+			} else if t-open.entryIdx+1 >= cfg.HoldBars {
+				closePosition(t, bar.Close*(1-sign*slip), domain.ExitReasonTime)
+			}
+		}
+
+		if open == nil && t < end-1 {
+			lo := t + 1 - r.lookback
+			if lo < 0 {
+				lo = 0
+			}
+			pending = pickSignal(r.generator.Generate(series[lo:t+1]), cfg)
+		}
+
+		equity := cash
+		if open != nil {
+			sign := directionSign(open.signal.Direction)
+			equity += sign*open.units*(bar.Close-open.entryPrice) - open.entryFee
+		}
+		result.Equity = append(result.Equity, domain.EquityPoint{Time: bar.OpenTime, Equity: equity})
+	}
+
+	if open != nil {
+		sign := directionSign(open.signal.Direction)
+		closePosition(end-1, series[end-1].Close*(1-sign*slip), domain.ExitReasonEnd)
+		result.Equity[len(result.Equity)-1].Equity = cash
+	}
+
+	result.Metrics = ComputeMetrics(result.Trades, result.Equity, cfg.InitialEquity, cfg.Interval)
+	return result, nil
+}
+
+// levelExit checks a bar against the position's stop and first target. When
+// both are inside the bar the stop wins; gaps through a level fill at the open.
+func levelExit(p *position, bar *domain.Candle, useStops bool) (float64, string, bool) {
+	l := p.signal.Levels
+	if !useStops || l == nil || len(l.Targets) == 0 {
+		return 0, "", false
+	}
+	high, low := bar.High, bar.Low
+	if high <= 0 || low <= 0 || high < low {
+		high, low = bar.Close, bar.Close
+	}
+	target := l.Targets[0]
+	switch p.signal.Direction {
+	case domain.DirectionLong:
+		if low <= l.Stop {
+			return math.Min(l.Stop, openOr(bar, l.Stop)), domain.ExitReasonStop, true
+		}
+		if high >= target {
+			return math.Max(target, openOr(bar, target)), domain.ExitReasonTarget, true
+		}
+	case domain.DirectionShort:
+		if high >= l.Stop {
+			return math.Max(l.Stop, openOr(bar, l.Stop)), domain.ExitReasonStop, true
+		}
+		if low <= target {
+			return math.Min(target, openOr(bar, target)), domain.ExitReasonTarget, true
+		}
+	}
+	return 0, "", false
+}
+
+func openOr(bar *domain.Candle, fallback float64) float64 {
+	if bar.Open > 0 {
+		return bar.Open
+	}
+	return fallback
+}
+
+// pickSignal chooses the entry among the signals fired on one bar: the lowest
+// risk level that passes the config filters, first-generated on ties.
+func pickSignal(signals []domain.Signal, cfg domain.BacktestConfig) *domain.Signal {
+	var best *domain.Signal
+	for i := range signals {
+		s := signals[i]
+		switch s.Direction {
+		case domain.DirectionLong:
+		case domain.DirectionShort:
+			if !cfg.AllowShort {
+				continue
+			}
+		default:
+			continue
+		}
+		if cfg.MaxRisk > 0 && s.Risk > cfg.MaxRisk {
+			continue
+		}
+		if len(cfg.Indicators) > 0 && !containsString(cfg.Indicators, s.Indicator) {
+			continue
+		}
+		if best == nil || s.Risk < best.Risk {
+			best = &signals[i]
+		}
+	}
+	return best
+}
+
+func applyDefaults(cfg domain.BacktestConfig) domain.BacktestConfig {
+	if cfg.HoldBars <= 0 {
+		cfg.HoldBars = defaultHoldBars
+	}
+	if cfg.InitialEquity <= 0 {
+		cfg.InitialEquity = defaultInitialEquity
+	}
+	if cfg.FeeBps < 0 {
+		cfg.FeeBps = 0
+	}
+	if cfg.SlippageBps < 0 {
+		cfg.SlippageBps = 0
+	}
+	return cfg
+}
+
+func sortedCandles(in []*domain.Candle) []*domain.Candle {
+	out := make([]*domain.Candle, 0, len(in))
+	for _, c := range in {
+		if c != nil {
+			out = append(out, c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].OpenTime.Before(out[j].OpenTime) })
+	return out
+}
+
+func directionSign(d domain.SignalDirection) float64 {
+	if d == domain.DirectionShort {
+		return -1
+	}
+	return 1
+}
+
+func containsString(values []string, v string) bool {
+	for _, candidate := range values {
+		if candidate == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/signal"
+)
+
+// scriptedGenerator fires a fixed signal when the newest candle it is shown
+// opens at one of the scripted times, and records the newest candle it saw.
+type scriptedGenerator struct {
+	at      map[time.Time]domain.Signal
+	calls   int
+	maxSeen time.Time
+}
+
+func (g *scriptedGenerator) Generate(candles []*domain.Candle) []domain.Signal {
+	g.calls++
+	last := candles[len(candles)-1].OpenTime
+	if last.After(g.maxSeen) {
+		g.maxSeen = last
+	}
+	if s, ok := g.at[last]; ok {
+		return []domain.Signal{s}
+	}
+	return nil
+}
+
+func flatCandles(n int, price float64) []*domain.Candle {
+	base := time.Unix(0, 0).UTC()
+	out := make([]*domain.Candle, 0, n)
+	for i := 0; i < n; i++ {
+		out = append(out, &domain.Candle{
+			Symbol: "BTC", Interval: "1h", OpenTime: base.Add(time.Duration(i) * time.Hour),
+			Open: price, High: price + 1, Low: price - 1, Close: price, Volume: 100,
+		})
+	}
+	return out
+}
+
+func TestRunTimeExitAppliesFeesAndSlippage(t *testing.T) {
+	candles := flatCandles(20, 100)
+	for i := 6; i < 20; i++ {
+		candles[i].Open, candles[i].Close, candles[i].High, candles[i].Low = 110, 110, 111, 109
+	}
+	gen := &scriptedGenerator{at: map[time.Time]domain.Signal{
+		candles[4].OpenTime: {Indicator: domain.IndicatorRSI, Direction: domain.DirectionLong, Risk: domain.RiskLevel2},
+	}}
+
+	res, err := NewRunner(gen, 10).Run(candles, domain.BacktestConfig{
+		Interval: "1h", HoldBars: 3, FeeBps: 10, SlippageBps: 0, InitialEquity: 1000,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Trades) != 1 {
+		t.Fatalf("expected 1 trade, got %d", len(res.Trades))
+	}
+	tr := res.Trades[0]
+	if !tr.EntryTime.Equal(candles[5].OpenTime) || tr.EntryPrice != 100 {
+		t.Fatalf("expected entry at next bar open (100), got %s %.2f", tr.EntryTime, tr.EntryPrice)
+	}
+	if tr.ExitReason != domain.ExitReasonTime || tr.Bars != 3 || tr.ExitPrice != 110 {
+		t.Fatalf("unexpected exit: %+v", tr)
+	}
+	units := 1000.0 / 100
+	wantFees := units*100*0.001 + units*110*0.001
+	if math.Abs(tr.Fees-wantFees) > 1e-9 {
+		t.Fatalf("expected fees %.4f, got %.4f", wantFees, tr.Fees)
+	}
+	if math.Abs(tr.PnL-(units*10-wantFees)) > 1e-9 {
+		t.Fatalf("unexpected pnl %.4f", tr.PnL)
+	}
+	if math.Abs(res.Metrics.FinalEquity-(1000+tr.PnL)) > 1e-9 {
+		t.Fatalf("expected final equity to include pnl, got %.4f", res.Metrics.FinalEquity)
+	}
+	if res.Metrics.WinRate != 1 || res.Metrics.ProfitFactor != maxProfitFactor {
+		t.Fatalf("unexpected metrics: %+v", res.Metrics)
+	}
+}
+
+func TestRunNeverShowsFutureCandles(t *testing.T) {
+	candles := flatCandles(30, 100)
+	gen := &scriptedGenerator{}
+	runner := NewRunner(gen, 5)
+
+	if _, err := runner.Run(candles, domain.BacktestConfig{Interval: "1h"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gen.calls != len(candles)-1 {
+		t.Fatalf("expected one generate call per bar except the last, got %d", gen.calls)
+	}
+	if !gen.maxSeen.Equal(candles[len(candles)-2].OpenTime) {
+		t.Fatalf("generator saw a candle beyond the last decision bar: %s", gen.maxSeen)
+	}
+}
+
+func TestRunStopAndTargetExits(t *testing.T) {
+	candles := flatCandles(20, 100)
+	candles[7].Low = 94 // stop for the first trade
+	candles[12].High = 112
+	gen := &scriptedGenerator{at: map[time.Time]domain.Signal{
+		candles[4].OpenTime: {Indicator: domain.IndicatorMACD, Direction: domain.DirectionLong,
+			Levels: &domain.SignalLevels{Entry: 100, Stop: 95, Targets: []float64{110}}},
+		candles[9].OpenTime: {Indicator: domain.IndicatorMACD, Direction: domain.DirectionLong,
+			Levels: &domain.SignalLevels{Entry: 100, Stop: 95, Targets: []float64{110}}},
+	}}
+
+	res, err := NewRunner(gen, 10).Run(candles, domain.BacktestConfig{Interval: "1h", HoldBars: 50, UseStops: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Trades) != 2 {
+		t.Fatalf("expected 2 trades, got %+v", res.Trades)
+	}
+	if res.Trades[0].ExitReason != domain.ExitReasonStop || res.Trades[0].ExitPrice != 95 {
+		t.Fatalf("expected stop exit at 95, got %+v", res.Trades[0])
+	}
+	if res.Trades[1].ExitReason != domain.ExitReasonTarget || res.Trades[1].ExitPrice != 110 {
+		t.Fatalf("expected target exit at 110, got %+v", res.Trades[1])
+	}
+	if res.Metrics.Wins != 1 || res.Metrics.Losses != 1 {
+		t.Fatalf("unexpected win/loss: %+v", res.Metrics)
+	}
+	if res.Metrics.MaxDrawdown <= 0 {
+		t.Fatalf("expected drawdown from the stopped trade, got %.4f", res.Metrics.MaxDrawdown)
+	}
+}
+
+func TestRunFiltersSignals(t *testing.T) {
+	candles := flatCandles(20, 100)
+	gen := &scriptedGenerator{at: map[time.Time]domain.Signal{
+		candles[3].OpenTime: {Indicator: domain.IndicatorRSI, Direction: domain.DirectionShort, Risk: domain.RiskLevel1},
+		candles[5].OpenTime: {Indicator: domain.IndicatorRSI, Direction: domain.DirectionLong, Risk: domain.RiskLevel5},
+		candles[7].OpenTime: {Indicator: domain.IndicatorMACD, Direction: domain.DirectionLong, Risk: domain.RiskLevel2},
+		candles[9].OpenTime: {Indicator: domain.IndicatorRSI, Direction: domain.DirectionLong, Risk: domain.RiskLevel2},
+	}}
+	res, err := NewRunner(gen, 10).Run(candles, domain.BacktestConfig{
+		Interval: "1h", HoldBars: 1, MaxRisk: domain.RiskLevel3, Indicators: []string{domain.IndicatorRSI},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Trades) != 1 || !res.Trades[0].EntryTime.Equal(candles[10].OpenTime) {
+		t.Fatalf("expected only the low-risk long RSI trade, got %+v", res.Trades)
+	}
+}
+
+func TestRunRespectsWindowAndWarmup(t *testing.T) {
+	candles := flatCandles(40, 100)
+	gen := &scriptedGenerator{}
+	res, err := NewRunner(gen, 10).Run(candles, domain.BacktestConfig{
+		Interval: "1h", From: candles[20].OpenTime, To: candles[29].OpenTime,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Equity) != 10 || !res.Equity[0].Time.Equal(candles[20].OpenTime) {
+		t.Fatalf("expected equity over the 10-bar window, got %d points", len(res.Equity))
+	}
+
+	if _, err := NewRunner(gen, 10).Run(candles[:1], domain.BacktestConfig{}); err == nil {
+		t.Fatal("expected error for a single-candle window")
+	}
+}
+
+func TestRunWithSignalEngine(t *testing.T) {
+	base := time.Unix(0, 0).UTC()
+	candles := make([]*domain.Candle, 0, 300)
+	for i := 0; i < 300; i++ {
+		c := 100 + 10*math.Sin(float64(i)/8)
+		candles = append(candles, &domain.Candle{
+			Symbol: "BTC", Interval: "1h", OpenTime: base.Add(time.Duration(i) * time.Hour),
+			Open: c, High: c + 1, Low: c - 1, Close: c, Volume: 100 + float64(i%7)*20,
+		})
+	}
+	res, err := NewRunner(signal.NewEngine(nil), 0).Run(candles, domain.BacktestConfig{
+		Interval: "1h", HoldBars: 12, UseStops: true, AllowShort: true, FeeBps: 10, SlippageBps: 5,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Trades) == 0 {
+		t.Fatal("expected the oscillating series to produce trades")
+	}
+	if len(res.Equity) != len(candles) {
+		t.Fatalf("expected an equity point per bar, got %d", len(res.Equity))
+	}
+}
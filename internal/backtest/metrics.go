@@ -0,0 +1,119 @@
+package backtest
+
+import (
+	"math"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+// maxProfitFactor caps the profit factor when there are no losing trades so
+// the value stays finite and JSON-encodable.
+const maxProfitFactor = 100
+
+// ComputeMetrics derives trade and equity-curve statistics. Sharpe and
+// Sortino use per-bar equity returns annualised by the interval length, with
+// a zero risk-free rate.
+func ComputeMetrics(trades []domain.BacktestTrade, equity []domain.EquityPoint, initial float64, interval string) domain.BacktestMetrics {
+	m := domain.BacktestMetrics{Trades: len(trades), FinalEquity: initial}
+	if len(equity) > 0 {
+		m.FinalEquity = equity[len(equity)-1].Equity
+	}
+	if initial > 0 {
+		m.TotalReturn = m.FinalEquity/initial - 1
+	}
+
+	var grossProfit, grossLoss, sumReturn float64
+	for _, t := range trades {
+		sumReturn += t.ReturnPct
+		if t.PnL > 0 {
+			m.Wins++
+			grossProfit += t.PnL
+		} else {
+			m.Losses++
+			grossLoss -= t.PnL
+		}
+	}
+	if len(trades) > 0 {
+		m.WinRate = float64(m.Wins) / float64(len(trades))
+		m.AvgTradeReturn = sumReturn / float64(len(trades))
+	}
+	switch {
+	case grossLoss > 0:
+		m.ProfitFactor = math.Min(grossProfit/grossLoss, maxProfitFactor)
+	case grossProfit > 0:
+		m.ProfitFactor = maxProfitFactor
+	}
+
+	m.MaxDrawdown = MaxDrawdown(equity)
+	m.Sharpe, m.Sortino = riskAdjusted(equity, interval)
+	return m
+}
+
+// MaxDrawdown returns the largest peak-to-trough fall of the equity curve as
+// a positive fraction.
+func MaxDrawdown(equity []domain.EquityPoint) float64 {
+	var peak, worst float64
+	for _, p := range equity {
+		if p.Equity > peak {
+			peak = p.Equity
+		}
+		if peak > 0 {
+			if dd := (peak - p.Equity) / peak; dd > worst {
+				worst = dd
+			}
+		}
+	}
+	return worst
+}
+
+func riskAdjusted(equity []domain.EquityPoint, interval string) (float64, float64) {
+	if len(equity) < 3 {
+		return 0, 0
+	}
+	returns := make([]float64, 0, len(equity)-1)
+	for i := 1; i < len(equity); i++ {
+		prev := equity[i-1].Equity
+		if prev <= 0 {
+			continue
+		}
+		returns = append(returns, equity[i].Equity/prev-1)
+	}
+	if len(returns) < 2 {
+		return 0, 0
+	}
+
+	var mean float64
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	var variance, downside float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+		if r < 0 {
+			downside += r * r
+		}
+	}
+	std := math.Sqrt(variance / float64(len(returns)-1))
+	downDev := math.Sqrt(downside / float64(len(returns)))
+
+	scale := math.Sqrt(barsPerYear(interval))
+	var sharpe, sortino float64
+	if std > 0 {
+		sharpe = mean / std * scale
+	}
+	if downDev > 0 {
+		sortino = mean / downDev * scale
+	}
+	return sharpe, sortino
+}
+
+func barsPerYear(interval string) float64 {
+	d := domain.IntervalDuration(interval)
+	if d <= 0 {
+		d = time.Hour
+	}
+	return float64(365*24*time.Hour) / float64(d)
+}
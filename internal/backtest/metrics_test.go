@@ -0,0 +1,54 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+func equityCurve(values ...float64) []domain.EquityPoint {
+	base := time.Unix(0, 0).UTC()
+	out := make([]domain.EquityPoint, len(values))
+	for i, v := range values {
+		out[i] = domain.EquityPoint{Time: base.Add(time.Duration(i) * time.Hour), Equity: v}
+	}
+	return out
+}
+
+func TestMaxDrawdown(t *testing.T) {
+	got := MaxDrawdown(equityCurve(100, 120, 90, 110, 130, 117))
+	if math.Abs(got-0.25) > 1e-9 {
+		t.Fatalf("expected 25%% drawdown, got %.4f", got)
+	}
+	if MaxDrawdown(equityCurve(100, 101, 102)) != 0 {
+		t.Fatal("expected no drawdown on a rising curve")
+	}
+}
+
+func TestComputeMetrics(t *testing.T) {
+	trades := []domain.BacktestTrade{
+		{PnL: 30, ReturnPct: 0.03},
+		{PnL: -10, ReturnPct: -0.01},
+		{PnL: 20, ReturnPct: 0.02},
+	}
+	m := ComputeMetrics(trades, equityCurve(1000, 1030, 1020, 1040), 1000, "1h")
+	if m.Trades != 3 || m.Wins != 2 || m.Losses != 1 {
+		t.Fatalf("unexpected counts: %+v", m)
+	}
+	if math.Abs(m.WinRate-2.0/3) > 1e-9 || math.Abs(m.ProfitFactor-5) > 1e-9 {
+		t.Fatalf("unexpected win rate/profit factor: %+v", m)
+	}
+	if math.Abs(m.TotalReturn-0.04) > 1e-9 || math.Abs(m.AvgTradeReturn-0.04/3) > 1e-9 {
+		t.Fatalf("unexpected returns: %+v", m)
+	}
+	if m.Sharpe <= 0 || m.Sortino <= 0 {
+		t.Fatalf("expected positive risk-adjusted ratios, got sharpe=%.2f sortino=%.2f", m.Sharpe, m.Sortino)
+	}
+
+	empty := ComputeMetrics(nil, nil, 1000, "1h")
+	if empty.FinalEquity != 1000 || empty.ProfitFactor != 0 || empty.Sharpe != 0 {
+		t.Fatalf("unexpected empty metrics: %+v", empty)
+	}
+}
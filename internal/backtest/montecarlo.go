@@ -0,0 +1,184 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+const (
+	DefaultSimulations = 1000
+	MaxSimulations     = 10000
+
+	// fanPaths bounds how many simulations feed the per-trade fan so memory
+	// stays linear in the trade count.
+	fanPaths = 1000
+)
+
+// MonteCarlo replays a run's trade returns in random order (or resampled
+// with replacement) and reports percentile bands of the outcomes. Trades
+// compound on full equity, as they do in Run.
+func MonteCarlo(trades []domain.BacktestTrade, cfg domain.MonteCarloConfig) (*domain.MonteCarloResult, error) {
+	if len(trades) < 2 {
+		return nil, fmt.Errorf("need at least 2 trades for a Monte Carlo analysis, got %d", len(trades))
+	}
+	if cfg.Simulations <= 0 {
+		cfg.Simulations = DefaultSimulations
+	}
+	if cfg.Simulations > MaxSimulations {
+		return nil, fmt.Errorf("simulations must be at most %d", MaxSimulations)
+	}
+	switch cfg.Method {
+	case "":
+		cfg.Method = domain.MonteCarloBootstrap
+	case domain.MonteCarloBootstrap, domain.MonteCarloShuffle:
+	default:
+		return nil, fmt.Errorf("unsupported Monte Carlo method %q", cfg.Method)
+	}
+	if cfg.SlippageBps < 0 {
+		return nil, fmt.Errorf("slippage_bps must not be negative")
+	}
+
+	returns := make([]float64, len(trades))
+	for i, t := range trades {
+		returns[i] = t.ReturnPct
+	}
+	n := len(returns)
+	rng := rand.New(rand.NewSource(cfg.Seed))
+
+	finals := make([]float64, cfg.Simulations)
+	drawdowns := make([]float64, cfg.Simulations)
+	recoveries := make([]float64, cfg.Simulations)
+	// steps[i][s] is the cumulative return of simulation s after i trades.
+	fanSims := cfg.Simulations
+	if fanSims > fanPaths {
+		fanSims = fanPaths
+	}
+	steps := make([][]float64, n+1)
+	for i := range steps {
+		steps[i] = make([]float64, fanSims)
+	}
+	var losses, unrecovered int
+
+	path := make([]float64, n)
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	for s := 0; s < cfg.Simulations; s++ {
+		if cfg.Method == domain.MonteCarloShuffle {
+			rng.Shuffle(n, func(i, j int) { order[i], order[j] = order[j], order[i] })
+			for i, idx := range order {
+				path[i] = returns[idx]
+			}
+		} else {
+			for i := range path {
+				path[i] = returns[rng.Intn(n)]
+			}
+		}
+		if cfg.SlippageBps > 0 {
+			for i := range path {
+				path[i] -= 2 * rng.Float64() * cfg.SlippageBps / 10_000
+			}
+		}
+
+		stats := walkPath(path, func(i int, equity float64) {
+			if s < fanSims {
+				steps[i][s] = equity - 1
+			}
+		})
+		finals[s] = stats.final - 1
+		drawdowns[s] = stats.maxDrawdown
+		recoveries[s] = float64(stats.longestUnderwater)
+		if stats.final < 1 {
+			losses++
+		}
+		if stats.underwaterAtEnd {
+			unrecovered++
+		}
+	}
+
+	result := &domain.MonteCarloResult{
+		Config:         cfg,
+		Trades:         n,
+		FinalReturn:    percentileBand(finals),
+		MaxDrawdown:    percentileBand(drawdowns),
+		RecoveryTrades: percentileBand(recoveries),
+		ProbLoss:       float64(losses) / float64(cfg.Simulations),
+		Unrecovered:    float64(unrecovered) / float64(cfg.Simulations),
+		Fan:            make([]domain.PercentileBand, n+1),
+		Actual:         make([]float64, n+1),
+	}
+	for i := range steps {
+		result.Fan[i] = percentileBand(steps[i])
+	}
+	walkPath(returns, func(i int, equity float64) { result.Actual[i] = equity - 1 })
+	return result, nil
+}
+
+type pathStats struct {
+	final             float64
+	maxDrawdown       float64
+	longestUnderwater int
+	underwaterAtEnd   bool
+}
+
+// walkPath compounds returns from an equity of 1, calling visit with the
+// equity before the first trade and after each one.
+func walkPath(returns []float64, visit func(i int, equity float64)) pathStats {
+	equity, peak := 1.0, 1.0
+	var stats pathStats
+	underwater := 0
+	visit(0, equity)
+	for i, r := range returns {
+		equity *= 1 + r
+		if equity < 0 {
+			equity = 0
+		}
+		visit(i+1, equity)
+		if equity >= peak {
+			peak = equity
+			underwater = 0
+			continue
+		}
+		underwater++
+		if underwater > stats.longestUnderwater {
+			stats.longestUnderwater = underwater
+		}
+		if dd := (peak - equity) / peak; dd > stats.maxDrawdown {
+			stats.maxDrawdown = dd
+		}
+	}
+	stats.final = equity
+	stats.underwaterAtEnd = underwater > 0
+	return stats
+}
+
+// percentileBand sorts values in place and reads the band off them with
+// linear interpolation.
+func percentileBand(values []float64) domain.PercentileBand {
+	sort.Float64s(values)
+	return domain.PercentileBand{
+		P5:  percentile(values, 0.05),
+		P25: percentile(values, 0.25),
+		P50: percentile(values, 0.50),
+		P75: percentile(values, 0.75),
+		P95: percentile(values, 0.95),
+	}
+}
+
+func percentile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	pos := q * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	if lo == hi {
+		return sorted[lo]
+	}
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
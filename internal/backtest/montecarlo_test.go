@@ -0,0 +1,87 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+func tradesWithReturns(returns ...float64) []domain.BacktestTrade {
+	out := make([]domain.BacktestTrade, len(returns))
+	for i, r := range returns {
+		out[i] = domain.BacktestTrade{ReturnPct: r}
+	}
+	return out
+}
+
+func TestMonteCarloShuffleKeepsFinalReturn(t *testing.T) {
+	trades := tradesWithReturns(0.1, -0.05, 0.02, -0.08, 0.04, 0.06)
+	res, err := MonteCarlo(trades, domain.MonteCarloConfig{Simulations: 200, Method: domain.MonteCarloShuffle, Seed: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := res.Actual[len(res.Actual)-1]
+	if math.Abs(res.FinalReturn.P5-want) > 1e-12 || math.Abs(res.FinalReturn.P95-want) > 1e-12 {
+		t.Fatalf("expected reordering to keep the final return %.6f, got %+v", want, res.FinalReturn)
+	}
+	if res.MaxDrawdown.P95 <= res.MaxDrawdown.P5 {
+		t.Fatalf("expected a spread of drawdowns, got %+v", res.MaxDrawdown)
+	}
+	if len(res.Fan) != len(trades)+1 || res.Fan[0].P50 != 0 || res.Trades != len(trades) {
+		t.Fatalf("expected fan starting at zero for every trade, got %d points", len(res.Fan))
+	}
+}
+
+func TestMonteCarloSlippageShiftsDistributionDown(t *testing.T) {
+	trades := tradesWithReturns(0.03, -0.01, 0.02, 0.01, -0.02, 0.04, 0.01, -0.01)
+	clean, err := MonteCarlo(trades, domain.MonteCarloConfig{Simulations: 500, Seed: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if clean.Config.Method != domain.MonteCarloBootstrap {
+		t.Fatalf("expected bootstrap default, got %q", clean.Config.Method)
+	}
+	slipped, err := MonteCarlo(trades, domain.MonteCarloConfig{Simulations: 500, Seed: 3, SlippageBps: 50})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if slipped.FinalReturn.P50 >= clean.FinalReturn.P50 {
+		t.Fatalf("expected slippage to lower the median return: %.4f vs %.4f", slipped.FinalReturn.P50, clean.FinalReturn.P50)
+	}
+	if slipped.ProbLoss < clean.ProbLoss {
+		t.Fatalf("expected slippage to raise the loss probability: %.3f vs %.3f", slipped.ProbLoss, clean.ProbLoss)
+	}
+	b := clean.FinalReturn
+	if !(b.P5 <= b.P25 && b.P25 <= b.P50 && b.P50 <= b.P75 && b.P75 <= b.P95) {
+		t.Fatalf("expected ordered percentiles, got %+v", b)
+	}
+}
+
+func TestMonteCarloErrors(t *testing.T) {
+	two := tradesWithReturns(0.01, 0.02)
+	cases := []struct {
+		trades []domain.BacktestTrade
+		cfg    domain.MonteCarloConfig
+	}{
+		{tradesWithReturns(0.01), domain.MonteCarloConfig{}},
+		{two, domain.MonteCarloConfig{Simulations: MaxSimulations + 1}},
+		{two, domain.MonteCarloConfig{Method: "jackknife"}},
+		{two, domain.MonteCarloConfig{SlippageBps: -1}},
+	}
+	for i, tc := range cases {
+		if _, err := MonteCarlo(tc.trades, tc.cfg); err == nil {
+			t.Fatalf("case %d: expected error", i)
+		}
+	}
+}
+
+func TestWalkPathTracksRecovery(t *testing.T) {
+	stats := walkPath([]float64{0.1, -0.1, -0.1, 0.3, -0.05}, func(int, float64) {})
+	if stats.longestUnderwater != 2 || !stats.underwaterAtEnd {
+		t.Fatalf("unexpected recovery stats: %+v", stats)
+	}
+	if math.Abs(stats.maxDrawdown-0.19) > 1e-9 {
+		t.Fatalf("expected 19%% drawdown, got %.4f", stats.maxDrawdown)
+	}
+}
@@ -0,0 +1,320 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+const (
+	DefaultFolds         = 4
+	MaxFolds             = 10
+	DefaultRandomSamples = 50
+	MaxCandidates        = 500
+
+	leaderboardSize = 10
+)
+
+// GeneratorFactory builds a signal generator for one parameter set.
+type GeneratorFactory func(params domain.SignalParams) SignalGenerator
+
+// Optimizer sweeps signal parameters with walk-forward validation: the
+// backtest window is split into Folds+1 equal segments and fold i picks the
+// best candidate on segment i, then scores it on segment i+1.
+type Optimizer struct {
+	factory  GeneratorFactory
+	lookback int
+}
+
+func NewOptimizer(factory GeneratorFactory, lookback int) *Optimizer {
+	if lookback <= 0 {
+		lookback = DefaultLookback
+	}
+	return &Optimizer{factory: factory, lookback: lookback}
+}
+
+type candidate struct {
+	values map[string]float64
+	params domain.SignalParams
+}
+
+// Evaluations returns how many backtests Run will perform for req, so
+// callers can size progress reporting before starting.
+func Evaluations(req domain.OptimizeRequest) (int, error) {
+	candidates, err := expandCandidates(req)
+	if err != nil {
+		return 0, err
+	}
+	return len(candidates) * (req.Folds + 1), nil
+}
+
+// Run evaluates every candidate on every segment. progress, when set, is
+// called after each backtest with the number done and the total.
+func (o *Optimizer) Run(ctx context.Context, candles []*domain.Candle, req domain.OptimizeRequest, progress func(done, total int)) (*domain.OptimizeResult, error) {
+	if o.factory == nil {
+		return nil, fmt.Errorf("optimizer has no generator factory")
+	}
+	candidates, err := expandCandidates(req)
+	if err != nil {
+		return nil, err
+	}
+	segments := splitWindow(req.Base.From, req.Base.To, req.Folds+1)
+
+	total := len(candidates) * len(segments)
+	done := 0
+	// scores[c][s] and metrics[c][s] hold candidate c on segment s.
+	scores := make([][]float64, len(candidates))
+	metrics := make([][]domain.BacktestMetrics, len(candidates))
+	for c, cand := range candidates {
+		runner := NewRunner(o.factory(cand.params), o.lookback)
+		scores[c] = make([]float64, len(segments))
+		metrics[c] = make([]domain.BacktestMetrics, len(segments))
+		for s, seg := range segments {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			cfg := req.Base
+			cfg.From, cfg.To = seg[0], seg[1]
+			params := cand.params
+			cfg.Params = &params
+			res, err := runner.Run(candles, cfg)
+			if err != nil {
+				return nil, fmt.Errorf("segment %s - %s: %w", seg[0].Format(time.RFC3339), seg[1].Format(time.RFC3339), err)
+			}
+			scores[c][s] = ObjectiveScore(res.Metrics, req.Objective)
+			metrics[c][s] = res.Metrics
+			done++
+			if progress != nil {
+				progress(done, total)
+			}
+		}
+	}
+
+	result := &domain.OptimizeResult{
+		Objective:  req.Objective,
+		Candidates: len(candidates),
+		Folds:      make([]domain.WalkForwardFold, 0, req.Folds),
+	}
+	// winners[f] is the candidate fold f picked on segment f.
+	winners := make([]int, req.Folds)
+	wins := make([]int, len(candidates))
+	var sumTrain, sumTest float64
+	for f := 0; f < req.Folds; f++ {
+		best := 0
+		for c := range candidates {
+			if scores[c][f] > scores[best][f] {
+				best = c
+			}
+		}
+		winners[f] = best
+		wins[best]++
+		fold := domain.WalkForwardFold{
+			Index:       f,
+			TrainFrom:   segments[f][0],
+			TrainTo:     segments[f][1],
+			TestFrom:    segments[f+1][0],
+			TestTo:      segments[f+1][1],
+			Values:      candidates[best].values,
+			TrainScore:  scores[best][f],
+			TestScore:   scores[best][f+1],
+			TestMetrics: metrics[best][f+1],
+		}
+		if fold.TestScore > 0 {
+			result.PositiveFolds++
+		}
+		sumTrain += fold.TrainScore
+		sumTest += fold.TestScore
+		result.Folds = append(result.Folds, fold)
+	}
+	result.MeanTrainScore = sumTrain / float64(req.Folds)
+	result.MeanTestScore = sumTest / float64(req.Folds)
+	if result.MeanTrainScore > 0 {
+		result.Efficiency = result.MeanTestScore / result.MeanTrainScore
+	}
+
+	board := make([]domain.ParamCandidate, 0, len(candidates))
+	for c, cand := range candidates {
+		pc := domain.ParamCandidate{Values: cand.values, Params: cand.params, FoldWins: wins[c]}
+		pc.MeanTrainScore = mean(scores[c][:len(scores[c])-1])
+		// Segment s is out-of-sample for c unless fold s selected c on
+		// it; the first segment is only ever trained on.
+		var test []float64
+		for s := 1; s < len(segments); s++ {
+			if s < req.Folds && winners[s] == c {
+				continue
+			}
+			test = append(test, scores[c][s])
+		}
+		setTestStats(&pc, test)
+		board = append(board, pc)
+	}
+	sort.SliceStable(board, func(i, j int) bool { return board[i].Stability > board[j].Stability })
+	if len(board) > leaderboardSize {
+		board = board[:leaderboardSize]
+	}
+	result.Leaderboard = board
+	result.Recommended = recommend(candidates, result.Folds, winners, wins)
+	return result, nil
+}
+
+// recommend picks the set that won the most folds, breaking ties by its
+// mean score on the segments after the folds it won. Its test statistics
+// come from those segments only, so nothing it was selected on is counted.
+func recommend(candidates []candidate, folds []domain.WalkForwardFold, winners, wins []int) *domain.ParamCandidate {
+	var rec *domain.ParamCandidate
+	for c, cand := range candidates {
+		if wins[c] == 0 {
+			continue
+		}
+		var train, test []float64
+		for f, w := range winners {
+			if w == c {
+				train = append(train, folds[f].TrainScore)
+				test = append(test, folds[f].TestScore)
+			}
+		}
+		pc := domain.ParamCandidate{Values: cand.values, Params: cand.params, FoldWins: wins[c], MeanTrainScore: mean(train)}
+		setTestStats(&pc, test)
+		if rec == nil || pc.FoldWins > rec.FoldWins || (pc.FoldWins == rec.FoldWins && pc.MeanTestScore > rec.MeanTestScore) {
+			rec = &pc
+		}
+	}
+	return rec
+}
+
+func setTestStats(pc *domain.ParamCandidate, test []float64) {
+	pc.MeanTestScore = mean(test)
+	pc.StdTestScore = stddev(test, pc.MeanTestScore)
+	pc.Stability = pc.MeanTestScore - pc.StdTestScore
+	for _, v := range test {
+		if v > 0 {
+			pc.PositiveFolds++
+		}
+	}
+}
+
+// ObjectiveScore extracts the metric an optimisation maximises.
+func ObjectiveScore(m domain.BacktestMetrics, objective string) float64 {
+	switch objective {
+	case domain.ObjectiveSortino:
+		return m.Sortino
+	case domain.ObjectiveTotalReturn:
+		return m.TotalReturn
+	case domain.ObjectiveProfitFactor:
+		return m.ProfitFactor
+	default:
+		return m.Sharpe
+	}
+}
+
+// expandCandidates turns the request grid into concrete parameter sets on
+// top of req.Base.Params. Invalid combinations (e.g. macd_fast >= macd_slow)
+// are dropped; random mode samples without replacement using req.Seed.
+func expandCandidates(req domain.OptimizeRequest) ([]candidate, error) {
+	if req.Base.Params == nil {
+		return nil, fmt.Errorf("optimizer needs base params")
+	}
+	if req.Folds < 1 || req.Folds > MaxFolds {
+		return nil, fmt.Errorf("folds must be between 1 and %d", MaxFolds)
+	}
+	names := make([]string, 0, len(req.Grid))
+	for name, values := range req.Grid {
+		if len(values) == 0 {
+			return nil, fmt.Errorf("grid %q has no values", name)
+		}
+		if err := (&domain.SignalParams{}).Set(name, 0); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	combos := 1
+	for _, name := range names {
+		combos *= len(req.Grid[name])
+		if combos > 1_000_000 {
+			return nil, fmt.Errorf("grid has too many combinations")
+		}
+	}
+
+	indexes := make([]int, combos)
+	for i := range indexes {
+		indexes[i] = i
+	}
+	if req.Mode == domain.OptimizeModeRandom {
+		samples := req.Samples
+		if samples <= 0 {
+			samples = DefaultRandomSamples
+		}
+		rng := rand.New(rand.NewSource(req.Seed))
+		rng.Shuffle(len(indexes), func(i, j int) { indexes[i], indexes[j] = indexes[j], indexes[i] })
+		if samples < len(indexes) {
+			indexes = indexes[:samples]
+		}
+		sort.Ints(indexes)
+	}
+	if len(indexes) > MaxCandidates {
+		return nil, fmt.Errorf("grid has %d combinations; use random mode or at most %d", len(indexes), MaxCandidates)
+	}
+
+	out := make([]candidate, 0, len(indexes))
+	for _, idx := range indexes {
+		params := *req.Base.Params
+		values := make(map[string]float64, len(names))
+		rem := idx
+		for i := len(names) - 1; i >= 0; i-- {
+			grid := req.Grid[names[i]]
+			v := grid[rem%len(grid)]
+			rem /= len(grid)
+			values[names[i]] = v
+			_ = params.Set(names[i], v)
+		}
+		if params.Validate() != nil {
+			continue
+		}
+		out = append(out, candidate{values: values, params: params})
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no valid parameter combinations in grid")
+	}
+	return out, nil
+}
+
+// splitWindow cuts [from, to) into n equal segments.
+func splitWindow(from, to time.Time, n int) [][2]time.Time {
+	step := to.Sub(from) / time.Duration(n)
+	out := make([][2]time.Time, n)
+	for i := range out {
+		out[i][0] = from.Add(time.Duration(i) * step)
+		out[i][1] = from.Add(time.Duration(i+1) * step)
+	}
+	out[n-1][1] = to
+	return out
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func stddev(values []float64, mean float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(sum / float64(len(values)-1))
+}
@@ -0,0 +1,216 @@
+package backtest
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/signal"
+)
+
+// directionGenerator fires the same signal on every bar.
+type directionGenerator struct{ direction domain.SignalDirection }
+
+func (g directionGenerator) Generate(candles []*domain.Candle) []domain.Signal {
+	if g.direction == "" {
+		return nil
+	}
+	return []domain.Signal{{Indicator: domain.IndicatorRSI, Direction: g.direction, Risk: domain.RiskLevel2}}
+}
+
+func risingCandles(n int) []*domain.Candle {
+	base := time.Unix(0, 0).UTC()
+	out := make([]*domain.Candle, 0, n)
+	for i := 0; i < n; i++ {
+		p := 100 + float64(i)
+		out = append(out, &domain.Candle{
+			Symbol: "BTC", Interval: "1h", OpenTime: base.Add(time.Duration(i) * time.Hour),
+			Open: p, High: p + 0.5, Low: p - 0.5, Close: p + 0.4, Volume: 100,
+		})
+	}
+	return out
+}
+
+func TestOptimizerPicksStableWinnerOutOfSample(t *testing.T) {
+	candles := risingCandles(120)
+	base := signal.DefaultParams()
+	factory := func(p domain.SignalParams) SignalGenerator {
+		switch p.RSIPeriod {
+		case 10:
+			return directionGenerator{direction: domain.DirectionLong}
+		case 20:
+			return directionGenerator{direction: domain.DirectionShort}
+		}
+		return directionGenerator{}
+	}
+
+	req := domain.OptimizeRequest{
+		Base: domain.BacktestConfig{
+			Symbol: "BTC", Interval: "1h", HoldBars: 4, AllowShort: true,
+			From: candles[20].OpenTime, To: candles[119].OpenTime, Params: &base,
+		},
+		Grid:      map[string][]float64{"rsi_period": {10, 14, 20}},
+		Mode:      domain.OptimizeModeGrid,
+		Folds:     3,
+		Objective: domain.ObjectiveTotalReturn,
+	}
+	total, err := Evaluations(req)
+	if err != nil || total != 12 {
+		t.Fatalf("expected 12 evaluations, got %d (%v)", total, err)
+	}
+
+	var lastDone, lastTotal int
+	res, err := NewOptimizer(factory, 10).Run(context.Background(), candles, req, func(done, total int) {
+		lastDone, lastTotal = done, total
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lastDone != 12 || lastTotal != 12 {
+		t.Fatalf("expected progress to reach 12/12, got %d/%d", lastDone, lastTotal)
+	}
+	if res.Candidates != 3 || len(res.Folds) != 3 {
+		t.Fatalf("expected 3 candidates and 3 folds, got %d/%d", res.Candidates, len(res.Folds))
+	}
+	for _, f := range res.Folds {
+		if f.Values["rsi_period"] != 10 {
+			t.Fatalf("fold %d: expected rsi_period 10 to win in-sample, got %v", f.Index, f.Values)
+		}
+		if !f.TestFrom.Equal(f.TrainTo) || f.TestScore <= 0 {
+			t.Fatalf("fold %d: expected adjacent positive test window, got %+v", f.Index, f)
+		}
+	}
+	if res.PositiveFolds != 3 || res.Efficiency <= 0 {
+		t.Fatalf("expected stable out-of-sample result, got %+v", res)
+	}
+	if res.Recommended == nil || res.Recommended.Params.RSIPeriod != 10 || res.Recommended.FoldWins != 3 {
+		t.Fatalf("expected rsi_period 10 recommended, got %+v", res.Recommended)
+	}
+	if last := res.Leaderboard[len(res.Leaderboard)-1]; last.Params.RSIPeriod != 20 || last.MeanTestScore >= 0 {
+		t.Fatalf("expected losing short candidate last, got %+v", last)
+	}
+}
+
+// windowGenerator goes long only while the latest bar is inside one of the
+// windows.
+type windowGenerator struct{ windows [][2]time.Time }
+
+func (g windowGenerator) Generate(candles []*domain.Candle) []domain.Signal {
+	at := candles[len(candles)-1].OpenTime
+	for _, w := range g.windows {
+		if !at.Before(w[0]) && at.Before(w[1]) {
+			return []domain.Signal{{Indicator: domain.IndicatorRSI, Direction: domain.DirectionLong, Risk: domain.RiskLevel2}}
+		}
+	}
+	return nil
+}
+
+func TestOptimizerScoresOnlyUnselectedSegments(t *testing.T) {
+	candles := risingCandles(140)
+	base := signal.DefaultParams()
+	from, to := candles[20].OpenTime, candles[139].OpenTime
+	segs := splitWindow(from, to, 4)
+	factory := func(p domain.SignalParams) SignalGenerator {
+		if p.RSIPeriod == 10 {
+			return windowGenerator{windows: [][2]time.Time{segs[0], segs[2], segs[3]}}
+		}
+		return windowGenerator{windows: [][2]time.Time{segs[1]}}
+	}
+	req := domain.OptimizeRequest{
+		Base: domain.BacktestConfig{
+			Symbol: "BTC", Interval: "1h", HoldBars: 4,
+			From: from, To: to, Params: &base,
+		},
+		Grid:      map[string][]float64{"rsi_period": {10, 20}},
+		Folds:     3,
+		Objective: domain.ObjectiveTotalReturn,
+	}
+	res, err := NewOptimizer(factory, 10).Run(context.Background(), candles, req, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Fold 0 and 2 pick rsi_period 10, fold 1 picks 20 on the only
+	// segment it trades.
+	for i, want := range []float64{10, 20, 10} {
+		if got := res.Folds[i].Values["rsi_period"]; got != want {
+			t.Fatalf("fold %d: expected rsi_period %v, got %v", i, want, got)
+		}
+	}
+	for _, pc := range res.Leaderboard {
+		// 20 was selected on segment 1, its only profitable one.
+		if pc.Params.RSIPeriod == 20 && (pc.MeanTestScore != 0 || pc.PositiveFolds != 0) {
+			t.Fatalf("expected the selection segment excluded from test stats, got %+v", pc)
+		}
+	}
+	rec := res.Recommended
+	if rec == nil || rec.Params.RSIPeriod != 10 || rec.FoldWins != 2 {
+		t.Fatalf("expected the most frequent fold winner recommended, got %+v", rec)
+	}
+	want := (res.Folds[0].TestScore + res.Folds[2].TestScore) / 2
+	if math.Abs(rec.MeanTestScore-want) > 1e-12 || rec.PositiveFolds != 1 {
+		t.Fatalf("expected the recommendation scored on its folds' test segments, got %+v", rec)
+	}
+}
+
+func TestExpandCandidates(t *testing.T) {
+	base := signal.DefaultParams()
+	req := domain.OptimizeRequest{
+		Base:  domain.BacktestConfig{Params: &base},
+		Grid:  map[string][]float64{"macd_fast": {8, 12, 30}, "macd_slow": {26}},
+		Folds: 2,
+	}
+	cands, err := expandCandidates(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cands) != 2 {
+		t.Fatalf("expected macd_fast 30 >= macd_slow to be dropped, got %d candidates", len(cands))
+	}
+	if cands[0].params.RSIPeriod != base.RSIPeriod {
+		t.Fatal("expected unswept params to keep base values")
+	}
+
+	req.Grid = map[string][]float64{"rsi_period": {7, 9, 11, 14, 21}, "rsi_oversold": {20, 25, 30}}
+	req.Mode = domain.OptimizeModeRandom
+	req.Samples = 4
+	req.Seed = 7
+	first, err := expandCandidates(req)
+	if err != nil || len(first) != 4 {
+		t.Fatalf("expected 4 random samples, got %d (%v)", len(first), err)
+	}
+	second, _ := expandCandidates(req)
+	for i := range first {
+		if first[i].params != second[i].params {
+			t.Fatal("expected the same seed to sample the same candidates")
+		}
+	}
+
+	bad := []domain.OptimizeRequest{
+		{Base: domain.BacktestConfig{}, Folds: 2},
+		{Base: domain.BacktestConfig{Params: &base}, Folds: 0},
+		{Base: domain.BacktestConfig{Params: &base}, Folds: 2, Grid: map[string][]float64{"nope": {1}}},
+		{Base: domain.BacktestConfig{Params: &base}, Folds: 2, Grid: map[string][]float64{"rsi_period": {}}},
+		{Base: domain.BacktestConfig{Params: &base}, Folds: 2, Grid: map[string][]float64{"rsi_period": {1}}},
+	}
+	for i, r := range bad {
+		if _, err := expandCandidates(r); err == nil {
+			t.Fatalf("case %d: expected error", i)
+		}
+	}
+}
+
+func TestOptimizerHonoursCancellation(t *testing.T) {
+	base := signal.DefaultParams()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	candles := risingCandles(40)
+	_, err := NewOptimizer(func(domain.SignalParams) SignalGenerator { return directionGenerator{} }, 10).Run(ctx, candles, domain.OptimizeRequest{
+		Base:  domain.BacktestConfig{Interval: "1h", From: candles[0].OpenTime, To: candles[39].OpenTime, Params: &base},
+		Folds: 1,
+	}, nil)
+	if err == nil {
+		t.Fatal("expected context error")
+	}
+}
@@ -0,0 +1,500 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+const (
+	DefaultRiskPerTrade     = 0.01
+	DefaultMaxAssetExposure = 0.25
+	DefaultMaxGrossExposure = 1.0
+
+	// volatilityWindow is how many closes size the fallback stop and the
+	// volatility target.
+	volatilityWindow = 20
+	// minPositionFraction drops entries smaller than this share of equity,
+	// which only appear when a cap is nearly exhausted.
+	minPositionFraction = 0.001
+)
+
+// PortfolioRunner replays several assets through one signal generator and
+// simulates a shared account holding any number of positions at once.
+type PortfolioRunner struct {
+	generator SignalGenerator
+	lookback  int
+}
+
+func NewPortfolioRunner(generator SignalGenerator, lookback int) *PortfolioRunner {
+	if lookback <= 0 {
+		lookback = DefaultLookback
+	}
+	return &PortfolioRunner{generator: generator, lookback: lookback}
+}
+
+type assetSeries struct {
+	symbol  string
+	candles []*domain.Candle
+	index   map[int64]int
+}
+
+type portfolioPosition struct {
+	position
+	symbol string
+}
+
+// portfolioSim carries the account state through one Run.
+type portfolioSim struct {
+	cfg      domain.PortfolioConfig
+	fee      float64
+	slip     float64
+	cash     float64
+	open     []*portfolioPosition
+	price    map[string]float64
+	result   *domain.PortfolioResult
+	exposure map[string]float64
+}
+
+// Run simulates cfg over candles keyed by symbol. As in Runner.Run, signals
+// are generated at a bar's close and fill at that asset's next open. Bars are
+// processed in time order across assets; at each timestamp fills and exits
+// happen first, then the book is marked at the close. Correlations for the
+// exposure report use the whole window, but sizing only uses past closes.
+func (r *PortfolioRunner) Run(candles map[string][]*domain.Candle, cfg domain.PortfolioConfig) (*domain.PortfolioResult, error) {
+	if r.generator == nil {
+		return nil, fmt.Errorf("portfolio runner has no signal generator")
+	}
+	cfg = applyPortfolioDefaults(cfg)
+	base := cfg.Base
+
+	assets := make([]*assetSeries, 0, len(cfg.Symbols))
+	for _, symbol := range cfg.Symbols {
+		series := sortedCandles(candles[symbol])
+		if len(series) == 0 {
+			continue
+		}
+		a := &assetSeries{symbol: symbol, candles: series, index: make(map[int64]int, len(series))}
+		for i, c := range series {
+			a.index[c.OpenTime.UnixNano()] = i
+		}
+		assets = append(assets, a)
+	}
+	if len(assets) == 0 {
+		return nil, fmt.Errorf("no candles for any portfolio symbol")
+	}
+	timeline := portfolioTimeline(assets, base.From, base.To)
+	if len(timeline) < 2 {
+		return nil, fmt.Errorf("not enough candles in backtest window: %d", len(timeline))
+	}
+
+	sim := &portfolioSim{
+		cfg:   cfg,
+		fee:   base.FeeBps / 10_000,
+		slip:  base.SlippageBps / 10_000,
+		cash:  base.InitialEquity,
+		price: make(map[string]float64, len(assets)),
+		result: &domain.PortfolioResult{
+			Config:       cfg,
+			Equity:       make([]domain.EquityPoint, 0, len(timeline)),
+			Exposure:     make([]domain.ExposurePoint, 0, len(timeline)),
+			Trades:       make([]domain.PortfolioTrade, 0),
+			Correlations: correlationMatrix(assets, timeline),
+		},
+		exposure: make(map[string]float64, len(assets)),
+	}
+	pending := make(map[string]*domain.Signal)
+	lastIdx := make(map[string]int, len(assets))
+	equity := base.InitialEquity
+
+	for ti, ts := range timeline {
+		key := ts.UnixNano()
+		for _, a := range assets {
+			i, ok := a.index[key]
+			if !ok {
+				continue
+			}
+			lastIdx[a.symbol] = i
+			bar := a.candles[i]
+			if sig := pending[a.symbol]; sig != nil {
+				delete(pending, a.symbol)
+				sim.enter(a, i, *sig, equity)
+			}
+			sim.exit(a, i, bar)
+		}
+
+		for _, a := range assets {
+			if i, ok := a.index[key]; ok {
+				sim.price[a.symbol] = a.candles[i].Close
+			}
+		}
+		equity = sim.mark(ts)
+
+		if ti == len(timeline)-1 {
+			continue
+		}
+		for _, a := range assets {
+			i, ok := a.index[key]
+			if !ok || i == len(a.candles)-1 {
+				continue
+			}
+			lo := i + 1 - r.lookback
+			if lo < 0 {
+				lo = 0
+			}
+			sig := pickSignal(r.generator.Generate(a.candles[lo:i+1]), base)
+			if sig == nil {
+				continue
+			}
+			if sim.holding(a.symbol, sig.Indicator, sig.Direction) {
+				sim.result.SkippedSignals++
+				continue
+			}
+			s := *sig
+			pending[a.symbol] = &s
+		}
+	}
+
+	if len(sim.open) > 0 {
+		for _, a := range assets {
+			i := lastIdx[a.symbol]
+			for _, p := range sim.positionsIn(a.symbol) {
+				sign := directionSign(p.signal.Direction)
+				sim.close(a, p, i, a.candles[i].Close*(1-sign*sim.slip), domain.ExitReasonEnd)
+			}
+		}
+		sim.result.Equity[len(sim.result.Equity)-1].Equity = sim.cash
+	}
+
+	sim.summarise(assets, len(timeline))
+	return sim.result, nil
+}
+
+// enter sizes and opens a position at bar i's open.
+func (s *portfolioSim) enter(a *assetSeries, i int, sig domain.Signal, equity float64) {
+	bar := a.candles[i]
+	price := bar.Open
+	if price <= 0 && i > 0 {
+		price = a.candles[i-1].Close
+	}
+	if price <= 0 || equity <= 0 {
+		s.result.SkippedSignals++
+		return
+	}
+	sign := directionSign(sig.Direction)
+	price *= 1 + sign*s.slip
+
+	notional := s.size(a, i, sig, price, equity)
+	if notional < equity*minPositionFraction {
+		s.result.SkippedSignals++
+		return
+	}
+	units := notional / price
+	entryFee := notional * s.fee
+	if sign > 0 {
+		s.cash -= notional + entryFee
+	} else {
+		s.cash += notional - entryFee
+	}
+	s.open = append(s.open, &portfolioPosition{
+		position: position{signal: sig, entryIdx: i, entryPrice: price, units: units, entryFee: entryFee},
+		symbol:   a.symbol,
+	})
+}
+
+// size returns the notional for a new position: the risk budget over the
+// stop distance, then clipped by the volatility target, the exposure caps
+// and, for longs, the cash balance.
+func (s *portfolioSim) size(a *assetSeries, i int, sig domain.Signal, price, equity float64) float64 {
+	cfg := s.cfg
+	vol := trailingVolatility(a.candles, i)
+
+	stopDist := 0.0
+	if cfg.Base.UseStops && sig.Levels != nil && sig.Levels.Stop > 0 {
+		stopDist = math.Abs(price - sig.Levels.Stop)
+	}
+	if stopDist <= 0 {
+		stopDist = 2 * vol * price
+	}
+	if stopDist <= 0 {
+		return 0
+	}
+	budget := equity * cfg.RiskPerTrade * sig.Risk.SizingWeight()
+	notional := budget / stopDist * price
+
+	if cfg.TargetVolatility > 0 && vol > 0 {
+		annual := vol * math.Sqrt(barsPerYear(cfg.Base.Interval))
+		notional = math.Min(notional, equity*cfg.TargetVolatility/annual)
+	}
+	var assetExposure, gross float64
+	for _, p := range s.open {
+		e := p.units * s.price[p.symbol]
+		gross += e
+		if p.symbol == a.symbol {
+			assetExposure += e
+		}
+	}
+	notional = math.Min(notional, cfg.MaxAssetExposure*equity-assetExposure)
+	notional = math.Min(notional, cfg.MaxGrossExposure*equity-gross)
+	if sig.Direction == domain.DirectionLong {
+		notional = math.Min(notional, s.cash/(1+s.fee))
+	}
+	return notional
+}
+
+// exit closes positions in a whose stop, target or holding period is hit on
+// bar i.
+func (s *portfolioSim) exit(a *assetSeries, i int, bar *domain.Candle) {
+	for _, p := range s.positionsIn(a.symbol) {
+		sign := directionSign(p.signal.Direction)
+		if price, reason, ok := levelExit(&p.position, bar, s.cfg.Base.UseStops); ok {
+			s.close(a, p, i, price*(1-sign*s.slip), reason)
+		} else if i-p.entryIdx+1 >= s.cfg.Base.HoldBars {
+			s.close(a, p, i, bar.Close*(1-sign*s.slip), domain.ExitReasonTime)
+		}
+	}
+}
+
+func (s *portfolioSim) close(a *assetSeries, p *portfolioPosition, i int, price float64, reason string) {
+	exitFee := p.units * price * s.fee
+	sign := directionSign(p.signal.Direction)
+	if sign > 0 {
+		s.cash += p.units*price - exitFee
+	} else {
+		s.cash -= p.units*price + exitFee
+	}
+	pnl := sign*p.units*(price-p.entryPrice) - p.entryFee - exitFee
+	notional := p.units * p.entryPrice
+	trade := domain.PortfolioTrade{
+		Symbol: p.symbol,
+		BacktestTrade: domain.BacktestTrade{
+			Indicator:  p.signal.Indicator,
+			Direction:  p.signal.Direction,
+			Risk:       p.signal.Risk,
+			EntryTime:  a.candles[p.entryIdx].OpenTime,
+			ExitTime:   a.candles[i].OpenTime,
+			EntryPrice: p.entryPrice,
+			ExitPrice:  price,
+			ExitReason: reason,
+			Bars:       i - p.entryIdx + 1,
+			ReturnPct:  pnl / notional,
+			PnL:        pnl,
+			Fees:       p.entryFee + exitFee,
+		},
+		Units:    p.units,
+		Notional: notional,
+	}
+	if l := p.signal.Levels; s.cfg.Base.UseStops && l != nil {
+		trade.Stop = l.Stop
+		if len(l.Targets) > 0 {
+			trade.Target = l.Targets[0]
+		}
+	}
+	s.result.Trades = append(s.result.Trades, trade)
+
+	for j, q := range s.open {
+		if q == p {
+			s.open = append(s.open[:j], s.open[j+1:]...)
+			break
+		}
+	}
+}
+
+// mark values the book at the latest closes and records equity and
+// exposure for ts.
+func (s *portfolioSim) mark(ts time.Time) float64 {
+	signed := make(map[string]float64, len(s.price))
+	equity := s.cash
+	for _, p := range s.open {
+		e := directionSign(p.signal.Direction) * p.units * s.price[p.symbol]
+		signed[p.symbol] += e
+		equity += e
+	}
+	s.result.Equity = append(s.result.Equity, domain.EquityPoint{Time: ts, Equity: equity})
+
+	point := domain.ExposurePoint{Time: ts, Positions: len(s.open)}
+	if equity > 0 {
+		weights := make(map[string]float64, len(signed))
+		for symbol, e := range signed {
+			w := e / equity
+			weights[symbol] = w
+			point.Gross += math.Abs(w)
+			point.Net += w
+			s.exposure[symbol] += math.Abs(w)
+		}
+		var variance float64
+		for a, wa := range weights {
+			for b, wb := range weights {
+				variance += wa * wb * s.result.Correlations[a][b]
+			}
+		}
+		point.CorrelationAdjusted = math.Sqrt(math.Max(0, variance))
+	}
+	s.result.Exposure = append(s.result.Exposure, point)
+	return equity
+}
+
+func (s *portfolioSim) positionsIn(symbol string) []*portfolioPosition {
+	out := make([]*portfolioPosition, 0)
+	for _, p := range s.open {
+		if p.symbol == symbol {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// holding reports whether the same indicator already has a position open in
+// symbol in that direction, so a persisting signal does not pyramid.
+func (s *portfolioSim) holding(symbol, indicator string, direction domain.SignalDirection) bool {
+	for _, p := range s.open {
+		if p.symbol == symbol && p.signal.Indicator == indicator && p.signal.Direction == direction {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *portfolioSim) summarise(assets []*assetSeries, bars int) {
+	res := s.result
+	trades := make([]domain.BacktestTrade, len(res.Trades))
+	byAsset := make(map[string]*domain.AssetContribution, len(assets))
+	res.Assets = make([]domain.AssetContribution, len(assets))
+	for i, a := range assets {
+		res.Assets[i].Symbol = a.symbol
+		res.Assets[i].AvgExposure = s.exposure[a.symbol] / float64(bars)
+		byAsset[a.symbol] = &res.Assets[i]
+	}
+	for i, t := range res.Trades {
+		trades[i] = t.BacktestTrade
+		c := byAsset[t.Symbol]
+		c.Trades++
+		if t.PnL > 0 {
+			c.Wins++
+		}
+		c.PnL += t.PnL
+		c.Fees += t.Fees
+	}
+	initial := s.cfg.Base.InitialEquity
+	for i := range res.Assets {
+		res.Assets[i].Contribution = res.Assets[i].PnL / initial
+	}
+	res.Metrics = ComputeMetrics(trades, res.Equity, initial, s.cfg.Base.Interval)
+
+	for _, p := range res.Exposure {
+		res.AvgGrossExposure += p.Gross
+		res.AvgCorrelationAdjusted += p.CorrelationAdjusted
+		if p.Gross > res.MaxGrossExposure {
+			res.MaxGrossExposure = p.Gross
+		}
+	}
+	if n := float64(len(res.Exposure)); n > 0 {
+		res.AvgGrossExposure /= n
+		res.AvgCorrelationAdjusted /= n
+	}
+}
+
+// trailingVolatility is the standard deviation of close-to-close returns
+// over the volatilityWindow closes before bar i.
+func trailingVolatility(candles []*domain.Candle, i int) float64 {
+	lo := i - volatilityWindow - 1
+	if lo < 0 {
+		lo = 0
+	}
+	returns := make([]float64, 0, volatilityWindow)
+	for j := lo + 1; j < i; j++ {
+		if prev := candles[j-1].Close; prev > 0 {
+			returns = append(returns, candles[j].Close/prev-1)
+		}
+	}
+	return stddev(returns, mean(returns))
+}
+
+func portfolioTimeline(assets []*assetSeries, from, to time.Time) []time.Time {
+	seen := make(map[int64]time.Time)
+	for _, a := range assets {
+		for _, c := range a.candles {
+			if (!from.IsZero() && c.OpenTime.Before(from)) || (!to.IsZero() && c.OpenTime.After(to)) {
+				continue
+			}
+			seen[c.OpenTime.UnixNano()] = c.OpenTime
+		}
+	}
+	out := make([]time.Time, 0, len(seen))
+	for _, t := range seen {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+	return out
+}
+
+// correlationMatrix returns pairwise Pearson correlations of close-to-close
+// returns over the timestamps both assets share.
+func correlationMatrix(assets []*assetSeries, timeline []time.Time) map[string]map[string]float64 {
+	returns := make([]map[int64]float64, len(assets))
+	for k, a := range assets {
+		returns[k] = make(map[int64]float64, len(timeline))
+		for _, ts := range timeline {
+			i, ok := a.index[ts.UnixNano()]
+			if !ok || i == 0 || a.candles[i-1].Close <= 0 {
+				continue
+			}
+			returns[k][ts.UnixNano()] = a.candles[i].Close/a.candles[i-1].Close - 1
+		}
+	}
+
+	out := make(map[string]map[string]float64, len(assets))
+	for _, a := range assets {
+		out[a.symbol] = map[string]float64{a.symbol: 1}
+	}
+	for x := range assets {
+		for y := x + 1; y < len(assets); y++ {
+			var xs, ys []float64
+			for key, rx := range returns[x] {
+				if ry, ok := returns[y][key]; ok {
+					xs = append(xs, rx)
+					ys = append(ys, ry)
+				}
+			}
+			rho := pearson(xs, ys)
+			out[assets[x].symbol][assets[y].symbol] = rho
+			out[assets[y].symbol][assets[x].symbol] = rho
+		}
+	}
+	return out
+}
+
+func pearson(xs, ys []float64) float64 {
+	if len(xs) < 3 {
+		return 0
+	}
+	mx, my := mean(xs), mean(ys)
+	var cov, vx, vy float64
+	for i := range xs {
+		dx, dy := xs[i]-mx, ys[i]-my
+		cov += dx * dy
+		vx += dx * dx
+		vy += dy * dy
+	}
+	if vx == 0 || vy == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(vx*vy)
+}
+
+func applyPortfolioDefaults(cfg domain.PortfolioConfig) domain.PortfolioConfig {
+	cfg.Base = applyDefaults(cfg.Base)
+	if cfg.RiskPerTrade <= 0 {
+		cfg.RiskPerTrade = DefaultRiskPerTrade
+	}
+	if cfg.MaxAssetExposure <= 0 {
+		cfg.MaxAssetExposure = DefaultMaxAssetExposure
+	}
+	if cfg.MaxGrossExposure <= 0 {
+		cfg.MaxGrossExposure = DefaultMaxGrossExposure
+	}
+	return cfg
+}
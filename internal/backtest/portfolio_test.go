@@ -0,0 +1,227 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+// symbolGenerator fires a scripted signal for a symbol when the newest candle
+// it is shown opens at the scripted time.
+type symbolGenerator struct {
+	at map[string]map[time.Time]domain.Signal
+}
+
+func (g *symbolGenerator) Generate(candles []*domain.Candle) []domain.Signal {
+	last := candles[len(candles)-1]
+	if s, ok := g.at[last.Symbol][last.OpenTime]; ok {
+		return []domain.Signal{s}
+	}
+	return nil
+}
+
+func symbolCandles(symbol string, n int, price float64) []*domain.Candle {
+	out := flatCandles(n, price)
+	for _, c := range out {
+		c.Symbol = symbol
+	}
+	return out
+}
+
+func levels(stop, target float64) *domain.SignalLevels {
+	return &domain.SignalLevels{Stop: stop, Targets: []float64{target}}
+}
+
+func TestPortfolioRunSizesByRiskAndCapsPerAsset(t *testing.T) {
+	btc := symbolCandles("BTC", 20, 100)
+	eth := symbolCandles("ETH", 20, 100)
+	for i := 6; i < 20; i++ {
+		eth[i].Open, eth[i].Close, eth[i].High, eth[i].Low = 110, 110, 111, 109
+	}
+	gen := &symbolGenerator{at: map[string]map[time.Time]domain.Signal{
+		"BTC": {btc[4].OpenTime: {Indicator: domain.IndicatorRSI, Direction: domain.DirectionLong, Risk: domain.RiskLevel2, Levels: levels(95, 120)}},
+		"ETH": {eth[4].OpenTime: {Indicator: domain.IndicatorRSI, Direction: domain.DirectionLong, Risk: domain.RiskLevel4, Levels: levels(98, 120)}},
+	}}
+
+	res, err := NewPortfolioRunner(gen, 10).Run(map[string][]*domain.Candle{"BTC": btc, "ETH": eth}, domain.PortfolioConfig{
+		Base:    domain.BacktestConfig{Interval: "1h", HoldBars: 5, UseStops: true, InitialEquity: 10000},
+		Symbols: []string{"BTC", "ETH"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Trades) != 2 {
+		t.Fatalf("expected 2 trades, got %d", len(res.Trades))
+	}
+	notional := map[string]float64{}
+	for _, tr := range res.Trades {
+		notional[tr.Symbol] = tr.Notional
+		if tr.ExitReason != domain.ExitReasonTime || tr.Bars != 5 {
+			t.Fatalf("unexpected exit: %+v", tr)
+		}
+	}
+	// BTC risks 1% of 10k over a 5 point stop; ETH's risk-4 budget over a
+	// 2 point stop would be 2500, exactly the per-asset cap.
+	if math.Abs(notional["BTC"]-2000) > 1e-9 || math.Abs(notional["ETH"]-2500) > 1e-9 {
+		t.Fatalf("unexpected sizing: %+v", notional)
+	}
+
+	open := res.Exposure[5]
+	if open.Positions != 2 || math.Abs(open.Gross-0.45) > 1e-9 || math.Abs(open.Net-0.45) > 1e-9 {
+		t.Fatalf("unexpected exposure at entry: %+v", open)
+	}
+	// Flat BTC returns leave the pair uncorrelated.
+	if want := math.Sqrt(0.2*0.2 + 0.25*0.25); math.Abs(open.CorrelationAdjusted-want) > 1e-9 {
+		t.Fatalf("expected correlation adjusted %.4f, got %.4f", want, open.CorrelationAdjusted)
+	}
+
+	if res.Metrics.FinalEquity != 10250 {
+		t.Fatalf("expected final equity 10250, got %.4f", res.Metrics.FinalEquity)
+	}
+	if len(res.Assets) != 2 || res.Assets[1].Symbol != "ETH" || res.Assets[1].PnL != 250 || res.Assets[1].Contribution != 0.025 {
+		t.Fatalf("unexpected contributions: %+v", res.Assets)
+	}
+	if res.Assets[0].PnL != 0 || res.Assets[0].AvgExposure <= 0 {
+		t.Fatalf("unexpected BTC contribution: %+v", res.Assets[0])
+	}
+	if res.MaxGrossExposure < 0.45 || res.AvgGrossExposure <= 0 {
+		t.Fatalf("unexpected gross exposure summary: avg %.4f max %.4f", res.AvgGrossExposure, res.MaxGrossExposure)
+	}
+}
+
+func TestPortfolioRunShortCashAccounting(t *testing.T) {
+	btc := symbolCandles("BTC", 20, 100)
+	for i := 6; i < 20; i++ {
+		btc[i].Open, btc[i].Close, btc[i].High, btc[i].Low = 90, 90, 91, 89
+	}
+	gen := &symbolGenerator{at: map[string]map[time.Time]domain.Signal{
+		"BTC": {btc[4].OpenTime: {Indicator: domain.IndicatorMACD, Direction: domain.DirectionShort, Risk: domain.RiskLevel1, Levels: levels(105, 80)}},
+	}}
+
+	res, err := NewPortfolioRunner(gen, 10).Run(map[string][]*domain.Candle{"BTC": btc}, domain.PortfolioConfig{
+		Base:    domain.BacktestConfig{Interval: "1h", HoldBars: 4, UseStops: true, AllowShort: true, FeeBps: 10, InitialEquity: 10000},
+		Symbols: []string{"BTC"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Trades) != 1 {
+		t.Fatalf("expected 1 trade, got %d", len(res.Trades))
+	}
+	tr := res.Trades[0]
+	units := 2000.0 / 100
+	wantFees := units*100*0.001 + units*90*0.001
+	if tr.Units != units || math.Abs(tr.Fees-wantFees) > 1e-9 || math.Abs(tr.PnL-(units*10-wantFees)) > 1e-9 {
+		t.Fatalf("unexpected short trade: %+v", tr)
+	}
+	if res.Exposure[5].Net >= 0 {
+		t.Fatalf("expected negative net exposure while short, got %+v", res.Exposure[5])
+	}
+	if math.Abs(res.Metrics.FinalEquity-(10000+tr.PnL)) > 1e-9 {
+		t.Fatalf("expected final equity to include pnl, got %.4f", res.Metrics.FinalEquity)
+	}
+}
+
+func TestPortfolioRunSkipsDuplicateAndUnsizeableSignals(t *testing.T) {
+	btc := symbolCandles("BTC", 20, 100)
+	eth := symbolCandles("ETH", 20, 100)
+	long := domain.Signal{Indicator: domain.IndicatorRSI, Direction: domain.DirectionLong, Risk: domain.RiskLevel2, Levels: levels(95, 120)}
+	gen := &symbolGenerator{at: map[string]map[time.Time]domain.Signal{
+		"BTC": {btc[4].OpenTime: long, btc[6].OpenTime: long},
+		// No stop and flat prices give no distance to size against.
+		"ETH": {eth[4].OpenTime: {Indicator: domain.IndicatorRSI, Direction: domain.DirectionLong, Risk: domain.RiskLevel2}},
+	}}
+
+	res, err := NewPortfolioRunner(gen, 10).Run(map[string][]*domain.Candle{"BTC": btc, "ETH": eth}, domain.PortfolioConfig{
+		Base:    domain.BacktestConfig{Interval: "1h", HoldBars: 5, UseStops: true, InitialEquity: 10000},
+		Symbols: []string{"BTC", "ETH"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Trades) != 1 || res.Trades[0].Symbol != "BTC" {
+		t.Fatalf("expected a single BTC trade, got %+v", res.Trades)
+	}
+	if res.SkippedSignals != 2 {
+		t.Fatalf("expected 2 skipped signals, got %d", res.SkippedSignals)
+	}
+}
+
+func TestPortfolioRunFallsBackToVolatilityStopAndTarget(t *testing.T) {
+	btc := symbolCandles("BTC", 40, 100)
+	for i := range btc {
+		if i%2 == 1 {
+			btc[i].Open, btc[i].Close = 101, 101
+		}
+	}
+	gen := &symbolGenerator{at: map[string]map[time.Time]domain.Signal{
+		"BTC": {btc[24].OpenTime: {Indicator: domain.IndicatorRSI, Direction: domain.DirectionLong, Risk: domain.RiskLevel1}},
+	}}
+	cfg := domain.PortfolioConfig{
+		Base:             domain.BacktestConfig{Interval: "1h", HoldBars: 3, InitialEquity: 10000},
+		Symbols:          []string{"BTC"},
+		MaxAssetExposure: 1,
+	}
+
+	res, err := NewPortfolioRunner(gen, 10).Run(map[string][]*domain.Candle{"BTC": btc}, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Trades) != 1 {
+		t.Fatalf("expected 1 trade, got %d", len(res.Trades))
+	}
+	vol := trailingVolatility(btc, 25)
+	want := 100 / (2 * vol * btc[25].Open) * btc[25].Open
+	if math.Abs(res.Trades[0].Notional-math.Min(want, 10000)) > 1e-6 {
+		t.Fatalf("expected volatility sized notional %.4f, got %.4f", want, res.Trades[0].Notional)
+	}
+
+	cfg.TargetVolatility = 0.1
+	res, err = NewPortfolioRunner(gen, 10).Run(map[string][]*domain.Candle{"BTC": btc}, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	capped := 10000 * 0.1 / (vol * math.Sqrt(barsPerYear("1h")))
+	if math.Abs(res.Trades[0].Notional-capped) > 1e-6 {
+		t.Fatalf("expected volatility target notional %.4f, got %.4f", capped, res.Trades[0].Notional)
+	}
+}
+
+func TestPortfolioCorrelationMatrix(t *testing.T) {
+	mk := func(symbol string, closes []float64) *assetSeries {
+		a := &assetSeries{symbol: symbol, index: map[int64]int{}}
+		for i, c := range closes {
+			candle := &domain.Candle{Symbol: symbol, OpenTime: time.Unix(int64(i)*3600, 0).UTC(), Close: c}
+			a.candles = append(a.candles, candle)
+			a.index[candle.OpenTime.UnixNano()] = i
+		}
+		return a
+	}
+	up := []float64{100, 102, 101, 105, 104, 108}
+	down := []float64{100, 98, 99, 95, 96, 92}
+	assets := []*assetSeries{mk("A", up), mk("B", up), mk("C", down)}
+	corr := correlationMatrix(assets, portfolioTimeline(assets, time.Time{}, time.Time{}))
+
+	if corr["A"]["A"] != 1 || math.Abs(corr["A"]["B"]-1) > 1e-9 {
+		t.Fatalf("expected identical series to be perfectly correlated: %+v", corr)
+	}
+	if corr["A"]["C"] >= -0.9 || corr["C"]["A"] != corr["A"]["C"] {
+		t.Fatalf("expected mirrored series to be strongly anticorrelated and symmetric: %+v", corr)
+	}
+}
+
+func TestPortfolioRunErrors(t *testing.T) {
+	if _, err := NewPortfolioRunner(nil, 10).Run(nil, domain.PortfolioConfig{}); err == nil {
+		t.Fatal("expected error without generator")
+	}
+	gen := &symbolGenerator{}
+	if _, err := NewPortfolioRunner(gen, 10).Run(map[string][]*domain.Candle{}, domain.PortfolioConfig{Symbols: []string{"BTC"}}); err == nil {
+		t.Fatal("expected error without candles")
+	}
+	one := map[string][]*domain.Candle{"BTC": symbolCandles("BTC", 1, 100)}
+	if _, err := NewPortfolioRunner(gen, 10).Run(one, domain.PortfolioConfig{Symbols: []string{"BTC"}}); err == nil {
+		t.Fatal("expected error for a single bar window")
+	}
+}
@@ -0,0 +1,48 @@
+package backtest
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// EngineVersion is bumped whenever a simulator change can move results for an
+// unchanged config (fill model, exit ordering, metric definitions).
+const EngineVersion = "1"
+
+var (
+	versionOnce sync.Once
+	version     string
+)
+
+// Version identifies the engine that produced a run: EngineVersion plus the
+// VCS revision the binary was built from, when the build recorded one.
+func Version() string {
+	versionOnce.Do(func() {
+		version = "engine/" + EngineVersion
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		var revision string
+		var dirty bool
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				revision = s.Value
+			case "vcs.modified":
+				dirty = s.Value == "true"
+			}
+		}
+		if revision == "" {
+			return
+		}
+		if len(revision) > 12 {
+			revision = revision[:12]
+		}
+		version += "+" + revision
+		if dirty {
+			version += "-dirty"
+		}
+	})
+	return version
+}
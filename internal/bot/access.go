@@ -0,0 +1,264 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"bug-free-umbrella/internal/domain"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// AccessManager decides who may use the bot. Role returns "" for chats that
+// have not been let in.
+type AccessManager interface {
+	Role(ctx context.Context, chatID int64) (domain.UserRole, error)
+	Redeem(ctx context.Context, chatID int64, username, code string) (*domain.TelegramUser, error)
+	CreateInvite(ctx context.Context, createdBy int64, role domain.UserRole) (*domain.TelegramInvite, error)
+	ListUsers(ctx context.Context) ([]domain.TelegramUser, error)
+	SetRole(ctx context.Context, actor, chatID int64, role domain.UserRole) (*domain.TelegramUser, error)
+	RemoveUser(ctx context.Context, chatID int64) (bool, error)
+}
+
+const inviteOnlyReply = "This bot is invite-only. Ask an admin for an invite code and send /start <code>."
+
+// commandRoles is the lowest role that may run each command. Commands not
+// listed need viewer.
+var commandRoles = map[string]domain.UserRole{
+	"/ask":       domain.RoleTrader,
+	"/reset":     domain.RoleTrader,
+	"/forget":    domain.RoleTrader,
+	"/buy":       domain.RoleTrader,
+	"/sell":      domain.RoleTrader,
+	"/paper":     domain.RoleTrader,
+	"/portfolio": domain.RoleTrader,
+	"/size":      domain.RoleTrader,
+	"/alert":     domain.RoleTrader,
+	"/invite":    domain.RoleAdmin,
+	"/users":     domain.RoleAdmin,
+	"/train":     domain.RoleAdmin,
+	"/intel":     domain.RoleAdmin,
+	"/broadcast": domain.RoleAdmin,
+	"/jobs":      domain.RoleAdmin,
+}
+
+// accessRequest is what the guard needs to know about an incoming update.
+type accessRequest struct {
+	ChatID   int64
+	SenderID int64
+	Group    bool
+	// Command is the slash command, "text" for free text, "document" for
+	// uploads or "callback" for button presses.
+	Command  string
+	Args     []string
+	Callback string
+}
+
+// requiredRole returns the lowest role allowed to make r.
+func (r accessRequest) requiredRole() domain.UserRole {
+	switch r.Command {
+	case "text", "document":
+		return domain.RoleTrader
+	case "callback":
+		if action, _, _ := strings.Cut(r.Callback, "|"); action == browseActionAsk {
+			return domain.RoleTrader
+		}
+		return domain.RoleViewer
+	}
+	if role, ok := commandRoles[r.Command]; ok {
+		return role
+	}
+	return domain.RoleViewer
+}
+
+// changesChatSettings reports whether r edits the chat's alert or digest
+// settings rather than reading them.
+func (r accessRequest) changesChatSettings() bool {
+	sub := ""
+	if len(r.Args) > 0 {
+		sub = strings.ToLower(r.Args[0])
+	}
+	switch r.Command {
+	case "/alerts":
+		return sub != "" && sub != "status"
+	case "/alert":
+		return sub == "add" || sub == "rm" || sub == "remove" || sub == "delete"
+	case "/digest":
+		if _, onDemand := parseOnDemandDigest(r.Args); onDemand {
+			return false
+		}
+		return sub != "status"
+	}
+	return false
+}
+
+// accessGuard enforces roles on every update. In group chats the group's
+// own role and the sender's both count, admin commands need the sender to be
+// a bot admin, and only group admins may change the group's settings.
+type accessGuard struct {
+	access     AccessManager
+	groupAdmin func(chat *tele.Chat, userID int64) bool
+}
+
+func newAccessGuard(b *tele.Bot, access AccessManager) *accessGuard {
+	return &accessGuard{
+		access: access,
+		groupAdmin: func(chat *tele.Chat, userID int64) bool {
+			members, err := b.AdminsOf(chat)
+			if err != nil {
+				log.Printf("failed to load admins of chat %d: %v", chat.ID, err)
+				return false
+			}
+			for _, m := range members {
+				if m.User != nil && m.User.ID == userID {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// authorize decides r. When it is refused, reply says why; an empty reply
+// means the update is dropped silently.
+func (g *accessGuard) authorize(ctx context.Context, r accessRequest, chat *tele.Chat) (bool, string) {
+	required := r.requiredRole()
+	if g.access == nil {
+		if required == domain.RoleAdmin {
+			return false, "Admin commands unavailable."
+		}
+		return true, ""
+	}
+
+	sender, err := g.access.Role(ctx, r.SenderID)
+	if err != nil {
+		log.Printf("access check failed for user %d: %v", r.SenderID, err)
+		return false, "Unable to check access right now. Try again shortly."
+	}
+	role := sender
+	if r.Group {
+		chatRole, err := g.access.Role(ctx, r.ChatID)
+		if err != nil {
+			log.Printf("access check failed for chat %d: %v", r.ChatID, err)
+			return false, "Unable to check access right now. Try again shortly."
+		}
+		if chatRole.Rank() > role.Rank() {
+			role = chatRole
+		}
+	}
+
+	switch {
+	case required == domain.RoleAdmin && sender != domain.RoleAdmin:
+		return false, "That command is for bot admins."
+	case role == "":
+		if r.Group && r.Command == "text" {
+			return false, ""
+		}
+		return false, inviteOnlyReply
+	case !role.Allows(required):
+		if r.Command == "text" {
+			if r.Group {
+				return false, ""
+			}
+			return false, fmt.Sprintf("Your role (%s) can't ask the advisor. Use /price or /signals instead.", role)
+		}
+		return false, fmt.Sprintf("Your role (%s) can't use %s.", role, r.Command)
+	}
+
+	if r.Group && sender != domain.RoleAdmin && r.changesChatSettings() &&
+		(chat == nil || g.groupAdmin == nil || !g.groupAdmin(chat, r.SenderID)) {
+		return false, "Only group admins can change this chat's settings."
+	}
+	return true, ""
+}
+
+// middleware runs authorize ahead of every handler. /start is always let
+// through so invite codes can be redeemed. Updates without a sender or chat,
+// such as channel posts, have no role to check, so only what a viewer may do
+// gets through.
+func (g *accessGuard) middleware(next tele.HandlerFunc) tele.HandlerFunc {
+	return func(c tele.Context) error {
+		chat, sender := c.Chat(), c.Sender()
+		var r accessRequest
+		switch {
+		case c.Callback() != nil:
+			r.Command, r.Callback = "callback", c.Callback().Data
+		case c.Message() != nil && c.Message().Document != nil:
+			r.Command = "document"
+		case c.Message() != nil && strings.HasPrefix(c.Text(), "/"):
+			r.Command, _, _ = strings.Cut(strings.Fields(c.Text())[0], "@")
+			r.Args = c.Args()
+		default:
+			r.Command = "text"
+		}
+		if r.Command == "/start" {
+			return next(c)
+		}
+		if chat == nil || sender == nil {
+			if r.requiredRole() != domain.RoleViewer {
+				log.Printf("dropped %s from an unidentified sender", r.Command)
+				return nil
+			}
+			return next(c)
+		}
+		r.ChatID, r.SenderID = chat.ID, sender.ID
+		r.Group = chat.Type == tele.ChatGroup || chat.Type == tele.ChatSuperGroup
+
+		ok, reply := g.authorize(context.Background(), r, chat)
+		if ok {
+			return next(c)
+		}
+		if reply == "" {
+			return nil
+		}
+		if c.Callback() != nil {
+			return c.Respond(&tele.CallbackResponse{Text: reply, ShowAlert: true})
+		}
+		return c.Send(reply)
+	}
+}
+
+// registerAccessCommands adds the guard and /start, which redeems invite
+// codes.
+func registerAccessCommands(b *tele.Bot, access AccessManager) {
+	guard := newAccessGuard(b, access)
+	b.Use(guard.middleware)
+
+	b.Handle("/start", func(c tele.Context) error {
+		chat := c.Chat()
+		if chat == nil {
+			return c.Send("Unable to detect chat.")
+		}
+		username := ""
+		if s := c.Sender(); s != nil {
+			username = s.Username
+		}
+		return c.Send(handleStartCommand(context.Background(), access, chat.ID, username, c.Message().Payload))
+	})
+}
+
+// handleStartCommand greets the chat, redeeming code when one is given.
+func handleStartCommand(ctx context.Context, access AccessManager, chatID int64, username, code string) string {
+	const welcome = "Welcome! Try /price BTC or /signals."
+	if access == nil {
+		return welcome
+	}
+	code = strings.TrimSpace(code)
+	if code == "" {
+		role, err := access.Role(ctx, chatID)
+		if err != nil {
+			return fmt.Sprintf("Unable to check access: %v", err)
+		}
+		if role == "" {
+			return inviteOnlyReply
+		}
+		return fmt.Sprintf("%s\nYour role: %s", welcome, role)
+	}
+	user, err := access.Redeem(ctx, chatID, username, code)
+	if err != nil {
+		return fmt.Sprintf("Unable to redeem invite: %v", err)
+	}
+	return fmt.Sprintf("Access granted as %s.\n%s", user.Role, welcome)
+}
@@ -0,0 +1,227 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+type fakeAccess struct {
+	roles    map[int64]domain.UserRole
+	err      error
+	redeemed []string
+	users    []domain.TelegramUser
+}
+
+func (f *fakeAccess) Role(ctx context.Context, chatID int64) (domain.UserRole, error) {
+	return f.roles[chatID], f.err
+}
+
+func (f *fakeAccess) Redeem(ctx context.Context, chatID int64, username, code string) (*domain.TelegramUser, error) {
+	if code != "GOOD" {
+		return nil, errors.New("invite code is invalid, used or expired")
+	}
+	f.redeemed = append(f.redeemed, code)
+	return &domain.TelegramUser{ChatID: chatID, Username: username, Role: domain.RoleViewer}, nil
+}
+
+func (f *fakeAccess) CreateInvite(ctx context.Context, createdBy int64, role domain.UserRole) (*domain.TelegramInvite, error) {
+	return &domain.TelegramInvite{
+		Code:      "CODE123",
+		Role:      role,
+		CreatedBy: createdBy,
+		ExpiresAt: time.Date(2026, 6, 8, 9, 0, 0, 0, time.UTC),
+	}, nil
+}
+
+func (f *fakeAccess) ListUsers(ctx context.Context) ([]domain.TelegramUser, error) {
+	return f.users, f.err
+}
+
+func (f *fakeAccess) SetRole(ctx context.Context, actor, chatID int64, role domain.UserRole) (*domain.TelegramUser, error) {
+	if !role.IsValid() {
+		return nil, errors.New("role must be viewer, trader or admin")
+	}
+	return &domain.TelegramUser{ChatID: chatID, Role: role}, nil
+}
+
+func (f *fakeAccess) RemoveUser(ctx context.Context, chatID int64) (bool, error) {
+	return chatID == 7, nil
+}
+
+func TestAccessGuardRoles(t *testing.T) {
+	access := &fakeAccess{roles: map[int64]domain.UserRole{
+		1: domain.RoleAdmin,
+		2: domain.RoleViewer,
+		3: domain.RoleTrader,
+	}}
+	g := &accessGuard{access: access}
+
+	cases := []struct {
+		name  string
+		req   accessRequest
+		ok    bool
+		reply string
+	}{
+		{"stranger", accessRequest{ChatID: 9, SenderID: 9, Command: "/price"}, false, "invite-only"},
+		{"viewer reads", accessRequest{ChatID: 2, SenderID: 2, Command: "/signals"}, true, ""},
+		{"viewer asks", accessRequest{ChatID: 2, SenderID: 2, Command: "text"}, false, "can't ask the advisor"},
+		{"viewer trades", accessRequest{ChatID: 2, SenderID: 2, Command: "/buy"}, false, "can't use /buy"},
+		{"viewer ask button", accessRequest{ChatID: 2, SenderID: 2, Command: "callback", Callback: browseActionAsk + "|t|0"}, false, "viewer"},
+		{"viewer page button", accessRequest{ChatID: 2, SenderID: 2, Command: "callback", Callback: browseActionPage + "|t|1"}, true, ""},
+		{"trader asks", accessRequest{ChatID: 3, SenderID: 3, Command: "text"}, true, ""},
+		{"trader admin command", accessRequest{ChatID: 3, SenderID: 3, Command: "/train"}, false, "bot admins"},
+		{"admin", accessRequest{ChatID: 1, SenderID: 1, Command: "/broadcast"}, true, ""},
+	}
+	for _, tc := range cases {
+		ok, reply := g.authorize(context.Background(), tc.req, nil)
+		if ok != tc.ok || !strings.Contains(reply, tc.reply) {
+			t.Fatalf("%s: got ok=%v reply=%q", tc.name, ok, reply)
+		}
+	}
+
+	access.err = errors.New("db down")
+	if ok, reply := g.authorize(context.Background(), accessRequest{ChatID: 1, SenderID: 1, Command: "/price"}, nil); ok || !strings.Contains(reply, "Unable to check access") {
+		t.Fatalf("expected a refusal on lookup errors, got %v %q", ok, reply)
+	}
+}
+
+func TestAccessGuardGroups(t *testing.T) {
+	access := &fakeAccess{roles: map[int64]domain.UserRole{
+		-100: domain.RoleTrader,
+		1:    domain.RoleAdmin,
+		-200: domain.RoleViewer,
+	}}
+	var groupAdmins map[int64]bool
+	g := &accessGuard{access: access, groupAdmin: func(chat *tele.Chat, userID int64) bool { return groupAdmins[userID] }}
+	chat := &tele.Chat{ID: -100, Type: tele.ChatSuperGroup}
+	ctx := context.Background()
+
+	// Members of an allowed group use it with the group's role.
+	if ok, _ := g.authorize(ctx, accessRequest{ChatID: -100, SenderID: 5, Group: true, Command: "text"}, chat); !ok {
+		t.Fatal("expected group member to ask the advisor")
+	}
+	// Free text in unknown groups is ignored without a reply.
+	if ok, reply := g.authorize(ctx, accessRequest{ChatID: -300, SenderID: 5, Group: true, Command: "text"}, chat); ok || reply != "" {
+		t.Fatalf("expected silent refusal, got %v %q", ok, reply)
+	}
+	// Free text a viewer group cannot use is ignored too.
+	if ok, reply := g.authorize(ctx, accessRequest{ChatID: -200, SenderID: 5, Group: true, Command: "text"}, chat); ok || reply != "" {
+		t.Fatalf("expected silent refusal, got %v %q", ok, reply)
+	}
+	// The group's role never grants admin commands.
+	if ok, _ := g.authorize(ctx, accessRequest{ChatID: -100, SenderID: 5, Group: true, Command: "/users"}, chat); ok {
+		t.Fatal("expected admin command to be refused to a group member")
+	}
+
+	change := accessRequest{ChatID: -100, SenderID: 5, Group: true, Command: "/alerts", Args: []string{"on"}}
+	if ok, reply := g.authorize(ctx, change, chat); ok || !strings.Contains(reply, "group admins") {
+		t.Fatalf("expected settings change refused, got %v %q", ok, reply)
+	}
+	groupAdmins = map[int64]bool{5: true}
+	if ok, _ := g.authorize(ctx, change, chat); !ok {
+		t.Fatal("expected group admin to change settings")
+	}
+	groupAdmins = nil
+	change.SenderID = 1
+	if ok, _ := g.authorize(ctx, change, chat); !ok {
+		t.Fatal("expected bot admin to change settings")
+	}
+
+	read := accessRequest{ChatID: -100, SenderID: 5, Group: true, Command: "/alerts", Args: []string{"status"}}
+	if ok, _ := g.authorize(ctx, read, chat); !ok {
+		t.Fatal("expected anyone to read settings")
+	}
+}
+
+func TestAccessRequestChangesChatSettings(t *testing.T) {
+	cases := map[string]bool{
+		"/alerts":               false,
+		"/alerts status":        false,
+		"/alerts off":           true,
+		"/alerts symbols BTC":   true,
+		"/alert list":           false,
+		"/alert add BTC > 1":    true,
+		"/alert rm 3":           true,
+		"/digest":               false,
+		"/digest weekly":        false,
+		"/digest status":        false,
+		"/digest daily 08:00":   true,
+		"/digest off":           true,
+		"/price BTC":            false,
+		"/signals BTC --risk 2": false,
+	}
+	for text, want := range cases {
+		fields := strings.Fields(text)
+		r := accessRequest{Command: fields[0], Args: fields[1:]}
+		if got := r.changesChatSettings(); got != want {
+			t.Fatalf("%q: expected %v, got %v", text, want, got)
+		}
+	}
+}
+
+func TestAccessGuardWithoutManager(t *testing.T) {
+	g := &accessGuard{}
+	if ok, _ := g.authorize(context.Background(), accessRequest{ChatID: 9, SenderID: 9, Command: "text"}, nil); !ok {
+		t.Fatal("expected an open bot without an access manager")
+	}
+	if ok, _ := g.authorize(context.Background(), accessRequest{ChatID: 9, SenderID: 9, Command: "/jobs"}, nil); ok {
+		t.Fatal("expected admin commands refused without an access manager")
+	}
+}
+
+func TestAccessGuardMiddlewareWithoutSender(t *testing.T) {
+	b, err := tele.NewBot(tele.Settings{Offline: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	g := &accessGuard{access: &fakeAccess{roles: map[int64]domain.UserRole{}}}
+	handled := 0
+	h := g.middleware(func(tele.Context) error { handled++; return nil })
+
+	channel := &tele.Chat{ID: -100, Type: tele.ChatChannel}
+	for _, msg := range []*tele.Message{
+		{Text: "/buy BTC 1", Chat: channel},
+		{Text: "/jobs", Chat: channel},
+		{Text: "what now?", Chat: channel},
+		{Text: "/users"},
+	} {
+		if err := h(b.NewContext(tele.Update{Message: msg})); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if handled != 0 {
+			t.Fatalf("expected %q refused without a sender", msg.Text)
+		}
+	}
+
+	if err := h(b.NewContext(tele.Update{Message: &tele.Message{Text: "/price BTC", Chat: channel}})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if handled != 1 {
+		t.Fatal("expected viewer commands let through without a sender")
+	}
+}
+
+func TestHandleStartCommand(t *testing.T) {
+	access := &fakeAccess{roles: map[int64]domain.UserRole{3: domain.RoleTrader}}
+	ctx := context.Background()
+
+	if got := handleStartCommand(ctx, access, 9, "", ""); got != inviteOnlyReply {
+		t.Fatalf("expected invite-only reply, got %q", got)
+	}
+	if got := handleStartCommand(ctx, access, 3, "", ""); !strings.Contains(got, "Your role: trader") {
+		t.Fatalf("expected role in greeting, got %q", got)
+	}
+	if got := handleStartCommand(ctx, access, 9, "bob", "GOOD"); !strings.Contains(got, "Access granted as viewer") {
+		t.Fatalf("expected access granted, got %q", got)
+	}
+	if got := handleStartCommand(ctx, access, 9, "bob", "BAD"); !strings.Contains(got, "Unable to redeem invite") {
+		t.Fatalf("expected redeem failure, got %q", got)
+	}
+}
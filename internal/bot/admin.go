@@ -0,0 +1,269 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/ml/training"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+type MLTrainer interface {
+	RunTraining(ctx context.Context) ([]training.ModelTrainResult, error)
+}
+
+type MarketIntelRunner interface {
+	RunMarketIntel(ctx context.Context) (domain.MarketIntelRunResult, error)
+}
+
+// AdminTools are the operations behind the admin-only commands. A nil field
+// makes its command report that it is unavailable.
+type AdminTools struct {
+	Training    MLTrainer
+	MarketIntel MarketIntelRunner
+	Jobs        func() []domain.JobStatus
+}
+
+const usersUsage = "Usage:\n/users - list chats with access\n/users set <chat_id> <viewer|trader|admin>\n/users remove <chat_id>"
+
+// registerAdminCommands adds the commands the access guard reserves for bot
+// admins. /train and /intel run in the background and report back when done,
+// one run at a time each.
+func registerAdminCommands(b *tele.Bot, access AccessManager, tools AdminTools, alerts *AlertDispatcher) {
+	b.Handle("/invite", func(c tele.Context) error {
+		if access == nil {
+			return c.Send("Access control unavailable")
+		}
+		return c.Send(handleInviteCommand(context.Background(), access, c.Sender().ID, b.Me.Username, c.Args()))
+	})
+
+	b.Handle("/users", func(c tele.Context) error {
+		if access == nil {
+			return c.Send("Access control unavailable")
+		}
+		return c.Send(handleUsersCommand(context.Background(), access, c.Sender().ID, c.Args()))
+	})
+
+	var trainingRunning, intelRunning atomic.Bool
+	b.Handle("/train", func(c tele.Context) error {
+		if tools.Training == nil {
+			return c.Send("ML training unavailable. Set ML_ENABLED=true to enable.")
+		}
+		if !trainingRunning.CompareAndSwap(false, true) {
+			return c.Send("Training is already running.")
+		}
+		chat := c.Chat()
+		go func() {
+			defer trainingRunning.Store(false)
+			results, err := tools.Training.RunTraining(context.Background())
+			if _, err := b.Send(chat, formatTrainingResults(results, err)); err != nil {
+				log.Printf("failed to report training to chat %d: %v", chat.ID, err)
+			}
+		}()
+		return c.Send("Training started. I'll report back when it finishes.")
+	})
+
+	b.Handle("/intel", func(c tele.Context) error {
+		if args := c.Args(); len(args) != 1 || strings.ToLower(args[0]) != "run" {
+			return c.Send("Usage: /intel run")
+		}
+		if tools.MarketIntel == nil {
+			return c.Send("Market intel unavailable. Set MARKET_INTEL_ENABLED=true to enable.")
+		}
+		if !intelRunning.CompareAndSwap(false, true) {
+			return c.Send("A market intel cycle is already running.")
+		}
+		chat := c.Chat()
+		go func() {
+			defer intelRunning.Store(false)
+			result, err := tools.MarketIntel.RunMarketIntel(context.Background())
+			if _, err := b.Send(chat, formatMarketIntelResult(result, err)); err != nil {
+				log.Printf("failed to report market intel to chat %d: %v", chat.ID, err)
+			}
+		}()
+		return c.Send("Market intel cycle started.")
+	})
+
+	b.Handle("/broadcast", func(c tele.Context) error {
+		if access == nil {
+			return c.Send("Access control unavailable")
+		}
+		return c.Send(handleBroadcastCommand(context.Background(), access, alerts, c.Message().Payload))
+	})
+
+	b.Handle("/jobs", func(c tele.Context) error {
+		if tools.Jobs == nil {
+			return c.Send("Job status unavailable")
+		}
+		return c.Send(formatJobStatuses(tools.Jobs(), time.Now()))
+	})
+}
+
+// handleInviteCommand creates an invite and returns how to use it.
+func handleInviteCommand(ctx context.Context, access AccessManager, createdBy int64, botUsername string, args []string) string {
+	role := domain.RoleViewer
+	if len(args) > 0 {
+		role = domain.UserRole(strings.ToLower(args[0]))
+	}
+	if len(args) > 1 || !role.IsValid() {
+		return "Usage: /invite [viewer|trader|admin]"
+	}
+	inv, err := access.CreateInvite(ctx, createdBy, role)
+	if err != nil {
+		return fmt.Sprintf("Unable to create invite: %v", err)
+	}
+	msg := fmt.Sprintf("Invite for a %s, valid until %s:\n/start %s", inv.Role, inv.ExpiresAt.UTC().Format("2006-01-02 15:04 UTC"), inv.Code)
+	if botUsername != "" {
+		msg += fmt.Sprintf("\nhttps://t.me/%s?start=%s", botUsername, inv.Code)
+	}
+	return msg
+}
+
+// handleUsersCommand runs one /users subcommand and returns the reply.
+func handleUsersCommand(ctx context.Context, access AccessManager, actor int64, args []string) string {
+	if len(args) == 0 {
+		users, err := access.ListUsers(ctx)
+		if err != nil {
+			return fmt.Sprintf("Error loading users: %v", err)
+		}
+		return formatTelegramUsers(users)
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "set":
+		if len(args) != 3 {
+			return usersUsage
+		}
+		chatID, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return usersUsage
+		}
+		user, err := access.SetRole(ctx, actor, chatID, domain.UserRole(strings.ToLower(args[2])))
+		if err != nil {
+			return fmt.Sprintf("Unable to set role: %v", err)
+		}
+		return fmt.Sprintf("%d is now a %s.", user.ChatID, user.Role)
+	case "remove", "rm":
+		if len(args) != 2 {
+			return usersUsage
+		}
+		chatID, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return usersUsage
+		}
+		removed, err := access.RemoveUser(ctx, chatID)
+		if err != nil {
+			return fmt.Sprintf("Unable to remove user: %v", err)
+		}
+		if !removed {
+			return fmt.Sprintf("%d had no access.", chatID)
+		}
+		return fmt.Sprintf("Access removed for %d.", chatID)
+	}
+	return usersUsage
+}
+
+// handleBroadcastCommand sends text to every chat with access.
+func handleBroadcastCommand(ctx context.Context, access AccessManager, alerts *AlertDispatcher, text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "Usage: /broadcast <message>"
+	}
+	users, err := access.ListUsers(ctx)
+	if err != nil {
+		return fmt.Sprintf("Error loading users: %v", err)
+	}
+	ids := make([]int64, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.ChatID)
+	}
+	if len(ids) == 0 {
+		return "No chats to broadcast to."
+	}
+	if err := alerts.Broadcast(ctx, ids, "Announcement:\n"+text); err != nil {
+		return fmt.Sprintf("Broadcast failed: %v", err)
+	}
+	return fmt.Sprintf("Broadcast sent to %d chat(s).", len(ids))
+}
+
+func formatTelegramUsers(users []domain.TelegramUser) string {
+	if len(users) == 0 {
+		return "No chats have access yet. Create an invite with /invite"
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d chat(s) with access:", len(users))
+	for _, u := range users {
+		fmt.Fprintf(&sb, "\n%d %s", u.ChatID, u.Role)
+		if u.Username != "" {
+			fmt.Fprintf(&sb, " @%s", u.Username)
+		}
+		if u.ChatID < 0 {
+			sb.WriteString(" (group)")
+		}
+	}
+	return sb.String()
+}
+
+func formatTrainingResults(results []training.ModelTrainResult, err error) string {
+	if err != nil {
+		return fmt.Sprintf("Training failed: %v", err)
+	}
+	if len(results) == 0 {
+		return "Training finished with no models trained."
+	}
+	var sb strings.Builder
+	sb.WriteString("Training finished:")
+	for _, r := range results {
+		fmt.Fprintf(&sb, "\n%s %s v%d AUC %.4f (%d samples)", r.ModelKey, r.Interval, r.Version, r.AUC, r.SampleCount)
+		switch {
+		case r.Promoted:
+			sb.WriteString(" - promoted")
+		case r.PromoteError != nil:
+			fmt.Fprintf(&sb, " - not promoted: %v", r.PromoteError)
+		}
+	}
+	return sb.String()
+}
+
+func formatMarketIntelResult(r domain.MarketIntelRunResult, err error) string {
+	if err != nil {
+		return fmt.Sprintf("Market intel cycle failed: %v", err)
+	}
+	msg := fmt.Sprintf(
+		"Market intel cycle complete: %d ingested, %d scored, %d on-chain snapshots, %d composites, %d signals.",
+		r.ItemsIngested, r.ItemsScored, r.OnChainSnapshots, r.CompositesWritten, r.SignalsWritten,
+	)
+	if len(r.Errors) > 0 {
+		msg += fmt.Sprintf("\nWarnings (%d):\n%s", len(r.Errors), strings.Join(r.Errors, "\n"))
+	}
+	return msg
+}
+
+func formatJobStatuses(jobs []domain.JobStatus, now time.Time) string {
+	if len(jobs) == 0 {
+		return "No background jobs have run yet."
+	}
+	var sb strings.Builder
+	sb.WriteString("Background jobs:")
+	for _, j := range jobs {
+		state := "ok"
+		if j.LastError != "" {
+			state = "failing"
+		}
+		fmt.Fprintf(&sb, "\n%s: %s, last run %s ago (%s), %d run(s), %d failure(s)",
+			j.Name, state,
+			now.Sub(j.LastRun).Round(time.Second), j.LastDuration.Round(time.Millisecond),
+			j.Runs, j.Failures)
+		if j.LastError != "" {
+			fmt.Fprintf(&sb, "\n  last error: %s", j.LastError)
+		}
+	}
+	return sb.String()
+}
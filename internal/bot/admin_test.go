@@ -0,0 +1,105 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/ml/training"
+)
+
+func TestHandleInviteCommand(t *testing.T) {
+	access := &fakeAccess{}
+	ctx := context.Background()
+
+	got := handleInviteCommand(ctx, access, 1, "adadbot", nil)
+	if !strings.Contains(got, "Invite for a viewer") || !strings.Contains(got, "/start CODE123") ||
+		!strings.Contains(got, "https://t.me/adadbot?start=CODE123") {
+		t.Fatalf("unexpected invite reply: %q", got)
+	}
+	if got := handleInviteCommand(ctx, access, 1, "", []string{"Trader"}); !strings.Contains(got, "Invite for a trader") || strings.Contains(got, "t.me") {
+		t.Fatalf("unexpected invite reply: %q", got)
+	}
+	if got := handleInviteCommand(ctx, access, 1, "", []string{"owner"}); !strings.HasPrefix(got, "Usage") {
+		t.Fatalf("expected usage, got %q", got)
+	}
+}
+
+func TestHandleUsersCommand(t *testing.T) {
+	access := &fakeAccess{users: []domain.TelegramUser{
+		{ChatID: 1, Role: domain.RoleAdmin, Username: "alice"},
+		{ChatID: -1001, Role: domain.RoleViewer},
+	}}
+	ctx := context.Background()
+
+	list := handleUsersCommand(ctx, access, 1, nil)
+	if !strings.Contains(list, "1 admin @alice") || !strings.Contains(list, "-1001 viewer (group)") {
+		t.Fatalf("unexpected list: %q", list)
+	}
+	if got := handleUsersCommand(ctx, access, 1, []string{"set", "7", "trader"}); got != "7 is now a trader." {
+		t.Fatalf("unexpected set reply: %q", got)
+	}
+	if got := handleUsersCommand(ctx, access, 1, []string{"set", "7", "owner"}); !strings.Contains(got, "Unable to set role") {
+		t.Fatalf("expected set failure, got %q", got)
+	}
+	if got := handleUsersCommand(ctx, access, 1, []string{"remove", "7"}); got != "Access removed for 7." {
+		t.Fatalf("unexpected remove reply: %q", got)
+	}
+	if got := handleUsersCommand(ctx, access, 1, []string{"rm", "8"}); got != "8 had no access." {
+		t.Fatalf("unexpected remove reply: %q", got)
+	}
+	if got := handleUsersCommand(ctx, access, 1, []string{"set", "x", "viewer"}); got != usersUsage {
+		t.Fatalf("expected usage, got %q", got)
+	}
+}
+
+func TestHandleBroadcastCommand(t *testing.T) {
+	sender := &fakeSender{}
+	alerts := NewAlertDispatcher(sender, nil)
+	access := &fakeAccess{users: []domain.TelegramUser{{ChatID: 1}, {ChatID: -1001}}}
+
+	if got := handleBroadcastCommand(context.Background(), access, alerts, "  "); !strings.HasPrefix(got, "Usage") {
+		t.Fatalf("expected usage, got %q", got)
+	}
+	got := handleBroadcastCommand(context.Background(), access, alerts, "Maintenance at 10:00 UTC")
+	if got != "Broadcast sent to 2 chat(s)." {
+		t.Fatalf("unexpected reply: %q", got)
+	}
+	if len(sender.messages[1]) != 1 || !strings.Contains(sender.messages[-1001][0], "Maintenance at 10:00 UTC") {
+		t.Fatalf("unexpected messages: %+v", sender.messages)
+	}
+}
+
+func TestFormatAdminResults(t *testing.T) {
+	got := formatTrainingResults([]training.ModelTrainResult{
+		{ModelKey: "logreg", Interval: "1h", Version: 3, AUC: 0.61234, SampleCount: 900, Promoted: true},
+		{ModelKey: "xgb", Interval: "4h", Version: 2, AUC: 0.5, PromoteError: errors.New("auc below floor")},
+	}, nil)
+	if !strings.Contains(got, "logreg 1h v3 AUC 0.6123 (900 samples) - promoted") || !strings.Contains(got, "not promoted: auc below floor") {
+		t.Fatalf("unexpected training summary: %q", got)
+	}
+	if got := formatTrainingResults(nil, errors.New("no rows")); got != "Training failed: no rows" {
+		t.Fatalf("unexpected training failure: %q", got)
+	}
+
+	intel := formatMarketIntelResult(domain.MarketIntelRunResult{ItemsIngested: 4, SignalsWritten: 2, Errors: []string{"reddit: 429"}}, nil)
+	if !strings.Contains(intel, "4 ingested") || !strings.Contains(intel, "2 signals") || !strings.Contains(intel, "reddit: 429") {
+		t.Fatalf("unexpected intel summary: %q", intel)
+	}
+
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	jobs := formatJobStatuses([]domain.JobStatus{
+		{Name: "digest", Runs: 10, LastRun: now.Add(-90 * time.Second), LastDuration: 40 * time.Millisecond},
+		{Name: "market-intel", Runs: 3, Failures: 1, LastRun: now.Add(-time.Hour), LastError: "timeout"},
+	}, now)
+	if !strings.Contains(jobs, "digest: ok, last run 1m30s ago (40ms), 10 run(s), 0 failure(s)") ||
+		!strings.Contains(jobs, "market-intel: failing") || !strings.Contains(jobs, "last error: timeout") {
+		t.Fatalf("unexpected jobs summary: %q", jobs)
+	}
+	if got := formatJobStatuses(nil, now); !strings.Contains(got, "No background jobs") {
+		t.Fatalf("unexpected empty summary: %q", got)
+	}
+}
@@ -0,0 +1,48 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// AdvisorMemory clears what the advisor remembers about a chat.
+type AdvisorMemory interface {
+	Reset(ctx context.Context, chatID int64) error
+	Forget(ctx context.Context, chatID int64) error
+}
+
+// registerAdvisorMemoryCommands adds /reset, which starts the advisor
+// conversation afresh but keeps remembered facts, and /forget, which erases
+// everything the advisor stored for the chat.
+func registerAdvisorMemoryCommands(b *tele.Bot, memory AdvisorMemory) {
+	for _, command := range []string{"/reset", "/forget"} {
+		b.Handle(command, func(c tele.Context) error {
+			chat := c.Chat()
+			if chat == nil {
+				return c.Send("Unable to detect chat.")
+			}
+			return c.Send(handleAdvisorMemoryCommand(context.Background(), memory, chat.ID, command))
+		})
+	}
+}
+
+func handleAdvisorMemoryCommand(ctx context.Context, memory AdvisorMemory, chatID int64, command string) string {
+	if memory == nil {
+		return "Advisor not configured."
+	}
+	if command == "/forget" {
+		if err := memory.Forget(ctx, chatID); err != nil {
+			log.Printf("advisor forget error for chat %d: %v", chatID, err)
+			return fmt.Sprintf("Unable to forget this conversation: %v", err)
+		}
+		return "Deleted our conversation, its summary and everything I remembered about you."
+	}
+	if err := memory.Reset(ctx, chatID); err != nil {
+		log.Printf("advisor reset error for chat %d: %v", chatID, err)
+		return fmt.Sprintf("Unable to reset the conversation: %v", err)
+	}
+	return "Started a fresh conversation. Facts I remembered about you are kept; /forget erases everything."
+}
@@ -0,0 +1,52 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHandleAdvisorMemoryCommand(t *testing.T) {
+	if msg := handleAdvisorMemoryCommand(context.Background(), nil, 1, "/reset"); msg != "Advisor not configured." {
+		t.Fatalf("unexpected reply without an advisor: %q", msg)
+	}
+
+	mem := &stubAdvisorMemory{}
+	if msg := handleAdvisorMemoryCommand(context.Background(), mem, 7, "/reset"); !strings.Contains(msg, "fresh conversation") {
+		t.Fatalf("unexpected reset reply: %q", msg)
+	}
+	if msg := handleAdvisorMemoryCommand(context.Background(), mem, 8, "/forget"); !strings.Contains(msg, "Deleted our conversation") {
+		t.Fatalf("unexpected forget reply: %q", msg)
+	}
+	if len(mem.resets) != 1 || mem.resets[0] != 7 || len(mem.forgets) != 1 || mem.forgets[0] != 8 {
+		t.Fatalf("expected reset of 7 and forget of 8, got %v %v", mem.resets, mem.forgets)
+	}
+
+	mem.err = errors.New("db down")
+	if msg := handleAdvisorMemoryCommand(context.Background(), mem, 7, "/forget"); !strings.Contains(msg, "db down") {
+		t.Fatalf("expected the error in the reply, got %q", msg)
+	}
+}
+
+type stubAdvisorMemory struct {
+	resets  []int64
+	forgets []int64
+	err     error
+}
+
+func (s *stubAdvisorMemory) Reset(ctx context.Context, chatID int64) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.resets = append(s.resets, chatID)
+	return nil
+}
+
+func (s *stubAdvisorMemory) Forget(ctx context.Context, chatID int64) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.forgets = append(s.forgets, chatID)
+	return nil
+}
@@ -3,11 +3,17 @@ package bot
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	"bug-free-umbrella/internal/delivery"
 	"bug-free-umbrella/internal/domain"
 
 	tele "gopkg.in/telebot.v3"
@@ -21,76 +27,224 @@ type SignalImageFetcher interface {
 	GetSignalImage(ctx context.Context, signalID int64) (*domain.SignalImageData, error)
 }
 
-// AlertDispatcher broadcasts newly-generated signals to subscribed chats.
+// AlertSubscriptionStore persists chats' alert subscriptions across
+// restarts.
+type AlertSubscriptionStore interface {
+	ListSubscriptions(ctx context.Context) ([]domain.AlertSubscription, error)
+	SaveSubscription(ctx context.Context, sub domain.AlertSubscription) error
+}
+
+// AlertQueue is the outbox alert messages are written to when delivery runs
+// through the background worker.
+type AlertQueue interface {
+	Enqueue(ctx context.Context, deliveries []domain.AlertDelivery) (int, error)
+}
+
+// AlertDispatcher broadcasts newly-generated signals to subscribed chats,
+// applying each chat's filters and quiet hours. Subscriptions are cached in
+// memory and written through to the store when one is set. With a store,
+// every dispatch and edit re-reads it first, so changes made through another
+// replica apply. With a queue set, messages are enqueued and sent later by
+// Deliver; without one they are sent inline.
 type AlertDispatcher struct {
 	sender messageSender
 	images SignalImageFetcher
+	store  AlertSubscriptionStore
+	queue  AlertQueue
+	now    func() time.Time
 
-	mu          sync.RWMutex
-	subscribers map[int64]struct{}
+	// editMu serializes subscription edits, which hold it across the store
+	// write; mu only guards the cache.
+	editMu        sync.Mutex
+	mu            sync.RWMutex
+	subscriptions map[int64]domain.AlertSubscription
 }
 
 func NewAlertDispatcher(sender messageSender, images SignalImageFetcher) *AlertDispatcher {
 	return &AlertDispatcher{
-		sender:      sender,
-		images:      images,
-		subscribers: make(map[int64]struct{}),
+		sender:        sender,
+		images:        images,
+		now:           time.Now,
+		subscriptions: make(map[int64]domain.AlertSubscription),
 	}
 }
 
-func (d *AlertDispatcher) Subscribe(chatID int64) bool {
-	d.mu.Lock()
-	defer d.mu.Unlock()
+// UseStore loads stored subscriptions and persists later changes to store.
+func (d *AlertDispatcher) UseStore(ctx context.Context, store AlertSubscriptionStore) error {
+	d.store = store
+	return d.reload(ctx)
+}
 
-	if _, exists := d.subscribers[chatID]; exists {
-		return false
+// reload replaces the cached subscriptions with the stored ones.
+func (d *AlertDispatcher) reload(ctx context.Context) error {
+	if d.store == nil {
+		return nil
+	}
+	subs, err := d.store.ListSubscriptions(ctx)
+	if err != nil {
+		return err
 	}
-	d.subscribers[chatID] = struct{}{}
-	return true
+	cache := make(map[int64]domain.AlertSubscription, len(subs))
+	for _, sub := range subs {
+		cache[sub.ChatID] = sub
+	}
+	d.mu.Lock()
+	d.subscriptions = cache
+	d.mu.Unlock()
+	return nil
 }
 
-func (d *AlertDispatcher) Unsubscribe(chatID int64) bool {
-	d.mu.Lock()
-	defer d.mu.Unlock()
+// UseQueue routes alert messages through queue instead of sending them
+// inline. Call it before alerts start flowing.
+func (d *AlertDispatcher) UseQueue(queue AlertQueue) {
+	d.queue = queue
+}
 
-	if _, exists := d.subscribers[chatID]; !exists {
-		return false
-	}
-	delete(d.subscribers, chatID)
-	return true
+// Subscribe turns alerts on for a chat, keeping any filters it set before.
+// It reports false when alerts were already on.
+func (d *AlertDispatcher) Subscribe(ctx context.Context, chatID int64) (bool, error) {
+	changed := false
+	_, err := d.UpdateSubscription(ctx, chatID, func(sub *domain.AlertSubscription) {
+		changed = !sub.Enabled
+		sub.Enabled = true
+	})
+	return changed, err
+}
+
+// Unsubscribe turns alerts off for a chat. It reports false when they were
+// already off.
+func (d *AlertDispatcher) Unsubscribe(ctx context.Context, chatID int64) (bool, error) {
+	changed := false
+	_, err := d.UpdateSubscription(ctx, chatID, func(sub *domain.AlertSubscription) {
+		changed = sub.Enabled
+		sub.Enabled = false
+	})
+	return changed && err == nil, err
 }
 
 func (d *AlertDispatcher) IsSubscribed(chatID int64) bool {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 
-	_, exists := d.subscribers[chatID]
-	return exists
+	return d.subscriptions[chatID].Enabled
+}
+
+// Subscription returns a chat's subscription, or a disabled one with no
+// filters when the chat never set one up.
+func (d *AlertDispatcher) Subscription(chatID int64) domain.AlertSubscription {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if sub, ok := d.subscriptions[chatID]; ok {
+		return sub
+	}
+	return domain.AlertSubscription{ChatID: chatID, Timezone: "UTC"}
+}
+
+// UpdateSubscription applies edit to a chat's latest stored subscription
+// and saves it. The cached copy only changes once the store accepted it.
+func (d *AlertDispatcher) UpdateSubscription(ctx context.Context, chatID int64, edit func(*domain.AlertSubscription)) (domain.AlertSubscription, error) {
+	d.editMu.Lock()
+	defer d.editMu.Unlock()
+
+	if err := d.reload(ctx); err != nil {
+		return d.Subscription(chatID), err
+	}
+	sub := d.Subscription(chatID)
+	edit(&sub)
+	sub.UpdatedAt = d.now().UTC()
+	if d.store != nil {
+		if err := d.store.SaveSubscription(ctx, sub); err != nil {
+			return d.Subscription(chatID), err
+		}
+	}
+	d.mu.Lock()
+	d.subscriptions[chatID] = sub
+	d.mu.Unlock()
+	return sub, nil
 }
 
 func (d *AlertDispatcher) SubscriberCount() int {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
-	return len(d.subscribers)
+
+	n := 0
+	for _, sub := range d.subscriptions {
+		if sub.Enabled {
+			n++
+		}
+	}
+	return n
 }
 
 func (d *AlertDispatcher) NotifySignals(ctx context.Context, signals []domain.Signal) error {
-	_ = ctx
 	if d == nil || d.sender == nil || len(signals) == 0 {
 		return nil
 	}
 
-	chatIDs := d.snapshotSubscribers()
-	if len(chatIDs) == 0 {
+	if err := d.reload(ctx); err != nil {
+		log.Printf("failed to reload alert subscriptions, using cached ones: %v", err)
+	}
+	subs := d.snapshotSubscribers()
+	if len(subs) == 0 {
 		return nil
 	}
 
-	var failures []string
-	for _, chatID := range chatIDs {
+	now := d.now()
+	var deliveries []domain.AlertDelivery
+	for _, sub := range subs {
+		if sub.InQuietHours(now) {
+			continue
+		}
 		for _, s := range signals {
-			if err := d.sendSignalToChat(ctx, chatID, s); err != nil {
-				failures = append(failures, fmt.Sprintf("chat %d signal %d: %v", chatID, s.ID, err))
+			if !sub.Matches(s) {
+				continue
 			}
+			deliveries = append(deliveries, domain.AlertDelivery{
+				ChatID:   sub.ChatID,
+				Kind:     domain.DeliveryKindSignal,
+				SignalID: s.ID,
+				Text:     "Proactive signal alert:\n" + formatSignal(s),
+			})
+		}
+	}
+	return d.dispatch(ctx, deliveries)
+}
+
+// Broadcast sends text to every chat in chatIDs, whether or not it has
+// alerts on. Quiet hours are ignored; broadcasts are rare and deliberate.
+func (d *AlertDispatcher) Broadcast(ctx context.Context, chatIDs []int64, text string) error {
+	if d == nil || d.sender == nil {
+		return errors.New("telegram bot is not running")
+	}
+	deliveries := make([]domain.AlertDelivery, 0, len(chatIDs))
+	for _, id := range chatIDs {
+		deliveries = append(deliveries, domain.AlertDelivery{
+			ChatID: id,
+			Kind:   domain.DeliveryKindBroadcast,
+			Text:   text,
+		})
+	}
+	return d.dispatch(ctx, deliveries)
+}
+
+// dispatch enqueues deliveries, or sends them one by one when there is no
+// queue.
+func (d *AlertDispatcher) dispatch(ctx context.Context, deliveries []domain.AlertDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if d.queue != nil {
+		if _, err := d.queue.Enqueue(ctx, deliveries); err != nil {
+			return fmt.Errorf("failed to enqueue %d alerts: %w", len(deliveries), err)
+		}
+		return nil
+	}
+
+	var failures []string
+	for _, del := range deliveries {
+		if err := d.Deliver(ctx, del); err != nil {
+			failures = append(failures, fmt.Sprintf("chat %d %s: %v", del.ChatID, del.Kind, err))
 		}
 	}
 	if len(failures) > 0 {
@@ -99,37 +253,333 @@ func (d *AlertDispatcher) NotifySignals(ctx context.Context, signals []domain.Si
 	return nil
 }
 
-func (d *AlertDispatcher) snapshotSubscribers() []int64 {
+// snapshotSubscribers returns the enabled subscriptions ordered by chat.
+func (d *AlertDispatcher) snapshotSubscribers() []domain.AlertSubscription {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 
-	chatIDs := make([]int64, 0, len(d.subscribers))
-	for chatID := range d.subscribers {
-		chatIDs = append(chatIDs, chatID)
+	subs := make([]domain.AlertSubscription, 0, len(d.subscriptions))
+	for _, sub := range d.subscriptions {
+		if sub.Enabled {
+			subs = append(subs, sub)
+		}
 	}
-	sort.Slice(chatIDs, func(i, j int) bool { return chatIDs[i] < chatIDs[j] })
-	return chatIDs
+	sort.Slice(subs, func(i, j int) bool { return subs[i].ChatID < subs[j].ChatID })
+	return subs
 }
 
-func (d *AlertDispatcher) sendSignalToChat(ctx context.Context, chatID int64, s domain.Signal) error {
-	caption := "Proactive signal alert:\n" + formatSignal(s)
-	if d.images == nil || s.ID <= 0 {
-		_, err := d.sender.Send(&tele.Chat{ID: chatID}, caption)
-		return err
+// Deliver sends one alert message, attaching the signal's chart when there
+// is one. Telegram rate limits and chats that can no longer be reached are
+// reported so the delivery worker can wait or give up.
+func (d *AlertDispatcher) Deliver(ctx context.Context, del domain.AlertDelivery) error {
+	if d == nil || d.sender == nil {
+		return delivery.Permanent(errors.New("telegram bot is not running"))
+	}
+	chat := &tele.Chat{ID: del.ChatID}
+	if len(del.Image) > 0 {
+		return d.deliverPhoto(chat, del.Image, del.Text)
+	}
+	var what interface{} = del.Text
+	if d.images != nil && del.SignalID > 0 {
+		imageData, err := d.images.GetSignalImage(ctx, del.SignalID)
+		if err == nil && imageData != nil && len(imageData.Bytes) > 0 {
+			what = &tele.Photo{
+				File:    tele.FromReader(bytes.NewReader(imageData.Bytes)),
+				Caption: del.Text,
+			}
+		}
+	}
+	_, err := d.sender.Send(chat, what)
+	return classifySendError(err)
+}
+
+// maxCaptionLen is Telegram's photo caption limit.
+const maxCaptionLen = 1024
+
+// deliverPhoto sends img captioned with text. Text too long for a caption
+// is split: its first paragraph captions the photo and the rest follows as
+// a message.
+func (d *AlertDispatcher) deliverPhoto(chat *tele.Chat, img []byte, text string) error {
+	caption, rest := text, ""
+	if len(text) > maxCaptionLen {
+		caption, rest, _ = strings.Cut(text, "\n\n")
+		if len(caption) > maxCaptionLen {
+			caption = caption[:maxCaptionLen]
+		}
+	}
+	photo := &tele.Photo{File: tele.FromReader(bytes.NewReader(img)), Caption: caption}
+	if _, err := d.sender.Send(chat, photo); err != nil {
+		return classifySendError(err)
+	}
+	if rest == "" {
+		return nil
+	}
+	_, err := d.sender.Send(chat, rest)
+	return classifySendError(err)
+}
+
+func classifySendError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var flood tele.FloodError
+	if errors.As(err, &flood) {
+		return delivery.RetryAfter(err, time.Duration(flood.RetryAfter)*time.Second)
+	}
+	var apiErr *tele.Error
+	if errors.As(err, &apiErr) && (apiErr.Code == 403 || errors.Is(err, tele.ErrChatNotFound)) {
+		return delivery.Permanent(err)
+	}
+	return err
+}
+
+const alertsUsage = "Usage:\n" +
+	"/alerts on | off | status\n" +
+	"/alerts symbols BTC ETH (or all)\n" +
+	"/alerts intervals 1h 4h (or all)\n" +
+	"/alerts indicators rsi macd (or all)\n" +
+	"/alerts risk 2-4 (or all)\n" +
+	"/alerts direction long|short|all\n" +
+	"/alerts quiet 22:00-07:00 (or off)\n" +
+	"/alerts timezone Europe/Berlin\n" +
+	"/alerts reset - clear filters and quiet hours"
+
+// alertIndicators are the indicators a subscription can filter on.
+var alertIndicators = []string{
+	domain.IndicatorRSI,
+	domain.IndicatorMACD,
+	domain.IndicatorBollinger,
+	domain.IndicatorVolumeZ,
+	domain.IndicatorMLLogRegUp4H,
+	domain.IndicatorMLXGBoostUp4H,
+	domain.IndicatorMLEnsembleUp4H,
+	domain.IndicatorFundSentimentComposite,
+}
+
+// handleAlertsCommand runs one /alerts subcommand and returns the reply.
+func handleAlertsCommand(ctx context.Context, alerts *AlertDispatcher, chatID int64, args []string) string {
+	edit, isFilter, err := parseAlertFilter(args)
+	if isFilter {
+		if err != nil {
+			return fmt.Sprintf("Invalid filter: %v\n\n%s", err, alertsUsage)
+		}
+		sub, err := alerts.UpdateSubscription(ctx, chatID, edit)
+		if err != nil {
+			return fmt.Sprintf("Unable to save alert filters: %v", err)
+		}
+		msg := "Alert filters updated.\n" + formatAlertSubscription(sub)
+		if !sub.Enabled {
+			msg += "\nTurn alerts on with /alerts on"
+		}
+		return msg
+	}
+
+	mode, err := parseAlertMode(args)
+	if err != nil {
+		return alertsUsage
+	}
+	switch mode {
+	case "on":
+		changed, err := alerts.Subscribe(ctx, chatID)
+		if err != nil {
+			return fmt.Sprintf("Unable to enable alerts: %v", err)
+		}
+		if changed {
+			return "Proactive alerts enabled for this chat."
+		}
+		return "Proactive alerts are already enabled for this chat."
+	case "off":
+		changed, err := alerts.Unsubscribe(ctx, chatID)
+		if err != nil {
+			return fmt.Sprintf("Unable to disable alerts: %v", err)
+		}
+		if changed {
+			return "Proactive alerts disabled for this chat."
+		}
+		return "Proactive alerts are already disabled for this chat."
+	default:
+		if err := alerts.reload(ctx); err != nil {
+			log.Printf("failed to reload alert subscriptions, using cached ones: %v", err)
+		}
+		return formatAlertSubscription(alerts.Subscription(chatID))
+	}
+}
+
+// parseAlertFilter reads a filter subcommand. isFilter is false when args do
+// not start with a filter keyword, so on/off/status can be tried instead.
+func parseAlertFilter(args []string) (edit func(*domain.AlertSubscription), isFilter bool, err error) {
+	if len(args) == 0 {
+		return nil, false, nil
+	}
+	keyword := strings.ToLower(strings.TrimSpace(args[0]))
+	values := splitAlertValues(args[1:])
+	all := len(values) == 1 && (values[0] == "all" || values[0] == "any" || values[0] == "off")
+
+	switch keyword {
+	case "symbols", "symbol":
+		list, err := parseAlertList(values, all, strings.ToUpper, func(v string) bool {
+			_, ok := domain.CoinGeckoID[v]
+			return ok
+		})
+		return func(sub *domain.AlertSubscription) { sub.Symbols = list }, true, err
+	case "intervals", "interval":
+		list, err := parseAlertList(values, all, strings.ToLower, func(v string) bool {
+			return slices.Contains(domain.SupportedIntervals, v)
+		})
+		return func(sub *domain.AlertSubscription) { sub.Intervals = list }, true, err
+	case "indicators", "indicator":
+		list, err := parseAlertList(values, all, strings.ToLower, func(v string) bool {
+			return slices.Contains(alertIndicators, v)
+		})
+		return func(sub *domain.AlertSubscription) { sub.Indicators = list }, true, err
+	case "risk":
+		lo, hi, err := parseAlertRisk(values, all)
+		return func(sub *domain.AlertSubscription) { sub.MinRisk, sub.MaxRisk = lo, hi }, true, err
+	case "direction":
+		if len(values) != 1 {
+			return nil, true, errors.New("expected long, short or all")
+		}
+		dir := domain.SignalDirection(values[0])
+		if all {
+			dir = ""
+		} else if dir != domain.DirectionLong && dir != domain.DirectionShort {
+			return nil, true, errors.New("expected long, short or all")
+		}
+		return func(sub *domain.AlertSubscription) { sub.Direction = dir }, true, nil
+	case "quiet":
+		start, end, err := parseQuietHours(values, all)
+		return func(sub *domain.AlertSubscription) { sub.QuietStart, sub.QuietEnd = start, end }, true, err
+	case "timezone", "tz":
+		if len(args) != 2 {
+			return nil, true, errors.New("expected one IANA timezone such as Europe/Berlin")
+		}
+		loc, err := time.LoadLocation(strings.TrimSpace(args[1]))
+		if err != nil {
+			return nil, true, fmt.Errorf("unknown timezone %q", args[1])
+		}
+		return func(sub *domain.AlertSubscription) { sub.Timezone = loc.String() }, true, nil
+	case "reset":
+		return func(sub *domain.AlertSubscription) {
+			*sub = domain.AlertSubscription{ChatID: sub.ChatID, Enabled: sub.Enabled, Timezone: sub.Timezone}
+		}, true, nil
 	}
+	return nil, false, nil
+}
 
-	imageData, err := d.images.GetSignalImage(ctx, s.ID)
-	if err != nil || imageData == nil || len(imageData.Bytes) == 0 {
-		_, sendErr := d.sender.Send(&tele.Chat{ID: chatID}, caption)
-		return sendErr
+// splitAlertValues accepts values separated by spaces, commas or both.
+func splitAlertValues(args []string) []string {
+	var out []string
+	for _, arg := range args {
+		for _, v := range strings.Split(arg, ",") {
+			if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+				out = append(out, v)
+			}
+		}
 	}
+	return out
+}
 
-	photo := &tele.Photo{
-		File:    tele.FromReader(bytes.NewReader(imageData.Bytes)),
-		Caption: caption,
+func parseAlertList(values []string, all bool, norm func(string) string, valid func(string) bool) ([]string, error) {
+	if len(values) == 0 {
+		return nil, errors.New("expected at least one value or all")
+	}
+	if all {
+		return nil, nil
 	}
-	_, sendErr := d.sender.Send(&tele.Chat{ID: chatID}, photo)
-	return sendErr
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		v = norm(v)
+		if !valid(v) {
+			return nil, fmt.Errorf("unsupported value %q", v)
+		}
+		if !slices.Contains(out, v) {
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+// parseAlertRisk reads "3" (only that level) or "2-4".
+func parseAlertRisk(values []string, all bool) (domain.RiskLevel, domain.RiskLevel, error) {
+	if all {
+		return 0, 0, nil
+	}
+	if len(values) != 1 {
+		return 0, 0, errors.New("expected a level such as 3 or a range such as 2-4")
+	}
+	loRaw, hiRaw, isRange := strings.Cut(values[0], "-")
+	if !isRange {
+		hiRaw = loRaw
+	}
+	lo, errLo := strconv.Atoi(loRaw)
+	hi, errHi := strconv.Atoi(hiRaw)
+	if errLo != nil || errHi != nil || !domain.RiskLevel(lo).IsValid() || !domain.RiskLevel(hi).IsValid() || lo > hi {
+		return 0, 0, errors.New("risk levels run from 1 to 5, low to high")
+	}
+	return domain.RiskLevel(lo), domain.RiskLevel(hi), nil
+}
+
+// parseQuietHours reads "22:00-07:00" as minutes after midnight.
+func parseQuietHours(values []string, off bool) (int, int, error) {
+	if off {
+		return 0, 0, nil
+	}
+	if len(values) != 1 {
+		return 0, 0, errors.New("expected a range such as 22:00-07:00 or off")
+	}
+	startRaw, endRaw, ok := strings.Cut(values[0], "-")
+	if !ok {
+		return 0, 0, errors.New("expected a range such as 22:00-07:00 or off")
+	}
+	start, errStart := time.Parse("15:04", startRaw)
+	end, errEnd := time.Parse("15:04", endRaw)
+	if errStart != nil || errEnd != nil {
+		return 0, 0, errors.New("times must be HH:MM")
+	}
+	startMin := start.Hour()*60 + start.Minute()
+	endMin := end.Hour()*60 + end.Minute()
+	if startMin == endMin {
+		return 0, 0, errors.New("quiet hours must not start and end at the same time")
+	}
+	return startMin, endMin, nil
+}
+
+func formatAlertSubscription(sub domain.AlertSubscription) string {
+	status := "OFF"
+	if sub.Enabled {
+		status = "ON"
+	}
+	list := func(values []string) string {
+		if len(values) == 0 {
+			return "all"
+		}
+		return strings.Join(values, ", ")
+	}
+	risk := "all"
+	switch {
+	case sub.MinRisk > 0 && sub.MinRisk == sub.MaxRisk:
+		risk = strconv.Itoa(int(sub.MinRisk))
+	case sub.MinRisk > 0 || sub.MaxRisk > 0:
+		lo, hi := max(sub.MinRisk, domain.RiskLevel1), sub.MaxRisk
+		if hi == 0 {
+			hi = domain.RiskLevel5
+		}
+		risk = fmt.Sprintf("%d-%d", lo, hi)
+	}
+	direction := "all"
+	if sub.Direction != "" {
+		direction = string(sub.Direction)
+	}
+	quiet := "off"
+	if sub.HasQuietHours() {
+		quiet = fmt.Sprintf("%02d:%02d-%02d:%02d %s", sub.QuietStart/60, sub.QuietStart%60, sub.QuietEnd/60, sub.QuietEnd%60, sub.Location())
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Alerts status: %s\n", status)
+	fmt.Fprintf(&sb, "Symbols: %s\nIntervals: %s\nIndicators: %s\n", list(sub.Symbols), list(sub.Intervals), list(sub.Indicators))
+	fmt.Fprintf(&sb, "Risk: %s\nDirection: %s\nQuiet hours: %s", risk, direction, quiet)
+	return sb.String()
 }
 
 func parseAlertMode(args []string) (string, error) {
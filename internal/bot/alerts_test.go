@@ -2,11 +2,13 @@ package bot
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
 	"time"
 
+	"bug-free-umbrella/internal/delivery"
 	"bug-free-umbrella/internal/domain"
 
 	tele "gopkg.in/telebot.v3"
@@ -37,13 +39,13 @@ func TestAlertDispatcherNotifySignals(t *testing.T) {
 	sender := &fakeSender{}
 	dispatcher := NewAlertDispatcher(sender, nil)
 
-	if !dispatcher.Subscribe(10) {
-		t.Fatal("expected initial subscribe to return true")
+	if ok, err := dispatcher.Subscribe(context.Background(), 10); !ok || err != nil {
+		t.Fatalf("expected initial subscribe to return true, got %v err=%v", ok, err)
 	}
-	if !dispatcher.Subscribe(20) {
-		t.Fatal("expected initial subscribe to return true")
+	if ok, err := dispatcher.Subscribe(context.Background(), 20); !ok || err != nil {
+		t.Fatalf("expected initial subscribe to return true, got %v err=%v", ok, err)
 	}
-	if dispatcher.Subscribe(10) {
+	if ok, _ := dispatcher.Subscribe(context.Background(), 10); ok {
 		t.Fatal("expected duplicate subscribe to return false")
 	}
 
@@ -71,11 +73,11 @@ func TestAlertDispatcherUnsubscribe(t *testing.T) {
 	sender := &fakeSender{}
 	dispatcher := NewAlertDispatcher(sender, nil)
 
-	dispatcher.Subscribe(10)
-	if !dispatcher.Unsubscribe(10) {
-		t.Fatal("expected unsubscribe to return true")
+	_, _ = dispatcher.Subscribe(context.Background(), 10)
+	if ok, err := dispatcher.Unsubscribe(context.Background(), 10); !ok || err != nil {
+		t.Fatalf("expected unsubscribe to return true, got %v err=%v", ok, err)
 	}
-	if dispatcher.Unsubscribe(10) {
+	if ok, _ := dispatcher.Unsubscribe(context.Background(), 10); ok {
 		t.Fatal("expected second unsubscribe to return false")
 	}
 
@@ -111,7 +113,7 @@ func TestAlertDispatcherSendsPhotoWhenImageAvailable(t *testing.T) {
 			},
 		},
 	})
-	dispatcher.Subscribe(99)
+	_, _ = dispatcher.Subscribe(context.Background(), 99)
 
 	err := dispatcher.NotifySignals(context.Background(), []domain.Signal{{
 		ID:        55,
@@ -130,6 +132,270 @@ func TestAlertDispatcherSendsPhotoWhenImageAvailable(t *testing.T) {
 	}
 }
 
+func TestAlertDispatcherAppliesFilters(t *testing.T) {
+	sender := &fakeSender{}
+	dispatcher := NewAlertDispatcher(sender, nil)
+	dispatcher.now = func() time.Time { return time.Date(2026, 3, 2, 23, 0, 0, 0, time.UTC) }
+
+	_, _ = dispatcher.Subscribe(context.Background(), 10)
+	_, _ = dispatcher.Subscribe(context.Background(), 20)
+	_, _ = dispatcher.Subscribe(context.Background(), 30)
+	if _, err := dispatcher.UpdateSubscription(context.Background(), 10, func(sub *domain.AlertSubscription) {
+		sub.Symbols = []string{"ETH"}
+		sub.MaxRisk = domain.RiskLevel3
+	}); err != nil {
+		t.Fatalf("unexpected update error: %v", err)
+	}
+	// 23:00 UTC is 00:00 in Berlin, inside 22:00-07:00.
+	if _, err := dispatcher.UpdateSubscription(context.Background(), 30, func(sub *domain.AlertSubscription) {
+		sub.QuietStart, sub.QuietEnd = 22*60, 7*60
+		sub.Timezone = "Europe/Berlin"
+	}); err != nil {
+		t.Fatalf("unexpected update error: %v", err)
+	}
+
+	signals := []domain.Signal{
+		{Symbol: "BTC", Interval: "1h", Indicator: domain.IndicatorRSI, Direction: domain.DirectionLong, Risk: domain.RiskLevel2},
+		{Symbol: "ETH", Interval: "1h", Indicator: domain.IndicatorRSI, Direction: domain.DirectionLong, Risk: domain.RiskLevel4},
+		{Symbol: "ETH", Interval: "4h", Indicator: domain.IndicatorMACD, Direction: domain.DirectionShort, Risk: domain.RiskLevel2},
+	}
+	if err := dispatcher.NotifySignals(context.Background(), signals); err != nil {
+		t.Fatalf("unexpected notify error: %v", err)
+	}
+	if got := sender.messages[10]; len(got) != 1 || !strings.Contains(got[0], "ETH 4h MACD SHORT") {
+		t.Fatalf("expected only the low-risk ETH signal for chat 10, got %+v", got)
+	}
+	if got := len(sender.messages[20]); got != 3 {
+		t.Fatalf("expected every signal for unfiltered chat 20, got %d", got)
+	}
+	if got := len(sender.messages[30]); got != 0 {
+		t.Fatalf("expected no signals during quiet hours, got %d", got)
+	}
+}
+
+func TestAlertDispatcherPersistsSubscriptions(t *testing.T) {
+	store := &fakeSubscriptionStore{subs: []domain.AlertSubscription{
+		{ChatID: 7, Enabled: true, Symbols: []string{"SOL"}, Timezone: "UTC"},
+		{ChatID: 8, Enabled: false, Timezone: "UTC"},
+	}}
+	dispatcher := NewAlertDispatcher(&fakeSender{}, nil)
+	if err := dispatcher.UseStore(context.Background(), store); err != nil {
+		t.Fatalf("unexpected load error: %v", err)
+	}
+	if !dispatcher.IsSubscribed(7) || dispatcher.IsSubscribed(8) || dispatcher.SubscriberCount() != 1 {
+		t.Fatalf("expected stored subscriptions to be restored, got count=%d", dispatcher.SubscriberCount())
+	}
+	if got := dispatcher.Subscription(7).Symbols; len(got) != 1 || got[0] != "SOL" {
+		t.Fatalf("expected stored filters to be restored, got %v", got)
+	}
+
+	if ok, err := dispatcher.Subscribe(context.Background(), 8); !ok || err != nil {
+		t.Fatalf("expected subscribe to succeed, got %v err=%v", ok, err)
+	}
+	if len(store.saved) != 1 || store.saved[0].ChatID != 8 || !store.saved[0].Enabled {
+		t.Fatalf("expected subscribe to be saved, got %+v", store.saved)
+	}
+
+	store.saveErr = errors.New("db down")
+	if _, err := dispatcher.Unsubscribe(context.Background(), 8); err == nil {
+		t.Fatal("expected save error")
+	}
+	if !dispatcher.IsSubscribed(8) {
+		t.Fatal("expected cached subscription to stay unchanged after a failed save")
+	}
+}
+
+func TestAlertDispatcherRereadsStoreBeforeDispatch(t *testing.T) {
+	sender := &fakeSender{}
+	store := &fakeSubscriptionStore{subs: []domain.AlertSubscription{
+		{ChatID: 7, Enabled: true, Timezone: "UTC"},
+	}}
+	dispatcher := NewAlertDispatcher(sender, nil)
+	if err := dispatcher.UseStore(context.Background(), store); err != nil {
+		t.Fatalf("unexpected load error: %v", err)
+	}
+
+	// Another replica turns chat 7 off and chat 8 on.
+	store.subs = []domain.AlertSubscription{
+		{ChatID: 7, Enabled: false, Timezone: "UTC"},
+		{ChatID: 8, Enabled: true, Symbols: []string{"ETH"}, Timezone: "UTC"},
+	}
+	signals := []domain.Signal{{Symbol: "ETH", Interval: "1h", Indicator: domain.IndicatorRSI, Direction: domain.DirectionLong, Risk: domain.RiskLevel2}}
+	if err := dispatcher.NotifySignals(context.Background(), signals); err != nil {
+		t.Fatalf("unexpected notify error: %v", err)
+	}
+	if len(sender.messages[7]) != 0 || len(sender.messages[8]) != 1 {
+		t.Fatalf("expected the stored subscriptions to apply, got %v", sender.messages)
+	}
+
+	// An edit starts from the stored filters, not the cached ones.
+	store.subs[1].Symbols = []string{"SOL"}
+	sub, err := dispatcher.UpdateSubscription(context.Background(), 8, func(sub *domain.AlertSubscription) {
+		sub.Direction = domain.DirectionLong
+	})
+	if err != nil || len(sub.Symbols) != 1 || sub.Symbols[0] != "SOL" {
+		t.Fatalf("expected the edit applied to the stored subscription, got %+v err=%v", sub, err)
+	}
+}
+
+func TestHandleAlertsCommand(t *testing.T) {
+	dispatcher := NewAlertDispatcher(&fakeSender{}, nil)
+
+	if got := handleAlertsCommand(context.Background(), dispatcher, 5, nil); !strings.Contains(got, "Alerts status: OFF") {
+		t.Fatalf("unexpected status reply: %s", got)
+	}
+	if got := handleAlertsCommand(context.Background(), dispatcher, 5, []string{"symbols", "btc,", "eth"}); !strings.Contains(got, "Symbols: BTC, ETH") || !strings.Contains(got, "/alerts on") {
+		t.Fatalf("unexpected symbols reply: %s", got)
+	}
+	if dispatcher.IsSubscribed(5) {
+		t.Fatal("expected editing filters to leave alerts off")
+	}
+	if got := handleAlertsCommand(context.Background(), dispatcher, 5, []string{"on"}); !strings.Contains(got, "enabled") {
+		t.Fatalf("unexpected on reply: %s", got)
+	}
+
+	steps := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"intervals", "4h", "1d"}, "Intervals: 4h, 1d"},
+		{[]string{"indicators", "RSI"}, "Indicators: rsi"},
+		{[]string{"risk", "2-4"}, "Risk: 2-4"},
+		{[]string{"risk", "3"}, "Risk: 3"},
+		{[]string{"direction", "short"}, "Direction: short"},
+		{[]string{"timezone", "Europe/Berlin"}, "Quiet hours: off"},
+		{[]string{"quiet", "22:00-07:00"}, "Quiet hours: 22:00-07:00 Europe/Berlin"},
+		{[]string{"symbols", "all"}, "Symbols: all"},
+	}
+	for _, step := range steps {
+		if got := handleAlertsCommand(context.Background(), dispatcher, 5, step.args); !strings.Contains(got, step.want) {
+			t.Fatalf("%v: expected %q in reply, got %s", step.args, step.want, got)
+		}
+	}
+
+	sub := dispatcher.Subscription(5)
+	if !sub.Enabled || sub.MinRisk != 3 || sub.MaxRisk != 3 || sub.Direction != domain.DirectionShort || sub.QuietStart != 22*60 || sub.QuietEnd != 7*60 {
+		t.Fatalf("unexpected stored subscription: %+v", sub)
+	}
+
+	got := handleAlertsCommand(context.Background(), dispatcher, 5, []string{"reset"})
+	if !strings.Contains(got, "Alerts status: ON") || !strings.Contains(got, "Risk: all") || !strings.Contains(got, "Quiet hours: off") {
+		t.Fatalf("unexpected reset reply: %s", got)
+	}
+	if tz := dispatcher.Subscription(5).Timezone; tz != "Europe/Berlin" {
+		t.Fatalf("expected reset to keep timezone, got %q", tz)
+	}
+
+	for _, args := range [][]string{
+		{"symbols", "SHIB"},
+		{"intervals", "7m"},
+		{"indicators", "stoch"},
+		{"risk", "4-2"},
+		{"risk", "9"},
+		{"direction", "sideways"},
+		{"quiet", "22:00"},
+		{"quiet", "08:00-08:00"},
+		{"timezone", "Mars/Olympus"},
+	} {
+		if got := handleAlertsCommand(context.Background(), dispatcher, 5, args); !strings.HasPrefix(got, "Invalid filter") {
+			t.Fatalf("%v: expected invalid filter reply, got %s", args, got)
+		}
+	}
+	if got := handleAlertsCommand(context.Background(), dispatcher, 5, []string{"nope"}); !strings.HasPrefix(got, "Usage:") {
+		t.Fatalf("expected usage reply, got %s", got)
+	}
+}
+
+func TestAlertDispatcherEnqueuesWithQueue(t *testing.T) {
+	sender := &fakeSender{}
+	queue := &fakeAlertQueue{}
+	dispatcher := NewAlertDispatcher(sender, nil)
+	dispatcher.UseQueue(queue)
+	_, _ = dispatcher.Subscribe(context.Background(), 10)
+
+	err := dispatcher.NotifySignals(context.Background(), []domain.Signal{{
+		ID: 42, Symbol: "BTC", Interval: "1h", Indicator: domain.IndicatorRSI, Direction: domain.DirectionLong, Risk: domain.RiskLevel2,
+	}})
+	if err != nil {
+		t.Fatalf("unexpected notify error: %v", err)
+	}
+	if len(sender.messages) != 0 {
+		t.Fatalf("expected nothing sent inline, got %+v", sender.messages)
+	}
+	if len(queue.queued) != 1 {
+		t.Fatalf("expected one queued delivery, got %+v", queue.queued)
+	}
+	got := queue.queued[0]
+	if got.ChatID != 10 || got.Kind != domain.DeliveryKindSignal || got.SignalID != 42 || !strings.Contains(got.Text, "BTC 1h RSI LONG") {
+		t.Fatalf("unexpected delivery: %+v", got)
+	}
+
+	queue.err = errors.New("db down")
+	if err := dispatcher.NotifySignals(context.Background(), []domain.Signal{{Symbol: "ETH"}}); err == nil {
+		t.Fatal("expected enqueue error")
+	}
+}
+
+func TestClassifySendError(t *testing.T) {
+	if classifySendError(nil) != nil {
+		t.Fatal("expected nil error")
+	}
+
+	err := classifySendError(tele.FloodError{RetryAfter: 12})
+	if after, ok := delivery.RetryAfterOf(err); !ok || after != 12*time.Second {
+		t.Fatalf("expected flood error to retry after 12s, got %s ok=%v", after, ok)
+	}
+
+	for _, blocked := range []error{tele.ErrBlockedByUser, tele.ErrChatNotFound, tele.ErrUserIsDeactivated} {
+		if !delivery.IsPermanent(classifySendError(blocked)) {
+			t.Fatalf("%v: expected permanent error", blocked)
+		}
+	}
+
+	plain := errors.New("connection reset")
+	if classifySendError(plain) != plain {
+		t.Fatal("expected other errors to be returned as-is")
+	}
+}
+
+type fakeAlertQueue struct {
+	queued []domain.AlertDelivery
+	err    error
+}
+
+func (f *fakeAlertQueue) Enqueue(ctx context.Context, deliveries []domain.AlertDelivery) (int, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	f.queued = append(f.queued, deliveries...)
+	return len(deliveries), nil
+}
+
+type fakeSubscriptionStore struct {
+	subs    []domain.AlertSubscription
+	saved   []domain.AlertSubscription
+	saveErr error
+}
+
+func (f *fakeSubscriptionStore) ListSubscriptions(ctx context.Context) ([]domain.AlertSubscription, error) {
+	return f.subs, nil
+}
+
+func (f *fakeSubscriptionStore) SaveSubscription(ctx context.Context, sub domain.AlertSubscription) error {
+	if f.saveErr != nil {
+		return f.saveErr
+	}
+	f.saved = append(f.saved, sub)
+	for i := range f.subs {
+		if f.subs[i].ChatID == sub.ChatID {
+			f.subs[i] = sub
+			return nil
+		}
+	}
+	f.subs = append(f.subs, sub)
+	return nil
+}
+
 type fakeSender struct {
 	messages map[int64][]string
 	kinds    map[int64][]string
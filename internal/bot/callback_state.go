@@ -0,0 +1,79 @@
+package bot
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const callbackStatePrefix = "tg:callback:"
+
+// CallbackStateStore keeps inline keyboard state between callback queries.
+// Load returns nil without an error when the key is missing or expired.
+type CallbackStateStore interface {
+	SaveCallbackState(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	LoadCallbackState(ctx context.Context, key string) ([]byte, error)
+}
+
+// RedisCallbackStore keeps callback state in Redis so buttons on old
+// messages keep working after a restart.
+type RedisCallbackStore struct {
+	redis *redis.Client
+}
+
+func NewRedisCallbackStore(client *redis.Client) *RedisCallbackStore {
+	return &RedisCallbackStore{redis: client}
+}
+
+func (s *RedisCallbackStore) SaveCallbackState(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.redis.Set(ctx, callbackStatePrefix+key, value, ttl).Err()
+}
+
+func (s *RedisCallbackStore) LoadCallbackState(ctx context.Context, key string) ([]byte, error) {
+	data, err := s.redis.Get(ctx, callbackStatePrefix+key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return data, err
+}
+
+// memoryCallbackStore is the fallback without Redis. State is lost on
+// restart.
+type memoryCallbackStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryCallbackEntry
+}
+
+type memoryCallbackEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+func newMemoryCallbackStore() *memoryCallbackStore {
+	return &memoryCallbackStore{entries: make(map[string]memoryCallbackEntry)}
+}
+
+func (s *memoryCallbackStore) SaveCallbackState(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for k, e := range s.entries {
+		if now.After(e.expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+	s.entries[key] = memoryCallbackEntry{value: append([]byte(nil), value...), expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (s *memoryCallbackStore) LoadCallbackState(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok || time.Now().After(e.expiresAt) {
+		return nil, nil
+	}
+	return e.value, nil
+}
@@ -0,0 +1,62 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisCallbackStoreSurvivesRestart(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ctx := context.Background()
+
+	store := NewRedisCallbackStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	if data, err := store.LoadCallbackState(ctx, "missing"); data != nil || err != nil {
+		t.Fatalf("expected nil for a missing key, got %q %v", data, err)
+	}
+
+	br, _, _ := newTestSignalBrowser()
+	br.store = store
+	if _, err := br.start(ctx, domain.SignalFilter{Symbol: "BTC"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ttl := mr.TTL(callbackStatePrefix + "signals:tok"); ttl != signalBrowseTTL {
+		t.Fatalf("expected state stored with ttl, got %v", ttl)
+	}
+
+	// A new browser on a new client stands in for a restarted bot.
+	restarted, _, _ := newTestSignalBrowser()
+	restarted.store = NewRedisCallbackStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	reply, err := restarted.handle(ctx, 7, "pg|tok|1")
+	if err != nil || !strings.Contains(reply.Text, "Signals (BTC), page 2") {
+		t.Fatalf("expected the filter to survive, got %q %v", reply.Text, err)
+	}
+
+	mr.FastForward(signalBrowseTTL + time.Minute)
+	if reply, _ := restarted.handle(ctx, 7, "pg|tok|0"); !strings.Contains(reply.Text, "expired") {
+		t.Fatalf("expected expired state, got %q", reply.Text)
+	}
+}
+
+func TestMemoryCallbackStoreExpires(t *testing.T) {
+	store := newMemoryCallbackStore()
+	ctx := context.Background()
+	if err := store.SaveCallbackState(ctx, "a", []byte("1"), time.Hour); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.SaveCallbackState(ctx, "b", []byte("2"), -time.Second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, _ := store.LoadCallbackState(ctx, "a"); string(data) != "1" {
+		t.Fatalf("expected stored value, got %q", data)
+	}
+	if data, _ := store.LoadCallbackState(ctx, "b"); data != nil {
+		t.Fatalf("expected expired value to be gone, got %q", data)
+	}
+}
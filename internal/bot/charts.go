@@ -0,0 +1,99 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"bug-free-umbrella/internal/domain"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+const chartUsage = "Usage: /chart BTC [interval] [lookback] [overlays] [panes]\n" +
+	"Example: /chart BTC 4h 7d ema rsi\n" +
+	"Intervals: 5m, 15m, 1h, 4h, 1d. Lookback: candles (200) or time (48h, 7d, 2w).\n" +
+	"Overlays: ema, bollinger, vwap. Panes: rsi, macd, volume."
+
+type ChartRenderer interface {
+	RenderChart(ctx context.Context, req domain.ChartRequest) ([]byte, error)
+}
+
+// registerChartCommands adds /chart, which renders candles for any symbol
+// and interval with the chosen overlays and panes.
+func registerChartCommands(b *tele.Bot, charts ChartRenderer) {
+	b.Handle("/chart", func(c tele.Context) error {
+		if charts == nil {
+			return c.Send("Charts unavailable")
+		}
+		req, err := parseChartArgs(c.Args())
+		if err != nil {
+			return c.Send(fmt.Sprintf("Invalid chart: %v\n\n%s", err, chartUsage))
+		}
+		_ = c.Notify(tele.UploadingPhoto)
+		img, err := charts.RenderChart(context.Background(), req)
+		if err != nil {
+			return c.Send(fmt.Sprintf("Unable to chart %s: %v", req.Symbol, err))
+		}
+		return c.Send(&tele.Photo{
+			File:    tele.FromReader(bytes.NewReader(img)),
+			Caption: formatChartCaption(req),
+		})
+	})
+}
+
+// parseChartArgs reads a symbol followed by options in any order: an
+// interval, a lookback, and overlay or pane names.
+func parseChartArgs(args []string) (domain.ChartRequest, error) {
+	req := domain.ChartRequest{}
+	if len(args) == 0 {
+		return req, errors.New("missing symbol")
+	}
+	req.Symbol = strings.ToUpper(strings.TrimSpace(args[0]))
+	if _, ok := domain.CoinGeckoID[req.Symbol]; !ok {
+		return req, fmt.Errorf("unknown symbol %s", req.Symbol)
+	}
+
+	lookback := ""
+	for _, raw := range args[1:] {
+		arg := strings.ToLower(strings.TrimSpace(raw))
+		switch {
+		case slices.Contains(domain.SupportedIntervals, arg) && req.Interval == "":
+			req.Interval = arg
+		case slices.Contains(domain.ChartOverlays, arg):
+			req.Overlays = append(req.Overlays, arg)
+		case slices.Contains(domain.ChartPanes, arg):
+			req.Panes = append(req.Panes, arg)
+		case lookback == "" && arg != "" && arg[0] >= '0' && arg[0] <= '9':
+			lookback = arg
+		default:
+			return req, fmt.Errorf("unknown option %q", raw)
+		}
+	}
+	// The lookback is read last so "7d 4h" and "4h 7d" mean the same.
+	n, ok := domain.ChartLookbackCandles(req.Interval, lookback)
+	if !ok {
+		return req, fmt.Errorf("invalid lookback %q", lookback)
+	}
+	req.Candles = n
+	return req, nil
+}
+
+func formatChartCaption(req domain.ChartRequest) string {
+	interval := req.Interval
+	if interval == "" {
+		interval = "1h"
+	}
+	caption := fmt.Sprintf("%s %s", req.Symbol, interval)
+	if req.Candles > 0 {
+		caption += ", " + strconv.Itoa(req.Candles) + " candles"
+	}
+	if extras := append(append([]string{}, req.Overlays...), req.Panes...); len(extras) > 0 {
+		caption += " | " + strings.ToUpper(strings.Join(extras, " "))
+	}
+	return caption
+}
@@ -0,0 +1,38 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseChartArgs(t *testing.T) {
+	req, err := parseChartArgs([]string{"btc", "7d", "rsi", "4h", "EMA", "volume"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.Symbol != "BTC" || req.Interval != "4h" || req.Candles != 42 ||
+		len(req.Overlays) != 1 || req.Overlays[0] != "ema" || len(req.Panes) != 2 || req.Panes[1] != "volume" {
+		t.Fatalf("unexpected request: %+v", req)
+	}
+
+	req, err = parseChartArgs([]string{"ETH"})
+	if err != nil || req.Interval != "" || req.Candles != 0 {
+		t.Fatalf("expected defaults left to the service, got %+v %v", req, err)
+	}
+	if req, err = parseChartArgs([]string{"SOL", "200"}); err != nil || req.Candles != 200 {
+		t.Fatalf("expected a candle count, got %+v %v", req, err)
+	}
+
+	for _, args := range [][]string{nil, {"SHIB"}, {"BTC", "ichimoku"}, {"BTC", "7y"}, {"BTC", "7d", "48h"}} {
+		if _, err := parseChartArgs(args); err == nil {
+			t.Fatalf("expected error for %v", args)
+		}
+	}
+}
+
+func TestFormatChartCaption(t *testing.T) {
+	req, _ := parseChartArgs([]string{"BTC", "rsi", "bollinger"})
+	if got := formatChartCaption(req); !strings.HasPrefix(got, "BTC 1h") || !strings.HasSuffix(got, "| BOLLINGER RSI") {
+		t.Fatalf("unexpected caption: %q", got)
+	}
+}
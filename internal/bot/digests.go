@@ -0,0 +1,243 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bug-free-umbrella/internal/chart"
+	"bug-free-umbrella/internal/domain"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+const digestUsage = "Usage:\n" +
+	"/digest [daily|weekly] - digest right now\n" +
+	"/digest daily 08:00 [Europe/Berlin]\n" +
+	"/digest weekly mon 08:00 [Europe/Berlin]\n" +
+	"/digest status | off"
+
+// maxDigestText keeps a digest inside Telegram's 4096 character message
+// limit.
+const maxDigestText = 4000
+
+type DigestManager interface {
+	BuildDigest(ctx context.Context, period domain.DigestPeriod) (*domain.Digest, error)
+	GetDigestSubscription(ctx context.Context, chatID int64) (*domain.DigestSubscription, error)
+	SubscribeDigest(ctx context.Context, sub domain.DigestSubscription) (*domain.DigestSubscription, error)
+	UnsubscribeDigest(ctx context.Context, chatID int64) (bool, error)
+}
+
+var digestWeekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// registerDigestCommands adds /digest, which sends a digest on demand or
+// schedules one. Digests go out through alerts so they share its image
+// handling and, when enabled, the delivery queue.
+func registerDigestCommands(b *tele.Bot, digests DigestManager, alerts *AlertDispatcher) {
+	b.Handle("/digest", func(c tele.Context) error {
+		if digests == nil {
+			return c.Send("Digests unavailable")
+		}
+		chat := c.Chat()
+		if chat == nil {
+			return c.Send("Unable to detect chat.")
+		}
+		ctx := context.Background()
+		args := c.Args()
+		if period, ok := parseOnDemandDigest(args); ok {
+			_ = c.Notify(tele.UploadingPhoto)
+			d, err := digests.BuildDigest(ctx, period)
+			if err != nil {
+				return c.Send(fmt.Sprintf("Unable to build digest: %v", err))
+			}
+			return alerts.Deliver(ctx, digestDelivery(chat.ID, d))
+		}
+		return c.Send(handleDigestCommand(ctx, digests, chat.ID, args, time.Now()))
+	})
+}
+
+// parseOnDemandDigest reports whether args ask for a digest right now, and
+// for which period.
+func parseOnDemandDigest(args []string) (domain.DigestPeriod, bool) {
+	switch len(args) {
+	case 0:
+		return domain.DigestDaily, true
+	case 1:
+		period := domain.DigestPeriod(strings.ToLower(args[0]))
+		return period, period.IsValid()
+	}
+	return "", false
+}
+
+// handleDigestCommand runs one scheduling subcommand and returns the reply.
+func handleDigestCommand(ctx context.Context, digests DigestManager, chatID int64, args []string, now time.Time) string {
+	if len(args) == 0 {
+		return digestUsage
+	}
+	switch strings.ToLower(args[0]) {
+	case "status":
+		sub, err := digests.GetDigestSubscription(ctx, chatID)
+		if err != nil {
+			return fmt.Sprintf("Error loading digest: %v", err)
+		}
+		if sub == nil {
+			return "No scheduled digest. Set one with /digest daily 08:00"
+		}
+		return formatDigestSubscription(*sub, now)
+	case "off", "stop":
+		removed, err := digests.UnsubscribeDigest(ctx, chatID)
+		if err != nil {
+			return fmt.Sprintf("Unable to stop digest: %v", err)
+		}
+		if !removed {
+			return "No scheduled digest to stop."
+		}
+		return "Scheduled digest stopped."
+	}
+
+	sub, err := parseDigestSchedule(args)
+	if err != nil {
+		return fmt.Sprintf("Invalid schedule: %v\n\n%s", err, digestUsage)
+	}
+	sub.ChatID = chatID
+	saved, err := digests.SubscribeDigest(ctx, sub)
+	if err != nil {
+		return fmt.Sprintf("Unable to schedule digest: %v", err)
+	}
+	return "Digest scheduled.\n" + formatDigestSubscription(*saved, now)
+}
+
+// parseDigestSchedule reads "daily HH:MM [tz]" or "weekly DAY HH:MM [tz]".
+func parseDigestSchedule(args []string) (domain.DigestSubscription, error) {
+	sub := domain.DigestSubscription{Period: domain.DigestPeriod(strings.ToLower(args[0]))}
+	rest := args[1:]
+	switch sub.Period {
+	case domain.DigestDaily:
+	case domain.DigestWeekly:
+		if len(rest) == 0 {
+			return sub, errors.New("weekly digests need a day such as mon")
+		}
+		key := strings.ToLower(rest[0])
+		if len(key) > 3 {
+			key = key[:3]
+		}
+		day, ok := digestWeekdays[key]
+		if !ok {
+			return sub, fmt.Errorf("unknown day %q", rest[0])
+		}
+		sub.Weekday = day
+		rest = rest[1:]
+	default:
+		return sub, errors.New("period must be daily or weekly")
+	}
+
+	if len(rest) == 0 || len(rest) > 2 {
+		return sub, errors.New("expected a time such as 08:00 and an optional timezone")
+	}
+	at, err := time.Parse("15:04", rest[0])
+	if err != nil {
+		return sub, errors.New("time must be HH:MM")
+	}
+	sub.Minute = at.Hour()*60 + at.Minute()
+	sub.Timezone = "UTC"
+	if len(rest) == 2 {
+		loc, err := time.LoadLocation(strings.TrimSpace(rest[1]))
+		if err != nil {
+			return sub, fmt.Errorf("unknown timezone %q", rest[1])
+		}
+		sub.Timezone = loc.String()
+	}
+	return sub, nil
+}
+
+func formatDigestSubscription(sub domain.DigestSubscription, now time.Time) string {
+	when := "Daily"
+	if sub.Period == domain.DigestWeekly {
+		when = "Weekly on " + sub.Weekday.String()
+	}
+	next := sub.NextDue(now).In(sub.Location())
+	return fmt.Sprintf("%s at %02d:%02d %s\nNext: %s",
+		when, sub.Minute/60, sub.Minute%60, sub.Location(), next.Format("Mon Jan 2 15:04"))
+}
+
+// SendDigest delivers a scheduled digest to a chat.
+func (d *AlertDispatcher) SendDigest(ctx context.Context, chatID int64, digest *domain.Digest) error {
+	if d == nil || d.sender == nil {
+		return nil
+	}
+	return d.dispatch(ctx, []domain.AlertDelivery{digestDelivery(chatID, digest)})
+}
+
+func digestDelivery(chatID int64, d *domain.Digest) domain.AlertDelivery {
+	return domain.AlertDelivery{
+		ChatID: chatID,
+		Kind:   domain.DeliveryKindDigest,
+		Text:   formatDigest(d),
+		Image:  d.Chart,
+	}
+}
+
+// formatDigest renders a digest as a message. The first paragraph holds the
+// title and chart legend so it can stand alone as the photo caption.
+func formatDigest(d *domain.Digest) string {
+	var sb strings.Builder
+	title := "Daily"
+	if d.Period == domain.DigestWeekly {
+		title = "Weekly"
+	}
+	fmt.Fprintf(&sb, "%s market digest, %s to %s UTC", title, d.From.UTC().Format("Jan 2 15:04"), d.To.UTC().Format("Jan 2 15:04"))
+	if len(d.Chart) > 0 && len(d.Prices) > 0 {
+		legend := make([]string, 0, len(d.Prices))
+		for i, p := range d.Prices {
+			legend = append(legend, fmt.Sprintf("%s %s", p.Symbol, chart.SeriesColorName(i)))
+		}
+		sb.WriteString("\nChart: " + strings.Join(legend, ", "))
+	}
+
+	if d.Summary != "" {
+		sb.WriteString("\n\n" + d.Summary)
+	}
+
+	if len(d.Prices) > 0 {
+		sb.WriteString("\n\nPrices:")
+		for _, p := range d.Prices {
+			fmt.Fprintf(&sb, "\n%s %+.2f%% to %s", p.Symbol, p.ChangePct, formatLevelPrice(p.Close))
+		}
+	}
+	if len(d.TopSignals) > 0 {
+		sb.WriteString("\n\nTop signals by risk:")
+		for _, s := range d.TopSignals {
+			fmt.Fprintf(&sb, "\n#%d %s %s %s %s risk %d", s.ID, s.Symbol, s.Interval,
+				strings.ToUpper(s.Indicator), strings.ToUpper(string(s.Direction)), s.Risk)
+		}
+	}
+	if d.ML != nil {
+		fmt.Fprintf(&sb, "\n\nML accuracy: %.1f%% (%d/%d predictions)", d.ML.Accuracy*100, d.ML.Correct, d.ML.Predictions)
+	}
+	if len(d.SentimentMoves) > 0 {
+		sb.WriteString("\n\nSentiment moves:")
+		for _, m := range d.SentimentMoves {
+			fmt.Fprintf(&sb, "\n%s %+.2f (%.2f to %.2f) %s", m.Symbol, m.Change, m.From, m.To, strings.ToUpper(string(m.Direction)))
+		}
+	}
+	if len(d.Headlines) > 0 {
+		sb.WriteString("\n\nHeadlines:")
+		for _, h := range d.Headlines {
+			fmt.Fprintf(&sb, "\n- %s (%s)", h.Title, h.Source)
+			if h.URL != "" {
+				sb.WriteString("\n  " + h.URL)
+			}
+		}
+	}
+
+	text := sb.String()
+	if len(text) > maxDigestText {
+		text = text[:maxDigestText] + "\n[truncated]"
+	}
+	return text
+}
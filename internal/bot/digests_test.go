@@ -0,0 +1,127 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+type stubDigestManager struct {
+	sub     *domain.DigestSubscription
+	saved   domain.DigestSubscription
+	removed bool
+}
+
+func (s *stubDigestManager) BuildDigest(ctx context.Context, period domain.DigestPeriod) (*domain.Digest, error) {
+	return &domain.Digest{Period: period}, nil
+}
+
+func (s *stubDigestManager) GetDigestSubscription(ctx context.Context, chatID int64) (*domain.DigestSubscription, error) {
+	return s.sub, nil
+}
+
+func (s *stubDigestManager) SubscribeDigest(ctx context.Context, sub domain.DigestSubscription) (*domain.DigestSubscription, error) {
+	s.saved = sub
+	return &sub, nil
+}
+
+func (s *stubDigestManager) UnsubscribeDigest(ctx context.Context, chatID int64) (bool, error) {
+	return s.removed, nil
+}
+
+func TestParseOnDemandDigest(t *testing.T) {
+	if period, ok := parseOnDemandDigest(nil); !ok || period != domain.DigestDaily {
+		t.Fatalf("expected daily by default, got %q %v", period, ok)
+	}
+	if period, ok := parseOnDemandDigest([]string{"Weekly"}); !ok || period != domain.DigestWeekly {
+		t.Fatalf("expected weekly, got %q %v", period, ok)
+	}
+	if _, ok := parseOnDemandDigest([]string{"status"}); ok {
+		t.Fatal("expected status to be a subcommand")
+	}
+	if _, ok := parseOnDemandDigest([]string{"daily", "08:00"}); ok {
+		t.Fatal("expected a schedule to be a subcommand")
+	}
+}
+
+func TestHandleDigestCommand(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 6, 10, 9, 0, 0, 0, time.UTC) // Wednesday
+	mgr := &stubDigestManager{}
+
+	reply := handleDigestCommand(ctx, mgr, 42, []string{"weekly", "Monday", "08:30", "Europe/Berlin"}, now)
+	if mgr.saved.ChatID != 42 || mgr.saved.Period != domain.DigestWeekly || mgr.saved.Weekday != time.Monday ||
+		mgr.saved.Minute != 8*60+30 || mgr.saved.Timezone != "Europe/Berlin" {
+		t.Fatalf("unexpected schedule: %+v", mgr.saved)
+	}
+	if !strings.Contains(reply, "Weekly on Monday at 08:30 Europe/Berlin") || !strings.Contains(reply, "Next: Mon Jun 15 08:30") {
+		t.Fatalf("unexpected reply: %q", reply)
+	}
+
+	if reply := handleDigestCommand(ctx, mgr, 42, []string{"daily", "8am"}, now); !strings.HasPrefix(reply, "Invalid schedule: time must be HH:MM") {
+		t.Fatalf("expected time error, got %q", reply)
+	}
+	if reply := handleDigestCommand(ctx, mgr, 42, []string{"weekly", "someday", "08:00"}, now); !strings.Contains(reply, "unknown day") {
+		t.Fatalf("expected day error, got %q", reply)
+	}
+	if reply := handleDigestCommand(ctx, mgr, 42, []string{"status"}, now); !strings.HasPrefix(reply, "No scheduled digest") {
+		t.Fatalf("unexpected status: %q", reply)
+	}
+	if reply := handleDigestCommand(ctx, mgr, 42, []string{"off"}, now); reply != "No scheduled digest to stop." {
+		t.Fatalf("unexpected off reply: %q", reply)
+	}
+}
+
+func TestFormatDigest(t *testing.T) {
+	from := time.Date(2026, 6, 9, 9, 0, 0, 0, time.UTC)
+	text := formatDigest(&domain.Digest{
+		Period:  domain.DigestDaily,
+		From:    from,
+		To:      from.Add(24 * time.Hour),
+		Summary: "Markets rose.",
+		Prices: []domain.DigestPriceChange{
+			{Symbol: "BTC", Close: 65000, ChangePct: 2.5},
+			{Symbol: "ETH", Close: 3000, ChangePct: -1},
+		},
+		ML:        &domain.MLMetrics{Predictions: 10, Correct: 6, Accuracy: 0.6},
+		Headlines: []domain.DigestHeadline{{Title: "ETF inflows", Source: "rss", URL: "https://example.com/a"}},
+		Chart:     []byte{1},
+	})
+	head, _, _ := strings.Cut(text, "\n\n")
+	if head != "Daily market digest, Jun 9 09:00 to Jun 10 09:00 UTC\nChart: BTC blue, ETH orange" {
+		t.Fatalf("unexpected caption paragraph: %q", head)
+	}
+	for _, want := range []string{"Markets rose.", "BTC +2.50% to $65000.00", "ML accuracy: 60.0% (6/10 predictions)", "- ETF inflows (rss)"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("expected %q in digest:\n%s", want, text)
+		}
+	}
+}
+
+func TestAlertDispatcherSendDigestSplitsLongCaption(t *testing.T) {
+	sender := &fakeSender{}
+	dispatcher := NewAlertDispatcher(sender, nil)
+
+	summary := strings.Repeat("word ", 300)
+	err := dispatcher.SendDigest(context.Background(), 7, &domain.Digest{
+		Period:  domain.DigestDaily,
+		Summary: summary,
+		Prices:  []domain.DigestPriceChange{{Symbol: "BTC", Close: 1, ChangePct: 1}},
+		Chart:   []byte{0x89, 0x50, 0x4e, 0x47},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sender.kinds[7]) != 2 || sender.kinds[7][0] != "photo" || sender.kinds[7][1] != "text" {
+		t.Fatalf("expected photo then text, got %v", sender.kinds[7])
+	}
+	if !strings.HasPrefix(sender.messages[7][0], "Daily market digest") || strings.Contains(sender.messages[7][0], "word") {
+		t.Fatalf("expected the caption to hold only the heading, got %q", sender.messages[7][0])
+	}
+	if !strings.HasPrefix(sender.messages[7][1], "word") {
+		t.Fatalf("expected the rest to follow as text, got %q", sender.messages[7][1])
+	}
+}
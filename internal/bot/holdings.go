@@ -0,0 +1,181 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"bug-free-umbrella/internal/domain"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// maxHoldingsCSVBytes caps trade-history uploads.
+const maxHoldingsCSVBytes = 5 << 20
+
+const holdingsUsage = "Usage:\n" +
+	"/portfolio - your holdings and PnL\n" +
+	"/portfolio buy BTC 0.5 30000 [fee]\n" +
+	"/portfolio sell BTC 0.1 42000 [fee]\n" +
+	"/portfolio share on|off - let the advisor see your holdings\n" +
+	"Send an exchange trade-history CSV as a file to import it."
+
+type HoldingsTracker interface {
+	Valuation(ctx context.Context, chatID int64) (*domain.HoldingsValuation, error)
+	AddTrade(ctx context.Context, t domain.HoldingTrade) (*domain.HoldingTrade, error)
+	ImportCSV(ctx context.Context, chatID int64, r io.Reader) (*domain.HoldingsImportResult, error)
+	SetShareWithAdvisor(ctx context.Context, chatID int64, share bool) error
+}
+
+// registerHoldingsCommands adds /portfolio and CSV imports. Holdings belong to
+// the chat, the same identity the advisor uses.
+func registerHoldingsCommands(b *tele.Bot, holdings HoldingsTracker) {
+	b.Handle("/portfolio", func(c tele.Context) error {
+		if holdings == nil {
+			return c.Send("Portfolio tracking unavailable")
+		}
+		chat := c.Chat()
+		if chat == nil {
+			return c.Send("Unable to detect chat.")
+		}
+		ctx := context.Background()
+		args := c.Args()
+		if len(args) == 0 {
+			v, err := holdings.Valuation(ctx, chat.ID)
+			if err != nil {
+				return c.Send(fmt.Sprintf("Error loading portfolio: %v", err))
+			}
+			return c.Send(formatHoldings(*v))
+		}
+
+		switch strings.ToLower(args[0]) {
+		case "buy", "sell":
+			trade, err := parseHoldingTradeArgs(domain.PaperSide(strings.ToLower(args[0])), args[1:])
+			if err != nil {
+				return c.Send(holdingsUsage)
+			}
+			trade.ChatID = chat.ID
+			out, err := holdings.AddTrade(ctx, trade)
+			if err != nil {
+				return c.Send(fmt.Sprintf("Trade rejected: %v", err))
+			}
+			return c.Send(fmt.Sprintf("Recorded %s %.6g %s at %s (#%d)",
+				out.Side, out.Quantity, out.Symbol, formatLevelPrice(out.Price), out.ID))
+		case "share":
+			if len(args) != 2 || (args[1] != "on" && args[1] != "off") {
+				return c.Send(holdingsUsage)
+			}
+			share := args[1] == "on"
+			if err := holdings.SetShareWithAdvisor(ctx, chat.ID, share); err != nil {
+				return c.Send(fmt.Sprintf("Error saving preference: %v", err))
+			}
+			if share {
+				return c.Send("The advisor can now see your holdings.")
+			}
+			return c.Send("The advisor will no longer see your holdings.")
+		default:
+			return c.Send(holdingsUsage)
+		}
+	})
+
+	b.Handle(tele.OnDocument, func(c tele.Context) error {
+		msg := c.Message()
+		if holdings == nil || msg == nil || msg.Document == nil || !isCSVDocument(msg.Document) {
+			return nil
+		}
+		if msg.Document.FileSize > maxHoldingsCSVBytes {
+			return c.Send("That file is too large to import (max 5 MB).")
+		}
+		file, err := b.File(&msg.Document.File)
+		if err != nil {
+			return c.Send(fmt.Sprintf("Error downloading file: %v", err))
+		}
+		defer file.Close()
+
+		res, err := holdings.ImportCSV(context.Background(), c.Chat().ID, io.LimitReader(file, maxHoldingsCSVBytes))
+		if err != nil {
+			return c.Send(fmt.Sprintf("Import failed: %v", err))
+		}
+		return c.Send(formatHoldingsImport(*res))
+	})
+}
+
+func isCSVDocument(doc *tele.Document) bool {
+	return strings.HasSuffix(strings.ToLower(doc.FileName), ".csv") || doc.MIME == "text/csv"
+}
+
+// parseHoldingTradeArgs reads "SYMBOL QUANTITY PRICE [FEE]".
+func parseHoldingTradeArgs(side domain.PaperSide, args []string) (domain.HoldingTrade, error) {
+	trade := domain.HoldingTrade{Side: side}
+	if len(args) < 3 || len(args) > 4 {
+		return trade, errors.New("expected symbol, quantity and price")
+	}
+	trade.Symbol = strings.ToUpper(strings.TrimSpace(args[0]))
+	if _, ok := domain.CoinGeckoID[trade.Symbol]; !ok {
+		return trade, errors.New("unsupported symbol")
+	}
+	values := make([]float64, 0, 3)
+	for _, raw := range args[1:] {
+		v, err := strconv.ParseFloat(strings.TrimPrefix(raw, "$"), 64)
+		if err != nil || v < 0 {
+			return trade, errors.New("invalid number")
+		}
+		values = append(values, v)
+	}
+	trade.Quantity, trade.Price = values[0], values[1]
+	if len(values) == 3 {
+		trade.Fee = values[2]
+	}
+	if trade.Quantity <= 0 || trade.Price <= 0 {
+		return trade, errors.New("quantity and price must be positive")
+	}
+	return trade, nil
+}
+
+func formatHoldings(v domain.HoldingsValuation) string {
+	if len(v.Holdings) == 0 {
+		msg := "No holdings yet.\n\n" + holdingsUsage
+		if v.RealizedPnL != 0 {
+			msg = fmt.Sprintf("No open holdings. Realised PnL: %+.2f\n\n%s", v.RealizedPnL, holdingsUsage)
+		}
+		return msg
+	}
+	var sb strings.Builder
+	pct := 0.0
+	if v.CostBasis > 0 {
+		pct = v.UnrealizedPnL / v.CostBasis * 100
+	}
+	fmt.Fprintf(&sb, "Portfolio value: $%.2f\nCost basis: $%.2f\nUnrealised: %+.2f (%+.2f%%)\nRealised: %+.2f\n",
+		v.MarketValue, v.CostBasis, v.UnrealizedPnL, pct, v.RealizedPnL)
+	for _, h := range v.Holdings {
+		price := formatLevelPrice(h.Price)
+		if !h.Priced {
+			price += " (no live price)"
+		}
+		fmt.Fprintf(&sb, "\n%s %.6g @ %s (avg %s) %.1f%% | %+.2f",
+			h.Symbol, h.Quantity, price, formatLevelPrice(h.AvgCost), h.Allocation*100, h.UnrealizedPnL)
+	}
+	if v.ShareAdvisor {
+		sb.WriteString("\n\nShared with the advisor.")
+	}
+	return sb.String()
+}
+
+func formatHoldingsImport(r domain.HoldingsImportResult) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Imported %d trades", r.Imported)
+	if r.Duplicates > 0 {
+		fmt.Fprintf(&sb, ", %d already imported", r.Duplicates)
+	}
+	if r.Skipped > 0 {
+		fmt.Fprintf(&sb, ", skipped %d rows", r.Skipped)
+	}
+	sb.WriteString(".")
+	for _, e := range r.Errors {
+		sb.WriteString("\n" + e)
+	}
+	return sb.String()
+}
@@ -0,0 +1,68 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"bug-free-umbrella/internal/domain"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+func TestParseHoldingTradeArgs(t *testing.T) {
+	trade, err := parseHoldingTradeArgs(domain.PaperBuy, []string{"btc", "0.5", "$30000", "12.5"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if trade.Symbol != "BTC" || trade.Quantity != 0.5 || trade.Price != 30000 || trade.Fee != 12.5 || trade.Side != domain.PaperBuy {
+		t.Fatalf("unexpected trade: %+v", trade)
+	}
+
+	for _, args := range [][]string{
+		{"BTC", "1"},
+		{"NOPE", "1", "2"},
+		{"BTC", "0", "2"},
+		{"BTC", "1", "abc"},
+		{"BTC", "1", "2", "-1"},
+	} {
+		if _, err := parseHoldingTradeArgs(domain.PaperSell, args); err == nil {
+			t.Fatalf("expected %v to be rejected", args)
+		}
+	}
+}
+
+func TestFormatHoldings(t *testing.T) {
+	msg := formatHoldings(domain.HoldingsValuation{
+		Holdings: []domain.Holding{
+			{Symbol: "BTC", Quantity: 0.5, Price: 40000, Priced: true, AvgCost: 30000, Allocation: 0.8, UnrealizedPnL: 5000},
+			{Symbol: "SOL", Quantity: 10, Price: 100, AvgCost: 100, Allocation: 0.2},
+		},
+		MarketValue: 25000, CostBasis: 20000, UnrealizedPnL: 5000, RealizedPnL: -10,
+		ShareAdvisor: true,
+	})
+	for _, want := range []string{"Portfolio value: $25000.00", "+25.00%", "BTC 0.5", "80.0%", "no live price", "Shared with the advisor"} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("expected %q in:\n%s", want, msg)
+		}
+	}
+
+	if msg := formatHoldings(domain.HoldingsValuation{}); !strings.Contains(msg, "No holdings yet") {
+		t.Fatalf("expected empty note, got %q", msg)
+	}
+}
+
+func TestFormatHoldingsImport(t *testing.T) {
+	msg := formatHoldingsImport(domain.HoldingsImportResult{Imported: 3, Duplicates: 1, Skipped: 2, Errors: []string{"line 4: invalid price \"x\""}})
+	if !strings.Contains(msg, "Imported 3 trades, 1 already imported, skipped 2 rows.") || !strings.Contains(msg, "line 4") {
+		t.Fatalf("unexpected import summary: %q", msg)
+	}
+}
+
+func TestIsCSVDocument(t *testing.T) {
+	if !isCSVDocument(&tele.Document{FileName: "Trades.CSV"}) || !isCSVDocument(&tele.Document{MIME: "text/csv"}) {
+		t.Fatal("expected csv documents to be recognised")
+	}
+	if isCSVDocument(&tele.Document{FileName: "chart.png", MIME: "image/png"}) {
+		t.Fatal("expected non-csv document to be ignored")
+	}
+}
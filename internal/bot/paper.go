@@ -0,0 +1,143 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"bug-free-umbrella/internal/domain"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+type PaperTrader interface {
+	AccountForOwner(ctx context.Context, owner, name string) (*domain.PaperAccount, error)
+	PlaceOrder(ctx context.Context, req domain.PaperOrderRequest) (*domain.PaperOrder, error)
+	Portfolio(ctx context.Context, accountID int64) (*domain.PaperPortfolio, error)
+}
+
+// registerPaperCommands adds /buy, /sell and /paper. Each chat trades its
+// own paper account, opened on first use.
+func registerPaperCommands(b *tele.Bot, paper PaperTrader) {
+	account := func(c tele.Context) (*domain.PaperAccount, error) {
+		chat := c.Chat()
+		if chat == nil {
+			return nil, errors.New("unable to detect chat")
+		}
+		return paper.AccountForOwner(context.Background(), paperOwner(chat.ID), fmt.Sprintf("telegram %d", chat.ID))
+	}
+
+	trade := func(side domain.PaperSide, usage string) tele.HandlerFunc {
+		return func(c tele.Context) error {
+			if paper == nil {
+				return c.Send("Paper trading unavailable")
+			}
+			req, err := parsePaperOrderArgs(side, c.Args())
+			if err != nil {
+				return c.Send(usage)
+			}
+			acct, err := account(c)
+			if err != nil {
+				return c.Send(fmt.Sprintf("Error opening paper account: %v", err))
+			}
+			req.AccountID = acct.ID
+			order, err := paper.PlaceOrder(context.Background(), req)
+			if err != nil {
+				return c.Send(fmt.Sprintf("Order rejected: %v", err))
+			}
+			return c.Send(formatPaperOrder(*order))
+		}
+	}
+
+	b.Handle("/buy", trade(domain.PaperBuy, "Usage: /buy BTC 0.01 | /buy BTC $250"))
+	b.Handle("/sell", trade(domain.PaperSell, "Usage: /sell BTC 0.01 | /sell BTC $250 | /sell BTC all"))
+
+	b.Handle("/paper", func(c tele.Context) error {
+		if paper == nil {
+			return c.Send("Paper trading unavailable")
+		}
+		acct, err := account(c)
+		if err != nil {
+			return c.Send(fmt.Sprintf("Error opening paper account: %v", err))
+		}
+		p, err := paper.Portfolio(context.Background(), acct.ID)
+		if err != nil {
+			return c.Send(fmt.Sprintf("Error loading portfolio: %v", err))
+		}
+		return c.Send(formatPaperPortfolio(*p))
+	})
+}
+
+func paperOwner(chatID int64) string {
+	return fmt.Sprintf("telegram:%d", chatID)
+}
+
+// parsePaperOrderArgs reads "SYMBOL [amount]", where amount is a quantity,
+// a USD notional prefixed with $, or "all". Buys need an amount; a sell
+// without one closes the position.
+func parsePaperOrderArgs(side domain.PaperSide, args []string) (domain.PaperOrderRequest, error) {
+	req := domain.PaperOrderRequest{Side: side, Source: domain.PaperSourceManual}
+	if len(args) == 0 || len(args) > 2 {
+		return req, errors.New("expected symbol and amount")
+	}
+	req.Symbol = strings.ToUpper(strings.TrimSpace(args[0]))
+	if _, ok := domain.CoinGeckoID[req.Symbol]; !ok {
+		return req, errors.New("unsupported symbol")
+	}
+	if len(args) == 1 {
+		if side == domain.PaperBuy {
+			return req, errors.New("missing amount")
+		}
+		return req, nil
+	}
+
+	amount := strings.ToLower(strings.TrimSpace(args[1]))
+	if amount == "all" {
+		if side == domain.PaperBuy {
+			return req, errors.New("cannot buy all")
+		}
+		return req, nil
+	}
+	notional := strings.HasPrefix(amount, "$")
+	v, err := strconv.ParseFloat(strings.TrimPrefix(amount, "$"), 64)
+	if err != nil || v <= 0 {
+		return req, errors.New("invalid amount")
+	}
+	if notional {
+		req.Notional = v
+	} else {
+		req.Quantity = v
+	}
+	return req, nil
+}
+
+func formatPaperOrder(o domain.PaperOrder) string {
+	line := fmt.Sprintf(
+		"Paper %s %.6g %s at %s ($%.2f)",
+		strings.ToUpper(string(o.Side)),
+		o.Quantity,
+		o.Symbol,
+		formatLevelPrice(o.FillPrice),
+		o.Notional,
+	)
+	if o.Side == domain.PaperSell {
+		line += fmt.Sprintf("\nRealised PnL: %+.2f", o.RealizedPnL)
+	}
+	return line
+}
+
+func formatPaperPortfolio(p domain.PaperPortfolio) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Paper account #%d\nEquity: $%.2f (%+.2f%%)\nCash: $%.2f\n", p.Account.ID, p.Equity, p.Return*100, p.Account.Cash)
+	fmt.Fprintf(&sb, "Realised: %+.2f | Unrealised: %+.2f", p.RealizedPnL, p.UnrealizedPnL)
+	if len(p.Holdings) == 0 {
+		sb.WriteString("\nNo open positions.")
+		return sb.String()
+	}
+	for _, h := range p.Holdings {
+		fmt.Fprintf(&sb, "\n%s %.6g @ %s -> %s (%+.2f)", h.Symbol, h.Quantity, formatLevelPrice(h.AvgPrice), formatLevelPrice(h.MarkPrice), h.UnrealizedPnL)
+	}
+	return sb.String()
+}
@@ -0,0 +1,73 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+func TestParsePaperOrderArgs(t *testing.T) {
+	req, err := parsePaperOrderArgs(domain.PaperBuy, []string{"btc", "$250"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.Symbol != "BTC" || req.Notional != 250 || req.Quantity != 0 || req.Side != domain.PaperBuy {
+		t.Fatalf("unexpected request: %+v", req)
+	}
+
+	req, err = parsePaperOrderArgs(domain.PaperSell, []string{"ETH", "0.5"})
+	if err != nil || req.Quantity != 0.5 || req.Notional != 0 {
+		t.Fatalf("unexpected sell request: %+v (%v)", req, err)
+	}
+
+	for _, args := range [][]string{{"ETH"}, {"ETH", "all"}} {
+		req, err = parsePaperOrderArgs(domain.PaperSell, args)
+		if err != nil || req.Quantity != 0 || req.Notional != 0 {
+			t.Fatalf("expected %v to sell everything, got %+v (%v)", args, req, err)
+		}
+	}
+}
+
+func TestParsePaperOrderArgsRejectsInvalid(t *testing.T) {
+	cases := []struct {
+		side domain.PaperSide
+		args []string
+	}{
+		{domain.PaperBuy, nil},
+		{domain.PaperBuy, []string{"BTC"}},
+		{domain.PaperBuy, []string{"BTC", "all"}},
+		{domain.PaperBuy, []string{"NOPE", "1"}},
+		{domain.PaperBuy, []string{"BTC", "-1"}},
+		{domain.PaperSell, []string{"BTC", "$x"}},
+		{domain.PaperSell, []string{"BTC", "1", "2"}},
+	}
+	for _, tc := range cases {
+		if _, err := parsePaperOrderArgs(tc.side, tc.args); err == nil {
+			t.Fatalf("expected %s %v to fail", tc.side, tc.args)
+		}
+	}
+}
+
+func TestFormatPaperPortfolio(t *testing.T) {
+	p := domain.PaperPortfolio{
+		Account:       domain.PaperAccount{ID: 7, Cash: 9500},
+		Equity:        10100,
+		Return:        0.01,
+		UnrealizedPnL: 100,
+		Holdings: []domain.PaperHolding{{
+			PaperPosition: domain.PaperPosition{Symbol: "SOL", Quantity: 5, AvgPrice: 100},
+			MarkPrice:     120,
+			UnrealizedPnL: 100,
+		}},
+	}
+	out := formatPaperPortfolio(p)
+	for _, want := range []string{"#7", "$10100.00 (+1.00%)", "SOL 5 @ $100.00 -> $120.00 (+100.00)"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in %q", want, out)
+		}
+	}
+	if !strings.Contains(formatPaperPortfolio(domain.PaperPortfolio{}), "No open positions") {
+		t.Fatal("expected empty portfolio note")
+	}
+}
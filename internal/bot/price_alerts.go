@@ -0,0 +1,139 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/pricealert"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+const priceAlertUsage = "Usage:\n" +
+	"/alert list\n" +
+	"/alert add ETH above 4000 [once|recurring]\n" +
+	"/alert add SOL move 5% 1h [up|down|any] [once|recurring]\n" +
+	"/alert add BTC volume 50% 1h [once|recurring]\n" +
+	"/alert rm ID"
+
+type PriceAlertManager interface {
+	CreateRule(ctx context.Context, rule domain.PriceAlertRule) (*domain.PriceAlertRule, error)
+	ListRules(ctx context.Context, chatID int64) ([]domain.PriceAlertRule, error)
+	DeleteRule(ctx context.Context, chatID, id int64) error
+}
+
+// registerPriceAlertCommands adds /alert for the chat's own price alert
+// rules. /alerts stays the signal broadcast toggle.
+func registerPriceAlertCommands(b *tele.Bot, alerts PriceAlertManager) {
+	b.Handle("/alert", func(c tele.Context) error {
+		if alerts == nil {
+			return c.Send("Price alerts unavailable")
+		}
+		chat := c.Chat()
+		if chat == nil {
+			return c.Send("Unable to detect chat.")
+		}
+		return c.Send(handlePriceAlertCommand(context.Background(), alerts, chat.ID, c.Args()))
+	})
+}
+
+// handlePriceAlertCommand runs one /alert subcommand and returns the reply.
+func handlePriceAlertCommand(ctx context.Context, alerts PriceAlertManager, chatID int64, args []string) string {
+	if len(args) == 0 {
+		return priceAlertUsage
+	}
+	switch strings.ToLower(args[0]) {
+	case "list", "ls":
+		rules, err := alerts.ListRules(ctx, chatID)
+		if err != nil {
+			return fmt.Sprintf("Error loading alerts: %v", err)
+		}
+		return formatPriceAlertRules(rules)
+	case "add":
+		rule, err := pricealert.ParseRule(args[1:])
+		if err != nil {
+			return fmt.Sprintf("Invalid alert: %v\n\n%s", err, priceAlertUsage)
+		}
+		rule.ChatID = chatID
+		created, err := alerts.CreateRule(ctx, rule)
+		if err != nil {
+			return fmt.Sprintf("Unable to add alert: %v", err)
+		}
+		msg := fmt.Sprintf("Alert #%d added: %s", created.ID, pricealert.Describe(*created))
+		if !created.Armed {
+			msg += "\nThe condition already holds, so it will fire on the next cross."
+		}
+		return msg
+	case "rm", "remove", "delete":
+		if len(args) != 2 {
+			return priceAlertUsage
+		}
+		id, err := strconv.ParseInt(strings.TrimPrefix(args[1], "#"), 10, 64)
+		if err != nil || id <= 0 {
+			return priceAlertUsage
+		}
+		if err := alerts.DeleteRule(ctx, chatID, id); err != nil {
+			return fmt.Sprintf("Unable to remove alert: %v", err)
+		}
+		return fmt.Sprintf("Alert #%d removed.", id)
+	}
+	return priceAlertUsage
+}
+
+func formatPriceAlertRules(rules []domain.PriceAlertRule) string {
+	if len(rules) == 0 {
+		return "No price alerts. Add one with /alert add ETH above 4000"
+	}
+	var sb strings.Builder
+	sb.WriteString("Your price alerts:")
+	for _, r := range rules {
+		fmt.Fprintf(&sb, "\n#%d %s", r.ID, pricealert.Describe(r))
+		switch {
+		case !r.Active:
+			sb.WriteString(" - done")
+		case !r.Armed:
+			sb.WriteString(" - waiting to re-arm")
+		}
+		if r.TriggerCount > 0 {
+			fmt.Fprintf(&sb, ", fired %dx", r.TriggerCount)
+		}
+	}
+	return sb.String()
+}
+
+func formatPriceAlertTrigger(t domain.PriceAlertTrigger) string {
+	r := t.Rule
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Price alert #%d: %s\n", r.ID, pricealert.Describe(r))
+	switch r.Type {
+	case domain.PriceAlertCross:
+		fmt.Fprintf(&sb, "%s is now %s", r.Symbol, formatLevelPrice(t.Price))
+	case domain.PriceAlertVolume:
+		fmt.Fprintf(&sb, "%s 24h volume up %.2f%% (price %s)", r.Symbol, t.Value, formatLevelPrice(t.Price))
+	default:
+		fmt.Fprintf(&sb, "%s moved %.2f%% to %s", r.Symbol, t.Value, formatLevelPrice(t.Price))
+	}
+	if r.Active {
+		sb.WriteString("\nIt will fire again after re-arming.")
+	}
+	return sb.String()
+}
+
+// NotifyPriceAlerts sends each fired rule to the chat that owns it.
+func (d *AlertDispatcher) NotifyPriceAlerts(ctx context.Context, triggers []domain.PriceAlertTrigger) error {
+	if d == nil || d.sender == nil {
+		return nil
+	}
+	deliveries := make([]domain.AlertDelivery, 0, len(triggers))
+	for _, t := range triggers {
+		deliveries = append(deliveries, domain.AlertDelivery{
+			ChatID: t.Rule.ChatID,
+			Kind:   domain.DeliveryKindPriceAlert,
+			Text:   formatPriceAlertTrigger(t),
+		})
+	}
+	return d.dispatch(ctx, deliveries)
+}
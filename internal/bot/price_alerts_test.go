@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+type stubPriceAlertManager struct {
+	rules   []domain.PriceAlertRule
+	created domain.PriceAlertRule
+	deleted int64
+}
+
+func (s *stubPriceAlertManager) CreateRule(ctx context.Context, rule domain.PriceAlertRule) (*domain.PriceAlertRule, error) {
+	s.created = rule
+	rule.ID = 3
+	rule.Active = true
+	return &rule, nil
+}
+
+func (s *stubPriceAlertManager) ListRules(ctx context.Context, chatID int64) ([]domain.PriceAlertRule, error) {
+	return s.rules, nil
+}
+
+func (s *stubPriceAlertManager) DeleteRule(ctx context.Context, chatID, id int64) error {
+	if id != 3 {
+		return errors.New("price alert not found")
+	}
+	s.deleted = id
+	return nil
+}
+
+func TestHandlePriceAlertCommand(t *testing.T) {
+	ctx := context.Background()
+	mgr := &stubPriceAlertManager{}
+
+	reply := handlePriceAlertCommand(ctx, mgr, 42, []string{"add", "ETH", "below", "3000", "recurring"})
+	if mgr.created.ChatID != 42 || mgr.created.Direction != domain.PriceAlertDown || !mgr.created.Recurring {
+		t.Fatalf("unexpected rule: %+v", mgr.created)
+	}
+	if !strings.Contains(reply, "Alert #3 added: ETH crosses below $3000 (recurring)") || !strings.Contains(reply, "next cross") {
+		t.Fatalf("unexpected reply: %q", reply)
+	}
+
+	if reply := handlePriceAlertCommand(ctx, mgr, 42, []string{"add", "ETH", "sideways", "3"}); !strings.HasPrefix(reply, "Invalid alert") {
+		t.Fatalf("expected parse error, got %q", reply)
+	}
+	if reply := handlePriceAlertCommand(ctx, mgr, 42, []string{"rm", "#3"}); reply != "Alert #3 removed." || mgr.deleted != 3 {
+		t.Fatalf("unexpected reply: %q", reply)
+	}
+	if reply := handlePriceAlertCommand(ctx, mgr, 42, []string{"rm", "9"}); !strings.HasPrefix(reply, "Unable to remove") {
+		t.Fatalf("unexpected reply: %q", reply)
+	}
+	if reply := handlePriceAlertCommand(ctx, mgr, 42, []string{"rm", "x"}); reply != priceAlertUsage {
+		t.Fatalf("expected usage, got %q", reply)
+	}
+
+	mgr.rules = []domain.PriceAlertRule{
+		{ID: 1, Symbol: "BTC", Type: domain.PriceAlertCross, Direction: domain.PriceAlertUp, Threshold: 100000, Active: true, Armed: true},
+		{ID: 2, Symbol: "SOL", Type: domain.PriceAlertMove, Direction: domain.PriceAlertAny, Threshold: 5, WindowMins: 60, TriggerCount: 1},
+	}
+	reply = handlePriceAlertCommand(ctx, mgr, 42, []string{"list"})
+	for _, want := range []string{"#1 BTC crosses above $100000 (once)", "#2 SOL moves 5% within 1h (once) - done, fired 1x"} {
+		if !strings.Contains(reply, want) {
+			t.Fatalf("expected %q in %q", want, reply)
+		}
+	}
+}
+
+func TestAlertDispatcherNotifyPriceAlerts(t *testing.T) {
+	sender := &fakeSender{}
+	dispatcher := NewAlertDispatcher(sender, nil)
+
+	err := dispatcher.NotifyPriceAlerts(context.Background(), []domain.PriceAlertTrigger{
+		{Rule: domain.PriceAlertRule{ID: 1, ChatID: 10, Symbol: "ETH", Type: domain.PriceAlertCross, Direction: domain.PriceAlertUp, Threshold: 4000}, Price: 4012.5, Value: 4012.5, TriggeredAt: time.Now()},
+		{Rule: domain.PriceAlertRule{ID: 2, ChatID: 20, Symbol: "SOL", Type: domain.PriceAlertMove, Direction: domain.PriceAlertDown, Threshold: 5, WindowMins: 60, Active: true}, Price: 180, Value: 5.5},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sender.messages[10]) != 1 || !strings.Contains(sender.messages[10][0], "ETH is now $4012.50") {
+		t.Fatalf("unexpected message: %+v", sender.messages[10])
+	}
+	if len(sender.messages[20]) != 1 || !strings.Contains(sender.messages[20][0], "SOL moved 5.50% to $180.00") || !strings.Contains(sender.messages[20][0], "fire again") {
+		t.Fatalf("unexpected message: %+v", sender.messages[20])
+	}
+}
@@ -0,0 +1,573 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+const (
+	signalBrowseUnique = "sigs"
+	signalPageSize     = 5
+	// signalBrowseTTL is how long the buttons of a /signals message work.
+	signalBrowseTTL    = 24 * time.Hour
+	similarSignalLimit = 5
+)
+
+// Callback actions. Telegram caps callback data at 64 bytes, so they are
+// short and the rest of the state lives in the store under a token.
+const (
+	browseActionPage    = "pg"
+	browseActionFilter  = "flt"
+	browseActionSet     = "set"
+	browseActionSignal  = "sig"
+	browseActionChart   = "chart"
+	browseActionExplain = "why"
+	browseActionSimilar = "sim"
+	browseActionAsk     = "ask"
+)
+
+const (
+	browseFilterSymbol   = "sym"
+	browseFilterRisk     = "risk"
+	browseFilterInterval = "int"
+)
+
+// signalBrowseState is one /signals message: its filters, the page on
+// screen and the signals shown on it.
+type signalBrowseState struct {
+	Symbol   string          `json:"symbol,omitempty"`
+	Risk     int             `json:"risk,omitempty"`
+	Interval string          `json:"interval,omitempty"`
+	Page     int             `json:"page"`
+	HasNext  bool            `json:"has_next"`
+	Signals  []domain.Signal `json:"signals"`
+}
+
+// signalReply is what a browse step shows. Edit replaces the message that
+// holds the keyboard; otherwise a new message is sent.
+type signalReply struct {
+	Text   string
+	Markup *tele.ReplyMarkup
+	Photo  []byte
+	Edit   bool
+}
+
+type signalBrowser struct {
+	signals  SignalLister
+	charts   ChartRenderer
+	advisor  Advisor
+	store    CallbackStateStore
+	newToken func() string
+}
+
+func newSignalBrowser(signals SignalLister, charts ChartRenderer, advisor Advisor, store CallbackStateStore) *signalBrowser {
+	if store == nil {
+		store = newMemoryCallbackStore()
+	}
+	return &signalBrowser{
+		signals:  signals,
+		charts:   charts,
+		advisor:  advisor,
+		store:    store,
+		newToken: randomCallbackToken,
+	}
+}
+
+// registerSignalBrowser adds /signals and the callbacks of its keyboard.
+func registerSignalBrowser(b *tele.Bot, browser *signalBrowser) {
+	b.Handle("/signals", func(c tele.Context) error {
+		if browser.signals == nil {
+			return c.Send("Signal service unavailable")
+		}
+		filter, err := parseSignalArgs(c.Args())
+		if err != nil {
+			return c.Send("Usage: /signals BTC | /signals --risk 3 | /signals BTC --risk 3")
+		}
+		reply, err := browser.start(context.Background(), filter)
+		if err != nil {
+			return c.Send(fmt.Sprintf("Error fetching signals: %v", err))
+		}
+		return sendSignalReply(c, reply)
+	})
+
+	b.Handle(&tele.Btn{Unique: signalBrowseUnique}, func(c tele.Context) error {
+		chat := c.Chat()
+		if chat == nil || browser.signals == nil {
+			return c.Respond()
+		}
+		cb := c.Callback()
+		action, _, _ := strings.Cut(cb.Data, "|")
+		if action == browseActionAsk || action == browseActionChart {
+			_ = c.Respond(&tele.CallbackResponse{Text: "Working on it..."})
+		} else {
+			_ = c.Respond()
+		}
+		reply, err := browser.handle(context.Background(), chat.ID, cb.Data)
+		if err != nil {
+			log.Printf("signal browser callback %q for chat %d: %v", cb.Data, chat.ID, err)
+			return c.Send(fmt.Sprintf("Error: %v", err))
+		}
+		return sendSignalReply(c, reply)
+	})
+}
+
+func sendSignalReply(c tele.Context, reply signalReply) error {
+	opts := []any{}
+	if reply.Markup != nil {
+		opts = append(opts, reply.Markup)
+	}
+	if len(reply.Photo) > 0 {
+		return c.Send(&tele.Photo{File: tele.FromReader(bytes.NewReader(reply.Photo)), Caption: reply.Text}, opts...)
+	}
+	if reply.Edit && c.Callback() != nil {
+		return c.Edit(reply.Text, opts...)
+	}
+	return c.Send(reply.Text, opts...)
+}
+
+// start opens a browsable list for filter.
+func (br *signalBrowser) start(ctx context.Context, filter domain.SignalFilter) (signalReply, error) {
+	st := &signalBrowseState{Symbol: filter.Symbol, Interval: filter.Interval}
+	if filter.Risk != nil {
+		st.Risk = int(*filter.Risk)
+	}
+	token := br.newToken()
+	if err := br.loadPage(ctx, st); err != nil {
+		return signalReply{}, err
+	}
+	if err := br.save(ctx, token, st); err != nil {
+		return signalReply{}, err
+	}
+	return signalListView(token, st), nil
+}
+
+// handle runs one callback. data is "action|token[|arg]".
+func (br *signalBrowser) handle(ctx context.Context, chatID int64, data string) (signalReply, error) {
+	parts := strings.SplitN(data, "|", 3)
+	if len(parts) < 2 {
+		return signalReply{Text: "Unknown button."}, nil
+	}
+	action, token, arg := parts[0], parts[1], ""
+	if len(parts) == 3 {
+		arg = parts[2]
+	}
+	st, err := br.load(ctx, token)
+	if err != nil {
+		return signalReply{}, err
+	}
+	if st == nil {
+		return signalReply{Text: "These buttons have expired. Send /signals again."}, nil
+	}
+
+	switch action {
+	case browseActionPage:
+		page, err := strconv.Atoi(arg)
+		if err != nil || page < 0 {
+			return signalReply{Text: "Unknown page."}, nil
+		}
+		st.Page = page
+		return br.reload(ctx, token, st)
+	case browseActionFilter:
+		return signalFilterView(token, arg, st.Page), nil
+	case browseActionSet:
+		if !st.setFilter(arg) {
+			return signalReply{Text: "Unknown filter."}, nil
+		}
+		st.Page = 0
+		return br.reload(ctx, token, st)
+	}
+
+	idx, err := strconv.Atoi(arg)
+	if err != nil || idx < 0 || idx >= len(st.Signals) {
+		return signalReply{Text: "That signal is no longer on this page."}, nil
+	}
+	s := st.Signals[idx]
+	switch action {
+	case browseActionSignal:
+		return signalCardView(token, idx, st.Page, s), nil
+	case browseActionChart:
+		return br.chart(ctx, s)
+	case browseActionExplain:
+		return signalReply{Text: explainSignal(s)}, nil
+	case browseActionSimilar:
+		return br.similar(ctx, s)
+	case browseActionAsk:
+		if br.advisor == nil {
+			return signalReply{Text: "Advisor not configured. Set OPENAI_API_KEY to enable."}, nil
+		}
+		answer, err := br.advisor.Ask(ctx, chatID, signalAdvisorPrompt(s))
+		if err != nil {
+			log.Printf("advisor error for chat %d: %v", chatID, err)
+			return signalReply{Text: "Sorry, I'm having trouble right now."}, nil
+		}
+		if len(answer) > 4000 {
+			answer = answer[:4000] + "\n\n[truncated]"
+		}
+		return signalReply{Text: answer}, nil
+	}
+	return signalReply{Text: "Unknown button."}, nil
+}
+
+func (br *signalBrowser) reload(ctx context.Context, token string, st *signalBrowseState) (signalReply, error) {
+	if err := br.loadPage(ctx, st); err != nil {
+		return signalReply{}, err
+	}
+	if err := br.save(ctx, token, st); err != nil {
+		return signalReply{}, err
+	}
+	reply := signalListView(token, st)
+	reply.Edit = true
+	return reply, nil
+}
+
+// loadPage fetches the current page, asking for one extra signal to learn
+// whether there is a next page.
+func (br *signalBrowser) loadPage(ctx context.Context, st *signalBrowseState) error {
+	filter := domain.SignalFilter{
+		Symbol:   st.Symbol,
+		Interval: st.Interval,
+		Limit:    signalPageSize + 1,
+		Offset:   st.Page * signalPageSize,
+	}
+	if st.Risk > 0 {
+		risk := domain.RiskLevel(st.Risk)
+		filter.Risk = &risk
+	}
+	signals, err := br.signals.ListSignals(ctx, filter)
+	if err != nil {
+		return err
+	}
+	st.HasNext = len(signals) > signalPageSize
+	if st.HasNext {
+		signals = signals[:signalPageSize]
+	}
+	st.Signals = signals
+	return nil
+}
+
+// chart sends the signal's stored image, or renders a fresh chart when the
+// image has expired.
+func (br *signalBrowser) chart(ctx context.Context, s domain.Signal) (signalReply, error) {
+	caption := formatSignal(s)
+	if img, err := br.signals.GetSignalImage(ctx, s.ID); err == nil && img != nil && len(img.Bytes) > 0 {
+		return signalReply{Text: caption, Photo: img.Bytes}, nil
+	}
+	if br.charts == nil {
+		return signalReply{Text: "No chart available for this signal."}, nil
+	}
+	png, err := br.charts.RenderChart(ctx, domain.ChartRequest{
+		Symbol:   s.Symbol,
+		Interval: s.Interval,
+		Overlays: []string{domain.ChartOverlayEMA},
+		Panes:    []string{domain.ChartPaneRSI},
+	})
+	if err != nil {
+		return signalReply{Text: fmt.Sprintf("Unable to chart %s: %v", s.Symbol, err)}, nil
+	}
+	return signalReply{Text: caption, Photo: png}, nil
+}
+
+// similar lists earlier signals of the same symbol, interval, indicator and
+// direction.
+func (br *signalBrowser) similar(ctx context.Context, s domain.Signal) (signalReply, error) {
+	past, err := br.signals.ListSignals(ctx, domain.SignalFilter{
+		Symbol:    s.Symbol,
+		Interval:  s.Interval,
+		Indicator: s.Indicator,
+		Limit:     50,
+	})
+	if err != nil {
+		return signalReply{}, err
+	}
+	lines := make([]string, 0, similarSignalLimit)
+	for _, p := range past {
+		if p.ID == s.ID || p.Direction != s.Direction || !p.Timestamp.Before(s.Timestamp) {
+			continue
+		}
+		lines = append(lines, formatSignalLine(p))
+		if len(lines) == similarSignalLimit {
+			break
+		}
+	}
+	head := fmt.Sprintf("Past %s %s %s %s signals", s.Symbol, s.Interval, strings.ToUpper(s.Indicator), strings.ToUpper(string(s.Direction)))
+	if len(lines) == 0 {
+		return signalReply{Text: head + ": none before #" + strconv.FormatInt(s.ID, 10) + "."}, nil
+	}
+	return signalReply{Text: head + ":\n" + strings.Join(lines, "\n")}, nil
+}
+
+func (st *signalBrowseState) setFilter(arg string) bool {
+	kind, value, ok := strings.Cut(arg, ":")
+	if !ok {
+		return false
+	}
+	switch kind {
+	case browseFilterSymbol:
+		if _, known := domain.CoinGeckoID[value]; value != "" && !known {
+			return false
+		}
+		st.Symbol = value
+	case browseFilterRisk:
+		if value == "" {
+			st.Risk = 0
+			return true
+		}
+		level, err := strconv.Atoi(value)
+		if err != nil || !domain.RiskLevel(level).IsValid() {
+			return false
+		}
+		st.Risk = level
+	case browseFilterInterval:
+		if value != "" && domain.IntervalDuration(value) == 0 {
+			return false
+		}
+		st.Interval = value
+	default:
+		return false
+	}
+	return true
+}
+
+func (br *signalBrowser) save(ctx context.Context, token string, st *signalBrowseState) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	return br.store.SaveCallbackState(ctx, "signals:"+token, data, signalBrowseTTL)
+}
+
+func (br *signalBrowser) load(ctx context.Context, token string) (*signalBrowseState, error) {
+	data, err := br.store.LoadCallbackState(ctx, "signals:"+token)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	var st signalBrowseState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+func signalListView(token string, st *signalBrowseState) signalReply {
+	var sb strings.Builder
+	sb.WriteString("Signals")
+	filters := make([]string, 0, 3)
+	if st.Symbol != "" {
+		filters = append(filters, st.Symbol)
+	}
+	if st.Interval != "" {
+		filters = append(filters, st.Interval)
+	}
+	if st.Risk > 0 {
+		filters = append(filters, fmt.Sprintf("risk %d", st.Risk))
+	}
+	if len(filters) > 0 {
+		sb.WriteString(" (" + strings.Join(filters, ", ") + ")")
+	}
+	fmt.Fprintf(&sb, ", page %d", st.Page+1)
+	if len(st.Signals) == 0 {
+		sb.WriteString("\n\nNo matching signals.")
+	}
+
+	m := &tele.ReplyMarkup{}
+	rows := make([]tele.Row, 0, 3)
+	picks := make(tele.Row, 0, len(st.Signals))
+	for i, s := range st.Signals {
+		fmt.Fprintf(&sb, "\n\n%d. %s", i+1, formatSignalLine(s))
+		picks = append(picks, m.Data(strconv.Itoa(i+1), signalBrowseUnique, browseActionSignal, token, strconv.Itoa(i)))
+	}
+	if len(picks) > 0 {
+		rows = append(rows, picks)
+	}
+	rows = append(rows, m.Row(
+		m.Data("Symbol: "+orAny(st.Symbol), signalBrowseUnique, browseActionFilter, token, browseFilterSymbol),
+		m.Data("Risk: "+orAny(riskLabel(st.Risk)), signalBrowseUnique, browseActionFilter, token, browseFilterRisk),
+		m.Data("Interval: "+orAny(st.Interval), signalBrowseUnique, browseActionFilter, token, browseFilterInterval),
+	))
+	nav := make(tele.Row, 0, 2)
+	if st.Page > 0 {
+		nav = append(nav, m.Data("« Prev", signalBrowseUnique, browseActionPage, token, strconv.Itoa(st.Page-1)))
+	}
+	if st.HasNext {
+		nav = append(nav, m.Data("Next »", signalBrowseUnique, browseActionPage, token, strconv.Itoa(st.Page+1)))
+	}
+	if len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+	m.Inline(rows...)
+	return signalReply{Text: sb.String(), Markup: m}
+}
+
+func signalFilterView(token, kind string, page int) signalReply {
+	var (
+		title  string
+		values []string
+	)
+	switch kind {
+	case browseFilterSymbol:
+		title, values = "Filter by symbol:", domain.SupportedSymbols
+	case browseFilterRisk:
+		title = "Filter by risk level:"
+		for r := domain.RiskLevel1; r.IsValid(); r++ {
+			values = append(values, strconv.Itoa(int(r)))
+		}
+	case browseFilterInterval:
+		title, values = "Filter by interval:", domain.SupportedIntervals
+	default:
+		return signalReply{Text: "Unknown filter."}
+	}
+
+	m := &tele.ReplyMarkup{}
+	rows := make([]tele.Row, 0, len(values)/5+2)
+	row := tele.Row{}
+	for _, v := range values {
+		row = append(row, m.Data(v, signalBrowseUnique, browseActionSet, token, kind+":"+v))
+		if len(row) == 5 {
+			rows = append(rows, row)
+			row = tele.Row{}
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	rows = append(rows, m.Row(
+		m.Data("Any", signalBrowseUnique, browseActionSet, token, kind+":"),
+		m.Data("Back", signalBrowseUnique, browseActionPage, token, strconv.Itoa(page)),
+	))
+	m.Inline(rows...)
+	return signalReply{Text: title, Markup: m, Edit: true}
+}
+
+func signalCardView(token string, idx, page int, s domain.Signal) signalReply {
+	m := &tele.ReplyMarkup{}
+	i := strconv.Itoa(idx)
+	m.Inline(
+		m.Row(
+			m.Data("Chart", signalBrowseUnique, browseActionChart, token, i),
+			m.Data("Explain", signalBrowseUnique, browseActionExplain, token, i),
+			m.Data("Similar past", signalBrowseUnique, browseActionSimilar, token, i),
+		),
+		m.Row(
+			m.Data("Ask the advisor", signalBrowseUnique, browseActionAsk, token, i),
+			m.Data("Back to list", signalBrowseUnique, browseActionPage, token, strconv.Itoa(page)),
+		),
+	)
+	return signalReply{Text: formatSignal(s), Markup: m, Edit: true}
+}
+
+// formatSignalLine is a signal on one line, for lists.
+func formatSignalLine(s domain.Signal) string {
+	return fmt.Sprintf("#%d %s %s %s %s risk %d, %s",
+		s.ID, s.Symbol, s.Interval, strings.ToUpper(s.Indicator), strings.ToUpper(string(s.Direction)),
+		s.Risk, s.Timestamp.UTC().Format("Jan 2 15:04"))
+}
+
+var indicatorExplanations = map[string]string{
+	domain.IndicatorRSI:                    "RSI measures momentum from 0 to 100. Falling through the oversold line suggests selling is exhausted; rising through the overbought line suggests buying is.",
+	domain.IndicatorMACD:                   "MACD is the gap between a fast and a slow EMA. Crossing its signal line marks a shift in momentum.",
+	domain.IndicatorBollinger:              "Bollinger Bands track volatility around a moving average. A close outside the bands after a squeeze often starts a move that way.",
+	domain.IndicatorVolumeZ:                "The volume z-score measures how unusual volume is against its recent average. Spikes often come with breakouts or capitulation.",
+	domain.IndicatorFundSentimentComposite: "The composite blends market-intel news sentiment with fundamentals into one score.",
+}
+
+var riskFactorLabels = map[string]string{
+	"risk_vol":   "volatility",
+	"risk_liq":   "liquidity",
+	"risk_hit":   "historical hit rate",
+	"risk_hit_n": "signals in hit rate",
+	"risk_agree": "agreement with other indicators",
+}
+
+// explainSignal describes why a signal fired from its details, which hold
+// the trigger followed by key=value risk factors.
+func explainSignal(s domain.Signal) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "#%d %s %s %s %s, risk %d of 5", s.ID, s.Symbol, s.Interval,
+		strings.ToUpper(s.Indicator), strings.ToUpper(string(s.Direction)), s.Risk)
+
+	parts := strings.Split(s.Details, ";")
+	if trigger := strings.TrimSpace(parts[0]); trigger != "" && !strings.Contains(trigger, "=") {
+		sb.WriteString("\nTrigger: " + trigger)
+		parts = parts[1:]
+	}
+	factors := make([]string, 0, len(parts))
+	horizon := ""
+	for _, p := range parts {
+		key, value, ok := strings.Cut(strings.TrimSpace(p), "=")
+		if label, known := riskFactorLabels[key]; ok && known {
+			factors = append(factors, label+" "+value)
+		}
+		if ok && key == "target" {
+			horizon = value
+		}
+	}
+
+	if text, ok := indicatorExplanations[s.Indicator]; ok {
+		sb.WriteString("\n\n" + text)
+	} else if strings.HasPrefix(s.Indicator, "ml_") {
+		sb.WriteString("\n\n" + explainModelSignal(s.Direction, horizon))
+	}
+	if len(factors) > 0 {
+		sb.WriteString("\n\nRisk inputs: " + strings.Join(factors, ", "))
+	}
+	if levels := formatSignalLevels(s.Levels); levels != "" {
+		sb.WriteString("\n" + levels)
+	}
+	return sb.String()
+}
+
+// explainModelSignal describes an ML signal over the horizon its model
+// predicts, which inference records as target=<hours>h in the details.
+func explainModelSignal(direction domain.SignalDirection, horizon string) string {
+	move := "rises"
+	if direction == domain.DirectionShort {
+		move = "falls"
+	}
+	if horizon == "" {
+		return "A machine-learning model's estimate that price " + move + " over its prediction horizon."
+	}
+	return "A machine-learning model's estimate that price " + move + " over the next " + horizon + "."
+}
+
+// signalAdvisorPrompt seeds an advisor question with the signal's context.
+func signalAdvisorPrompt(s domain.Signal) string {
+	prompt := "What do you make of this signal, and how would you act on it?\n" + formatSignal(s)
+	if s.Details != "" {
+		prompt += "\nDetails: " + s.Details
+	}
+	return prompt
+}
+
+func orAny(v string) string {
+	if v == "" {
+		return "any"
+	}
+	return v
+}
+
+func riskLabel(risk int) string {
+	if risk <= 0 {
+		return ""
+	}
+	return strconv.Itoa(risk)
+}
+
+func randomCallbackToken() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(buf)
+}
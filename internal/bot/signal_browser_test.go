@@ -0,0 +1,180 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+type browseSignals struct {
+	all     []domain.Signal
+	filters []domain.SignalFilter
+}
+
+func (b *browseSignals) ListSignals(ctx context.Context, filter domain.SignalFilter) ([]domain.Signal, error) {
+	b.filters = append(b.filters, filter)
+	out := make([]domain.Signal, 0, filter.Limit)
+	skipped := 0
+	for _, s := range b.all {
+		if (filter.Symbol != "" && s.Symbol != filter.Symbol) || (filter.Indicator != "" && s.Indicator != filter.Indicator) ||
+			(filter.Interval != "" && s.Interval != filter.Interval) || (filter.Risk != nil && s.Risk != *filter.Risk) {
+			continue
+		}
+		if skipped < filter.Offset {
+			skipped++
+			continue
+		}
+		if len(out) == filter.Limit {
+			break
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func (b *browseSignals) GetSignalImage(ctx context.Context, signalID int64) (*domain.SignalImageData, error) {
+	return nil, fmt.Errorf("no image")
+}
+
+type browseAdvisor struct {
+	question string
+}
+
+func (a *browseAdvisor) Ask(ctx context.Context, chatID int64, message string) (string, error) {
+	a.question = message
+	return "Looks fine.", nil
+}
+
+func newTestSignalBrowser() (*signalBrowser, *browseSignals, *browseAdvisor) {
+	base := time.Date(2026, 6, 10, 12, 0, 0, 0, time.UTC)
+	signals := &browseSignals{}
+	// Newest first, as the repository returns them.
+	for i := 0; i < 12; i++ {
+		symbol := "BTC"
+		if i%3 == 2 {
+			symbol = "ETH"
+		}
+		signals.all = append(signals.all, domain.Signal{
+			ID: int64(100 - i), Symbol: symbol, Interval: "1h", Indicator: domain.IndicatorRSI,
+			Direction: domain.DirectionLong, Risk: domain.RiskLevel(i%5 + 1), Timestamp: base.Add(-time.Duration(i) * time.Hour),
+			Details: "rsi 28.10 crossed below 30;risk_model=dynamic;risk_score=0.4100;risk_vol=0.3000;risk_hit=0.5500",
+		})
+	}
+	adv := &browseAdvisor{}
+	br := newSignalBrowser(signals, nil, adv, newMemoryCallbackStore())
+	br.newToken = func() string { return "tok" }
+	return br, signals, adv
+}
+
+func buttonData(reply signalReply) []string {
+	var out []string
+	for _, row := range reply.Markup.InlineKeyboard {
+		for _, btn := range row {
+			out = append(out, btn.Text+"="+btn.Data)
+		}
+	}
+	return out
+}
+
+func TestSignalBrowserPagesAndFilters(t *testing.T) {
+	ctx := context.Background()
+	br, signals, _ := newTestSignalBrowser()
+
+	reply, err := br.start(ctx, domain.SignalFilter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	buttons := strings.Join(buttonData(reply), " ")
+	if !strings.Contains(reply.Text, "1. #100 BTC 1h RSI LONG") || strings.Contains(reply.Text, "#95") {
+		t.Fatalf("expected the first five signals, got %q", reply.Text)
+	}
+	if !strings.Contains(buttons, "1=sig|tok|0") || !strings.Contains(buttons, "Next »=pg|tok|1") || strings.Contains(buttons, "Prev") {
+		t.Fatalf("unexpected first page buttons: %s", buttons)
+	}
+	if last := signals.filters[0]; last.Limit != signalPageSize+1 || last.Offset != 0 {
+		t.Fatalf("unexpected page query: %+v", last)
+	}
+
+	reply, err = br.handle(ctx, 7, "pg|tok|2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	buttons = strings.Join(buttonData(reply), " ")
+	if !reply.Edit || !strings.Contains(reply.Text, "page 3") || !strings.Contains(reply.Text, "#90") ||
+		strings.Contains(buttons, "Next") || !strings.Contains(buttons, "« Prev=pg|tok|1") {
+		t.Fatalf("unexpected last page: %q %s", reply.Text, buttons)
+	}
+
+	reply, _ = br.handle(ctx, 7, "flt|tok|sym")
+	if !strings.Contains(strings.Join(buttonData(reply), " "), "ETH=set|tok|sym:ETH") {
+		t.Fatalf("expected symbol picker, got %v", buttonData(reply))
+	}
+	reply, _ = br.handle(ctx, 7, "set|tok|sym:ETH")
+	if !strings.Contains(reply.Text, "Signals (ETH), page 1") || strings.Contains(reply.Text, "BTC") {
+		t.Fatalf("expected ETH signals, got %q", reply.Text)
+	}
+	if reply, _ = br.handle(ctx, 7, "set|tok|risk:9"); reply.Text != "Unknown filter." {
+		t.Fatalf("expected invalid risk to be rejected, got %q", reply.Text)
+	}
+
+	if reply, _ = br.handle(ctx, 7, "pg|gone|0"); !strings.Contains(reply.Text, "expired") {
+		t.Fatalf("expected expired state, got %q", reply.Text)
+	}
+}
+
+func TestSignalBrowserSignalActions(t *testing.T) {
+	ctx := context.Background()
+	br, _, adv := newTestSignalBrowser()
+	if _, err := br.start(ctx, domain.SignalFilter{Symbol: "BTC"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reply, _ := br.handle(ctx, 7, "sig|tok|1")
+	buttons := strings.Join(buttonData(reply), " ")
+	if !strings.HasPrefix(reply.Text, "#99 BTC") || !strings.Contains(buttons, "Similar past=sim|tok|1") || !strings.Contains(buttons, "Back to list=pg|tok|0") {
+		t.Fatalf("unexpected signal card: %q %s", reply.Text, buttons)
+	}
+
+	reply, _ = br.handle(ctx, 7, "why|tok|1")
+	if !strings.Contains(reply.Text, "Trigger: rsi 28.10 crossed below 30") || !strings.Contains(reply.Text, "volatility 0.3000, historical hit rate 0.5500") {
+		t.Fatalf("unexpected explanation: %q", reply.Text)
+	}
+
+	reply, _ = br.handle(ctx, 7, "sim|tok|0")
+	if !strings.Contains(reply.Text, "#99 BTC") || strings.Contains(reply.Text, "#100 ") || strings.Contains(reply.Text, "ETH") {
+		t.Fatalf("unexpected similar signals: %q", reply.Text)
+	}
+
+	reply, _ = br.handle(ctx, 7, "chart|tok|0")
+	if reply.Text != "No chart available for this signal." {
+		t.Fatalf("expected no chart without image or renderer, got %q", reply.Text)
+	}
+
+	reply, _ = br.handle(ctx, 7, "ask|tok|0")
+	if reply.Text != "Looks fine." || !strings.Contains(adv.question, "#100 BTC 1h RSI LONG") || !strings.Contains(adv.question, "Details: rsi 28.10") {
+		t.Fatalf("expected advisor seeded with the signal, got %q from %q", reply.Text, adv.question)
+	}
+
+	if reply, _ = br.handle(ctx, 7, "why|tok|9"); !strings.Contains(reply.Text, "no longer on this page") {
+		t.Fatalf("expected out-of-range signal to be reported, got %q", reply.Text)
+	}
+}
+
+func TestExplainSignalUsesModelHorizon(t *testing.T) {
+	sig := domain.Signal{
+		ID: 5, Symbol: "ETH", Interval: "1h", Indicator: domain.IndicatorMLEnsembleUp4H, Direction: domain.DirectionShort, Risk: 2,
+		Details: "model_key=ensemble_v1;model_version=3;prob_up=0.3100;confidence=0.3800;target=6h;ensemble_score=-0.4200",
+	}
+	if text := explainSignal(sig); !strings.Contains(text, "price falls over the next 6h.") {
+		t.Fatalf("expected the horizon from the details, got %q", text)
+	}
+
+	sig.Details = "model_key=ensemble_v1;model_version=3"
+	if text := explainSignal(sig); !strings.Contains(text, "over its prediction horizon.") || strings.Contains(text, "4 hours") {
+		t.Fatalf("expected no horizon claimed without one recorded, got %q", text)
+	}
+}
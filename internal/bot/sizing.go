@@ -0,0 +1,98 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"bug-free-umbrella/internal/domain"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+const sizeUsage = "Usage: /size BTC 10000 [risk%] [fixed_fractional|volatility_parity|kelly]\n" +
+	"Example: /size ETH 25000 0.5% kelly"
+
+type PositionSizer interface {
+	SizeSymbol(ctx context.Context, req domain.SizingRequest) (*domain.PositionSize, error)
+}
+
+// registerSizingCommands adds /size, which sizes a trade on a symbol from its
+// latest signal for the given account equity.
+func registerSizingCommands(b *tele.Bot, sizer PositionSizer) {
+	b.Handle("/size", func(c tele.Context) error {
+		if sizer == nil {
+			return c.Send("Position sizing unavailable")
+		}
+		req, err := parseSizeArgs(c.Args())
+		if err != nil {
+			return c.Send(sizeUsage)
+		}
+		out, err := sizer.SizeSymbol(context.Background(), req)
+		if err != nil {
+			return c.Send(fmt.Sprintf("Unable to size %s: %v", req.Symbol, err))
+		}
+		return c.Send(formatPositionSize(*out))
+	})
+}
+
+// parseSizeArgs reads "SYMBOL EQUITY [RISK%] [METHOD]". Risk is a percentage
+// of equity, with or without the % sign.
+func parseSizeArgs(args []string) (domain.SizingRequest, error) {
+	req := domain.SizingRequest{}
+	if len(args) < 2 || len(args) > 4 {
+		return req, errors.New("expected symbol and equity")
+	}
+	req.Symbol = strings.ToUpper(strings.TrimSpace(args[0]))
+	if _, ok := domain.CoinGeckoID[req.Symbol]; !ok {
+		return req, errors.New("unsupported symbol")
+	}
+	equity, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimPrefix(args[1], "$"), ",", ""), 64)
+	if err != nil || equity <= 0 {
+		return req, errors.New("invalid equity")
+	}
+	req.Equity = equity
+	for _, raw := range args[2:] {
+		raw = strings.ToLower(strings.TrimSpace(raw))
+		if m := domain.SizingMethod(raw); m.IsValid() {
+			req.Method = m
+			continue
+		}
+		pct, err := strconv.ParseFloat(strings.TrimSuffix(raw, "%"), 64)
+		if err != nil || pct <= 0 || pct >= 100 || req.RiskPerTrade != 0 {
+			return req, errors.New("invalid risk or method")
+		}
+		req.RiskPerTrade = pct / 100
+	}
+	return req, nil
+}
+
+func formatPositionSize(p domain.PositionSize) string {
+	var sb strings.Builder
+	direction := strings.ToUpper(string(p.Direction))
+	fmt.Fprintf(&sb, "%s %s size for $%.2f equity (%s)\n", p.Symbol, direction, p.Equity, strings.ReplaceAll(string(p.Method), "_", " "))
+	fmt.Fprintf(&sb, "Quantity: %.6g %s\nNotional: $%.2f (%.1f%% of equity)\n", p.Quantity, p.Symbol, p.Notional, p.EquityFraction*100)
+	fmt.Fprintf(&sb, "Entry %s | Stop %s\nRisk at stop: $%.2f (%.2f%%), risk level %d",
+		formatLevelPrice(p.Entry), formatLevelPrice(p.Stop), p.RiskAmount, p.RiskFraction*100, p.Risk)
+	if p.SignalID > 0 {
+		fmt.Fprintf(&sb, "\nFrom signal #%d", p.SignalID)
+	}
+	if p.Capped {
+		sb.WriteString("\nCapped by the risk budget or max position size.")
+	}
+	if p.Note != "" {
+		sb.WriteString("\n" + p.Note)
+	}
+	return sb.String()
+}
+
+// formatSignalSizing is the one-line size shown under a signal's levels.
+func formatSignalSizing(p *domain.PositionSize) string {
+	if p == nil {
+		return ""
+	}
+	return fmt.Sprintf("Size: %.6g %s ($%.2f) risking $%.2f per $%.0f",
+		p.Quantity, p.Symbol, p.Notional, p.RiskAmount, p.Equity)
+}
@@ -0,0 +1,62 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+func TestParseSizeArgs(t *testing.T) {
+	req, err := parseSizeArgs([]string{"btc", "$10,000"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.Symbol != "BTC" || req.Equity != 10000 || req.RiskPerTrade != 0 || req.Method != "" {
+		t.Fatalf("unexpected request: %+v", req)
+	}
+
+	req, err = parseSizeArgs([]string{"ETH", "25000", "kelly", "0.5%"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.Method != domain.SizingKelly || req.RiskPerTrade != 0.005 {
+		t.Fatalf("unexpected request: %+v", req)
+	}
+
+	for _, args := range [][]string{
+		{"BTC"},
+		{"NOPE", "1000"},
+		{"BTC", "0"},
+		{"BTC", "1000", "150"},
+		{"BTC", "1000", "1", "2"},
+		{"BTC", "1000", "martingale"},
+	} {
+		if _, err := parseSizeArgs(args); err == nil {
+			t.Fatalf("expected %v to be rejected", args)
+		}
+	}
+}
+
+func TestFormatPositionSize(t *testing.T) {
+	msg := formatPositionSize(domain.PositionSize{
+		Symbol: "BTC", Method: domain.SizingFixedFractional, Direction: domain.DirectionLong, SignalID: 12,
+		Risk: domain.RiskLevel3, Equity: 10000, Entry: 50000, Stop: 48000, Quantity: 0.0375,
+		Notional: 1875, RiskAmount: 75, RiskFraction: 0.0075, EquityFraction: 0.1875,
+	})
+	for _, want := range []string{"BTC LONG size for $10000.00 equity (fixed fractional)", "Quantity: 0.0375 BTC", "Risk at stop: $75.00 (0.75%), risk level 3", "From signal #12"} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("expected %q in %q", want, msg)
+		}
+	}
+}
+
+func TestFormatSignalIncludesSizing(t *testing.T) {
+	s := domain.Signal{
+		Symbol: "SOL", Interval: "1h", Indicator: domain.IndicatorRSI, Direction: domain.DirectionLong,
+		Sizing: &domain.PositionSize{Symbol: "SOL", Quantity: 12.5, Notional: 1250, RiskAmount: 100, Equity: 10000},
+	}
+	if got := formatSignal(s); !strings.Contains(got, "Size: 12.5 SOL ($1250.00) risking $100.00 per $10000") {
+		t.Fatalf("expected sizing line in %q", got)
+	}
+}
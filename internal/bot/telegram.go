@@ -1,7 +1,6 @@
 package bot
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -29,21 +28,35 @@ type Advisor interface {
 	Ask(ctx context.Context, chatID int64, message string) (string, error)
 }
 
-func StartTelegramBot(priceService PriceQuerier, signalService SignalLister, advisorService Advisor) *AlertDispatcher {
+func StartTelegramBot(priceService PriceQuerier, signalService SignalLister, advisorService Advisor, paperTrader PaperTrader, holdings HoldingsTracker, sizer PositionSizer, priceAlerts PriceAlertManager, subscriptions AlertSubscriptionStore, digests DigestManager, charts ChartRenderer, callbackState CallbackStateStore, access AccessManager, admin AdminTools, webhook *WebhookPoller) *AlertDispatcher {
 	token := os.Getenv("TELEGRAM_BOT_TOKEN")
 	if token == "" {
 		log.Println("TELEGRAM_BOT_TOKEN not set, skipping Telegram bot startup")
 		return nil
 	}
+	// Long polling only works with a single replica; the webhook lets
+	// several share the bot.
+	var poller tele.Poller = &tele.LongPoller{Timeout: 10 * time.Second}
+	if webhook != nil {
+		poller = webhook
+	}
 	pref := tele.Settings{
 		Token:  token,
-		Poller: &tele.LongPoller{Timeout: 10 * time.Second},
+		Poller: poller,
 	}
 	b, err := tele.NewBot(pref)
 	if err != nil {
 		log.Fatalf("failed to create Telegram bot: %v", err)
 	}
 	alerts := NewAlertDispatcher(b, signalService)
+	if subscriptions != nil {
+		if err := alerts.UseStore(context.Background(), subscriptions); err != nil {
+			log.Printf("failed to load alert subscriptions: %v", err)
+		}
+	}
+
+	// The access guard must be in place before any handler is registered.
+	registerAccessCommands(b, access)
 
 	b.Handle("/ping", func(c tele.Context) error {
 		return c.Send("pong")
@@ -89,34 +102,7 @@ func StartTelegramBot(priceService PriceQuerier, signalService SignalLister, adv
 		return c.Send(msg)
 	})
 
-	b.Handle("/signals", func(c tele.Context) error {
-		if signalService == nil {
-			return c.Send("Signal service unavailable")
-		}
-
-		filter, err := parseSignalArgs(c.Args())
-		if err != nil {
-			return c.Send("Usage: /signals BTC | /signals --risk 3 | /signals BTC --risk 3")
-		}
-
-		signals, err := signalService.ListSignals(context.Background(), filter)
-		if err != nil {
-			return c.Send(fmt.Sprintf("Error fetching signals: %v", err))
-		}
-		if len(signals) == 0 {
-			return c.Send("No matching signals right now.")
-		}
-
-		if err := c.Send("Latest signals:"); err != nil {
-			return err
-		}
-		for _, s := range signals {
-			if err := sendSignalWithOptionalImage(c, signalService, s); err != nil {
-				return err
-			}
-		}
-		return nil
-	})
+	registerSignalBrowser(b, newSignalBrowser(signalService, charts, advisorService, callbackState))
 
 	b.Handle("/alerts", func(c tele.Context) error {
 		chat := c.Chat()
@@ -124,30 +110,19 @@ func StartTelegramBot(priceService PriceQuerier, signalService SignalLister, adv
 			return c.Send("Unable to detect chat")
 		}
 
-		mode, err := parseAlertMode(c.Args())
-		if err != nil {
-			return c.Send("Usage: /alerts on | /alerts off | /alerts status")
-		}
-
-		switch mode {
-		case "on":
-			if alerts.Subscribe(chat.ID) {
-				return c.Send("Proactive alerts enabled for this chat.")
-			}
-			return c.Send("Proactive alerts are already enabled for this chat.")
-		case "off":
-			if alerts.Unsubscribe(chat.ID) {
-				return c.Send("Proactive alerts disabled for this chat.")
-			}
-			return c.Send("Proactive alerts are already disabled for this chat.")
-		default:
-			if alerts.IsSubscribed(chat.ID) {
-				return c.Send("Alerts status: ON")
-			}
-			return c.Send("Alerts status: OFF")
-		}
+		return c.Send(handleAlertsCommand(context.Background(), alerts, chat.ID, c.Args()))
 	})
 
+	registerPaperCommands(b, paperTrader)
+	registerHoldingsCommands(b, holdings)
+	registerSizingCommands(b, sizer)
+	registerPriceAlertCommands(b, priceAlerts)
+	registerDigestCommands(b, digests, alerts)
+	registerChartCommands(b, charts)
+	registerAdminCommands(b, access, admin, alerts)
+	memory, _ := advisorService.(AdvisorMemory)
+	registerAdvisorMemoryCommands(b, memory)
+
 	b.Handle("/ask", func(c tele.Context) error {
 		if advisorService == nil {
 			return c.Send("Advisor not configured. Set OPENAI_API_KEY to enable.")
@@ -170,7 +145,12 @@ func StartTelegramBot(priceService PriceQuerier, signalService SignalLister, adv
 		return handleAdvisorQuery(c, advisorService, text)
 	})
 
-	log.Println("Telegram bot started")
+	if webhook != nil {
+		log.Println("Telegram bot started in webhook mode")
+	} else {
+		removeWebhook(b)
+		log.Println("Telegram bot started")
+	}
 	go b.Start()
 	return alerts
 }
@@ -247,7 +227,7 @@ func parseSignalArgs(args []string) (domain.SignalFilter, error) {
 }
 
 func formatSignal(s domain.Signal) string {
-	return fmt.Sprintf(
+	line := fmt.Sprintf(
 		"#%d %s %s %s %s risk %d at %s",
 		s.ID,
 		s.Symbol,
@@ -257,22 +237,35 @@ func formatSignal(s domain.Signal) string {
 		s.Risk,
 		s.Timestamp.UTC().Format(time.RFC822),
 	)
+	if levels := formatSignalLevels(s.Levels); levels != "" {
+		line += "\n" + levels
+	}
+	if size := formatSignalSizing(s.Sizing); size != "" {
+		line += "\n" + size
+	}
+	return line
 }
 
-func sendSignalWithOptionalImage(c tele.Context, signalService SignalLister, s domain.Signal) error {
-	caption := formatSignal(s)
-	if signalService == nil || s.ID <= 0 {
-		return c.Send(caption)
+func formatSignalLevels(l *domain.SignalLevels) string {
+	if l == nil {
+		return ""
 	}
-
-	imageData, err := signalService.GetSignalImage(context.Background(), s.ID)
-	if err != nil || imageData == nil || len(imageData.Bytes) == 0 {
-		return c.Send(caption)
+	targets := make([]string, 0, len(l.Targets))
+	for _, t := range l.Targets {
+		targets = append(targets, formatLevelPrice(t))
 	}
+	return fmt.Sprintf(
+		"Entry %s | Stop %s | Targets %s | R:R %.2f",
+		formatLevelPrice(l.Entry),
+		formatLevelPrice(l.Stop),
+		strings.Join(targets, ", "),
+		l.RewardRisk,
+	)
+}
 
-	photo := &tele.Photo{
-		File:    tele.FromReader(bytes.NewReader(imageData.Bytes)),
-		Caption: caption,
+func formatLevelPrice(v float64) string {
+	if v >= 1 {
+		return fmt.Sprintf("$%.2f", v)
 	}
-	return c.Send(photo)
+	return fmt.Sprintf("$%.4f", v)
 }
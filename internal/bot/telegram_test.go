@@ -1,14 +1,16 @@
 package bot
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"bug-free-umbrella/internal/domain"
 )
 
 func TestStartTelegramBotSkipsWithoutToken(t *testing.T) {
 	t.Setenv("TELEGRAM_BOT_TOKEN", "")
-	StartTelegramBot(nil, nil, nil)
+	StartTelegramBot(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, AdminTools{}, nil)
 }
 
 func TestParseSignalArgsSymbolAndRisk(t *testing.T) {
@@ -32,3 +34,31 @@ func TestParseSignalArgsRejectsInvalidRisk(t *testing.T) {
 		t.Fatal("expected risk parsing error")
 	}
 }
+
+func TestFormatSignalIncludesLevels(t *testing.T) {
+	s := domain.Signal{
+		ID:        7,
+		Symbol:    "ADA",
+		Interval:  "1h",
+		Indicator: domain.IndicatorRSI,
+		Direction: domain.DirectionLong,
+		Risk:      domain.RiskLevel3,
+		Timestamp: time.Unix(0, 0).UTC(),
+		Levels: &domain.SignalLevels{
+			Entry:      0.5,
+			Stop:       0.48,
+			Targets:    []float64{0.53, 0.56},
+			RewardRisk: 1.5,
+		},
+	}
+	got := formatSignal(s)
+	want := "Entry $0.5000 | Stop $0.4800 | Targets $0.5300, $0.5600 | R:R 1.50"
+	if !strings.Contains(got, want) {
+		t.Fatalf("expected levels line %q in %q", want, got)
+	}
+
+	s.Levels = nil
+	if strings.Contains(formatSignal(s), "Entry") {
+		t.Fatal("expected no levels line without levels")
+	}
+}
@@ -0,0 +1,226 @@
+package bot
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	tele "gopkg.in/telebot.v3"
+)
+
+// WebhookPath is where Telegram posts updates in webhook mode.
+const WebhookPath = "/telegram/webhook"
+
+const (
+	updateSeenPrefix = "tg:update:"
+	// updateSeenTTL covers Telegram's retries of an update, which stop well
+	// within a day.
+	updateSeenTTL = 24 * time.Hour
+	// webhookReassertEvery is how often the registration is checked, so it
+	// comes back if something else removed or replaced it.
+	webhookReassertEvery = time.Minute
+)
+
+// UpdateDeduper makes update handling idempotent across replicas. MarkUpdate
+// reports whether this is the first time the update was seen; ReleaseUpdate
+// forgets it again when it could not be handled, so Telegram's retry is.
+type UpdateDeduper interface {
+	MarkUpdate(ctx context.Context, updateID int) (bool, error)
+	ReleaseUpdate(ctx context.Context, updateID int) error
+}
+
+// RedisUpdateDeduper shares seen update IDs between replicas.
+type RedisUpdateDeduper struct {
+	redis *redis.Client
+}
+
+func NewRedisUpdateDeduper(client *redis.Client) *RedisUpdateDeduper {
+	return &RedisUpdateDeduper{redis: client}
+}
+
+func (d *RedisUpdateDeduper) MarkUpdate(ctx context.Context, updateID int) (bool, error) {
+	return d.redis.SetNX(ctx, updateSeenPrefix+strconv.Itoa(updateID), 1, updateSeenTTL).Result()
+}
+
+func (d *RedisUpdateDeduper) ReleaseUpdate(ctx context.Context, updateID int) error {
+	return d.redis.Del(ctx, updateSeenPrefix+strconv.Itoa(updateID)).Err()
+}
+
+// memoryUpdateDeduper is the fallback without Redis. It only dedupes within
+// one process.
+type memoryUpdateDeduper struct {
+	mu   sync.Mutex
+	seen map[int]time.Time
+}
+
+func newMemoryUpdateDeduper() *memoryUpdateDeduper {
+	return &memoryUpdateDeduper{seen: make(map[int]time.Time)}
+}
+
+func (d *memoryUpdateDeduper) MarkUpdate(ctx context.Context, updateID int) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	for id, at := range d.seen {
+		if now.Sub(at) > updateSeenTTL {
+			delete(d.seen, id)
+		}
+	}
+	if _, ok := d.seen[updateID]; ok {
+		return false, nil
+	}
+	d.seen[updateID] = now
+	return true, nil
+}
+
+func (d *memoryUpdateDeduper) ReleaseUpdate(ctx context.Context, updateID int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.seen, updateID)
+	return nil
+}
+
+// WebhookPoller receives updates over HTTP instead of long polling, so
+// several replicas can answer one bot's updates; background jobs still run
+// on only one of them (see RUN_JOBS). It is the bot's tele.Poller and an
+// http.Handler to mount at WebhookPath. The webhook is registered when the
+// bot starts and checked every minute. Stop leaves it registered, since other
+// replicas still serve it; it is only removed when a bot starts long polling.
+type WebhookPoller struct {
+	url    string
+	secret string
+	dedupe UpdateDeduper
+
+	mu   sync.Mutex
+	bot  *tele.Bot
+	dest chan tele.Update
+}
+
+// NewWebhookPoller builds a poller for the server reachable at baseURL.
+// Requests must carry secret in Telegram's secret token header.
+func NewWebhookPoller(baseURL, secret string, dedupe UpdateDeduper) *WebhookPoller {
+	if dedupe == nil {
+		dedupe = newMemoryUpdateDeduper()
+	}
+	return &WebhookPoller{
+		url:    strings.TrimRight(baseURL, "/") + WebhookPath,
+		secret: secret,
+		dedupe: dedupe,
+	}
+}
+
+// Poll implements tele.Poller.
+func (w *WebhookPoller) Poll(b *tele.Bot, dest chan tele.Update, stop chan struct{}) {
+	w.mu.Lock()
+	w.bot, w.dest = b, dest
+	w.mu.Unlock()
+
+	w.register(b)
+	ticker := time.NewTicker(webhookReassertEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.register(b)
+		case <-stop:
+			w.mu.Lock()
+			w.dest = nil
+			w.mu.Unlock()
+			return
+		}
+	}
+}
+
+// register sets the webhook unless Telegram already has it.
+func (w *WebhookPoller) register(b *tele.Bot) {
+	// getWebhookInfo reports the registered URL in Listen.
+	if current, err := b.Webhook(); err == nil && current.Listen == w.url {
+		return
+	}
+	err := b.SetWebhook(&tele.Webhook{
+		Endpoint:    &tele.WebhookEndpoint{PublicURL: w.url},
+		SecretToken: w.secret,
+	})
+	if err != nil {
+		log.Printf("failed to set Telegram webhook: %v", err)
+		return
+	}
+	log.Printf("Telegram webhook set to %s", w.url)
+}
+
+// removeWebhook drops any webhook left from webhook mode, which Telegram
+// requires before getUpdates works again.
+func removeWebhook(b *tele.Bot) {
+	current, err := b.Webhook()
+	if err == nil && current.Listen == "" {
+		return
+	}
+	if err := b.RemoveWebhook(); err != nil {
+		log.Printf("failed to remove Telegram webhook: %v", err)
+		return
+	}
+	log.Println("Telegram webhook removed for long polling")
+}
+
+// Stop stops the bot. The webhook stays registered.
+func (w *WebhookPoller) Stop() {
+	w.mu.Lock()
+	b := w.bot
+	w.mu.Unlock()
+	if b != nil {
+		b.Stop()
+	}
+}
+
+// ServeHTTP accepts one update from Telegram. Updates already handled, by
+// this or another replica, are acknowledged and dropped.
+func (w *WebhookPoller) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("X-Telegram-Bot-Api-Secret-Token")
+	if w.secret == "" || subtle.ConstantTimeCompare([]byte(token), []byte(w.secret)) != 1 {
+		http.Error(rw, "invalid secret token", http.StatusUnauthorized)
+		return
+	}
+	var upd tele.Update
+	if err := json.NewDecoder(http.MaxBytesReader(rw, r.Body, 1<<20)).Decode(&upd); err != nil {
+		http.Error(rw, "invalid update", http.StatusBadRequest)
+		return
+	}
+
+	w.mu.Lock()
+	dest := w.dest
+	w.mu.Unlock()
+	if dest == nil {
+		http.Error(rw, "bot not running", http.StatusServiceUnavailable)
+		return
+	}
+
+	ctx := r.Context()
+	first, err := w.dedupe.MarkUpdate(ctx, upd.ID)
+	if err != nil {
+		// Handling an update twice beats dropping it.
+		log.Printf("failed to check Telegram update %d: %v", upd.ID, err)
+		first = true
+	}
+	if !first {
+		rw.WriteHeader(http.StatusOK)
+		return
+	}
+
+	select {
+	case dest <- upd:
+		rw.WriteHeader(http.StatusOK)
+	case <-ctx.Done():
+		if err := w.dedupe.ReleaseUpdate(context.Background(), upd.ID); err != nil {
+			log.Printf("failed to release Telegram update %d: %v", upd.ID, err)
+		}
+		http.Error(rw, "bot busy", http.StatusServiceUnavailable)
+	}
+}
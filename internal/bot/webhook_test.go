@@ -0,0 +1,199 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	tele "gopkg.in/telebot.v3"
+)
+
+func postUpdate(w *WebhookPoller, secret, body string) int {
+	req := httptest.NewRequest(http.MethodPost, WebhookPath, strings.NewReader(body))
+	if secret != "" {
+		req.Header.Set("X-Telegram-Bot-Api-Secret-Token", secret)
+	}
+	rec := httptest.NewRecorder()
+	w.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestWebhookServeHTTP(t *testing.T) {
+	w := NewWebhookPoller("https://bot.example.com/", "s3cret", nil)
+	if w.url != "https://bot.example.com"+WebhookPath {
+		t.Fatalf("unexpected webhook url %q", w.url)
+	}
+
+	if code := postUpdate(w, "", `{"update_id":1}`); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without secret, got %d", code)
+	}
+	if code := postUpdate(w, "wrong", `{"update_id":1}`); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 with a wrong secret, got %d", code)
+	}
+	if code := postUpdate(w, "s3cret", `{"update_id":1}`); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 before the bot starts, got %d", code)
+	}
+
+	dest := make(chan tele.Update, 4)
+	w.dest = dest
+	if code := postUpdate(w, "s3cret", `not json`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad body, got %d", code)
+	}
+	for i := 0; i < 2; i++ {
+		if code := postUpdate(w, "s3cret", `{"update_id":7,"message":{"text":"hi"}}`); code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", code)
+		}
+	}
+	if len(dest) != 1 {
+		t.Fatalf("expected a retried update to be handled once, got %d", len(dest))
+	}
+	if upd := <-dest; upd.ID != 7 || upd.Message == nil || upd.Message.Text != "hi" {
+		t.Fatalf("unexpected update: %+v", upd)
+	}
+}
+
+func TestWebhookReleasesUpdateWhenNotHandled(t *testing.T) {
+	dedupe := newMemoryUpdateDeduper()
+	w := NewWebhookPoller("https://bot.example.com", "s3cret", dedupe)
+	w.dest = make(chan tele.Update) // nobody reads
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodPost, WebhookPath, strings.NewReader(`{"update_id":9}`)).WithContext(ctx)
+	req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "s3cret")
+	rec := httptest.NewRecorder()
+	w.ServeHTTP(rec, req)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rec.Code)
+	}
+	if first, _ := dedupe.MarkUpdate(context.Background(), 9); !first {
+		t.Fatal("expected the update to be released for Telegram's retry")
+	}
+}
+
+func TestRedisUpdateDeduperSharesSeenUpdates(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	a, b := NewRedisUpdateDeduper(client), NewRedisUpdateDeduper(client)
+	ctx := context.Background()
+
+	if first, err := a.MarkUpdate(ctx, 42); err != nil || !first {
+		t.Fatalf("expected first sighting, got %v %v", first, err)
+	}
+	if first, err := b.MarkUpdate(ctx, 42); err != nil || first {
+		t.Fatalf("expected the other replica to see a duplicate, got %v %v", first, err)
+	}
+	if ttl := mr.TTL(updateSeenPrefix + "42"); ttl != updateSeenTTL {
+		t.Fatalf("unexpected ttl %v", ttl)
+	}
+	if err := a.ReleaseUpdate(ctx, 42); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first, _ := b.MarkUpdate(ctx, 42); !first {
+		t.Fatal("expected a released update to be handled again")
+	}
+}
+
+// fakeTelegramAPI records the Bot API methods called.
+type fakeTelegramAPI struct {
+	mu      sync.Mutex
+	calls   []string
+	hookURL string
+	secret  string
+}
+
+func (f *fakeTelegramAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	f.calls = append(f.calls, method)
+	switch method {
+	case "getWebhookInfo":
+		_, _ = w.Write([]byte(`{"ok":true,"result":{"url":"` + f.hookURL + `"}}`))
+		return
+	case "setWebhook":
+		var params map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&params)
+		f.hookURL, f.secret = params["url"], params["secret_token"]
+	case "deleteWebhook":
+		f.hookURL = ""
+	}
+	_, _ = w.Write([]byte(`{"ok":true,"result":true}`))
+}
+
+func (f *fakeTelegramAPI) snapshot() ([]string, string, string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...), f.hookURL, f.secret
+}
+
+func TestWebhookRegistersOnStartAndStaysOnStop(t *testing.T) {
+	api := &fakeTelegramAPI{}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	w := NewWebhookPoller("https://bot.example.com", "s3cret", nil)
+	b, err := tele.NewBot(tele.Settings{Token: "test", URL: srv.URL, Offline: true, Poller: w})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	go b.Start()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		calls, url, secret := api.snapshot()
+		if url == "https://bot.example.com"+WebhookPath && secret == "s3cret" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("webhook was not registered, calls %v", calls)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if code := postUpdate(w, "s3cret", `{"update_id":1}`); code != http.StatusOK {
+		t.Fatalf("expected updates accepted once started, got %d", code)
+	}
+
+	w.register(b)
+	calls, _, _ := api.snapshot()
+	if n := strings.Count(strings.Join(calls, ","), "setWebhook"); n != 1 {
+		t.Fatalf("expected an existing registration to be left alone, got calls %v", calls)
+	}
+
+	w.Stop()
+	calls, url, _ := api.snapshot()
+	if url == "" || strings.Contains(strings.Join(calls, ","), "deleteWebhook") {
+		t.Fatalf("expected the webhook kept for other replicas on stop, got %v", calls)
+	}
+	if code := postUpdate(w, "s3cret", `{"update_id":2}`); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 after stop, got %d", code)
+	}
+}
+
+func TestRemoveWebhookOnlyWhenRegistered(t *testing.T) {
+	api := &fakeTelegramAPI{}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	b, err := tele.NewBot(tele.Settings{Token: "test", URL: srv.URL, Offline: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	removeWebhook(b)
+	if calls, _, _ := api.snapshot(); strings.Contains(strings.Join(calls, ","), "deleteWebhook") {
+		t.Fatalf("expected nothing removed without a webhook, got %v", calls)
+	}
+
+	api.hookURL = "https://bot.example.com" + WebhookPath
+	removeWebhook(b)
+	if calls, url, _ := api.snapshot(); url != "" || calls[len(calls)-1] != "deleteWebhook" {
+		t.Fatalf("expected the webhook removed for long polling, got %v", calls)
+	}
+}
@@ -0,0 +1,170 @@
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+const (
+	customMainHeight = 400
+	customPaneHeight = 120
+	customPaneGap    = 16
+)
+
+var (
+	colEMAFast = color.RGBA{R: 62, G: 106, B: 214, A: 255}
+	colEMASlow = color.RGBA{R: 128, G: 82, B: 196, A: 255}
+	colVWAP    = color.RGBA{R: 255, G: 149, B: 0, A: 255}
+)
+
+// RenderChart draws candles with the requested overlays and panes. candles
+// may start before the shown window so indicators are warmed up; only the
+// last req.Candles are drawn. Each pane adds its own panel, so the image
+// grows taller with more panes.
+func (r *Renderer) RenderChart(candles []*domain.Candle, req domain.ChartRequest) ([]byte, error) {
+	series := normalizeCandles(candles)
+	if len(series) < 2 {
+		return nil, fmt.Errorf("need at least 2 candles to render chart")
+	}
+	shown := req.Candles
+	if shown <= 0 || shown > len(series) {
+		shown = len(series)
+	}
+	offset := len(series) - shown
+	view := series[offset:]
+	closes := extractCloses(series)
+
+	height := 20 + customMainHeight + len(req.Panes)*(customPaneHeight+customPaneGap) + 30
+	img := image.NewRGBA(image.Rect(0, 0, defaultChartWidth, height))
+	fillRect(img, img.Bounds(), colBackground)
+
+	mainRect := image.Rect(60, 20, defaultChartWidth-20, 20+customMainHeight)
+	drawGrid(img, mainRect, 8, 6)
+
+	type line struct {
+		values []float64
+		col    color.RGBA
+	}
+	lines := make([]line, 0, 4)
+	for _, overlay := range req.Overlays {
+		switch overlay {
+		case domain.ChartOverlayEMA:
+			lines = append(lines,
+				line{emaSeries(closes, 20)[offset:], colEMAFast},
+				line{emaSeries(closes, 50)[offset:], colEMASlow},
+			)
+		case domain.ChartOverlayBollinger:
+			upper, mean, lower := bollingerSeries(closes, 20, 2)
+			lines = append(lines,
+				line{upper[offset:], colBand},
+				line{mean[offset:], colLineB},
+				line{lower[offset:], colBand},
+			)
+		case domain.ChartOverlayVWAP:
+			lines = append(lines, line{vwapSeries(view), colVWAP})
+		default:
+			return nil, fmt.Errorf("unsupported overlay: %s", overlay)
+		}
+	}
+
+	minPrice, maxPrice := priceBounds(view, nil)
+	for _, l := range lines {
+		lo, hi := finiteBounds(l.values)
+		minPrice, maxPrice = math.Min(minPrice, lo), math.Max(maxPrice, hi)
+	}
+	if err := drawCandles(img, mainRect, view, minPrice, maxPrice); err != nil {
+		return nil, err
+	}
+	for _, l := range lines {
+		drawSeries(img, mainRect, l.values, minPrice, maxPrice, l.col)
+	}
+
+	top := mainRect.Max.Y
+	for _, pane := range req.Panes {
+		rect := image.Rect(60, top+customPaneGap, defaultChartWidth-20, top+customPaneGap+customPaneHeight)
+		top = rect.Max.Y
+		drawGrid(img, rect, 8, 3)
+		switch pane {
+		case domain.ChartPaneRSI:
+			drawHorizontalValueLine(img, rect, 30, 0, 100, colBand)
+			drawHorizontalValueLine(img, rect, 70, 0, 100, colBand)
+			if rsi := rsiSeries(closes, 14); rsi != nil {
+				drawSeries(img, rect, rsi[offset:], 0, 100, colLineA)
+			}
+		case domain.ChartPaneMACD:
+			macd, signal := macdSeries(closes, 12, 26, 9)
+			macd, signal = macd[offset:], signal[offset:]
+			minV, maxV := finiteBounds(append(append([]float64{}, macd...), signal...))
+			drawHorizontalValueLine(img, rect, 0, minV, maxV, colBand)
+			drawSeries(img, rect, macd, minV, maxV, colLineA)
+			drawSeries(img, rect, signal, minV, maxV, colLineB)
+		case domain.ChartPaneVolume:
+			drawVolumeBars(img, rect, view)
+		default:
+			return nil, fmt.Errorf("unsupported pane: %s", pane)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// bollingerSeries returns the upper, middle and lower bands, NaN until a
+// full period is available.
+func bollingerSeries(closes []float64, period int, width float64) ([]float64, []float64, []float64) {
+	upper := make([]float64, len(closes))
+	mean := make([]float64, len(closes))
+	lower := make([]float64, len(closes))
+	for i := range closes {
+		if i < period-1 {
+			upper[i], mean[i], lower[i] = math.NaN(), math.NaN(), math.NaN()
+			continue
+		}
+		m, s := meanStd(closes[i-period+1 : i+1])
+		upper[i], mean[i], lower[i] = m+width*s, m, m-width*s
+	}
+	return upper, mean, lower
+}
+
+// vwapSeries is the volume-weighted average typical price anchored at the
+// first candle.
+func vwapSeries(candles []domain.Candle) []float64 {
+	out := make([]float64, len(candles))
+	var pv, vol float64
+	for i, c := range candles {
+		pv += (c.High + c.Low + c.Close) / 3 * c.Volume
+		vol += c.Volume
+		if vol == 0 {
+			out[i] = math.NaN()
+			continue
+		}
+		out[i] = pv / vol
+	}
+	return out
+}
+
+// drawVolumeBars draws volume coloured by candle direction.
+func drawVolumeBars(img *image.RGBA, rect image.Rectangle, candles []domain.Candle) {
+	volumes := extractVolumes(candles)
+	_, maxV := finiteBounds(volumes)
+	barW := max(1, (rect.Dx()-10)/len(candles)-1)
+	baseY := mapValueToY(0, 0, maxV, rect)
+	for i, c := range candles {
+		col := colBull
+		if c.Close < c.Open {
+			col = colBear
+		}
+		x := mapIndexToX(i, len(candles), rect)
+		y := mapValueToY(c.Volume, 0, maxV, rect)
+		fillRect(img, image.Rect(x-barW/2, y, x+barW/2+1, baseY+1), col)
+	}
+}
@@ -0,0 +1,66 @@
+package chart
+
+import (
+	"bytes"
+	"image/png"
+	"math"
+	"testing"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+func TestRenderChartGrowsWithPanes(t *testing.T) {
+	candles := buildTestCandles(160)
+	plain, err := NewRenderer().RenderChart(candles, domain.ChartRequest{Candles: 100})
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	full, err := NewRenderer().RenderChart(candles, domain.ChartRequest{
+		Candles:  100,
+		Overlays: domain.ChartOverlays,
+		Panes:    domain.ChartPanes,
+	})
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+
+	plainImg, err := png.Decode(bytes.NewReader(plain))
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	fullImg, err := png.Decode(bytes.NewReader(full))
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	want := plainImg.Bounds().Dy() + len(domain.ChartPanes)*(customPaneHeight+customPaneGap)
+	if fullImg.Bounds().Dy() != want || fullImg.Bounds().Dx() != defaultChartWidth {
+		t.Fatalf("expected %dx%d, got %v", defaultChartWidth, want, fullImg.Bounds())
+	}
+}
+
+func TestRenderChartRejectsUnknownOptions(t *testing.T) {
+	candles := buildTestCandles(40)
+	if _, err := NewRenderer().RenderChart(candles, domain.ChartRequest{Overlays: []string{"ichimoku"}}); err == nil {
+		t.Fatal("expected error for unknown overlay")
+	}
+	if _, err := NewRenderer().RenderChart(candles, domain.ChartRequest{Panes: []string{"obv"}}); err == nil {
+		t.Fatal("expected error for unknown pane")
+	}
+	if _, err := NewRenderer().RenderChart(candles[:1], domain.ChartRequest{}); err == nil {
+		t.Fatal("expected error for a single candle")
+	}
+}
+
+func TestVWAPSeries(t *testing.T) {
+	got := vwapSeries([]domain.Candle{
+		{High: 12, Low: 8, Close: 10, Volume: 1},
+		{High: 22, Low: 18, Close: 20, Volume: 3},
+		{High: 1, Low: 1, Close: 1, Volume: 0},
+	})
+	if got[0] != 10 || got[1] != 17.5 || got[2] != 17.5 {
+		t.Fatalf("unexpected vwap: %v", got)
+	}
+	if !math.IsNaN(vwapSeries([]domain.Candle{{Close: 1}})[0]) {
+		t.Fatal("expected NaN before any volume")
+	}
+}
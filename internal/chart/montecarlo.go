@@ -0,0 +1,85 @@
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+var (
+	colFanOuter = color.RGBA{R: 198, G: 214, B: 240, A: 255}
+	colFanInner = color.RGBA{R: 146, G: 176, B: 228, A: 255}
+	colZero     = color.RGBA{R: 150, G: 150, B: 160, A: 255}
+)
+
+// RenderMonteCarloFan draws the simulated cumulative return per trade as a
+// fan: the 5-95 and 25-75 percentile bands, the median, and the run's own
+// trade order on top.
+func (r *Renderer) RenderMonteCarloFan(res domain.MonteCarloResult) ([]byte, error) {
+	if len(res.Fan) < 2 {
+		return nil, fmt.Errorf("need at least 2 fan points to render chart")
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, defaultChartWidth, defaultChartHeight))
+	fillRect(img, img.Bounds(), colBackground)
+	rect := image.Rect(60, 20, defaultChartWidth-20, defaultChartHeight-30)
+	drawGrid(img, rect, 8, 6)
+
+	values := make([]float64, 0, 2*len(res.Fan)+len(res.Actual)+1)
+	values = append(values, 0)
+	for _, b := range res.Fan {
+		values = append(values, b.P5, b.P95)
+	}
+	values = append(values, res.Actual...)
+	minV, maxV := finiteBounds(values)
+	pad := (maxV - minV) * 0.05
+	minV, maxV = minV-pad, maxV+pad
+	if minV == maxV {
+		maxV = minV + 1
+	}
+
+	total := len(res.Fan)
+	for x := rect.Min.X; x < rect.Max.X; x++ {
+		b := fanAt(res.Fan, float64(x-rect.Min.X)/float64(rect.Dx()-1)*float64(total-1))
+		drawLine(img, x, mapValueToY(b.P95, minV, maxV, rect), x, mapValueToY(b.P5, minV, maxV, rect), colFanOuter)
+		drawLine(img, x, mapValueToY(b.P75, minV, maxV, rect), x, mapValueToY(b.P25, minV, maxV, rect), colFanInner)
+	}
+	drawHorizontalValueLine(img, rect, 0, minV, maxV, colZero)
+
+	median := make([]float64, total)
+	for i, b := range res.Fan {
+		median[i] = b.P50
+	}
+	drawSeries(img, rect, median, minV, maxV, colLineA)
+	if len(res.Actual) == total {
+		drawSeries(img, rect, res.Actual, minV, maxV, colLineB)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// fanAt interpolates the band at a fractional trade index.
+func fanAt(fan []domain.PercentileBand, pos float64) domain.PercentileBand {
+	lo := int(pos)
+	if lo >= len(fan)-1 {
+		return fan[len(fan)-1]
+	}
+	f := pos - float64(lo)
+	a, b := fan[lo], fan[lo+1]
+	lerp := func(x, y float64) float64 { return x + (y-x)*f }
+	return domain.PercentileBand{
+		P5:  lerp(a.P5, b.P5),
+		P25: lerp(a.P25, b.P25),
+		P50: lerp(a.P50, b.P50),
+		P75: lerp(a.P75, b.P75),
+		P95: lerp(a.P95, b.P95),
+	}
+}
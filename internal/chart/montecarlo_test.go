@@ -0,0 +1,46 @@
+package chart
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+func TestRenderMonteCarloFan(t *testing.T) {
+	fan := make([]domain.PercentileBand, 0, 21)
+	actual := make([]float64, 0, 21)
+	for i := 0; i <= 20; i++ {
+		step := float64(i) * 0.01
+		fan = append(fan, domain.PercentileBand{P5: -2 * step, P25: -step / 2, P50: step, P75: 2 * step, P95: 3 * step})
+		actual = append(actual, step*1.5)
+	}
+	out, err := NewRenderer().RenderMonteCarloFan(domain.MonteCarloResult{Fan: fan, Actual: actual})
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	decoded, err := png.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+
+	// Near the right edge the outer band spans well beyond the inner one.
+	rect := image.Rect(60, 20, defaultChartWidth-20, defaultChartHeight-30)
+	minV, maxV := -0.4-0.05*1.0, 0.6+0.05*1.0
+	x := rect.Max.X - 2
+	if got := color.RGBAModel.Convert(decoded.At(x, mapValueToY(-0.35, minV, maxV, rect))); got != colFanOuter {
+		t.Fatalf("expected outer band colour, got %+v", got)
+	}
+	if got := color.RGBAModel.Convert(decoded.At(x, mapValueToY(0.1, minV, maxV, rect))); got != colFanInner {
+		t.Fatalf("expected inner band colour, got %+v", got)
+	}
+}
+
+func TestRenderMonteCarloFanNeedsPoints(t *testing.T) {
+	if _, err := NewRenderer().RenderMonteCarloFan(domain.MonteCarloResult{}); err == nil {
+		t.Fatal("expected error for empty fan")
+	}
+}
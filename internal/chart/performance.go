@@ -0,0 +1,75 @@
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+type seriesColor struct {
+	name string
+	col  color.RGBA
+}
+
+// seriesPalette colours multi-asset charts. The renderer has no text, so
+// callers print the legend using SeriesColorName.
+var seriesPalette = []seriesColor{
+	{"blue", color.RGBA{R: 62, G: 106, B: 214, A: 255}},
+	{"orange", color.RGBA{R: 255, G: 149, B: 0, A: 255}},
+	{"green", color.RGBA{R: 18, G: 140, B: 126, A: 255}},
+	{"red", color.RGBA{R: 210, G: 61, B: 87, A: 255}},
+	{"purple", color.RGBA{R: 128, G: 82, B: 196, A: 255}},
+	{"brown", color.RGBA{R: 140, G: 96, B: 62, A: 255}},
+	{"pink", color.RGBA{R: 230, G: 110, B: 180, A: 255}},
+	{"grey", color.RGBA{R: 96, G: 104, B: 120, A: 255}},
+	{"olive", color.RGBA{R: 150, G: 160, B: 40, A: 255}},
+	{"cyan", color.RGBA{R: 40, G: 180, B: 220, A: 255}},
+}
+
+// SeriesColorName names the colour the i-th series is drawn in.
+func SeriesColorName(i int) string {
+	return seriesPalette[i%len(seriesPalette)].name
+}
+
+// RenderPerformanceChart overlays each asset's percent-change path on one
+// axis, with a zero line for reference.
+func (r *Renderer) RenderPerformanceChart(prices []domain.DigestPriceChange) ([]byte, error) {
+	values := []float64{0}
+	series := 0
+	for _, p := range prices {
+		if len(p.Path) >= 2 {
+			values = append(values, p.Path...)
+			series++
+		}
+	}
+	if series == 0 {
+		return nil, fmt.Errorf("need at least one price path with 2 points to render chart")
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, defaultChartWidth, defaultChartHeight))
+	fillRect(img, img.Bounds(), colBackground)
+	rect := image.Rect(60, 20, defaultChartWidth-20, defaultChartHeight-30)
+	drawGrid(img, rect, 8, 6)
+
+	minV, maxV := finiteBounds(values)
+	pad := (maxV - minV) * 0.05
+	minV, maxV = minV-pad, maxV+pad
+	drawHorizontalValueLine(img, rect, 0, minV, maxV, colZero)
+
+	for i, p := range prices {
+		if len(p.Path) < 2 {
+			continue
+		}
+		drawSeries(img, rect, p.Path, minV, maxV, seriesPalette[i%len(seriesPalette)].col)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
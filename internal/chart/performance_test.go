@@ -0,0 +1,48 @@
+package chart
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+func TestRenderPerformanceChart(t *testing.T) {
+	out, err := NewRenderer().RenderPerformanceChart([]domain.DigestPriceChange{
+		{Symbol: "BTC", Path: []float64{0, 2, 4, 6, 8, 10}},
+		{Symbol: "ETH", Path: []float64{0, -2, -4, -6, -8, -10}},
+		{Symbol: "SOL", Path: []float64{0}},
+	})
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	decoded, err := png.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+
+	rect := image.Rect(60, 20, defaultChartWidth-20, defaultChartHeight-30)
+	minV, maxV := -10-1.0, 10+1.0
+	last := rect.Max.X - 1
+	if got := color.RGBAModel.Convert(decoded.At(last, mapValueToY(10, minV, maxV, rect))); got != seriesPalette[0].col {
+		t.Fatalf("expected first series colour at top right, got %+v", got)
+	}
+	if got := color.RGBAModel.Convert(decoded.At(last, mapValueToY(-10, minV, maxV, rect))); got != seriesPalette[1].col {
+		t.Fatalf("expected second series colour at bottom right, got %+v", got)
+	}
+}
+
+func TestRenderPerformanceChartNeedsPaths(t *testing.T) {
+	if _, err := NewRenderer().RenderPerformanceChart([]domain.DigestPriceChange{{Symbol: "BTC"}}); err == nil {
+		t.Fatal("expected error without paths")
+	}
+}
+
+func TestSeriesColorNameWraps(t *testing.T) {
+	if SeriesColorName(0) != "blue" || SeriesColorName(len(seriesPalette)+1) != "orange" {
+		t.Fatalf("unexpected palette names: %s %s", SeriesColorName(0), SeriesColorName(len(seriesPalette)+1))
+	}
+}
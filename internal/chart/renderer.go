@@ -54,9 +54,11 @@ func (r *Renderer) RenderSignalChart(candles []*domain.Candle, signal domain.Sig
 	drawGrid(img, mainRect, 8, 6)
 	drawGrid(img, auxRect, 8, 3)
 
-	if err := drawCandles(img, mainRect, series); err != nil {
+	minPrice, maxPrice := priceBounds(series, signal.Levels)
+	if err := drawCandles(img, mainRect, series, minPrice, maxPrice); err != nil {
 		return nil, err
 	}
+	drawLevels(img, mainRect, signal.Levels, minPrice, maxPrice)
 
 	markerX := mapIndexToX(len(series)-1, len(series), mainRect)
 	drawLine(img, markerX, mainRect.Min.Y, markerX, mainRect.Max.Y, colMarker)
@@ -102,11 +104,12 @@ func normalizeCandles(in []*domain.Candle) []domain.Candle {
 	return out
 }
 
-func drawCandles(img *image.RGBA, rect image.Rectangle, candles []domain.Candle) error {
+// priceBounds returns the price range of the main panel, widened to keep any
+// signal levels on screen.
+func priceBounds(candles []domain.Candle, levels *domain.SignalLevels) (float64, float64) {
 	if len(candles) == 0 {
-		return fmt.Errorf("no candles")
+		return 0, 1
 	}
-
 	minPrice := candles[0].Low
 	maxPrice := candles[0].High
 	for _, c := range candles {
@@ -117,9 +120,25 @@ func drawCandles(img *image.RGBA, rect image.Rectangle, candles []domain.Candle)
 			maxPrice = c.High
 		}
 	}
+	if levels != nil {
+		for _, v := range append([]float64{levels.Entry, levels.Stop}, levels.Targets...) {
+			if v <= 0 {
+				continue
+			}
+			minPrice = math.Min(minPrice, v)
+			maxPrice = math.Max(maxPrice, v)
+		}
+	}
 	if maxPrice <= minPrice {
 		maxPrice = minPrice + 1
 	}
+	return minPrice, maxPrice
+}
+
+func drawCandles(img *image.RGBA, rect image.Rectangle, candles []domain.Candle, minPrice, maxPrice float64) error {
+	if len(candles) == 0 {
+		return fmt.Errorf("no candles")
+	}
 
 	candleWidth := max(3, (rect.Dx()-10)/len(candles)-1)
 	for i, c := range candles {
@@ -146,6 +165,18 @@ func drawCandles(img *image.RGBA, rect image.Rectangle, candles []domain.Candle)
 	return nil
 }
 
+// drawLevels overlays entry, stop and target prices across the main panel.
+func drawLevels(img *image.RGBA, rect image.Rectangle, levels *domain.SignalLevels, minPrice, maxPrice float64) {
+	if levels == nil {
+		return
+	}
+	for _, t := range levels.Targets {
+		drawHorizontalValueLine(img, rect, t, minPrice, maxPrice, colBull)
+	}
+	drawHorizontalValueLine(img, rect, levels.Stop, minPrice, maxPrice, colBear)
+	drawHorizontalValueLine(img, rect, levels.Entry, minPrice, maxPrice, colMarker)
+}
+
 func drawRSI(img *image.RGBA, rect image.Rectangle, candles []domain.Candle) {
 	closes := extractCloses(candles)
 	rsi := rsiSeries(closes, 14)
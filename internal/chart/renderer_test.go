@@ -1,6 +1,10 @@
 package chart
 
 import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
 	"testing"
 	"time"
 
@@ -39,6 +43,38 @@ func TestRenderSignalChartByIndicator(t *testing.T) {
 	}
 }
 
+func TestRenderSignalChartDrawsLevels(t *testing.T) {
+	candles := buildTestCandles(160)
+	levels := &domain.SignalLevels{Entry: 50000, Stop: 45000, Targets: []float64{58000}}
+	out, err := NewRenderer().RenderSignalChart(candles, domain.Signal{
+		Symbol:    "BTC",
+		Interval:  "1h",
+		Indicator: domain.IndicatorRSI,
+		Direction: domain.DirectionLong,
+		Levels:    levels,
+	})
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	decoded, err := png.Decode(bytes.NewReader(out.Bytes))
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+
+	minPrice, maxPrice := priceBounds(normalizeCandles(candles)[len(candles)-maxChartCandles:], levels)
+	if minPrice > levels.Stop || maxPrice < levels.Targets[0] {
+		t.Fatalf("expected bounds to include levels, got %.0f..%.0f", minPrice, maxPrice)
+	}
+	mainRect := image.Rect(60, 20, defaultChartWidth-20, (defaultChartHeight*72)/100)
+	x := mainRect.Min.X + 5
+	if got := color.RGBAModel.Convert(decoded.At(x, mapValueToY(levels.Stop, minPrice, maxPrice, mainRect))); got != colBear {
+		t.Fatalf("expected stop line color, got %+v", got)
+	}
+	if got := color.RGBAModel.Convert(decoded.At(x, mapValueToY(levels.Targets[0], minPrice, maxPrice, mainRect))); got != colBull {
+		t.Fatalf("expected target line color, got %+v", got)
+	}
+}
+
 func buildTestCandles(count int) []*domain.Candle {
 	base := time.Now().UTC().Add(-time.Duration(count) * time.Hour)
 	out := make([]*domain.Candle, 0, count)
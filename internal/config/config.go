@@ -1,18 +1,27 @@
 package config
 
 import (
+	"bug-free-umbrella/internal/advisor"
+	"bug-free-umbrella/internal/delivery"
 	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/llm"
+	"bug-free-umbrella/internal/notify"
 	"log"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 )
 
 type Config struct {
-	TelegramBotToken  string
-	DatabaseURL       string
-	RedisURL          string
-	CoinGeckoPollSecs int
+	TelegramBotToken      string
+	TelegramAdminIDs      []int64 // always admins; empty leaves the bot open
+	TelegramWebhookURL    string  // public base URL; empty uses long polling
+	TelegramWebhookSecret string
+	RunJobs               bool // start pollers and scheduled jobs; off on extra replicas
+	DatabaseURL           string
+	RedisURL              string
+	CoinGeckoPollSecs     int
 
 	MCPTransport          string
 	MCPHTTPEnabled        bool
@@ -22,9 +31,27 @@ type Config struct {
 	MCPRequestTimeoutSecs int
 	MCPRateLimitPerMin    int
 
-	OpenAIAPIKey      string
-	OpenAIModel       string
-	AdvisorMaxHistory int
+	OpenAIAPIKey         string
+	OpenAIModel          string
+	AnthropicAPIKey      string
+	LLMProvider          string
+	LLMBaseURL           string
+	LLMAPIKey            string
+	AdvisorLLMProvider   string
+	AdvisorModel         string
+	AdvisorMaxHistory    int
+	AdvisorMaxToolRounds int
+	AdvisorTimeoutSecs   int
+	AdvisorHistoryTokens int
+	// AdvisorContextTokens overrides the model's context window; zero looks
+	// it up from the model name.
+	AdvisorContextTokens int
+	AdvisorMemoryFacts   bool
+	AdvisorRetentionDays int
+	// AdvisorVerifyMode is how replies with figures the supplied data does
+	// not back are handled: off, annotate, correct or regenerate.
+	AdvisorVerifyMode         string
+	AdvisorVerifyTolerancePct float64
 
 	MLEnabled         bool
 	MLInterval        string
@@ -54,6 +81,7 @@ type Config struct {
 	MarketIntelNewsFeeds        []string
 	MarketIntelRedditSubs       []string
 	MarketIntelRedditPostLimit  int
+	MarketIntelScoringProvider  string
 	MarketIntelScoringModel     string
 	MarketIntelScoringBatchSize int
 	MarketIntelRetentionDays    int
@@ -64,6 +92,27 @@ type Config struct {
 	OnChainADAKoiosBaseURL      string
 	OnChainXRPAPIBaseURL        string
 
+	SizingMethod           string
+	SizingRiskPerTrade     float64
+	SizingTargetVolatility float64
+	SizingKellyFraction    float64
+	SizingKellyWinRate     float64
+	SizingMaxPositionPct   float64
+	SizingReferenceEquity  float64
+
+	AlertDeliveryWorkers  int
+	AlertGlobalRatePerSec float64
+	AlertChatRatePerSec   float64
+	AlertMaxAttempts      int
+
+	// SMTP is used by email notification channels; without a host they
+	// cannot be created.
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+
 	SSHEnabled     bool
 	SSHPort        int
 	SSHHostKeyPath string
@@ -93,6 +142,39 @@ func Load() *Config {
 	if cfg.DatabaseURL == "" {
 		log.Println("Warning: DATABASE_URL not set")
 	}
+
+	for _, raw := range strings.Split(os.Getenv("TELEGRAM_ADMIN_IDS"), ",") {
+		if raw = strings.TrimSpace(raw); raw == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			log.Printf("Warning: ignoring invalid TELEGRAM_ADMIN_IDS entry %q", raw)
+			continue
+		}
+		cfg.TelegramAdminIDs = append(cfg.TelegramAdminIDs, id)
+	}
+	if cfg.TelegramBotToken != "" && len(cfg.TelegramAdminIDs) == 0 {
+		log.Println("Warning: TELEGRAM_ADMIN_IDS not set, Telegram bot is open to everyone")
+	}
+	cfg.TelegramWebhookURL = strings.TrimSpace(os.Getenv("TELEGRAM_WEBHOOK_URL"))
+	cfg.TelegramWebhookSecret = strings.TrimSpace(os.Getenv("TELEGRAM_WEBHOOK_SECRET"))
+	if cfg.TelegramWebhookURL != "" && !strings.HasPrefix(cfg.TelegramWebhookURL, "https://") {
+		log.Println("Warning: TELEGRAM_WEBHOOK_URL must start with https://, using long polling")
+		cfg.TelegramWebhookURL = ""
+	}
+	if cfg.TelegramWebhookURL != "" && !validWebhookSecret(cfg.TelegramWebhookSecret) {
+		log.Println("Warning: TELEGRAM_WEBHOOK_SECRET must be 1-256 of A-Z, a-z, 0-9, _ and -, using long polling")
+		cfg.TelegramWebhookURL = ""
+	}
+	cfg.RunJobs = true
+	if v := strings.TrimSpace(os.Getenv("RUN_JOBS")); v != "" {
+		if strings.EqualFold(v, "true") {
+			cfg.RunJobs = true
+		} else if strings.EqualFold(v, "false") {
+			cfg.RunJobs = false
+		}
+	}
 	if cfg.RedisURL == "" {
 		log.Println("Warning: REDIS_URL not set, defaulting to localhost:6379")
 		cfg.RedisURL = "localhost:6379"
@@ -143,14 +225,23 @@ func Load() *Config {
 	}
 
 	cfg.OpenAIAPIKey = os.Getenv("OPENAI_API_KEY")
-	if cfg.OpenAIAPIKey == "" {
-		log.Println("Warning: OPENAI_API_KEY not set, advisor will be disabled")
-	}
-
 	cfg.OpenAIModel = strings.TrimSpace(os.Getenv("OPENAI_MODEL"))
 	if cfg.OpenAIModel == "" {
 		cfg.OpenAIModel = "gpt-4o-mini"
 	}
+	cfg.AnthropicAPIKey = strings.TrimSpace(os.Getenv("ANTHROPIC_API_KEY"))
+	cfg.LLMBaseURL = strings.TrimSpace(os.Getenv("LLM_BASE_URL"))
+	cfg.LLMAPIKey = strings.TrimSpace(os.Getenv("LLM_API_KEY"))
+
+	cfg.LLMProvider = parseLLMProvider("LLM_PROVIDER", llm.ProviderOpenAI)
+	cfg.AdvisorLLMProvider = parseLLMProvider("ADVISOR_LLM_PROVIDER", cfg.LLMProvider)
+	cfg.AdvisorModel = strings.TrimSpace(os.Getenv("ADVISOR_MODEL"))
+	if cfg.AdvisorModel == "" {
+		cfg.AdvisorModel = cfg.defaultModel(cfg.AdvisorLLMProvider)
+	}
+	if !cfg.AdvisorLLM().Enabled() {
+		log.Printf("Warning: %s LLM backend not configured, advisor will be disabled", cfg.AdvisorLLMProvider)
+	}
 
 	cfg.AdvisorMaxHistory = 20
 	if v := os.Getenv("ADVISOR_MAX_HISTORY"); v != "" {
@@ -159,6 +250,66 @@ func Load() *Config {
 		}
 	}
 
+	cfg.AdvisorMaxToolRounds = 5
+	if v := strings.TrimSpace(os.Getenv("ADVISOR_MAX_TOOL_ROUNDS")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.AdvisorMaxToolRounds = n
+		}
+	}
+
+	cfg.AdvisorTimeoutSecs = 60
+	if v := strings.TrimSpace(os.Getenv("ADVISOR_TIMEOUT_SECS")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.AdvisorTimeoutSecs = n
+		}
+	}
+
+	cfg.AdvisorHistoryTokens = 4000
+	if v := strings.TrimSpace(os.Getenv("ADVISOR_HISTORY_TOKENS")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.AdvisorHistoryTokens = n
+		}
+	}
+
+	cfg.AdvisorContextTokens = 0
+	if v := strings.TrimSpace(os.Getenv("ADVISOR_CONTEXT_TOKENS")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.AdvisorContextTokens = n
+		}
+	}
+
+	cfg.AdvisorMemoryFacts = true
+	if v := strings.TrimSpace(os.Getenv("ADVISOR_MEMORY_FACTS")); v != "" {
+		if strings.EqualFold(v, "true") {
+			cfg.AdvisorMemoryFacts = true
+		} else if strings.EqualFold(v, "false") {
+			cfg.AdvisorMemoryFacts = false
+		}
+	}
+
+	cfg.AdvisorRetentionDays = 90
+	if v := strings.TrimSpace(os.Getenv("ADVISOR_RETENTION_DAYS")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.AdvisorRetentionDays = n
+		}
+	}
+
+	cfg.AdvisorVerifyMode = advisor.VerifyAnnotate
+	if v := strings.ToLower(strings.TrimSpace(os.Getenv("ADVISOR_VERIFY_MODE"))); v != "" {
+		if slices.Contains(advisor.VerifyModes, v) {
+			cfg.AdvisorVerifyMode = v
+		} else {
+			log.Printf("Warning: unsupported ADVISOR_VERIFY_MODE=%q, defaulting to %s", v, advisor.VerifyAnnotate)
+		}
+	}
+
+	cfg.AdvisorVerifyTolerancePct = 2
+	if v := strings.TrimSpace(os.Getenv("ADVISOR_VERIFY_TOLERANCE_PCT")); v != "" {
+		if n, err := strconv.ParseFloat(v, 64); err == nil && n > 0 && n < 100 {
+			cfg.AdvisorVerifyTolerancePct = n
+		}
+	}
+
 	cfg.MLEnabled = strings.EqualFold(strings.TrimSpace(os.Getenv("ML_ENABLED")), "true")
 
 	cfg.MLInterval = strings.TrimSpace(os.Getenv("ML_INTERVAL"))
@@ -321,12 +472,10 @@ func Load() *Config {
 		}
 	}
 
+	cfg.MarketIntelScoringProvider = parseLLMProvider("MARKET_INTEL_SCORING_PROVIDER", cfg.LLMProvider)
 	cfg.MarketIntelScoringModel = strings.TrimSpace(os.Getenv("MARKET_INTEL_SCORING_MODEL"))
 	if cfg.MarketIntelScoringModel == "" {
-		cfg.MarketIntelScoringModel = cfg.OpenAIModel
-	}
-	if cfg.MarketIntelScoringModel == "" {
-		cfg.MarketIntelScoringModel = "gpt-4o-mini"
+		cfg.MarketIntelScoringModel = cfg.defaultModel(cfg.MarketIntelScoringProvider)
 	}
 
 	cfg.MarketIntelScoringBatchSize = 24
@@ -374,6 +523,103 @@ func Load() *Config {
 		cfg.OnChainXRPAPIBaseURL = "https://api.xrpscan.com"
 	}
 
+	cfg.SizingMethod = strings.ToLower(strings.TrimSpace(os.Getenv("SIZING_METHOD")))
+	if !domain.SizingMethod(cfg.SizingMethod).IsValid() {
+		if cfg.SizingMethod != "" {
+			log.Printf("Warning: unsupported SIZING_METHOD=%q, defaulting to fixed_fractional", cfg.SizingMethod)
+		}
+		cfg.SizingMethod = string(domain.SizingFixedFractional)
+	}
+
+	cfg.SizingRiskPerTrade = 0.01
+	if v := strings.TrimSpace(os.Getenv("SIZING_RISK_PER_TRADE")); v != "" {
+		if n, err := strconv.ParseFloat(v, 64); err == nil && n > 0 && n < 1 {
+			cfg.SizingRiskPerTrade = n
+		}
+	}
+
+	cfg.SizingTargetVolatility = 0.005
+	if v := strings.TrimSpace(os.Getenv("SIZING_TARGET_VOLATILITY")); v != "" {
+		if n, err := strconv.ParseFloat(v, 64); err == nil && n > 0 && n < 1 {
+			cfg.SizingTargetVolatility = n
+		}
+	}
+
+	cfg.SizingKellyFraction = 0.25
+	if v := strings.TrimSpace(os.Getenv("SIZING_KELLY_FRACTION")); v != "" {
+		if n, err := strconv.ParseFloat(v, 64); err == nil && n > 0 && n <= 1 {
+			cfg.SizingKellyFraction = n
+		}
+	}
+
+	cfg.SizingKellyWinRate = 0.5
+	if v := strings.TrimSpace(os.Getenv("SIZING_KELLY_WIN_RATE")); v != "" {
+		if n, err := strconv.ParseFloat(v, 64); err == nil && n > 0 && n < 1 {
+			cfg.SizingKellyWinRate = n
+		}
+	}
+
+	cfg.SizingMaxPositionPct = 0.25
+	if v := strings.TrimSpace(os.Getenv("SIZING_MAX_POSITION_PCT")); v != "" {
+		if n, err := strconv.ParseFloat(v, 64); err == nil && n > 0 && n <= 1 {
+			cfg.SizingMaxPositionPct = n
+		}
+	}
+
+	cfg.SizingReferenceEquity = 10000
+	if v := strings.TrimSpace(os.Getenv("SIZING_REFERENCE_EQUITY")); v != "" {
+		if n, err := strconv.ParseFloat(v, 64); err == nil && n >= 0 {
+			cfg.SizingReferenceEquity = n
+		}
+	}
+
+	cfg.AlertDeliveryWorkers = 4
+	if v := strings.TrimSpace(os.Getenv("ALERT_DELIVERY_WORKERS")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.AlertDeliveryWorkers = n
+		}
+	}
+
+	// Telegram allows about 30 messages a second per bot; stay under it.
+	cfg.AlertGlobalRatePerSec = 25
+	if v := strings.TrimSpace(os.Getenv("ALERT_GLOBAL_RATE_PER_SEC")); v != "" {
+		if n, err := strconv.ParseFloat(v, 64); err == nil && n > 0 {
+			cfg.AlertGlobalRatePerSec = n
+		}
+	}
+
+	cfg.AlertChatRatePerSec = 1
+	if v := strings.TrimSpace(os.Getenv("ALERT_CHAT_RATE_PER_SEC")); v != "" {
+		if n, err := strconv.ParseFloat(v, 64); err == nil && n > 0 {
+			cfg.AlertChatRatePerSec = n
+		}
+	}
+
+	cfg.AlertMaxAttempts = 8
+	if v := strings.TrimSpace(os.Getenv("ALERT_MAX_ATTEMPTS")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.AlertMaxAttempts = n
+		}
+	}
+
+	cfg.SMTPHost = strings.TrimSpace(os.Getenv("SMTP_HOST"))
+	cfg.SMTPPort = 587
+	if v := strings.TrimSpace(os.Getenv("SMTP_PORT")); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n < 65536 {
+			cfg.SMTPPort = n
+		}
+	}
+	cfg.SMTPUsername = strings.TrimSpace(os.Getenv("SMTP_USERNAME"))
+	cfg.SMTPPassword = os.Getenv("SMTP_PASSWORD")
+	cfg.SMTPFrom = strings.TrimSpace(os.Getenv("SMTP_FROM"))
+	if cfg.SMTPFrom == "" {
+		cfg.SMTPFrom = cfg.SMTPUsername
+	}
+	if cfg.SMTPHost != "" && cfg.SMTPFrom == "" {
+		log.Println("Warning: SMTP_HOST set without SMTP_FROM or SMTP_USERNAME, email channels disabled")
+		cfg.SMTPHost = ""
+	}
+
 	cfg.SSHEnabled = strings.EqualFold(strings.TrimSpace(os.Getenv("SSH_ENABLED")), "true")
 
 	cfg.SSHPort = 2222
@@ -440,6 +686,99 @@ func Load() *Config {
 	return cfg
 }
 
+// Sizing returns the position sizing rules.
+func (c *Config) Sizing() domain.SizingConfig {
+	return domain.SizingConfig{
+		Method:           domain.SizingMethod(c.SizingMethod),
+		RiskPerTrade:     c.SizingRiskPerTrade,
+		TargetVolatility: c.SizingTargetVolatility,
+		KellyFraction:    c.SizingKellyFraction,
+		KellyWinRate:     c.SizingKellyWinRate,
+		MaxPositionPct:   c.SizingMaxPositionPct,
+		ReferenceEquity:  c.SizingReferenceEquity,
+	}
+}
+
+// AlertDelivery returns the alert delivery worker settings; the rest keep
+// their defaults.
+func (c *Config) AlertDelivery() delivery.Config {
+	cfg := delivery.DefaultConfig()
+	cfg.Workers = c.AlertDeliveryWorkers
+	cfg.GlobalPerSec = c.AlertGlobalRatePerSec
+	cfg.ChatPerSec = c.AlertChatRatePerSec
+	cfg.MaxAttempts = c.AlertMaxAttempts
+	return cfg
+}
+
+// NotificationDelivery returns the worker settings for notification
+// channels. Retries follow the alert settings; each channel gets at most
+// one message a second, which Discord and Slack webhooks both accept.
+func (c *Config) NotificationDelivery() delivery.Config {
+	cfg := delivery.DefaultConfig()
+	cfg.Workers = 2
+	cfg.MaxAttempts = c.AlertMaxAttempts
+	return cfg
+}
+
+// SMTP returns the mail server settings for email channels.
+func (c *Config) SMTP() notify.SMTPConfig {
+	return notify.SMTPConfig{
+		Host:     c.SMTPHost,
+		Port:     c.SMTPPort,
+		Username: c.SMTPUsername,
+		Password: c.SMTPPassword,
+		From:     c.SMTPFrom,
+	}
+}
+
+// AdvisorLLM returns the backend settings for the advisor.
+func (c *Config) AdvisorLLM() llm.Config {
+	return c.llmConfig(c.AdvisorLLMProvider, c.AdvisorModel)
+}
+
+// SentimentLLM returns the backend settings for market intel sentiment
+// scoring.
+func (c *Config) SentimentLLM() llm.Config {
+	return c.llmConfig(c.MarketIntelScoringProvider, c.MarketIntelScoringModel)
+}
+
+func (c *Config) llmConfig(provider, model string) llm.Config {
+	cfg := llm.Config{Provider: provider, Model: model}
+	switch provider {
+	case llm.ProviderOpenAI:
+		cfg.APIKey = c.OpenAIAPIKey
+	case llm.ProviderAnthropic:
+		cfg.APIKey = c.AnthropicAPIKey
+	case llm.ProviderOpenAICompatible:
+		cfg.APIKey = c.LLMAPIKey
+		cfg.BaseURL = c.LLMBaseURL
+	}
+	return cfg
+}
+
+// defaultModel keeps OPENAI_MODEL as the OpenAI default; other providers
+// use the backend's own default.
+func (c *Config) defaultModel(provider string) string {
+	if provider == llm.ProviderOpenAI {
+		return c.OpenAIModel
+	}
+	return llm.DefaultModel(provider)
+}
+
+func parseLLMProvider(key, fallback string) string {
+	provider := strings.ToLower(strings.TrimSpace(os.Getenv(key)))
+	if provider == "" {
+		return fallback
+	}
+	for _, known := range llm.Providers {
+		if provider == known {
+			return provider
+		}
+	}
+	log.Printf("Warning: unsupported %s=%q, defaulting to %s", key, provider, fallback)
+	return fallback
+}
+
 func parseMLIntervals(raw string, fallback string) []string {
 	return parseIntervalList(raw, []string{fallback})
 }
@@ -547,3 +886,16 @@ func parseSymbolListWithDefault(raw string, fallback []string) []string {
 	}
 	return out
 }
+
+// validWebhookSecret reports whether s is a secret token Telegram accepts.
+func validWebhookSecret(s string) bool {
+	if s == "" || len(s) > 256 {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
@@ -17,6 +17,21 @@ func TestLoadDefaults(t *testing.T) {
 	t.Setenv("MCP_AUTH_TOKEN", "")
 	t.Setenv("MCP_REQUEST_TIMEOUT_SECS", "")
 	t.Setenv("MCP_RATE_LIMIT_PER_MIN", "")
+	t.Setenv("ADVISOR_MAX_TOOL_ROUNDS", "")
+	t.Setenv("ADVISOR_TIMEOUT_SECS", "")
+	t.Setenv("ADVISOR_HISTORY_TOKENS", "")
+	t.Setenv("ADVISOR_CONTEXT_TOKENS", "")
+	t.Setenv("ADVISOR_MEMORY_FACTS", "")
+	t.Setenv("RUN_JOBS", "")
+	t.Setenv("ADVISOR_RETENTION_DAYS", "")
+	t.Setenv("ADVISOR_VERIFY_MODE", "")
+	t.Setenv("ADVISOR_VERIFY_TOLERANCE_PCT", "")
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("OPENAI_MODEL", "")
+	t.Setenv("LLM_PROVIDER", "")
+	t.Setenv("ADVISOR_LLM_PROVIDER", "")
+	t.Setenv("ADVISOR_MODEL", "")
+	t.Setenv("MARKET_INTEL_SCORING_PROVIDER", "")
 	t.Setenv("ML_ENABLED", "")
 	t.Setenv("ML_INTERVAL", "")
 	t.Setenv("ML_INTERVALS", "")
@@ -28,6 +43,20 @@ func TestLoadDefaults(t *testing.T) {
 	t.Setenv("ML_LONG_THRESHOLD", "")
 	t.Setenv("ML_SHORT_THRESHOLD", "")
 	t.Setenv("ML_MIN_TRAIN_SAMPLES", "")
+	t.Setenv("SIZING_METHOD", "")
+	t.Setenv("SIZING_RISK_PER_TRADE", "")
+	t.Setenv("SIZING_REFERENCE_EQUITY", "")
+	t.Setenv("ALERT_DELIVERY_WORKERS", "")
+	t.Setenv("ALERT_GLOBAL_RATE_PER_SEC", "")
+	t.Setenv("ALERT_CHAT_RATE_PER_SEC", "")
+	t.Setenv("ALERT_MAX_ATTEMPTS", "")
+	t.Setenv("SMTP_HOST", "")
+	t.Setenv("SMTP_PORT", "")
+	t.Setenv("SMTP_USERNAME", "")
+	t.Setenv("SMTP_FROM", "")
+	t.Setenv("TELEGRAM_ADMIN_IDS", "")
+	t.Setenv("TELEGRAM_WEBHOOK_URL", "")
+	t.Setenv("TELEGRAM_WEBHOOK_SECRET", "")
 	t.Setenv("ML_ENABLE_IFOREST", "")
 	t.Setenv("ML_ANOMALY_THRESHOLD", "")
 	t.Setenv("ML_ANOMALY_DAMP_MAX", "")
@@ -60,6 +89,9 @@ func TestLoadDefaults(t *testing.T) {
 	if cfg.CoinGeckoPollSecs != 60 {
 		t.Fatalf("expected default poll secs 60, got %d", cfg.CoinGeckoPollSecs)
 	}
+	if !cfg.RunJobs {
+		t.Fatal("expected background jobs on by default")
+	}
 	if cfg.MCPTransport != "stdio" {
 		t.Fatalf("expected default MCP transport stdio, got %s", cfg.MCPTransport)
 	}
@@ -69,6 +101,21 @@ func TestLoadDefaults(t *testing.T) {
 	if cfg.MCPRequestTimeoutSecs != 5 || cfg.MCPRateLimitPerMin != 60 {
 		t.Fatalf("unexpected MCP defaults: timeout=%d rate=%d", cfg.MCPRequestTimeoutSecs, cfg.MCPRateLimitPerMin)
 	}
+	if cfg.AdvisorMaxToolRounds != 5 || cfg.AdvisorTimeoutSecs != 60 {
+		t.Fatalf("unexpected advisor defaults: rounds=%d timeout=%d", cfg.AdvisorMaxToolRounds, cfg.AdvisorTimeoutSecs)
+	}
+	if cfg.AdvisorHistoryTokens != 4000 || cfg.AdvisorContextTokens != 0 || !cfg.AdvisorMemoryFacts || cfg.AdvisorRetentionDays != 90 {
+		t.Fatalf("unexpected advisor memory defaults: %+v", cfg)
+	}
+	if cfg.AdvisorVerifyMode != "annotate" || cfg.AdvisorVerifyTolerancePct != 2 {
+		t.Fatalf("unexpected advisor verification defaults: mode=%s tolerance=%v", cfg.AdvisorVerifyMode, cfg.AdvisorVerifyTolerancePct)
+	}
+	if got := cfg.AdvisorLLM(); got.Provider != "openai" || got.Model != "gpt-4o-mini" || got.Enabled() {
+		t.Fatalf("unexpected advisor LLM defaults: %+v", got)
+	}
+	if got := cfg.SentimentLLM(); got.Provider != "openai" || got.Model != "gpt-4o-mini" {
+		t.Fatalf("unexpected sentiment LLM defaults: %+v", got)
+	}
 	if cfg.MLEnabled || cfg.MLInterval != "1h" || cfg.MLTargetHours != 4 || cfg.MLTrainWindowDays != 90 {
 		t.Fatalf("unexpected ML defaults: %+v", cfg)
 	}
@@ -111,6 +158,24 @@ func TestLoadDefaults(t *testing.T) {
 	if cfg.WebConsoleEnabled {
 		t.Fatalf("expected web console disabled by default")
 	}
+	if s := cfg.Sizing(); s.Method != "fixed_fractional" || s.RiskPerTrade != 0.01 || s.MaxPositionPct != 0.25 || s.ReferenceEquity != 10000 {
+		t.Fatalf("unexpected sizing defaults: %+v", s)
+	}
+	if d := cfg.AlertDelivery(); d.Workers != 4 || d.GlobalPerSec != 25 || d.ChatPerSec != 1 || d.MaxAttempts != 8 {
+		t.Fatalf("unexpected alert delivery defaults: %+v", d)
+	}
+	if d := cfg.NotificationDelivery(); d.Workers != 2 || d.ChatPerSec != 1 || d.MaxAttempts != 8 {
+		t.Fatalf("unexpected notification delivery defaults: %+v", d)
+	}
+	if m := cfg.SMTP(); m.Host != "" || m.Port != 587 {
+		t.Fatalf("unexpected smtp defaults: %+v", m)
+	}
+	if len(cfg.TelegramAdminIDs) != 0 {
+		t.Fatalf("expected no telegram admins by default, got %v", cfg.TelegramAdminIDs)
+	}
+	if cfg.TelegramWebhookURL != "" {
+		t.Fatalf("expected long polling by default, got webhook %q", cfg.TelegramWebhookURL)
+	}
 	if cfg.WebConsoleCookieSecret == "" || cfg.WebConsoleSessionTTLSecs != 86400 || cfg.WebConsoleHeartbeatSecs != 20 || cfg.WebConsoleStaticDir != "web/dist" {
 		t.Fatalf("unexpected web console defaults: %+v", cfg)
 	}
@@ -128,6 +193,22 @@ func TestLoadWithEnv(t *testing.T) {
 	t.Setenv("MCP_AUTH_TOKEN", "secret")
 	t.Setenv("MCP_REQUEST_TIMEOUT_SECS", "9")
 	t.Setenv("MCP_RATE_LIMIT_PER_MIN", "75")
+	t.Setenv("ADVISOR_MAX_TOOL_ROUNDS", "3")
+	t.Setenv("ADVISOR_TIMEOUT_SECS", "90")
+	t.Setenv("ADVISOR_HISTORY_TOKENS", "6000")
+	t.Setenv("ADVISOR_CONTEXT_TOKENS", "32000")
+	t.Setenv("ADVISOR_MEMORY_FACTS", "false")
+	t.Setenv("RUN_JOBS", " FALSE ")
+	t.Setenv("ADVISOR_RETENTION_DAYS", "30")
+	t.Setenv("ADVISOR_VERIFY_MODE", " Regenerate ")
+	t.Setenv("ADVISOR_VERIFY_TOLERANCE_PCT", "0.5")
+	t.Setenv("LLM_PROVIDER", "openai_compatible")
+	t.Setenv("LLM_BASE_URL", "http://localhost:11434/v1/")
+	t.Setenv("LLM_API_KEY", "local")
+	t.Setenv("ADVISOR_LLM_PROVIDER", " Anthropic ")
+	t.Setenv("ANTHROPIC_API_KEY", "sk-ant")
+	t.Setenv("ADVISOR_MODEL", "")
+	t.Setenv("MARKET_INTEL_SCORING_PROVIDER", "")
 	t.Setenv("ML_ENABLED", "true")
 	t.Setenv("ML_INTERVAL", "1h")
 	t.Setenv("ML_INTERVALS", "1h,4h,invalid,1h")
@@ -163,6 +244,23 @@ func TestLoadWithEnv(t *testing.T) {
 	t.Setenv("ONCHAIN_ETH_BLOCKSCOUT_BASE_URL", "https://eth.custom")
 	t.Setenv("ONCHAIN_ADA_KOIOS_BASE_URL", "https://koios.custom")
 	t.Setenv("ONCHAIN_XRP_API_BASE_URL", "https://xrp.custom")
+	t.Setenv("SIZING_METHOD", "Kelly")
+	t.Setenv("SIZING_RISK_PER_TRADE", "0.02")
+	t.Setenv("SIZING_KELLY_FRACTION", "0.5")
+	t.Setenv("SIZING_KELLY_WIN_RATE", "0.45")
+	t.Setenv("SIZING_MAX_POSITION_PCT", "2")
+	t.Setenv("SIZING_REFERENCE_EQUITY", "0")
+	t.Setenv("ALERT_DELIVERY_WORKERS", "8")
+	t.Setenv("ALERT_GLOBAL_RATE_PER_SEC", "20")
+	t.Setenv("ALERT_CHAT_RATE_PER_SEC", "0.5")
+	t.Setenv("ALERT_MAX_ATTEMPTS", "-1")
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv("SMTP_PORT", "465")
+	t.Setenv("SMTP_USERNAME", "bot@example.com")
+	t.Setenv("SMTP_FROM", "")
+	t.Setenv("TELEGRAM_ADMIN_IDS", "42, bad,-1001")
+	t.Setenv("TELEGRAM_WEBHOOK_URL", "https://bot.example.com")
+	t.Setenv("TELEGRAM_WEBHOOK_SECRET", "s3cret_token-1")
 	t.Setenv("WEB_CONSOLE_ENABLED", "true")
 	t.Setenv("WEB_CONSOLE_COOKIE_SECRET", "console-secret")
 	t.Setenv("WEB_CONSOLE_SESSION_TTL_SECS", "3600")
@@ -176,12 +274,30 @@ func TestLoadWithEnv(t *testing.T) {
 	if cfg.CoinGeckoPollSecs != 120 {
 		t.Fatalf("expected poll secs 120, got %d", cfg.CoinGeckoPollSecs)
 	}
+	if cfg.RunJobs {
+		t.Fatal("expected RUN_JOBS=false to turn background jobs off")
+	}
 	if cfg.MCPTransport != "http" || !cfg.MCPHTTPEnabled || cfg.MCPHTTPBind != "0.0.0.0" || cfg.MCPHTTPPort != 9191 || cfg.MCPAuthToken != "secret" {
 		t.Fatalf("unexpected MCP config: %+v", cfg)
 	}
 	if cfg.MCPRequestTimeoutSecs != 9 || cfg.MCPRateLimitPerMin != 75 {
 		t.Fatalf("unexpected MCP timeout/rate: %+v", cfg)
 	}
+	if cfg.AdvisorMaxToolRounds != 3 || cfg.AdvisorTimeoutSecs != 90 {
+		t.Fatalf("unexpected advisor limits: rounds=%d timeout=%d", cfg.AdvisorMaxToolRounds, cfg.AdvisorTimeoutSecs)
+	}
+	if cfg.AdvisorHistoryTokens != 6000 || cfg.AdvisorContextTokens != 32000 || cfg.AdvisorMemoryFacts || cfg.AdvisorRetentionDays != 30 {
+		t.Fatalf("unexpected advisor memory settings: %+v", cfg)
+	}
+	if cfg.AdvisorVerifyMode != "regenerate" || cfg.AdvisorVerifyTolerancePct != 0.5 {
+		t.Fatalf("unexpected advisor verification: mode=%s tolerance=%v", cfg.AdvisorVerifyMode, cfg.AdvisorVerifyTolerancePct)
+	}
+	if got := cfg.AdvisorLLM(); got.Provider != "anthropic" || got.APIKey != "sk-ant" || got.Model != "claude-3-5-haiku-latest" || !got.Enabled() {
+		t.Fatalf("unexpected advisor LLM: %+v", got)
+	}
+	if got := cfg.SentimentLLM(); got.Provider != "openai_compatible" || got.BaseURL != "http://localhost:11434/v1/" || got.APIKey != "local" || got.Model != "gpt-4o-mini" {
+		t.Fatalf("unexpected sentiment LLM: %+v", got)
+	}
 	if !cfg.MLEnabled || cfg.MLInterval != "1h" || cfg.MLTargetHours != 6 || cfg.MLTrainWindowDays != 30 {
 		t.Fatalf("unexpected ML env values: %+v", cfg)
 	}
@@ -235,11 +351,42 @@ func TestLoadWithEnv(t *testing.T) {
 		cfg.WebConsoleStaticDir != "ui/dist" {
 		t.Fatalf("unexpected web console env values: %+v", cfg)
 	}
+	// Out-of-range max position keeps the default; zero equity turns signal sizing off.
+	if s := cfg.Sizing(); s.Method != "kelly" || s.RiskPerTrade != 0.02 || s.KellyFraction != 0.5 ||
+		s.KellyWinRate != 0.45 || s.MaxPositionPct != 0.25 || s.ReferenceEquity != 0 {
+		t.Fatalf("unexpected sizing env values: %+v", s)
+	}
+	// A negative attempt limit keeps the default.
+	if d := cfg.AlertDelivery(); d.Workers != 8 || d.GlobalPerSec != 20 || d.ChatPerSec != 0.5 || d.MaxAttempts != 8 {
+		t.Fatalf("unexpected alert delivery env values: %+v", d)
+	}
+	// SMTP_FROM defaults to the username.
+	if m := cfg.SMTP(); m.Host != "smtp.example.com" || m.Port != 465 || m.From != "bot@example.com" {
+		t.Fatalf("unexpected smtp env values: %+v", m)
+	}
+	// Invalid admin IDs are skipped.
+	if !reflect.DeepEqual(cfg.TelegramAdminIDs, []int64{42, -1001}) {
+		t.Fatalf("unexpected telegram admins: %v", cfg.TelegramAdminIDs)
+	}
+	if cfg.TelegramWebhookURL != "https://bot.example.com" || cfg.TelegramWebhookSecret != "s3cret_token-1" {
+		t.Fatalf("unexpected telegram webhook: %q %q", cfg.TelegramWebhookURL, cfg.TelegramWebhookSecret)
+	}
 
 	t.Setenv("COINGECKO_POLL_SECS", "bad")
 	t.Setenv("MCP_HTTP_PORT", "bad")
 	t.Setenv("MCP_REQUEST_TIMEOUT_SECS", "bad")
 	t.Setenv("MCP_RATE_LIMIT_PER_MIN", "bad")
+	t.Setenv("ADVISOR_MAX_TOOL_ROUNDS", "0")
+	t.Setenv("ADVISOR_TIMEOUT_SECS", "bad")
+	t.Setenv("ADVISOR_HISTORY_TOKENS", "0")
+	t.Setenv("ADVISOR_CONTEXT_TOKENS", "-5")
+	t.Setenv("ADVISOR_MEMORY_FACTS", "maybe")
+	t.Setenv("RUN_JOBS", "sometimes")
+	t.Setenv("ADVISOR_RETENTION_DAYS", "bad")
+	t.Setenv("ADVISOR_VERIFY_MODE", "strict")
+	t.Setenv("ADVISOR_VERIFY_TOLERANCE_PCT", "-1")
+	t.Setenv("LLM_PROVIDER", "gemini")
+	t.Setenv("ADVISOR_LLM_PROVIDER", "bad")
 	t.Setenv("ML_TARGET_HOURS", "bad")
 	t.Setenv("ML_TRAIN_WINDOW_DAYS", "bad")
 	t.Setenv("ML_INFER_POLL_SECS", "bad")
@@ -272,9 +419,24 @@ func TestLoadWithEnv(t *testing.T) {
 	if cfg.CoinGeckoPollSecs != 60 {
 		t.Fatalf("invalid poll secs should fall back to default, got %d", cfg.CoinGeckoPollSecs)
 	}
+	if !cfg.RunJobs {
+		t.Fatal("invalid RUN_JOBS should fall back to running jobs")
+	}
 	if cfg.MCPHTTPPort != 8090 || cfg.MCPRequestTimeoutSecs != 5 || cfg.MCPRateLimitPerMin != 60 {
 		t.Fatalf("invalid MCP numeric values should fall back to defaults: %+v", cfg)
 	}
+	if cfg.AdvisorMaxToolRounds != 5 || cfg.AdvisorTimeoutSecs != 60 {
+		t.Fatalf("invalid advisor limits should fall back to defaults: rounds=%d timeout=%d", cfg.AdvisorMaxToolRounds, cfg.AdvisorTimeoutSecs)
+	}
+	if cfg.AdvisorHistoryTokens != 4000 || cfg.AdvisorContextTokens != 0 || !cfg.AdvisorMemoryFacts || cfg.AdvisorRetentionDays != 90 {
+		t.Fatalf("invalid advisor memory settings should fall back to defaults: %+v", cfg)
+	}
+	if cfg.AdvisorVerifyMode != "annotate" || cfg.AdvisorVerifyTolerancePct != 2 {
+		t.Fatalf("invalid advisor verification should fall back to defaults: mode=%s tolerance=%v", cfg.AdvisorVerifyMode, cfg.AdvisorVerifyTolerancePct)
+	}
+	if cfg.LLMProvider != "openai" || cfg.AdvisorLLMProvider != "openai" {
+		t.Fatalf("invalid LLM providers should fall back to openai: %q %q", cfg.LLMProvider, cfg.AdvisorLLMProvider)
+	}
 	if cfg.MLTargetHours != 4 || cfg.MLTrainWindowDays != 90 || cfg.MLInferPollSecs != 900 || cfg.MLResolvePollSecs != 1800 {
 		t.Fatalf("invalid ML numeric values should fall back to defaults: %+v", cfg)
 	}
@@ -306,3 +468,17 @@ func TestLoadWithEnv(t *testing.T) {
 		t.Fatalf("invalid web console values should fall back to defaults: %+v", cfg)
 	}
 }
+
+func TestLoadRejectsUnsafeTelegramWebhook(t *testing.T) {
+	t.Setenv("TELEGRAM_WEBHOOK_URL", "https://bot.example.com")
+	t.Setenv("TELEGRAM_WEBHOOK_SECRET", "has spaces")
+	if cfg := Load(); cfg.TelegramWebhookURL != "" {
+		t.Fatalf("expected an invalid secret to fall back to long polling, got %q", cfg.TelegramWebhookURL)
+	}
+
+	t.Setenv("TELEGRAM_WEBHOOK_URL", "http://bot.example.com")
+	t.Setenv("TELEGRAM_WEBHOOK_SECRET", "ok")
+	if cfg := Load(); cfg.TelegramWebhookURL != "" {
+		t.Fatalf("expected a plain http URL to fall back to long polling, got %q", cfg.TelegramWebhookURL)
+	}
+}
@@ -0,0 +1,153 @@
+// Package delivery drains the alert outbox: a pool of workers sends queued
+// messages within global and per-chat rate limits, retries failures with
+// exponential backoff and moves messages that cannot be delivered to the
+// dead letters.
+package delivery
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+// Queue is the outbox the worker drains.
+type Queue interface {
+	Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.AlertDelivery, error)
+	MarkSent(ctx context.Context, id int64, at time.Time) error
+	MarkRetry(ctx context.Context, id int64, next time.Time, lastErr string) error
+	MarkDead(ctx context.Context, id int64, lastErr string) error
+	PurgeSent(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// Sender delivers one message. Errors wrapped with RetryAfter or Permanent
+// steer how the worker retries.
+type Sender interface {
+	Deliver(ctx context.Context, d domain.AlertDelivery) error
+}
+
+// Config tunes the worker pool. Telegram allows about 30 messages a second
+// per bot and one a second per chat.
+type Config struct {
+	Workers      int
+	GlobalPerSec float64
+	ChatPerSec   float64
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	PollInterval time.Duration
+	// Lease is how long a claimed message is held before another worker may
+	// claim it again. It must outlast the rate-limit wait of a full batch.
+	Lease     time.Duration
+	BatchSize int
+	// Retention is how long sent messages are kept for inspection.
+	Retention time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Workers:      4,
+		GlobalPerSec: 25,
+		ChatPerSec:   1,
+		MaxAttempts:  8,
+		BaseBackoff:  2 * time.Second,
+		MaxBackoff:   10 * time.Minute,
+		PollInterval: time.Second,
+		Lease:        2 * time.Minute,
+		BatchSize:    50,
+		Retention:    7 * 24 * time.Hour,
+	}
+}
+
+// withDefaults fills unset fields from DefaultConfig.
+func (c Config) withDefaults() Config {
+	def := DefaultConfig()
+	if c.Workers <= 0 {
+		c.Workers = def.Workers
+	}
+	if c.GlobalPerSec <= 0 {
+		c.GlobalPerSec = def.GlobalPerSec
+	}
+	if c.ChatPerSec <= 0 {
+		c.ChatPerSec = def.ChatPerSec
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = def.MaxAttempts
+	}
+	if c.BaseBackoff <= 0 {
+		c.BaseBackoff = def.BaseBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = def.MaxBackoff
+	}
+	if c.PollInterval <= 0 {
+		c.PollInterval = def.PollInterval
+	}
+	if c.Lease <= 0 {
+		c.Lease = def.Lease
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = def.BatchSize
+	}
+	if c.Retention <= 0 {
+		c.Retention = def.Retention
+	}
+	return c
+}
+
+// Backoff returns the wait before retry number attempt (1-based): base
+// doubled per attempt, capped at max.
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	wait := base
+	for i := 1; i < attempt && wait < max; i++ {
+		wait *= 2
+	}
+	return min(wait, max)
+}
+
+type retryAfterError struct {
+	err   error
+	after time.Duration
+}
+
+func (e *retryAfterError) Error() string { return e.err.Error() }
+func (e *retryAfterError) Unwrap() error { return e.err }
+
+// RetryAfter marks err as rate limited: the message is retried no sooner
+// than after, and the chat is paused for as long.
+func RetryAfter(err error, after time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &retryAfterError{err: err, after: after}
+}
+
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as one retrying cannot fix, such as a user who
+// blocked the bot. The message goes straight to the dead letters.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// RetryAfterOf returns the wait attached to err by RetryAfter.
+func RetryAfterOf(err error) (time.Duration, bool) {
+	var ra *retryAfterError
+	if errors.As(err, &ra) {
+		return ra.after, true
+	}
+	return 0, false
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
@@ -0,0 +1,55 @@
+package delivery
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBackoffDoublesUpToMax(t *testing.T) {
+	cases := map[int]time.Duration{
+		1:  2 * time.Second,
+		2:  4 * time.Second,
+		4:  16 * time.Second,
+		10: time.Minute,
+	}
+	for attempt, want := range cases {
+		if got := Backoff(attempt, 2*time.Second, time.Minute); got != want {
+			t.Fatalf("attempt %d: expected %s, got %s", attempt, want, got)
+		}
+	}
+}
+
+func TestErrorClassification(t *testing.T) {
+	base := errors.New("boom")
+
+	if RetryAfter(nil, time.Second) != nil || Permanent(nil) != nil {
+		t.Fatal("expected nil errors to stay nil")
+	}
+
+	wrapped := fmt.Errorf("send: %w", RetryAfter(base, 7*time.Second))
+	if after, ok := RetryAfterOf(wrapped); !ok || after != 7*time.Second {
+		t.Fatalf("expected retry after 7s, got %s ok=%v", after, ok)
+	}
+	if !errors.Is(wrapped, base) {
+		t.Fatal("expected wrapped error to unwrap to its cause")
+	}
+	if IsPermanent(wrapped) {
+		t.Fatal("expected rate limit not to be permanent")
+	}
+
+	if !IsPermanent(fmt.Errorf("send: %w", Permanent(base))) {
+		t.Fatal("expected permanent error")
+	}
+	if _, ok := RetryAfterOf(base); ok || IsPermanent(base) {
+		t.Fatal("expected plain error to be retried with backoff")
+	}
+}
+
+func TestConfigDefaults(t *testing.T) {
+	cfg := Config{Workers: 2}.withDefaults()
+	if cfg.Workers != 2 || cfg.GlobalPerSec != 25 || cfg.ChatPerSec != 1 || cfg.Lease != 2*time.Minute {
+		t.Fatalf("unexpected defaults: %+v", cfg)
+	}
+}
@@ -0,0 +1,84 @@
+package delivery
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// maxTrackedChats bounds the per-chat schedule; past it, chats whose next
+// slot has already passed are forgotten.
+const maxTrackedChats = 10000
+
+// limiter spaces sends evenly: at most perSec globally and chatPerSec per
+// chat. Each send books the next free slot, so waiting workers queue up in
+// order instead of retrying.
+type limiter struct {
+	mu          sync.Mutex
+	now         func() time.Time
+	globalGap   time.Duration
+	chatGap     time.Duration
+	nextGlobal  time.Time
+	nextForChat map[int64]time.Time
+}
+
+func newLimiter(perSec, chatPerSec float64, now func() time.Time) *limiter {
+	return &limiter{
+		now:         now,
+		globalGap:   time.Duration(float64(time.Second) / perSec),
+		chatGap:     time.Duration(float64(time.Second) / chatPerSec),
+		nextForChat: make(map[int64]time.Time),
+	}
+}
+
+// reserve books a send slot for chatID and returns when it starts.
+func (l *limiter) reserve(chatID int64) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	at := now
+	if l.nextGlobal.After(at) {
+		at = l.nextGlobal
+	}
+	if next := l.nextForChat[chatID]; next.After(at) {
+		at = next
+	}
+	l.nextGlobal = at.Add(l.globalGap)
+	l.nextForChat[chatID] = at.Add(l.chatGap)
+
+	if len(l.nextForChat) > maxTrackedChats {
+		for id, next := range l.nextForChat {
+			if !next.After(now) {
+				delete(l.nextForChat, id)
+			}
+		}
+	}
+	return at
+}
+
+// pause holds back sends to chatID until until.
+func (l *limiter) pause(chatID int64, until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until.After(l.nextForChat[chatID]) {
+		l.nextForChat[chatID] = until
+	}
+}
+
+// wait blocks until chatID's next slot or until ctx is done.
+func (l *limiter) wait(ctx context.Context, chatID int64) error {
+	delay := l.reserve(chatID).Sub(l.now())
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,46 @@
+package delivery
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimiterSpacesSends(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	l := newLimiter(10, 1, func() time.Time { return now })
+
+	if got := l.reserve(1); !got.Equal(now) {
+		t.Fatalf("expected first send immediately, got %s", got)
+	}
+	if got := l.reserve(2); !got.Equal(now.Add(100 * time.Millisecond)) {
+		t.Fatalf("expected global spacing for another chat, got %s", got.Sub(now))
+	}
+	if got := l.reserve(1); !got.Equal(now.Add(time.Second)) {
+		t.Fatalf("expected per-chat spacing, got %s", got.Sub(now))
+	}
+}
+
+func TestLimiterPause(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	l := newLimiter(10, 1, func() time.Time { return now })
+
+	l.pause(1, now.Add(30*time.Second))
+	if got := l.reserve(1); !got.Equal(now.Add(30 * time.Second)) {
+		t.Fatalf("expected paused chat to wait 30s, got %s", got.Sub(now))
+	}
+	if got := l.reserve(2); got.Sub(now) > time.Minute {
+		t.Fatalf("expected other chats to keep going, got %s", got.Sub(now))
+	}
+}
+
+func TestLimiterWaitHonoursContext(t *testing.T) {
+	l := newLimiter(1000, 0.001, time.Now)
+	_ = l.reserve(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.wait(ctx, 1); err == nil {
+		t.Fatal("expected cancelled wait to fail")
+	}
+}
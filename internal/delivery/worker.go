@@ -0,0 +1,152 @@
+package delivery
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const purgeInterval = time.Hour
+
+// Worker drains the outbox with a pool of senders.
+type Worker struct {
+	tracer  trace.Tracer
+	queue   Queue
+	sender  Sender
+	cfg     Config
+	now     func() time.Time
+	limiter *limiter
+}
+
+func NewWorker(tracer trace.Tracer, queue Queue, sender Sender, cfg Config) *Worker {
+	cfg = cfg.withDefaults()
+	return &Worker{
+		tracer:  tracer,
+		queue:   queue,
+		sender:  sender,
+		cfg:     cfg,
+		now:     time.Now,
+		limiter: newLimiter(cfg.GlobalPerSec, cfg.ChatPerSec, time.Now),
+	}
+}
+
+// Start runs the worker pool. Blocks until ctx is cancelled, then waits for
+// in-flight sends to finish.
+func (w *Worker) Start(ctx context.Context) {
+	if w.queue == nil || w.sender == nil {
+		log.Println("Alert delivery worker disabled: no queue or sender")
+		<-ctx.Done()
+		return
+	}
+
+	log.Printf("Alert delivery worker starting with %d senders...", w.cfg.Workers)
+	jobs := make(chan domain.AlertDelivery)
+	var wg sync.WaitGroup
+	for range w.cfg.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for d := range jobs {
+				w.deliver(ctx, d)
+			}
+		}()
+	}
+
+	poll := time.NewTicker(w.cfg.PollInterval)
+	defer poll.Stop()
+	purge := time.NewTicker(purgeInterval)
+	defer purge.Stop()
+
+	for {
+		w.dispatchDue(ctx, jobs)
+		select {
+		case <-ctx.Done():
+			close(jobs)
+			wg.Wait()
+			log.Println("Alert delivery worker stopped")
+			return
+		case <-purge.C:
+			w.purgeSent(ctx)
+		case <-poll.C:
+		}
+	}
+}
+
+// dispatchDue claims due messages and hands them to the senders. A full
+// batch is followed by another claim, so a backlog drains without waiting
+// for the next poll.
+func (w *Worker) dispatchDue(ctx context.Context, jobs chan<- domain.AlertDelivery) {
+	for ctx.Err() == nil {
+		batch, err := w.queue.Claim(ctx, w.now(), w.cfg.Lease, w.cfg.BatchSize)
+		if err != nil {
+			log.Printf("alert delivery claim error: %v", err)
+			return
+		}
+		for _, d := range batch {
+			select {
+			case jobs <- d:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if len(batch) < w.cfg.BatchSize {
+			return
+		}
+	}
+}
+
+// deliver sends one claimed message and records the outcome. If ctx ends
+// while waiting for a send slot, the message is left claimed and is retried
+// once its lease runs out.
+func (w *Worker) deliver(ctx context.Context, d domain.AlertDelivery) {
+	ctx, span := w.tracer.Start(ctx, "alert-delivery.deliver")
+	defer span.End()
+
+	if err := w.limiter.wait(ctx, d.ChatID); err != nil {
+		return
+	}
+	sendErr := w.sender.Deliver(ctx, d)
+
+	// The send happened; record it even if we are shutting down.
+	ctx = context.WithoutCancel(ctx)
+	now := w.now()
+	if sendErr == nil {
+		if err := w.queue.MarkSent(ctx, d.ID, now); err != nil {
+			log.Printf("alert delivery %d: failed to mark sent: %v", d.ID, err)
+		}
+		return
+	}
+
+	if IsPermanent(sendErr) || d.Attempts >= w.cfg.MaxAttempts {
+		log.Printf("alert delivery %d to chat %d dead after %d attempts: %v", d.ID, d.ChatID, d.Attempts, sendErr)
+		if err := w.queue.MarkDead(ctx, d.ID, sendErr.Error()); err != nil {
+			log.Printf("alert delivery %d: failed to mark dead: %v", d.ID, err)
+		}
+		return
+	}
+
+	wait := Backoff(d.Attempts, w.cfg.BaseBackoff, w.cfg.MaxBackoff)
+	if after, ok := RetryAfterOf(sendErr); ok && after > 0 {
+		wait = after
+		w.limiter.pause(d.ChatID, now.Add(after))
+	}
+	if err := w.queue.MarkRetry(ctx, d.ID, now.Add(wait), sendErr.Error()); err != nil {
+		log.Printf("alert delivery %d: failed to schedule retry: %v", d.ID, err)
+	}
+}
+
+func (w *Worker) purgeSent(ctx context.Context) {
+	n, err := w.queue.PurgeSent(ctx, w.now().Add(-w.cfg.Retention))
+	if err != nil {
+		log.Printf("alert delivery purge error: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("Purged %d delivered alerts", n)
+	}
+}
@@ -0,0 +1,180 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestWorkerRecordsOutcomes(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	queue := &fakeQueue{}
+	sender := &fakeSender{errs: map[int64]error{
+		2: errors.New("timeout"),
+		3: RetryAfter(errors.New("flood"), 30*time.Second),
+		4: Permanent(errors.New("blocked")),
+		5: errors.New("timeout"),
+	}}
+	w := newTestWorker(queue, sender, now)
+
+	for _, d := range []domain.AlertDelivery{
+		{ID: 1, ChatID: 10, Attempts: 1},
+		{ID: 2, ChatID: 20, Attempts: 3},
+		{ID: 3, ChatID: 30, Attempts: 1},
+		{ID: 4, ChatID: 40, Attempts: 1},
+		{ID: 5, ChatID: 50, Attempts: 8},
+	} {
+		w.deliver(context.Background(), d)
+	}
+
+	if queue.status[1] != domain.DeliverySent {
+		t.Fatalf("expected 1 sent, got %q", queue.status[1])
+	}
+	if queue.status[2] != domain.DeliveryPending || !queue.next[2].Equal(now.Add(8*time.Second)) || queue.lastErr[2] != "timeout" {
+		t.Fatalf("expected 2 retried after 8s backoff, got %q at %s", queue.status[2], queue.next[2].Sub(now))
+	}
+	if queue.status[3] != domain.DeliveryPending || !queue.next[3].Equal(now.Add(30*time.Second)) {
+		t.Fatalf("expected 3 retried after retry_after, got %q at %s", queue.status[3], queue.next[3].Sub(now))
+	}
+	if got := w.limiter.reserve(30); !got.Equal(now.Add(30 * time.Second)) {
+		t.Fatalf("expected rate-limited chat to be paused, got %s", got.Sub(now))
+	}
+	if queue.status[4] != domain.DeliveryDead || queue.lastErr[4] != "blocked" {
+		t.Fatalf("expected permanent failure to be dead, got %q", queue.status[4])
+	}
+	if queue.status[5] != domain.DeliveryDead {
+		t.Fatalf("expected exhausted retries to be dead, got %q", queue.status[5])
+	}
+}
+
+func TestWorkerStartDrainsQueue(t *testing.T) {
+	queue := &fakeQueue{pending: []domain.AlertDelivery{
+		{ID: 1, ChatID: 10}, {ID: 2, ChatID: 20}, {ID: 3, ChatID: 30},
+	}}
+	sender := &fakeSender{}
+	w := NewWorker(trace.NewNoopTracerProvider().Tracer("test"), queue, sender, Config{
+		Workers: 2, GlobalPerSec: 1000, BatchSize: 2, PollInterval: time.Millisecond,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.Start(ctx)
+		close(done)
+	}()
+
+	deadline := time.After(2 * time.Second)
+	for queue.sentCount() < 3 {
+		select {
+		case <-deadline:
+			t.Fatalf("expected all messages sent, got %d", queue.sentCount())
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	cancel()
+	<-done
+	if got := sender.count(); got != 3 {
+		t.Fatalf("expected 3 sends, got %d", got)
+	}
+}
+
+func newTestWorker(queue *fakeQueue, sender *fakeSender, now time.Time) *Worker {
+	w := NewWorker(trace.NewNoopTracerProvider().Tracer("test"), queue, sender, Config{})
+	clock := func() time.Time { return now }
+	w.now = clock
+	w.limiter = newLimiter(1000, 1000, clock)
+	return w
+}
+
+type fakeQueue struct {
+	mu      sync.Mutex
+	pending []domain.AlertDelivery
+	status  map[int64]domain.AlertDeliveryStatus
+	next    map[int64]time.Time
+	lastErr map[int64]string
+}
+
+func (q *fakeQueue) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.AlertDelivery, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	n := min(limit, len(q.pending))
+	batch := q.pending[:n]
+	q.pending = q.pending[n:]
+	for i := range batch {
+		batch[i].Attempts++
+	}
+	return batch, nil
+}
+
+func (q *fakeQueue) set(id int64, status domain.AlertDeliveryStatus, next time.Time, lastErr string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.status == nil {
+		q.status = make(map[int64]domain.AlertDeliveryStatus)
+		q.next = make(map[int64]time.Time)
+		q.lastErr = make(map[int64]string)
+	}
+	q.status[id] = status
+	q.next[id] = next
+	q.lastErr[id] = lastErr
+}
+
+func (q *fakeQueue) MarkSent(ctx context.Context, id int64, at time.Time) error {
+	q.set(id, domain.DeliverySent, time.Time{}, "")
+	return nil
+}
+
+func (q *fakeQueue) MarkRetry(ctx context.Context, id int64, next time.Time, lastErr string) error {
+	q.set(id, domain.DeliveryPending, next, lastErr)
+	return nil
+}
+
+func (q *fakeQueue) MarkDead(ctx context.Context, id int64, lastErr string) error {
+	q.set(id, domain.DeliveryDead, time.Time{}, lastErr)
+	return nil
+}
+
+func (q *fakeQueue) PurgeSent(ctx context.Context, cutoff time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (q *fakeQueue) sentCount() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	n := 0
+	for _, s := range q.status {
+		if s == domain.DeliverySent {
+			n++
+		}
+	}
+	return n
+}
+
+type fakeSender struct {
+	mu   sync.Mutex
+	errs map[int64]error
+	sent []int64
+}
+
+func (s *fakeSender) Deliver(ctx context.Context, d domain.AlertDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sent = append(s.sent, d.ID)
+	return s.errs[d.ID]
+}
+
+func (s *fakeSender) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sent)
+}
@@ -0,0 +1,67 @@
+package domain
+
+import "time"
+
+// UserRole is what a Telegram chat may do with the bot. Each role includes
+// the ones below it.
+type UserRole string
+
+const (
+	RoleViewer UserRole = "viewer"
+	RoleTrader UserRole = "trader"
+	RoleAdmin  UserRole = "admin"
+)
+
+func (r UserRole) IsValid() bool {
+	return r.Rank() > 0
+}
+
+// Rank orders roles; unknown roles rank 0.
+func (r UserRole) Rank() int {
+	switch r {
+	case RoleViewer:
+		return 1
+	case RoleTrader:
+		return 2
+	case RoleAdmin:
+		return 3
+	}
+	return 0
+}
+
+// Allows reports whether r grants at least the required role.
+func (r UserRole) Allows(required UserRole) bool {
+	return r.Rank() > 0 && r.Rank() >= required.Rank()
+}
+
+// TelegramUser is a chat allowed to use the bot. Group chats have negative
+// IDs and grant their role to every member.
+type TelegramUser struct {
+	ChatID    int64     `json:"chat_id"`
+	Username  string    `json:"username,omitempty"`
+	Role      UserRole  `json:"role"`
+	InvitedBy int64     `json:"invited_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TelegramInvite is a single-use code that grants Role to whoever redeems
+// it before ExpiresAt.
+type TelegramInvite struct {
+	Code      string     `json:"code"`
+	Role      UserRole   `json:"role"`
+	CreatedBy int64      `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedBy    *int64     `json:"used_by,omitempty"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+}
+
+// JobStatus is the run history of a background job since startup.
+type JobStatus struct {
+	Name         string        `json:"name"`
+	Runs         int           `json:"runs"`
+	Failures     int           `json:"failures"`
+	LastRun      time.Time     `json:"last_run"`
+	LastDuration time.Duration `json:"last_duration"`
+	LastError    string        `json:"last_error,omitempty"`
+}
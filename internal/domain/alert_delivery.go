@@ -0,0 +1,46 @@
+package domain
+
+import "time"
+
+// AlertDeliveryStatus is where a queued alert message is in its delivery.
+type AlertDeliveryStatus string
+
+const (
+	// DeliveryPending waits for its next attempt.
+	DeliveryPending AlertDeliveryStatus = "pending"
+	// DeliverySending has been claimed by a worker. If the worker dies, the
+	// claim lapses and the message is picked up again.
+	DeliverySending AlertDeliveryStatus = "sending"
+	DeliverySent    AlertDeliveryStatus = "sent"
+	// DeliveryDead ran out of attempts or failed permanently, e.g. because
+	// the user blocked the bot.
+	DeliveryDead AlertDeliveryStatus = "dead"
+)
+
+// AlertDeliveryKind records what produced a queued message.
+type AlertDeliveryKind string
+
+const (
+	DeliveryKindSignal     AlertDeliveryKind = "signal"
+	DeliveryKindPriceAlert AlertDeliveryKind = "price_alert"
+	DeliveryKindDigest     AlertDeliveryKind = "digest"
+	DeliveryKindBroadcast  AlertDeliveryKind = "broadcast"
+)
+
+// AlertDelivery is one message in the alert outbox. Signal alerts carry
+// SignalID so the chart image can be attached when the message is sent;
+// digests carry their chart in Image.
+type AlertDelivery struct {
+	ID            int64               `json:"id"`
+	ChatID        int64               `json:"chat_id"`
+	Kind          AlertDeliveryKind   `json:"kind"`
+	SignalID      int64               `json:"signal_id,omitempty"`
+	Text          string              `json:"text"`
+	Image         []byte              `json:"-"`
+	Status        AlertDeliveryStatus `json:"status"`
+	Attempts      int                 `json:"attempts"`
+	NextAttemptAt time.Time           `json:"next_attempt_at"`
+	LastError     string              `json:"last_error,omitempty"`
+	CreatedAt     time.Time           `json:"created_at"`
+	SentAt        *time.Time          `json:"sent_at,omitempty"`
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"slices"
+	"time"
+
+	// The runtime image ships without zoneinfo; subscriptions need it for
+	// quiet hours in the user's timezone.
+	_ "time/tzdata"
+)
+
+// AlertSubscription is a chat's opt-in to proactive signal alerts and the
+// filters applied before anything is sent. Empty lists and zero values match
+// everything, so a fresh subscription receives every signal. Turning alerts
+// off keeps the filters for the next time they are turned on.
+type AlertSubscription struct {
+	ChatID     int64           `json:"chat_id"`
+	Enabled    bool            `json:"enabled"`
+	Symbols    []string        `json:"symbols,omitempty"`
+	Intervals  []string        `json:"intervals,omitempty"`
+	Indicators []string        `json:"indicators,omitempty"`
+	MinRisk    RiskLevel       `json:"min_risk,omitempty"`
+	MaxRisk    RiskLevel       `json:"max_risk,omitempty"`
+	Direction  SignalDirection `json:"direction,omitempty"`
+	// QuietStart and QuietEnd are minutes after local midnight. Alerts are
+	// dropped from QuietStart up to QuietEnd, wrapping past midnight when
+	// QuietEnd is earlier; equal values mean no quiet hours.
+	QuietStart int       `json:"quiet_start"`
+	QuietEnd   int       `json:"quiet_end"`
+	Timezone   string    `json:"timezone"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Matches reports whether a signal passes the subscription's filters.
+func (s AlertSubscription) Matches(sig Signal) bool {
+	if len(s.Symbols) > 0 && !slices.Contains(s.Symbols, sig.Symbol) {
+		return false
+	}
+	if len(s.Intervals) > 0 && !slices.Contains(s.Intervals, sig.Interval) {
+		return false
+	}
+	if len(s.Indicators) > 0 && !slices.Contains(s.Indicators, sig.Indicator) {
+		return false
+	}
+	if s.MinRisk > 0 && sig.Risk < s.MinRisk {
+		return false
+	}
+	if s.MaxRisk > 0 && sig.Risk > s.MaxRisk {
+		return false
+	}
+	if s.Direction != "" && sig.Direction != s.Direction {
+		return false
+	}
+	return true
+}
+
+// HasQuietHours reports whether the subscription silences part of the day.
+func (s AlertSubscription) HasQuietHours() bool {
+	return s.QuietStart != s.QuietEnd
+}
+
+// InQuietHours reports whether at falls in the subscription's quiet hours,
+// read in its timezone.
+func (s AlertSubscription) InQuietHours(at time.Time) bool {
+	if !s.HasQuietHours() {
+		return false
+	}
+	local := at.In(s.Location())
+	now := local.Hour()*60 + local.Minute()
+	if s.QuietStart < s.QuietEnd {
+		return now >= s.QuietStart && now < s.QuietEnd
+	}
+	return now >= s.QuietStart || now < s.QuietEnd
+}
+
+// Location returns the subscription's timezone, UTC when unset or unknown.
+func (s AlertSubscription) Location() *time.Location {
+	if s.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
@@ -0,0 +1,112 @@
+package domain
+
+import "time"
+
+// BacktestConfig describes one strategy simulation over stored candles.
+type BacktestConfig struct {
+	Symbol        string        `json:"symbol"`
+	Interval      string        `json:"interval"`
+	From          time.Time     `json:"from"`
+	To            time.Time     `json:"to"`
+	Indicators    []string      `json:"indicators,omitempty"`
+	MaxRisk       RiskLevel     `json:"max_risk,omitempty"`
+	FeeBps        float64       `json:"fee_bps"`
+	SlippageBps   float64       `json:"slippage_bps"`
+	HoldBars      int           `json:"hold_bars"`
+	UseStops      bool          `json:"use_stops"`
+	AllowShort    bool          `json:"allow_short"`
+	InitialEquity float64       `json:"initial_equity"`
+	Params        *SignalParams `json:"params,omitempty"`
+}
+
+const (
+	ExitReasonStop   = "stop"
+	ExitReasonTarget = "target"
+	ExitReasonTime   = "time"
+	ExitReasonEnd    = "end"
+)
+
+// BacktestTrade is one simulated round trip.
+type BacktestTrade struct {
+	Indicator  string          `json:"indicator"`
+	Direction  SignalDirection `json:"direction"`
+	Risk       RiskLevel       `json:"risk"`
+	EntryTime  time.Time       `json:"entry_time"`
+	ExitTime   time.Time       `json:"exit_time"`
+	EntryPrice float64         `json:"entry_price"`
+	ExitPrice  float64         `json:"exit_price"`
+	Stop       float64         `json:"stop,omitempty"`
+	Target     float64         `json:"target,omitempty"`
+	ExitReason string          `json:"exit_reason"`
+	Bars       int             `json:"bars"`
+	ReturnPct  float64         `json:"return_pct"`
+	PnL        float64         `json:"pnl"`
+	Fees       float64         `json:"fees"`
+}
+
+// EquityPoint is the marked-to-market account value at a candle close.
+type EquityPoint struct {
+	Time   time.Time `json:"time"`
+	Equity float64   `json:"equity"`
+}
+
+// BacktestMetrics summarises a simulation. Returns and drawdown are
+// fractions (0.05 = 5%); Sharpe and Sortino are annualised.
+type BacktestMetrics struct {
+	Trades         int     `json:"trades"`
+	Wins           int     `json:"wins"`
+	Losses         int     `json:"losses"`
+	WinRate        float64 `json:"win_rate"`
+	ProfitFactor   float64 `json:"profit_factor"`
+	TotalReturn    float64 `json:"total_return"`
+	AvgTradeReturn float64 `json:"avg_trade_return"`
+	Sharpe         float64 `json:"sharpe"`
+	Sortino        float64 `json:"sortino"`
+	MaxDrawdown    float64 `json:"max_drawdown"`
+	FinalEquity    float64 `json:"final_equity"`
+}
+
+type BacktestResult struct {
+	RunID         int64           `json:"run_id,omitempty"`
+	EngineVersion string          `json:"engine_version,omitempty"`
+	Config        BacktestConfig  `json:"config"`
+	Metrics       BacktestMetrics `json:"metrics"`
+	Trades        []BacktestTrade `json:"trades"`
+	Equity        []EquityPoint   `json:"equity"`
+}
+
+// BacktestRun is a persisted simulation: the exact parameters and engine
+// version that produced it plus its outcome. Listings leave Equity and
+// Trades empty; they are loaded when a single run is fetched.
+type BacktestRun struct {
+	ID            int64           `json:"id"`
+	EngineVersion string          `json:"engine_version"`
+	Config        BacktestConfig  `json:"config"`
+	Metrics       BacktestMetrics `json:"metrics"`
+	Equity        []EquityPoint   `json:"equity,omitempty"`
+	Trades        []BacktestTrade `json:"trades,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// MetricDiff compares one metric across two runs; Delta is B minus A.
+type MetricDiff struct {
+	Metric string  `json:"metric"`
+	A      float64 `json:"a"`
+	B      float64 `json:"b"`
+	Delta  float64 `json:"delta"`
+}
+
+// ParamDiff is a configuration field whose value differs between two runs.
+type ParamDiff struct {
+	Param string `json:"param"`
+	A     string `json:"a"`
+	B     string `json:"b"`
+}
+
+// BacktestRunComparison lines up two runs metric by metric.
+type BacktestRunComparison struct {
+	A       BacktestRun  `json:"a"`
+	B       BacktestRun  `json:"b"`
+	Metrics []MetricDiff `json:"metrics"`
+	Params  []ParamDiff  `json:"params"`
+}
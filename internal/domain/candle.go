@@ -55,3 +55,22 @@ var SupportedSymbols = []string{
 
 // SupportedIntervals defines the candle intervals we store.
 var SupportedIntervals = []string{"5m", "15m", "1h", "4h", "1d"}
+
+// IntervalDuration returns the wall-clock length of a supported candle
+// interval, or 0 for unknown intervals.
+func IntervalDuration(interval string) time.Duration {
+	switch interval {
+	case "5m":
+		return 5 * time.Minute
+	case "15m":
+		return 15 * time.Minute
+	case "1h":
+		return time.Hour
+	case "4h":
+		return 4 * time.Hour
+	case "1d":
+		return 24 * time.Hour
+	default:
+		return 0
+	}
+}
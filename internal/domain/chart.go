@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Chart overlays are drawn over the candles.
+const (
+	ChartOverlayEMA       = "ema"
+	ChartOverlayBollinger = "bollinger"
+	ChartOverlayVWAP      = "vwap"
+)
+
+// Chart panes are drawn in their own panel below the candles.
+const (
+	ChartPaneRSI    = "rsi"
+	ChartPaneMACD   = "macd"
+	ChartPaneVolume = "volume"
+)
+
+// ChartOverlays and ChartPanes list the supported options in drawing order.
+var (
+	ChartOverlays = []string{ChartOverlayEMA, ChartOverlayBollinger, ChartOverlayVWAP}
+	ChartPanes    = []string{ChartPaneRSI, ChartPaneMACD, ChartPaneVolume}
+)
+
+// ChartRequest selects what an on-demand chart shows. Candles is the
+// lookback as a number of candles of Interval.
+type ChartRequest struct {
+	Symbol   string   `json:"symbol"`
+	Interval string   `json:"interval"`
+	Candles  int      `json:"candles"`
+	Overlays []string `json:"overlays,omitempty"`
+	Panes    []string `json:"panes,omitempty"`
+}
+
+// ChartLookbackCandles converts a lookback into a candle count for interval.
+// lookback is either a count such as "200" or a duration such as "48h",
+// "7d" or "2w". An empty lookback returns 0, which selects the default. ok is
+// false when lookback cannot be read.
+func ChartLookbackCandles(interval, lookback string) (n int, ok bool) {
+	lookback = strings.ToLower(strings.TrimSpace(lookback))
+	if lookback == "" {
+		return 0, true
+	}
+	if n, err := strconv.Atoi(lookback); err == nil {
+		return n, n > 0
+	}
+	step := IntervalDuration(strings.ToLower(strings.TrimSpace(interval)))
+	if interval == "" {
+		step = time.Hour
+	}
+	unit := map[byte]time.Duration{'m': time.Minute, 'h': time.Hour, 'd': 24 * time.Hour, 'w': 7 * 24 * time.Hour}[lookback[len(lookback)-1]]
+	count, err := strconv.Atoi(lookback[:len(lookback)-1])
+	if step <= 0 || unit == 0 || err != nil || count <= 0 {
+		return 0, false
+	}
+	return int(time.Duration(count) * unit / step), true
+}
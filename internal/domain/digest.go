@@ -0,0 +1,110 @@
+package domain
+
+import "time"
+
+// DigestPeriod is how often a market digest is sent and how far back it
+// looks.
+type DigestPeriod string
+
+const (
+	DigestDaily  DigestPeriod = "daily"
+	DigestWeekly DigestPeriod = "weekly"
+)
+
+func (p DigestPeriod) IsValid() bool {
+	return p == DigestDaily || p == DigestWeekly
+}
+
+// Days is the length of the period in days.
+func (p DigestPeriod) Days() int {
+	if p == DigestWeekly {
+		return 7
+	}
+	return 1
+}
+
+// DigestSubscription schedules a chat's digest. Minute is the local send
+// time in minutes after midnight; weekly digests go out on Weekday.
+type DigestSubscription struct {
+	ChatID     int64        `json:"chat_id"`
+	Period     DigestPeriod `json:"period"`
+	Minute     int          `json:"minute"`
+	Weekday    time.Weekday `json:"weekday"`
+	Timezone   string       `json:"timezone"`
+	LastSentAt *time.Time   `json:"last_sent_at,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+// Location resolves Timezone, falling back to UTC.
+func (s DigestSubscription) Location() *time.Location {
+	if loc, err := time.LoadLocation(s.Timezone); err == nil && s.Timezone != "" {
+		return loc
+	}
+	return time.UTC
+}
+
+// LastDue returns the most recent scheduled send at or before now.
+func (s DigestSubscription) LastDue(now time.Time) time.Time {
+	loc := s.Location()
+	local := now.In(loc)
+	due := time.Date(local.Year(), local.Month(), local.Day(), s.Minute/60, s.Minute%60, 0, 0, loc)
+	if s.Period == DigestWeekly {
+		due = due.AddDate(0, 0, -((int(local.Weekday()) - int(s.Weekday) + 7) % 7))
+	}
+	if due.After(now) {
+		due = due.AddDate(0, 0, -s.Period.Days())
+	}
+	return due
+}
+
+// NextDue returns the first scheduled send after now.
+func (s DigestSubscription) NextDue(now time.Time) time.Time {
+	return s.LastDue(now).AddDate(0, 0, s.Period.Days())
+}
+
+// Digest summarises the market over one period.
+type Digest struct {
+	Period         DigestPeriod          `json:"period"`
+	From           time.Time             `json:"from"`
+	To             time.Time             `json:"to"`
+	Prices         []DigestPriceChange   `json:"prices"`
+	TopSignals     []Signal              `json:"top_signals"`
+	ML             *MLMetrics            `json:"ml,omitempty"`
+	SentimentMoves []DigestSentimentMove `json:"sentiment_moves"`
+	Headlines      []DigestHeadline      `json:"headlines"`
+	// Summary is a short overview, written by the advisor LLM when
+	// SummaryByLLM is set and from a template otherwise.
+	Summary      string `json:"summary"`
+	SummaryByLLM bool   `json:"summary_by_llm"`
+	// Chart is a PNG of each asset's percent change over the period.
+	Chart []byte `json:"-"`
+}
+
+// DigestPriceChange is one asset's move over the period. Path holds the
+// percent change from Open at each candle close, ending at Close.
+type DigestPriceChange struct {
+	Symbol    string    `json:"symbol"`
+	Open      float64   `json:"open"`
+	Close     float64   `json:"close"`
+	ChangePct float64   `json:"change_pct"`
+	Path      []float64 `json:"-"`
+}
+
+// DigestSentimentMove is how far an asset's market-intel composite score
+// moved over the period.
+type DigestSentimentMove struct {
+	Symbol    string          `json:"symbol"`
+	From      float64         `json:"from"`
+	To        float64         `json:"to"`
+	Change    float64         `json:"change"`
+	Direction SignalDirection `json:"direction"`
+}
+
+// DigestHeadline is a news item with strong sentiment from the period.
+type DigestHeadline struct {
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	Source      string    `json:"source"`
+	Sentiment   *float64  `json:"sentiment,omitempty"`
+	PublishedAt time.Time `json:"published_at"`
+}
@@ -35,9 +35,36 @@ type Signal struct {
 	Risk      RiskLevel       `json:"risk"`
 	Direction SignalDirection `json:"direction"`
 	Details   string          `json:"details,omitempty"`
+	Levels    *SignalLevels   `json:"levels,omitempty"`
 	Image     *SignalImageRef `json:"image,omitempty"`
+	Sizing    *PositionSize   `json:"sizing,omitempty"`
 }
 
+// SignalLevels are the trade levels attached to a directional signal: where
+// it is entered, where it is proven wrong and where profit is taken.
+type SignalLevels struct {
+	Entry      float64   `json:"entry"`
+	Stop       float64   `json:"stop"`
+	Targets    []float64 `json:"targets"`
+	RewardRisk float64   `json:"reward_risk"`
+	Basis      string    `json:"basis"`
+}
+
+const (
+	LevelBasisATR   = "atr"
+	LevelBasisBands = "bands"
+	LevelBasisSwing = "swing"
+)
+
+// LevelOutcome is how a signal's levels played out after it fired.
+type LevelOutcome string
+
+const (
+	LevelOutcomeOpen   LevelOutcome = "open"
+	LevelOutcomeStop   LevelOutcome = "stop"
+	LevelOutcomeTarget LevelOutcome = "target"
+)
+
 type SignalImageRef struct {
 	ImageID   int64     `json:"image_id"`
 	MimeType  string    `json:"mime_type"`
@@ -55,7 +82,19 @@ type SignalFilter struct {
 	Symbol    string
 	Risk      *RiskLevel
 	Indicator string
+	Interval  string
+	Since     time.Time // zero means no lower bound
 	Limit     int
+	Offset    int
+}
+
+// RiskDistributionBucket counts persisted signals per risk level, used to
+// calibrate the 1-5 scale.
+type RiskDistributionBucket struct {
+	Indicator string    `json:"indicator"`
+	Interval  string    `json:"interval"`
+	Risk      RiskLevel `json:"risk"`
+	Count     int       `json:"count"`
 }
 
 type Recommendation struct {
@@ -77,12 +116,40 @@ func (r RiskLevel) IsValid() bool {
 	return r >= RiskLevel1 && r <= RiskLevel5
 }
 
+// SizingWeight scales a per-trade risk budget by risk level: full size up to
+// level 2, then three quarters, half and a quarter.
+func (r RiskLevel) SizingWeight() float64 {
+	switch {
+	case r <= RiskLevel2:
+		return 1
+	case r == RiskLevel3:
+		return 0.75
+	case r == RiskLevel4:
+		return 0.5
+	default:
+		return 0.25
+	}
+}
+
 type ConversationMessage struct {
+	ID        int64
 	Role      string
 	Content   string
 	CreatedAt time.Time
 }
 
+// ConversationMemory is what the advisor keeps about a chat beyond its recent
+// messages: a running summary of compacted turns and facts the user asked it
+// to remember. Messages with IDs up to CompactedThrough are covered by the
+// summary and no longer sent verbatim.
+type ConversationMemory struct {
+	ChatID           int64
+	Summary          string
+	CompactedThrough int64
+	Facts            []string
+	UpdatedAt        time.Time
+}
+
 type MLFeatureRow struct {
 	Symbol        string
 	Interval      string
@@ -50,8 +50,184 @@ func TestRiskLevelIsValid(t *testing.T) {
 	}
 }
 
+func TestRiskLevelSizingWeight(t *testing.T) {
+	want := map[RiskLevel]float64{RiskLevel1: 1, RiskLevel2: 1, RiskLevel3: 0.75, RiskLevel4: 0.5, RiskLevel5: 0.25}
+	for level, w := range want {
+		if got := level.SizingWeight(); got != w {
+			t.Fatalf("risk %d: expected weight %v, got %v", level, w, got)
+		}
+	}
+}
+
 func TestMLIndicatorConstants(t *testing.T) {
 	if IndicatorMLLogRegUp4H == "" || IndicatorMLXGBoostUp4H == "" || IndicatorMLEnsembleUp4H == "" {
 		t.Fatal("expected ML indicator constants to be non-empty")
 	}
 }
+
+func TestSignalParamsSetAndValidate(t *testing.T) {
+	p := SignalParams{
+		RSIPeriod: 14, RSIOversold: 30, RSIOverbought: 70,
+		MACDFast: 12, MACDSlow: 26, MACDSignal: 9,
+		BollingerPeriod: 20, BollingerStdDevs: 2, SqueezeThreshold: 0.08,
+		VolumeWindow: 20, VolumeZThreshold: 2,
+	}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("expected valid params: %v", err)
+	}
+	if err := p.Set("rsi_period", 9.6); err != nil || p.RSIPeriod != 10 {
+		t.Fatalf("expected rounded rsi_period 10, got %d (%v)", p.RSIPeriod, err)
+	}
+	if err := p.Set("unknown", 1); err == nil {
+		t.Fatal("expected unknown parameter error")
+	}
+	values := p.Values()
+	for _, name := range SignalParamNames() {
+		if _, ok := values[name]; !ok {
+			t.Fatalf("expected Values to include %q", name)
+		}
+	}
+	if len(values) != 11 || values["rsi_period"] != 10 {
+		t.Fatalf("unexpected values: %v", values)
+	}
+	_ = p.Set("rsi_oversold", 80)
+	if err := p.Validate(); err == nil {
+		t.Fatal("expected inverted rsi bands to be rejected")
+	}
+}
+
+func TestAlertSubscriptionMatches(t *testing.T) {
+	sig := Signal{Symbol: "BTC", Interval: "1h", Indicator: IndicatorRSI, Risk: RiskLevel3, Direction: DirectionLong}
+	if !(AlertSubscription{}).Matches(sig) {
+		t.Fatal("empty filters should match every signal")
+	}
+	sub := AlertSubscription{
+		Symbols: []string{"BTC", "ETH"}, Intervals: []string{"1h"}, Indicators: []string{IndicatorRSI},
+		MinRisk: RiskLevel2, MaxRisk: RiskLevel3, Direction: DirectionLong,
+	}
+	if !sub.Matches(sig) {
+		t.Fatalf("expected %+v to match", sig)
+	}
+	for _, miss := range []Signal{
+		{Symbol: "SOL", Interval: "1h", Indicator: IndicatorRSI, Risk: RiskLevel3, Direction: DirectionLong},
+		{Symbol: "BTC", Interval: "4h", Indicator: IndicatorRSI, Risk: RiskLevel3, Direction: DirectionLong},
+		{Symbol: "BTC", Interval: "1h", Indicator: IndicatorMACD, Risk: RiskLevel3, Direction: DirectionLong},
+		{Symbol: "BTC", Interval: "1h", Indicator: IndicatorRSI, Risk: RiskLevel1, Direction: DirectionLong},
+		{Symbol: "BTC", Interval: "1h", Indicator: IndicatorRSI, Risk: RiskLevel4, Direction: DirectionLong},
+		{Symbol: "BTC", Interval: "1h", Indicator: IndicatorRSI, Risk: RiskLevel3, Direction: DirectionShort},
+	} {
+		if sub.Matches(miss) {
+			t.Fatalf("expected %+v to be filtered out", miss)
+		}
+	}
+}
+
+func TestAlertSubscriptionQuietHours(t *testing.T) {
+	// 22:00-07:00 in New York, which is UTC-5 in January.
+	sub := AlertSubscription{QuietStart: 22 * 60, QuietEnd: 7 * 60, Timezone: "America/New_York"}
+	cases := map[string]bool{
+		"2026-01-15T02:59:00Z": false, // 21:59 local
+		"2026-01-15T03:00:00Z": true,  // 22:00 local
+		"2026-01-15T11:59:00Z": true,  // 06:59 local
+		"2026-01-15T12:00:00Z": false, // 07:00 local
+	}
+	for raw, want := range cases {
+		at, _ := time.Parse(time.RFC3339, raw)
+		if got := sub.InQuietHours(at); got != want {
+			t.Fatalf("InQuietHours(%s) = %v, want %v", raw, got, want)
+		}
+	}
+
+	day := AlertSubscription{QuietStart: 9 * 60, QuietEnd: 17 * 60}
+	if !day.InQuietHours(time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)) || day.InQuietHours(time.Date(2026, 1, 15, 18, 0, 0, 0, time.UTC)) {
+		t.Fatal("unexpected same-day quiet hours")
+	}
+	if (AlertSubscription{QuietStart: 60, QuietEnd: 60}).InQuietHours(time.Now()) {
+		t.Fatal("equal start and end means no quiet hours")
+	}
+	if (AlertSubscription{Timezone: "Mars/Olympus"}).Location() != time.UTC {
+		t.Fatal("unknown timezones should fall back to UTC")
+	}
+}
+
+func TestDigestSubscriptionDue(t *testing.T) {
+	daily := DigestSubscription{Period: DigestDaily, Minute: 8 * 60, Timezone: "Europe/Berlin"}
+	// 06:30 UTC is 08:30 in Berlin (summer time), so today's 08:00 is due.
+	now := time.Date(2026, 7, 15, 6, 30, 0, 0, time.UTC)
+	if got := daily.LastDue(now); !got.Equal(time.Date(2026, 7, 15, 6, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected daily last due: %s", got)
+	}
+	// 05:30 UTC is 07:30 in Berlin, so the last send was yesterday.
+	early := time.Date(2026, 7, 15, 5, 30, 0, 0, time.UTC)
+	if got := daily.LastDue(early); !got.Equal(time.Date(2026, 7, 14, 6, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected daily last due before send time: %s", got)
+	}
+	if got := daily.NextDue(early); !got.Equal(time.Date(2026, 7, 15, 6, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected daily next due: %s", got)
+	}
+
+	// 2026-07-15 is a Wednesday.
+	weekly := DigestSubscription{Period: DigestWeekly, Minute: 9 * 60, Weekday: time.Monday}
+	if got := weekly.LastDue(now); !got.Equal(time.Date(2026, 7, 13, 9, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected weekly last due: %s", got)
+	}
+	monday := time.Date(2026, 7, 13, 8, 0, 0, 0, time.UTC)
+	if got := weekly.LastDue(monday); !got.Equal(time.Date(2026, 7, 6, 9, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected weekly last due before send time: %s", got)
+	}
+	if got := weekly.NextDue(now); !got.Equal(time.Date(2026, 7, 20, 9, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected weekly next due: %s", got)
+	}
+}
+
+func TestUserRoleAllows(t *testing.T) {
+	if !RoleAdmin.Allows(RoleTrader) || !RoleTrader.Allows(RoleViewer) || !RoleViewer.Allows(RoleViewer) {
+		t.Fatal("expected higher roles to include lower ones")
+	}
+	if RoleViewer.Allows(RoleTrader) || UserRole("").Allows(RoleViewer) || UserRole("owner").IsValid() {
+		t.Fatal("expected lower and unknown roles to be refused")
+	}
+}
+
+func TestNotificationChannelFilters(t *testing.T) {
+	ch := NotificationChannel{
+		Type: ChannelSlack, Enabled: true, Kinds: []AlertDeliveryKind{DeliveryKindSignal},
+		Symbols: []string{"BTC"}, MinRisk: RiskLevel3,
+	}
+	if !ch.Type.IsValid() || NotificationChannelType("sms").IsValid() {
+		t.Fatal("unexpected channel type validity")
+	}
+	if !ch.Wants(DeliveryKindSignal) || ch.Wants(DeliveryKindPriceAlert) {
+		t.Fatal("expected only signals to be wanted")
+	}
+	if !ch.MatchesSignal(Signal{Symbol: "BTC", Risk: RiskLevel4}) || ch.MatchesSignal(Signal{Symbol: "BTC", Risk: RiskLevel2}) {
+		t.Fatal("expected signal filters to apply")
+	}
+	if !ch.MatchesPriceAlert(PriceAlertTrigger{Rule: PriceAlertRule{Symbol: "BTC"}}) || ch.MatchesPriceAlert(PriceAlertTrigger{Rule: PriceAlertRule{Symbol: "ETH"}}) {
+		t.Fatal("expected the symbol filter to apply to price alerts")
+	}
+	ch.Enabled = false
+	if ch.Wants(DeliveryKindSignal) {
+		t.Fatal("expected a disabled channel to want nothing")
+	}
+}
+
+func TestMaskChannelTarget(t *testing.T) {
+	cases := []struct {
+		kind   NotificationChannelType
+		target string
+		want   string
+	}{
+		{ChannelSlack, "https://hooks.slack.com/services/T0/B0/secret", "https://hooks.slack.com/***"},
+		{ChannelDiscord, "https://discord.com/api/webhooks/1/token", "https://discord.com/***"},
+		{ChannelWebhook, "https://ci.example.com?token=abc", "https://ci.example.com/***"},
+		{ChannelWebhook, "https://ci.example.com", "https://ci.example.com"},
+		{ChannelWebhook, "not a url", "***"},
+		{ChannelEmail, "ops@example.com,dev@example.com", "ops@example.com,dev@example.com"},
+	}
+	for _, tc := range cases {
+		if got := MaskChannelTarget(tc.kind, tc.target); got != tc.want {
+			t.Fatalf("MaskChannelTarget(%s, %q) = %q, want %q", tc.kind, tc.target, got, tc.want)
+		}
+	}
+}
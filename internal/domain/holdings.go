@@ -0,0 +1,72 @@
+package domain
+
+import "time"
+
+// Sources of holding trades.
+const (
+	HoldingSourceManual = "manual"
+	HoldingSourceCSV    = "csv"
+)
+
+// HoldingTrade is one buy or sell in a user's real portfolio. ChatID is the
+// same identity the advisor uses: a Telegram chat ID or the synthetic ID of
+// an SSH user. ExternalID deduplicates rows imported from exchange exports.
+type HoldingTrade struct {
+	ID         int64     `json:"id"`
+	ChatID     int64     `json:"chat_id"`
+	Symbol     string    `json:"symbol"`
+	Side       PaperSide `json:"side"`
+	Quantity   float64   `json:"quantity"`
+	Price      float64   `json:"price"`
+	Fee        float64   `json:"fee"`
+	ExecutedAt time.Time `json:"executed_at"`
+	Source     string    `json:"source"`
+	ExternalID string    `json:"external_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// HoldingLot is the unsold part of one purchase. Price is the cost per unit
+// including the buy fee.
+type HoldingLot struct {
+	Quantity   float64   `json:"quantity"`
+	Price      float64   `json:"price"`
+	AcquiredAt time.Time `json:"acquired_at"`
+}
+
+// Holding is the open position in one symbol, with cost basis from FIFO lots.
+// Allocation is the share of the portfolio's market value.
+type Holding struct {
+	Symbol        string       `json:"symbol"`
+	Quantity      float64      `json:"quantity"`
+	CostBasis     float64      `json:"cost_basis"`
+	AvgCost       float64      `json:"avg_cost"`
+	Price         float64      `json:"price"`
+	Priced        bool         `json:"priced"`
+	MarketValue   float64      `json:"market_value"`
+	UnrealizedPnL float64      `json:"unrealized_pnl"`
+	RealizedPnL   float64      `json:"realized_pnl"`
+	Allocation    float64      `json:"allocation"`
+	Lots          []HoldingLot `json:"lots"`
+}
+
+// HoldingsValuation values every open holding of a user at current prices.
+// RealizedPnL also counts symbols that have since been sold out.
+type HoldingsValuation struct {
+	ChatID        int64     `json:"chat_id"`
+	Holdings      []Holding `json:"holdings"`
+	CostBasis     float64   `json:"cost_basis"`
+	MarketValue   float64   `json:"market_value"`
+	UnrealizedPnL float64   `json:"unrealized_pnl"`
+	RealizedPnL   float64   `json:"realized_pnl"`
+	ShareAdvisor  bool      `json:"share_with_advisor"`
+	AsOf          time.Time `json:"as_of"`
+}
+
+// HoldingsImportResult summarises a CSV import. Duplicates are rows that were
+// already imported; Skipped rows were not trades of a tracked symbol.
+type HoldingsImportResult struct {
+	Imported   int      `json:"imported"`
+	Duplicates int      `json:"duplicates"`
+	Skipped    int      `json:"skipped"`
+	Errors     []string `json:"errors,omitempty"`
+}
@@ -0,0 +1,85 @@
+package domain
+
+import "time"
+
+// Dimensions ML prediction analytics can be grouped by.
+const (
+	MLGroupModel      = "model"
+	MLGroupSymbol     = "symbol"
+	MLGroupInterval   = "interval"
+	MLGroupDirection  = "direction"
+	MLGroupRisk       = "risk"
+	MLGroupConfidence = "confidence"
+)
+
+// MLGroupDimensions lists every supported grouping dimension.
+var MLGroupDimensions = []string{
+	MLGroupModel, MLGroupSymbol, MLGroupInterval, MLGroupDirection, MLGroupRisk, MLGroupConfidence,
+}
+
+// MLAnalyticsQuery selects resolved predictions and how to slice them. Empty
+// filters match everything; GroupBy combines dimensions, so
+// ["model","symbol"] yields one group per model and symbol pair.
+type MLAnalyticsQuery struct {
+	GroupBy  []string  `json:"group_by"`
+	ModelKey string    `json:"model_key,omitempty"`
+	Symbol   string    `json:"symbol,omitempty"`
+	Interval string    `json:"interval,omitempty"`
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+}
+
+// MLMetrics scores a set of resolved predictions. Precision and recall treat
+// "up" as the positive class. Brier score and log loss use prob_up against
+// the actual move. Returns follow the predicted direction (hold predictions
+// take no position); CumulativeReturn sums them rather than compounding,
+// since prediction horizons overlap.
+type MLMetrics struct {
+	Predictions      int     `json:"predictions"`
+	Correct          int     `json:"correct"`
+	Accuracy         float64 `json:"accuracy"`
+	Precision        float64 `json:"precision"`
+	Recall           float64 `json:"recall"`
+	Brier            float64 `json:"brier"`
+	LogLoss          float64 `json:"log_loss"`
+	Positions        int     `json:"positions"`
+	AvgReturn        float64 `json:"avg_return"`
+	CumulativeReturn float64 `json:"cumulative_return"`
+}
+
+// CalibrationBin is one bucket of a reliability curve: predictions whose
+// prob_up fell in [Lower, Upper), their mean prob_up and how often the price
+// actually rose.
+type CalibrationBin struct {
+	Lower         float64 `json:"lower"`
+	Upper         float64 `json:"upper"`
+	Count         int     `json:"count"`
+	MeanPredicted float64 `json:"mean_predicted"`
+	ObservedUp    float64 `json:"observed_up"`
+}
+
+// ReturnPoint is the cumulative directional return after the predictions
+// resolving up to Time.
+type ReturnPoint struct {
+	Time       time.Time `json:"time"`
+	Cumulative float64   `json:"cumulative"`
+}
+
+// MLAnalyticsGroup is the analytics for one slice. Values holds the slice's
+// value for each GroupBy dimension, in order; Key joins them for display.
+type MLAnalyticsGroup struct {
+	Key         string           `json:"key"`
+	Values      []string         `json:"values"`
+	Metrics     MLMetrics        `json:"metrics"`
+	Calibration []CalibrationBin `json:"calibration"`
+	Returns     []ReturnPoint    `json:"returns"`
+}
+
+// MLAnalyticsReport is the result of an MLAnalyticsQuery. Truncated is set
+// when only the most recent predictions up to the service limit were used.
+type MLAnalyticsReport struct {
+	Query     MLAnalyticsQuery   `json:"query"`
+	Overall   MLAnalyticsGroup   `json:"overall"`
+	Groups    []MLAnalyticsGroup `json:"groups"`
+	Truncated bool               `json:"truncated"`
+}
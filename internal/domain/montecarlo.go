@@ -0,0 +1,48 @@
+package domain
+
+import "time"
+
+const (
+	MonteCarloBootstrap = "bootstrap"
+	MonteCarloShuffle   = "shuffle"
+)
+
+// MonteCarloConfig controls a robustness analysis of a stored run. Bootstrap
+// resamples trades with replacement; shuffle only reorders them, so without
+// slippage it changes drawdown and recovery but not the final return.
+// SlippageBps adds a uniformly random extra cost of up to that many basis
+// points on each side of every trade.
+type MonteCarloConfig struct {
+	Simulations int     `json:"simulations"`
+	Method      string  `json:"method"`
+	SlippageBps float64 `json:"slippage_bps"`
+	Seed        int64   `json:"seed"`
+}
+
+// PercentileBand summarises a distribution.
+type PercentileBand struct {
+	P5  float64 `json:"p5"`
+	P25 float64 `json:"p25"`
+	P50 float64 `json:"p50"`
+	P75 float64 `json:"p75"`
+	P95 float64 `json:"p95"`
+}
+
+// MonteCarloResult holds the simulated distributions for one run. Returns
+// and drawdowns are fractions; RecoveryTrades is the longest stretch, in
+// trades, a path spent below its previous peak. Fan and Actual are the
+// cumulative return after each trade (index 0 is the start), for the
+// simulated percentiles and the run's own trade order respectively.
+type MonteCarloResult struct {
+	RunID          int64            `json:"run_id"`
+	Config         MonteCarloConfig `json:"config"`
+	Trades         int              `json:"trades"`
+	FinalReturn    PercentileBand   `json:"final_return"`
+	MaxDrawdown    PercentileBand   `json:"max_drawdown"`
+	RecoveryTrades PercentileBand   `json:"recovery_trades"`
+	ProbLoss       float64          `json:"prob_loss"`
+	Unrecovered    float64          `json:"unrecovered"`
+	Fan            []PercentileBand `json:"fan"`
+	Actual         []float64        `json:"actual"`
+	CreatedAt      time.Time        `json:"created_at"`
+}
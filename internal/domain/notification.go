@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"net/url"
+	"slices"
+	"time"
+)
+
+// NotificationChannelType is where a notification channel sends to.
+type NotificationChannelType string
+
+const (
+	ChannelDiscord NotificationChannelType = "discord"
+	ChannelSlack   NotificationChannelType = "slack"
+	ChannelEmail   NotificationChannelType = "email"
+	// ChannelWebhook posts the notification as JSON, signed with the
+	// channel's secret.
+	ChannelWebhook NotificationChannelType = "webhook"
+)
+
+func (t NotificationChannelType) IsValid() bool {
+	switch t {
+	case ChannelDiscord, ChannelSlack, ChannelEmail, ChannelWebhook:
+		return true
+	}
+	return false
+}
+
+// DeliveryKindTest marks test notifications sent from the API.
+const DeliveryKindTest AlertDeliveryKind = "test"
+
+// NotificationChannel is a destination outside Telegram that receives
+// signals and price alerts. Target is the incoming webhook URL, or a
+// comma-separated list of addresses for email. A webhook URL is itself the
+// credential, so like Secret it is never serialised; MaskedTarget is shown
+// in its place. Kinds selects what is sent; the signal filters work like an
+// AlertSubscription's, and Symbols also filters price alerts.
+type NotificationChannel struct {
+	ID           int64                   `json:"id"`
+	Name         string                  `json:"name"`
+	Type         NotificationChannelType `json:"type"`
+	Target       string                  `json:"-"`
+	MaskedTarget string                  `json:"target"`
+	Secret       string                  `json:"-"`
+	Kinds        []AlertDeliveryKind     `json:"kinds"`
+	Symbols      []string                `json:"symbols,omitempty"`
+	Intervals    []string                `json:"intervals,omitempty"`
+	Indicators   []string                `json:"indicators,omitempty"`
+	MinRisk      RiskLevel               `json:"min_risk,omitempty"`
+	MaxRisk      RiskLevel               `json:"max_risk,omitempty"`
+	Direction    SignalDirection         `json:"direction,omitempty"`
+	Enabled      bool                    `json:"enabled"`
+	HasSecret    bool                    `json:"has_secret"`
+	CreatedAt    time.Time               `json:"created_at"`
+	UpdatedAt    time.Time               `json:"updated_at"`
+}
+
+// MaskChannelTarget returns target as the API shows it: email addresses as
+// they are, and for URLs only the scheme and host.
+func MaskChannelTarget(kind NotificationChannelType, target string) string {
+	if kind == ChannelEmail || target == "" {
+		return target
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return "***"
+	}
+	masked := u.Scheme + "://" + u.Host
+	if u.User != nil || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		masked += "/***"
+	}
+	return masked
+}
+
+// Wants reports whether the channel receives notifications of kind.
+func (c NotificationChannel) Wants(kind AlertDeliveryKind) bool {
+	return c.Enabled && slices.Contains(c.Kinds, kind)
+}
+
+// MatchesSignal reports whether a signal passes the channel's filters.
+func (c NotificationChannel) MatchesSignal(sig Signal) bool {
+	return AlertSubscription{
+		Symbols:    c.Symbols,
+		Intervals:  c.Intervals,
+		Indicators: c.Indicators,
+		MinRisk:    c.MinRisk,
+		MaxRisk:    c.MaxRisk,
+		Direction:  c.Direction,
+	}.Matches(sig)
+}
+
+// MatchesPriceAlert reports whether a fired rule passes the channel's symbol
+// filter.
+func (c NotificationChannel) MatchesPriceAlert(t PriceAlertTrigger) bool {
+	return len(c.Symbols) == 0 || slices.Contains(c.Symbols, t.Rule.Symbol)
+}
+
+// Notification is the payload queued for a channel. Generic webhooks
+// receive it as JSON; the other channels get it rendered as text. ID is the
+// delivery ID, the same on every retry, so receivers can drop duplicates.
+type Notification struct {
+	ID         int64              `json:"id"`
+	Kind       AlertDeliveryKind  `json:"kind"`
+	Signals    []Signal           `json:"signals,omitempty"`
+	PriceAlert *PriceAlertTrigger `json:"price_alert,omitempty"`
+	Text       string             `json:"text,omitempty"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+// NotificationDelivery is one entry in a channel's delivery log.
+type NotificationDelivery struct {
+	ID            int64               `json:"id"`
+	ChannelID     int64               `json:"channel_id"`
+	Kind          AlertDeliveryKind   `json:"kind"`
+	Status        AlertDeliveryStatus `json:"status"`
+	Attempts      int                 `json:"attempts"`
+	NextAttemptAt time.Time           `json:"next_attempt_at"`
+	LastError     string              `json:"last_error,omitempty"`
+	CreatedAt     time.Time           `json:"created_at"`
+	SentAt        *time.Time          `json:"sent_at,omitempty"`
+}
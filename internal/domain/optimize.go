@@ -0,0 +1,92 @@
+package domain
+
+import "time"
+
+const (
+	OptimizeModeGrid   = "grid"
+	OptimizeModeRandom = "random"
+
+	ObjectiveSharpe       = "sharpe"
+	ObjectiveSortino      = "sortino"
+	ObjectiveTotalReturn  = "total_return"
+	ObjectiveProfitFactor = "profit_factor"
+)
+
+// OptimizeRequest sweeps signal parameters over a backtest config. Grid maps
+// parameter names (see SignalParamNames) to the values to try; grid mode
+// tries every combination, random mode samples Samples of them. Parameters
+// not in Grid keep the value from Base.Params.
+type OptimizeRequest struct {
+	Base      BacktestConfig       `json:"base"`
+	Grid      map[string][]float64 `json:"grid"`
+	Mode      string               `json:"mode"`
+	Samples   int                  `json:"samples,omitempty"`
+	Folds     int                  `json:"folds"`
+	Objective string               `json:"objective"`
+	Seed      int64                `json:"seed,omitempty"`
+}
+
+// WalkForwardFold is one optimise-on-N, test-on-N+1 step.
+type WalkForwardFold struct {
+	Index       int                `json:"index"`
+	TrainFrom   time.Time          `json:"train_from"`
+	TrainTo     time.Time          `json:"train_to"`
+	TestFrom    time.Time          `json:"test_from"`
+	TestTo      time.Time          `json:"test_to"`
+	Values      map[string]float64 `json:"values"`
+	TrainScore  float64            `json:"train_score"`
+	TestScore   float64            `json:"test_score"`
+	TestMetrics BacktestMetrics    `json:"test_metrics"`
+}
+
+// ParamCandidate aggregates one parameter set over the windows it was not
+// selected on. Stability is MeanTestScore minus StdTestScore, so consistent
+// sets rank above ones with a single lucky window.
+type ParamCandidate struct {
+	Values         map[string]float64 `json:"values"`
+	Params         SignalParams       `json:"params"`
+	MeanTrainScore float64            `json:"mean_train_score"`
+	MeanTestScore  float64            `json:"mean_test_score"`
+	StdTestScore   float64            `json:"std_test_score"`
+	Stability      float64            `json:"stability"`
+	PositiveFolds  int                `json:"positive_folds"`
+	FoldWins       int                `json:"fold_wins"`
+}
+
+// OptimizeResult reports walk-forward performance. Efficiency is the mean
+// out-of-sample score of the fold winners over their mean in-sample score;
+// values near 1 mean the in-sample optimum carried over. Recommended is the
+// most frequent fold winner, scored on the test windows of the folds it won.
+type OptimizeResult struct {
+	Objective      string            `json:"objective"`
+	Candidates     int               `json:"candidates"`
+	Folds          []WalkForwardFold `json:"folds"`
+	MeanTrainScore float64           `json:"mean_train_score"`
+	MeanTestScore  float64           `json:"mean_test_score"`
+	Efficiency     float64           `json:"efficiency"`
+	PositiveFolds  int               `json:"positive_folds"`
+	Leaderboard    []ParamCandidate  `json:"leaderboard"`
+	Recommended    *ParamCandidate   `json:"recommended,omitempty"`
+}
+
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+)
+
+// OptimizationJob tracks a background optimisation.
+type OptimizationJob struct {
+	ID          int64           `json:"id"`
+	Status      string          `json:"status"`
+	Request     OptimizeRequest `json:"request"`
+	Done        int             `json:"done"`
+	Total       int             `json:"total"`
+	Error       string          `json:"error,omitempty"`
+	Result      *OptimizeResult `json:"result,omitempty"`
+	PromotedSet int64           `json:"promoted_set,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	StartedAt   *time.Time      `json:"started_at,omitempty"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,115 @@
+package domain
+
+import "time"
+
+// PaperSide is the side of a paper order.
+type PaperSide string
+
+const (
+	PaperBuy  PaperSide = "buy"
+	PaperSell PaperSide = "sell"
+)
+
+// Sources of paper orders.
+const (
+	PaperSourceManual = "manual"
+	PaperSourceSignal = "signal"
+)
+
+// PaperAccount is a virtual spot account. Owner ties an account to a chat or
+// user (for example "telegram:12345") and is empty for accounts created
+// through the API. Orders fill SlippageBps away from the market price.
+type PaperAccount struct {
+	ID              int64     `json:"id"`
+	Name            string    `json:"name"`
+	Owner           string    `json:"owner,omitempty"`
+	StartingBalance float64   `json:"starting_balance"`
+	Cash            float64   `json:"cash"`
+	SlippageBps     float64   `json:"slippage_bps"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// PaperPosition is an account's holding in one symbol. RealizedPnL
+// accumulates across round trips, so the row outlives a flat position.
+type PaperPosition struct {
+	AccountID   int64     `json:"account_id"`
+	Symbol      string    `json:"symbol"`
+	Quantity    float64   `json:"quantity"`
+	AvgPrice    float64   `json:"avg_price"`
+	RealizedPnL float64   `json:"realized_pnl"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// PaperOrderRequest asks for a market order. Quantity or Notional (in USD)
+// sizes it; a sell with neither closes the whole position.
+type PaperOrderRequest struct {
+	AccountID int64     `json:"account_id"`
+	Symbol    string    `json:"symbol"`
+	Side      PaperSide `json:"side"`
+	Quantity  float64   `json:"quantity,omitempty"`
+	Notional  float64   `json:"notional,omitempty"`
+	Source    string    `json:"source,omitempty"`
+	SignalID  *int64    `json:"signal_id,omitempty"`
+}
+
+// PaperOrder is a filled paper order. RealizedPnL is set on sells.
+type PaperOrder struct {
+	ID          int64     `json:"id"`
+	AccountID   int64     `json:"account_id"`
+	Symbol      string    `json:"symbol"`
+	Side        PaperSide `json:"side"`
+	Quantity    float64   `json:"quantity"`
+	MarketPrice float64   `json:"market_price"`
+	FillPrice   float64   `json:"fill_price"`
+	Notional    float64   `json:"notional"`
+	RealizedPnL float64   `json:"realized_pnl"`
+	Source      string    `json:"source"`
+	SignalID    *int64    `json:"signal_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// PaperSubscription makes an account follow fresh signals. Empty Symbol and
+// Indicator and a zero MaxRisk match everything. Long signals buy
+// TradeNotional USD when the account is flat in the symbol; short signals
+// close the position.
+type PaperSubscription struct {
+	ID            int64     `json:"id"`
+	AccountID     int64     `json:"account_id"`
+	Symbol        string    `json:"symbol,omitempty"`
+	Indicator     string    `json:"indicator,omitempty"`
+	MaxRisk       RiskLevel `json:"max_risk,omitempty"`
+	TradeNotional float64   `json:"trade_notional"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Matches reports whether s passes the subscription's filters.
+func (sub PaperSubscription) Matches(s Signal) bool {
+	if sub.Symbol != "" && sub.Symbol != s.Symbol {
+		return false
+	}
+	if sub.Indicator != "" && sub.Indicator != s.Indicator {
+		return false
+	}
+	if sub.MaxRisk > 0 && s.Risk > sub.MaxRisk {
+		return false
+	}
+	return true
+}
+
+// PaperHolding is a position marked at the current price.
+type PaperHolding struct {
+	PaperPosition
+	MarkPrice     float64 `json:"mark_price"`
+	MarketValue   float64 `json:"market_value"`
+	UnrealizedPnL float64 `json:"unrealized_pnl"`
+}
+
+// PaperPortfolio is an account valued at current prices.
+type PaperPortfolio struct {
+	Account       PaperAccount   `json:"account"`
+	Holdings      []PaperHolding `json:"holdings"`
+	Equity        float64        `json:"equity"`
+	RealizedPnL   float64        `json:"realized_pnl"`
+	UnrealizedPnL float64        `json:"unrealized_pnl"`
+	Return        float64        `json:"return"`
+}
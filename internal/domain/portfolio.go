@@ -0,0 +1,71 @@
+package domain
+
+import "time"
+
+// PortfolioConfig simulates following signals across several assets from one
+// shared account. Base supplies the window, filters, costs and exit rules;
+// its Symbol is ignored in favour of Symbols.
+//
+// Each entry risks RiskPerTrade of current equity (scaled down for risk
+// levels above 2) between the fill and the signal's stop, or twice the
+// recent volatility when there is no stop. TargetVolatility, when set, caps
+// a position so its annualised volatility contributes at most that fraction
+// of equity. MaxAssetExposure and MaxGrossExposure cap notional per asset and
+// in total, as fractions of equity.
+type PortfolioConfig struct {
+	Base             BacktestConfig `json:"base"`
+	Symbols          []string       `json:"symbols"`
+	RiskPerTrade     float64        `json:"risk_per_trade"`
+	TargetVolatility float64        `json:"target_volatility,omitempty"`
+	MaxAssetExposure float64        `json:"max_asset_exposure"`
+	MaxGrossExposure float64        `json:"max_gross_exposure"`
+}
+
+// PortfolioTrade is one simulated round trip in a portfolio run.
+type PortfolioTrade struct {
+	Symbol string `json:"symbol"`
+	BacktestTrade
+	Units    float64 `json:"units"`
+	Notional float64 `json:"notional"`
+}
+
+// ExposurePoint is the book at one candle close, as fractions of equity.
+// CorrelationAdjusted is sqrt(w'Cw) over the signed per-asset weights w and
+// the return correlation matrix C, so offsetting or uncorrelated positions
+// count for less than their gross sum.
+type ExposurePoint struct {
+	Time                time.Time `json:"time"`
+	Gross               float64   `json:"gross"`
+	Net                 float64   `json:"net"`
+	CorrelationAdjusted float64   `json:"correlation_adjusted"`
+	Positions           int       `json:"positions"`
+}
+
+// AssetContribution attributes portfolio PnL to one asset. Contribution is
+// PnL over initial equity.
+type AssetContribution struct {
+	Symbol       string  `json:"symbol"`
+	Trades       int     `json:"trades"`
+	Wins         int     `json:"wins"`
+	PnL          float64 `json:"pnl"`
+	Fees         float64 `json:"fees"`
+	Contribution float64 `json:"contribution"`
+	AvgExposure  float64 `json:"avg_exposure"`
+}
+
+// PortfolioResult reports a portfolio simulation. SkippedSignals counts
+// entries dropped because a cap, the cash balance or a duplicate open
+// position left no room.
+type PortfolioResult struct {
+	Config                 PortfolioConfig               `json:"config"`
+	Metrics                BacktestMetrics               `json:"metrics"`
+	Equity                 []EquityPoint                 `json:"equity"`
+	Exposure               []ExposurePoint               `json:"exposure"`
+	Trades                 []PortfolioTrade              `json:"trades"`
+	Assets                 []AssetContribution           `json:"assets"`
+	Correlations           map[string]map[string]float64 `json:"correlations"`
+	AvgGrossExposure       float64                       `json:"avg_gross_exposure"`
+	MaxGrossExposure       float64                       `json:"max_gross_exposure"`
+	AvgCorrelationAdjusted float64                       `json:"avg_correlation_adjusted"`
+	SkippedSignals         int                           `json:"skipped_signals"`
+}
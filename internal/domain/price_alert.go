@@ -0,0 +1,61 @@
+package domain
+
+import "time"
+
+// PriceAlertType is what a price alert rule watches.
+type PriceAlertType string
+
+const (
+	// PriceAlertCross fires when the price crosses Threshold.
+	PriceAlertCross PriceAlertType = "cross"
+	// PriceAlertMove fires when the price moves at least Threshold percent
+	// within the rule's window.
+	PriceAlertMove PriceAlertType = "move"
+	// PriceAlertVolume fires when 24h volume rises at least Threshold
+	// percent within the rule's window.
+	PriceAlertVolume PriceAlertType = "volume"
+)
+
+// PriceAlertDirection narrows a rule to upward or downward crosses and
+// moves. Volume rules only look up.
+type PriceAlertDirection string
+
+const (
+	PriceAlertUp   PriceAlertDirection = "up"
+	PriceAlertDown PriceAlertDirection = "down"
+	PriceAlertAny  PriceAlertDirection = "any"
+)
+
+// PriceAlertRule is a user's alert on one symbol. Threshold is a USD price
+// for cross rules and a percentage for move and volume rules.
+//
+// After firing, a rule is disarmed until its measure falls back past the
+// threshold by Hysteresis (a fraction of the threshold), so a price hovering
+// at the line alerts once. One-shot rules are deactivated when they fire;
+// recurring rules stay active and fire again each time they re-arm.
+type PriceAlertRule struct {
+	ID              int64               `json:"id"`
+	ChatID          int64               `json:"chat_id"`
+	Symbol          string              `json:"symbol"`
+	Type            PriceAlertType      `json:"type"`
+	Direction       PriceAlertDirection `json:"direction"`
+	Threshold       float64             `json:"threshold"`
+	WindowMins      int                 `json:"window_mins,omitempty"`
+	Hysteresis      float64             `json:"hysteresis"`
+	Recurring       bool                `json:"recurring"`
+	Active          bool                `json:"active"`
+	Armed           bool                `json:"armed"`
+	TriggerCount    int                 `json:"trigger_count"`
+	LastTriggeredAt *time.Time          `json:"last_triggered_at,omitempty"`
+	CreatedAt       time.Time           `json:"created_at"`
+}
+
+// PriceAlertTrigger is one firing of a rule. Value is the measure that
+// crossed the threshold: the price for cross rules, the percent change for
+// move and volume rules.
+type PriceAlertTrigger struct {
+	Rule        PriceAlertRule `json:"rule"`
+	Price       float64        `json:"price"`
+	Value       float64        `json:"value"`
+	TriggeredAt time.Time      `json:"triggered_at"`
+}
@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// SignalParams are the tunable thresholds of the classic signal rules.
+type SignalParams struct {
+	RSIPeriod        int     `json:"rsi_period"`
+	RSIOversold      float64 `json:"rsi_oversold"`
+	RSIOverbought    float64 `json:"rsi_overbought"`
+	MACDFast         int     `json:"macd_fast"`
+	MACDSlow         int     `json:"macd_slow"`
+	MACDSignal       int     `json:"macd_signal"`
+	BollingerPeriod  int     `json:"bollinger_period"`
+	BollingerStdDevs float64 `json:"bollinger_std_devs"`
+	SqueezeThreshold float64 `json:"squeeze_threshold"`
+	VolumeWindow     int     `json:"volume_window"`
+	VolumeZThreshold float64 `json:"volume_z_threshold"`
+}
+
+// signalParamFields maps the sweepable parameter names (the JSON keys) to
+// their fields. Integer fields round the value they are given.
+var signalParamFields = map[string]func(p *SignalParams, v float64){
+	"rsi_period":         func(p *SignalParams, v float64) { p.RSIPeriod = int(math.Round(v)) },
+	"rsi_oversold":       func(p *SignalParams, v float64) { p.RSIOversold = v },
+	"rsi_overbought":     func(p *SignalParams, v float64) { p.RSIOverbought = v },
+	"macd_fast":          func(p *SignalParams, v float64) { p.MACDFast = int(math.Round(v)) },
+	"macd_slow":          func(p *SignalParams, v float64) { p.MACDSlow = int(math.Round(v)) },
+	"macd_signal":        func(p *SignalParams, v float64) { p.MACDSignal = int(math.Round(v)) },
+	"bollinger_period":   func(p *SignalParams, v float64) { p.BollingerPeriod = int(math.Round(v)) },
+	"bollinger_std_devs": func(p *SignalParams, v float64) { p.BollingerStdDevs = v },
+	"squeeze_threshold":  func(p *SignalParams, v float64) { p.SqueezeThreshold = v },
+	"volume_window":      func(p *SignalParams, v float64) { p.VolumeWindow = int(math.Round(v)) },
+	"volume_z_threshold": func(p *SignalParams, v float64) { p.VolumeZThreshold = v },
+}
+
+// SignalParamNames lists the parameter names accepted by Set, sorted.
+func SignalParamNames() []string {
+	names := make([]string, 0, len(signalParamFields))
+	for name := range signalParamFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Set assigns one parameter by name.
+func (p *SignalParams) Set(name string, v float64) error {
+	set, ok := signalParamFields[name]
+	if !ok {
+		return fmt.Errorf("unknown signal parameter %q", name)
+	}
+	set(p, v)
+	return nil
+}
+
+// Values returns every parameter keyed by name.
+func (p SignalParams) Values() map[string]float64 {
+	return map[string]float64{
+		"rsi_period":         float64(p.RSIPeriod),
+		"rsi_oversold":       p.RSIOversold,
+		"rsi_overbought":     p.RSIOverbought,
+		"macd_fast":          float64(p.MACDFast),
+		"macd_slow":          float64(p.MACDSlow),
+		"macd_signal":        float64(p.MACDSignal),
+		"bollinger_period":   float64(p.BollingerPeriod),
+		"bollinger_std_devs": p.BollingerStdDevs,
+		"squeeze_threshold":  p.SqueezeThreshold,
+		"volume_window":      float64(p.VolumeWindow),
+		"volume_z_threshold": p.VolumeZThreshold,
+	}
+}
+
+// Validate rejects parameter sets the rules cannot evaluate sensibly.
+func (p SignalParams) Validate() error {
+	switch {
+	case p.RSIPeriod < 2:
+		return fmt.Errorf("rsi_period must be at least 2")
+	case p.RSIOversold <= 0 || p.RSIOverbought >= 100 || p.RSIOversold >= p.RSIOverbought:
+		return fmt.Errorf("rsi bands must satisfy 0 < rsi_oversold < rsi_overbought < 100")
+	case p.MACDFast < 1 || p.MACDSignal < 1 || p.MACDFast >= p.MACDSlow:
+		return fmt.Errorf("macd periods must be positive with macd_fast < macd_slow")
+	case p.BollingerPeriod < 2 || p.BollingerStdDevs <= 0:
+		return fmt.Errorf("bollinger_period must be at least 2 and bollinger_std_devs positive")
+	case p.SqueezeThreshold <= 0:
+		return fmt.Errorf("squeeze_threshold must be positive")
+	case p.VolumeWindow < 2 || p.VolumeZThreshold <= 0:
+		return fmt.Errorf("volume_window must be at least 2 and volume_z_threshold positive")
+	}
+	return nil
+}
+
+// SignalParamSet is a parameter set promoted into the live engine.
+type SignalParamSet struct {
+	ID         int64        `json:"id"`
+	Params     SignalParams `json:"params"`
+	Source     string       `json:"source"`
+	PromotedAt time.Time    `json:"promoted_at"`
+}
@@ -0,0 +1,92 @@
+package domain
+
+// SizingMethod selects how a position is sized from the risk budget.
+type SizingMethod string
+
+const (
+	// SizingFixedFractional risks a fixed share of equity between entry and
+	// stop.
+	SizingFixedFractional SizingMethod = "fixed_fractional"
+	// SizingVolatilityParity sizes so that one ATR move costs the same share
+	// of equity on every asset.
+	SizingVolatilityParity SizingMethod = "volatility_parity"
+	// SizingKelly risks a fraction of the Kelly-optimal bet, capped by the
+	// signal's risk level.
+	SizingKelly SizingMethod = "kelly"
+)
+
+func (m SizingMethod) IsValid() bool {
+	switch m {
+	case SizingFixedFractional, SizingVolatilityParity, SizingKelly:
+		return true
+	}
+	return false
+}
+
+// SizingConfig holds the sizing rules.
+//
+// RiskPerTrade is the most equity a trade may lose at its stop, scaled down
+// for risk levels above 2 (see RiskLevel.SizingWeight). Fixed fractional
+// sizing risks all of it; the other methods risk up to it. TargetVolatility
+// is the share of equity one ATR move should cost under volatility parity.
+// Kelly sizing takes KellyFraction of full Kelly, assuming KellyWinRate when
+// the caller has no better estimate. MaxPositionPct caps notional as a share
+// of equity. ReferenceEquity is the account size used when sizing is attached
+// to listed signals; zero leaves signals unsized.
+type SizingConfig struct {
+	Method           SizingMethod `json:"method"`
+	RiskPerTrade     float64      `json:"risk_per_trade"`
+	TargetVolatility float64      `json:"target_volatility"`
+	KellyFraction    float64      `json:"kelly_fraction"`
+	KellyWinRate     float64      `json:"kelly_win_rate"`
+	MaxPositionPct   float64      `json:"max_position_pct"`
+	ReferenceEquity  float64      `json:"reference_equity,omitempty"`
+}
+
+// SizingInput describes one trade to size. Stop may be zero when ATR is set,
+// in which case the stop is placed 1.5 ATRs from entry as signal levels do.
+// RewardRisk and WinRate only matter for Kelly sizing.
+type SizingInput struct {
+	Symbol     string          `json:"symbol"`
+	Direction  SignalDirection `json:"direction,omitempty"`
+	Equity     float64         `json:"equity"`
+	Entry      float64         `json:"entry"`
+	Stop       float64         `json:"stop,omitempty"`
+	ATR        float64         `json:"atr,omitempty"`
+	Risk       RiskLevel       `json:"risk"`
+	RewardRisk float64         `json:"reward_risk,omitempty"`
+	WinRate    float64         `json:"win_rate,omitempty"`
+}
+
+// SizingRequest asks for a position size on a symbol using its latest
+// directional signal. Zero RiskPerTrade and an empty Method use the
+// configured defaults.
+type SizingRequest struct {
+	Symbol       string       `json:"symbol"`
+	Equity       float64      `json:"equity"`
+	RiskPerTrade float64      `json:"risk_per_trade,omitempty"`
+	Method       SizingMethod `json:"method,omitempty"`
+}
+
+// PositionSize is a sized trade. RiskFraction is the share of equity lost if
+// the stop is hit at the returned quantity; Capped reports that the risk
+// budget or MaxPositionPct cut the method's size down.
+type PositionSize struct {
+	Symbol         string          `json:"symbol"`
+	Method         SizingMethod    `json:"method"`
+	Direction      SignalDirection `json:"direction,omitempty"`
+	SignalID       int64           `json:"signal_id,omitempty"`
+	Risk           RiskLevel       `json:"risk"`
+	RiskWeight     float64         `json:"risk_weight"`
+	Equity         float64         `json:"equity"`
+	Entry          float64         `json:"entry"`
+	Stop           float64         `json:"stop"`
+	StopDistance   float64         `json:"stop_distance"`
+	Quantity       float64         `json:"quantity"`
+	Notional       float64         `json:"notional"`
+	RiskAmount     float64         `json:"risk_amount"`
+	RiskFraction   float64         `json:"risk_fraction"`
+	EquityFraction float64         `json:"equity_fraction"`
+	Capped         bool            `json:"capped,omitempty"`
+	Note           string          `json:"note,omitempty"`
+}
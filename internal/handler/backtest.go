@@ -1,9 +1,16 @@
 package handler
 
 import (
+	"context"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
@@ -106,3 +113,401 @@ func (h *Handler) GetBacktestPredictions(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"predictions": preds})
 }
+
+type StrategyBacktestRunner interface {
+	RunStrategy(ctx context.Context, cfg domain.BacktestConfig) (*domain.BacktestResult, error)
+	RunPortfolio(ctx context.Context, cfg domain.PortfolioConfig) (*domain.PortfolioResult, error)
+	ListRuns(ctx context.Context, limit int) ([]domain.BacktestRun, error)
+	GetRun(ctx context.Context, id int64) (*domain.BacktestRun, error)
+	CompareRuns(ctx context.Context, a, b int64) (*domain.BacktestRunComparison, error)
+	RunMonteCarlo(ctx context.Context, runID int64, cfg domain.MonteCarloConfig) (*domain.MonteCarloResult, error)
+	GetMonteCarlo(ctx context.Context, runID int64) (*domain.MonteCarloResult, error)
+	MonteCarloChart(ctx context.Context, runID int64) ([]byte, error)
+}
+
+type backtestRunRequest struct {
+	Symbol        string               `json:"symbol"`
+	Interval      string               `json:"interval"`
+	From          *time.Time           `json:"from"`
+	To            *time.Time           `json:"to"`
+	Days          int                  `json:"days"`
+	Indicators    []string             `json:"indicators"`
+	MaxRisk       int                  `json:"max_risk"`
+	FeeBps        *float64             `json:"fee_bps"`
+	SlippageBps   *float64             `json:"slippage_bps"`
+	HoldBars      int                  `json:"hold_bars"`
+	UseStops      *bool                `json:"use_stops"`
+	AllowShort    bool                 `json:"allow_short"`
+	InitialEquity float64              `json:"initial_equity"`
+	Params        *domain.SignalParams `json:"params"`
+}
+
+func (r backtestRunRequest) config() domain.BacktestConfig {
+	cfg := domain.BacktestConfig{
+		Symbol:        r.Symbol,
+		Interval:      r.Interval,
+		Indicators:    r.Indicators,
+		MaxRisk:       domain.RiskLevel(r.MaxRisk),
+		FeeBps:        service.DefaultBacktestFeeBps,
+		SlippageBps:   service.DefaultBacktestSlippageBps,
+		HoldBars:      r.HoldBars,
+		UseStops:      true,
+		AllowShort:    r.AllowShort,
+		InitialEquity: r.InitialEquity,
+		Params:        r.Params,
+	}
+	if r.To != nil {
+		cfg.To = *r.To
+	}
+	if r.From != nil {
+		cfg.From = *r.From
+	} else if r.Days > 0 {
+		to := cfg.To
+		if to.IsZero() {
+			to = time.Now().UTC()
+			cfg.To = to
+		}
+		cfg.From = to.AddDate(0, 0, -r.Days)
+	}
+	if r.FeeBps != nil {
+		cfg.FeeBps = *r.FeeBps
+	}
+	if r.SlippageBps != nil {
+		cfg.SlippageBps = *r.SlippageBps
+	}
+	if r.UseStops != nil {
+		cfg.UseStops = *r.UseStops
+	}
+	return cfg
+}
+
+// RunStrategyBacktest godoc
+// @Summary      Run a strategy backtest
+// @Description  Replays stored candles through the signal engine without lookahead and simulates trades with fees, slippage, holding period and stop/target exits
+// @Tags         backtest
+// @Accept       json
+// @Produce      json
+// @Param        request  body  backtestRunRequest  true  "Backtest parameters"
+// @Success      200  {object}  domain.BacktestResult
+// @Failure      400  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/backtest/run [post]
+func (h *Handler) RunStrategyBacktest(c *gin.Context) {
+	if h.strategyBacktest == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "strategy backtest service unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.run-strategy-backtest")
+	defer span.End()
+
+	var req backtestRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	if req.Days < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a positive integer"})
+		return
+	}
+
+	result, err := h.strategyBacktest.RunStrategy(ctx, req.config())
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidBacktestConfig) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+type portfolioRunRequest struct {
+	backtestRunRequest
+	Symbols          []string `json:"symbols"`
+	RiskPerTrade     float64  `json:"risk_per_trade"`
+	TargetVolatility float64  `json:"target_volatility"`
+	MaxAssetExposure float64  `json:"max_asset_exposure"`
+	MaxGrossExposure float64  `json:"max_gross_exposure"`
+}
+
+// RunPortfolioBacktest godoc
+// @Summary      Run a multi-asset portfolio backtest
+// @Description  Follows signals across several symbols from one shared account, sizing each entry by risk per trade over the stop distance (scaled down for riskier signals), with optional volatility targeting and per-asset and gross exposure caps. Reports portfolio equity, correlation-adjusted exposure and per-asset contribution. Symbols default to every tracked symbol.
+// @Tags         backtest
+// @Accept       json
+// @Produce      json
+// @Param        request  body  portfolioRunRequest  true  "Portfolio backtest parameters"
+// @Success      200  {object}  domain.PortfolioResult
+// @Failure      400  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/backtest/portfolio [post]
+func (h *Handler) RunPortfolioBacktest(c *gin.Context) {
+	if h.strategyBacktest == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "strategy backtest service unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.run-portfolio-backtest")
+	defer span.End()
+
+	var req portfolioRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	if req.Days < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a positive integer"})
+		return
+	}
+
+	result, err := h.strategyBacktest.RunPortfolio(ctx, domain.PortfolioConfig{
+		Base:             req.config(),
+		Symbols:          req.Symbols,
+		RiskPerTrade:     req.RiskPerTrade,
+		TargetVolatility: req.TargetVolatility,
+		MaxAssetExposure: req.MaxAssetExposure,
+		MaxGrossExposure: req.MaxGrossExposure,
+	})
+	if err != nil {
+		c.JSON(backtestRunErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ListBacktestRuns godoc
+// @Summary      List stored strategy backtest runs
+// @Description  Returns the most recent persisted runs with their parameters, engine version and metrics (equity curves and trades omitted)
+// @Tags         backtest
+// @Produce      json
+// @Param        limit  query  int  false  "Max runs (1-200, default 50)"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/backtest/runs [get]
+func (h *Handler) ListBacktestRuns(c *gin.Context) {
+	if h.strategyBacktest == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "strategy backtest service unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.list-backtest-runs")
+	defer span.End()
+
+	limit := 50
+	if rawLimit := c.Query("limit"); rawLimit != "" {
+		n, err := strconv.Atoi(rawLimit)
+		if err != nil || n <= 0 || n > 200 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 200"})
+			return
+		}
+		limit = n
+	}
+
+	runs, err := h.strategyBacktest.ListRuns(ctx, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"runs": runs})
+}
+
+// GetBacktestRun godoc
+// @Summary      Get a stored strategy backtest run
+// @Description  Returns one persisted run with its parameters, metrics, equity curve and trades
+// @Tags         backtest
+// @Produce      json
+// @Param        id  path  int  true  "Run ID"
+// @Success      200  {object}  domain.BacktestRun
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/backtest/runs/{id} [get]
+func (h *Handler) GetBacktestRun(c *gin.Context) {
+	if h.strategyBacktest == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "strategy backtest service unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.get-backtest-run")
+	defer span.End()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be a positive integer"})
+		return
+	}
+
+	run, err := h.strategyBacktest.GetRun(ctx, id)
+	if err != nil {
+		c.JSON(backtestRunErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, run)
+}
+
+// CompareBacktestRuns godoc
+// @Summary      Compare two strategy backtest runs
+// @Description  Diffs two persisted runs metric by metric (delta = b - a) and lists the parameters that differ
+// @Tags         backtest
+// @Produce      json
+// @Param        a  query  int  true  "Baseline run ID"
+// @Param        b  query  int  true  "Candidate run ID"
+// @Success      200  {object}  domain.BacktestRunComparison
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/backtest/runs/compare [get]
+func (h *Handler) CompareBacktestRuns(c *gin.Context) {
+	if h.strategyBacktest == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "strategy backtest service unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.compare-backtest-runs")
+	defer span.End()
+
+	a, errA := strconv.ParseInt(c.Query("a"), 10, 64)
+	b, errB := strconv.ParseInt(c.Query("b"), 10, 64)
+	if errA != nil || errB != nil || a <= 0 || b <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a and b must be positive run ids"})
+		return
+	}
+
+	cmp, err := h.strategyBacktest.CompareRuns(ctx, a, b)
+	if err != nil {
+		c.JSON(backtestRunErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, cmp)
+}
+
+// RunBacktestMonteCarlo godoc
+// @Summary      Run a Monte Carlo robustness analysis
+// @Description  Bootstraps or reshuffles a stored run's trades, optionally with random extra slippage, and stores percentile bands of final return, max drawdown and time to recovery with the run
+// @Tags         backtest
+// @Accept       json
+// @Produce      json
+// @Param        id       path  int                      true   "Run ID"
+// @Param        request  body  domain.MonteCarloConfig  false  "simulations (default 1000), method (bootstrap|shuffle), slippage_bps, seed"
+// @Success      200  {object}  domain.MonteCarloResult
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/backtest/runs/{id}/montecarlo [post]
+func (h *Handler) RunBacktestMonteCarlo(c *gin.Context) {
+	if h.strategyBacktest == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "strategy backtest service unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.run-backtest-monte-carlo")
+	defer span.End()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be a positive integer"})
+		return
+	}
+	// The body is optional; an empty one runs with the defaults.
+	var cfg domain.MonteCarloConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	res, err := h.strategyBacktest.RunMonteCarlo(ctx, id, cfg)
+	if err != nil {
+		c.JSON(backtestRunErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// GetBacktestMonteCarlo godoc
+// @Summary      Get a stored Monte Carlo analysis
+// @Description  Returns the last Monte Carlo robustness analysis stored with a run
+// @Tags         backtest
+// @Produce      json
+// @Param        id  path  int  true  "Run ID"
+// @Success      200  {object}  domain.MonteCarloResult
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/backtest/runs/{id}/montecarlo [get]
+func (h *Handler) GetBacktestMonteCarlo(c *gin.Context) {
+	if h.strategyBacktest == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "strategy backtest service unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.get-backtest-monte-carlo")
+	defer span.End()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be a positive integer"})
+		return
+	}
+
+	res, err := h.strategyBacktest.GetMonteCarlo(ctx, id)
+	if err != nil {
+		c.JSON(backtestRunErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// GetBacktestMonteCarloChart godoc
+// @Summary      Get a Monte Carlo fan chart
+// @Description  Renders the stored Monte Carlo analysis of a run as a fan chart of cumulative return per trade
+// @Tags         backtest
+// @Produce      png
+// @Param        id  path  int  true  "Run ID"
+// @Success      200  {file}    binary
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/backtest/runs/{id}/montecarlo/chart [get]
+func (h *Handler) GetBacktestMonteCarloChart(c *gin.Context) {
+	if h.strategyBacktest == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "strategy backtest service unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.get-backtest-monte-carlo-chart")
+	defer span.End()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be a positive integer"})
+		return
+	}
+
+	png, err := h.strategyBacktest.MonteCarloChart(ctx, id)
+	if err != nil {
+		c.JSON(backtestRunErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "image/png", png)
+}
+
+func backtestRunErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidBacktestConfig):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrBacktestRunNotFound), errors.Is(err, service.ErrMonteCarloNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -3,9 +3,12 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"bug-free-umbrella/internal/domain"
 	"bug-free-umbrella/internal/repository"
@@ -51,3 +54,321 @@ func TestGetBacktestSummary(t *testing.T) {
 		t.Fatalf("expected summary field")
 	}
 }
+
+type strategyBacktestRunnerStub struct {
+	lastCfg   domain.BacktestConfig
+	err       error
+	runs      map[int64]*domain.BacktestRun
+	lastLimit int
+
+	lastMonteCarlo domain.MonteCarloConfig
+	lastPortfolio  domain.PortfolioConfig
+}
+
+func (s *strategyBacktestRunnerStub) ListRuns(ctx context.Context, limit int) ([]domain.BacktestRun, error) {
+	s.lastLimit = limit
+	out := make([]domain.BacktestRun, 0, len(s.runs))
+	for _, r := range s.runs {
+		out = append(out, *r)
+	}
+	return out, nil
+}
+
+func (s *strategyBacktestRunnerStub) GetRun(ctx context.Context, id int64) (*domain.BacktestRun, error) {
+	run, ok := s.runs[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", service.ErrBacktestRunNotFound, id)
+	}
+	return run, nil
+}
+
+func (s *strategyBacktestRunnerStub) CompareRuns(ctx context.Context, a, b int64) (*domain.BacktestRunComparison, error) {
+	runA, err := s.GetRun(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+	runB, err := s.GetRun(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.BacktestRunComparison{
+		A:       *runA,
+		B:       *runB,
+		Metrics: []domain.MetricDiff{{Metric: "total_return", A: runA.Metrics.TotalReturn, B: runB.Metrics.TotalReturn, Delta: runB.Metrics.TotalReturn - runA.Metrics.TotalReturn}},
+	}, nil
+}
+
+func (s *strategyBacktestRunnerStub) RunMonteCarlo(ctx context.Context, runID int64, cfg domain.MonteCarloConfig) (*domain.MonteCarloResult, error) {
+	if _, err := s.GetRun(ctx, runID); err != nil {
+		return nil, err
+	}
+	if cfg.Method != "" && cfg.Method != domain.MonteCarloBootstrap && cfg.Method != domain.MonteCarloShuffle {
+		return nil, fmt.Errorf("%w: unsupported method", service.ErrInvalidBacktestConfig)
+	}
+	s.lastMonteCarlo = cfg
+	return &domain.MonteCarloResult{RunID: runID, Config: cfg, FinalReturn: domain.PercentileBand{P50: 0.05}}, nil
+}
+
+func (s *strategyBacktestRunnerStub) GetMonteCarlo(ctx context.Context, runID int64) (*domain.MonteCarloResult, error) {
+	if runID != 1 {
+		return nil, fmt.Errorf("%w: run %d", service.ErrMonteCarloNotFound, runID)
+	}
+	return &domain.MonteCarloResult{RunID: runID}, nil
+}
+
+func (s *strategyBacktestRunnerStub) MonteCarloChart(ctx context.Context, runID int64) ([]byte, error) {
+	if _, err := s.GetMonteCarlo(ctx, runID); err != nil {
+		return nil, err
+	}
+	return []byte("\x89PNG"), nil
+}
+
+func (s *strategyBacktestRunnerStub) RunStrategy(ctx context.Context, cfg domain.BacktestConfig) (*domain.BacktestResult, error) {
+	s.lastCfg = cfg
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &domain.BacktestResult{
+		Config:  cfg,
+		Metrics: domain.BacktestMetrics{Trades: 2, WinRate: 0.5},
+		Trades:  []domain.BacktestTrade{{Indicator: domain.IndicatorRSI}, {Indicator: domain.IndicatorMACD}},
+	}, nil
+}
+
+func (s *strategyBacktestRunnerStub) RunPortfolio(ctx context.Context, cfg domain.PortfolioConfig) (*domain.PortfolioResult, error) {
+	s.lastPortfolio = cfg
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &domain.PortfolioResult{
+		Config: cfg,
+		Assets: []domain.AssetContribution{{Symbol: "BTC", Trades: 1}, {Symbol: "ETH"}},
+	}, nil
+}
+
+func TestRunStrategyBacktest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	runner := &strategyBacktestRunnerStub{}
+	h := &Handler{tracer: trace.NewNoopTracerProvider().Tracer("handler-test")}
+	h.SetStrategyBacktestRunner(runner)
+
+	r := gin.New()
+	r.POST("/api/backtest/run", h.RunStrategyBacktest)
+
+	body := `{"symbol":"BTC","interval":"4h","days":30,"fee_bps":0,"hold_bars":6,"use_stops":false}`
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/backtest/run", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	cfg := runner.lastCfg
+	if cfg.Symbol != "BTC" || cfg.Interval != "4h" || cfg.HoldBars != 6 {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+	if cfg.FeeBps != 0 || cfg.SlippageBps != service.DefaultBacktestSlippageBps || cfg.UseStops {
+		t.Fatalf("expected explicit fee and stops, default slippage: %+v", cfg)
+	}
+	if got := cfg.To.Sub(cfg.From); got != 30*24*time.Hour {
+		t.Fatalf("expected 30-day window, got %s", got)
+	}
+
+	var resp domain.BacktestResult
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if resp.Metrics.Trades != 2 || len(resp.Trades) != 2 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+}
+
+func TestRunStrategyBacktestErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tracer := trace.NewNoopTracerProvider().Tracer("handler-test")
+
+	unavailable := &Handler{tracer: tracer}
+	r := gin.New()
+	r.POST("/api/backtest/run", unavailable.RunStrategyBacktest)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/backtest/run", strings.NewReader(`{}`)))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", w.Code)
+	}
+
+	h := &Handler{tracer: tracer}
+	h.SetStrategyBacktestRunner(&strategyBacktestRunnerStub{err: fmt.Errorf("%w: unsupported symbol", service.ErrInvalidBacktestConfig)})
+	r = gin.New()
+	r.POST("/api/backtest/run", h.RunStrategyBacktest)
+
+	for _, body := range []string{`{"symbol":`, `{"symbol":"FAKE"}`} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/backtest/run", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("body %s: expected 400, got %d", body, w.Code)
+		}
+	}
+}
+
+func TestBacktestRunEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	runner := &strategyBacktestRunnerStub{runs: map[int64]*domain.BacktestRun{
+		1: {ID: 1, EngineVersion: "engine/1", Config: domain.BacktestConfig{Symbol: "BTC"}, Metrics: domain.BacktestMetrics{TotalReturn: 0.1}},
+		2: {ID: 2, EngineVersion: "engine/1", Config: domain.BacktestConfig{Symbol: "BTC"}, Metrics: domain.BacktestMetrics{TotalReturn: 0.25},
+			Equity: []domain.EquityPoint{{Equity: 10000}, {Equity: 12500}}},
+	}}
+	h := &Handler{tracer: trace.NewNoopTracerProvider().Tracer("handler-test")}
+	h.SetStrategyBacktestRunner(runner)
+	r := gin.New()
+	h.RegisterRoutes(r)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/backtest/runs?limit=5", nil))
+	if w.Code != http.StatusOK || runner.lastLimit != 5 {
+		t.Fatalf("list: expected 200 with limit 5, got %d (limit %d)", w.Code, runner.lastLimit)
+	}
+	var list struct {
+		Runs []domain.BacktestRun `json:"runs"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || len(list.Runs) != 2 {
+		t.Fatalf("list: unexpected body %s (%v)", w.Body.String(), err)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/backtest/runs/2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("get: expected 200, got %d", w.Code)
+	}
+	var run domain.BacktestRun
+	if err := json.Unmarshal(w.Body.Bytes(), &run); err != nil || run.ID != 2 || len(run.Equity) != 2 {
+		t.Fatalf("get: unexpected body %s (%v)", w.Body.String(), err)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/backtest/runs/compare?a=1&b=2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("compare: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var cmp domain.BacktestRunComparison
+	if err := json.Unmarshal(w.Body.Bytes(), &cmp); err != nil || len(cmp.Metrics) != 1 || cmp.Metrics[0].Delta < 0.149 {
+		t.Fatalf("compare: unexpected body %s (%v)", w.Body.String(), err)
+	}
+
+	cases := []struct {
+		path string
+		code int
+	}{
+		{"/api/backtest/runs?limit=0", http.StatusBadRequest},
+		{"/api/backtest/runs/abc", http.StatusBadRequest},
+		{"/api/backtest/runs/9", http.StatusNotFound},
+		{"/api/backtest/runs/compare?a=1", http.StatusBadRequest},
+		{"/api/backtest/runs/compare?a=1&b=9", http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if w.Code != tc.code {
+			t.Fatalf("%s: expected %d, got %d", tc.path, tc.code, w.Code)
+		}
+	}
+}
+
+func TestBacktestMonteCarloEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	runner := &strategyBacktestRunnerStub{runs: map[int64]*domain.BacktestRun{1: {ID: 1}, 2: {ID: 2}}}
+	h := &Handler{tracer: trace.NewNoopTracerProvider().Tracer("handler-test")}
+	h.SetStrategyBacktestRunner(runner)
+	r := gin.New()
+	h.RegisterRoutes(r)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/backtest/runs/1/montecarlo",
+		strings.NewReader(`{"simulations":500,"method":"shuffle","slippage_bps":10,"seed":4}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("run: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if cfg := runner.lastMonteCarlo; cfg.Simulations != 500 || cfg.Method != domain.MonteCarloShuffle || cfg.SlippageBps != 10 || cfg.Seed != 4 {
+		t.Fatalf("run: unexpected config %+v", cfg)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/backtest/runs/2/montecarlo", nil))
+	if w.Code != http.StatusOK || runner.lastMonteCarlo != (domain.MonteCarloConfig{}) {
+		t.Fatalf("run without body: expected 200 with defaults, got %d %+v", w.Code, runner.lastMonteCarlo)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/backtest/runs/1/montecarlo/chart", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("chart: expected png, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	cases := []struct {
+		method, path, body string
+		code               int
+	}{
+		{http.MethodGet, "/api/backtest/runs/1/montecarlo", "", http.StatusOK},
+		{http.MethodGet, "/api/backtest/runs/2/montecarlo", "", http.StatusNotFound},
+		{http.MethodGet, "/api/backtest/runs/2/montecarlo/chart", "", http.StatusNotFound},
+		{http.MethodPost, "/api/backtest/runs/9/montecarlo", "", http.StatusNotFound},
+		{http.MethodPost, "/api/backtest/runs/1/montecarlo", `{"method":"jackknife"}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/backtest/runs/x/montecarlo", "", http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+		if w.Code != tc.code {
+			t.Fatalf("%s %s: expected %d, got %d", tc.method, tc.path, tc.code, w.Code)
+		}
+	}
+}
+
+func TestRunPortfolioBacktest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	runner := &strategyBacktestRunnerStub{}
+	h := &Handler{tracer: trace.NewNoopTracerProvider().Tracer("handler-test")}
+	h.SetStrategyBacktestRunner(runner)
+	r := gin.New()
+	h.RegisterRoutes(r)
+
+	body := `{"symbols":["BTC","ETH"],"interval":"4h","days":60,"max_risk":3,"risk_per_trade":0.01,"target_volatility":0.2,"max_asset_exposure":0.3}`
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/backtest/portfolio", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	cfg := runner.lastPortfolio
+	if len(cfg.Symbols) != 2 || cfg.RiskPerTrade != 0.01 || cfg.TargetVolatility != 0.2 || cfg.MaxAssetExposure != 0.3 || cfg.MaxGrossExposure != 0 {
+		t.Fatalf("unexpected portfolio config: %+v", cfg)
+	}
+	if cfg.Base.Interval != "4h" || cfg.Base.MaxRisk != domain.RiskLevel3 || !cfg.Base.UseStops || cfg.Base.FeeBps != service.DefaultBacktestFeeBps {
+		t.Fatalf("unexpected base config: %+v", cfg.Base)
+	}
+	if got := cfg.Base.To.Sub(cfg.Base.From); got != 60*24*time.Hour {
+		t.Fatalf("expected 60-day window, got %s", got)
+	}
+	var resp domain.PortfolioResult
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if len(resp.Assets) != 2 || resp.Assets[0].Symbol != "BTC" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+
+	runner.err = fmt.Errorf("%w: risk_per_trade must be between 0 and 0.1", service.ErrInvalidBacktestConfig)
+	for _, body := range []string{`{"symbols":`, `{"days":-1}`, `{"risk_per_trade":1}`} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/backtest/portfolio", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("body %s: expected 400, got %d", body, w.Code)
+		}
+	}
+
+	unavailable := &Handler{tracer: trace.NewNoopTracerProvider().Tracer("handler-test")}
+	r = gin.New()
+	unavailable.RegisterRoutes(r)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/backtest/portfolio", strings.NewReader(`{}`)))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", w.Code)
+	}
+}
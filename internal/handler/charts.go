@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ChartRenderer interface {
+	RenderChart(ctx context.Context, req domain.ChartRequest) ([]byte, error)
+}
+
+// GetChart godoc
+// @Summary      Render a candle chart
+// @Description  Renders candles for any tracked symbol and interval with optional overlays (ema, bollinger, vwap) and panes (rsi, macd, volume). Images are cached by parameters.
+// @Tags         charts
+// @Produce      png
+// @Param        symbol    path   string  true   "Symbol followed by .png, e.g. BTC.png"
+// @Param        interval  query  string  false  "Candle interval (5m, 15m, 1h, 4h, 1d)"  default(1h)
+// @Param        lookback  query  string  false  "Candle count or duration such as 7d"
+// @Param        overlays  query  string  false  "Comma-separated overlays"
+// @Param        panes     query  string  false  "Comma-separated panes"
+// @Success      200  {file}    binary
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/charts/{symbol}.png [get]
+func (h *Handler) GetChart(c *gin.Context) {
+	if h.charts == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "chart service unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.get-chart")
+	defer span.End()
+
+	symbol, ok := strings.CutSuffix(c.Param("file"), ".png")
+	if !ok || symbol == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "chart path must be /api/charts/{symbol}.png"})
+		return
+	}
+	interval := c.Query("interval")
+	candles, err := service.ChartLookbackCandles(interval, c.Query("lookback"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	png, err := h.charts.RenderChart(ctx, domain.ChartRequest{
+		Symbol:   symbol,
+		Interval: interval,
+		Candles:  candles,
+		Overlays: splitQueryList(c.Query("overlays")),
+		Panes:    splitQueryList(c.Query("panes")),
+	})
+	if err != nil {
+		c.JSON(chartErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "image/png", png)
+}
+
+func splitQueryList(raw string) []string {
+	if strings.TrimSpace(raw) == "" {
+		return nil
+	}
+	return strings.Split(raw, ",")
+}
+
+func chartErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidChart):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrChartNoData):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
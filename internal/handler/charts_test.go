@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type chartRendererStub struct {
+	lastReq domain.ChartRequest
+}
+
+func (s *chartRendererStub) RenderChart(ctx context.Context, req domain.ChartRequest) ([]byte, error) {
+	s.lastReq = req
+	switch req.Symbol {
+	case "SHIB":
+		return nil, fmt.Errorf("%w: unsupported symbol", service.ErrInvalidChart)
+	case "DOT":
+		return nil, service.ErrChartNoData
+	}
+	return []byte{0x89, 0x50, 0x4e, 0x47}, nil
+}
+
+func newChartsTestRouter(stub ChartRenderer) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{tracer: trace.NewNoopTracerProvider().Tracer("handler-test")}
+	if stub != nil {
+		h.SetCharts(stub)
+	}
+	r := gin.New()
+	h.RegisterRoutes(r)
+	return r
+}
+
+func TestGetChartUnavailable(t *testing.T) {
+	r := newChartsTestRouter(nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/charts/BTC.png", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", w.Code)
+	}
+}
+
+func TestGetChartParsesQuery(t *testing.T) {
+	stub := &chartRendererStub{}
+	r := newChartsTestRouter(stub)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/charts/btc.png?interval=4h&lookback=7d&overlays=ema,vwap&panes=rsi", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("expected png, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	req := stub.lastReq
+	if req.Symbol != "btc" || req.Interval != "4h" || req.Candles != 42 ||
+		len(req.Overlays) != 2 || req.Overlays[1] != "vwap" || len(req.Panes) != 1 || req.Panes[0] != "rsi" {
+		t.Fatalf("unexpected request: %+v", req)
+	}
+}
+
+func TestGetChartErrors(t *testing.T) {
+	r := newChartsTestRouter(&chartRendererStub{})
+	cases := map[string]int{
+		"/api/charts/BTC":                 http.StatusNotFound,
+		"/api/charts/BTC.png?lookback=7y": http.StatusBadRequest,
+		"/api/charts/SHIB.png":            http.StatusBadRequest,
+		"/api/charts/DOT.png":             http.StatusNotFound,
+	}
+	for path, want := range cases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != want {
+			t.Fatalf("%s: expected %d, got %d", path, want, w.Code)
+		}
+	}
+}
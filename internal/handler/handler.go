@@ -13,8 +13,16 @@ type Handler struct {
 	priceService      *service.PriceService
 	signalService     *service.SignalService
 	backtestService   *service.BacktestService
+	strategyBacktest  StrategyBacktestRunner
+	optimizer         StrategyOptimizer
+	mlAnalytics       MLAnalyticsQuerier
 	mlTrainer         MLTrainingRunner
 	marketIntelRunner MarketIntelRunner
+	paper             PaperTrader
+	holdings          HoldingsManager
+	priceAlerts       PriceAlertManager
+	charts            ChartRenderer
+	notifications     NotificationManager
 }
 
 func New(
@@ -43,15 +51,87 @@ func (h *Handler) SetBacktestService(svc *service.BacktestService) {
 	h.backtestService = svc
 }
 
+func (h *Handler) SetStrategyBacktestRunner(runner StrategyBacktestRunner) {
+	h.strategyBacktest = runner
+}
+
+func (h *Handler) SetStrategyOptimizer(optimizer StrategyOptimizer) {
+	h.optimizer = optimizer
+}
+
+func (h *Handler) SetMLAnalytics(analytics MLAnalyticsQuerier) {
+	h.mlAnalytics = analytics
+}
+
+func (h *Handler) SetPaperTrading(paper PaperTrader) {
+	h.paper = paper
+}
+
+func (h *Handler) SetHoldings(holdings HoldingsManager) {
+	h.holdings = holdings
+}
+
+func (h *Handler) SetPriceAlerts(alerts PriceAlertManager) {
+	h.priceAlerts = alerts
+}
+
+func (h *Handler) SetCharts(charts ChartRenderer) {
+	h.charts = charts
+}
+
+func (h *Handler) SetNotifications(notifications NotificationManager) {
+	h.notifications = notifications
+}
+
 func (h *Handler) RegisterRoutes(r gin.IRouter) {
 	r.GET("/api/prices", h.GetAllPrices)
 	r.GET("/api/prices/:symbol", h.GetPrice)
 	r.GET("/api/candles/:symbol", h.GetCandles)
 	r.GET("/api/signals", h.GetSignals)
+	r.GET("/api/signals/risk-distribution", h.GetSignalRiskDistribution)
+	r.GET("/api/signals/params", h.GetSignalParams)
 	r.GET("/api/signals/:id/image", h.GetSignalImage)
+	r.GET("/api/charts/:file", h.GetChart)
 	r.GET("/api/backtest/summary", h.GetBacktestSummary)
 	r.GET("/api/backtest/daily", h.GetBacktestDaily)
 	r.GET("/api/backtest/predictions", h.GetBacktestPredictions)
+	r.GET("/api/backtest/analytics", h.GetMLAnalytics)
+	r.POST("/api/backtest/run", h.RunStrategyBacktest)
+	r.POST("/api/backtest/portfolio", h.RunPortfolioBacktest)
+	r.GET("/api/backtest/runs", h.ListBacktestRuns)
+	r.GET("/api/backtest/runs/compare", h.CompareBacktestRuns)
+	r.GET("/api/backtest/runs/:id", h.GetBacktestRun)
+	r.POST("/api/backtest/runs/:id/montecarlo", h.RunBacktestMonteCarlo)
+	r.GET("/api/backtest/runs/:id/montecarlo", h.GetBacktestMonteCarlo)
+	r.GET("/api/backtest/runs/:id/montecarlo/chart", h.GetBacktestMonteCarloChart)
+	r.POST("/api/backtest/optimize", h.StartOptimization)
+	r.GET("/api/backtest/optimize", h.ListOptimizations)
+	r.GET("/api/backtest/optimize/:id", h.GetOptimization)
+	r.POST("/api/backtest/optimize/:id/promote", h.PromoteOptimization)
+	r.POST("/api/paper/accounts", h.CreatePaperAccount)
+	r.GET("/api/paper/accounts", h.ListPaperAccounts)
+	r.GET("/api/paper/accounts/:id", h.GetPaperPortfolio)
+	r.POST("/api/paper/accounts/:id/orders", h.PlacePaperOrder)
+	r.GET("/api/paper/accounts/:id/orders", h.ListPaperOrders)
+	r.POST("/api/paper/accounts/:id/subscriptions", h.CreatePaperSubscription)
+	r.GET("/api/paper/accounts/:id/subscriptions", h.ListPaperSubscriptions)
+	r.DELETE("/api/paper/accounts/:id/subscriptions/:subId", h.DeletePaperSubscription)
+	r.GET("/api/holdings/:chatId", h.GetHoldings)
+	r.GET("/api/holdings/:chatId/trades", h.ListHoldingTrades)
+	r.POST("/api/holdings/:chatId/trades", h.AddHoldingTrade)
+	r.POST("/api/holdings/:chatId/import", h.ImportHoldingTrades)
+	r.DELETE("/api/holdings/:chatId/trades/:tradeId", h.DeleteHoldingTrade)
+	r.PUT("/api/holdings/:chatId/advisor", h.SetHoldingsAdvisorSharing)
+	r.GET("/api/alerts/:chatId", h.ListPriceAlerts)
+	r.POST("/api/alerts/:chatId", h.CreatePriceAlert)
+	r.DELETE("/api/alerts/:chatId/:alertId", h.DeletePriceAlert)
+	r.GET("/api/notifications/channels", h.ListNotificationChannels)
+	r.POST("/api/notifications/channels", h.CreateNotificationChannel)
+	r.GET("/api/notifications/channels/:id", h.GetNotificationChannel)
+	r.PUT("/api/notifications/channels/:id", h.UpdateNotificationChannel)
+	r.DELETE("/api/notifications/channels/:id", h.DeleteNotificationChannel)
+	r.POST("/api/notifications/channels/:id/test", h.TestNotificationChannel)
+	r.GET("/api/notifications/channels/:id/deliveries", h.ListNotificationDeliveries)
 	r.POST("/api/ml/train", h.TriggerMLTraining)
 	r.POST("/api/market-intel/run", h.TriggerMarketIntelRun)
 }
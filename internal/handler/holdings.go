@@ -0,0 +1,286 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxHoldingsImportBytes caps the size of an uploaded trade history.
+const maxHoldingsImportBytes = 5 << 20
+
+type HoldingsManager interface {
+	Valuation(ctx context.Context, chatID int64) (*domain.HoldingsValuation, error)
+	Trades(ctx context.Context, chatID int64) ([]domain.HoldingTrade, error)
+	AddTrade(ctx context.Context, t domain.HoldingTrade) (*domain.HoldingTrade, error)
+	ImportCSV(ctx context.Context, chatID int64, r io.Reader) (*domain.HoldingsImportResult, error)
+	DeleteTrade(ctx context.Context, chatID, id int64) error
+	SetShareWithAdvisor(ctx context.Context, chatID int64, share bool) error
+}
+
+type holdingTradeRequest struct {
+	Symbol     string     `json:"symbol" binding:"required"`
+	Side       string     `json:"side" binding:"required"`
+	Quantity   float64    `json:"quantity" binding:"required"`
+	Price      float64    `json:"price" binding:"required"`
+	Fee        float64    `json:"fee"`
+	ExecutedAt *time.Time `json:"executed_at"`
+}
+
+type holdingsAdvisorRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// GetHoldings godoc
+// @Summary      Get a user's holdings
+// @Description  Values the user's open holdings at current prices with FIFO cost basis, allocation and realised/unrealised PnL. chatId is the Telegram chat ID or the SSH user's chat ID
+// @Tags         holdings
+// @Produce      json
+// @Param        chatId  path  int  true  "User chat ID"
+// @Success      200  {object}  domain.HoldingsValuation
+// @Failure      400  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/holdings/{chatId} [get]
+func (h *Handler) GetHoldings(c *gin.Context) {
+	if h.holdings == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "holdings service unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.get-holdings")
+	defer span.End()
+
+	chatID, ok := chatIDParam(c)
+	if !ok {
+		return
+	}
+	v, err := h.holdings.Valuation(ctx, chatID)
+	if err != nil {
+		c.JSON(holdingsErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, v)
+}
+
+// ListHoldingTrades godoc
+// @Summary      List a user's holding trades
+// @Tags         holdings
+// @Produce      json
+// @Param        chatId  path  int  true  "User chat ID"
+// @Success      200  {object}  map[string][]domain.HoldingTrade
+// @Failure      400  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/holdings/{chatId}/trades [get]
+func (h *Handler) ListHoldingTrades(c *gin.Context) {
+	if h.holdings == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "holdings service unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.list-holding-trades")
+	defer span.End()
+
+	chatID, ok := chatIDParam(c)
+	if !ok {
+		return
+	}
+	trades, err := h.holdings.Trades(ctx, chatID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trades": trades})
+}
+
+// AddHoldingTrade godoc
+// @Summary      Record a holding trade
+// @Description  Adds a manual buy or sell. executed_at defaults to now
+// @Tags         holdings
+// @Accept       json
+// @Produce      json
+// @Param        chatId   path  int                  true  "User chat ID"
+// @Param        request  body  holdingTradeRequest  true  "Trade"
+// @Success      201  {object}  domain.HoldingTrade
+// @Failure      400  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/holdings/{chatId}/trades [post]
+func (h *Handler) AddHoldingTrade(c *gin.Context) {
+	if h.holdings == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "holdings service unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.add-holding-trade")
+	defer span.End()
+
+	chatID, ok := chatIDParam(c)
+	if !ok {
+		return
+	}
+	var req holdingTradeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	trade := domain.HoldingTrade{
+		ChatID:   chatID,
+		Symbol:   req.Symbol,
+		Side:     domain.PaperSide(req.Side),
+		Quantity: req.Quantity,
+		Price:    req.Price,
+		Fee:      req.Fee,
+	}
+	if req.ExecutedAt != nil {
+		trade.ExecutedAt = *req.ExecutedAt
+	}
+	out, err := h.holdings.AddTrade(ctx, trade)
+	if err != nil {
+		c.JSON(holdingsErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, out)
+}
+
+// ImportHoldingTrades godoc
+// @Summary      Import exchange trade history
+// @Description  Imports a CSV trade-history export sent as the request body. Columns are matched by header name (time/date, symbol/pair, side, quantity/amount, price, optional fee). Re-importing the same rows adds nothing
+// @Tags         holdings
+// @Accept       text/csv
+// @Produce      json
+// @Param        chatId  path  int  true  "User chat ID"
+// @Success      200  {object}  domain.HoldingsImportResult
+// @Failure      400  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/holdings/{chatId}/import [post]
+func (h *Handler) ImportHoldingTrades(c *gin.Context) {
+	if h.holdings == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "holdings service unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.import-holding-trades")
+	defer span.End()
+
+	chatID, ok := chatIDParam(c)
+	if !ok {
+		return
+	}
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxHoldingsImportBytes)
+	res, err := h.holdings.ImportCSV(ctx, chatID, body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "csv too large"})
+			return
+		}
+		c.JSON(holdingsErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// DeleteHoldingTrade godoc
+// @Summary      Delete a holding trade
+// @Tags         holdings
+// @Param        chatId   path  int  true  "User chat ID"
+// @Param        tradeId  path  int  true  "Trade ID"
+// @Success      204
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/holdings/{chatId}/trades/{tradeId} [delete]
+func (h *Handler) DeleteHoldingTrade(c *gin.Context) {
+	if h.holdings == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "holdings service unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.delete-holding-trade")
+	defer span.End()
+
+	chatID, ok := chatIDParam(c)
+	if !ok {
+		return
+	}
+	tradeID, err := strconv.ParseInt(c.Param("tradeId"), 10, 64)
+	if err != nil || tradeID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tradeId must be a positive integer"})
+		return
+	}
+	if err := h.holdings.DeleteTrade(ctx, chatID, tradeID); err != nil {
+		c.JSON(holdingsErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// SetHoldingsAdvisorSharing godoc
+// @Summary      Share holdings with the advisor
+// @Description  Opts the user in or out of having their holdings included in advisor answers
+// @Tags         holdings
+// @Accept       json
+// @Param        chatId   path  int                     true  "User chat ID"
+// @Param        request  body  holdingsAdvisorRequest  true  "Opt-in"
+// @Success      204
+// @Failure      400  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/holdings/{chatId}/advisor [put]
+func (h *Handler) SetHoldingsAdvisorSharing(c *gin.Context) {
+	if h.holdings == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "holdings service unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.set-holdings-advisor-sharing")
+	defer span.End()
+
+	chatID, ok := chatIDParam(c)
+	if !ok {
+		return
+	}
+	var req holdingsAdvisorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	if err := h.holdings.SetShareWithAdvisor(ctx, chatID, *req.Enabled); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// chatIDParam reads the chatId path parameter. Chat IDs can be negative
+// (Telegram groups, SSH users) but never zero.
+func chatIDParam(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("chatId"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "chatId must be a non-zero integer"})
+		return 0, false
+	}
+	return id, true
+}
+
+func holdingsErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidHoldingTrade):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrHoldingTradeNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type holdingsManagerStub struct {
+	lastTrade  domain.HoldingTrade
+	lastCSV    string
+	lastShare  *bool
+	lastChatID int64
+}
+
+func (s *holdingsManagerStub) Valuation(ctx context.Context, chatID int64) (*domain.HoldingsValuation, error) {
+	s.lastChatID = chatID
+	return &domain.HoldingsValuation{ChatID: chatID, MarketValue: 1234}, nil
+}
+
+func (s *holdingsManagerStub) Trades(ctx context.Context, chatID int64) ([]domain.HoldingTrade, error) {
+	return []domain.HoldingTrade{{ID: 1, ChatID: chatID}}, nil
+}
+
+func (s *holdingsManagerStub) AddTrade(ctx context.Context, t domain.HoldingTrade) (*domain.HoldingTrade, error) {
+	s.lastTrade = t
+	if t.Side != domain.PaperBuy && t.Side != domain.PaperSell {
+		return nil, service.ErrInvalidHoldingTrade
+	}
+	t.ID = 5
+	return &t, nil
+}
+
+func (s *holdingsManagerStub) ImportCSV(ctx context.Context, chatID int64, r io.Reader) (*domain.HoldingsImportResult, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	s.lastCSV = string(raw)
+	return &domain.HoldingsImportResult{Imported: 2}, nil
+}
+
+func (s *holdingsManagerStub) DeleteTrade(ctx context.Context, chatID, id int64) error {
+	if id != 5 {
+		return service.ErrHoldingTradeNotFound
+	}
+	return nil
+}
+
+func (s *holdingsManagerStub) SetShareWithAdvisor(ctx context.Context, chatID int64, share bool) error {
+	s.lastShare = &share
+	return nil
+}
+
+func newHoldingsTestRouter(stub HoldingsManager) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{tracer: trace.NewNoopTracerProvider().Tracer("handler-test")}
+	if stub != nil {
+		h.SetHoldings(stub)
+	}
+	r := gin.New()
+	h.RegisterRoutes(r)
+	return r
+}
+
+func TestGetHoldingsAcceptsNegativeChatID(t *testing.T) {
+	stub := &holdingsManagerStub{}
+	r := newHoldingsTestRouter(stub)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/holdings/-1000003", nil))
+	if w.Code != http.StatusOK || stub.lastChatID != -1000003 || !strings.Contains(w.Body.String(), `"market_value":1234`) {
+		t.Fatalf("unexpected response %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/holdings/0", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for chat id 0, got %d", w.Code)
+	}
+}
+
+func TestAddAndDeleteHoldingTrade(t *testing.T) {
+	stub := &holdingsManagerStub{}
+	r := newHoldingsTestRouter(stub)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/holdings/42/trades",
+		strings.NewReader(`{"symbol":"BTC","side":"buy","quantity":0.5,"price":30000,"executed_at":"2026-01-01T00:00:00Z"}`)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if stub.lastTrade.ChatID != 42 || stub.lastTrade.ExecutedAt.Year() != 2026 {
+		t.Fatalf("unexpected trade: %+v", stub.lastTrade)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/holdings/42/trades",
+		strings.NewReader(`{"symbol":"BTC","side":"hold","quantity":1,"price":1}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid side, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/holdings/42/trades/5", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/holdings/42/trades/6", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestImportHoldingTradesReadsBody(t *testing.T) {
+	stub := &holdingsManagerStub{}
+	r := newHoldingsTestRouter(stub)
+
+	body := "date,pair,side,price,amount\n2026-01-01,BTCUSDT,BUY,30000,1\n"
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/holdings/42/import", strings.NewReader(body))
+	req.Header.Set("Content-Type", "text/csv")
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || stub.lastCSV != body || !strings.Contains(w.Body.String(), `"imported":2`) {
+		t.Fatalf("unexpected import response %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestSetHoldingsAdvisorSharing(t *testing.T) {
+	stub := &holdingsManagerStub{}
+	r := newHoldingsTestRouter(stub)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/holdings/42/advisor", strings.NewReader(`{"enabled":false}`)))
+	if w.Code != http.StatusNoContent || stub.lastShare == nil || *stub.lastShare {
+		t.Fatalf("expected opt-out to be stored, got %d %v", w.Code, stub.lastShare)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/holdings/42/advisor", strings.NewReader(`{}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without enabled, got %d", w.Code)
+	}
+}
+
+func TestHoldingsRoutesUnavailable(t *testing.T) {
+	r := newHoldingsTestRouter(nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/holdings/42", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", w.Code)
+	}
+}
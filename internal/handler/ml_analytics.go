@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MLAnalyticsQuerier interface {
+	Analyze(ctx context.Context, q domain.MLAnalyticsQuery) (*domain.MLAnalyticsReport, error)
+}
+
+// GetMLAnalytics godoc
+// @Summary      ML prediction analytics
+// @Description  Scores resolved ML predictions by any combination of model, symbol, interval, direction, risk and confidence bucket: accuracy, precision/recall (up as positive), Brier score, log loss, a reliability curve and the cumulative return from following each prediction's direction
+// @Tags         backtest
+// @Produce      json
+// @Param        group_by  query  string  false  "Comma-separated dimensions: model, symbol, interval, direction, risk, confidence" default(model)
+// @Param        model     query  string  false  "Model key filter"
+// @Param        symbol    query  string  false  "Symbol filter"
+// @Param        interval  query  string  false  "Interval filter"
+// @Param        days      query  int     false  "Days of history by target time" default(90)
+// @Success      200  {object}  domain.MLAnalyticsReport
+// @Failure      400  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/backtest/analytics [get]
+func (h *Handler) GetMLAnalytics(c *gin.Context) {
+	if h.mlAnalytics == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "ml analytics service unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.get-ml-analytics")
+	defer span.End()
+
+	q := domain.MLAnalyticsQuery{
+		ModelKey: c.Query("model"),
+		Symbol:   c.Query("symbol"),
+		Interval: c.Query("interval"),
+	}
+	if rawGroupBy := strings.TrimSpace(c.Query("group_by")); rawGroupBy != "" {
+		q.GroupBy = strings.Split(rawGroupBy, ",")
+	}
+	if rawDays := strings.TrimSpace(c.Query("days")); rawDays != "" {
+		n, err := strconv.Atoi(rawDays)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a positive integer"})
+			return
+		}
+		q.To = time.Now().UTC()
+		q.From = q.To.AddDate(0, 0, -n)
+	}
+
+	report, err := h.mlAnalytics.Analyze(ctx, q)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidMLAnalyticsQuery) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
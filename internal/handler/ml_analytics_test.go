@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type mlAnalyticsStub struct {
+	lastQuery domain.MLAnalyticsQuery
+}
+
+func (s *mlAnalyticsStub) Analyze(ctx context.Context, q domain.MLAnalyticsQuery) (*domain.MLAnalyticsReport, error) {
+	s.lastQuery = q
+	for _, dim := range q.GroupBy {
+		if dim == "weather" {
+			return nil, fmt.Errorf("%w: unsupported group_by", service.ErrInvalidMLAnalyticsQuery)
+		}
+	}
+	return &domain.MLAnalyticsReport{
+		Query:  q,
+		Groups: []domain.MLAnalyticsGroup{{Key: "ml_xgboost_up4h / BTC", Metrics: domain.MLMetrics{Predictions: 10, Accuracy: 0.7}}},
+	}, nil
+}
+
+func TestGetMLAnalytics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	stub := &mlAnalyticsStub{}
+	h := &Handler{tracer: trace.NewNoopTracerProvider().Tracer("handler-test")}
+	h.SetMLAnalytics(stub)
+	r := gin.New()
+	h.RegisterRoutes(r)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/backtest/analytics?group_by=model,symbol&model=ml_xgboost_up4h&interval=1h&days=30", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	q := stub.lastQuery
+	if len(q.GroupBy) != 2 || q.GroupBy[1] != "symbol" || q.ModelKey != "ml_xgboost_up4h" || q.Interval != "1h" || q.Symbol != "" {
+		t.Fatalf("unexpected query: %+v", q)
+	}
+	if got := q.To.Sub(q.From); got != 30*24*time.Hour {
+		t.Fatalf("expected 30-day window, got %s", got)
+	}
+	var resp domain.MLAnalyticsReport
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if len(resp.Groups) != 1 || resp.Groups[0].Metrics.Accuracy != 0.7 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+
+	for _, path := range []string{"/api/backtest/analytics?days=-1", "/api/backtest/analytics?group_by=weather"} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", path, w.Code)
+		}
+	}
+
+	unavailable := &Handler{tracer: trace.NewNoopTracerProvider().Tracer("handler-test")}
+	r = gin.New()
+	unavailable.RegisterRoutes(r)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/backtest/analytics", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", w.Code)
+	}
+}
@@ -0,0 +1,321 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationManager interface {
+	CreateChannel(ctx context.Context, ch domain.NotificationChannel) (*domain.NotificationChannel, error)
+	ListChannels(ctx context.Context) ([]domain.NotificationChannel, error)
+	GetChannel(ctx context.Context, id int64) (*domain.NotificationChannel, error)
+	UpdateChannel(ctx context.Context, ch domain.NotificationChannel) (*domain.NotificationChannel, error)
+	DeleteChannel(ctx context.Context, id int64) error
+	TestChannel(ctx context.Context, id int64) error
+	ListDeliveries(ctx context.Context, channelID int64, limit int) ([]domain.NotificationDelivery, error)
+}
+
+type notificationChannelRequest struct {
+	Name       string   `json:"name" binding:"required"`
+	Type       string   `json:"type" binding:"required"`
+	Target     string   `json:"target"`
+	Secret     string   `json:"secret"`
+	Kinds      []string `json:"kinds"`
+	Symbols    []string `json:"symbols"`
+	Intervals  []string `json:"intervals"`
+	Indicators []string `json:"indicators"`
+	MinRisk    int      `json:"min_risk"`
+	MaxRisk    int      `json:"max_risk"`
+	Direction  string   `json:"direction"`
+	Enabled    *bool    `json:"enabled"`
+}
+
+func (r notificationChannelRequest) channel() domain.NotificationChannel {
+	kinds := make([]domain.AlertDeliveryKind, 0, len(r.Kinds))
+	for _, k := range r.Kinds {
+		kinds = append(kinds, domain.AlertDeliveryKind(k))
+	}
+	return domain.NotificationChannel{
+		Name:       r.Name,
+		Type:       domain.NotificationChannelType(r.Type),
+		Target:     r.Target,
+		Secret:     r.Secret,
+		Kinds:      kinds,
+		Symbols:    r.Symbols,
+		Intervals:  r.Intervals,
+		Indicators: r.Indicators,
+		MinRisk:    domain.RiskLevel(r.MinRisk),
+		MaxRisk:    domain.RiskLevel(r.MaxRisk),
+		Direction:  domain.SignalDirection(r.Direction),
+		Enabled:    r.Enabled == nil || *r.Enabled,
+	}
+}
+
+// ListNotificationChannels godoc
+// @Summary      List notification channels
+// @Description  Returns every Discord, Slack, email and webhook channel. Secrets are never returned; has_secret tells whether one is set. Webhook URLs are shown as scheme and host only
+// @Tags         notifications
+// @Produce      json
+// @Success      200  {object}  map[string][]domain.NotificationChannel
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/notifications/channels [get]
+func (h *Handler) ListNotificationChannels(c *gin.Context) {
+	if h.notifications == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "notifications unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.list-notification-channels")
+	defer span.End()
+
+	channels, err := h.notifications.ListChannels(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"channels": channels})
+}
+
+// CreateNotificationChannel godoc
+// @Summary      Create a notification channel
+// @Description  Registers a destination for signals and price alerts. type is discord or slack (target is the incoming webhook URL), email (target is comma-separated addresses) or webhook (target is any URL; with a secret, the JSON body is signed in X-Signature-256 as sha256=<hex HMAC-SHA256>). kinds is signal and/or price_alert (default signal); the filters work like Telegram alert subscriptions, and symbols also filters price alerts
+// @Tags         notifications
+// @Accept       json
+// @Produce      json
+// @Param        request  body  notificationChannelRequest  true  "Channel"
+// @Success      201  {object}  domain.NotificationChannel
+// @Failure      400  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/notifications/channels [post]
+func (h *Handler) CreateNotificationChannel(c *gin.Context) {
+	if h.notifications == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "notifications unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.create-notification-channel")
+	defer span.End()
+
+	var req notificationChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	ch, err := h.notifications.CreateChannel(ctx, req.channel())
+	if err != nil {
+		c.JSON(notificationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, ch)
+}
+
+// GetNotificationChannel godoc
+// @Summary      Get a notification channel
+// @Tags         notifications
+// @Produce      json
+// @Param        id  path  int  true  "Channel ID"
+// @Success      200  {object}  domain.NotificationChannel
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/notifications/channels/{id} [get]
+func (h *Handler) GetNotificationChannel(c *gin.Context) {
+	if h.notifications == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "notifications unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.get-notification-channel")
+	defer span.End()
+
+	id, ok := channelIDParam(c)
+	if !ok {
+		return
+	}
+	ch, err := h.notifications.GetChannel(ctx, id)
+	if err != nil {
+		c.JSON(notificationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ch)
+}
+
+// UpdateNotificationChannel godoc
+// @Summary      Update a notification channel
+// @Description  Replaces the channel's settings. An empty target or secret keeps the current one
+// @Tags         notifications
+// @Accept       json
+// @Produce      json
+// @Param        id       path  int                         true  "Channel ID"
+// @Param        request  body  notificationChannelRequest  true  "Channel"
+// @Success      200  {object}  domain.NotificationChannel
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/notifications/channels/{id} [put]
+func (h *Handler) UpdateNotificationChannel(c *gin.Context) {
+	if h.notifications == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "notifications unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.update-notification-channel")
+	defer span.End()
+
+	id, ok := channelIDParam(c)
+	if !ok {
+		return
+	}
+	var req notificationChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	update := req.channel()
+	update.ID = id
+	ch, err := h.notifications.UpdateChannel(ctx, update)
+	if err != nil {
+		c.JSON(notificationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ch)
+}
+
+// DeleteNotificationChannel godoc
+// @Summary      Delete a notification channel
+// @Description  Removes the channel with its queued notifications and delivery log
+// @Tags         notifications
+// @Param        id  path  int  true  "Channel ID"
+// @Success      204
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/notifications/channels/{id} [delete]
+func (h *Handler) DeleteNotificationChannel(c *gin.Context) {
+	if h.notifications == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "notifications unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.delete-notification-channel")
+	defer span.End()
+
+	id, ok := channelIDParam(c)
+	if !ok {
+		return
+	}
+	if err := h.notifications.DeleteChannel(ctx, id); err != nil {
+		c.JSON(notificationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// TestNotificationChannel godoc
+// @Summary      Send a test notification
+// @Description  Sends a test message to the channel right away, bypassing the queue, and reports the channel's error if it fails
+// @Tags         notifications
+// @Produce      json
+// @Param        id  path  int  true  "Channel ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      502  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/notifications/channels/{id}/test [post]
+func (h *Handler) TestNotificationChannel(c *gin.Context) {
+	if h.notifications == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "notifications unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.test-notification-channel")
+	defer span.End()
+
+	id, ok := channelIDParam(c)
+	if !ok {
+		return
+	}
+	if err := h.notifications.TestChannel(ctx, id); err != nil {
+		c.JSON(notificationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "sent"})
+}
+
+// ListNotificationDeliveries godoc
+// @Summary      List a channel's deliveries
+// @Description  Returns the channel's delivery log, newest first: pending and retrying notifications with their last error, sent ones for 7 days and dead ones until the channel is deleted
+// @Tags         notifications
+// @Produce      json
+// @Param        id     path   int  true   "Channel ID"
+// @Param        limit  query  int  false  "Max deliveries (1-200, default 50)"
+// @Success      200  {object}  map[string][]domain.NotificationDelivery
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/notifications/channels/{id}/deliveries [get]
+func (h *Handler) ListNotificationDeliveries(c *gin.Context) {
+	if h.notifications == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "notifications unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.list-notification-deliveries")
+	defer span.End()
+
+	id, ok := channelIDParam(c)
+	if !ok {
+		return
+	}
+	limit := 50
+	if rawLimit := c.Query("limit"); rawLimit != "" {
+		n, err := strconv.Atoi(rawLimit)
+		if err != nil || n <= 0 || n > 200 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 200"})
+			return
+		}
+		limit = n
+	}
+	deliveries, err := h.notifications.ListDeliveries(ctx, id, limit)
+	if err != nil {
+		c.JSON(notificationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+func channelIDParam(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be a positive integer"})
+		return 0, false
+	}
+	return id, true
+}
+
+func notificationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidChannel):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrChannelNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrChannelTestFailed):
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
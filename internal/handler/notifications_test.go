@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type notificationManagerStub struct {
+	last      domain.NotificationChannel
+	listLimit int
+}
+
+func (s *notificationManagerStub) CreateChannel(ctx context.Context, ch domain.NotificationChannel) (*domain.NotificationChannel, error) {
+	s.last = ch
+	if !ch.Type.IsValid() {
+		return nil, fmt.Errorf("%w: bad type", service.ErrInvalidChannel)
+	}
+	ch.ID = 3
+	return &ch, nil
+}
+
+func (s *notificationManagerStub) ListChannels(ctx context.Context) ([]domain.NotificationChannel, error) {
+	return []domain.NotificationChannel{{
+		ID: 3, Name: "team", Type: domain.ChannelSlack, Target: "https://hooks.slack.com/services/T0/B0/token",
+		MaskedTarget: "https://hooks.slack.com/***", Secret: "hidden", HasSecret: true,
+	}}, nil
+}
+
+func (s *notificationManagerStub) GetChannel(ctx context.Context, id int64) (*domain.NotificationChannel, error) {
+	if id != 3 {
+		return nil, service.ErrChannelNotFound
+	}
+	return &domain.NotificationChannel{ID: 3, Name: "team"}, nil
+}
+
+func (s *notificationManagerStub) UpdateChannel(ctx context.Context, ch domain.NotificationChannel) (*domain.NotificationChannel, error) {
+	s.last = ch
+	if ch.ID != 3 {
+		return nil, service.ErrChannelNotFound
+	}
+	return &ch, nil
+}
+
+func (s *notificationManagerStub) DeleteChannel(ctx context.Context, id int64) error {
+	if id != 3 {
+		return service.ErrChannelNotFound
+	}
+	return nil
+}
+
+func (s *notificationManagerStub) TestChannel(ctx context.Context, id int64) error {
+	if id != 3 {
+		return fmt.Errorf("%w: http 404", service.ErrChannelTestFailed)
+	}
+	return nil
+}
+
+func (s *notificationManagerStub) ListDeliveries(ctx context.Context, channelID int64, limit int) ([]domain.NotificationDelivery, error) {
+	s.listLimit = limit
+	return []domain.NotificationDelivery{{ID: 1, ChannelID: channelID, Status: domain.DeliveryDead, LastError: "http 404"}}, nil
+}
+
+func newNotificationsTestRouter(stub NotificationManager) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{tracer: trace.NewNoopTracerProvider().Tracer("handler-test")}
+	if stub != nil {
+		h.SetNotifications(stub)
+	}
+	r := gin.New()
+	h.RegisterRoutes(r)
+	return r
+}
+
+func serve(r *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return w
+}
+
+func TestNotificationsUnavailable(t *testing.T) {
+	r := newNotificationsTestRouter(nil)
+	if w := serve(r, http.MethodGet, "/api/notifications/channels", ""); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", w.Code)
+	}
+}
+
+func TestCreateAndListNotificationChannels(t *testing.T) {
+	stub := &notificationManagerStub{}
+	r := newNotificationsTestRouter(stub)
+
+	body := `{"name":"ci","type":"webhook","target":"https://ci.example.com","secret":"k","kinds":["signal","price_alert"],"symbols":["BTC"],"min_risk":2}`
+	w := serve(r, http.MethodPost, "/api/notifications/channels", body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if stub.last.Secret != "k" || len(stub.last.Kinds) != 2 || stub.last.MinRisk != domain.RiskLevel2 || !stub.last.Enabled {
+		t.Fatalf("unexpected channel: %+v", stub.last)
+	}
+	if strings.Contains(w.Body.String(), `"k"`) {
+		t.Fatalf("expected the secret to be hidden: %s", w.Body.String())
+	}
+
+	if w := serve(r, http.MethodPost, "/api/notifications/channels", `{"name":"x","type":"sms","target":"y"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid channel, got %d", w.Code)
+	}
+	if w := serve(r, http.MethodPost, "/api/notifications/channels", `{"name":"x"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a missing target, got %d", w.Code)
+	}
+
+	w = serve(r, http.MethodGet, "/api/notifications/channels", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"has_secret":true`) || strings.Contains(w.Body.String(), "hidden") ||
+		strings.Contains(w.Body.String(), "token") || !strings.Contains(w.Body.String(), `"target":"https://hooks.slack.com/***"`) {
+		t.Fatalf("unexpected list response: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestUpdateAndDeleteNotificationChannel(t *testing.T) {
+	stub := &notificationManagerStub{}
+	r := newNotificationsTestRouter(stub)
+
+	w := serve(r, http.MethodPut, "/api/notifications/channels/3", `{"name":"team","type":"slack","target":"https://hooks.slack.com/x","enabled":false}`)
+	if w.Code != http.StatusOK || stub.last.ID != 3 || stub.last.Enabled {
+		t.Fatalf("unexpected update: %d %+v", w.Code, stub.last)
+	}
+	if w := serve(r, http.MethodPut, "/api/notifications/channels/4", `{"name":"team","type":"slack","target":"https://hooks.slack.com/x"}`); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+	if w := serve(r, http.MethodGet, "/api/notifications/channels/x", ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+	if w := serve(r, http.MethodGet, "/api/notifications/channels/3", ""); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if w := serve(r, http.MethodDelete, "/api/notifications/channels/3", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+	if w := serve(r, http.MethodDelete, "/api/notifications/channels/4", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestTestNotificationChannelAndDeliveries(t *testing.T) {
+	stub := &notificationManagerStub{}
+	r := newNotificationsTestRouter(stub)
+
+	if w := serve(r, http.MethodPost, "/api/notifications/channels/3/test", ""); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if w := serve(r, http.MethodPost, "/api/notifications/channels/4/test", ""); w.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502 for a failed test, got %d", w.Code)
+	}
+
+	w := serve(r, http.MethodGet, "/api/notifications/channels/3/deliveries?limit=10", "")
+	if w.Code != http.StatusOK || stub.listLimit != 10 || !strings.Contains(w.Body.String(), `"status":"dead"`) {
+		t.Fatalf("unexpected deliveries response: %d %s", w.Code, w.Body.String())
+	}
+	if w := serve(r, http.MethodGet, "/api/notifications/channels/3/deliveries?limit=500", ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad limit, got %d", w.Code)
+	}
+}
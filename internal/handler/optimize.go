@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StrategyOptimizer interface {
+	StartOptimization(ctx context.Context, req domain.OptimizeRequest) (*domain.OptimizationJob, error)
+	GetOptimization(ctx context.Context, id int64) (*domain.OptimizationJob, error)
+	ListOptimizations(ctx context.Context) []domain.OptimizationJob
+	PromoteOptimization(ctx context.Context, id int64) (*domain.SignalParamSet, error)
+	ActiveSignalParams(ctx context.Context) (*domain.SignalParamSet, error)
+}
+
+type optimizeRequest struct {
+	backtestRunRequest
+	Grid      map[string][]float64 `json:"grid"`
+	Mode      string               `json:"mode"`
+	Samples   int                  `json:"samples"`
+	Folds     int                  `json:"folds"`
+	Objective string               `json:"objective"`
+	Seed      int64                `json:"seed"`
+}
+
+// StartOptimization godoc
+// @Summary      Start a parameter optimisation
+// @Description  Queues a background grid or random search over signal parameters with walk-forward validation (optimise on window N, test on N+1). Poll the returned job for progress.
+// @Tags         backtest
+// @Accept       json
+// @Produce      json
+// @Param        request  body  optimizeRequest  true  "Backtest parameters plus grid, mode, samples, folds, objective and seed"
+// @Success      202  {object}  domain.OptimizationJob
+// @Failure      400  {object}  map[string]string
+// @Failure      429  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/backtest/optimize [post]
+func (h *Handler) StartOptimization(c *gin.Context) {
+	if h.optimizer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "strategy optimizer unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.start-optimization")
+	defer span.End()
+
+	var req optimizeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	if req.Days < 0 || req.Samples < 0 || req.Folds < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days, samples and folds must not be negative"})
+		return
+	}
+
+	job, err := h.optimizer.StartOptimization(ctx, domain.OptimizeRequest{
+		Base:      req.config(),
+		Grid:      req.Grid,
+		Mode:      req.Mode,
+		Samples:   req.Samples,
+		Folds:     req.Folds,
+		Objective: req.Objective,
+		Seed:      req.Seed,
+	})
+	if err != nil {
+		c.JSON(optimizationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, job)
+}
+
+// ListOptimizations godoc
+// @Summary      List parameter optimisations
+// @Description  Returns recent optimisation jobs newest first with status and progress (results omitted)
+// @Tags         backtest
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}
+// @Failure      503  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/backtest/optimize [get]
+func (h *Handler) ListOptimizations(c *gin.Context) {
+	if h.optimizer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "strategy optimizer unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.list-optimizations")
+	defer span.End()
+
+	c.JSON(http.StatusOK, gin.H{"jobs": h.optimizer.ListOptimizations(ctx)})
+}
+
+// GetOptimization godoc
+// @Summary      Get a parameter optimisation
+// @Description  Returns one optimisation job with progress and, once finished, its walk-forward folds and leaderboard
+// @Tags         backtest
+// @Produce      json
+// @Param        id  path  int  true  "Job ID"
+// @Success      200  {object}  domain.OptimizationJob
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/backtest/optimize/{id} [get]
+func (h *Handler) GetOptimization(c *gin.Context) {
+	if h.optimizer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "strategy optimizer unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.get-optimization")
+	defer span.End()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be a positive integer"})
+		return
+	}
+
+	job, err := h.optimizer.GetOptimization(ctx, id)
+	if err != nil {
+		c.JSON(optimizationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// PromoteOptimization godoc
+// @Summary      Promote an optimisation result
+// @Description  Applies the recommended parameter set of a finished optimisation to the live signal engine and persists it
+// @Tags         backtest
+// @Produce      json
+// @Param        id  path  int  true  "Job ID"
+// @Success      200  {object}  domain.SignalParamSet
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/backtest/optimize/{id}/promote [post]
+func (h *Handler) PromoteOptimization(c *gin.Context) {
+	if h.optimizer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "strategy optimizer unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.promote-optimization")
+	defer span.End()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be a positive integer"})
+		return
+	}
+
+	set, err := h.optimizer.PromoteOptimization(ctx, id)
+	if err != nil {
+		c.JSON(optimizationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, set)
+}
+
+// GetSignalParams godoc
+// @Summary      Get live signal parameters
+// @Description  Returns the rule parameters the live signal engine is using and where they came from
+// @Tags         signals
+// @Produce      json
+// @Success      200  {object}  domain.SignalParamSet
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/signals/params [get]
+func (h *Handler) GetSignalParams(c *gin.Context) {
+	if h.optimizer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "strategy optimizer unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.get-signal-params")
+	defer span.End()
+
+	set, err := h.optimizer.ActiveSignalParams(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, set)
+}
+
+func optimizationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidBacktestConfig):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrOptimizationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrOptimizationNotReady):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrOptimizationQueueFull):
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type strategyOptimizerStub struct {
+	lastReq domain.OptimizeRequest
+	jobs    map[int64]*domain.OptimizationJob
+}
+
+func (s *strategyOptimizerStub) StartOptimization(ctx context.Context, req domain.OptimizeRequest) (*domain.OptimizationJob, error) {
+	if len(req.Grid) == 0 {
+		return nil, fmt.Errorf("%w: grid must name at least one parameter", service.ErrInvalidBacktestConfig)
+	}
+	s.lastReq = req
+	return &domain.OptimizationJob{ID: 7, Status: domain.JobStatusQueued, Request: req, Total: 12}, nil
+}
+
+func (s *strategyOptimizerStub) GetOptimization(ctx context.Context, id int64) (*domain.OptimizationJob, error) {
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", service.ErrOptimizationNotFound, id)
+	}
+	return job, nil
+}
+
+func (s *strategyOptimizerStub) ListOptimizations(ctx context.Context) []domain.OptimizationJob {
+	out := make([]domain.OptimizationJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		out = append(out, *job)
+	}
+	return out
+}
+
+func (s *strategyOptimizerStub) PromoteOptimization(ctx context.Context, id int64) (*domain.SignalParamSet, error) {
+	job, err := s.GetOptimization(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != domain.JobStatusSucceeded {
+		return nil, fmt.Errorf("%w: job %d is %s", service.ErrOptimizationNotReady, id, job.Status)
+	}
+	return &domain.SignalParamSet{ID: 1, Params: job.Result.Recommended.Params, Source: "optimization job 1"}, nil
+}
+
+func (s *strategyOptimizerStub) ActiveSignalParams(ctx context.Context) (*domain.SignalParamSet, error) {
+	return &domain.SignalParamSet{Params: domain.SignalParams{RSIPeriod: 14}, Source: "default"}, nil
+}
+
+func TestOptimizationEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	opt := &strategyOptimizerStub{jobs: map[int64]*domain.OptimizationJob{
+		1: {ID: 1, Status: domain.JobStatusSucceeded, Result: &domain.OptimizeResult{
+			Recommended: &domain.ParamCandidate{Params: domain.SignalParams{RSIPeriod: 10}},
+		}},
+		2: {ID: 2, Status: domain.JobStatusRunning, Done: 3, Total: 12},
+	}}
+	h := &Handler{tracer: trace.NewNoopTracerProvider().Tracer("handler-test")}
+	h.SetStrategyOptimizer(opt)
+	r := gin.New()
+	h.RegisterRoutes(r)
+
+	body := `{"symbol":"BTC","interval":"4h","days":120,"grid":{"rsi_period":[7,14,21]},"mode":"random","samples":2,"folds":3,"objective":"sortino","seed":5}`
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/backtest/optimize", strings.NewReader(body)))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("start: expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+	req := opt.lastReq
+	if req.Base.Symbol != "BTC" || req.Base.FeeBps != service.DefaultBacktestFeeBps || req.Mode != domain.OptimizeModeRandom ||
+		req.Samples != 2 || req.Folds != 3 || req.Objective != domain.ObjectiveSortino || req.Seed != 5 || len(req.Grid["rsi_period"]) != 3 {
+		t.Fatalf("start: unexpected request %+v", req)
+	}
+	if req.Base.To.Sub(req.Base.From).Hours() != 120*24 {
+		t.Fatalf("start: expected 120-day window, got %s..%s", req.Base.From, req.Base.To)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/backtest/optimize/2", nil))
+	var job domain.OptimizationJob
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &job) != nil || job.Done != 3 {
+		t.Fatalf("get: unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/backtest/optimize/1/promote", nil))
+	var set domain.SignalParamSet
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &set) != nil || set.Params.RSIPeriod != 10 {
+		t.Fatalf("promote: unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/signals/params", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"rsi_period":14`) {
+		t.Fatalf("params: unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	cases := []struct {
+		method, path, body string
+		code               int
+	}{
+		{http.MethodGet, "/api/backtest/optimize", "", http.StatusOK},
+		{http.MethodPost, "/api/backtest/optimize", `{"symbol":"BTC"}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/backtest/optimize", `{"symbol":"BTC","folds":-1,"grid":{"rsi_period":[7]}}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/backtest/optimize", `not json`, http.StatusBadRequest},
+		{http.MethodGet, "/api/backtest/optimize/abc", "", http.StatusBadRequest},
+		{http.MethodGet, "/api/backtest/optimize/9", "", http.StatusNotFound},
+		{http.MethodPost, "/api/backtest/optimize/2/promote", "", http.StatusConflict},
+		{http.MethodPost, "/api/backtest/optimize/9/promote", "", http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+		if w.Code != tc.code {
+			t.Fatalf("%s %s: expected %d, got %d", tc.method, tc.path, tc.code, w.Code)
+		}
+	}
+}
+
+func TestOptimizationUnavailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{tracer: trace.NewNoopTracerProvider().Tracer("handler-test")}
+	r := gin.New()
+	h.RegisterRoutes(r)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/signals/params", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", w.Code)
+	}
+}
@@ -0,0 +1,368 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PaperTrader interface {
+	CreateAccount(ctx context.Context, acct domain.PaperAccount) (*domain.PaperAccount, error)
+	ListAccounts(ctx context.Context) ([]domain.PaperAccount, error)
+	Portfolio(ctx context.Context, accountID int64) (*domain.PaperPortfolio, error)
+	PlaceOrder(ctx context.Context, req domain.PaperOrderRequest) (*domain.PaperOrder, error)
+	Orders(ctx context.Context, accountID int64, limit int) ([]domain.PaperOrder, error)
+	Subscribe(ctx context.Context, sub domain.PaperSubscription) (*domain.PaperSubscription, error)
+	Subscriptions(ctx context.Context, accountID int64) ([]domain.PaperSubscription, error)
+	Unsubscribe(ctx context.Context, accountID, subscriptionID int64) error
+}
+
+type paperAccountRequest struct {
+	Name            string  `json:"name" binding:"required"`
+	StartingBalance float64 `json:"starting_balance"`
+	SlippageBps     float64 `json:"slippage_bps"`
+}
+
+type paperOrderRequest struct {
+	Symbol   string  `json:"symbol" binding:"required"`
+	Side     string  `json:"side" binding:"required"`
+	Quantity float64 `json:"quantity"`
+	Notional float64 `json:"notional"`
+}
+
+type paperSubscriptionRequest struct {
+	Symbol        string  `json:"symbol"`
+	Indicator     string  `json:"indicator"`
+	MaxRisk       int     `json:"max_risk"`
+	TradeNotional float64 `json:"trade_notional"`
+}
+
+// CreatePaperAccount godoc
+// @Summary      Open a paper trading account
+// @Description  Creates a virtual spot account. starting_balance defaults to 10000 USD and slippage_bps to 5
+// @Tags         paper
+// @Accept       json
+// @Produce      json
+// @Param        request  body  paperAccountRequest  true  "Account settings"
+// @Success      201  {object}  domain.PaperAccount
+// @Failure      400  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/paper/accounts [post]
+func (h *Handler) CreatePaperAccount(c *gin.Context) {
+	if h.paper == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "paper trading service unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.create-paper-account")
+	defer span.End()
+
+	var req paperAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	acct, err := h.paper.CreateAccount(ctx, domain.PaperAccount{
+		Name:            req.Name,
+		StartingBalance: req.StartingBalance,
+		SlippageBps:     req.SlippageBps,
+	})
+	if err != nil {
+		c.JSON(paperErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, acct)
+}
+
+// ListPaperAccounts godoc
+// @Summary      List paper trading accounts
+// @Tags         paper
+// @Produce      json
+// @Success      200  {object}  map[string][]domain.PaperAccount
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/paper/accounts [get]
+func (h *Handler) ListPaperAccounts(c *gin.Context) {
+	if h.paper == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "paper trading service unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.list-paper-accounts")
+	defer span.End()
+
+	accounts, err := h.paper.ListAccounts(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"accounts": accounts})
+}
+
+// GetPaperPortfolio godoc
+// @Summary      Get a paper trading portfolio
+// @Description  Values an account at current prices: cash, holdings with unrealised PnL, realised PnL, equity and return since opening
+// @Tags         paper
+// @Produce      json
+// @Param        id  path  int  true  "Account ID"
+// @Success      200  {object}  domain.PaperPortfolio
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/paper/accounts/{id} [get]
+func (h *Handler) GetPaperPortfolio(c *gin.Context) {
+	if h.paper == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "paper trading service unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.get-paper-portfolio")
+	defer span.End()
+
+	id, ok := paperAccountID(c)
+	if !ok {
+		return
+	}
+	p, err := h.paper.Portfolio(ctx, id)
+	if err != nil {
+		c.JSON(paperErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+// PlacePaperOrder godoc
+// @Summary      Place a paper market order
+// @Description  Fills at the current price moved against the order by the account's slippage. Size by quantity or USD notional; a sell with neither closes the position
+// @Tags         paper
+// @Accept       json
+// @Produce      json
+// @Param        id       path  int                true  "Account ID"
+// @Param        request  body  paperOrderRequest  true  "Order"
+// @Success      201  {object}  domain.PaperOrder
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/paper/accounts/{id}/orders [post]
+func (h *Handler) PlacePaperOrder(c *gin.Context) {
+	if h.paper == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "paper trading service unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.place-paper-order")
+	defer span.End()
+
+	id, ok := paperAccountID(c)
+	if !ok {
+		return
+	}
+	var req paperOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	order, err := h.paper.PlaceOrder(ctx, domain.PaperOrderRequest{
+		AccountID: id,
+		Symbol:    req.Symbol,
+		Side:      domain.PaperSide(req.Side),
+		Quantity:  req.Quantity,
+		Notional:  req.Notional,
+		Source:    domain.PaperSourceManual,
+	})
+	if err != nil {
+		c.JSON(paperErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, order)
+}
+
+// ListPaperOrders godoc
+// @Summary      List paper orders
+// @Description  Returns an account's orders, most recent first
+// @Tags         paper
+// @Produce      json
+// @Param        id     path   int  true   "Account ID"
+// @Param        limit  query  int  false  "Max orders (1-500, default 50)"
+// @Success      200  {object}  map[string][]domain.PaperOrder
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/paper/accounts/{id}/orders [get]
+func (h *Handler) ListPaperOrders(c *gin.Context) {
+	if h.paper == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "paper trading service unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.list-paper-orders")
+	defer span.End()
+
+	id, ok := paperAccountID(c)
+	if !ok {
+		return
+	}
+	limit := 50
+	if rawLimit := c.Query("limit"); rawLimit != "" {
+		n, err := strconv.Atoi(rawLimit)
+		if err != nil || n <= 0 || n > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+			return
+		}
+		limit = n
+	}
+	orders, err := h.paper.Orders(ctx, id, limit)
+	if err != nil {
+		c.JSON(paperErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"orders": orders})
+}
+
+// CreatePaperSubscription godoc
+// @Summary      Follow signals with a paper account
+// @Description  Long signals matching the filters buy trade_notional USD (default 500) when the account is flat in the symbol; matching short signals close the position. Empty filters match everything
+// @Tags         paper
+// @Accept       json
+// @Produce      json
+// @Param        id       path  int                       true  "Account ID"
+// @Param        request  body  paperSubscriptionRequest  true  "symbol, indicator, max_risk (1-5), trade_notional"
+// @Success      201  {object}  domain.PaperSubscription
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/paper/accounts/{id}/subscriptions [post]
+func (h *Handler) CreatePaperSubscription(c *gin.Context) {
+	if h.paper == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "paper trading service unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.create-paper-subscription")
+	defer span.End()
+
+	id, ok := paperAccountID(c)
+	if !ok {
+		return
+	}
+	var req paperSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	sub, err := h.paper.Subscribe(ctx, domain.PaperSubscription{
+		AccountID:     id,
+		Symbol:        req.Symbol,
+		Indicator:     req.Indicator,
+		MaxRisk:       domain.RiskLevel(req.MaxRisk),
+		TradeNotional: req.TradeNotional,
+	})
+	if err != nil {
+		c.JSON(paperErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, sub)
+}
+
+// ListPaperSubscriptions godoc
+// @Summary      List an account's signal subscriptions
+// @Tags         paper
+// @Produce      json
+// @Param        id  path  int  true  "Account ID"
+// @Success      200  {object}  map[string][]domain.PaperSubscription
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/paper/accounts/{id}/subscriptions [get]
+func (h *Handler) ListPaperSubscriptions(c *gin.Context) {
+	if h.paper == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "paper trading service unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.list-paper-subscriptions")
+	defer span.End()
+
+	id, ok := paperAccountID(c)
+	if !ok {
+		return
+	}
+	subs, err := h.paper.Subscriptions(ctx, id)
+	if err != nil {
+		c.JSON(paperErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"subscriptions": subs})
+}
+
+// DeletePaperSubscription godoc
+// @Summary      Stop following signals
+// @Tags         paper
+// @Param        id     path  int  true  "Account ID"
+// @Param        subId  path  int  true  "Subscription ID"
+// @Success      204
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/paper/accounts/{id}/subscriptions/{subId} [delete]
+func (h *Handler) DeletePaperSubscription(c *gin.Context) {
+	if h.paper == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "paper trading service unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.delete-paper-subscription")
+	defer span.End()
+
+	id, ok := paperAccountID(c)
+	if !ok {
+		return
+	}
+	subID, err := strconv.ParseInt(c.Param("subId"), 10, 64)
+	if err != nil || subID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "subId must be a positive integer"})
+		return
+	}
+	if err := h.paper.Unsubscribe(ctx, id, subID); err != nil {
+		c.JSON(paperErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// paperAccountID parses the :id path parameter, writing a 400 when it is not
+// a positive integer.
+func paperAccountID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be a positive integer"})
+		return 0, false
+	}
+	return id, true
+}
+
+func paperErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidPaperOrder):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrPaperAccountNotFound), errors.Is(err, service.ErrPaperSubscriptionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInsufficientPaperFunds):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type paperTraderStub struct {
+	lastOrder domain.PaperOrderRequest
+	lastSub   domain.PaperSubscription
+	unsubbed  [2]int64
+}
+
+func (s *paperTraderStub) CreateAccount(ctx context.Context, acct domain.PaperAccount) (*domain.PaperAccount, error) {
+	acct.ID = 1
+	acct.Cash = acct.StartingBalance
+	return &acct, nil
+}
+
+func (s *paperTraderStub) ListAccounts(ctx context.Context) ([]domain.PaperAccount, error) {
+	return []domain.PaperAccount{{ID: 1, Name: "desk"}}, nil
+}
+
+func (s *paperTraderStub) Portfolio(ctx context.Context, accountID int64) (*domain.PaperPortfolio, error) {
+	if accountID != 1 {
+		return nil, service.ErrPaperAccountNotFound
+	}
+	return &domain.PaperPortfolio{Account: domain.PaperAccount{ID: 1}, Equity: 10500}, nil
+}
+
+func (s *paperTraderStub) PlaceOrder(ctx context.Context, req domain.PaperOrderRequest) (*domain.PaperOrder, error) {
+	s.lastOrder = req
+	if req.Quantity > 100 {
+		return nil, fmt.Errorf("%w: order costs too much", service.ErrInsufficientPaperFunds)
+	}
+	return &domain.PaperOrder{ID: 3, AccountID: req.AccountID, Symbol: req.Symbol, Side: req.Side, Quantity: req.Quantity}, nil
+}
+
+func (s *paperTraderStub) Orders(ctx context.Context, accountID int64, limit int) ([]domain.PaperOrder, error) {
+	return []domain.PaperOrder{{ID: 3}}, nil
+}
+
+func (s *paperTraderStub) Subscribe(ctx context.Context, sub domain.PaperSubscription) (*domain.PaperSubscription, error) {
+	s.lastSub = sub
+	sub.ID = 4
+	return &sub, nil
+}
+
+func (s *paperTraderStub) Subscriptions(ctx context.Context, accountID int64) ([]domain.PaperSubscription, error) {
+	return []domain.PaperSubscription{{ID: 4, AccountID: accountID}}, nil
+}
+
+func (s *paperTraderStub) Unsubscribe(ctx context.Context, accountID, subscriptionID int64) error {
+	s.unsubbed = [2]int64{accountID, subscriptionID}
+	if subscriptionID != 4 {
+		return service.ErrPaperSubscriptionNotFound
+	}
+	return nil
+}
+
+func newPaperTestRouter(stub PaperTrader) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{tracer: trace.NewNoopTracerProvider().Tracer("handler-test")}
+	if stub != nil {
+		h.SetPaperTrading(stub)
+	}
+	r := gin.New()
+	h.RegisterRoutes(r)
+	return r
+}
+
+func TestPaperAccountsAndPortfolio(t *testing.T) {
+	r := newPaperTestRouter(&paperTraderStub{})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/paper/accounts", strings.NewReader(`{"name":"desk","starting_balance":5000}`)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var acct domain.PaperAccount
+	if err := json.Unmarshal(w.Body.Bytes(), &acct); err != nil || acct.Cash != 5000 {
+		t.Fatalf("unexpected account: %+v (%v)", acct, err)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/paper/accounts", strings.NewReader(`{}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a name, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/paper/accounts/1", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"equity":10500`) {
+		t.Fatalf("unexpected portfolio response %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/paper/accounts/2", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/paper/accounts/abc", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestPlacePaperOrder(t *testing.T) {
+	stub := &paperTraderStub{}
+	r := newPaperTestRouter(stub)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/paper/accounts/1/orders", strings.NewReader(`{"symbol":"BTC","side":"buy","quantity":0.5}`)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if stub.lastOrder.AccountID != 1 || stub.lastOrder.Side != domain.PaperBuy || stub.lastOrder.Source != domain.PaperSourceManual {
+		t.Fatalf("unexpected order request: %+v", stub.lastOrder)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/paper/accounts/1/orders", strings.NewReader(`{"symbol":"BTC","side":"buy","quantity":500}`)))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for insufficient funds, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/paper/accounts/1/orders?limit=0", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for bad limit, got %d", w.Code)
+	}
+}
+
+func TestPaperSubscriptions(t *testing.T) {
+	stub := &paperTraderStub{}
+	r := newPaperTestRouter(stub)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/paper/accounts/1/subscriptions", strings.NewReader(`{"indicator":"rsi","max_risk":3,"trade_notional":250}`)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if stub.lastSub.AccountID != 1 || stub.lastSub.MaxRisk != domain.RiskLevel3 || stub.lastSub.TradeNotional != 250 {
+		t.Fatalf("unexpected subscription: %+v", stub.lastSub)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/paper/accounts/1/subscriptions/4", nil))
+	if w.Code != http.StatusNoContent || stub.unsubbed != [2]int64{1, 4} {
+		t.Fatalf("expected 204, got %d (%v)", w.Code, stub.unsubbed)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/paper/accounts/1/subscriptions/9", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestPaperRoutesUnavailable(t *testing.T) {
+	r := newPaperTestRouter(nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/paper/accounts", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", w.Code)
+	}
+}
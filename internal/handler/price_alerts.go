@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PriceAlertManager interface {
+	CreateRule(ctx context.Context, rule domain.PriceAlertRule) (*domain.PriceAlertRule, error)
+	ListRules(ctx context.Context, chatID int64) ([]domain.PriceAlertRule, error)
+	DeleteRule(ctx context.Context, chatID, id int64) error
+}
+
+type priceAlertRequest struct {
+	Symbol     string  `json:"symbol" binding:"required"`
+	Type       string  `json:"type" binding:"required"`
+	Direction  string  `json:"direction"`
+	Threshold  float64 `json:"threshold" binding:"required"`
+	WindowMins int     `json:"window_mins"`
+	Hysteresis float64 `json:"hysteresis"`
+	Recurring  bool    `json:"recurring"`
+}
+
+// ListPriceAlerts godoc
+// @Summary      List a user's price alerts
+// @Description  Returns all of the user's price alert rules, including fired one-shot rules
+// @Tags         alerts
+// @Produce      json
+// @Param        chatId  path  int  true  "User chat ID"
+// @Success      200  {object}  map[string][]domain.PriceAlertRule
+// @Failure      400  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/alerts/{chatId} [get]
+func (h *Handler) ListPriceAlerts(c *gin.Context) {
+	if h.priceAlerts == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "price alerts unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.list-price-alerts")
+	defer span.End()
+
+	chatID, ok := chatIDParam(c)
+	if !ok {
+		return
+	}
+	rules, err := h.priceAlerts.ListRules(ctx, chatID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"alerts": rules})
+}
+
+// CreatePriceAlert godoc
+// @Summary      Create a price alert
+// @Description  Adds a rule evaluated on every price refresh. type is cross (threshold is a USD price, direction up or down), move (threshold is a percent move within window_mins, direction up, down or any) or volume (threshold is a percent rise in 24h volume within window_mins). Rules are one-shot unless recurring; hysteresis is the fraction of the threshold the measure must fall back before a rule re-arms (default 0.01)
+// @Tags         alerts
+// @Accept       json
+// @Produce      json
+// @Param        chatId   path  int                true  "User chat ID"
+// @Param        request  body  priceAlertRequest  true  "Rule"
+// @Success      201  {object}  domain.PriceAlertRule
+// @Failure      400  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/alerts/{chatId} [post]
+func (h *Handler) CreatePriceAlert(c *gin.Context) {
+	if h.priceAlerts == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "price alerts unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.create-price-alert")
+	defer span.End()
+
+	chatID, ok := chatIDParam(c)
+	if !ok {
+		return
+	}
+	var req priceAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+	rule, err := h.priceAlerts.CreateRule(ctx, domain.PriceAlertRule{
+		ChatID:     chatID,
+		Symbol:     req.Symbol,
+		Type:       domain.PriceAlertType(req.Type),
+		Direction:  domain.PriceAlertDirection(req.Direction),
+		Threshold:  req.Threshold,
+		WindowMins: req.WindowMins,
+		Hysteresis: req.Hysteresis,
+		Recurring:  req.Recurring,
+	})
+	if err != nil {
+		c.JSON(priceAlertErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+// DeletePriceAlert godoc
+// @Summary      Delete a price alert
+// @Tags         alerts
+// @Param        chatId   path  int  true  "User chat ID"
+// @Param        alertId  path  int  true  "Alert ID"
+// @Success      204
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/alerts/{chatId}/{alertId} [delete]
+func (h *Handler) DeletePriceAlert(c *gin.Context) {
+	if h.priceAlerts == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "price alerts unavailable"})
+		return
+	}
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.delete-price-alert")
+	defer span.End()
+
+	chatID, ok := chatIDParam(c)
+	if !ok {
+		return
+	}
+	alertID, err := strconv.ParseInt(c.Param("alertId"), 10, 64)
+	if err != nil || alertID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "alertId must be a positive integer"})
+		return
+	}
+	if err := h.priceAlerts.DeleteRule(ctx, chatID, alertID); err != nil {
+		c.JSON(priceAlertErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func priceAlertErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidPriceAlert):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrPriceAlertNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
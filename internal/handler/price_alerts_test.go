@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type priceAlertManagerStub struct {
+	lastRule domain.PriceAlertRule
+}
+
+func (s *priceAlertManagerStub) CreateRule(ctx context.Context, rule domain.PriceAlertRule) (*domain.PriceAlertRule, error) {
+	s.lastRule = rule
+	if rule.Type != domain.PriceAlertCross && rule.Type != domain.PriceAlertMove {
+		return nil, service.ErrInvalidPriceAlert
+	}
+	rule.ID = 8
+	return &rule, nil
+}
+
+func (s *priceAlertManagerStub) ListRules(ctx context.Context, chatID int64) ([]domain.PriceAlertRule, error) {
+	return []domain.PriceAlertRule{{ID: 1, ChatID: chatID, Symbol: "BTC"}}, nil
+}
+
+func (s *priceAlertManagerStub) DeleteRule(ctx context.Context, chatID, id int64) error {
+	if id != 8 {
+		return service.ErrPriceAlertNotFound
+	}
+	return nil
+}
+
+func newPriceAlertsTestRouter(stub PriceAlertManager) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{tracer: trace.NewNoopTracerProvider().Tracer("handler-test")}
+	if stub != nil {
+		h.SetPriceAlerts(stub)
+	}
+	r := gin.New()
+	h.RegisterRoutes(r)
+	return r
+}
+
+func TestPriceAlertsUnavailable(t *testing.T) {
+	r := newPriceAlertsTestRouter(nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/alerts/7", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", w.Code)
+	}
+}
+
+func TestCreateAndListPriceAlerts(t *testing.T) {
+	stub := &priceAlertManagerStub{}
+	r := newPriceAlertsTestRouter(stub)
+
+	w := httptest.NewRecorder()
+	body := `{"symbol":"sol","type":"move","direction":"down","threshold":5,"window_mins":60,"recurring":true}`
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/alerts/-1000003", strings.NewReader(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if stub.lastRule.ChatID != -1000003 || stub.lastRule.Direction != domain.PriceAlertDown || stub.lastRule.WindowMins != 60 || !stub.lastRule.Recurring {
+		t.Fatalf("unexpected rule: %+v", stub.lastRule)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/alerts/7", strings.NewReader(`{"symbol":"BTC","type":"spread","threshold":1}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid rule, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/alerts/7", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"alerts"`) {
+		t.Fatalf("unexpected list response: %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/alerts/0", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for zero chat id, got %d", w.Code)
+	}
+}
+
+func TestDeletePriceAlert(t *testing.T) {
+	r := newPriceAlertsTestRouter(&priceAlertManagerStub{})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/alerts/7/8", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/alerts/7/9", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/alerts/7/x", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
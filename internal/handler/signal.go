@@ -123,3 +123,56 @@ func (h *Handler) GetSignalImage(c *gin.Context) {
 
 	c.Data(http.StatusOK, imageData.Ref.MimeType, imageData.Bytes)
 }
+
+// GetSignalRiskDistribution godoc
+// @Summary      Get signal risk distribution
+// @Description  Returns counts of persisted signals per indicator, interval and risk level for calibrating the 1-5 scale
+// @Tags         signals
+// @Produce      json
+// @Param        days       query  int     false  "Days of history" default(30)
+// @Param        indicator  query  string  false  "Indicator key"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Security     ApiKeyAuth
+// @Router       /api/signals/risk-distribution [get]
+func (h *Handler) GetSignalRiskDistribution(c *gin.Context) {
+	if h.signalService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "signal service unavailable"})
+		return
+	}
+
+	ctx, span := h.tracer.Start(c.Request.Context(), "handler.get-signal-risk-distribution")
+	defer span.End()
+
+	days := 30
+	if rawDays := strings.TrimSpace(c.Query("days")); rawDays != "" {
+		n, err := strconv.Atoi(rawDays)
+		if err != nil || n <= 0 || n > 365 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 365"})
+			return
+		}
+		days = n
+	}
+	indicator := strings.ToLower(strings.TrimSpace(c.Query("indicator")))
+
+	buckets, err := h.signalService.RiskDistribution(ctx, days, indicator)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	totals := make(map[domain.RiskLevel]int, 5)
+	total := 0
+	for _, b := range buckets {
+		totals[b.Risk] += b.Count
+		total += b.Count
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"days":    days,
+		"total":   total,
+		"totals":  totals,
+		"buckets": buckets,
+	})
+}
@@ -182,9 +182,57 @@ func TestGetSignalImageNotFound(t *testing.T) {
 	}
 }
 
+func TestGetSignalRiskDistribution(t *testing.T) {
+	tracer := trace.NewNoopTracerProvider().Tracer("handler-test")
+	repo := &handlerSignalStoreStub{riskResp: []domain.RiskDistributionBucket{
+		{Indicator: domain.IndicatorRSI, Interval: "1h", Risk: domain.RiskLevel2, Count: 3},
+		{Indicator: domain.IndicatorMACD, Interval: "1h", Risk: domain.RiskLevel2, Count: 2},
+		{Indicator: domain.IndicatorMACD, Interval: "15m", Risk: domain.RiskLevel4, Count: 1},
+	}}
+	h := &Handler{
+		tracer:        tracer,
+		signalService: service.NewSignalService(tracer, &stubRepo{}, repo, stubSignalEngine{}),
+	}
+
+	router := gin.New()
+	router.GET("/api/signals/risk-distribution", h.GetSignalRiskDistribution)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/signals/risk-distribution?days=14", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Days   int            `json:"days"`
+		Total  int            `json:"total"`
+		Totals map[string]int `json:"totals"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if resp.Days != 14 || resp.Total != 6 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if resp.Totals["2"] != 5 || resp.Totals["4"] != 1 {
+		t.Fatalf("unexpected totals: %+v", resp.Totals)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/signals/risk-distribution?days=0", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid days, got %d", w.Code)
+	}
+}
+
 type handlerSignalStoreStub struct {
 	lastFilter domain.SignalFilter
 	resp       []domain.Signal
+	riskResp   []domain.RiskDistributionBucket
+}
+
+func (s *handlerSignalStoreStub) RiskDistribution(ctx context.Context, since time.Time, indicator string) ([]domain.RiskDistributionBucket, error) {
+	return append([]domain.RiskDistributionBucket(nil), s.riskResp...), nil
 }
 
 func (s *handlerSignalStoreStub) InsertSignals(ctx context.Context, signals []domain.Signal) ([]domain.Signal, error) {
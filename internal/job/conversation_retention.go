@@ -0,0 +1,81 @@
+package job
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultConversationRetention = 90 * 24 * time.Hour
+	conversationRetentionTick    = 24 * time.Hour
+)
+
+type ConversationPruner interface {
+	DeleteMessagesOlderThan(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// ConversationRetention deletes advisor conversation messages older than the
+// retention period once a day. Running summaries and remembered facts are
+// kept.
+type ConversationRetention struct {
+	tracer    trace.Tracer
+	prune     ConversationPruner
+	retention time.Duration
+	now       func() time.Time
+}
+
+func NewConversationRetention(tracer trace.Tracer, prune ConversationPruner, retentionDays int) *ConversationRetention {
+	retention := defaultConversationRetention
+	if retentionDays > 0 {
+		retention = time.Duration(retentionDays) * 24 * time.Hour
+	}
+	return &ConversationRetention{
+		tracer:    tracer,
+		prune:     prune,
+		retention: retention,
+		now:       time.Now,
+	}
+}
+
+func (j *ConversationRetention) Start(ctx context.Context) {
+	if j == nil || j.prune == nil {
+		<-ctx.Done()
+		return
+	}
+
+	log.Println("Conversation retention starting...")
+	ticker := time.NewTicker(conversationRetentionTick)
+	defer ticker.Stop()
+
+	j.run(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Conversation retention stopped")
+			return
+		case <-ticker.C:
+			j.run(ctx)
+		}
+	}
+}
+
+func (j *ConversationRetention) run(ctx context.Context) {
+	if j.tracer != nil {
+		_, span := j.tracer.Start(ctx, "conversation-retention-job.run")
+		defer span.End()
+	}
+	start := time.Now()
+	deleted, err := j.prune.DeleteMessagesOlderThan(ctx, j.now().Add(-j.retention))
+	recordJobRun("conversation-retention", start, err)
+	if err != nil {
+		log.Printf("conversation retention error: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("conversation retention removed %d message(s)", deleted)
+	}
+}
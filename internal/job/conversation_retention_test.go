@@ -0,0 +1,66 @@
+package job
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestConversationRetentionDeletesPastCutoff(t *testing.T) {
+	stub := &stubConversationPruner{}
+	job := NewConversationRetention(trace.NewNoopTracerProvider().Tracer("test"), stub, 30)
+	now := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	job.now = func() time.Time { return now }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		job.Start(ctx)
+		close(done)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("retention job did not stop")
+	}
+
+	cutoffs := stub.calls()
+	if len(cutoffs) == 0 {
+		t.Fatal("expected retention to run at least once")
+	}
+	if want := now.AddDate(0, 0, -30); !cutoffs[0].Equal(want) {
+		t.Fatalf("expected cutoff %v, got %v", want, cutoffs[0])
+	}
+}
+
+func TestConversationRetentionDefaultsTo90Days(t *testing.T) {
+	job := NewConversationRetention(nil, &stubConversationPruner{}, 0)
+	if job.retention != 90*24*time.Hour {
+		t.Fatalf("expected 90 day default, got %v", job.retention)
+	}
+}
+
+type stubConversationPruner struct {
+	mu      sync.Mutex
+	cutoffs []time.Time
+}
+
+func (s *stubConversationPruner) DeleteMessagesOlderThan(ctx context.Context, cutoff time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cutoffs = append(s.cutoffs, cutoff)
+	return 0, nil
+}
+
+func (s *stubConversationPruner) calls() []time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]time.Time(nil), s.cutoffs...)
+}
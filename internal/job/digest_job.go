@@ -0,0 +1,66 @@
+package job
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// digestTick is how often schedules are checked. Digests are scheduled to
+// the minute.
+const digestTick = time.Minute
+
+type DigestRunner interface {
+	SendDue(ctx context.Context) error
+}
+
+// DigestJob sends scheduled market digests as they come due.
+type DigestJob struct {
+	tracer trace.Tracer
+	runner DigestRunner
+}
+
+func NewDigestJob(tracer trace.Tracer, runner DigestRunner) *DigestJob {
+	return &DigestJob{
+		tracer: tracer,
+		runner: runner,
+	}
+}
+
+func (j *DigestJob) Start(ctx context.Context) {
+	if j == nil || j.runner == nil {
+		<-ctx.Done()
+		return
+	}
+
+	log.Println("Digest job starting...")
+	ticker := time.NewTicker(digestTick)
+	defer ticker.Stop()
+
+	j.run(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Digest job stopped")
+			return
+		case <-ticker.C:
+			j.run(ctx)
+		}
+	}
+}
+
+func (j *DigestJob) run(ctx context.Context) {
+	start := time.Now()
+	if j.tracer != nil {
+		_, span := j.tracer.Start(ctx, "digest-job.send-due")
+		defer span.End()
+	}
+	err := j.runner.SendDue(ctx)
+	recordJobRun("digest", start, err)
+	if err != nil {
+		log.Printf("digest send error: %v", err)
+	}
+}
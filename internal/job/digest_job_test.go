@@ -0,0 +1,44 @@
+package job
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestDigestJobStartRunsImmediately(t *testing.T) {
+	stub := &stubDigestRunner{}
+	job := NewDigestJob(trace.NewNoopTracerProvider().Tracer("test"), stub)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		job.Start(ctx)
+		close(done)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("digest job did not stop")
+	}
+
+	if atomic.LoadInt32(&stub.calls) == 0 {
+		t.Fatal("expected SendDue to run at least once")
+	}
+}
+
+type stubDigestRunner struct {
+	calls int32
+}
+
+func (s *stubDigestRunner) SendDue(ctx context.Context) error {
+	atomic.AddInt32(&s.calls, 1)
+	return nil
+}
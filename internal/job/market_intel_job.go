@@ -52,7 +52,9 @@ func (j *MarketIntelJob) runOnce(ctx context.Context) {
 	_, span := j.tracer.Start(ctx, "market-intel-job.run-once")
 	defer span.End()
 
+	start := time.Now()
 	result, err := j.runner.RunMarketIntel(ctx)
+	recordJobRun("market-intel", start, err)
 	if err != nil {
 		log.Printf("Market intel cycle error: %v", err)
 		return
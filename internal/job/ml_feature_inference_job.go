@@ -53,12 +53,15 @@ func (j *MLFeatureInferenceJob) runOnce(ctx context.Context) {
 	_, span := j.tracer.Start(ctx, "ml-feature-inference-job.run-once")
 	defer span.End()
 
+	start := time.Now()
 	rows, err := j.service.RefreshFeatures(ctx)
 	if err != nil {
+		recordJobRun("ml-inference", start, err)
 		log.Printf("ML feature refresh error: %v", err)
 		return
 	}
 	_, err = j.service.RunInference(ctx)
+	recordJobRun("ml-inference", start, err)
 	if err != nil {
 		log.Printf("ML inference error: %v", err)
 		return
@@ -52,7 +52,9 @@ func (j *MLOutcomeResolverJob) runOnce(ctx context.Context) {
 	_, span := j.tracer.Start(ctx, "ml-outcome-resolver-job.run-once")
 	defer span.End()
 
+	start := time.Now()
 	resolved, err := j.service.ResolveOutcomes(ctx, j.batchSize)
+	recordJobRun("ml-outcomes", start, err)
 	if err != nil {
 		log.Printf("ML outcome resolver error: %v", err)
 		return
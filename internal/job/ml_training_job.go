@@ -54,7 +54,9 @@ func (j *MLTrainingJob) runOnce(ctx context.Context) {
 	_, span := j.tracer.Start(ctx, "ml-training-job.run-once")
 	defer span.End()
 
+	start := time.Now()
 	results, err := j.service.RunTraining(ctx)
+	recordJobRun("ml-training", start, err)
 	if err != nil {
 		log.Printf("ML training error: %v", err)
 		return
@@ -15,6 +15,7 @@ type PricePoller struct {
 	tracer       trace.Tracer
 	priceService PriceDataRefresher
 	pollInterval time.Duration
+	refreshSinks []PriceRefreshSink
 }
 
 type PriceDataRefresher interface {
@@ -23,6 +24,11 @@ type PriceDataRefresher interface {
 	RefreshLongCandles(ctx context.Context, symbol string) error
 }
 
+// PriceRefreshSink is told each time current prices were refreshed.
+type PriceRefreshSink interface {
+	OnPricesRefreshed(ctx context.Context) error
+}
+
 func NewPricePoller(tracer trace.Tracer, priceService PriceDataRefresher, pollIntervalSecs int) *PricePoller {
 	return &PricePoller{
 		tracer:       tracer,
@@ -31,14 +37,21 @@ func NewPricePoller(tracer trace.Tracer, priceService PriceDataRefresher, pollIn
 	}
 }
 
+// AddRefreshSink registers a receiver called after every successful price
+// refresh; nil sinks are ignored. Call it before Start.
+func (p *PricePoller) AddRefreshSink(sink PriceRefreshSink) {
+	if sink == nil {
+		return
+	}
+	p.refreshSinks = append(p.refreshSinks, sink)
+}
+
 // Start launches background polling goroutines. Blocks until ctx is cancelled.
 func (p *PricePoller) Start(ctx context.Context) {
 	log.Println("Price poller starting...")
 
 	// Tier 1: Current prices every pollInterval (default 60s)
-	go p.pollLoop(ctx, "current-prices", p.pollInterval, func(ctx context.Context) error {
-		return p.priceService.RefreshPrices(ctx)
-	})
+	go p.pollLoop(ctx, "current-prices", p.pollInterval, p.refreshPrices)
 
 	// Tier 2: Short candles (5m, 15m, 1h) — 2 coins every 5 minutes, round-robin
 	go p.pollShortCandles(ctx)
@@ -50,9 +63,28 @@ func (p *PricePoller) Start(ctx context.Context) {
 	log.Println("Price poller stopped")
 }
 
+func (p *PricePoller) refreshPrices(ctx context.Context) error {
+	if err := p.priceService.RefreshPrices(ctx); err != nil {
+		return err
+	}
+	for _, sink := range p.refreshSinks {
+		if err := sink.OnPricesRefreshed(ctx); err != nil {
+			log.Printf("price refresh sink error: %v", err)
+		}
+	}
+	return nil
+}
+
 func (p *PricePoller) pollLoop(ctx context.Context, name string, interval time.Duration, fn func(context.Context) error) {
+	run := func() error {
+		start := time.Now()
+		err := fn(ctx)
+		recordJobRun(name, start, err)
+		return err
+	}
+
 	// Run immediately on start
-	if err := fn(ctx); err != nil {
+	if err := run(); err != nil {
 		log.Printf("poller %s initial run error: %v", name, err)
 	}
 
@@ -64,7 +96,7 @@ func (p *PricePoller) pollLoop(ctx context.Context, name string, interval time.D
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			if err := fn(ctx); err != nil {
+			if err := run(); err != nil {
 				log.Printf("poller %s error: %v", name, err)
 			}
 		}
@@ -99,6 +131,8 @@ func (p *PricePoller) pollShortCandles(ctx context.Context) {
 }
 
 func (p *PricePoller) fetchShortBatch(ctx context.Context, coinIndex *int, count int) {
+	start := time.Now()
+	var lastErr error
 	symbols := domain.SupportedSymbols
 	for i := 0; i < count; i++ {
 		symbol := symbols[*coinIndex%len(symbols)]
@@ -106,8 +140,10 @@ func (p *PricePoller) fetchShortBatch(ctx context.Context, coinIndex *int, count
 
 		if err := p.priceService.RefreshShortCandles(ctx, symbol); err != nil {
 			log.Printf("short candle refresh error for %s: %v", symbol, err)
+			lastErr = err
 		}
 	}
+	recordJobRun("candles-short", start, lastErr)
 }
 
 func (p *PricePoller) pollLongCandles(ctx context.Context) {
@@ -141,7 +177,10 @@ func (p *PricePoller) fetchLongBatch(ctx context.Context, coinIndex *int) {
 	symbol := symbols[*coinIndex%len(symbols)]
 	*coinIndex++
 
-	if err := p.priceService.RefreshLongCandles(ctx, symbol); err != nil {
+	start := time.Now()
+	err := p.priceService.RefreshLongCandles(ctx, symbol)
+	recordJobRun("candles-long", start, err)
+	if err != nil {
 		log.Printf("long candle refresh error for %s: %v", symbol, err)
 	}
 }
@@ -2,6 +2,7 @@ package job
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -64,6 +65,39 @@ func TestFetchLongBatch(t *testing.T) {
 	}
 }
 
+type countingRefreshSink struct {
+	calls int
+}
+
+func (s *countingRefreshSink) OnPricesRefreshed(ctx context.Context) error {
+	s.calls++
+	return nil
+}
+
+func TestRefreshPricesNotifiesSinks(t *testing.T) {
+	tracer := trace.NewNoopTracerProvider().Tracer("test")
+	stub := &stubPriceService{}
+	poller := NewPricePoller(tracer, stub, 1)
+	sink := &countingRefreshSink{}
+	poller.AddRefreshSink(sink)
+	poller.AddRefreshSink(nil)
+
+	if err := poller.refreshPrices(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stub.refreshPricesCalls != 1 || sink.calls != 1 {
+		t.Fatalf("expected one refresh and one sink call, got %d %d", stub.refreshPricesCalls, sink.calls)
+	}
+
+	stub.refreshErr = errors.New("upstream down")
+	if err := poller.refreshPrices(context.Background()); err == nil {
+		t.Fatal("expected refresh error")
+	}
+	if sink.calls != 1 {
+		t.Fatalf("sinks should not run after a failed refresh, got %d calls", sink.calls)
+	}
+}
+
 func eventually(t *testing.T, cond func() bool) {
 	t.Helper()
 	deadline := time.Now().Add(100 * time.Millisecond)
@@ -78,13 +112,14 @@ func eventually(t *testing.T, cond func() bool) {
 
 type stubPriceService struct {
 	refreshPricesCalls int
+	refreshErr         error
 	shortSymbols       []string
 	longSymbols        []string
 }
 
 func (s *stubPriceService) RefreshPrices(ctx context.Context) error {
 	s.refreshPricesCalls++
-	return nil
+	return s.refreshErr
 }
 
 func (s *stubPriceService) RefreshShortCandles(ctx context.Context, symbol string) error {
@@ -64,7 +64,9 @@ func (j *SignalImageMaintenance) runRetry(ctx context.Context) {
 		_, span := j.tracer.Start(ctx, "signal-image-job.retry")
 		defer span.End()
 	}
+	start := time.Now()
 	count, err := j.maintain.RetryFailedImages(ctx, defaultImageRetryBatchSize)
+	recordJobRun("signal-image-retry", start, err)
 	if err != nil {
 		log.Printf("signal image retry error: %v", err)
 		return
@@ -79,7 +81,9 @@ func (j *SignalImageMaintenance) runCleanup(ctx context.Context) {
 		_, span := j.tracer.Start(ctx, "signal-image-job.cleanup")
 		defer span.End()
 	}
+	start := time.Now()
 	deleted, err := j.maintain.DeleteExpiredSignalImages(ctx)
+	recordJobRun("signal-image-cleanup", start, err)
 	if err != nil {
 		log.Printf("signal image cleanup error: %v", err)
 		return
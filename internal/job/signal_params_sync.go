@@ -0,0 +1,63 @@
+package job
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const signalParamsSyncTick = time.Minute
+
+type LiveParamsRestorer interface {
+	RestoreLiveParams(ctx context.Context) error
+}
+
+// SignalParamsSync reloads the promoted signal parameter set into the live
+// engine every minute, so a promotion served by another replica reaches the
+// signal poller running here.
+type SignalParamsSync struct {
+	tracer  trace.Tracer
+	restore LiveParamsRestorer
+	tick    time.Duration
+}
+
+func NewSignalParamsSync(tracer trace.Tracer, restore LiveParamsRestorer) *SignalParamsSync {
+	return &SignalParamsSync{tracer: tracer, restore: restore, tick: signalParamsSyncTick}
+}
+
+func (j *SignalParamsSync) Start(ctx context.Context) {
+	if j == nil || j.restore == nil {
+		<-ctx.Done()
+		return
+	}
+
+	log.Println("Signal params sync starting...")
+	ticker := time.NewTicker(j.tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Signal params sync stopped")
+			return
+		case <-ticker.C:
+			j.run(ctx)
+		}
+	}
+}
+
+func (j *SignalParamsSync) run(ctx context.Context) {
+	if j.tracer != nil {
+		var span trace.Span
+		ctx, span = j.tracer.Start(ctx, "signal-params-sync-job.run")
+		defer span.End()
+	}
+	start := time.Now()
+	err := j.restore.RestoreLiveParams(ctx)
+	recordJobRun("signal-params-sync", start, err)
+	if err != nil {
+		log.Printf("signal params sync error: %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package job
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type countingRestorer struct{ n atomic.Int32 }
+
+func (r *countingRestorer) RestoreLiveParams(ctx context.Context) error {
+	r.n.Add(1)
+	return nil
+}
+
+func TestSignalParamsSyncReloadsEachTick(t *testing.T) {
+	restorer := &countingRestorer{}
+	job := NewSignalParamsSync(trace.NewNoopTracerProvider().Tracer("test"), restorer)
+	job.tick = 5 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		job.Start(ctx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for restorer.n.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+	if restorer.n.Load() < 2 {
+		t.Fatalf("expected repeated reloads, got %d", restorer.n.Load())
+	}
+}
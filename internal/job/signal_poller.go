@@ -23,7 +23,7 @@ const maxSeenAlertSignals = 10000
 type SignalPoller struct {
 	tracer        trace.Tracer
 	signalService SignalGenerator
-	alertSink     SignalAlertSink
+	alertSinks    []SignalAlertSink
 
 	alertMu        sync.Mutex
 	seenAlertKeys  map[string]struct{}
@@ -39,12 +39,23 @@ type SignalAlertSink interface {
 }
 
 func NewSignalPoller(tracer trace.Tracer, signalService SignalGenerator, alertSink SignalAlertSink) *SignalPoller {
-	return &SignalPoller{
+	p := &SignalPoller{
 		tracer:        tracer,
 		signalService: signalService,
-		alertSink:     alertSink,
 		seenAlertKeys: make(map[string]struct{}),
 	}
+	p.AddAlertSink(alertSink)
+	return p
+}
+
+// AddAlertSink registers another receiver of fresh signals. Sinks are
+// notified in the order they were added; nil sinks are ignored. Call it
+// before Start.
+func (p *SignalPoller) AddAlertSink(sink SignalAlertSink) {
+	if sink == nil {
+		return
+	}
+	p.alertSinks = append(p.alertSinks, sink)
 }
 
 // Start launches background signal generation goroutines. Blocks until ctx is cancelled.
@@ -83,6 +94,8 @@ func (p *SignalPoller) pollShortSignals(ctx context.Context) {
 }
 
 func (p *SignalPoller) fetchShortBatch(ctx context.Context, coinIndex *int, count int) {
+	start := time.Now()
+	var lastErr error
 	symbols := domain.SupportedSymbols
 	for i := 0; i < count; i++ {
 		symbol := symbols[*coinIndex%len(symbols)]
@@ -91,14 +104,16 @@ func (p *SignalPoller) fetchShortBatch(ctx context.Context, coinIndex *int, coun
 		signals, err := p.signalService.GenerateForSymbol(ctx, symbol, shortSignalIntervals)
 		if err != nil {
 			log.Printf("short signal generation error for %s: %v", symbol, err)
+			lastErr = err
 			continue
 		}
 		p.notifySignals(ctx, signals)
 	}
+	recordJobRun("signals-short", start, lastErr)
 }
 
 func (p *SignalPoller) notifySignals(ctx context.Context, generated []domain.Signal) {
-	if p.alertSink == nil || len(generated) == 0 {
+	if len(p.alertSinks) == 0 || len(generated) == 0 {
 		return
 	}
 
@@ -106,8 +121,10 @@ func (p *SignalPoller) notifySignals(ctx context.Context, generated []domain.Sig
 	if len(fresh) == 0 {
 		return
 	}
-	if err := p.alertSink.NotifySignals(ctx, fresh); err != nil {
-		log.Printf("signal alert dispatch error: %v", err)
+	for _, sink := range p.alertSinks {
+		if err := sink.NotifySignals(ctx, fresh); err != nil {
+			log.Printf("signal alert dispatch error: %v", err)
+		}
 	}
 }
 
@@ -159,7 +176,9 @@ func (p *SignalPoller) fetchLongBatch(ctx context.Context, coinIndex *int) {
 	symbol := symbols[*coinIndex%len(symbols)]
 	*coinIndex++
 
+	start := time.Now()
 	signals, err := p.signalService.GenerateForSymbol(ctx, symbol, longSignalIntervals)
+	recordJobRun("signals-long", start, err)
 	if err != nil {
 		log.Printf("long signal generation error for %s: %v", symbol, err)
 		return
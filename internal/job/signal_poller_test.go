@@ -95,6 +95,26 @@ func TestSignalPollerDedupeAlerts(t *testing.T) {
 	}
 }
 
+func TestSignalPollerNotifiesEverySink(t *testing.T) {
+	tracer := trace.NewNoopTracerProvider().Tracer("test")
+	first, second := &stubSignalAlerter{}, &stubSignalAlerter{}
+	poller := NewSignalPoller(tracer, &stubSignalService{}, nil)
+	poller.AddAlertSink(first)
+	poller.AddAlertSink(nil)
+	poller.AddAlertSink(second)
+
+	sig := domain.Signal{Symbol: "ETH", Interval: "4h", Indicator: domain.IndicatorRSI, Direction: domain.DirectionShort, Timestamp: time.Unix(200, 0).UTC()}
+	poller.notifySignals(context.Background(), []domain.Signal{sig})
+	poller.notifySignals(context.Background(), []domain.Signal{sig})
+
+	if first.notifyCalls != 1 || second.notifyCalls != 1 {
+		t.Fatalf("expected each sink notified once, got %d and %d", first.notifyCalls, second.notifyCalls)
+	}
+	if len(second.lastSignals) != 1 || second.lastSignals[0].Symbol != "ETH" {
+		t.Fatalf("unexpected signals: %+v", second.lastSignals)
+	}
+}
+
 type stubSignalService struct {
 	calls     int
 	symbols   []string
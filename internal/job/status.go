@@ -0,0 +1,48 @@
+package job
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+// runs holds the run history of every job in this process, keyed by name.
+var runs = struct {
+	sync.Mutex
+	byName map[string]*domain.JobStatus
+}{byName: map[string]*domain.JobStatus{}}
+
+// recordJobRun notes that the named job finished a run begun at start.
+func recordJobRun(name string, start time.Time, err error) {
+	runs.Lock()
+	defer runs.Unlock()
+
+	st, ok := runs.byName[name]
+	if !ok {
+		st = &domain.JobStatus{Name: name}
+		runs.byName[name] = st
+	}
+	st.Runs++
+	st.LastRun = start.UTC()
+	st.LastDuration = time.Since(start)
+	st.LastError = ""
+	if err != nil {
+		st.Failures++
+		st.LastError = err.Error()
+	}
+}
+
+// Statuses returns the run history of every job that has run, by name.
+func Statuses() []domain.JobStatus {
+	runs.Lock()
+	defer runs.Unlock()
+
+	out := make([]domain.JobStatus, 0, len(runs.byName))
+	for _, st := range runs.byName {
+		out = append(out, *st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
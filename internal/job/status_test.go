@@ -0,0 +1,33 @@
+package job
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRecordJobRunTracksFailures(t *testing.T) {
+	start := time.Now().Add(-time.Second)
+	recordJobRun("test-b", start, nil)
+	recordJobRun("test-a", start, errors.New("boom"))
+	recordJobRun("test-a", start, nil)
+
+	var a, b bool
+	for i, st := range Statuses() {
+		if i > 0 && Statuses()[i-1].Name > st.Name {
+			t.Fatalf("expected statuses sorted by name")
+		}
+		switch st.Name {
+		case "test-a":
+			a = true
+			if st.Runs != 2 || st.Failures != 1 || st.LastError != "" || st.LastDuration < time.Second {
+				t.Fatalf("unexpected status: %+v", st)
+			}
+		case "test-b":
+			b = st.Runs == 1 && st.Failures == 0
+		}
+	}
+	if !a || !b {
+		t.Fatalf("expected both jobs recorded, got %+v", Statuses())
+	}
+}
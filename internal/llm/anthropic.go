@@ -0,0 +1,300 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	anthropicBaseURL = "https://api.anthropic.com"
+	anthropicVersion = "2023-06-01"
+	// anthropicMaxTokens is sent when a request sets none; the Messages API
+	// requires it.
+	anthropicMaxTokens = 1024
+	// jsonInstruction stands in for JSON mode, which Anthropic does not have.
+	jsonInstruction = "Respond with a single JSON object and nothing else: no prose, no markdown."
+)
+
+// Anthropic talks to the Anthropic Messages API.
+type Anthropic struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+	model   string
+}
+
+func NewAnthropic(cfg Config, client *http.Client) *Anthropic {
+	if client == nil {
+		client = &http.Client{Timeout: 2 * time.Minute}
+	}
+	baseURL := strings.TrimRight(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = anthropicBaseURL
+	}
+	return &Anthropic{client: client, baseURL: baseURL, apiKey: cfg.APIKey, model: cfg.Model}
+}
+
+type anthropicRequest struct {
+	Model     string             `json:"model"`
+	MaxTokens int                `json:"max_tokens"`
+	System    string             `json:"system,omitempty"`
+	Messages  []anthropicMessage `json:"messages"`
+	Tools     []anthropicTool    `json:"tools,omitempty"`
+	Stream    bool               `json:"stream,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string           `json:"role"`
+	Content []anthropicBlock `json:"content"`
+}
+
+// anthropicBlock is a text, tool_use or tool_result content block.
+type anthropicBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+}
+
+type anthropicTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+type anthropicResponse struct {
+	Model   string           `json:"model"`
+	Content []anthropicBlock `json:"content"`
+	Usage   struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+func (c *Anthropic) Complete(ctx context.Context, req Request) (*Response, error) {
+	resp, err := c.post(ctx, c.request(req))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var decoded anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("anthropic: decode response: %w", err)
+	}
+	return decoded.response(), nil
+}
+
+// anthropicStreamEvent is one server-sent event of a streamed message.
+type anthropicStreamEvent struct {
+	Type         string            `json:"type"`
+	Index        int               `json:"index"`
+	Message      anthropicResponse `json:"message"`
+	ContentBlock anthropicBlock    `json:"content_block"`
+	Delta        struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+	} `json:"delta"`
+	Usage struct {
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Stream asks for a streamed message and passes each text delta to
+// onDelta. Tool inputs arrive as partial JSON and are assembled.
+func (c *Anthropic) Stream(ctx context.Context, req Request, onDelta func(string)) (*Response, error) {
+	body := c.request(req)
+	body.Stream = true
+	resp, err := c.post(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var decoded anthropicResponse
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		var ev anthropicStreamEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &ev); err != nil {
+			return nil, fmt.Errorf("anthropic: decode stream event: %w", err)
+		}
+		switch ev.Type {
+		case "message_start":
+			decoded.Model = ev.Message.Model
+			decoded.Usage.InputTokens = ev.Message.Usage.InputTokens
+		case "content_block_start":
+			for len(decoded.Content) <= ev.Index {
+				decoded.Content = append(decoded.Content, anthropicBlock{})
+			}
+			block := ev.ContentBlock
+			block.Input = nil // sent as {} here; the real input follows as deltas
+			decoded.Content[ev.Index] = block
+		case "content_block_delta":
+			if ev.Index >= len(decoded.Content) {
+				continue
+			}
+			block := &decoded.Content[ev.Index]
+			switch ev.Delta.Type {
+			case "text_delta":
+				block.Text += ev.Delta.Text
+				if onDelta != nil {
+					onDelta(ev.Delta.Text)
+				}
+			case "input_json_delta":
+				block.Input = append(block.Input, ev.Delta.PartialJSON...)
+			}
+		case "message_delta":
+			decoded.Usage.OutputTokens = ev.Usage.OutputTokens
+		case "error":
+			return nil, fmt.Errorf("anthropic: %s", ev.Error.Message)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for i, block := range decoded.Content {
+		if block.Type == "tool_use" && len(block.Input) == 0 {
+			decoded.Content[i].Input = json.RawMessage("{}")
+		}
+	}
+	return decoded.response(), nil
+}
+
+func (c *Anthropic) post(ctx context.Context, req anthropicRequest) (*http.Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/v1/messages", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", c.apiKey)
+	httpReq.Header.Set("anthropic-version", anthropicVersion)
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("anthropic: http %d: %s", resp.StatusCode, bytes.TrimSpace(snippet))
+	}
+	return resp, nil
+}
+
+func (r anthropicResponse) response() *Response {
+	out := &Response{
+		Message: Message{Role: RoleAssistant},
+		Model:   r.Model,
+		Usage:   Usage{InputTokens: r.Usage.InputTokens, OutputTokens: r.Usage.OutputTokens},
+	}
+	var text []string
+	for _, block := range r.Content {
+		switch block.Type {
+		case "text":
+			text = append(text, block.Text)
+		case "tool_use":
+			out.Message.ToolCalls = append(out.Message.ToolCalls, ToolCall{
+				ID:        block.ID,
+				Name:      block.Name,
+				Arguments: string(block.Input),
+			})
+		}
+	}
+	out.Message.Content = strings.Join(text, "")
+	return out
+}
+
+// request converts req to the Messages API shape. System messages become
+// the system prompt, tool results become user turns, and consecutive turns
+// from the same role are merged, as the API requires them to alternate.
+// The API rejects tool_use and tool_result blocks in a request that defines
+// no tools, so without tools earlier calls and results are sent as text.
+func (c *Anthropic) request(req Request) anthropicRequest {
+	out := anthropicRequest{Model: c.model, MaxTokens: req.MaxTokens}
+	if req.Model != "" {
+		out.Model = req.Model
+	}
+	if out.MaxTokens <= 0 {
+		out.MaxTokens = anthropicMaxTokens
+	}
+
+	flatten := len(req.Tools) == 0
+	callNames := map[string]string{}
+	var system []string
+	for _, m := range req.Messages {
+		var role string
+		var blocks []anthropicBlock
+		switch m.Role {
+		case RoleSystem:
+			system = append(system, m.Content)
+			continue
+		case RoleTool:
+			role = "user"
+			blocks = []anthropicBlock{{Type: "tool_result", ToolUseID: m.ToolCallID, Content: m.Content}}
+			if flatten {
+				blocks = []anthropicBlock{{Type: "text", Text: fmt.Sprintf("[%s result] %s", callNames[m.ToolCallID], m.Content)}}
+			}
+		case RoleAssistant:
+			role = "assistant"
+			if m.Content != "" {
+				blocks = append(blocks, anthropicBlock{Type: "text", Text: m.Content})
+			}
+			for _, call := range m.ToolCalls {
+				if flatten {
+					callNames[call.ID] = call.Name
+					blocks = append(blocks, anthropicBlock{Type: "text", Text: fmt.Sprintf("[called %s %s]", call.Name, call.Arguments)})
+					continue
+				}
+				input := json.RawMessage(call.Arguments)
+				if !json.Valid(input) {
+					input = json.RawMessage("{}")
+				}
+				blocks = append(blocks, anthropicBlock{Type: "tool_use", ID: call.ID, Name: call.Name, Input: input})
+			}
+		default:
+			role = "user"
+			blocks = []anthropicBlock{{Type: "text", Text: m.Content}}
+		}
+		if n := len(out.Messages); n > 0 && out.Messages[n-1].Role == role {
+			out.Messages[n-1].Content = append(out.Messages[n-1].Content, blocks...)
+			continue
+		}
+		out.Messages = append(out.Messages, anthropicMessage{Role: role, Content: blocks})
+	}
+	if req.JSON {
+		system = append(system, jsonInstruction)
+	}
+	out.System = strings.Join(system, "\n\n")
+
+	for _, t := range req.Tools {
+		schema := t.Parameters
+		if schema == nil {
+			schema = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+		out.Tools = append(out.Tools, anthropicTool{Name: t.Name, Description: t.Description, InputSchema: schema})
+	}
+	return out
+}
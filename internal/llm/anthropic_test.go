@@ -0,0 +1,194 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAnthropicRoundTrip(t *testing.T) {
+	var header http.Header
+	var body anthropicRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		header = r.Header.Clone()
+		raw, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(raw, &body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{
+			"id": "msg_1", "type": "message", "role": "assistant", "model": "claude-test",
+			"content": [
+				{"type": "text", "text": "Let me check."},
+				{"type": "tool_use", "id": "toolu_1", "name": "get_prices", "input": {"symbols": ["BTC"]}}
+			],
+			"stop_reason": "tool_use",
+			"usage": {"input_tokens": 30, "output_tokens": 8}
+		}`)
+	}))
+	defer srv.Close()
+
+	client := NewAnthropic(Config{Provider: ProviderAnthropic, APIKey: "sk-test", Model: "claude-test", BaseURL: srv.URL + "/"}, srv.Client())
+	resp, err := client.Complete(context.Background(), Request{
+		Messages: []Message{
+			System("be brief"),
+			User("BTC and ETH?"),
+			{Role: RoleAssistant, Content: "Looking.", ToolCalls: []ToolCall{
+				{ID: "toolu_a", Name: "get_prices", Arguments: `{"symbols":["BTC"]}`},
+				{ID: "toolu_b", Name: "get_prices", Arguments: `not json`},
+			}},
+			ToolResult("toolu_a", `[{"symbol":"BTC"}]`),
+			ToolResult("toolu_b", `[{"symbol":"ETH"}]`),
+		},
+		Tools: []Tool{{Name: "get_prices", Description: "prices", Parameters: map[string]any{"type": "object"}}},
+		JSON:  true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if header.Get("x-api-key") != "sk-test" || header.Get("anthropic-version") != anthropicVersion {
+		t.Fatalf("unexpected headers %v", header)
+	}
+	if body.Model != "claude-test" || body.MaxTokens != anthropicMaxTokens {
+		t.Fatalf("unexpected model or max tokens: %s %d", body.Model, body.MaxTokens)
+	}
+	if !strings.HasPrefix(body.System, "be brief") || !strings.Contains(body.System, jsonInstruction) {
+		t.Fatalf("expected system prompt with JSON instruction, got %q", body.System)
+	}
+	if len(body.Messages) != 3 {
+		t.Fatalf("expected user, assistant and merged tool results, got %+v", body.Messages)
+	}
+	asst := body.Messages[1]
+	if asst.Role != "assistant" || len(asst.Content) != 3 || asst.Content[1].Type != "tool_use" || string(asst.Content[2].Input) != "{}" {
+		t.Fatalf("unexpected assistant turn %+v", asst)
+	}
+	results := body.Messages[2]
+	if results.Role != "user" || len(results.Content) != 2 || results.Content[1].ToolUseID != "toolu_b" {
+		t.Fatalf("expected both tool results in one user turn, got %+v", results)
+	}
+	if len(body.Tools) != 1 || body.Tools[0].InputSchema["type"] != "object" {
+		t.Fatalf("unexpected tools %+v", body.Tools)
+	}
+
+	if resp.Message.Content != "Let me check." || resp.Model != "claude-test" || resp.Usage.InputTokens != 30 {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+	if len(resp.Message.ToolCalls) != 1 || resp.Message.ToolCalls[0].Arguments != `{"symbols": ["BTC"]}` {
+		t.Fatalf("unexpected tool calls %+v", resp.Message.ToolCalls)
+	}
+}
+
+func TestAnthropicFlattensToolTurnsWithoutTools(t *testing.T) {
+	client := NewAnthropic(Config{Provider: ProviderAnthropic, APIKey: "sk-test", Model: "claude-test"}, nil)
+	history := []Message{
+		User("BTC?"),
+		{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "toolu_a", Name: "get_prices", Arguments: `{"symbols":["BTC"]}`}}},
+		ToolResult("toolu_a", `[{"symbol":"BTC","price_usd":97000}]`),
+		User("answer now"),
+	}
+
+	withTools := client.request(Request{Messages: history, Tools: []Tool{{Name: "get_prices"}}})
+	if withTools.Messages[1].Content[0].Type != "tool_use" || withTools.Messages[2].Content[0].Type != "tool_result" {
+		t.Fatalf("expected tool blocks while tools are offered, got %+v", withTools.Messages)
+	}
+
+	body := client.request(Request{Messages: history})
+	if len(body.Tools) != 0 || len(body.Messages) != 3 {
+		t.Fatalf("expected no tools and three turns, got %+v", body)
+	}
+	for _, m := range body.Messages {
+		for _, b := range m.Content {
+			if b.Type != "text" {
+				t.Fatalf("expected only text blocks without tools, got %+v", m)
+			}
+		}
+	}
+	if got := body.Messages[1].Content[0].Text; got != `[called get_prices {"symbols":["BTC"]}]` {
+		t.Fatalf("unexpected flattened call %q", got)
+	}
+	results := body.Messages[2]
+	if results.Role != "user" || len(results.Content) != 2 || !strings.HasPrefix(results.Content[0].Text, "[get_prices result] ") {
+		t.Fatalf("expected the result and follow-up in one user turn, got %+v", results)
+	}
+}
+
+func TestAnthropicHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = io.WriteString(w, `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`)
+	}))
+	defer srv.Close()
+
+	client := NewAnthropic(Config{APIKey: "bad", Model: "m", BaseURL: srv.URL}, srv.Client())
+	_, err := client.Complete(context.Background(), Request{Messages: []Message{User("hi")}})
+	if err == nil || !strings.Contains(err.Error(), "http 401") || !strings.Contains(err.Error(), "invalid x-api-key") {
+		t.Fatalf("expected the API error, got %v", err)
+	}
+}
+
+func TestAnthropicStream(t *testing.T) {
+	var body anthropicRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(raw, &body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, ev := range []string{
+			`{"type":"message_start","message":{"model":"claude-test","content":[],"usage":{"input_tokens":21}}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Checking "}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"prices."}}`,
+			`{"type":"content_block_stop","index":0}`,
+			`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_prices","input":{}}}`,
+			`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"symbols\":"}}`,
+			`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"[\"BTC\"]}"}}`,
+			`{"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_2","name":"list_signals","input":{}}}`,
+			`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":15}}`,
+			`{"type":"message_stop"}`,
+		} {
+			_, _ = io.WriteString(w, "event: x\ndata: "+ev+"\n\n")
+		}
+	}))
+	defer srv.Close()
+
+	client := NewAnthropic(Config{APIKey: "k", Model: "claude-test", BaseURL: srv.URL}, srv.Client())
+	var deltas []string
+	resp, err := client.Stream(context.Background(), Request{Messages: []Message{User("BTC?")}}, func(d string) {
+		deltas = append(deltas, d)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !body.Stream {
+		t.Fatal("expected a streaming request")
+	}
+	if strings.Join(deltas, "|") != "Checking |prices." || resp.Message.Content != "Checking prices." {
+		t.Fatalf("unexpected text %q / %q", deltas, resp.Message.Content)
+	}
+	calls := resp.Message.ToolCalls
+	if len(calls) != 2 || calls[0].Arguments != `{"symbols":["BTC"]}` || calls[1].Arguments != "{}" {
+		t.Fatalf("unexpected tool calls %+v", calls)
+	}
+	if resp.Model != "claude-test" || resp.Usage.InputTokens != 21 || resp.Usage.OutputTokens != 15 {
+		t.Fatalf("unexpected metadata %+v", resp)
+	}
+}
+
+func TestAnthropicStreamError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n")
+	}))
+	defer srv.Close()
+
+	client := NewAnthropic(Config{APIKey: "k", Model: "m", BaseURL: srv.URL}, srv.Client())
+	if _, err := client.Stream(context.Background(), Request{Messages: []Message{User("hi")}}, nil); err == nil || !strings.Contains(err.Error(), "Overloaded") {
+		t.Fatalf("expected the stream error, got %v", err)
+	}
+}
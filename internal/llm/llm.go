@@ -0,0 +1,192 @@
+// Package llm is a provider-agnostic interface to chat models, with
+// adapters for OpenAI, Anthropic and OpenAI-compatible endpoints such as
+// llama.cpp or Ollama, and a deterministic stub for tests and offline runs.
+// Requests cover plain chat, JSON-only replies and tool calls.
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Providers a Config can select.
+const (
+	ProviderOpenAI           = "openai"
+	ProviderAnthropic        = "anthropic"
+	ProviderOpenAICompatible = "openai_compatible"
+	ProviderStub             = "stub"
+)
+
+// Providers lists every supported provider.
+var Providers = []string{ProviderOpenAI, ProviderAnthropic, ProviderOpenAICompatible, ProviderStub}
+
+// Role is who a message is from.
+type Role string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+	// RoleTool messages carry a tool call's result back to the model.
+	RoleTool Role = "tool"
+)
+
+// Message is one turn of a conversation. Assistant messages may request
+// ToolCalls; tool messages answer the call named by ToolCallID.
+type Message struct {
+	Role       Role
+	Content    string
+	ToolCalls  []ToolCall
+	ToolCallID string
+}
+
+// ToolCall is the model asking to run a tool. Arguments is a JSON object.
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments string
+}
+
+// Tool is a function the model may call. Parameters is a JSON Schema
+// object describing its arguments.
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  map[string]any
+}
+
+// Request is one completion. An empty Model uses the client's default. JSON
+// asks for a reply that is a single JSON object.
+type Request struct {
+	Model     string
+	Messages  []Message
+	Tools     []Tool
+	JSON      bool
+	MaxTokens int
+}
+
+// Usage counts the tokens a completion used, where the provider reports it.
+type Usage struct {
+	InputTokens  int
+	OutputTokens int
+}
+
+// Response is the model's reply.
+type Response struct {
+	Message Message
+	Model   string
+	Usage   Usage
+}
+
+// Client completes chat requests.
+type Client interface {
+	Complete(ctx context.Context, req Request) (*Response, error)
+}
+
+// StreamClient is a Client that can also stream a reply as it is
+// generated. onDelta gets each piece of reply text in order; the returned
+// Response is what Complete would have returned.
+type StreamClient interface {
+	Client
+	Stream(ctx context.Context, req Request, onDelta func(string)) (*Response, error)
+}
+
+// System, User and Assistant build plain messages.
+func System(content string) Message    { return Message{Role: RoleSystem, Content: content} }
+func User(content string) Message      { return Message{Role: RoleUser, Content: content} }
+func Assistant(content string) Message { return Message{Role: RoleAssistant, Content: content} }
+
+// ToolResult answers the tool call with id.
+func ToolResult(id, content string) Message {
+	return Message{Role: RoleTool, Content: content, ToolCallID: id}
+}
+
+// Config selects a provider and model for one use case. BaseURL overrides
+// the provider's endpoint and is required for OpenAI-compatible servers.
+type Config struct {
+	Provider string
+	Model    string
+	APIKey   string
+	BaseURL  string
+}
+
+// Enabled reports whether the config has what its provider needs.
+func (c Config) Enabled() bool {
+	switch c.Provider {
+	case ProviderOpenAI, ProviderAnthropic:
+		return c.APIKey != ""
+	case ProviderOpenAICompatible:
+		return c.BaseURL != ""
+	case ProviderStub:
+		return true
+	}
+	return false
+}
+
+// DefaultModel is the model used for a provider when none is configured.
+func DefaultModel(provider string) string {
+	switch provider {
+	case ProviderOpenAI:
+		return "gpt-4o-mini"
+	case ProviderAnthropic:
+		return "claude-3-5-haiku-latest"
+	case ProviderStub:
+		return "stub"
+	}
+	return ""
+}
+
+// DefaultContextWindow is assumed for models ContextWindow does not know,
+// which is typical of small local models.
+const DefaultContextWindow = 8192
+
+// contextWindows maps model name prefixes to their context window in tokens.
+// More specific prefixes come first.
+var contextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"gpt-4.1", 1047576},
+	{"gpt-4o", 128000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4", 8192},
+	{"gpt-3.5", 16385},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4", 200000},
+	{"claude", 200000},
+	{"stub", 1000000},
+}
+
+// ContextWindow returns how many tokens model can take in one request.
+func ContextWindow(model string) int {
+	name := strings.ToLower(strings.TrimSpace(model))
+	for _, w := range contextWindows {
+		if strings.HasPrefix(name, w.prefix) {
+			return w.tokens
+		}
+	}
+	return DefaultContextWindow
+}
+
+// New builds the client for cfg.
+func New(cfg Config) (Client, error) {
+	if !cfg.Enabled() {
+		switch cfg.Provider {
+		case ProviderOpenAI, ProviderAnthropic, ProviderOpenAICompatible:
+			return nil, fmt.Errorf("llm provider %s is not configured", cfg.Provider)
+		}
+		return nil, fmt.Errorf("unknown llm provider %q (use %s)", cfg.Provider, strings.Join(Providers, ", "))
+	}
+	if cfg.Model == "" {
+		cfg.Model = DefaultModel(cfg.Provider)
+	}
+	switch cfg.Provider {
+	case ProviderOpenAI, ProviderOpenAICompatible:
+		return NewOpenAI(cfg), nil
+	case ProviderAnthropic:
+		return NewAnthropic(cfg, nil), nil
+	}
+	return NewStub(), nil
+}
@@ -0,0 +1,119 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewSelectsProvider(t *testing.T) {
+	cases := []struct {
+		cfg  Config
+		want string
+	}{
+		{Config{Provider: ProviderOpenAI, APIKey: "k"}, "*llm.OpenAI"},
+		{Config{Provider: ProviderOpenAICompatible, BaseURL: "http://localhost:11434/v1/", Model: "llama3.1"}, "*llm.OpenAI"},
+		{Config{Provider: ProviderAnthropic, APIKey: "k"}, "*llm.Anthropic"},
+		{Config{Provider: ProviderStub}, "*llm.Stub"},
+	}
+	for _, tc := range cases {
+		client, err := New(tc.cfg)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.cfg.Provider, err)
+		}
+		if got := typeName(client); got != tc.want {
+			t.Fatalf("%s: expected %s, got %s", tc.cfg.Provider, tc.want, got)
+		}
+	}
+
+	if client, _ := New(Config{Provider: ProviderAnthropic, APIKey: "k"}); client.(*Anthropic).model != "claude-3-5-haiku-latest" {
+		t.Fatal("expected the provider's default model when none is set")
+	}
+	if _, err := New(Config{Provider: ProviderOpenAI}); err == nil || !strings.Contains(err.Error(), "not configured") {
+		t.Fatalf("expected a missing key error, got %v", err)
+	}
+	if _, err := New(Config{Provider: ProviderOpenAICompatible}); err == nil {
+		t.Fatal("expected an error without a base URL")
+	}
+	if _, err := New(Config{Provider: "gemini"}); err == nil || !strings.Contains(err.Error(), "unknown llm provider") {
+		t.Fatalf("expected an unknown provider error, got %v", err)
+	}
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case *OpenAI:
+		return "*llm.OpenAI"
+	case *Anthropic:
+		return "*llm.Anthropic"
+	case *Stub:
+		return "*llm.Stub"
+	}
+	return "unknown"
+}
+
+func TestStubIsDeterministic(t *testing.T) {
+	stub := NewStub(Response{Message: Message{ToolCalls: []ToolCall{{ID: "1", Name: "get_prices", Arguments: "{}"}}}})
+	ctx := context.Background()
+	req := Request{Messages: []Message{System("sys"), User("hello"), Assistant("hi"), User("price of BTC?")}}
+
+	first, err := stub.Complete(ctx, req)
+	if err != nil || len(first.Message.ToolCalls) != 1 || first.Message.Role != RoleAssistant || first.Model != ProviderStub {
+		t.Fatalf("expected the scripted tool call, got %+v (%v)", first, err)
+	}
+	second, _ := stub.Complete(ctx, req)
+	if second.Message.Content != "stub reply to: price of BTC?" {
+		t.Fatalf("expected an echo of the last user message, got %q", second.Message.Content)
+	}
+	third, _ := stub.Complete(ctx, Request{Messages: req.Messages, JSON: true})
+	if third.Message.Content != "{}" {
+		t.Fatalf("expected {} for JSON requests, got %q", third.Message.Content)
+	}
+	if got := len(stub.Requests()); got != 3 {
+		t.Fatalf("expected 3 recorded requests, got %d", got)
+	}
+
+	stub.SetError(errors.New("down"))
+	if _, err := stub.Complete(ctx, req); err == nil {
+		t.Fatal("expected the configured error")
+	}
+	stub.SetError(nil)
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := stub.Complete(cancelled, req); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context cancellation, got %v", err)
+	}
+}
+
+func TestStubStreamsWords(t *testing.T) {
+	var _ StreamClient = (*OpenAI)(nil)
+	var _ StreamClient = (*Anthropic)(nil)
+
+	stub := NewStub(Response{Message: Assistant("BTC is up")})
+	var deltas []string
+	resp, err := stub.Stream(context.Background(), Request{}, func(d string) { deltas = append(deltas, d) })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(deltas, "|") != "BTC |is |up" || resp.Message.Content != "BTC is up" {
+		t.Fatalf("unexpected deltas %q", deltas)
+	}
+}
+
+func TestContextWindowByModelPrefix(t *testing.T) {
+	cases := map[string]int{
+		"gpt-4o-mini":             128000,
+		"gpt-4.1-nano":            1047576,
+		"gpt-4":                   8192,
+		"GPT-3.5-turbo":           16385,
+		"claude-3-5-haiku-latest": 200000,
+		"llama3.1:8b":             DefaultContextWindow,
+		"":                        DefaultContextWindow,
+	}
+	for model, want := range cases {
+		if got := ContextWindow(model); got != want {
+			t.Fatalf("ContextWindow(%q) = %d, want %d", model, got, want)
+		}
+	}
+}
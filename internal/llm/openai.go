@@ -0,0 +1,143 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/openai/openai-go/shared"
+)
+
+// OpenAI talks to the OpenAI chat completions API, or to any server that
+// implements it when Config.BaseURL is set.
+type OpenAI struct {
+	client openai.Client
+	model  string
+}
+
+func NewOpenAI(cfg Config, opts ...option.RequestOption) *OpenAI {
+	key := cfg.APIKey
+	if key == "" {
+		// Local servers usually ignore the key, but the SDK sends one.
+		key = "none"
+	}
+	opts = append([]option.RequestOption{option.WithAPIKey(key)}, opts...)
+	if cfg.BaseURL != "" {
+		opts = append(opts, option.WithBaseURL(cfg.BaseURL))
+	}
+	return &OpenAI{client: openai.NewClient(opts...), model: cfg.Model}
+}
+
+func (c *OpenAI) Complete(ctx context.Context, req Request) (*Response, error) {
+	completion, err := c.client.Chat.Completions.New(ctx, c.params(req))
+	if err != nil {
+		return nil, err
+	}
+	return openAIResponse(completion)
+}
+
+// Stream asks for a streamed completion and passes each content delta to
+// onDelta. Tool call arguments are assembled, not streamed.
+func (c *OpenAI) Stream(ctx context.Context, req Request, onDelta func(string)) (*Response, error) {
+	params := c.params(req)
+	params.StreamOptions = openai.ChatCompletionStreamOptionsParam{IncludeUsage: openai.Bool(true)}
+
+	stream := c.client.Chat.Completions.NewStreaming(ctx, params)
+	defer stream.Close()
+
+	var acc openai.ChatCompletionAccumulator
+	for stream.Next() {
+		chunk := stream.Current()
+		if !acc.AddChunk(chunk) {
+			return nil, fmt.Errorf("unexpected chunk %s in stream %s", chunk.ID, acc.ID)
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Index == 0 && choice.Delta.Content != "" && onDelta != nil {
+				onDelta(choice.Delta.Content)
+			}
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+	return openAIResponse(&acc.ChatCompletion)
+}
+
+func (c *OpenAI) params(req Request) openai.ChatCompletionNewParams {
+	params := openai.ChatCompletionNewParams{
+		Model:    c.model,
+		Messages: make([]openai.ChatCompletionMessageParamUnion, 0, len(req.Messages)),
+	}
+	if req.Model != "" {
+		params.Model = req.Model
+	}
+	for _, m := range req.Messages {
+		params.Messages = append(params.Messages, openAIMessage(m))
+	}
+	for _, t := range req.Tools {
+		params.Tools = append(params.Tools, openai.ChatCompletionToolParam{Function: shared.FunctionDefinitionParam{
+			Name:        t.Name,
+			Description: openai.String(t.Description),
+			Parameters:  shared.FunctionParameters(t.Parameters),
+		}})
+	}
+	if req.JSON {
+		params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONObject: &shared.ResponseFormatJSONObjectParam{},
+		}
+	}
+	if req.MaxTokens > 0 {
+		params.MaxTokens = openai.Int(int64(req.MaxTokens))
+	}
+	return params
+}
+
+func openAIResponse(completion *openai.ChatCompletion) (*Response, error) {
+	if len(completion.Choices) == 0 {
+		return nil, fmt.Errorf("no choices in LLM response")
+	}
+
+	msg := completion.Choices[0].Message
+	out := &Response{
+		Message: Message{Role: RoleAssistant, Content: msg.Content},
+		Model:   completion.Model,
+		Usage: Usage{
+			InputTokens:  int(completion.Usage.PromptTokens),
+			OutputTokens: int(completion.Usage.CompletionTokens),
+		},
+	}
+	for _, call := range msg.ToolCalls {
+		out.Message.ToolCalls = append(out.Message.ToolCalls, ToolCall{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: call.Function.Arguments,
+		})
+	}
+	return out, nil
+}
+
+func openAIMessage(m Message) openai.ChatCompletionMessageParamUnion {
+	switch m.Role {
+	case RoleSystem:
+		return openai.SystemMessage(m.Content)
+	case RoleTool:
+		return openai.ToolMessage(m.Content, m.ToolCallID)
+	case RoleAssistant:
+		var p openai.ChatCompletionAssistantMessageParam
+		if m.Content != "" {
+			p.Content.OfString = openai.String(m.Content)
+		}
+		for _, call := range m.ToolCalls {
+			p.ToolCalls = append(p.ToolCalls, openai.ChatCompletionMessageToolCallParam{
+				ID: call.ID,
+				Function: openai.ChatCompletionMessageToolCallFunctionParam{
+					Name:      call.Name,
+					Arguments: call.Arguments,
+				},
+			})
+		}
+		return openai.ChatCompletionMessageParamUnion{OfAssistant: &p}
+	}
+	return openai.UserMessage(m.Content)
+}
@@ -0,0 +1,115 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openai/openai-go/option"
+)
+
+func TestOpenAICompatibleRoundTrip(t *testing.T) {
+	var path, auth string
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		raw, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(raw, &body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{
+			"id": "c1", "object": "chat.completion", "created": 1, "model": "llama3.1",
+			"choices": [{"index": 0, "finish_reason": "tool_calls", "message": {
+				"role": "assistant", "content": "",
+				"tool_calls": [{"id": "call_9", "type": "function", "function": {"name": "get_candles", "arguments": "{\"symbol\":\"BTC\"}"}}]
+			}}],
+			"usage": {"prompt_tokens": 12, "completion_tokens": 5, "total_tokens": 17}
+		}`)
+	}))
+	defer srv.Close()
+
+	client := NewOpenAI(Config{Provider: ProviderOpenAICompatible, BaseURL: srv.URL + "/v1/", Model: "llama3.1"}, option.WithMaxRetries(0))
+	resp, err := client.Complete(context.Background(), Request{
+		Messages: []Message{
+			System("be brief"),
+			User("BTC?"),
+			{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "call_1", Name: "get_prices", Arguments: `{}`}}},
+			ToolResult("call_1", `[{"symbol":"BTC"}]`),
+		},
+		Tools:     []Tool{{Name: "get_candles", Description: "candles", Parameters: map[string]any{"type": "object"}}},
+		JSON:      true,
+		MaxTokens: 200,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if path != "/v1/chat/completions" || auth != "Bearer none" {
+		t.Fatalf("unexpected request to %s with auth %q", path, auth)
+	}
+	if body["model"] != "llama3.1" || body["max_tokens"] != float64(200) {
+		t.Fatalf("unexpected model or max tokens: %v", body)
+	}
+	if rf, _ := body["response_format"].(map[string]any); rf["type"] != "json_object" {
+		t.Fatalf("expected JSON mode, got %v", body["response_format"])
+	}
+	raw, _ := json.Marshal(body["messages"])
+	for _, want := range []string{`"role":"system"`, `"tool_calls":[{`, `"tool_call_id":"call_1"`, `"name":"get_prices"`} {
+		if !strings.Contains(string(raw), want) {
+			t.Fatalf("expected %s in messages, got %s", want, raw)
+		}
+	}
+	tools, _ := json.Marshal(body["tools"])
+	if !strings.Contains(string(tools), `"name":"get_candles"`) {
+		t.Fatalf("expected tools in request, got %s", tools)
+	}
+
+	if resp.Model != "llama3.1" || resp.Usage.InputTokens != 12 || resp.Usage.OutputTokens != 5 {
+		t.Fatalf("unexpected response metadata %+v", resp)
+	}
+	if len(resp.Message.ToolCalls) != 1 || resp.Message.ToolCalls[0] != (ToolCall{ID: "call_9", Name: "get_candles", Arguments: `{"symbol":"BTC"}`}) {
+		t.Fatalf("unexpected tool calls %+v", resp.Message.ToolCalls)
+	}
+}
+
+func TestOpenAIStream(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(raw, &body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{
+			`{"id":"c1","object":"chat.completion.chunk","created":1,"model":"gpt-test","choices":[{"index":0,"delta":{"role":"assistant","content":"BTC "}}]}`,
+			`{"id":"c1","object":"chat.completion.chunk","created":1,"model":"gpt-test","choices":[{"index":0,"delta":{"content":"is up."}}]}`,
+			`{"id":"c1","object":"chat.completion.chunk","created":1,"model":"gpt-test","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+			`{"id":"c1","object":"chat.completion.chunk","created":1,"model":"gpt-test","choices":[],"usage":{"prompt_tokens":9,"completion_tokens":4,"total_tokens":13}}`,
+		} {
+			_, _ = io.WriteString(w, "data: "+chunk+"\n\n")
+		}
+		_, _ = io.WriteString(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	client := NewOpenAI(Config{Provider: ProviderOpenAI, APIKey: "k", BaseURL: srv.URL + "/", Model: "gpt-test"}, option.WithMaxRetries(0))
+	var deltas []string
+	resp, err := client.Stream(context.Background(), Request{Messages: []Message{User("BTC?")}}, func(d string) {
+		deltas = append(deltas, d)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if body["stream"] != true {
+		t.Fatalf("expected a streaming request, got %v", body)
+	}
+	if strings.Join(deltas, "|") != "BTC |is up." {
+		t.Fatalf("unexpected deltas %q", deltas)
+	}
+	if resp.Message.Content != "BTC is up." || resp.Usage.InputTokens != 9 || resp.Usage.OutputTokens != 4 {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+}
@@ -0,0 +1,85 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// Stub is a deterministic Client for tests and offline runs. It returns
+// its scripted responses in order; without a script, or once the script
+// runs out, it echoes the last user message, or replies "{}" to JSON
+// requests. Every request is kept in Requests. Stream sends the reply one
+// word at a time.
+type Stub struct {
+	mu       sync.Mutex
+	script   []Response
+	err      error
+	requests []Request
+}
+
+func NewStub(script ...Response) *Stub {
+	return &Stub{script: script}
+}
+
+// SetError makes every following request fail with err; nil clears it.
+func (s *Stub) SetError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// Requests returns the requests received so far.
+func (s *Stub) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Stub) Complete(ctx context.Context, req Request) (*Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	if len(s.script) > 0 {
+		resp := s.script[0]
+		s.script = s.script[1:]
+		resp.Message.Role = RoleAssistant
+		if resp.Model == "" {
+			resp.Model = ProviderStub
+		}
+		return &resp, nil
+	}
+
+	reply := "{}"
+	if !req.JSON {
+		reply = "stub reply"
+		for i := len(req.Messages) - 1; i >= 0; i-- {
+			if req.Messages[i].Role == RoleUser {
+				reply = "stub reply to: " + req.Messages[i].Content
+				break
+			}
+		}
+	}
+	return &Response{Message: Assistant(reply), Model: ProviderStub}, nil
+}
+
+func (s *Stub) Stream(ctx context.Context, req Request, onDelta func(string)) (*Response, error) {
+	resp, err := s.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if onDelta != nil {
+		for _, word := range strings.SplitAfter(resp.Message.Content, " ") {
+			if word != "" {
+				onDelta(word)
+			}
+		}
+	}
+	return resp, nil
+}
@@ -185,6 +185,46 @@ LIMIT $1`, limit)
 	return out, rows.Err()
 }
 
+// ListTopHeadlines returns the news items published in [from, to) with the
+// strongest confident sentiment, for digests.
+func (r *Repository) ListTopHeadlines(ctx context.Context, from, to time.Time, limit int) ([]domain.MarketIntelItem, error) {
+	_, span := r.tracer.Start(ctx, "market-intel-repo.list-top-headlines")
+	defer span.End()
+
+	if limit <= 0 {
+		limit = 5
+	}
+
+	rows, err := r.pool.Query(ctx, `
+SELECT i.id, i.source, i.source_item_id, i.title, i.url, i.excerpt, i.author,
+       i.published_at, i.fetched_at, i.metadata_json,
+       i.sentiment_score, i.sentiment_confidence, i.sentiment_label, i.sentiment_model, i.sentiment_reason,
+       i.scored_at, i.created_at, i.updated_at,
+       COALESCE(array_agg(ms.symbol) FILTER (WHERE ms.symbol IS NOT NULL), '{}'::text[])
+FROM market_intel_items i
+LEFT JOIN market_intel_item_symbols ms ON ms.item_id = i.id
+WHERE i.published_at >= $1 AND i.published_at < $2
+  AND i.source <> 'fear_greed'
+  AND i.title <> ''
+GROUP BY i.id
+ORDER BY ABS(COALESCE(i.sentiment_score, 0)) * COALESCE(i.sentiment_confidence, 0) DESC, i.published_at DESC
+LIMIT $3`, from.UTC(), to.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]domain.MarketIntelItem, 0, limit)
+	for rows.Next() {
+		item, err := scanMarketIntelItemRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	return out, rows.Err()
+}
+
 func (r *Repository) UpdateItemSentiment(
 	ctx context.Context,
 	itemID int64,
@@ -372,6 +412,36 @@ RETURNING symbol, interval, open_time,
 	return &out, nil
 }
 
+// ListCompositeScores returns the composite snapshots for an interval in
+// [from, to], oldest first.
+func (r *Repository) ListCompositeScores(ctx context.Context, interval string, from, to time.Time) ([]domain.MarketCompositeSnapshot, error) {
+	_, span := r.tracer.Start(ctx, "market-intel-repo.list-composite-scores")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx, `
+SELECT symbol, interval, open_time, composite_score, direction
+FROM market_composite_snapshots
+WHERE interval = $1 AND open_time >= $2 AND open_time <= $3
+ORDER BY open_time ASC, symbol ASC`, interval, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]domain.MarketCompositeSnapshot, 0)
+	for rows.Next() {
+		var snap domain.MarketCompositeSnapshot
+		var direction string
+		if err := rows.Scan(&snap.Symbol, &snap.Interval, &snap.OpenTime, &snap.CompositeScore, &direction); err != nil {
+			return nil, err
+		}
+		snap.OpenTime = snap.OpenTime.UTC()
+		snap.Direction = domain.SignalDirection(direction)
+		out = append(out, snap)
+	}
+	return out, rows.Err()
+}
+
 func (r *Repository) AttachCompositeSignalID(ctx context.Context, symbol, interval string, openTime time.Time, signalID int64) error {
 	_, span := r.tracer.Start(ctx, "market-intel-repo.attach-composite-signal-id")
 	defer span.End()
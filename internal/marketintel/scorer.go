@@ -8,9 +8,7 @@ import (
 	"strings"
 
 	"bug-free-umbrella/internal/domain"
-
-	"github.com/openai/openai-go"
-	"github.com/openai/openai-go/option"
+	"bug-free-umbrella/internal/llm"
 )
 
 type SentimentScore struct {
@@ -151,31 +149,27 @@ func normalizeLabel(label string) string {
 	}
 }
 
-type openAIChatClient interface {
-	CreateChatCompletion(ctx context.Context, params openai.ChatCompletionNewParams) (*openai.ChatCompletion, error)
+// CompletionClient is the slice of llm.Client the scorer needs.
+type CompletionClient interface {
+	Complete(ctx context.Context, req llm.Request) (*llm.Response, error)
 }
 
-type OpenAIScorer struct {
-	client openAIChatClient
+// LLMScorer scores items in batches with any llm backend in JSON mode.
+type LLMScorer struct {
+	client CompletionClient
 	model  string
 }
 
-func NewOpenAIScorer(apiKey string, model string) *OpenAIScorer {
-	apiKey = strings.TrimSpace(apiKey)
-	if apiKey == "" {
+// NewLLMScorer returns nil when client is nil, so the Scorer keeps to the
+// keyword heuristic.
+func NewLLMScorer(client CompletionClient, model string) *LLMScorer {
+	if client == nil {
 		return nil
 	}
-	if strings.TrimSpace(model) == "" {
-		model = "gpt-4o-mini"
-	}
-	client := openai.NewClient(option.WithAPIKey(apiKey))
-	return &OpenAIScorer{
-		client: &openAIClient{client: client},
-		model:  model,
-	}
+	return &LLMScorer{client: client, model: strings.TrimSpace(model)}
 }
 
-func (s *OpenAIScorer) ScoreBatch(ctx context.Context, items []domain.MarketIntelItem) ([]SentimentScore, error) {
+func (s *LLMScorer) ScoreBatch(ctx context.Context, items []domain.MarketIntelItem) ([]SentimentScore, error) {
 	if s == nil || s.client == nil || len(items) == 0 {
 		return nil, nil
 	}
@@ -187,37 +181,32 @@ func (s *OpenAIScorer) ScoreBatch(ctx context.Context, items []domain.MarketInte
 		sb.WriteString(fmt.Sprintf("excerpt=%s\n\n", strings.TrimSpace(item.Excerpt)))
 	}
 
-	systemPrompt := "You score crypto sentiment. Return ONLY JSON array. Each object requires: id (int), score (-1..1), confidence (0..1), label (bullish|neutral|bearish), reason (short text). No markdown."
+	systemPrompt := `You score crypto sentiment. Return ONLY a JSON object {"items": [...]}. Each item requires: id (int), score (-1..1), confidence (0..1), label (bullish|neutral|bearish), reason (short text). No markdown.`
 	userPrompt := "Items:\n" + sb.String()
 
-	completion, err := s.client.CreateChatCompletion(ctx, openai.ChatCompletionNewParams{
-		Model: s.model,
-		Messages: []openai.ChatCompletionMessageParamUnion{
-			openai.SystemMessage(systemPrompt),
-			openai.UserMessage(userPrompt),
-		},
+	resp, err := s.client.Complete(ctx, llm.Request{
+		Model:    s.model,
+		Messages: []llm.Message{llm.System(systemPrompt), llm.User(userPrompt)},
+		JSON:     true,
 	})
 	if err != nil {
 		return nil, err
 	}
-	if len(completion.Choices) == 0 {
-		return nil, fmt.Errorf("empty scorer completion")
-	}
 
-	raw := strings.TrimSpace(completion.Choices[0].Message.Content)
-	raw = trimCodeFence(raw)
-
-	var parsed []struct {
-		ID         int64   `json:"id"`
-		Score      float64 `json:"score"`
-		Confidence float64 `json:"confidence"`
-		Label      string  `json:"label"`
-		Reason     string  `json:"reason"`
+	raw := trimCodeFence(resp.Message.Content)
+	if raw == "" {
+		return nil, fmt.Errorf("empty scorer completion")
 	}
-	if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+	parsed, err := parseScoredRows(raw)
+	if err != nil {
 		return nil, fmt.Errorf("parse scorer json: %w", err)
 	}
 
+	model := s.model
+	if model == "" {
+		model = resp.Model
+	}
+
 	byID := make(map[int64]struct{}, len(items))
 	for _, item := range items {
 		byID[item.ID] = struct{}{}
@@ -234,7 +223,7 @@ func (s *OpenAIScorer) ScoreBatch(ctx context.Context, items []domain.MarketInte
 			Confidence: clamp(row.Confidence, 0, 1),
 			Label:      normalizeLabel(row.Label),
 			Reason:     strings.TrimSpace(row.Reason),
-			Model:      "llm:" + s.model,
+			Model:      "llm:" + model,
 		})
 	}
 
@@ -242,6 +231,29 @@ func (s *OpenAIScorer) ScoreBatch(ctx context.Context, items []domain.MarketInte
 	return out, nil
 }
 
+type scoredRow struct {
+	ID         int64   `json:"id"`
+	Score      float64 `json:"score"`
+	Confidence float64 `json:"confidence"`
+	Label      string  `json:"label"`
+	Reason     string  `json:"reason"`
+}
+
+// parseScoredRows accepts the requested {"items": [...]} object as well as
+// a bare array, which smaller local models tend to return.
+func parseScoredRows(raw string) ([]scoredRow, error) {
+	if strings.HasPrefix(raw, "[") {
+		var rows []scoredRow
+		err := json.Unmarshal([]byte(raw), &rows)
+		return rows, err
+	}
+	var wrapped struct {
+		Items []scoredRow `json:"items"`
+	}
+	err := json.Unmarshal([]byte(raw), &wrapped)
+	return wrapped.Items, err
+}
+
 func trimCodeFence(v string) string {
 	v = strings.TrimSpace(v)
 	if strings.HasPrefix(v, "```") {
@@ -255,11 +267,3 @@ func trimCodeFence(v string) string {
 	}
 	return v
 }
-
-type openAIClient struct {
-	client openai.Client
-}
-
-func (c *openAIClient) CreateChatCompletion(ctx context.Context, params openai.ChatCompletionNewParams) (*openai.ChatCompletion, error) {
-	return c.client.Chat.Completions.New(ctx, params)
-}
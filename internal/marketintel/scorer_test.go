@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/llm"
 )
 
 func TestScorerHeuristicFallback(t *testing.T) {
@@ -71,3 +72,48 @@ func (s stubLLMScorer) ScoreBatch(ctx context.Context, items []domain.MarketInte
 	}
 	return append([]SentimentScore(nil), s.scores...), nil
 }
+
+func TestLLMScorerParsesJSON(t *testing.T) {
+	stub := llm.NewStub(
+		llm.Response{Message: llm.Assistant(`{"items":[{"id":2,"score":-3,"confidence":0.7,"label":"negative","reason":" exploit "},{"id":9,"score":1}]}`)},
+		llm.Response{Message: llm.Assistant("```json\n[{\"id\":1,\"score\":0.4,\"confidence\":0.6,\"label\":\"bull\"}]\n```"), Model: "llama3.1"},
+	)
+	items := []domain.MarketIntelItem{{ID: 1, Title: "ETF inflows"}, {ID: 2, Title: "Bridge hacked"}}
+
+	scorer := NewLLMScorer(stub, "claude-3-5-haiku-latest")
+	out, err := scorer.ScoreBatch(context.Background(), items)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(out) != 1 || out[0].ItemID != 2 || out[0].Score != -1 || out[0].Label != "bearish" || out[0].Reason != "exploit" {
+		t.Fatalf("expected one clamped bearish score for item 2, got %+v", out)
+	}
+	if out[0].Model != "llm:claude-3-5-haiku-latest" {
+		t.Fatalf("unexpected model label %s", out[0].Model)
+	}
+	req := stub.Requests()[0]
+	if !req.JSON || req.Model != "claude-3-5-haiku-latest" || len(req.Messages) != 2 {
+		t.Fatalf("expected a JSON-mode request, got %+v", req)
+	}
+
+	out, err = NewLLMScorer(stub, "").ScoreBatch(context.Background(), items)
+	if err != nil || len(out) != 1 || out[0].Label != "bullish" || out[0].Model != "llm:llama3.1" {
+		t.Fatalf("expected a fenced bare array to parse, got %+v (%v)", out, err)
+	}
+}
+
+func TestLLMScorerErrors(t *testing.T) {
+	if NewLLMScorer(nil, "m") != nil {
+		t.Fatal("expected nil scorer without a client")
+	}
+	items := []domain.MarketIntelItem{{ID: 1, Title: "x"}}
+
+	stub := llm.NewStub(llm.Response{Message: llm.Assistant("not json")})
+	if _, err := NewLLMScorer(stub, "m").ScoreBatch(context.Background(), items); err == nil {
+		t.Fatal("expected a parse error")
+	}
+	stub.SetError(errors.New("down"))
+	if _, err := NewLLMScorer(stub, "m").ScoreBatch(context.Background(), items); err == nil {
+		t.Fatal("expected the client error")
+	}
+}
@@ -18,3 +18,27 @@ type SignalReaderWriter interface {
 	ListSignals(ctx context.Context, filter domain.SignalFilter) ([]domain.Signal, error)
 	GenerateForSymbol(ctx context.Context, symbol string, intervals []string) ([]domain.Signal, error)
 }
+
+// HoldingsReaderWriter exposes users' portfolio holdings.
+type HoldingsReaderWriter interface {
+	Valuation(ctx context.Context, chatID int64) (*domain.HoldingsValuation, error)
+	Trades(ctx context.Context, chatID int64) ([]domain.HoldingTrade, error)
+	AddTrade(ctx context.Context, t domain.HoldingTrade) (*domain.HoldingTrade, error)
+}
+
+// PositionSizer sizes trades from a symbol's latest signal.
+type PositionSizer interface {
+	SizeSymbol(ctx context.Context, req domain.SizingRequest) (*domain.PositionSize, error)
+}
+
+// PriceAlertManager manages users' price alert rules.
+type PriceAlertManager interface {
+	CreateRule(ctx context.Context, rule domain.PriceAlertRule) (*domain.PriceAlertRule, error)
+	ListRules(ctx context.Context, chatID int64) ([]domain.PriceAlertRule, error)
+	DeleteRule(ctx context.Context, chatID, id int64) error
+}
+
+// ChartRenderer renders on-demand candle charts as PNG.
+type ChartRenderer interface {
+	RenderChart(ctx context.Context, req domain.ChartRequest) ([]byte, error)
+}
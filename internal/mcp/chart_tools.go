@@ -0,0 +1,26 @@
+package mcp
+
+import (
+	"context"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func registerChartTools(server *mcp.Server, charts ChartRenderer) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "chart_render",
+		Description: "Render a PNG candle chart for a symbol and interval with optional overlays (ema, bollinger, vwap) and panes (rsi, macd, volume)",
+	}, func(ctx context.Context, _ *mcp.CallToolRequest, in chartRenderInput) (*mcp.CallToolResult, chartRenderOutput, error) {
+		req, err := normalizeChartInput(in)
+		if err != nil {
+			return nil, chartRenderOutput{}, err
+		}
+		img, err := charts.RenderChart(ctx, req)
+		if err != nil {
+			return nil, chartRenderOutput{}, err
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.ImageContent{Data: img, MIMEType: "image/png"}},
+		}, chartRenderOutput{Request: req}, nil
+	})
+}
@@ -0,0 +1,68 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type stubChartRenderer struct {
+	lastReq domain.ChartRequest
+}
+
+func (s *stubChartRenderer) RenderChart(ctx context.Context, req domain.ChartRequest) ([]byte, error) {
+	s.lastReq = req
+	return []byte{0x89, 0x50, 0x4e, 0x47}, nil
+}
+
+func TestChartRenderTool(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, prices, signals := testServer()
+	charts := &stubChartRenderer{}
+	srv := NewServer(nil, prices, signals, ServerConfig{RequestTimeout: time.Second, Charts: charts})
+	session, shutdown, err := connectInMemory(ctx, srv)
+	if err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	defer shutdown()
+	defer session.Close()
+
+	res, err := session.CallTool(ctx, &sdkmcp.CallToolParams{Name: "chart_render", Arguments: map[string]any{
+		"symbol": "eth", "interval": "4H", "lookback": "7d", "overlays": []string{"ema"}, "panes": []string{"rsi", "volume"},
+	}})
+	if err != nil || res.IsError {
+		t.Fatalf("chart_render failed: %v %+v", err, res)
+	}
+	if charts.lastReq.Symbol != "ETH" || charts.lastReq.Interval != "4h" || charts.lastReq.Candles != 42 || len(charts.lastReq.Panes) != 2 {
+		t.Fatalf("unexpected request: %+v", charts.lastReq)
+	}
+	if len(res.Content) != 1 {
+		t.Fatalf("expected one content item, got %d", len(res.Content))
+	}
+	img, ok := res.Content[0].(*sdkmcp.ImageContent)
+	if !ok || img.MIMEType != "image/png" || len(img.Data) != 4 {
+		t.Fatalf("expected png image content, got %#v", res.Content[0])
+	}
+	raw, _ := json.Marshal(res.StructuredContent)
+	var got chartRenderOutput
+	if err := json.Unmarshal(raw, &got); err != nil || got.Request.Symbol != "ETH" {
+		t.Fatalf("unexpected chart_render output: %s", raw)
+	}
+
+	res, err = session.CallTool(ctx, &sdkmcp.CallToolParams{Name: "chart_render", Arguments: map[string]any{
+		"symbol": "BTC", "lookback": "7y",
+	}})
+	if err != nil {
+		t.Fatalf("unexpected protocol error: %v", err)
+	}
+	if !res.IsError {
+		t.Fatal("expected validation error for lookback")
+	}
+}
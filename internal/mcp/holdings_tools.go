@@ -0,0 +1,53 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func registerHoldingsTools(server *mcp.Server, holdings HoldingsReaderWriter) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "holdings_get",
+		Description: "Get a user's portfolio holdings valued at current prices, with FIFO cost basis, allocation and realized/unrealized PnL",
+	}, func(ctx context.Context, _ *mcp.CallToolRequest, in holdingsGetInput) (*mcp.CallToolResult, holdingsGetOutput, error) {
+		if in.ChatID == 0 {
+			return nil, holdingsGetOutput{}, fmt.Errorf("chat_id is required")
+		}
+		v, err := holdings.Valuation(ctx, in.ChatID)
+		if err != nil {
+			return nil, holdingsGetOutput{}, err
+		}
+		return nil, holdingsGetOutput{Valuation: v}, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "holdings_trades_list",
+		Description: "List the buy and sell trades behind a user's holdings, oldest first",
+	}, func(ctx context.Context, _ *mcp.CallToolRequest, in holdingsTradesListInput) (*mcp.CallToolResult, holdingsTradesListOutput, error) {
+		if in.ChatID == 0 {
+			return nil, holdingsTradesListOutput{}, fmt.Errorf("chat_id is required")
+		}
+		trades, err := holdings.Trades(ctx, in.ChatID)
+		if err != nil {
+			return nil, holdingsTradesListOutput{}, err
+		}
+		return nil, holdingsTradesListOutput{Trades: trades}, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "holdings_add_trade",
+		Description: "Record a buy or sell in a user's holdings",
+	}, func(ctx context.Context, _ *mcp.CallToolRequest, in holdingsAddTradeInput) (*mcp.CallToolResult, holdingsAddTradeOutput, error) {
+		trade, err := normalizeHoldingTrade(in)
+		if err != nil {
+			return nil, holdingsAddTradeOutput{}, err
+		}
+		out, err := holdings.AddTrade(ctx, trade)
+		if err != nil {
+			return nil, holdingsAddTradeOutput{}, err
+		}
+		return nil, holdingsAddTradeOutput{Trade: out}, nil
+	})
+}
@@ -0,0 +1,92 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type stubHoldings struct {
+	lastTrade domain.HoldingTrade
+}
+
+func (s *stubHoldings) Valuation(ctx context.Context, chatID int64) (*domain.HoldingsValuation, error) {
+	return &domain.HoldingsValuation{ChatID: chatID, Holdings: []domain.Holding{{Symbol: "BTC", Quantity: 1}}}, nil
+}
+
+func (s *stubHoldings) Trades(ctx context.Context, chatID int64) ([]domain.HoldingTrade, error) {
+	return []domain.HoldingTrade{{ID: 1, ChatID: chatID, Symbol: "BTC"}}, nil
+}
+
+func (s *stubHoldings) AddTrade(ctx context.Context, t domain.HoldingTrade) (*domain.HoldingTrade, error) {
+	s.lastTrade = t
+	t.ID = 2
+	return &t, nil
+}
+
+func TestHoldingsTools(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, prices, signals := testServer()
+	holdings := &stubHoldings{}
+	srv := NewServer(nil, prices, signals, ServerConfig{RequestTimeout: time.Second, Holdings: holdings})
+	session, shutdown, err := connectInMemory(ctx, srv)
+	if err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	defer shutdown()
+	defer session.Close()
+
+	res, err := session.CallTool(ctx, &sdkmcp.CallToolParams{Name: "holdings_get", Arguments: map[string]any{"chat_id": -1000004}})
+	if err != nil || res.IsError {
+		t.Fatalf("holdings_get failed: %v %+v", err, res)
+	}
+	raw, _ := json.Marshal(res.StructuredContent)
+	var got holdingsGetOutput
+	if err := json.Unmarshal(raw, &got); err != nil || got.Valuation == nil || got.Valuation.ChatID != -1000004 {
+		t.Fatalf("unexpected holdings_get output: %s", raw)
+	}
+
+	res, err = session.CallTool(ctx, &sdkmcp.CallToolParams{Name: "holdings_add_trade", Arguments: map[string]any{
+		"chat_id": 7, "symbol": "eth", "side": "BUY", "quantity": 2, "price": 2500, "executed_at": "2026-01-01T00:00:00Z",
+	}})
+	if err != nil || res.IsError {
+		t.Fatalf("holdings_add_trade failed: %v %+v", err, res)
+	}
+	if holdings.lastTrade.Symbol != "ETH" || holdings.lastTrade.Side != domain.PaperBuy || holdings.lastTrade.ExecutedAt.Year() != 2026 {
+		t.Fatalf("unexpected trade: %+v", holdings.lastTrade)
+	}
+
+	res, err = session.CallTool(ctx, &sdkmcp.CallToolParams{Name: "holdings_add_trade", Arguments: map[string]any{
+		"chat_id": 7, "symbol": "ETH", "side": "hold", "quantity": 1, "price": 1,
+	}})
+	if err != nil {
+		t.Fatalf("unexpected protocol error: %v", err)
+	}
+	if !res.IsError {
+		t.Fatal("expected validation error for side")
+	}
+}
+
+func TestHoldingsToolsRequireService(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	srv, _, _ := testServer()
+	session, shutdown, err := connectInMemory(ctx, srv)
+	if err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	defer shutdown()
+	defer session.Close()
+
+	if _, err := session.CallTool(ctx, &sdkmcp.CallToolParams{Name: "holdings_get", Arguments: map[string]any{"chat_id": 1}}); err == nil {
+		t.Fatal("expected holdings tools to be absent without a holdings service")
+	}
+}
@@ -0,0 +1,52 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func registerPriceAlertTools(server *mcp.Server, alerts PriceAlertManager) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "price_alerts_list",
+		Description: "List a user's price alert rules with their armed state and trigger counts",
+	}, func(ctx context.Context, _ *mcp.CallToolRequest, in priceAlertsListInput) (*mcp.CallToolResult, priceAlertsListOutput, error) {
+		if in.ChatID == 0 {
+			return nil, priceAlertsListOutput{}, fmt.Errorf("chat_id is required")
+		}
+		rules, err := alerts.ListRules(ctx, in.ChatID)
+		if err != nil {
+			return nil, priceAlertsListOutput{}, err
+		}
+		return nil, priceAlertsListOutput{Alerts: rules}, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "price_alerts_create",
+		Description: "Create a price alert that notifies the user when a price crosses a level, moves a percentage within a window, or 24h volume spikes. Rules fire once unless recurring",
+	}, func(ctx context.Context, _ *mcp.CallToolRequest, in priceAlertsCreateInput) (*mcp.CallToolResult, priceAlertsCreateOutput, error) {
+		rule, err := normalizePriceAlertInput(in)
+		if err != nil {
+			return nil, priceAlertsCreateOutput{}, err
+		}
+		out, err := alerts.CreateRule(ctx, rule)
+		if err != nil {
+			return nil, priceAlertsCreateOutput{}, err
+		}
+		return nil, priceAlertsCreateOutput{Alert: out}, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "price_alerts_delete",
+		Description: "Delete one of a user's price alerts",
+	}, func(ctx context.Context, _ *mcp.CallToolRequest, in priceAlertsDeleteInput) (*mcp.CallToolResult, priceAlertsDeleteOutput, error) {
+		if in.ChatID == 0 || in.AlertID <= 0 {
+			return nil, priceAlertsDeleteOutput{}, fmt.Errorf("chat_id and alert_id are required")
+		}
+		if err := alerts.DeleteRule(ctx, in.ChatID, in.AlertID); err != nil {
+			return nil, priceAlertsDeleteOutput{}, err
+		}
+		return nil, priceAlertsDeleteOutput{Deleted: true}, nil
+	})
+}
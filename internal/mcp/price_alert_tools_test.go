@@ -0,0 +1,84 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type stubPriceAlerts struct {
+	rules []domain.PriceAlertRule
+}
+
+func (s *stubPriceAlerts) CreateRule(ctx context.Context, rule domain.PriceAlertRule) (*domain.PriceAlertRule, error) {
+	rule.ID = int64(len(s.rules) + 1)
+	s.rules = append(s.rules, rule)
+	return &rule, nil
+}
+
+func (s *stubPriceAlerts) ListRules(ctx context.Context, chatID int64) ([]domain.PriceAlertRule, error) {
+	return s.rules, nil
+}
+
+func (s *stubPriceAlerts) DeleteRule(ctx context.Context, chatID, id int64) error {
+	if id > int64(len(s.rules)) {
+		return errors.New("price alert not found")
+	}
+	return nil
+}
+
+func TestPriceAlertTools(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, prices, signals := testServer()
+	alerts := &stubPriceAlerts{}
+	srv := NewServer(nil, prices, signals, ServerConfig{RequestTimeout: time.Second, PriceAlerts: alerts})
+	session, shutdown, err := connectInMemory(ctx, srv)
+	if err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	defer shutdown()
+	defer session.Close()
+
+	res, err := session.CallTool(ctx, &sdkmcp.CallToolParams{Name: "price_alerts_create", Arguments: map[string]any{
+		"chat_id": 7, "symbol": "eth", "type": "Cross", "direction": "up", "threshold": 4000,
+	}})
+	if err != nil || res.IsError {
+		t.Fatalf("price_alerts_create failed: %v %+v", err, res)
+	}
+	if len(alerts.rules) != 1 || alerts.rules[0].Symbol != "ETH" || alerts.rules[0].Type != domain.PriceAlertCross || alerts.rules[0].ChatID != 7 {
+		t.Fatalf("unexpected rule: %+v", alerts.rules)
+	}
+
+	res, err = session.CallTool(ctx, &sdkmcp.CallToolParams{Name: "price_alerts_list", Arguments: map[string]any{"chat_id": 7}})
+	if err != nil || res.IsError {
+		t.Fatalf("price_alerts_list failed: %v %+v", err, res)
+	}
+	raw, _ := json.Marshal(res.StructuredContent)
+	var got priceAlertsListOutput
+	if err := json.Unmarshal(raw, &got); err != nil || len(got.Alerts) != 1 {
+		t.Fatalf("unexpected price_alerts_list output: %s", raw)
+	}
+
+	res, err = session.CallTool(ctx, &sdkmcp.CallToolParams{Name: "price_alerts_delete", Arguments: map[string]any{"chat_id": 7, "alert_id": 1}})
+	if err != nil || res.IsError {
+		t.Fatalf("price_alerts_delete failed: %v %+v", err, res)
+	}
+
+	res, err = session.CallTool(ctx, &sdkmcp.CallToolParams{Name: "price_alerts_create", Arguments: map[string]any{
+		"chat_id": 0, "symbol": "ETH", "type": "cross", "threshold": 4000,
+	}})
+	if err != nil {
+		t.Fatalf("unexpected protocol error: %v", err)
+	}
+	if !res.IsError {
+		t.Fatal("expected validation error for missing chat_id")
+	}
+}
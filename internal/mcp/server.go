@@ -16,6 +16,14 @@ const defaultRequestTimeout = 5 * time.Second
 
 type ServerConfig struct {
 	RequestTimeout time.Duration
+	// Holdings enables the holdings tools when set.
+	Holdings HoldingsReaderWriter
+	// Sizer enables the position_size tool when set.
+	Sizer PositionSizer
+	// PriceAlerts enables the price alert tools when set.
+	PriceAlerts PriceAlertManager
+	// Charts enables the chart_render tool when set.
+	Charts ChartRenderer
 }
 
 func NewServer(tracer trace.Tracer, prices PriceReader, signals SignalReaderWriter, cfg ServerConfig) *sdkmcp.Server {
@@ -38,6 +46,18 @@ func NewServer(tracer trace.Tracer, prices PriceReader, signals SignalReaderWrit
 	}
 
 	registerTools(srv, prices, signals)
+	if cfg.Holdings != nil {
+		registerHoldingsTools(srv, cfg.Holdings)
+	}
+	if cfg.Sizer != nil {
+		registerSizingTools(srv, cfg.Sizer)
+	}
+	if cfg.PriceAlerts != nil {
+		registerPriceAlertTools(srv, cfg.PriceAlerts)
+	}
+	if cfg.Charts != nil {
+		registerChartTools(srv, cfg.Charts)
+	}
 	registerResources(srv, prices, signals)
 	return srv
 }
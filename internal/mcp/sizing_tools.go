@@ -0,0 +1,24 @@
+package mcp
+
+import (
+	"context"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func registerSizingTools(server *mcp.Server, sizer PositionSizer) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "position_size",
+		Description: "Size a trade on a symbol from its latest signal's stop and risk level for the given account equity. Returns quantity, notional and the amount lost at the stop",
+	}, func(ctx context.Context, _ *mcp.CallToolRequest, in positionSizeInput) (*mcp.CallToolResult, positionSizeOutput, error) {
+		req, err := normalizeSizingRequest(in)
+		if err != nil {
+			return nil, positionSizeOutput{}, err
+		}
+		out, err := sizer.SizeSymbol(ctx, req)
+		if err != nil {
+			return nil, positionSizeOutput{}, err
+		}
+		return nil, positionSizeOutput{Size: out}, nil
+	})
+}
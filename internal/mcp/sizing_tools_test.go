@@ -0,0 +1,61 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type stubSizer struct {
+	lastReq domain.SizingRequest
+}
+
+func (s *stubSizer) SizeSymbol(ctx context.Context, req domain.SizingRequest) (*domain.PositionSize, error) {
+	s.lastReq = req
+	return &domain.PositionSize{Symbol: req.Symbol, Equity: req.Equity, Quantity: 0.1, Notional: 5000}, nil
+}
+
+func TestPositionSizeTool(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, prices, signals := testServer()
+	sizer := &stubSizer{}
+	srv := NewServer(nil, prices, signals, ServerConfig{RequestTimeout: time.Second, Sizer: sizer})
+	session, shutdown, err := connectInMemory(ctx, srv)
+	if err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	defer shutdown()
+	defer session.Close()
+
+	res, err := session.CallTool(ctx, &sdkmcp.CallToolParams{Name: "position_size", Arguments: map[string]any{
+		"symbol": "btc", "equity": 10000, "risk_per_trade": 0.02, "method": "Kelly",
+	}})
+	if err != nil || res.IsError {
+		t.Fatalf("position_size failed: %v %+v", err, res)
+	}
+	if sizer.lastReq.Symbol != "BTC" || sizer.lastReq.RiskPerTrade != 0.02 || sizer.lastReq.Method != domain.SizingKelly {
+		t.Fatalf("unexpected request: %+v", sizer.lastReq)
+	}
+	raw, _ := json.Marshal(res.StructuredContent)
+	var got positionSizeOutput
+	if err := json.Unmarshal(raw, &got); err != nil || got.Size == nil || got.Size.Notional != 5000 {
+		t.Fatalf("unexpected position_size output: %s", raw)
+	}
+
+	res, err = session.CallTool(ctx, &sdkmcp.CallToolParams{Name: "position_size", Arguments: map[string]any{
+		"symbol": "BTC", "equity": 10000, "method": "martingale",
+	}})
+	if err != nil {
+		t.Fatalf("unexpected protocol error: %v", err)
+	}
+	if !res.IsError {
+		t.Fatal("expected validation error for method")
+	}
+}
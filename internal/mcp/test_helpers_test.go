@@ -74,6 +74,7 @@ func testServer() (*sdkmcp.Server, *stubPriceService, *stubSignalService) {
 		listed: []domain.Signal{{
 			ID: 1, Symbol: "BTC", Interval: "1h", Indicator: domain.IndicatorRSI,
 			Direction: domain.DirectionLong, Risk: domain.RiskLevel2, Timestamp: time.Unix(0, 0).UTC(),
+			Levels: &domain.SignalLevels{Entry: 50000, Stop: 49000, Targets: []float64{52000}, RewardRisk: 2, Basis: domain.LevelBasisSwing},
 		}},
 		generated: []domain.Signal{{
 			ID: 2, Symbol: "BTC", Interval: "1h", Indicator: domain.IndicatorMACD,
@@ -66,7 +66,7 @@ func registerTools(server *mcp.Server, prices PriceReader, signals SignalReaderW
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "signals_list",
-		Description: "Get recent generated trading signals with optional filters",
+		Description: "Get recent generated trading signals with optional filters; directional signals include entry, stop, targets and reward/risk levels",
 	}, func(ctx context.Context, _ *mcp.CallToolRequest, in signalsListInput) (*mcp.CallToolResult, signalsListOutput, error) {
 		if signals == nil {
 			return nil, signalsListOutput{}, fmt.Errorf("signal service unavailable")
@@ -2,6 +2,7 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -57,6 +58,17 @@ func TestToolsListAndInvoke(t *testing.T) {
 	if res.IsError {
 		t.Fatalf("unexpected signals_list tool error: %+v", res.Content)
 	}
+	raw, err := json.Marshal(res.StructuredContent)
+	if err != nil {
+		t.Fatalf("marshal structured content: %v", err)
+	}
+	var listed signalsListOutput
+	if err := json.Unmarshal(raw, &listed); err != nil {
+		t.Fatalf("decode signals_list output: %v", err)
+	}
+	if len(listed.Signals) != 1 || listed.Signals[0].Levels == nil || listed.Signals[0].Levels.Stop != 49000 {
+		t.Fatalf("expected signal levels in signals_list output, got %s", raw)
+	}
 }
 
 func TestToolsValidationFailure(t *testing.T) {
@@ -3,6 +3,7 @@ package mcp
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"bug-free-umbrella/internal/domain"
 )
@@ -61,6 +62,90 @@ type signalsGenerateOutput struct {
 	Signals        []domain.Signal `json:"signals"`
 }
 
+type holdingsGetInput struct {
+	ChatID int64 `json:"chat_id" jsonschema:"user identity: Telegram chat ID or SSH user chat ID"`
+}
+
+type holdingsGetOutput struct {
+	Valuation *domain.HoldingsValuation `json:"valuation"`
+}
+
+type holdingsTradesListInput struct {
+	ChatID int64 `json:"chat_id" jsonschema:"user identity: Telegram chat ID or SSH user chat ID"`
+}
+
+type holdingsTradesListOutput struct {
+	Trades []domain.HoldingTrade `json:"trades"`
+}
+
+type holdingsAddTradeInput struct {
+	ChatID     int64   `json:"chat_id" jsonschema:"user identity: Telegram chat ID or SSH user chat ID"`
+	Symbol     string  `json:"symbol" jsonschema:"asset symbol (e.g. BTC, ETH)"`
+	Side       string  `json:"side" jsonschema:"buy or sell"`
+	Quantity   float64 `json:"quantity" jsonschema:"units bought or sold"`
+	Price      float64 `json:"price" jsonschema:"fill price in USD"`
+	Fee        float64 `json:"fee,omitempty" jsonschema:"optional fee in USD"`
+	ExecutedAt string  `json:"executed_at,omitempty" jsonschema:"optional RFC3339 time of the trade, defaults to now"`
+}
+
+type holdingsAddTradeOutput struct {
+	Trade *domain.HoldingTrade `json:"trade"`
+}
+
+type positionSizeInput struct {
+	Symbol       string  `json:"symbol" jsonschema:"asset symbol (e.g. BTC, ETH)"`
+	Equity       float64 `json:"equity" jsonschema:"account equity in USD"`
+	RiskPerTrade float64 `json:"risk_per_trade,omitempty" jsonschema:"optional max share of equity to lose at the stop, e.g. 0.01 for 1%"`
+	Method       string  `json:"method,omitempty" jsonschema:"optional sizing method: fixed_fractional, volatility_parity or kelly"`
+}
+
+type positionSizeOutput struct {
+	Size *domain.PositionSize `json:"size"`
+}
+
+type priceAlertsListInput struct {
+	ChatID int64 `json:"chat_id" jsonschema:"user identity: Telegram chat ID or SSH user chat ID"`
+}
+
+type priceAlertsListOutput struct {
+	Alerts []domain.PriceAlertRule `json:"alerts"`
+}
+
+type priceAlertsCreateInput struct {
+	ChatID     int64   `json:"chat_id" jsonschema:"user identity: Telegram chat ID or SSH user chat ID"`
+	Symbol     string  `json:"symbol" jsonschema:"asset symbol (e.g. BTC, ETH)"`
+	Type       string  `json:"type" jsonschema:"cross (price crosses threshold), move (percent move within window) or volume (percent rise in 24h volume within window)"`
+	Direction  string  `json:"direction,omitempty" jsonschema:"up or down for cross rules; up, down or any (default) for move rules"`
+	Threshold  float64 `json:"threshold" jsonschema:"USD price for cross rules, percent for move and volume rules"`
+	WindowMins int     `json:"window_mins,omitempty" jsonschema:"look-back window in minutes for move and volume rules (5 to 1440)"`
+	Recurring  bool    `json:"recurring,omitempty" jsonschema:"fire every time the rule re-arms instead of once"`
+}
+
+type priceAlertsCreateOutput struct {
+	Alert *domain.PriceAlertRule `json:"alert"`
+}
+
+type chartRenderInput struct {
+	Symbol   string   `json:"symbol" jsonschema:"asset symbol (e.g. BTC, ETH)"`
+	Interval string   `json:"interval,omitempty" jsonschema:"candle interval: 5m, 15m, 1h (default), 4h or 1d"`
+	Lookback string   `json:"lookback,omitempty" jsonschema:"candle count such as 200 or a duration such as 48h, 7d or 2w (default 120 candles)"`
+	Overlays []string `json:"overlays,omitempty" jsonschema:"overlays drawn on the candles: ema, bollinger, vwap"`
+	Panes    []string `json:"panes,omitempty" jsonschema:"panes drawn below the candles: rsi, macd, volume"`
+}
+
+type chartRenderOutput struct {
+	Request domain.ChartRequest `json:"request"`
+}
+
+type priceAlertsDeleteInput struct {
+	ChatID  int64 `json:"chat_id" jsonschema:"user identity: Telegram chat ID or SSH user chat ID"`
+	AlertID int64 `json:"alert_id" jsonschema:"id of the alert to delete"`
+}
+
+type priceAlertsDeleteOutput struct {
+	Deleted bool `json:"deleted"`
+}
+
 func normalizeSymbol(symbol string) (string, error) {
 	symbol = strings.ToUpper(strings.TrimSpace(symbol))
 	if symbol == "" {
@@ -174,3 +259,83 @@ func normalizeGenerateIntervals(intervals []string) ([]string, error) {
 	}
 	return result, nil
 }
+
+func normalizeHoldingTrade(in holdingsAddTradeInput) (domain.HoldingTrade, error) {
+	if in.ChatID == 0 {
+		return domain.HoldingTrade{}, fmt.Errorf("chat_id is required")
+	}
+	symbol, err := normalizeSymbol(in.Symbol)
+	if err != nil {
+		return domain.HoldingTrade{}, err
+	}
+	side := domain.PaperSide(strings.ToLower(strings.TrimSpace(in.Side)))
+	if side != domain.PaperBuy && side != domain.PaperSell {
+		return domain.HoldingTrade{}, fmt.Errorf("side must be buy or sell")
+	}
+	trade := domain.HoldingTrade{
+		ChatID:   in.ChatID,
+		Symbol:   symbol,
+		Side:     side,
+		Quantity: in.Quantity,
+		Price:    in.Price,
+		Fee:      in.Fee,
+	}
+	if strings.TrimSpace(in.ExecutedAt) != "" {
+		at, err := time.Parse(time.RFC3339, strings.TrimSpace(in.ExecutedAt))
+		if err != nil {
+			return domain.HoldingTrade{}, fmt.Errorf("executed_at must be RFC3339")
+		}
+		trade.ExecutedAt = at
+	}
+	return trade, nil
+}
+
+func normalizeSizingRequest(in positionSizeInput) (domain.SizingRequest, error) {
+	symbol, err := normalizeSymbol(in.Symbol)
+	if err != nil {
+		return domain.SizingRequest{}, err
+	}
+	if in.Equity <= 0 {
+		return domain.SizingRequest{}, fmt.Errorf("equity must be positive")
+	}
+	if in.RiskPerTrade < 0 || in.RiskPerTrade >= 1 {
+		return domain.SizingRequest{}, fmt.Errorf("risk_per_trade must be between 0 and 1")
+	}
+	method := domain.SizingMethod(strings.ToLower(strings.TrimSpace(in.Method)))
+	if method != "" && !method.IsValid() {
+		return domain.SizingRequest{}, fmt.Errorf("method must be fixed_fractional, volatility_parity or kelly")
+	}
+	return domain.SizingRequest{Symbol: symbol, Equity: in.Equity, RiskPerTrade: in.RiskPerTrade, Method: method}, nil
+}
+
+func normalizeChartInput(in chartRenderInput) (domain.ChartRequest, error) {
+	symbol, err := normalizeSymbol(in.Symbol)
+	if err != nil {
+		return domain.ChartRequest{}, err
+	}
+	interval := strings.ToLower(strings.TrimSpace(in.Interval))
+	candles, ok := domain.ChartLookbackCandles(interval, in.Lookback)
+	if !ok {
+		return domain.ChartRequest{}, fmt.Errorf("lookback must be a candle count or a duration such as 7d")
+	}
+	return domain.ChartRequest{Symbol: symbol, Interval: interval, Candles: candles, Overlays: in.Overlays, Panes: in.Panes}, nil
+}
+
+func normalizePriceAlertInput(in priceAlertsCreateInput) (domain.PriceAlertRule, error) {
+	if in.ChatID == 0 {
+		return domain.PriceAlertRule{}, fmt.Errorf("chat_id is required")
+	}
+	symbol, err := normalizeSymbol(in.Symbol)
+	if err != nil {
+		return domain.PriceAlertRule{}, err
+	}
+	return domain.PriceAlertRule{
+		ChatID:     in.ChatID,
+		Symbol:     symbol,
+		Type:       domain.PriceAlertType(strings.ToLower(strings.TrimSpace(in.Type))),
+		Direction:  domain.PriceAlertDirection(strings.ToLower(strings.TrimSpace(in.Direction))),
+		Threshold:  in.Threshold,
+		WindowMins: in.WindowMins,
+		Recurring:  in.Recurring,
+	}, nil
+}
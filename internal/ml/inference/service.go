@@ -269,7 +269,7 @@ func (s *Service) persistModelPrediction(
 		return pred, false, nil
 	}
 	indicator := indicatorForModelKey(modelKey)
-	signalDetails := signalDetails(modelKey, modelVersion, probUp, confidence, ensembleScore, anomalyScore, dampFactor)
+	signalDetails := signalDetails(modelKey, modelVersion, s.cfg.TargetHours, probUp, confidence, ensembleScore, anomalyScore, dampFactor)
 	persistedSignals, err := s.signals.InsertSignals(ctx, []domain.Signal{{
 		Symbol:    row.Symbol,
 		Interval:  row.Interval,
@@ -438,22 +438,22 @@ func (s *Service) buildAnomalyDetailsJSON(interval string, version int, anomalyS
 	return string(b)
 }
 
-func signalDetails(modelKey string, version int, probUp, confidence, ensembleScore, anomalyScore, dampFactor float64) string {
+func signalDetails(modelKey string, version, targetHours int, probUp, confidence, ensembleScore, anomalyScore, dampFactor float64) string {
 	if modelKey == common.ModelKeyEnsembleV1 {
 		if anomalyScore > 0 {
 			return fmt.Sprintf(
-				"model_key=%s;model_version=%d;prob_up=%.4f;confidence=%.4f;target=4h;ensemble_score=%.4f;anomaly_score=%.4f;damp_factor=%.4f",
-				modelKey, version, probUp, confidence, ensembleScore, anomalyScore, dampFactor,
+				"model_key=%s;model_version=%d;prob_up=%.4f;confidence=%.4f;target=%dh;ensemble_score=%.4f;anomaly_score=%.4f;damp_factor=%.4f",
+				modelKey, version, probUp, confidence, targetHours, ensembleScore, anomalyScore, dampFactor,
 			)
 		}
 		return fmt.Sprintf(
-			"model_key=%s;model_version=%d;prob_up=%.4f;confidence=%.4f;target=4h;ensemble_score=%.4f",
-			modelKey, version, probUp, confidence, ensembleScore,
+			"model_key=%s;model_version=%d;prob_up=%.4f;confidence=%.4f;target=%dh;ensemble_score=%.4f",
+			modelKey, version, probUp, confidence, targetHours, ensembleScore,
 		)
 	}
 	return fmt.Sprintf(
-		"model_key=%s;model_version=%d;prob_up=%.4f;confidence=%.4f;target=4h",
-		modelKey, version, probUp, confidence,
+		"model_key=%s;model_version=%d;prob_up=%.4f;confidence=%.4f;target=%dh",
+		modelKey, version, probUp, confidence, targetHours,
 	)
 }
 
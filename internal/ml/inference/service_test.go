@@ -343,3 +343,10 @@ func anomalyDataset() [][]float64 {
 	}
 	return samples
 }
+
+func TestSignalDetailsRecordsTargetHorizon(t *testing.T) {
+	details := signalDetails(common.ModelKeyLogReg, 2, 6, 0.61, 0.22, 0, 0, 1)
+	if !strings.Contains(details, ";target=6h") {
+		t.Fatalf("expected the configured horizon in details, got %s", details)
+	}
+}
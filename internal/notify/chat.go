@@ -0,0 +1,42 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+// discordMaxContent is Discord's limit on a message's content.
+const discordMaxContent = 2000
+
+// Discord posts to a Discord incoming webhook.
+type Discord struct {
+	client *http.Client
+}
+
+func (d *Discord) Send(ctx context.Context, ch domain.NotificationChannel, n domain.Notification) error {
+	text := Text(n)
+	if r := []rune(text); len(r) > discordMaxContent {
+		text = string(r[:discordMaxContent-1]) + "…"
+	}
+	body, err := json.Marshal(map[string]string{"content": text})
+	if err != nil {
+		return err
+	}
+	return post(ctx, d.client, ch.Target, body, nil)
+}
+
+// Slack posts to a Slack incoming webhook.
+type Slack struct {
+	client *http.Client
+}
+
+func (s *Slack) Send(ctx context.Context, ch domain.NotificationChannel, n domain.Notification) error {
+	body, err := json.Marshal(map[string]string{"text": Text(n)})
+	if err != nil {
+		return err
+	}
+	return post(ctx, s.client, ch.Target, body, nil)
+}
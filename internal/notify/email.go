@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"bug-free-umbrella/internal/delivery"
+	"bug-free-umbrella/internal/domain"
+)
+
+// SMTPConfig is the mail server email channels send through.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// Email sends notifications over SMTP to the comma-separated addresses in a
+// channel's target.
+type Email struct {
+	cfg  SMTPConfig
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func NewEmail(cfg SMTPConfig) *Email {
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	return &Email{cfg: cfg, send: smtp.SendMail}
+}
+
+func (e *Email) Send(ctx context.Context, ch domain.NotificationChannel, n domain.Notification) error {
+	to := Recipients(ch.Target)
+	if len(to) == 0 {
+		return delivery.Permanent(errors.New("no recipients"))
+	}
+	var auth smtp.Auth
+	if e.cfg.Username != "" {
+		auth = smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, e.cfg.Host)
+	}
+	addr := net.JoinHostPort(e.cfg.Host, strconv.Itoa(e.cfg.Port))
+	err := e.send(addr, auth, e.cfg.From, to, e.message(to, n))
+	var tpErr *textproto.Error
+	if errors.As(err, &tpErr) && tpErr.Code >= 500 {
+		return delivery.Permanent(err)
+	}
+	return err
+}
+
+func (e *Email) message(to []string, n domain.Notification) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", e.cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", Subject(n)))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().UTC().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(Text(n), "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
+
+// Recipients splits an email channel's target into addresses.
+func Recipients(target string) []string {
+	var out []string
+	for _, addr := range strings.Split(target, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			out = append(out, addr)
+		}
+	}
+	return out
+}
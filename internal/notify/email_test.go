@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"bug-free-umbrella/internal/delivery"
+	"bug-free-umbrella/internal/domain"
+)
+
+func TestEmailSend(t *testing.T) {
+	e := NewEmail(SMTPConfig{Host: "smtp.example.com", Username: "u", Password: "p", From: "bot@example.com"})
+	var addr, from string
+	var to []string
+	var msg []byte
+	var auth smtp.Auth
+	e.send = func(a string, au smtp.Auth, f string, t []string, m []byte) error {
+		addr, auth, from, to, msg = a, au, f, t, m
+		return nil
+	}
+
+	ch := domain.NotificationChannel{Type: domain.ChannelEmail, Target: "a@example.com, b@example.com,"}
+	n := domain.Notification{Kind: domain.DeliveryKindSignal, Signals: []domain.Signal{{ID: 3, Symbol: "ETH", Interval: "4h", Indicator: "rsi", Direction: "long", Risk: 2}}}
+	if err := e.Send(context.Background(), ch, n); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if addr != "smtp.example.com:587" || auth == nil || from != "bot@example.com" || len(to) != 2 || to[1] != "b@example.com" {
+		t.Fatalf("unexpected envelope: %s %v %s %v", addr, auth, from, to)
+	}
+	body := string(msg)
+	if !strings.Contains(body, "Subject: Signal: ETH 4h RSI LONG\r\n") || !strings.Contains(body, "#3 ETH 4h RSI LONG risk 2") {
+		t.Fatalf("unexpected message: %q", body)
+	}
+
+	if err := e.Send(context.Background(), domain.NotificationChannel{Target: " "}, n); !delivery.IsPermanent(err) {
+		t.Fatalf("expected a permanent error without recipients, got %v", err)
+	}
+}
+
+func TestEmailErrors(t *testing.T) {
+	e := NewEmail(SMTPConfig{Host: "smtp.example.com", Port: 25, From: "bot@example.com"})
+	ch := domain.NotificationChannel{Target: "a@example.com"}
+
+	e.send = func(string, smtp.Auth, string, []string, []byte) error {
+		return &textproto.Error{Code: 550, Msg: "mailbox unavailable"}
+	}
+	if err := e.Send(context.Background(), ch, domain.Notification{Text: "x"}); !delivery.IsPermanent(err) {
+		t.Fatalf("expected 5xx to be permanent, got %v", err)
+	}
+	e.send = func(string, smtp.Auth, string, []string, []byte) error {
+		return &textproto.Error{Code: 421, Msg: "try later"}
+	}
+	if err := e.Send(context.Background(), ch, domain.Notification{Text: "x"}); err == nil || delivery.IsPermanent(err) {
+		t.Fatalf("expected 4xx to be retried, got %v", err)
+	}
+	e.send = func(string, smtp.Auth, string, []string, []byte) error { return errors.New("dial tcp: refused") }
+	if err := e.Send(context.Background(), ch, domain.Notification{Text: "x"}); err == nil || delivery.IsPermanent(err) {
+		t.Fatalf("expected connection errors to be retried, got %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+// Package notify sends signals and price alerts to channels outside
+// Telegram: Discord and Slack incoming webhooks, email over SMTP and generic
+// HTTP webhooks signed with HMAC-SHA256. Notifications are queued in an
+// outbox and sent by a delivery.Worker, which retries them and keeps the
+// delivery log.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bug-free-umbrella/internal/delivery"
+	"bug-free-umbrella/internal/domain"
+)
+
+// Notifier sends one notification to a channel. Errors wrapped with
+// delivery.Permanent or delivery.RetryAfter steer how the worker retries.
+type Notifier interface {
+	Send(ctx context.Context, ch domain.NotificationChannel, n domain.Notification) error
+}
+
+// Mux routes each notification to the Notifier for its channel's type.
+type Mux struct {
+	notifiers map[domain.NotificationChannelType]Notifier
+}
+
+// NewMux builds the notifiers for every channel type. Email is only
+// available when smtp has a host.
+func NewMux(client *http.Client, smtp SMTPConfig) *Mux {
+	if client == nil {
+		client = &http.Client{Timeout: 15 * time.Second}
+	}
+	m := &Mux{notifiers: map[domain.NotificationChannelType]Notifier{
+		domain.ChannelDiscord: &Discord{client: client},
+		domain.ChannelSlack:   &Slack{client: client},
+		domain.ChannelWebhook: &Webhook{client: client},
+	}}
+	if smtp.Host != "" {
+		m.notifiers[domain.ChannelEmail] = NewEmail(smtp)
+	}
+	return m
+}
+
+// Supports reports whether channels of type t can be sent to.
+func (m *Mux) Supports(t domain.NotificationChannelType) bool {
+	_, ok := m.notifiers[t]
+	return ok
+}
+
+func (m *Mux) Send(ctx context.Context, ch domain.NotificationChannel, n domain.Notification) error {
+	notifier, ok := m.notifiers[ch.Type]
+	if !ok {
+		return delivery.Permanent(fmt.Errorf("%s channels are not configured", ch.Type))
+	}
+	return notifier.Send(ctx, ch, n)
+}
+
+// ChannelLookup loads the channel a queued notification is for.
+type ChannelLookup interface {
+	GetChannel(ctx context.Context, id int64) (*domain.NotificationChannel, error)
+}
+
+// Sender is the delivery.Sender for the notification outbox. Queued rows
+// carry the channel ID in ChatID and the JSON notification in Text.
+type Sender struct {
+	channels ChannelLookup
+	notifier Notifier
+}
+
+func NewSender(channels ChannelLookup, notifier Notifier) *Sender {
+	return &Sender{channels: channels, notifier: notifier}
+}
+
+// Deliver sends one queued notification. Notifications for channels that
+// were deleted or disabled since they were queued are dropped.
+func (s *Sender) Deliver(ctx context.Context, d domain.AlertDelivery) error {
+	ch, err := s.channels.GetChannel(ctx, d.ChatID)
+	if err != nil {
+		return err
+	}
+	if ch == nil || !ch.Enabled {
+		return delivery.Permanent(fmt.Errorf("channel %d is gone or disabled", d.ChatID))
+	}
+	var n domain.Notification
+	if err := json.Unmarshal([]byte(d.Text), &n); err != nil {
+		return delivery.Permanent(fmt.Errorf("decode notification: %w", err))
+	}
+	n.ID = d.ID
+	return s.notifier.Send(ctx, *ch, n)
+}
+
+// post sends body to url and maps the response for the delivery worker:
+// 429 and 5xx are retried, other 4xx are permanent.
+func post(ctx context.Context, client *http.Client, url string, body []byte, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return delivery.Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+	err = fmt.Errorf("http %d: %s", resp.StatusCode, bytes.TrimSpace(snippet))
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return delivery.RetryAfter(err, retryAfter(resp.Header.Get("Retry-After")))
+	case resp.StatusCode >= 500:
+		return err
+	}
+	return delivery.Permanent(err)
+}
+
+// retryAfter reads a Retry-After header in seconds, defaulting to a minute.
+func retryAfter(v string) time.Duration {
+	secs, err := strconv.ParseFloat(v, 64)
+	if err != nil || secs <= 0 {
+		return time.Minute
+	}
+	return time.Duration(secs * float64(time.Second))
+}
@@ -0,0 +1,171 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/delivery"
+	"bug-free-umbrella/internal/domain"
+)
+
+// recordingServer captures the last request and answers with status.
+type recordingServer struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+func (s *recordingServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.header = r.Header.Clone()
+	s.body, _ = io.ReadAll(r.Body)
+	if s.status == http.StatusTooManyRequests {
+		w.Header().Set("Retry-After", "30")
+	}
+	w.WriteHeader(s.status)
+	_, _ = w.Write([]byte("nope"))
+}
+
+func TestPostMapsStatusCodes(t *testing.T) {
+	rec := &recordingServer{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+	ctx := context.Background()
+
+	rec.status = http.StatusNoContent
+	if err := post(ctx, srv.Client(), srv.URL, []byte(`{}`), nil); err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+
+	rec.status = http.StatusTooManyRequests
+	err := post(ctx, srv.Client(), srv.URL, []byte(`{}`), nil)
+	if after, ok := delivery.RetryAfterOf(err); !ok || after != 30*time.Second {
+		t.Fatalf("expected a 30s retry, got %v %v", after, err)
+	}
+
+	rec.status = http.StatusBadGateway
+	if err := post(ctx, srv.Client(), srv.URL, []byte(`{}`), nil); err == nil || delivery.IsPermanent(err) {
+		t.Fatalf("expected a retryable error, got %v", err)
+	}
+
+	rec.status = http.StatusNotFound
+	err = post(ctx, srv.Client(), srv.URL, []byte(`{}`), nil)
+	if !delivery.IsPermanent(err) || !strings.Contains(err.Error(), "http 404: nope") {
+		t.Fatalf("expected a permanent error, got %v", err)
+	}
+}
+
+func TestChatNotifiers(t *testing.T) {
+	rec := &recordingServer{status: http.StatusOK}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+	ch := domain.NotificationChannel{Target: srv.URL}
+	ctx := context.Background()
+
+	if err := (&Slack{client: srv.Client()}).Send(ctx, ch, domain.Notification{Text: "hello"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(rec.body) != `{"text":"hello"}` {
+		t.Fatalf("unexpected slack body: %s", rec.body)
+	}
+
+	long := strings.Repeat("x", 2500)
+	if err := (&Discord{client: srv.Client()}).Send(ctx, ch, domain.Notification{Text: long}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var msg map[string]string
+	if err := json.Unmarshal(rec.body, &msg); err != nil || len([]rune(msg["content"])) != discordMaxContent {
+		t.Fatalf("expected content truncated to %d runes, got %d (%v)", discordMaxContent, len([]rune(msg["content"])), err)
+	}
+}
+
+func TestWebhookSignsBody(t *testing.T) {
+	rec := &recordingServer{status: http.StatusOK}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	ch := domain.NotificationChannel{Target: srv.URL, Secret: "k"}
+	n := domain.Notification{ID: 42, Kind: domain.DeliveryKindSignal, Signals: []domain.Signal{{ID: 1, Symbol: "BTC"}}}
+	if err := (&Webhook{client: srv.Client()}).Send(context.Background(), ch, n); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := rec.header.Get(SignatureHeader); got != Sign("k", rec.body) || !strings.HasPrefix(got, "sha256=") {
+		t.Fatalf("unexpected signature %q", got)
+	}
+	if rec.header.Get(DeliveryHeader) != "42" || !strings.Contains(string(rec.body), `"symbol":"BTC"`) {
+		t.Fatalf("unexpected request: %v %s", rec.header, rec.body)
+	}
+	// Known vector: HMAC-SHA256("key", "The quick brown fox jumps over the lazy dog").
+	if got := Sign("key", []byte("The quick brown fox jumps over the lazy dog")); got != "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8" {
+		t.Fatalf("unexpected signature %q", got)
+	}
+
+	ch.Secret = ""
+	if err := (&Webhook{client: srv.Client()}).Send(context.Background(), ch, n); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec.header.Get(SignatureHeader) != "" {
+		t.Fatal("expected no signature without a secret")
+	}
+}
+
+type stubChannels map[int64]*domain.NotificationChannel
+
+func (s stubChannels) GetChannel(ctx context.Context, id int64) (*domain.NotificationChannel, error) {
+	return s[id], nil
+}
+
+type stubNotifier struct {
+	channel domain.NotificationChannel
+	sent    []domain.Notification
+}
+
+func (s *stubNotifier) Send(ctx context.Context, ch domain.NotificationChannel, n domain.Notification) error {
+	s.channel = ch
+	s.sent = append(s.sent, n)
+	return nil
+}
+
+func TestSenderDeliver(t *testing.T) {
+	notifier := &stubNotifier{}
+	s := NewSender(stubChannels{
+		1: {ID: 1, Type: domain.ChannelSlack, Enabled: true},
+		2: {ID: 2, Type: domain.ChannelSlack},
+	}, notifier)
+	ctx := context.Background()
+
+	if err := s.Deliver(ctx, domain.AlertDelivery{ID: 9, ChatID: 1, Text: `{"kind":"signal","text":"hi"}`}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(notifier.sent) != 1 || notifier.sent[0].ID != 9 || notifier.sent[0].Text != "hi" || notifier.channel.ID != 1 {
+		t.Fatalf("unexpected send: %+v to %+v", notifier.sent, notifier.channel)
+	}
+	for _, d := range []domain.AlertDelivery{
+		{ChatID: 2, Text: `{}`},
+		{ChatID: 3, Text: `{}`},
+		{ChatID: 1, Text: `not json`},
+	} {
+		if err := s.Deliver(ctx, d); !delivery.IsPermanent(err) {
+			t.Fatalf("expected a permanent error for %+v, got %v", d, err)
+		}
+	}
+}
+
+func TestMuxRoutesByType(t *testing.T) {
+	m := NewMux(nil, SMTPConfig{})
+	if !m.Supports(domain.ChannelSlack) || m.Supports(domain.ChannelEmail) {
+		t.Fatal("expected email unsupported without an SMTP host")
+	}
+	err := m.Send(context.Background(), domain.NotificationChannel{Type: domain.ChannelEmail}, domain.Notification{})
+	if !delivery.IsPermanent(err) {
+		t.Fatalf("expected a permanent error, got %v", err)
+	}
+	if !NewMux(nil, SMTPConfig{Host: "smtp.example.com"}).Supports(domain.ChannelEmail) {
+		t.Fatal("expected email supported with an SMTP host")
+	}
+}
@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/pricealert"
+)
+
+// Subject is a one-line summary of a notification, used as the email
+// subject.
+func Subject(n domain.Notification) string {
+	switch {
+	case len(n.Signals) == 1:
+		s := n.Signals[0]
+		return fmt.Sprintf("Signal: %s %s %s %s", s.Symbol, s.Interval, strings.ToUpper(s.Indicator), strings.ToUpper(string(s.Direction)))
+	case len(n.Signals) > 1:
+		return fmt.Sprintf("%d new signals", len(n.Signals))
+	case n.PriceAlert != nil:
+		return "Price alert: " + pricealert.Describe(n.PriceAlert.Rule)
+	}
+	return "Notification"
+}
+
+// Text renders a notification as plain text for chat and email channels.
+func Text(n domain.Notification) string {
+	if n.Text != "" {
+		return n.Text
+	}
+	if n.PriceAlert != nil {
+		return formatPriceAlert(*n.PriceAlert)
+	}
+	lines := make([]string, 0, len(n.Signals)+1)
+	lines = append(lines, "Signal alert:")
+	for _, s := range n.Signals {
+		lines = append(lines, formatSignal(s))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatSignal(s domain.Signal) string {
+	line := fmt.Sprintf("#%d %s %s %s %s risk %d at %s",
+		s.ID, s.Symbol, s.Interval, strings.ToUpper(s.Indicator), strings.ToUpper(string(s.Direction)),
+		s.Risk, s.Timestamp.UTC().Format(time.RFC822))
+	if l := s.Levels; l != nil {
+		targets := make([]string, 0, len(l.Targets))
+		for _, t := range l.Targets {
+			targets = append(targets, formatPrice(t))
+		}
+		line += fmt.Sprintf("\nEntry %s, stop %s, targets %s", formatPrice(l.Entry), formatPrice(l.Stop), strings.Join(targets, ", "))
+	}
+	return line
+}
+
+func formatPriceAlert(t domain.PriceAlertTrigger) string {
+	r := t.Rule
+	msg := fmt.Sprintf("Price alert #%d: %s\n", r.ID, pricealert.Describe(r))
+	switch r.Type {
+	case domain.PriceAlertCross:
+		return msg + fmt.Sprintf("%s is now %s", r.Symbol, formatPrice(t.Price))
+	case domain.PriceAlertVolume:
+		return msg + fmt.Sprintf("%s 24h volume up %.2f%% (price %s)", r.Symbol, t.Value, formatPrice(t.Price))
+	}
+	return msg + fmt.Sprintf("%s moved %.2f%% to %s", r.Symbol, t.Value, formatPrice(t.Price))
+}
+
+func formatPrice(v float64) string {
+	if v >= 1 {
+		return fmt.Sprintf("$%.2f", v)
+	}
+	return fmt.Sprintf("$%.4f", v)
+}
@@ -0,0 +1,39 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+func TestSubjectAndText(t *testing.T) {
+	signals := domain.Notification{Signals: []domain.Signal{
+		{ID: 1, Symbol: "BTC", Interval: "1h", Indicator: "macd", Direction: "short", Risk: 3,
+			Levels: &domain.SignalLevels{Entry: 100, Stop: 105, Targets: []float64{95, 90}}},
+		{ID: 2, Symbol: "SOL", Interval: "4h", Indicator: "rsi", Direction: "long", Risk: 2},
+	}}
+	if got := Subject(signals); got != "2 new signals" {
+		t.Fatalf("unexpected subject %q", got)
+	}
+	text := Text(signals)
+	if !strings.HasPrefix(text, "Signal alert:\n#1 BTC 1h MACD SHORT risk 3") ||
+		!strings.Contains(text, "Entry $100.00, stop $105.00, targets $95.00, $90.00") || !strings.Contains(text, "#2 SOL 4h RSI LONG") {
+		t.Fatalf("unexpected text %q", text)
+	}
+
+	alert := domain.Notification{PriceAlert: &domain.PriceAlertTrigger{
+		Rule:  domain.PriceAlertRule{ID: 4, Symbol: "DOGE", Type: domain.PriceAlertCross, Direction: domain.PriceAlertUp, Threshold: 0.2},
+		Price: 0.2012,
+	}}
+	if got := Subject(alert); got != "Price alert: DOGE crosses above $0.2 (once)" {
+		t.Fatalf("unexpected subject %q", got)
+	}
+	if got := Text(alert); got != "Price alert #4: DOGE crosses above $0.2 (once)\nDOGE is now $0.2012" {
+		t.Fatalf("unexpected text %q", got)
+	}
+
+	if got := Text(domain.Notification{Text: "Test notification"}); got != "Test notification" {
+		t.Fatalf("expected explicit text to win, got %q", got)
+	}
+}
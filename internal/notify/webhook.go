@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+const (
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the body,
+	// keyed with the channel's secret.
+	SignatureHeader = "X-Signature-256"
+	// DeliveryHeader carries the notification ID, unchanged across retries.
+	DeliveryHeader = "X-Delivery-ID"
+)
+
+// Webhook posts the notification as JSON to any HTTP endpoint. Receivers
+// verify SignatureHeader against the raw body.
+type Webhook struct {
+	client *http.Client
+}
+
+func (w *Webhook) Send(ctx context.Context, ch domain.NotificationChannel, n domain.Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	header := http.Header{}
+	header.Set(DeliveryHeader, strconv.FormatInt(n.ID, 10))
+	if ch.Secret != "" {
+		header.Set(SignatureHeader, Sign(ch.Secret, body))
+	}
+	return post(ctx, w.client, ch.Target, body, header)
+}
+
+// Sign returns the SignatureHeader value for body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,146 @@
+package pricealert
+
+import (
+	"sync"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+type sample struct {
+	at     time.Time
+	price  float64
+	volume float64
+}
+
+// History keeps the price feed for the longest alert window so move and
+// volume rules can look back. It lives in memory: after a restart, window
+// rules only see the samples taken since.
+type History struct {
+	mu      sync.Mutex
+	samples map[string][]sample
+}
+
+func NewHistory() *History {
+	return &History{samples: make(map[string][]sample)}
+}
+
+// Record appends one refresh of the price feed and drops samples older than
+// MaxWindow.
+func (h *History) Record(snaps []*domain.PriceSnapshot, at time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cutoff := at.Add(-MaxWindow)
+	for _, snap := range snaps {
+		if snap == nil || snap.PriceUSD <= 0 {
+			continue
+		}
+		series := append(h.samples[snap.Symbol], sample{at: at, price: snap.PriceUSD, volume: snap.Volume24h})
+		drop := 0
+		for drop < len(series) && series[drop].at.Before(cutoff) {
+			drop++
+		}
+		h.samples[snap.Symbol] = series[drop:]
+	}
+}
+
+// extremes returns the lowest and highest price and the lowest volume seen
+// for symbol since from.
+func (h *History) extremes(symbol string, from time.Time) (minPrice, maxPrice, minVolume float64, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, s := range h.samples[symbol] {
+		if s.at.Before(from) {
+			continue
+		}
+		if !ok {
+			minPrice, maxPrice, minVolume, ok = s.price, s.price, s.volume, true
+			continue
+		}
+		minPrice = min(minPrice, s.price)
+		maxPrice = max(maxPrice, s.price)
+		minVolume = min(minVolume, s.volume)
+	}
+	return minPrice, maxPrice, minVolume, ok
+}
+
+// Measure returns the value a rule compares with its threshold: the price for
+// cross rules, and for move and volume rules the largest percent change in
+// the rule's direction within its window. ok is false when there is nothing
+// to measure yet.
+func Measure(rule domain.PriceAlertRule, snap domain.PriceSnapshot, hist *History, now time.Time) (float64, bool) {
+	if snap.PriceUSD <= 0 {
+		return 0, false
+	}
+	if rule.Type == domain.PriceAlertCross {
+		return snap.PriceUSD, true
+	}
+	if hist == nil {
+		return 0, false
+	}
+	lo, hi, loVol, ok := hist.extremes(rule.Symbol, now.Add(-time.Duration(rule.WindowMins)*time.Minute))
+	if !ok {
+		return 0, false
+	}
+	if rule.Type == domain.PriceAlertVolume {
+		if loVol <= 0 {
+			return 0, false
+		}
+		return (snap.Volume24h/loVol - 1) * 100, true
+	}
+	up := (snap.PriceUSD/lo - 1) * 100
+	down := (1 - snap.PriceUSD/hi) * 100
+	switch rule.Direction {
+	case domain.PriceAlertUp:
+		return up, true
+	case domain.PriceAlertDown:
+		return down, true
+	}
+	return max(up, down), true
+}
+
+// Step advances a rule's state for one measurement. A disarmed rule re-arms
+// once the value is back past the threshold by the hysteresis; an armed rule
+// fires when the value reaches the threshold. It reports whether the rule
+// fired and whether its stored state changed.
+func Step(rule *domain.PriceAlertRule, value float64, now time.Time) (fired, changed bool) {
+	if !rule.Active {
+		return false, false
+	}
+	met, rearm := conditions(*rule, value)
+	if !rule.Armed {
+		if rearm {
+			rule.Armed = true
+			return false, true
+		}
+		return false, false
+	}
+	if !met {
+		return false, false
+	}
+	rule.Armed = false
+	rule.TriggerCount++
+	at := now.UTC()
+	rule.LastTriggeredAt = &at
+	if !rule.Recurring {
+		rule.Active = false
+	}
+	return true, true
+}
+
+// Met reports whether value already satisfies the rule, used to start new
+// cross rules disarmed when the price is past the line.
+func Met(rule domain.PriceAlertRule, value float64) bool {
+	met, _ := conditions(rule, value)
+	return met
+}
+
+func conditions(rule domain.PriceAlertRule, value float64) (met, rearm bool) {
+	t, h := rule.Threshold, rule.Hysteresis
+	if rule.Type == domain.PriceAlertCross && rule.Direction == domain.PriceAlertDown {
+		return value <= t, value > t*(1+h)
+	}
+	return value >= t, value < t*(1-h)
+}
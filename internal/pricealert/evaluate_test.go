@@ -0,0 +1,108 @@
+package pricealert
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+func snap(symbol string, price, volume float64) *domain.PriceSnapshot {
+	return &domain.PriceSnapshot{Symbol: symbol, PriceUSD: price, Volume24h: volume}
+}
+
+func TestCrossRuleFiresOnceUntilRearmed(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	rule := &domain.PriceAlertRule{Symbol: "BTC", Type: domain.PriceAlertCross, Direction: domain.PriceAlertUp, Threshold: 100, Hysteresis: 0.01, Recurring: true, Active: true, Armed: true}
+
+	if fired, _ := Step(rule, 99, now); fired {
+		t.Fatal("should not fire below threshold")
+	}
+	if fired, changed := Step(rule, 100, now); !fired || !changed || rule.Armed || rule.TriggerCount != 1 || rule.LastTriggeredAt == nil {
+		t.Fatalf("expected fire at threshold, got %+v", rule)
+	}
+	// Hovering at the line does not re-fire or re-arm.
+	if fired, changed := Step(rule, 99.5, now); fired || changed {
+		t.Fatal("should stay disarmed within hysteresis")
+	}
+	if fired, _ := Step(rule, 101, now); fired {
+		t.Fatal("should not fire while disarmed")
+	}
+	if _, changed := Step(rule, 98.9, now); !changed || !rule.Armed {
+		t.Fatal("expected re-arm past hysteresis")
+	}
+	if fired, _ := Step(rule, 102, now); !fired || rule.TriggerCount != 2 || !rule.Active {
+		t.Fatalf("expected second fire, got %+v", rule)
+	}
+}
+
+func TestOneShotRuleDeactivates(t *testing.T) {
+	now := time.Now()
+	rule := &domain.PriceAlertRule{Type: domain.PriceAlertCross, Direction: domain.PriceAlertDown, Threshold: 100, Hysteresis: 0.01, Active: true, Armed: true}
+	if fired, _ := Step(rule, 100.5, now); fired {
+		t.Fatal("should not fire above threshold")
+	}
+	if fired, _ := Step(rule, 95, now); !fired || rule.Active {
+		t.Fatalf("expected one-shot fire and deactivate, got %+v", rule)
+	}
+	if _, changed := Step(rule, 200, now); changed {
+		t.Fatal("inactive rule should not change")
+	}
+	if !Met(domain.PriceAlertRule{Type: domain.PriceAlertCross, Direction: domain.PriceAlertDown, Threshold: 100}, 90) {
+		t.Fatal("expected Met below a down threshold")
+	}
+}
+
+func TestMeasureMoveAndVolume(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	hist := NewHistory()
+	hist.Record([]*domain.PriceSnapshot{snap("ETH", 100, 1000)}, start.Add(-2*time.Hour))
+	hist.Record([]*domain.PriceSnapshot{snap("ETH", 110, 1000)}, start.Add(-30*time.Minute))
+	hist.Record([]*domain.PriceSnapshot{snap("ETH", 104.5, 1500), nil}, start)
+
+	now := *snap("ETH", 104.5, 1500)
+	move := domain.PriceAlertRule{Symbol: "ETH", Type: domain.PriceAlertMove, WindowMins: 60}
+
+	move.Direction = domain.PriceAlertDown
+	if v, ok := Measure(move, now, hist, start); !ok || math.Abs(v-5) > 1e-9 {
+		t.Fatalf("expected 5%% drop from the 1h high, got %v %v", v, ok)
+	}
+	move.Direction = domain.PriceAlertUp
+	if v, ok := Measure(move, now, hist, start); !ok || v != 0 {
+		t.Fatalf("expected no rise from the 1h low, got %v", v)
+	}
+	move.WindowMins = 180
+	if v, _ := Measure(move, now, hist, start); math.Abs(v-4.5) > 1e-9 {
+		t.Fatalf("expected 4.5%% rise over 3h, got %v", v)
+	}
+	move.Direction = domain.PriceAlertAny
+	move.WindowMins = 60
+	if v, _ := Measure(move, now, hist, start); math.Abs(v-5) > 1e-9 {
+		t.Fatalf("expected the larger move, got %v", v)
+	}
+
+	vol := domain.PriceAlertRule{Symbol: "ETH", Type: domain.PriceAlertVolume, Direction: domain.PriceAlertUp, WindowMins: 60}
+	if v, ok := Measure(vol, now, hist, start); !ok || math.Abs(v-50) > 1e-9 {
+		t.Fatalf("expected 50%% volume rise, got %v %v", v, ok)
+	}
+
+	move.Symbol = "SOL"
+	if _, ok := Measure(move, *snap("SOL", 10, 1), hist, start); ok {
+		t.Fatal("expected no measure without history")
+	}
+	cross := domain.PriceAlertRule{Symbol: "SOL", Type: domain.PriceAlertCross}
+	if v, ok := Measure(cross, *snap("SOL", 10, 1), nil, start); !ok || v != 10 {
+		t.Fatalf("cross rules measure the price, got %v", v)
+	}
+}
+
+func TestHistoryPrunesOldSamples(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	hist := NewHistory()
+	hist.Record([]*domain.PriceSnapshot{snap("BTC", 50, 1)}, start)
+	hist.Record([]*domain.PriceSnapshot{snap("BTC", 60, 1)}, start.Add(MaxWindow+time.Minute))
+	if got := len(hist.samples["BTC"]); got != 1 {
+		t.Fatalf("expected old sample pruned, got %d", got)
+	}
+}
@@ -0,0 +1,183 @@
+// Package pricealert evaluates users' price alert rules against the live
+// price feed.
+package pricealert
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+const (
+	// DefaultHysteresis re-arms a rule once its measure is 1% of the
+	// threshold back on the other side.
+	DefaultHysteresis = 0.01
+	// MaxHysteresis keeps a rule from needing an implausible retreat to
+	// re-arm.
+	MaxHysteresis = 0.5
+	MinWindow     = 5 * time.Minute
+	MaxWindow     = 24 * time.Hour
+)
+
+// Normalize fills defaults on a new rule and validates it.
+func Normalize(rule domain.PriceAlertRule) (domain.PriceAlertRule, error) {
+	rule.Symbol = strings.ToUpper(strings.TrimSpace(rule.Symbol))
+	if _, ok := domain.CoinGeckoID[rule.Symbol]; !ok {
+		return rule, fmt.Errorf("unsupported symbol %q", rule.Symbol)
+	}
+	if !(rule.Threshold > 0) || math.IsInf(rule.Threshold, 0) {
+		return rule, fmt.Errorf("threshold must be positive")
+	}
+	if rule.Hysteresis < 0 || rule.Hysteresis >= MaxHysteresis || math.IsNaN(rule.Hysteresis) {
+		return rule, fmt.Errorf("hysteresis must be between 0 and %.1f", MaxHysteresis)
+	}
+	if rule.Hysteresis == 0 {
+		rule.Hysteresis = DefaultHysteresis
+	}
+
+	switch rule.Type {
+	case domain.PriceAlertCross:
+		if rule.Direction != domain.PriceAlertUp && rule.Direction != domain.PriceAlertDown {
+			return rule, fmt.Errorf("cross rules need an up or down direction")
+		}
+		rule.WindowMins = 0
+		return rule, nil
+	case domain.PriceAlertMove:
+		if rule.Direction == "" {
+			rule.Direction = domain.PriceAlertAny
+		}
+		if rule.Direction != domain.PriceAlertUp && rule.Direction != domain.PriceAlertDown && rule.Direction != domain.PriceAlertAny {
+			return rule, fmt.Errorf("direction must be up, down or any")
+		}
+	case domain.PriceAlertVolume:
+		if rule.Direction != "" && rule.Direction != domain.PriceAlertUp {
+			return rule, fmt.Errorf("volume rules only watch for rises")
+		}
+		rule.Direction = domain.PriceAlertUp
+	default:
+		return rule, fmt.Errorf("type must be cross, move or volume")
+	}
+	window := time.Duration(rule.WindowMins) * time.Minute
+	if window < MinWindow || window > MaxWindow {
+		return rule, fmt.Errorf("window must be between %s and %s", MinWindow, MaxWindow)
+	}
+	return rule, nil
+}
+
+// ParseRule reads a rule from command words:
+//
+//	ETH above 4000 [once|recurring]
+//	SOL move 5% 1h [up|down|any] [once|recurring]
+//	BTC volume 50% 1h [once|recurring]
+//
+// Rules are one-shot unless "recurring" is given. The result still needs
+// Normalize.
+func ParseRule(args []string) (domain.PriceAlertRule, error) {
+	var rule domain.PriceAlertRule
+	if len(args) < 3 {
+		return rule, fmt.Errorf("expected symbol, condition and value")
+	}
+	rule.Symbol = strings.ToUpper(strings.TrimSpace(args[0]))
+	kind := strings.ToLower(strings.TrimSpace(args[1]))
+	value, err := parseNumber(args[2])
+	if err != nil {
+		return rule, err
+	}
+	rule.Threshold = value
+	rest := args[3:]
+
+	switch kind {
+	case "above", "below":
+		rule.Type = domain.PriceAlertCross
+		rule.Direction = domain.PriceAlertUp
+		if kind == "below" {
+			rule.Direction = domain.PriceAlertDown
+		}
+	case "move", "volume":
+		rule.Type = domain.PriceAlertMove
+		if kind == "volume" {
+			rule.Type = domain.PriceAlertVolume
+		}
+		if len(rest) == 0 {
+			return rule, fmt.Errorf("%s rules need a window such as 1h", kind)
+		}
+		window, err := time.ParseDuration(strings.ToLower(rest[0]))
+		if err != nil {
+			return rule, fmt.Errorf("invalid window %q", rest[0])
+		}
+		rule.WindowMins = int(window / time.Minute)
+		rest = rest[1:]
+	default:
+		return rule, fmt.Errorf("condition must be above, below, move or volume")
+	}
+
+	for _, word := range rest {
+		switch w := strings.ToLower(strings.TrimSpace(word)); w {
+		case "once":
+			rule.Recurring = false
+		case "recurring":
+			rule.Recurring = true
+		case "up", "down", "any":
+			if rule.Type == domain.PriceAlertCross {
+				return rule, fmt.Errorf("use above or below for price crosses")
+			}
+			rule.Direction = domain.PriceAlertDirection(w)
+		default:
+			return rule, fmt.Errorf("unexpected %q", word)
+		}
+	}
+	return rule, nil
+}
+
+func parseNumber(raw string) (float64, error) {
+	raw = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(raw), "$"), "%")
+	v, err := strconv.ParseFloat(strings.ReplaceAll(raw, ",", ""), 64)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("invalid value %q", raw)
+	}
+	return v, nil
+}
+
+// Describe renders a rule as a short sentence.
+func Describe(rule domain.PriceAlertRule) string {
+	mode := "once"
+	if rule.Recurring {
+		mode = "recurring"
+	}
+	window := FormatWindow(rule.WindowMins)
+	var what string
+	switch rule.Type {
+	case domain.PriceAlertCross:
+		side := "above"
+		if rule.Direction == domain.PriceAlertDown {
+			side = "below"
+		}
+		what = fmt.Sprintf("%s crosses %s $%s", rule.Symbol, side, strconv.FormatFloat(rule.Threshold, 'f', -1, 64))
+	case domain.PriceAlertMove:
+		dir := "moves"
+		switch rule.Direction {
+		case domain.PriceAlertUp:
+			dir = "rises"
+		case domain.PriceAlertDown:
+			dir = "falls"
+		}
+		what = fmt.Sprintf("%s %s %.4g%% within %s", rule.Symbol, dir, rule.Threshold, window)
+	case domain.PriceAlertVolume:
+		what = fmt.Sprintf("%s 24h volume up %.4g%% within %s", rule.Symbol, rule.Threshold, window)
+	default:
+		what = rule.Symbol + " " + string(rule.Type)
+	}
+	return what + " (" + mode + ")"
+}
+
+// FormatWindow renders a window in minutes the way users type it.
+func FormatWindow(mins int) string {
+	if mins > 0 && mins%60 == 0 {
+		return fmt.Sprintf("%dh", mins/60)
+	}
+	return fmt.Sprintf("%dm", mins)
+}
@@ -0,0 +1,94 @@
+package pricealert
+
+import (
+	"strings"
+	"testing"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+func TestParseRule(t *testing.T) {
+	rule, err := ParseRule([]string{"eth", "above", "$4,000"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rule.Symbol != "ETH" || rule.Type != domain.PriceAlertCross || rule.Direction != domain.PriceAlertUp || rule.Threshold != 4000 || rule.Recurring {
+		t.Fatalf("unexpected rule: %+v", rule)
+	}
+
+	rule, err = ParseRule([]string{"SOL", "move", "5%", "90m", "down", "recurring"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rule.Type != domain.PriceAlertMove || rule.Direction != domain.PriceAlertDown || rule.Threshold != 5 || rule.WindowMins != 90 || !rule.Recurring {
+		t.Fatalf("unexpected rule: %+v", rule)
+	}
+
+	rule, err = ParseRule([]string{"BTC", "volume", "50", "1h"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rule.Type != domain.PriceAlertVolume || rule.WindowMins != 60 {
+		t.Fatalf("unexpected rule: %+v", rule)
+	}
+
+	for _, args := range [][]string{
+		{"BTC", "above"},
+		{"BTC", "near", "100"},
+		{"BTC", "above", "-1"},
+		{"BTC", "move", "5"},
+		{"BTC", "move", "5", "soon"},
+		{"BTC", "above", "100", "down"},
+		{"BTC", "above", "100", "twice"},
+	} {
+		if _, err := ParseRule(args); err == nil {
+			t.Fatalf("expected %v to be rejected", args)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	rule, err := Normalize(domain.PriceAlertRule{Symbol: " btc ", Type: domain.PriceAlertCross, Direction: domain.PriceAlertDown, Threshold: 50000, WindowMins: 60})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rule.Symbol != "BTC" || rule.Hysteresis != DefaultHysteresis || rule.WindowMins != 0 {
+		t.Fatalf("unexpected rule: %+v", rule)
+	}
+
+	rule, err = Normalize(domain.PriceAlertRule{Symbol: "ETH", Type: domain.PriceAlertMove, Threshold: 3, WindowMins: 30, Hysteresis: 0.1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rule.Direction != domain.PriceAlertAny || rule.Hysteresis != 0.1 {
+		t.Fatalf("unexpected rule: %+v", rule)
+	}
+
+	for _, bad := range []domain.PriceAlertRule{
+		{Symbol: "NOPE", Type: domain.PriceAlertCross, Direction: domain.PriceAlertUp, Threshold: 1},
+		{Symbol: "BTC", Type: domain.PriceAlertCross, Direction: domain.PriceAlertAny, Threshold: 1},
+		{Symbol: "BTC", Type: domain.PriceAlertCross, Direction: domain.PriceAlertUp},
+		{Symbol: "BTC", Type: domain.PriceAlertMove, Threshold: 5, WindowMins: 1},
+		{Symbol: "BTC", Type: domain.PriceAlertMove, Threshold: 5, WindowMins: 60 * 48},
+		{Symbol: "BTC", Type: domain.PriceAlertVolume, Direction: domain.PriceAlertDown, Threshold: 5, WindowMins: 60},
+		{Symbol: "BTC", Type: domain.PriceAlertMove, Threshold: 5, WindowMins: 60, Hysteresis: 0.9},
+		{Symbol: "BTC", Type: "spread", Threshold: 5},
+	} {
+		if _, err := Normalize(bad); err == nil {
+			t.Fatalf("expected %+v to be rejected", bad)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	cases := map[string]domain.PriceAlertRule{
+		"ETH crosses above $4000 (once)":         {Symbol: "ETH", Type: domain.PriceAlertCross, Direction: domain.PriceAlertUp, Threshold: 4000},
+		"SOL falls 5% within 90m (recurring)":    {Symbol: "SOL", Type: domain.PriceAlertMove, Direction: domain.PriceAlertDown, Threshold: 5, WindowMins: 90, Recurring: true},
+		"BTC 24h volume up 50% within 1h (once)": {Symbol: "BTC", Type: domain.PriceAlertVolume, Direction: domain.PriceAlertUp, Threshold: 50, WindowMins: 60},
+	}
+	for want, rule := range cases {
+		if got := Describe(rule); !strings.EqualFold(got, want) {
+			t.Fatalf("Describe(%+v) = %q, want %q", rule, got, want)
+		}
+	}
+}
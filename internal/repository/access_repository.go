@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AccessRepository persists the Telegram chats allowed to use the bot and
+// the invite codes that admit them.
+type AccessRepository struct {
+	pool   PgxPool
+	tracer trace.Tracer
+}
+
+func NewAccessRepository(pool PgxPool, tracer trace.Tracer) *AccessRepository {
+	return &AccessRepository{pool: pool, tracer: tracer}
+}
+
+const (
+	telegramUserColumns   = `chat_id, username, role, invited_by, created_at`
+	telegramInviteColumns = `code, role, created_by, created_at, expires_at, used_by, used_at`
+)
+
+// GetTelegramUser returns a chat's access, or nil when it has none.
+func (r *AccessRepository) GetTelegramUser(ctx context.Context, chatID int64) (*domain.TelegramUser, error) {
+	_, span := r.tracer.Start(ctx, "access-repo.get-user")
+	defer span.End()
+
+	u, err := scanTelegramUser(r.pool.QueryRow(ctx,
+		`SELECT `+telegramUserColumns+` FROM telegram_users WHERE chat_id = $1`,
+		chatID,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// ListTelegramUsers returns every allowed chat, admins first.
+func (r *AccessRepository) ListTelegramUsers(ctx context.Context) ([]domain.TelegramUser, error) {
+	_, span := r.tracer.Start(ctx, "access-repo.list-users")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx, `SELECT `+telegramUserColumns+` FROM telegram_users
+		ORDER BY CASE role WHEN 'admin' THEN 0 WHEN 'trader' THEN 1 ELSE 2 END, chat_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]domain.TelegramUser, 0)
+	for rows.Next() {
+		u, err := scanTelegramUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+// SaveTelegramUser inserts a chat or updates its role and username. The
+// original inviter is kept.
+func (r *AccessRepository) SaveTelegramUser(ctx context.Context, u domain.TelegramUser) error {
+	_, span := r.tracer.Start(ctx, "access-repo.save-user")
+	defer span.End()
+
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO telegram_users (chat_id, username, role, invited_by)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (chat_id) DO UPDATE
+		 SET username = CASE WHEN EXCLUDED.username = '' THEN telegram_users.username ELSE EXCLUDED.username END,
+		     role = EXCLUDED.role, updated_at = NOW()`,
+		u.ChatID, u.Username, string(u.Role), u.InvitedBy,
+	)
+	return err
+}
+
+// DeleteTelegramUser revokes a chat's access. It reports whether it had any.
+func (r *AccessRepository) DeleteTelegramUser(ctx context.Context, chatID int64) (bool, error) {
+	_, span := r.tracer.Start(ctx, "access-repo.delete-user")
+	defer span.End()
+
+	tag, err := r.pool.Exec(ctx, `DELETE FROM telegram_users WHERE chat_id = $1`, chatID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// CreateInvite stores a new invite code.
+func (r *AccessRepository) CreateInvite(ctx context.Context, inv domain.TelegramInvite) error {
+	_, span := r.tracer.Start(ctx, "access-repo.create-invite")
+	defer span.End()
+
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO telegram_invites (code, role, created_by, expires_at) VALUES ($1, $2, $3, $4)`,
+		inv.Code, string(inv.Role), inv.CreatedBy, inv.ExpiresAt.UTC(),
+	)
+	return err
+}
+
+// RedeemInvite marks an unused, unexpired code as used by chatID and
+// returns it. It returns nil when the code cannot be redeemed. The check and
+// the update are one statement, so a code is only ever redeemed once.
+func (r *AccessRepository) RedeemInvite(ctx context.Context, code string, chatID int64, now time.Time) (*domain.TelegramInvite, error) {
+	_, span := r.tracer.Start(ctx, "access-repo.redeem-invite")
+	defer span.End()
+
+	inv, err := scanTelegramInvite(r.pool.QueryRow(ctx,
+		`UPDATE telegram_invites SET used_by = $2, used_at = $3
+		 WHERE code = $1 AND used_at IS NULL AND expires_at > $3
+		 RETURNING `+telegramInviteColumns,
+		code, chatID, now.UTC(),
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+func scanTelegramUser(row pgx.Row) (domain.TelegramUser, error) {
+	var u domain.TelegramUser
+	var role string
+	if err := row.Scan(&u.ChatID, &u.Username, &role, &u.InvitedBy, &u.CreatedAt); err != nil {
+		return u, err
+	}
+	u.Role = domain.UserRole(role)
+	u.CreatedAt = u.CreatedAt.UTC()
+	return u, nil
+}
+
+func scanTelegramInvite(row pgx.Row) (domain.TelegramInvite, error) {
+	var inv domain.TelegramInvite
+	var role string
+	if err := row.Scan(&inv.Code, &role, &inv.CreatedBy, &inv.CreatedAt, &inv.ExpiresAt, &inv.UsedBy, &inv.UsedAt); err != nil {
+		return inv, err
+	}
+	inv.Role = domain.UserRole(role)
+	inv.CreatedAt = inv.CreatedAt.UTC()
+	inv.ExpiresAt = inv.ExpiresAt.UTC()
+	return inv, nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestAccessListScansUsers(t *testing.T) {
+	at := time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)
+	pool := &runStubPool{rowsData: [][]any{
+		{int64(7), "alice", "admin", int64(0), at},
+		{int64(-1001), "", "viewer", int64(7), at},
+	}}
+	repo := NewAccessRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	users, err := repo.ListTelegramUsers(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(users) != 2 || users[0].Role != domain.RoleAdmin || users[0].Username != "alice" ||
+		users[1].ChatID != -1001 || users[1].InvitedBy != 7 {
+		t.Fatalf("unexpected users: %+v", users)
+	}
+}
+
+func TestAccessGetMissingUserReturnsNil(t *testing.T) {
+	pool := &runStubPool{rowErr: pgx.ErrNoRows}
+	repo := NewAccessRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	u, err := repo.GetTelegramUser(context.Background(), 7)
+	if err != nil || u != nil {
+		t.Fatalf("expected nil user, got %+v err=%v", u, err)
+	}
+}
+
+func TestAccessSaveAndDeleteUser(t *testing.T) {
+	pool := &runStubPool{execTag: pgconn.NewCommandTag("DELETE 1")}
+	repo := NewAccessRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	if err := repo.SaveTelegramUser(context.Background(), domain.TelegramUser{ChatID: 7, Role: domain.RoleTrader, InvitedBy: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(pool.execSQL, "ON CONFLICT (chat_id) DO UPDATE") || strings.Contains(pool.execSQL, "invited_by = EXCLUDED") ||
+		pool.execArgs[2] != "trader" {
+		t.Fatalf("unexpected save: %q %v", pool.execSQL, pool.execArgs)
+	}
+
+	deleted, err := repo.DeleteTelegramUser(context.Background(), 7)
+	if err != nil || !deleted {
+		t.Fatalf("expected delete, got %v err=%v", deleted, err)
+	}
+}
+
+func TestAccessRedeemInvite(t *testing.T) {
+	now := time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)
+	usedBy := int64(9)
+	pool := &runStubPool{row: []any{"abc123", "trader", int64(7), now.Add(-time.Hour), now.Add(time.Hour), usedBy, now}}
+	repo := NewAccessRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	inv, err := repo.RedeemInvite(context.Background(), "abc123", 9, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if inv == nil || inv.Role != domain.RoleTrader || inv.UsedBy == nil || *inv.UsedBy != 9 {
+		t.Fatalf("unexpected invite: %+v", inv)
+	}
+	if !strings.Contains(pool.rowSQL, "used_at IS NULL AND expires_at > $3") || pool.rowArgs[1] != int64(9) {
+		t.Fatalf("expected a guarded single-use update, got %q %v", pool.rowSQL, pool.rowArgs)
+	}
+
+	pool.rowErr = pgx.ErrNoRows
+	if inv, err := repo.RedeemInvite(context.Background(), "used", 9, now); err != nil || inv != nil {
+		t.Fatalf("expected nil for an unusable code, got %+v err=%v", inv, err)
+	}
+}
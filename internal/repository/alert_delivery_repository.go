@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AlertDeliveryRepository is the outbox for alert messages. Producers insert
+// rows; delivery workers claim due rows with a lease, so a crashed worker's
+// messages are picked up again once the lease runs out.
+type AlertDeliveryRepository struct {
+	pool   PgxPool
+	tracer trace.Tracer
+}
+
+func NewAlertDeliveryRepository(pool PgxPool, tracer trace.Tracer) *AlertDeliveryRepository {
+	return &AlertDeliveryRepository{pool: pool, tracer: tracer}
+}
+
+// Enqueue inserts pending messages in one batch and returns how many were
+// stored.
+func (r *AlertDeliveryRepository) Enqueue(ctx context.Context, deliveries []domain.AlertDelivery) (int, error) {
+	if len(deliveries) == 0 {
+		return 0, nil
+	}
+
+	_, span := r.tracer.Start(ctx, "alert-delivery-repo.enqueue")
+	defer span.End()
+
+	batch := &pgx.Batch{}
+	for _, d := range deliveries {
+		batch.Queue(
+			`INSERT INTO alert_deliveries (chat_id, kind, signal_id, text, image) VALUES ($1, $2, $3, $4, $5)`,
+			d.ChatID, string(d.Kind), d.SignalID, d.Text, d.Image,
+		)
+	}
+
+	br := r.pool.SendBatch(ctx, batch)
+	defer br.Close()
+
+	inserted := 0
+	for range deliveries {
+		tag, err := br.Exec()
+		if err != nil {
+			return inserted, err
+		}
+		inserted += int(tag.RowsAffected())
+	}
+	return inserted, nil
+}
+
+// Claim marks up to limit due messages as sending, counts the attempt and
+// holds them until now+lease. Rows another worker holds are skipped. The
+// result is ordered oldest first.
+func (r *AlertDeliveryRepository) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.AlertDelivery, error) {
+	_, span := r.tracer.Start(ctx, "alert-delivery-repo.claim")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx,
+		`UPDATE alert_deliveries d
+		 SET status = 'sending', attempts = d.attempts + 1, next_attempt_at = $2, updated_at = NOW()
+		 FROM (
+		     SELECT id FROM alert_deliveries
+		     WHERE status IN ('pending', 'sending') AND next_attempt_at <= $1
+		     ORDER BY next_attempt_at, id
+		     LIMIT $3
+		     FOR UPDATE SKIP LOCKED
+		 ) due
+		 WHERE d.id = due.id
+		 RETURNING d.id, d.chat_id, d.kind, d.signal_id, d.text, d.image, d.status, d.attempts,
+		           d.next_attempt_at, d.last_error, d.created_at`,
+		now.UTC(), now.Add(lease).UTC(), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]domain.AlertDelivery, 0)
+	for rows.Next() {
+		var d domain.AlertDelivery
+		var kind, status string
+		if err := rows.Scan(&d.ID, &d.ChatID, &kind, &d.SignalID, &d.Text, &d.Image, &status, &d.Attempts,
+			&d.NextAttemptAt, &d.LastError, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		d.Kind = domain.AlertDeliveryKind(kind)
+		d.Status = domain.AlertDeliveryStatus(status)
+		d.NextAttemptAt = d.NextAttemptAt.UTC()
+		d.CreatedAt = d.CreatedAt.UTC()
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+// MarkSent records a successful delivery.
+func (r *AlertDeliveryRepository) MarkSent(ctx context.Context, id int64, at time.Time) error {
+	_, span := r.tracer.Start(ctx, "alert-delivery-repo.mark-sent")
+	defer span.End()
+
+	_, err := r.pool.Exec(ctx,
+		`UPDATE alert_deliveries SET status = 'sent', sent_at = $2, last_error = '', updated_at = NOW() WHERE id = $1`,
+		id, at.UTC(),
+	)
+	return err
+}
+
+// MarkRetry puts a failed message back in the queue until next.
+func (r *AlertDeliveryRepository) MarkRetry(ctx context.Context, id int64, next time.Time, lastErr string) error {
+	_, span := r.tracer.Start(ctx, "alert-delivery-repo.mark-retry")
+	defer span.End()
+
+	_, err := r.pool.Exec(ctx,
+		`UPDATE alert_deliveries SET status = 'pending', next_attempt_at = $2, last_error = $3, updated_at = NOW() WHERE id = $1`,
+		id, next.UTC(), lastErr,
+	)
+	return err
+}
+
+// MarkDead moves a message to the dead letters. It stays in the table with
+// its last error for inspection.
+func (r *AlertDeliveryRepository) MarkDead(ctx context.Context, id int64, lastErr string) error {
+	_, span := r.tracer.Start(ctx, "alert-delivery-repo.mark-dead")
+	defer span.End()
+
+	_, err := r.pool.Exec(ctx,
+		`UPDATE alert_deliveries SET status = 'dead', last_error = $2, updated_at = NOW() WHERE id = $1`,
+		id, lastErr,
+	)
+	return err
+}
+
+// PurgeSent deletes messages delivered before cutoff and returns how many
+// were removed. Dead letters are kept.
+func (r *AlertDeliveryRepository) PurgeSent(ctx context.Context, cutoff time.Time) (int64, error) {
+	_, span := r.tracer.Start(ctx, "alert-delivery-repo.purge-sent")
+	defer span.End()
+
+	tag, err := r.pool.Exec(ctx,
+		`DELETE FROM alert_deliveries WHERE status = 'sent' AND sent_at < $1`,
+		cutoff.UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestAlertDeliveryEnqueueBatches(t *testing.T) {
+	pool := &runStubPool{}
+	repo := NewAlertDeliveryRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	if _, err := repo.Enqueue(context.Background(), nil); err != nil || pool.batchLen != 0 {
+		t.Fatalf("expected empty enqueue to skip the batch, got len=%d err=%v", pool.batchLen, err)
+	}
+	_, err := repo.Enqueue(context.Background(), []domain.AlertDelivery{
+		{ChatID: 1, Kind: domain.DeliveryKindSignal, SignalID: 5, Text: "a"},
+		{ChatID: 2, Kind: domain.DeliveryKindPriceAlert, Text: "b"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pool.batchLen != 2 {
+		t.Fatalf("expected 2 queued inserts, got %d", pool.batchLen)
+	}
+}
+
+func TestAlertDeliveryClaimOrdersByID(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	pool := &runStubPool{rowsData: [][]any{
+		{int64(9), int64(2), "digest", int64(0), "b", []byte{0x89, 0x50}, "sending", 1, now.Add(time.Minute), "", now},
+		{int64(4), int64(1), "signal", int64(5), "a", nil, "sending", 3, now.Add(time.Minute), "timeout", now},
+	}}
+	repo := NewAlertDeliveryRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	got, err := repo.Claim(context.Background(), now, time.Minute, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != 4 || got[1].ID != 9 {
+		t.Fatalf("expected claims ordered by id, got %+v", got)
+	}
+	if got[0].Image != nil || len(got[1].Image) != 2 || got[1].Kind != domain.DeliveryKindDigest {
+		t.Fatalf("expected images to be scanned, got %+v", got)
+	}
+	if got[0].Kind != domain.DeliveryKindSignal || got[0].Status != domain.DeliverySending || got[0].Attempts != 3 || got[0].LastError != "timeout" {
+		t.Fatalf("unexpected claim: %+v", got[0])
+	}
+	if pool.queryArgs[0] != now || pool.queryArgs[1] != now.Add(time.Minute) || pool.queryArgs[2] != 10 {
+		t.Fatalf("unexpected args: %v", pool.queryArgs)
+	}
+}
+
+func TestAlertDeliveryMarkers(t *testing.T) {
+	pool := &runStubPool{}
+	repo := NewAlertDeliveryRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+	at := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	if err := repo.MarkRetry(context.Background(), 3, at, "flood"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(pool.execSQL, "status = 'pending'") || pool.execArgs[1] != at || pool.execArgs[2] != "flood" {
+		t.Fatalf("unexpected retry update: %q %v", pool.execSQL, pool.execArgs)
+	}
+	if err := repo.MarkDead(context.Background(), 3, "blocked"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(pool.execSQL, "status = 'dead'") {
+		t.Fatalf("unexpected dead update: %q", pool.execSQL)
+	}
+	if err := repo.MarkSent(context.Background(), 3, at); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(pool.execSQL, "status = 'sent'") || pool.execArgs[1] != at {
+		t.Fatalf("unexpected sent update: %q %v", pool.execSQL, pool.execArgs)
+	}
+	if _, err := repo.PurgeSent(context.Background(), at); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(pool.execSQL, "DELETE FROM alert_deliveries WHERE status = 'sent'") {
+		t.Fatalf("unexpected purge: %q", pool.execSQL)
+	}
+}
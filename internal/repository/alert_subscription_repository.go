@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"bug-free-umbrella/internal/domain"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AlertSubscriptionRepository persists which chats receive proactive signal
+// alerts and their filters. Filter lists are stored comma-separated; an empty
+// string matches everything.
+type AlertSubscriptionRepository struct {
+	pool   PgxPool
+	tracer trace.Tracer
+}
+
+func NewAlertSubscriptionRepository(pool PgxPool, tracer trace.Tracer) *AlertSubscriptionRepository {
+	return &AlertSubscriptionRepository{pool: pool, tracer: tracer}
+}
+
+// ListSubscriptions returns every stored subscription, enabled or not.
+func (r *AlertSubscriptionRepository) ListSubscriptions(ctx context.Context) ([]domain.AlertSubscription, error) {
+	_, span := r.tracer.Start(ctx, "alert-subscription-repo.list")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT chat_id, enabled, symbols, intervals, indicators, min_risk, max_risk, direction,
+		        quiet_start, quiet_end, timezone, updated_at
+		 FROM alert_subscriptions
+		 ORDER BY chat_id`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]domain.AlertSubscription, 0)
+	for rows.Next() {
+		var sub domain.AlertSubscription
+		var symbols, intervals, indicators, direction string
+		var minRisk, maxRisk, quietStart, quietEnd int16
+		if err := rows.Scan(&sub.ChatID, &sub.Enabled, &symbols, &intervals, &indicators, &minRisk, &maxRisk,
+			&direction, &quietStart, &quietEnd, &sub.Timezone, &sub.UpdatedAt); err != nil {
+			return nil, err
+		}
+		sub.Symbols = splitList(symbols)
+		sub.Intervals = splitList(intervals)
+		sub.Indicators = splitList(indicators)
+		sub.MinRisk = domain.RiskLevel(minRisk)
+		sub.MaxRisk = domain.RiskLevel(maxRisk)
+		sub.Direction = domain.SignalDirection(direction)
+		sub.QuietStart = int(quietStart)
+		sub.QuietEnd = int(quietEnd)
+		sub.UpdatedAt = sub.UpdatedAt.UTC()
+		out = append(out, sub)
+	}
+	return out, rows.Err()
+}
+
+// SaveSubscription inserts or replaces a chat's subscription.
+func (r *AlertSubscriptionRepository) SaveSubscription(ctx context.Context, sub domain.AlertSubscription) error {
+	_, span := r.tracer.Start(ctx, "alert-subscription-repo.save")
+	defer span.End()
+
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO alert_subscriptions (chat_id, enabled, symbols, intervals, indicators, min_risk, max_risk,
+		                                  direction, quiet_start, quiet_end, timezone, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW())
+		 ON CONFLICT (chat_id) DO UPDATE
+		 SET enabled = EXCLUDED.enabled, symbols = EXCLUDED.symbols, intervals = EXCLUDED.intervals,
+		     indicators = EXCLUDED.indicators, min_risk = EXCLUDED.min_risk, max_risk = EXCLUDED.max_risk,
+		     direction = EXCLUDED.direction, quiet_start = EXCLUDED.quiet_start, quiet_end = EXCLUDED.quiet_end,
+		     timezone = EXCLUDED.timezone, updated_at = NOW()`,
+		sub.ChatID, sub.Enabled, strings.Join(sub.Symbols, ","), strings.Join(sub.Intervals, ","),
+		strings.Join(sub.Indicators, ","), int16(sub.MinRisk), int16(sub.MaxRisk), string(sub.Direction),
+		int16(sub.QuietStart), int16(sub.QuietEnd), sub.Timezone,
+	)
+	return err
+}
+
+func splitList(raw string) []string {
+	if raw == "" {
+		return nil
+	}
+	return strings.Split(raw, ",")
+}
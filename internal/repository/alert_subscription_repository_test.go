@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestAlertSubscriptionSaveJoinsLists(t *testing.T) {
+	pool := &runStubPool{}
+	repo := NewAlertSubscriptionRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	err := repo.SaveSubscription(context.Background(), domain.AlertSubscription{
+		ChatID: 7, Enabled: true, Symbols: []string{"BTC", "ETH"}, Indicators: []string{"rsi"},
+		MinRisk: domain.RiskLevel2, Direction: domain.DirectionLong, QuietStart: 1320, QuietEnd: 420, Timezone: "Europe/Berlin",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(pool.execSQL, "ON CONFLICT (chat_id) DO UPDATE") {
+		t.Fatalf("expected upsert, got %q", pool.execSQL)
+	}
+	if pool.execArgs[2] != "BTC,ETH" || pool.execArgs[3] != "" || pool.execArgs[5] != int16(2) || pool.execArgs[8] != int16(1320) {
+		t.Fatalf("unexpected args: %v", pool.execArgs)
+	}
+}
+
+func TestAlertSubscriptionListSplitsLists(t *testing.T) {
+	at := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	pool := &runStubPool{rowsData: [][]any{
+		{int64(7), true, "BTC,ETH", "", "rsi,macd", 2, 4, "short", 1320, 420, "Europe/Berlin", at},
+		{int64(9), false, "", "", "", 0, 0, "", 0, 0, "UTC", at},
+	}}
+	repo := NewAlertSubscriptionRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	subs, err := repo.ListSubscriptions(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(subs) != 2 {
+		t.Fatalf("expected 2 subscriptions, got %d", len(subs))
+	}
+	got := subs[0]
+	if len(got.Symbols) != 2 || got.Intervals != nil || len(got.Indicators) != 2 || got.MaxRisk != domain.RiskLevel4 ||
+		got.Direction != domain.DirectionShort || got.QuietStart != 1320 || got.Timezone != "Europe/Berlin" {
+		t.Fatalf("unexpected subscription: %+v", got)
+	}
+	if subs[1].Enabled || subs[1].Symbols != nil {
+		t.Fatalf("unexpected subscription: %+v", subs[1])
+	}
+}
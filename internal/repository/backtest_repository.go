@@ -2,10 +2,14 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"bug-free-umbrella/internal/domain"
 
+	"github.com/jackc/pgx/v5"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -118,6 +122,58 @@ func (r *BacktestRepository) ListRecentPredictions(ctx context.Context, limit in
 	}
 	defer rows.Close()
 
+	return scanMLPredictions(rows)
+}
+
+// ListResolvedPredictions returns resolved predictions matching q's filters,
+// newest target time first, at most limit rows.
+func (r *BacktestRepository) ListResolvedPredictions(ctx context.Context, q domain.MLAnalyticsQuery, limit int) ([]domain.MLPrediction, error) {
+	_, span := r.tracer.Start(ctx, "backtest-repo.list-resolved-predictions")
+	defer span.End()
+
+	where := []string{"resolved_at IS NOT NULL", "actual_up IS NOT NULL"}
+	args := make([]any, 0, 6)
+	add := func(cond string, v any) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if q.ModelKey != "" {
+		add("model_key = $%d", q.ModelKey)
+	}
+	if q.Symbol != "" {
+		add("symbol = $%d", q.Symbol)
+	}
+	if q.Interval != "" {
+		add("interval = $%d", q.Interval)
+	}
+	if !q.From.IsZero() {
+		add("target_time >= $%d", q.From)
+	}
+	if !q.To.IsZero() {
+		add("target_time <= $%d", q.To)
+	}
+	args = append(args, limit)
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, symbol, interval, open_time, target_time,
+		        model_key, model_version, prob_up, confidence,
+		        direction, risk, signal_id, details_json, created_at,
+		        resolved_at, actual_up, is_correct, realized_return
+		 FROM ml_predictions
+		 WHERE `+strings.Join(where, " AND ")+`
+		 ORDER BY target_time DESC, id DESC
+		 LIMIT $`+strconv.Itoa(len(args)),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanMLPredictions(rows)
+}
+
+func scanMLPredictions(rows pgx.Rows) ([]domain.MLPrediction, error) {
 	var out []domain.MLPrediction
 	for rows.Next() {
 		var p domain.MLPrediction
@@ -3,9 +3,12 @@ package repository
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"bug-free-umbrella/internal/domain"
+
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"go.opentelemetry.io/otel/trace"
@@ -80,10 +83,47 @@ func TestBacktestListRecentPredictionsDefaultLimit(t *testing.T) {
 	}
 }
 
+func TestBacktestListResolvedPredictionsFilters(t *testing.T) {
+	target := time.Date(2026, 2, 13, 4, 0, 0, 0, time.UTC)
+	pool := &btStubPool{
+		rowsData: [][]any{
+			{int64(7), "BTC", "1h", target.Add(-4 * time.Hour), target,
+				"ml_xgboost_up4h", 3, 0.71, 0.42,
+				"long", 3, nil, "{}", target.Add(-4 * time.Hour),
+				target, true, true, 0.012},
+		},
+	}
+	repo := NewBacktestRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	from := target.AddDate(0, 0, -30)
+	results, err := repo.ListResolvedPredictions(context.Background(), domain.MLAnalyticsQuery{
+		ModelKey: "ml_xgboost_up4h", Symbol: "BTC", From: from,
+	}, 500)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Direction != domain.DirectionLong || results[0].Risk != domain.RiskLevel3 || *results[0].RealizedReturn != 0.012 {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	for _, want := range []string{"model_key = $1", "symbol = $2", "target_time >= $3", "LIMIT $4"} {
+		if !strings.Contains(pool.querySQL, want) {
+			t.Fatalf("expected %q in query:\n%s", want, pool.querySQL)
+		}
+	}
+	if strings.Contains(pool.querySQL, "interval =") {
+		t.Fatalf("expected no interval filter:\n%s", pool.querySQL)
+	}
+	if len(pool.queryArgs) != 4 || pool.queryArgs[3] != 500 {
+		t.Fatalf("unexpected args: %+v", pool.queryArgs)
+	}
+}
+
 // --- stubs ---
 
 type btStubPool struct {
-	rowsData [][]any
+	rowsData  [][]any
+	querySQL  string
+	queryArgs []any
 }
 
 func (s *btStubPool) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
@@ -95,6 +135,8 @@ func (s *btStubPool) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResul
 }
 
 func (s *btStubPool) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	s.querySQL = sql
+	s.queryArgs = args
 	if s.rowsData == nil {
 		return &btStubRows{}, nil
 	}
@@ -176,6 +218,13 @@ func (r *btStubRows) Scan(dest ...any) error {
 				v := row[i].(float64)
 				*ptr = &v
 			}
+		case **string:
+			if row[i] == nil {
+				*ptr = nil
+			} else {
+				v := row[i].(string)
+				*ptr = &v
+			}
 		case **time.Time:
 			if row[i] == nil {
 				*ptr = nil
@@ -185,6 +234,12 @@ func (r *btStubRows) Scan(dest ...any) error {
 			}
 		case *time.Time:
 			*ptr = row[i].(time.Time)
+		case *[]byte:
+			if row[i] == nil {
+				*ptr = nil
+			} else {
+				*ptr = row[i].([]byte)
+			}
 		default:
 			return fmt.Errorf("unsupported dest type %T", d)
 		}
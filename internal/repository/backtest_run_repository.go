@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"bug-free-umbrella/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BacktestRunRepository persists strategy backtest runs and their trades so
+// any run can be reproduced from its stored parameters and engine version.
+type BacktestRunRepository struct {
+	pool   PgxTxPool
+	tracer trace.Tracer
+}
+
+func NewBacktestRunRepository(pool PgxTxPool, tracer trace.Tracer) *BacktestRunRepository {
+	return &BacktestRunRepository{pool: pool, tracer: tracer}
+}
+
+const backtestRunColumns = `id, engine_version, params_json,
+	        trades, wins, losses, win_rate, profit_factor, total_return, avg_trade_return,
+	        sharpe, sortino, max_drawdown, final_equity, created_at`
+
+// InsertRun stores a run and its trades in one transaction, so a run is
+// never left without the trades it reports.
+func (r *BacktestRunRepository) InsertRun(ctx context.Context, run domain.BacktestRun) (int64, error) {
+	_, span := r.tracer.Start(ctx, "backtest-run-repo.insert-run")
+	defer span.End()
+
+	params, err := json.Marshal(run.Config)
+	if err != nil {
+		return 0, fmt.Errorf("encode backtest params: %w", err)
+	}
+	equity := run.Equity
+	if equity == nil {
+		equity = []domain.EquityPoint{}
+	}
+	equityJSON, err := json.Marshal(equity)
+	if err != nil {
+		return 0, fmt.Errorf("encode equity curve: %w", err)
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	m := run.Metrics
+	var id int64
+	err = tx.QueryRow(ctx,
+		`INSERT INTO backtest_runs (
+		     symbol, interval, window_from, window_to, engine_version, params_json,
+		     trades, wins, losses, win_rate, profit_factor, total_return, avg_trade_return,
+		     sharpe, sortino, max_drawdown, final_equity, equity_json)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		 RETURNING id`,
+		run.Config.Symbol, run.Config.Interval, run.Config.From.UTC(), run.Config.To.UTC(),
+		run.EngineVersion, string(params),
+		m.Trades, m.Wins, m.Losses, m.WinRate, m.ProfitFactor, m.TotalReturn, m.AvgTradeReturn,
+		m.Sharpe, m.Sortino, m.MaxDrawdown, m.FinalEquity, string(equityJSON),
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	if len(run.Trades) == 0 {
+		return id, tx.Commit(ctx)
+	}
+
+	batch := &pgx.Batch{}
+	for i, t := range run.Trades {
+		batch.Queue(
+			`INSERT INTO backtest_trades (
+			     run_id, seq, indicator, direction, risk, entry_time, exit_time,
+			     entry_price, exit_price, stop_price, target_price, exit_reason,
+			     bars, return_pct, pnl, fees)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`,
+			id, i, t.Indicator, string(t.Direction), int16(t.Risk), t.EntryTime.UTC(), t.ExitTime.UTC(),
+			t.EntryPrice, t.ExitPrice, positiveOrNil(t.Stop), positiveOrNil(t.Target), t.ExitReason,
+			t.Bars, t.ReturnPct, t.PnL, t.Fees,
+		)
+	}
+	br := tx.SendBatch(ctx, batch)
+	for range run.Trades {
+		if _, err := br.Exec(); err != nil {
+			br.Close()
+			return 0, err
+		}
+	}
+	if err := br.Close(); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// ListRuns returns the most recent runs without their equity curves or trades.
+func (r *BacktestRunRepository) ListRuns(ctx context.Context, limit int) ([]domain.BacktestRun, error) {
+	_, span := r.tracer.Start(ctx, "backtest-run-repo.list-runs")
+	defer span.End()
+
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 200 {
+		limit = 200
+	}
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+backtestRunColumns+`
+		 FROM backtest_runs
+		 ORDER BY created_at DESC, id DESC
+		 LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]domain.BacktestRun, 0)
+	for rows.Next() {
+		run, err := scanBacktestRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, run)
+	}
+	return out, rows.Err()
+}
+
+// GetRun loads one run with its equity curve and trades. It returns nil when
+// the run does not exist.
+func (r *BacktestRunRepository) GetRun(ctx context.Context, id int64) (*domain.BacktestRun, error) {
+	_, span := r.tracer.Start(ctx, "backtest-run-repo.get-run")
+	defer span.End()
+
+	var equityJSON string
+	run, err := scanBacktestRun(r.pool.QueryRow(ctx,
+		`SELECT `+backtestRunColumns+`, equity_json
+		 FROM backtest_runs
+		 WHERE id = $1`,
+		id,
+	), &equityJSON)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(equityJSON), &run.Equity); err != nil {
+		return nil, fmt.Errorf("decode equity curve for run %d: %w", id, err)
+	}
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT indicator, direction, risk, entry_time, exit_time,
+		        entry_price, exit_price, COALESCE(stop_price, 0), COALESCE(target_price, 0),
+		        exit_reason, bars, return_pct, pnl, fees
+		 FROM backtest_trades
+		 WHERE run_id = $1
+		 ORDER BY seq`,
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	run.Trades = make([]domain.BacktestTrade, 0)
+	for rows.Next() {
+		var t domain.BacktestTrade
+		var direction string
+		var risk int16
+		if err := rows.Scan(
+			&t.Indicator, &direction, &risk, &t.EntryTime, &t.ExitTime,
+			&t.EntryPrice, &t.ExitPrice, &t.Stop, &t.Target,
+			&t.ExitReason, &t.Bars, &t.ReturnPct, &t.PnL, &t.Fees,
+		); err != nil {
+			return nil, err
+		}
+		t.Direction = domain.SignalDirection(direction)
+		t.Risk = domain.RiskLevel(risk)
+		t.EntryTime, t.ExitTime = t.EntryTime.UTC(), t.ExitTime.UTC()
+		run.Trades = append(run.Trades, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func scanBacktestRun(row pgx.Row, extra ...any) (domain.BacktestRun, error) {
+	var run domain.BacktestRun
+	var params string
+	m := &run.Metrics
+	dest := append([]any{
+		&run.ID, &run.EngineVersion, &params,
+		&m.Trades, &m.Wins, &m.Losses, &m.WinRate, &m.ProfitFactor, &m.TotalReturn, &m.AvgTradeReturn,
+		&m.Sharpe, &m.Sortino, &m.MaxDrawdown, &m.FinalEquity, &run.CreatedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return run, err
+	}
+	if err := json.Unmarshal([]byte(params), &run.Config); err != nil {
+		return run, fmt.Errorf("decode params for run %d: %w", run.ID, err)
+	}
+	run.CreatedAt = run.CreatedAt.UTC()
+	return run, nil
+}
+
+func positiveOrNil(v float64) *float64 {
+	if v <= 0 {
+		return nil
+	}
+	return &v
+}
+
+// SaveMonteCarlo stores the robustness analysis of a run, replacing any
+// earlier one.
+func (r *BacktestRunRepository) SaveMonteCarlo(ctx context.Context, res domain.MonteCarloResult) error {
+	_, span := r.tracer.Start(ctx, "backtest-run-repo.save-monte-carlo")
+	defer span.End()
+
+	cfg, err := json.Marshal(res.Config)
+	if err != nil {
+		return fmt.Errorf("encode monte carlo config: %w", err)
+	}
+	body, err := json.Marshal(res)
+	if err != nil {
+		return fmt.Errorf("encode monte carlo result: %w", err)
+	}
+	_, err = r.pool.Exec(ctx,
+		`INSERT INTO backtest_monte_carlo (run_id, config_json, result_json, created_at)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (run_id) DO UPDATE
+		 SET config_json = EXCLUDED.config_json,
+		     result_json = EXCLUDED.result_json,
+		     created_at = EXCLUDED.created_at`,
+		res.RunID, string(cfg), string(body), res.CreatedAt.UTC(),
+	)
+	return err
+}
+
+// GetMonteCarlo returns the stored analysis of a run, or nil when none has
+// been run.
+func (r *BacktestRunRepository) GetMonteCarlo(ctx context.Context, runID int64) (*domain.MonteCarloResult, error) {
+	_, span := r.tracer.Start(ctx, "backtest-run-repo.get-monte-carlo")
+	defer span.End()
+
+	var body string
+	err := r.pool.QueryRow(ctx,
+		`SELECT result_json FROM backtest_monte_carlo WHERE run_id = $1`,
+		runID,
+	).Scan(&body)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var res domain.MonteCarloResult
+	if err := json.Unmarshal([]byte(body), &res); err != nil {
+		return nil, fmt.Errorf("decode monte carlo result for run %d: %w", runID, err)
+	}
+	return &res, nil
+}
@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestBacktestRunInsertRunWritesRunAndTrades(t *testing.T) {
+	pool := &runStubPool{row: []any{int64(42)}}
+	repo := NewBacktestRunRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	id, err := repo.InsertRun(context.Background(), domain.BacktestRun{
+		EngineVersion: "engine/1",
+		Config:        domain.BacktestConfig{Symbol: "BTC", Interval: "1h", From: from, To: from.Add(48 * time.Hour), HoldBars: 24},
+		Metrics:       domain.BacktestMetrics{Trades: 2, WinRate: 0.5},
+		Equity:        []domain.EquityPoint{{Time: from, Equity: 10000}},
+		Trades: []domain.BacktestTrade{
+			{Indicator: "rsi", Direction: domain.DirectionLong, Risk: domain.RiskLevel2, EntryPrice: 100, ExitPrice: 102, Stop: 98},
+			{Indicator: "macd", Direction: domain.DirectionShort, Risk: domain.RiskLevel3, EntryPrice: 102, ExitPrice: 103},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != 42 {
+		t.Fatalf("expected id 42, got %d", id)
+	}
+	if !strings.Contains(pool.rowSQL, "INSERT INTO backtest_runs") {
+		t.Fatalf("expected run insert, got %q", pool.rowSQL)
+	}
+	if params := pool.rowArgs[5].(string); !strings.Contains(params, `"hold_bars":24`) {
+		t.Fatalf("expected params json, got %s", params)
+	}
+	if pool.batchLen != 2 {
+		t.Fatalf("expected 2 trade inserts, got %d", pool.batchLen)
+	}
+	if !pool.tx.committed {
+		t.Fatal("expected the run and trades committed together")
+	}
+}
+
+func TestBacktestRunInsertRunRollsBackWhenTradesFail(t *testing.T) {
+	pool := &runStubPool{row: []any{int64(42)}, batchErr: errors.New("insert trade failed")}
+	repo := NewBacktestRunRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	id, err := repo.InsertRun(context.Background(), domain.BacktestRun{
+		Config: domain.BacktestConfig{Symbol: "BTC"},
+		Trades: []domain.BacktestTrade{{Indicator: "rsi", Direction: domain.DirectionLong}},
+	})
+	if err == nil || id != 0 {
+		t.Fatalf("expected the failure and no id, got %d, %v", id, err)
+	}
+	if pool.tx.committed || !pool.tx.rolledBack {
+		t.Fatalf("expected the run rolled back, got %+v", pool.tx)
+	}
+}
+
+func TestBacktestRunInsertRunWithoutTradesSkipsBatch(t *testing.T) {
+	pool := &runStubPool{row: []any{int64(7)}}
+	repo := NewBacktestRunRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	if _, err := repo.InsertRun(context.Background(), domain.BacktestRun{Config: domain.BacktestConfig{Symbol: "ETH"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pool.batchLen != 0 {
+		t.Fatalf("expected no batch, got %d statements", pool.batchLen)
+	}
+	if !pool.tx.committed {
+		t.Fatal("expected the run committed")
+	}
+}
+
+func TestBacktestRunListRunsDecodesParams(t *testing.T) {
+	created := time.Date(2026, 2, 1, 12, 0, 0, 0, time.UTC)
+	pool := &runStubPool{rowsData: [][]any{
+		{int64(3), "engine/1", `{"symbol":"BTC","interval":"4h","hold_bars":12}`,
+			10, 6, 4, 0.6, 1.8, 0.12, 0.012, 1.1, 1.6, 0.08, 11200.0, created},
+	}}
+	repo := NewBacktestRunRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	runs, err := repo.ListRuns(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(runs) != 1 {
+		t.Fatalf("expected 1 run, got %d", len(runs))
+	}
+	r := runs[0]
+	if r.ID != 3 || r.Config.Symbol != "BTC" || r.Config.HoldBars != 12 || r.Metrics.Trades != 10 || r.Metrics.FinalEquity != 11200 {
+		t.Fatalf("unexpected run: %+v", r)
+	}
+	if pool.queryArgs[0].(int) != 50 {
+		t.Fatalf("expected default limit 50, got %v", pool.queryArgs[0])
+	}
+}
+
+func TestBacktestRunGetRunNotFound(t *testing.T) {
+	pool := &runStubPool{rowErr: pgx.ErrNoRows}
+	repo := NewBacktestRunRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	run, err := repo.GetRun(context.Background(), 99)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if run != nil {
+		t.Fatalf("expected nil run, got %+v", run)
+	}
+}
+
+func TestBacktestRunGetRunLoadsEquityAndTrades(t *testing.T) {
+	created := time.Date(2026, 2, 1, 12, 0, 0, 0, time.UTC)
+	entry := created.Add(-24 * time.Hour)
+	pool := &runStubPool{
+		row: []any{int64(5), "engine/1", `{"symbol":"SOL","interval":"1h"}`,
+			1, 1, 0, 1.0, 100.0, 0.02, 0.02, 0.0, 0.0, 0.0, 10200.0, created,
+			`[{"time":"2026-01-31T12:00:00Z","equity":10000},{"time":"2026-02-01T12:00:00Z","equity":10200}]`},
+		rowsData: [][]any{
+			{"rsi", "long", 2, entry, created, 100.0, 102.0, 98.0, 102.0, "target", 24, 0.02, 200.0, 20.0},
+		},
+	}
+	repo := NewBacktestRunRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	run, err := repo.GetRun(context.Background(), 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if run == nil || run.Config.Symbol != "SOL" {
+		t.Fatalf("unexpected run: %+v", run)
+	}
+	if len(run.Equity) != 2 || run.Equity[1].Equity != 10200 {
+		t.Fatalf("unexpected equity: %+v", run.Equity)
+	}
+	if len(run.Trades) != 1 || run.Trades[0].Direction != domain.DirectionLong || run.Trades[0].Risk != domain.RiskLevel2 || run.Trades[0].Stop != 98 {
+		t.Fatalf("unexpected trades: %+v", run.Trades)
+	}
+}
+
+func TestBacktestRunSaveMonteCarloUpserts(t *testing.T) {
+	pool := &runStubPool{}
+	repo := NewBacktestRunRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	err := repo.SaveMonteCarlo(context.Background(), domain.MonteCarloResult{
+		RunID:  5,
+		Config: domain.MonteCarloConfig{Simulations: 500, Method: domain.MonteCarloShuffle},
+		Fan:    []domain.PercentileBand{{}, {P50: 0.01}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(pool.execSQL, "ON CONFLICT (run_id) DO UPDATE") || pool.execArgs[0].(int64) != 5 {
+		t.Fatalf("expected upsert for run 5, got %q %v", pool.execSQL, pool.execArgs)
+	}
+	if cfg := pool.execArgs[1].(string); !strings.Contains(cfg, `"method":"shuffle"`) {
+		t.Fatalf("expected config json, got %s", cfg)
+	}
+}
+
+func TestBacktestRunGetMonteCarlo(t *testing.T) {
+	pool := &runStubPool{row: []any{`{"run_id":5,"trades":12,"final_return":{"p50":0.08},"fan":[{"p50":0},{"p50":0.08}]}`}}
+	repo := NewBacktestRunRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	res, err := repo.GetMonteCarlo(context.Background(), 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res == nil || res.Trades != 12 || res.FinalReturn.P50 != 0.08 || len(res.Fan) != 2 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	missing := NewBacktestRunRepository(&runStubPool{rowErr: pgx.ErrNoRows}, trace.NewNoopTracerProvider().Tracer("test"))
+	if res, err := missing.GetMonteCarlo(context.Background(), 9); err != nil || res != nil {
+		t.Fatalf("expected nil, nil; got %+v, %v", res, err)
+	}
+}
+
+// --- stubs ---
+
+type runStubPool struct {
+	execSQL   string
+	execArgs  []any
+	execTag   pgconn.CommandTag
+	row       []any
+	rowQueue  [][]any
+	rowErr    error
+	rowSQL    string
+	rowArgs   []any
+	rowsData  [][]any
+	queryArgs []any
+	batchLen  int
+	batchErr  error
+	tx        *runStubTx
+}
+
+func (s *runStubPool) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	s.execSQL = sql
+	s.execArgs = args
+	return s.execTag, nil
+}
+
+func (s *runStubPool) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	s.batchLen = b.Len()
+	return &runStubBatchResults{err: s.batchErr}
+}
+
+type runStubBatchResults struct {
+	btStubBatchResults
+	err error
+}
+
+func (r *runStubBatchResults) Exec() (pgconn.CommandTag, error) { return pgconn.CommandTag{}, r.err }
+
+func (s *runStubPool) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	s.queryArgs = args
+	return &btStubRows{data: s.rowsData}, nil
+}
+
+func (s *runStubPool) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	s.rowSQL = sql
+	s.rowArgs = args
+	if len(s.rowQueue) > 0 {
+		row := s.rowQueue[0]
+		s.rowQueue = s.rowQueue[1:]
+		return &runStubRow{values: row}
+	}
+	return &runStubRow{values: s.row, err: s.rowErr}
+}
+
+func (s *runStubPool) Begin(ctx context.Context) (pgx.Tx, error) {
+	s.tx = &runStubTx{pool: s}
+	return s.tx, nil
+}
+
+// runStubTx runs statements against its pool and records each one and how
+// the transaction ended.
+type runStubTx struct {
+	pgx.Tx
+	pool       *runStubPool
+	sqls       []string
+	committed  bool
+	rolledBack bool
+}
+
+func (t *runStubTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	t.sqls = append(t.sqls, sql)
+	return t.pool.Exec(ctx, sql, args...)
+}
+
+func (t *runStubTx) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return t.pool.SendBatch(ctx, b)
+}
+
+func (t *runStubTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	t.sqls = append(t.sqls, sql)
+	return t.pool.QueryRow(ctx, sql, args...)
+}
+
+func (t *runStubTx) Commit(ctx context.Context) error {
+	t.committed = true
+	return nil
+}
+
+func (t *runStubTx) Rollback(ctx context.Context) error {
+	if !t.committed {
+		t.rolledBack = true
+	}
+	return nil
+}
+
+type runStubRow struct {
+	values []any
+	err    error
+}
+
+func (r *runStubRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	rows := &btStubRows{data: [][]any{r.values}}
+	rows.Next()
+	return rows.Scan(dest...)
+}
@@ -18,6 +18,13 @@ type PgxPool interface {
 	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
 }
 
+// PgxTxPool is a PgxPool that can also open transactions, for repositories
+// that must write several statements together.
+type PgxTxPool interface {
+	PgxPool
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
 type CandleRepository struct {
 	pool   PgxPool
 	tracer trace.Tracer
@@ -2,10 +2,13 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"time"
 
 	"bug-free-umbrella/internal/domain"
 
+	"github.com/jackc/pgx/v5"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -29,15 +32,17 @@ func (r *ConversationRepository) AppendMessage(ctx context.Context, chatID int64
 	return err
 }
 
+// RecentMessages returns a chat's last limit user and assistant messages,
+// oldest first. Tool calls stored for auditing are left out.
 func (r *ConversationRepository) RecentMessages(ctx context.Context, chatID int64, limit int) ([]domain.ConversationMessage, error) {
 	_, span := r.tracer.Start(ctx, "conversation-repo.recent-messages")
 	defer span.End()
 
 	rows, err := r.pool.Query(ctx,
-		`SELECT role, content, created_at
+		`SELECT id, role, content, created_at
 		 FROM conversation_messages
-		 WHERE chat_id = $1
-		 ORDER BY created_at DESC
+		 WHERE chat_id = $1 AND role IN ('user', 'assistant')
+		 ORDER BY created_at DESC, id DESC
 		 LIMIT $2`,
 		chatID, limit,
 	)
@@ -50,7 +55,7 @@ func (r *ConversationRepository) RecentMessages(ctx context.Context, chatID int6
 	for rows.Next() {
 		var m domain.ConversationMessage
 		var ts time.Time
-		if err := rows.Scan(&m.Role, &m.Content, &ts); err != nil {
+		if err := rows.Scan(&m.ID, &m.Role, &m.Content, &ts); err != nil {
 			return nil, err
 		}
 		m.CreatedAt = ts.UTC()
@@ -67,3 +72,106 @@ func (r *ConversationRepository) RecentMessages(ctx context.Context, chatID int6
 
 	return messages, nil
 }
+
+// GetMemory returns a chat's running summary and remembered facts, or nil
+// when the advisor has none for it yet.
+func (r *ConversationRepository) GetMemory(ctx context.Context, chatID int64) (*domain.ConversationMemory, error) {
+	_, span := r.tracer.Start(ctx, "conversation-repo.get-memory")
+	defer span.End()
+
+	mem := domain.ConversationMemory{ChatID: chatID}
+	var facts string
+	var ts time.Time
+	err := r.pool.QueryRow(ctx,
+		`SELECT summary, compacted_through, facts::text, updated_at
+		 FROM conversation_memory
+		 WHERE chat_id = $1`,
+		chatID,
+	).Scan(&mem.Summary, &mem.CompactedThrough, &facts, &ts)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if facts != "" {
+		_ = json.Unmarshal([]byte(facts), &mem.Facts)
+	}
+	mem.UpdatedAt = ts.UTC()
+	return &mem, nil
+}
+
+// SaveMemory inserts or replaces a chat's running summary and facts.
+func (r *ConversationRepository) SaveMemory(ctx context.Context, mem domain.ConversationMemory) error {
+	_, span := r.tracer.Start(ctx, "conversation-repo.save-memory")
+	defer span.End()
+
+	facts := mem.Facts
+	if facts == nil {
+		facts = []string{}
+	}
+	raw, err := json.Marshal(facts)
+	if err != nil {
+		return err
+	}
+	_, err = r.pool.Exec(ctx,
+		`INSERT INTO conversation_memory (chat_id, summary, compacted_through, facts, updated_at)
+		 VALUES ($1, $2, $3, $4::jsonb, NOW())
+		 ON CONFLICT (chat_id) DO UPDATE
+		 SET summary = EXCLUDED.summary,
+		     compacted_through = EXCLUDED.compacted_through,
+		     facts = EXCLUDED.facts,
+		     updated_at = NOW()`,
+		mem.ChatID, mem.Summary, mem.CompactedThrough, string(raw),
+	)
+	return err
+}
+
+// ResetConversation starts a chat afresh: the summary is cleared and every
+// message so far is marked compacted, so none of it reaches the prompt again.
+// Remembered facts are kept.
+func (r *ConversationRepository) ResetConversation(ctx context.Context, chatID int64) error {
+	_, span := r.tracer.Start(ctx, "conversation-repo.reset")
+	defer span.End()
+
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO conversation_memory (chat_id, summary, compacted_through, updated_at)
+		 VALUES ($1, '', (SELECT COALESCE(MAX(id), 0) FROM conversation_messages WHERE chat_id = $1), NOW())
+		 ON CONFLICT (chat_id) DO UPDATE
+		 SET summary = '',
+		     compacted_through = EXCLUDED.compacted_through,
+		     updated_at = NOW()`,
+		chatID,
+	)
+	return err
+}
+
+// ForgetConversation deletes everything stored for a chat: its messages,
+// including audited tool calls, its summary and its facts.
+func (r *ConversationRepository) ForgetConversation(ctx context.Context, chatID int64) error {
+	_, span := r.tracer.Start(ctx, "conversation-repo.forget")
+	defer span.End()
+
+	_, err := r.pool.Exec(ctx,
+		`WITH deleted AS (DELETE FROM conversation_messages WHERE chat_id = $1)
+		 DELETE FROM conversation_memory WHERE chat_id = $1`,
+		chatID,
+	)
+	return err
+}
+
+// DeleteMessagesOlderThan removes conversation messages created before cutoff
+// across all chats and reports how many were deleted.
+func (r *ConversationRepository) DeleteMessagesOlderThan(ctx context.Context, cutoff time.Time) (int64, error) {
+	_, span := r.tracer.Start(ctx, "conversation-repo.delete-older-than")
+	defer span.End()
+
+	tag, err := r.pool.Exec(ctx,
+		`DELETE FROM conversation_messages WHERE created_at < $1`,
+		cutoff.UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
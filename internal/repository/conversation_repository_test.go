@@ -3,9 +3,12 @@ package repository
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"bug-free-umbrella/internal/domain"
+
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"go.opentelemetry.io/otel/trace"
@@ -28,8 +31,8 @@ func TestConversationRecentMessagesReturnsChronological(t *testing.T) {
 	t2 := time.Date(2025, 1, 1, 10, 1, 0, 0, time.UTC)
 	// Rows come back newest-first from the query
 	rows := [][]any{
-		{"assistant", "hi there", t2},
-		{"user", "hello", t1},
+		{int64(2), "assistant", "hi there", t2},
+		{int64(1), "user", "hello", t1},
 	}
 	pool := &convStubPool{rowsData: rows}
 	repo := NewConversationRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
@@ -41,8 +44,11 @@ func TestConversationRecentMessagesReturnsChronological(t *testing.T) {
 	if len(messages) != 2 {
 		t.Fatalf("expected 2 messages, got %d", len(messages))
 	}
+	if !strings.Contains(pool.querySQL, "role IN ('user', 'assistant')") {
+		t.Fatalf("expected tool calls to be left out of history, got %s", pool.querySQL)
+	}
 	// After reversal, oldest first
-	if messages[0].Role != "user" || messages[0].Content != "hello" {
+	if messages[0].ID != 1 || messages[0].Role != "user" || messages[0].Content != "hello" {
 		t.Fatalf("expected first message to be user/hello, got %+v", messages[0])
 	}
 	if messages[1].Role != "assistant" || messages[1].Content != "hi there" {
@@ -63,16 +69,114 @@ func TestConversationRecentMessagesEmptyResult(t *testing.T) {
 	}
 }
 
+func TestConversationGetMemoryDecodesFacts(t *testing.T) {
+	ts := time.Date(2025, 1, 2, 9, 0, 0, 0, time.FixedZone("X", 3600))
+	pool := &convStubPool{row: []any{"likes BTC", int64(42), `["prefers ETH","low risk"]`, ts}}
+	repo := NewConversationRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	mem, err := repo.GetMemory(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mem == nil || mem.ChatID != 7 || mem.Summary != "likes BTC" || mem.CompactedThrough != 42 {
+		t.Fatalf("unexpected memory %+v", mem)
+	}
+	if len(mem.Facts) != 2 || mem.Facts[1] != "low risk" {
+		t.Fatalf("expected decoded facts, got %#v", mem.Facts)
+	}
+	if mem.UpdatedAt.Location() != time.UTC {
+		t.Fatalf("expected UTC timestamp, got %v", mem.UpdatedAt)
+	}
+}
+
+func TestConversationGetMemoryMissingReturnsNil(t *testing.T) {
+	pool := &convStubPool{rowErr: pgx.ErrNoRows}
+	repo := NewConversationRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	mem, err := repo.GetMemory(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mem != nil {
+		t.Fatalf("expected nil memory, got %+v", mem)
+	}
+}
+
+func TestConversationSaveMemoryUpsertsFactsAsJSON(t *testing.T) {
+	pool := &convStubPool{}
+	repo := NewConversationRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	err := repo.SaveMemory(context.Background(), domain.ConversationMemory{ChatID: 7, Summary: "s", CompactedThrough: 9})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(pool.execSQL, "ON CONFLICT (chat_id)") {
+		t.Fatalf("expected upsert, got %s", pool.execSQL)
+	}
+	if pool.execArgs[3] != "[]" {
+		t.Fatalf("expected nil facts stored as empty array, got %v", pool.execArgs[3])
+	}
+}
+
+func TestConversationResetMarksAllMessagesCompacted(t *testing.T) {
+	pool := &convStubPool{}
+	repo := NewConversationRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	if err := repo.ResetConversation(context.Background(), 7); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(pool.execSQL, "MAX(id)") || strings.Contains(pool.execSQL, "facts =") {
+		t.Fatalf("expected reset to move the watermark and keep facts, got %s", pool.execSQL)
+	}
+}
+
+func TestConversationForgetDeletesMessagesAndMemory(t *testing.T) {
+	pool := &convStubPool{}
+	repo := NewConversationRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	if err := repo.ForgetConversation(context.Background(), 7); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(pool.execSQL, "DELETE FROM conversation_messages") || !strings.Contains(pool.execSQL, "DELETE FROM conversation_memory") {
+		t.Fatalf("expected both tables cleared, got %s", pool.execSQL)
+	}
+}
+
+func TestConversationDeleteMessagesOlderThanReportsRows(t *testing.T) {
+	pool := &convStubPool{execTag: pgconn.NewCommandTag("DELETE 3")}
+	repo := NewConversationRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	cutoff := time.Date(2025, 1, 1, 0, 0, 0, 0, time.FixedZone("X", 3600))
+	n, err := repo.DeleteMessagesOlderThan(context.Background(), cutoff)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 3 {
+		t.Fatalf("expected 3 deleted rows, got %d", n)
+	}
+	if got := pool.execArgs[0].(time.Time); got.Location() != time.UTC {
+		t.Fatalf("expected UTC cutoff, got %v", got)
+	}
+}
+
 // --- stubs ---
 
 type convStubPool struct {
 	execCount int
+	execSQL   string
+	execArgs  []any
+	execTag   pgconn.CommandTag
 	rowsData  [][]any
+	querySQL  string
+	row       []any
+	rowErr    error
 }
 
 func (s *convStubPool) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
 	s.execCount++
-	return pgconn.CommandTag{}, nil
+	s.execSQL = sql
+	s.execArgs = args
+	return s.execTag, nil
 }
 
 func (s *convStubPool) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
@@ -80,6 +184,7 @@ func (s *convStubPool) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchRes
 }
 
 func (s *convStubPool) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	s.querySQL = sql
 	if s.rowsData == nil {
 		return &convStubRows{}, nil
 	}
@@ -93,7 +198,7 @@ func (s *convStubPool) Query(ctx context.Context, sql string, args ...any) (pgx.
 }
 
 func (s *convStubPool) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
-	return &convStubRow{}
+	return &convStubRow{data: s.row, err: s.rowErr}
 }
 
 type convStubBatchResults struct{}
@@ -128,6 +233,8 @@ func (r *convStubRows) Scan(dest ...any) error {
 	row := r.data[r.idx-1]
 	for i, d := range dest {
 		switch ptr := d.(type) {
+		case *int64:
+			*ptr = row[i].(int64)
 		case *string:
 			*ptr = row[i].(string)
 		case *time.Time:
@@ -143,6 +250,18 @@ func (r *convStubRows) Values() ([]any, error) { return nil, nil }
 func (r *convStubRows) RawValues() [][]byte    { return nil }
 func (r *convStubRows) Conn() *pgx.Conn        { return nil }
 
-type convStubRow struct{}
+type convStubRow struct {
+	data []any
+	err  error
+}
 
-func (convStubRow) Scan(dest ...any) error { return nil }
+func (r convStubRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	if r.data == nil {
+		return nil
+	}
+	rows := convStubRows{data: [][]any{r.data}, idx: 1}
+	return rows.Scan(dest...)
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DigestRepository persists chats' scheduled market digests.
+type DigestRepository struct {
+	pool   PgxPool
+	tracer trace.Tracer
+}
+
+func NewDigestRepository(pool PgxPool, tracer trace.Tracer) *DigestRepository {
+	return &DigestRepository{pool: pool, tracer: tracer}
+}
+
+const digestColumns = `chat_id, period, send_minute, weekday, timezone, last_sent_at, created_at`
+
+// ListDigestSubscriptions returns every scheduled digest.
+func (r *DigestRepository) ListDigestSubscriptions(ctx context.Context) ([]domain.DigestSubscription, error) {
+	_, span := r.tracer.Start(ctx, "digest-repo.list")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx, `SELECT `+digestColumns+` FROM digest_subscriptions ORDER BY chat_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]domain.DigestSubscription, 0)
+	for rows.Next() {
+		sub, err := scanDigestSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sub)
+	}
+	return out, rows.Err()
+}
+
+// GetDigestSubscription returns a chat's digest schedule, or nil when it has
+// none.
+func (r *DigestRepository) GetDigestSubscription(ctx context.Context, chatID int64) (*domain.DigestSubscription, error) {
+	_, span := r.tracer.Start(ctx, "digest-repo.get")
+	defer span.End()
+
+	sub, err := scanDigestSubscription(r.pool.QueryRow(ctx,
+		`SELECT `+digestColumns+` FROM digest_subscriptions WHERE chat_id = $1`,
+		chatID,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// SaveDigestSubscription inserts or replaces a chat's digest schedule.
+func (r *DigestRepository) SaveDigestSubscription(ctx context.Context, sub domain.DigestSubscription) error {
+	_, span := r.tracer.Start(ctx, "digest-repo.save")
+	defer span.End()
+
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO digest_subscriptions (chat_id, period, send_minute, weekday, timezone, last_sent_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 ON CONFLICT (chat_id) DO UPDATE
+		 SET period = EXCLUDED.period, send_minute = EXCLUDED.send_minute, weekday = EXCLUDED.weekday,
+		     timezone = EXCLUDED.timezone, last_sent_at = EXCLUDED.last_sent_at, updated_at = NOW()`,
+		sub.ChatID, string(sub.Period), int16(sub.Minute), int16(sub.Weekday), sub.Timezone, sub.LastSentAt,
+	)
+	return err
+}
+
+// DeleteDigestSubscription stops a chat's digest. It reports whether there
+// was one.
+func (r *DigestRepository) DeleteDigestSubscription(ctx context.Context, chatID int64) (bool, error) {
+	_, span := r.tracer.Start(ctx, "digest-repo.delete")
+	defer span.End()
+
+	tag, err := r.pool.Exec(ctx, `DELETE FROM digest_subscriptions WHERE chat_id = $1`, chatID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ClaimDigest records that a chat's digest due at due is being sent at at.
+// It reports false when the digest was already sent or claimed for that
+// slot, so only one replica sends it.
+func (r *DigestRepository) ClaimDigest(ctx context.Context, chatID int64, at, due time.Time) (bool, error) {
+	_, span := r.tracer.Start(ctx, "digest-repo.claim")
+	defer span.End()
+
+	var claimed int64
+	err := r.pool.QueryRow(ctx,
+		`UPDATE digest_subscriptions SET last_sent_at = $2, updated_at = NOW()
+		 WHERE chat_id = $1 AND (last_sent_at IS NULL OR last_sent_at < $3)
+		 RETURNING chat_id`,
+		chatID, at.UTC(), due.UTC(),
+	).Scan(&claimed)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ReleaseDigest gives up a claim made at at whose digest could not be sent,
+// restoring the previous send time so the next run retries it.
+func (r *DigestRepository) ReleaseDigest(ctx context.Context, chatID int64, at time.Time, previous *time.Time) error {
+	_, span := r.tracer.Start(ctx, "digest-repo.release")
+	defer span.End()
+
+	_, err := r.pool.Exec(ctx,
+		`UPDATE digest_subscriptions SET last_sent_at = $3, updated_at = NOW()
+		 WHERE chat_id = $1 AND last_sent_at = $2`,
+		chatID, at.UTC(), previous,
+	)
+	return err
+}
+
+func scanDigestSubscription(row pgx.Row) (domain.DigestSubscription, error) {
+	var sub domain.DigestSubscription
+	var period string
+	var minute, weekday int16
+	if err := row.Scan(&sub.ChatID, &period, &minute, &weekday, &sub.Timezone, &sub.LastSentAt, &sub.CreatedAt); err != nil {
+		return sub, err
+	}
+	sub.Period = domain.DigestPeriod(period)
+	sub.Minute = int(minute)
+	sub.Weekday = time.Weekday(weekday)
+	sub.CreatedAt = sub.CreatedAt.UTC()
+	if sub.LastSentAt != nil {
+		at := sub.LastSentAt.UTC()
+		sub.LastSentAt = &at
+	}
+	return sub, nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestDigestListScansSchedules(t *testing.T) {
+	at := time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)
+	pool := &runStubPool{rowsData: [][]any{
+		{int64(7), "weekly", 540, 1, "Europe/Berlin", at, at},
+		{int64(9), "daily", 480, 0, "UTC", nil, at},
+	}}
+	repo := NewDigestRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	subs, err := repo.ListDigestSubscriptions(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(subs) != 2 {
+		t.Fatalf("expected 2 schedules, got %d", len(subs))
+	}
+	got := subs[0]
+	if got.Period != domain.DigestWeekly || got.Minute != 540 || got.Weekday != time.Monday || got.Timezone != "Europe/Berlin" ||
+		got.LastSentAt == nil || !got.LastSentAt.Equal(at) {
+		t.Fatalf("unexpected schedule: %+v", got)
+	}
+	if subs[1].LastSentAt != nil {
+		t.Fatalf("expected no last send, got %v", subs[1].LastSentAt)
+	}
+}
+
+func TestDigestGetMissingReturnsNil(t *testing.T) {
+	pool := &runStubPool{rowErr: pgx.ErrNoRows}
+	repo := NewDigestRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	sub, err := repo.GetDigestSubscription(context.Background(), 7)
+	if err != nil || sub != nil {
+		t.Fatalf("expected nil schedule, got %+v err=%v", sub, err)
+	}
+	if pool.rowArgs[0] != int64(7) {
+		t.Fatalf("unexpected args: %v", pool.rowArgs)
+	}
+}
+
+func TestDigestSaveAndDelete(t *testing.T) {
+	pool := &runStubPool{execTag: pgconn.NewCommandTag("DELETE 1")}
+	repo := NewDigestRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	err := repo.SaveDigestSubscription(context.Background(), domain.DigestSubscription{
+		ChatID: 7, Period: domain.DigestWeekly, Minute: 540, Weekday: time.Friday, Timezone: "UTC",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(pool.execSQL, "ON CONFLICT (chat_id) DO UPDATE") || pool.execArgs[1] != "weekly" ||
+		pool.execArgs[2] != int16(540) || pool.execArgs[3] != int16(5) {
+		t.Fatalf("unexpected save: %q %v", pool.execSQL, pool.execArgs)
+	}
+
+	deleted, err := repo.DeleteDigestSubscription(context.Background(), 7)
+	if err != nil || !deleted {
+		t.Fatalf("expected delete, got %v err=%v", deleted, err)
+	}
+}
+
+func TestDigestClaimIsConditional(t *testing.T) {
+	now := time.Date(2026, 6, 10, 9, 0, 0, 0, time.UTC)
+	due := now.Add(-time.Hour)
+	pool := &runStubPool{row: []any{int64(7)}}
+	repo := NewDigestRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	claimed, err := repo.ClaimDigest(context.Background(), 7, now, due)
+	if err != nil || !claimed {
+		t.Fatalf("expected the digest claimed, got %v err=%v", claimed, err)
+	}
+	if !strings.Contains(pool.rowSQL, "last_sent_at IS NULL OR last_sent_at < $3") || pool.rowArgs[2] != due {
+		t.Fatalf("unexpected claim: %q %v", pool.rowSQL, pool.rowArgs)
+	}
+
+	pool.rowErr = pgx.ErrNoRows
+	if claimed, err := repo.ClaimDigest(context.Background(), 7, now, due); err != nil || claimed {
+		t.Fatalf("expected an already sent digest not claimed, got %v err=%v", claimed, err)
+	}
+
+	if err := repo.ReleaseDigest(context.Background(), 7, now, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(pool.execSQL, "AND last_sent_at = $2") || pool.execArgs[1] != now {
+		t.Fatalf("unexpected release: %q %v", pool.execSQL, pool.execArgs)
+	}
+}
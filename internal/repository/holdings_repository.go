@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"bug-free-umbrella/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HoldingsRepository persists the trades behind users' real portfolios and
+// whether each user shares them with the advisor.
+type HoldingsRepository struct {
+	pool   PgxPool
+	tracer trace.Tracer
+}
+
+func NewHoldingsRepository(pool PgxPool, tracer trace.Tracer) *HoldingsRepository {
+	return &HoldingsRepository{pool: pool, tracer: tracer}
+}
+
+// AddTrade inserts one trade and returns it with its id.
+func (r *HoldingsRepository) AddTrade(ctx context.Context, t domain.HoldingTrade) (*domain.HoldingTrade, error) {
+	_, span := r.tracer.Start(ctx, "holdings-repo.add-trade")
+	defer span.End()
+
+	err := r.pool.QueryRow(ctx,
+		`INSERT INTO holding_trades (chat_id, symbol, side, quantity, price, fee, executed_at, source, external_id)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		 RETURNING id, created_at`,
+		t.ChatID, t.Symbol, string(t.Side), t.Quantity, t.Price, t.Fee, t.ExecutedAt, t.Source, t.ExternalID,
+	).Scan(&t.ID, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	t.CreatedAt = t.CreatedAt.UTC()
+	return &t, nil
+}
+
+// InsertTrades inserts imported trades in one batch, skipping rows whose
+// external id was already imported for the same user. It returns how many
+// rows were new.
+func (r *HoldingsRepository) InsertTrades(ctx context.Context, trades []domain.HoldingTrade) (int, error) {
+	if len(trades) == 0 {
+		return 0, nil
+	}
+
+	_, span := r.tracer.Start(ctx, "holdings-repo.insert-trades")
+	defer span.End()
+
+	batch := &pgx.Batch{}
+	for _, t := range trades {
+		batch.Queue(
+			`INSERT INTO holding_trades (chat_id, symbol, side, quantity, price, fee, executed_at, source, external_id)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			 ON CONFLICT (chat_id, external_id) WHERE external_id <> '' DO NOTHING`,
+			t.ChatID, t.Symbol, string(t.Side), t.Quantity, t.Price, t.Fee, t.ExecutedAt, t.Source, t.ExternalID,
+		)
+	}
+
+	br := r.pool.SendBatch(ctx, batch)
+	defer br.Close()
+
+	inserted := 0
+	for range trades {
+		tag, err := br.Exec()
+		if err != nil {
+			return inserted, err
+		}
+		inserted += int(tag.RowsAffected())
+	}
+	return inserted, nil
+}
+
+// ListTrades returns a user's trades oldest first, the order FIFO lots are
+// built in.
+func (r *HoldingsRepository) ListTrades(ctx context.Context, chatID int64) ([]domain.HoldingTrade, error) {
+	_, span := r.tracer.Start(ctx, "holdings-repo.list-trades")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, chat_id, symbol, side, quantity, price, fee, executed_at, source, external_id, created_at
+		 FROM holding_trades
+		 WHERE chat_id = $1
+		 ORDER BY executed_at, id`,
+		chatID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]domain.HoldingTrade, 0)
+	for rows.Next() {
+		var t domain.HoldingTrade
+		var side string
+		if err := rows.Scan(&t.ID, &t.ChatID, &t.Symbol, &side, &t.Quantity, &t.Price, &t.Fee,
+			&t.ExecutedAt, &t.Source, &t.ExternalID, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		t.Side = domain.PaperSide(side)
+		t.ExecutedAt = t.ExecutedAt.UTC()
+		t.CreatedAt = t.CreatedAt.UTC()
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// DeleteTrade reports whether the user had a trade with that id.
+func (r *HoldingsRepository) DeleteTrade(ctx context.Context, chatID, id int64) (bool, error) {
+	_, span := r.tracer.Start(ctx, "holdings-repo.delete-trade")
+	defer span.End()
+
+	tag, err := r.pool.Exec(ctx, `DELETE FROM holding_trades WHERE chat_id = $1 AND id = $2`, chatID, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ShareWithAdvisor reports whether the user opted in to sharing holdings with
+// the advisor. Users without a stored preference have not.
+func (r *HoldingsRepository) ShareWithAdvisor(ctx context.Context, chatID int64) (bool, error) {
+	_, span := r.tracer.Start(ctx, "holdings-repo.share-with-advisor")
+	defer span.End()
+
+	var share bool
+	err := r.pool.QueryRow(ctx,
+		`SELECT share_with_advisor FROM holding_preferences WHERE chat_id = $1`,
+		chatID,
+	).Scan(&share)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	return share, err
+}
+
+func (r *HoldingsRepository) SetShareWithAdvisor(ctx context.Context, chatID int64, share bool) error {
+	_, span := r.tracer.Start(ctx, "holdings-repo.set-share-with-advisor")
+	defer span.End()
+
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO holding_preferences (chat_id, share_with_advisor, updated_at)
+		 VALUES ($1, $2, NOW())
+		 ON CONFLICT (chat_id) DO UPDATE
+		 SET share_with_advisor = EXCLUDED.share_with_advisor, updated_at = NOW()`,
+		chatID, share,
+	)
+	return err
+}
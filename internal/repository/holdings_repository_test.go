@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestHoldingsAddTradeReturnsID(t *testing.T) {
+	created := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	pool := &runStubPool{row: []any{int64(12), created}}
+	repo := NewHoldingsRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	trade, err := repo.AddTrade(context.Background(), domain.HoldingTrade{
+		ChatID: 7, Symbol: "BTC", Side: domain.PaperBuy, Quantity: 0.5, Price: 30000,
+		ExecutedAt: created, Source: domain.HoldingSourceManual,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if trade.ID != 12 || !trade.CreatedAt.Equal(created) {
+		t.Fatalf("unexpected trade: %+v", trade)
+	}
+	if pool.rowArgs[2] != "buy" {
+		t.Fatalf("expected side stored as text, got %v", pool.rowArgs[2])
+	}
+}
+
+func TestHoldingsInsertTradesBatchesAndSkipsDuplicates(t *testing.T) {
+	pool := &runStubPool{}
+	repo := NewHoldingsRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	n, err := repo.InsertTrades(context.Background(), []domain.HoldingTrade{
+		{ChatID: 7, Symbol: "BTC", Side: domain.PaperBuy, Quantity: 1, Price: 1, ExternalID: "a"},
+		{ChatID: 7, Symbol: "ETH", Side: domain.PaperBuy, Quantity: 1, Price: 1, ExternalID: "b"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pool.batchLen != 2 {
+		t.Fatalf("expected 2 queued inserts, got %d", pool.batchLen)
+	}
+	if n != 0 {
+		t.Fatalf("expected rows affected from the batch results, got %d", n)
+	}
+
+	if n, err := repo.InsertTrades(context.Background(), nil); err != nil || n != 0 {
+		t.Fatalf("expected no-op for empty input, got %d %v", n, err)
+	}
+}
+
+func TestHoldingsListTradesScansSide(t *testing.T) {
+	at := time.Date(2026, 4, 2, 9, 30, 0, 0, time.UTC)
+	pool := &runStubPool{rowsData: [][]any{
+		{int64(1), int64(7), "ETH", "sell", 2.0, 3000.0, 1.5, at, "csv", "abc", at},
+	}}
+	repo := NewHoldingsRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	trades, err := repo.ListTrades(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(trades) != 1 || trades[0].Side != domain.PaperSell || trades[0].Fee != 1.5 || trades[0].ExternalID != "abc" {
+		t.Fatalf("unexpected trades: %+v", trades)
+	}
+	if pool.queryArgs[0] != int64(7) {
+		t.Fatalf("unexpected args: %v", pool.queryArgs)
+	}
+}
+
+func TestHoldingsDeleteTradeScopedToUser(t *testing.T) {
+	pool := &runStubPool{execTag: pgconn.NewCommandTag("DELETE 1")}
+	repo := NewHoldingsRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	ok, err := repo.DeleteTrade(context.Background(), 7, 3)
+	if err != nil || !ok {
+		t.Fatalf("expected delete, got %v %v", ok, err)
+	}
+	if !strings.Contains(pool.execSQL, "chat_id = $1 AND id = $2") {
+		t.Fatalf("expected delete scoped to the user, got %q", pool.execSQL)
+	}
+}
+
+func TestHoldingsShareWithAdvisorDefaultsOff(t *testing.T) {
+	pool := &runStubPool{rowErr: pgx.ErrNoRows}
+	repo := NewHoldingsRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	share, err := repo.ShareWithAdvisor(context.Background(), 7)
+	if err != nil || share {
+		t.Fatalf("expected opt-out by default, got %v %v", share, err)
+	}
+
+	if err := repo.SetShareWithAdvisor(context.Background(), 7, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(pool.execSQL, "ON CONFLICT (chat_id)") || pool.execArgs[1] != true {
+		t.Fatalf("expected upsert of the preference, got %q %v", pool.execSQL, pool.execArgs)
+	}
+}
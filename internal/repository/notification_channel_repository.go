@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"bug-free-umbrella/internal/domain"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NotificationChannelRepository persists notification channels outside
+// Telegram. Filter lists are stored comma-separated like alert
+// subscriptions.
+type NotificationChannelRepository struct {
+	pool   PgxPool
+	tracer trace.Tracer
+}
+
+func NewNotificationChannelRepository(pool PgxPool, tracer trace.Tracer) *NotificationChannelRepository {
+	return &NotificationChannelRepository{pool: pool, tracer: tracer}
+}
+
+const notificationChannelColumns = `id, name, type, target, secret, kinds, symbols, intervals, indicators,
+	min_risk, max_risk, direction, enabled, created_at, updated_at`
+
+// CreateChannel inserts a channel and returns it with its id.
+func (r *NotificationChannelRepository) CreateChannel(ctx context.Context, ch domain.NotificationChannel) (*domain.NotificationChannel, error) {
+	_, span := r.tracer.Start(ctx, "notification-channel-repo.create")
+	defer span.End()
+
+	err := r.pool.QueryRow(ctx,
+		`INSERT INTO notification_channels (name, type, target, secret, kinds, symbols, intervals, indicators,
+		                                    min_risk, max_risk, direction, enabled)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		 RETURNING id, created_at, updated_at`,
+		channelArgs(ch)...,
+	).Scan(&ch.ID, &ch.CreatedAt, &ch.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	ch.HasSecret = ch.Secret != ""
+	ch.MaskedTarget = domain.MaskChannelTarget(ch.Type, ch.Target)
+	ch.CreatedAt = ch.CreatedAt.UTC()
+	ch.UpdatedAt = ch.UpdatedAt.UTC()
+	return &ch, nil
+}
+
+// ListChannels returns every channel, enabled or not, oldest first.
+func (r *NotificationChannelRepository) ListChannels(ctx context.Context) ([]domain.NotificationChannel, error) {
+	_, span := r.tracer.Start(ctx, "notification-channel-repo.list")
+	defer span.End()
+
+	return r.query(ctx, `SELECT `+notificationChannelColumns+` FROM notification_channels ORDER BY id`)
+}
+
+// GetChannel returns a channel, or nil if there is none with id.
+func (r *NotificationChannelRepository) GetChannel(ctx context.Context, id int64) (*domain.NotificationChannel, error) {
+	_, span := r.tracer.Start(ctx, "notification-channel-repo.get")
+	defer span.End()
+
+	channels, err := r.query(ctx, `SELECT `+notificationChannelColumns+` FROM notification_channels WHERE id = $1`, id)
+	if err != nil || len(channels) == 0 {
+		return nil, err
+	}
+	return &channels[0], nil
+}
+
+// UpdateChannel replaces a channel's settings and reports whether it exists.
+func (r *NotificationChannelRepository) UpdateChannel(ctx context.Context, ch domain.NotificationChannel) (bool, error) {
+	_, span := r.tracer.Start(ctx, "notification-channel-repo.update")
+	defer span.End()
+
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE notification_channels
+		 SET name = $1, type = $2, target = $3, secret = $4, kinds = $5, symbols = $6, intervals = $7,
+		     indicators = $8, min_risk = $9, max_risk = $10, direction = $11, enabled = $12, updated_at = NOW()
+		 WHERE id = $13`,
+		append(channelArgs(ch), ch.ID)...,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// DeleteChannel removes a channel and its delivery log, and reports whether
+// it existed.
+func (r *NotificationChannelRepository) DeleteChannel(ctx context.Context, id int64) (bool, error) {
+	_, span := r.tracer.Start(ctx, "notification-channel-repo.delete")
+	defer span.End()
+
+	tag, err := r.pool.Exec(ctx, `DELETE FROM notification_channels WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *NotificationChannelRepository) query(ctx context.Context, sql string, args ...any) ([]domain.NotificationChannel, error) {
+	rows, err := r.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]domain.NotificationChannel, 0)
+	for rows.Next() {
+		var ch domain.NotificationChannel
+		var kind, kinds, symbols, intervals, indicators, direction string
+		var minRisk, maxRisk int16
+		if err := rows.Scan(&ch.ID, &ch.Name, &kind, &ch.Target, &ch.Secret, &kinds, &symbols, &intervals,
+			&indicators, &minRisk, &maxRisk, &direction, &ch.Enabled, &ch.CreatedAt, &ch.UpdatedAt); err != nil {
+			return nil, err
+		}
+		ch.Type = domain.NotificationChannelType(kind)
+		for _, k := range splitList(kinds) {
+			ch.Kinds = append(ch.Kinds, domain.AlertDeliveryKind(k))
+		}
+		ch.Symbols = splitList(symbols)
+		ch.Intervals = splitList(intervals)
+		ch.Indicators = splitList(indicators)
+		ch.MinRisk = domain.RiskLevel(minRisk)
+		ch.MaxRisk = domain.RiskLevel(maxRisk)
+		ch.Direction = domain.SignalDirection(direction)
+		ch.HasSecret = ch.Secret != ""
+		ch.MaskedTarget = domain.MaskChannelTarget(ch.Type, ch.Target)
+		ch.CreatedAt = ch.CreatedAt.UTC()
+		ch.UpdatedAt = ch.UpdatedAt.UTC()
+		out = append(out, ch)
+	}
+	return out, rows.Err()
+}
+
+func channelArgs(ch domain.NotificationChannel) []any {
+	kinds := make([]string, 0, len(ch.Kinds))
+	for _, k := range ch.Kinds {
+		kinds = append(kinds, string(k))
+	}
+	return []any{
+		ch.Name, string(ch.Type), ch.Target, ch.Secret, strings.Join(kinds, ","),
+		strings.Join(ch.Symbols, ","), strings.Join(ch.Intervals, ","), strings.Join(ch.Indicators, ","),
+		int16(ch.MinRisk), int16(ch.MaxRisk), string(ch.Direction), ch.Enabled,
+	}
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNotificationChannelCreateJoinsLists(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	pool := &runStubPool{row: []any{int64(3), now, now}}
+	repo := NewNotificationChannelRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	ch, err := repo.CreateChannel(context.Background(), domain.NotificationChannel{
+		Name: "ops", Type: domain.ChannelWebhook, Target: "https://hooks.example.com/x", Secret: "k",
+		Kinds:   []domain.AlertDeliveryKind{domain.DeliveryKindSignal, domain.DeliveryKindPriceAlert},
+		Symbols: []string{"BTC", "ETH"}, MinRisk: domain.RiskLevel2, Enabled: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ch.ID != 3 || !ch.HasSecret || !ch.CreatedAt.Equal(now) || ch.MaskedTarget != "https://hooks.example.com/***" {
+		t.Fatalf("unexpected channel: %+v", ch)
+	}
+	if pool.rowArgs[4] != "signal,price_alert" || pool.rowArgs[5] != "BTC,ETH" || pool.rowArgs[8] != int16(2) {
+		t.Fatalf("unexpected args: %v", pool.rowArgs)
+	}
+}
+
+func TestNotificationChannelListSplitsLists(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	pool := &runStubPool{rowsData: [][]any{
+		{int64(1), "team", "slack", "https://hooks.slack.com/x", "", "signal", "BTC,SOL", "", "rsi", 0, 4, "long", true, now, now},
+	}}
+	repo := NewNotificationChannelRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	got, err := repo.ListChannels(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected one channel, got %d", len(got))
+	}
+	ch := got[0]
+	if ch.Type != domain.ChannelSlack || len(ch.Kinds) != 1 || ch.Kinds[0] != domain.DeliveryKindSignal ||
+		len(ch.Symbols) != 2 || ch.Intervals != nil || ch.Indicators[0] != "rsi" ||
+		ch.MaxRisk != domain.RiskLevel4 || ch.Direction != domain.DirectionLong || ch.HasSecret ||
+		ch.Target != "https://hooks.slack.com/x" || ch.MaskedTarget != "https://hooks.slack.com/***" {
+		t.Fatalf("unexpected channel: %+v", ch)
+	}
+
+	if missing, err := NewNotificationChannelRepository(&runStubPool{}, trace.NewNoopTracerProvider().Tracer("test")).GetChannel(context.Background(), 9); err != nil || missing != nil {
+		t.Fatalf("expected nil, nil; got %+v, %v", missing, err)
+	}
+}
+
+func TestNotificationChannelUpdateAndDelete(t *testing.T) {
+	pool := &runStubPool{execTag: pgconn.NewCommandTag("UPDATE 1")}
+	repo := NewNotificationChannelRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	ok, err := repo.UpdateChannel(context.Background(), domain.NotificationChannel{ID: 4, Name: "ops", Type: domain.ChannelDiscord})
+	if err != nil || !ok {
+		t.Fatalf("expected update, got %v %v", ok, err)
+	}
+	if !strings.Contains(pool.execSQL, "WHERE id = $13") || pool.execArgs[12] != int64(4) {
+		t.Fatalf("unexpected update: %q %v", pool.execSQL, pool.execArgs)
+	}
+
+	pool.execTag = pgconn.NewCommandTag("DELETE 0")
+	if ok, err := repo.DeleteChannel(context.Background(), 4); err != nil || ok {
+		t.Fatalf("expected nothing deleted, got %v %v", ok, err)
+	}
+}
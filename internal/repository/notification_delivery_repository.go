@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NotificationDeliveryRepository is the outbox and delivery log for
+// notification channels. It is drained by a delivery.Worker like the alert
+// outbox: claimed rows come back as AlertDeliveries whose ChatID is the
+// channel ID, so rate limits apply per channel, and whose Text is the JSON
+// notification.
+type NotificationDeliveryRepository struct {
+	pool   PgxPool
+	tracer trace.Tracer
+}
+
+func NewNotificationDeliveryRepository(pool PgxPool, tracer trace.Tracer) *NotificationDeliveryRepository {
+	return &NotificationDeliveryRepository{pool: pool, tracer: tracer}
+}
+
+// Enqueue inserts pending notifications in one batch and returns how many
+// were stored. Each delivery's ChatID is its channel and Text its payload.
+func (r *NotificationDeliveryRepository) Enqueue(ctx context.Context, deliveries []domain.AlertDelivery) (int, error) {
+	if len(deliveries) == 0 {
+		return 0, nil
+	}
+
+	_, span := r.tracer.Start(ctx, "notification-delivery-repo.enqueue")
+	defer span.End()
+
+	batch := &pgx.Batch{}
+	for _, d := range deliveries {
+		batch.Queue(
+			`INSERT INTO notification_deliveries (channel_id, kind, payload) VALUES ($1, $2, $3::jsonb)`,
+			d.ChatID, string(d.Kind), d.Text,
+		)
+	}
+
+	br := r.pool.SendBatch(ctx, batch)
+	defer br.Close()
+
+	inserted := 0
+	for range deliveries {
+		tag, err := br.Exec()
+		if err != nil {
+			return inserted, err
+		}
+		inserted += int(tag.RowsAffected())
+	}
+	return inserted, nil
+}
+
+// Claim marks up to limit due notifications as sending, counts the attempt
+// and holds them until now+lease. The result is ordered oldest first.
+func (r *NotificationDeliveryRepository) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.AlertDelivery, error) {
+	_, span := r.tracer.Start(ctx, "notification-delivery-repo.claim")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx,
+		`UPDATE notification_deliveries d
+		 SET status = 'sending', attempts = d.attempts + 1, next_attempt_at = $2, updated_at = NOW()
+		 FROM (
+		     SELECT id FROM notification_deliveries
+		     WHERE status IN ('pending', 'sending') AND next_attempt_at <= $1
+		     ORDER BY next_attempt_at, id
+		     LIMIT $3
+		     FOR UPDATE SKIP LOCKED
+		 ) due
+		 WHERE d.id = due.id
+		 RETURNING d.id, d.channel_id, d.kind, d.payload::text, d.status, d.attempts,
+		           d.next_attempt_at, d.last_error, d.created_at`,
+		now.UTC(), now.Add(lease).UTC(), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]domain.AlertDelivery, 0)
+	for rows.Next() {
+		var d domain.AlertDelivery
+		var kind, status string
+		if err := rows.Scan(&d.ID, &d.ChatID, &kind, &d.Text, &status, &d.Attempts,
+			&d.NextAttemptAt, &d.LastError, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		d.Kind = domain.AlertDeliveryKind(kind)
+		d.Status = domain.AlertDeliveryStatus(status)
+		d.NextAttemptAt = d.NextAttemptAt.UTC()
+		d.CreatedAt = d.CreatedAt.UTC()
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+// MarkSent records a successful delivery.
+func (r *NotificationDeliveryRepository) MarkSent(ctx context.Context, id int64, at time.Time) error {
+	_, span := r.tracer.Start(ctx, "notification-delivery-repo.mark-sent")
+	defer span.End()
+
+	_, err := r.pool.Exec(ctx,
+		`UPDATE notification_deliveries SET status = 'sent', sent_at = $2, last_error = '', updated_at = NOW() WHERE id = $1`,
+		id, at.UTC(),
+	)
+	return err
+}
+
+// MarkRetry puts a failed notification back in the queue until next.
+func (r *NotificationDeliveryRepository) MarkRetry(ctx context.Context, id int64, next time.Time, lastErr string) error {
+	_, span := r.tracer.Start(ctx, "notification-delivery-repo.mark-retry")
+	defer span.End()
+
+	_, err := r.pool.Exec(ctx,
+		`UPDATE notification_deliveries SET status = 'pending', next_attempt_at = $2, last_error = $3, updated_at = NOW() WHERE id = $1`,
+		id, next.UTC(), lastErr,
+	)
+	return err
+}
+
+// MarkDead gives up on a notification. It stays in the log with its last
+// error.
+func (r *NotificationDeliveryRepository) MarkDead(ctx context.Context, id int64, lastErr string) error {
+	_, span := r.tracer.Start(ctx, "notification-delivery-repo.mark-dead")
+	defer span.End()
+
+	_, err := r.pool.Exec(ctx,
+		`UPDATE notification_deliveries SET status = 'dead', last_error = $2, updated_at = NOW() WHERE id = $1`,
+		id, lastErr,
+	)
+	return err
+}
+
+// PurgeSent deletes notifications delivered before cutoff and returns how
+// many were removed. Dead ones are kept.
+func (r *NotificationDeliveryRepository) PurgeSent(ctx context.Context, cutoff time.Time) (int64, error) {
+	_, span := r.tracer.Start(ctx, "notification-delivery-repo.purge-sent")
+	defer span.End()
+
+	tag, err := r.pool.Exec(ctx,
+		`DELETE FROM notification_deliveries WHERE status = 'sent' AND sent_at < $1`,
+		cutoff.UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ListDeliveries returns a channel's most recent deliveries, newest first.
+func (r *NotificationDeliveryRepository) ListDeliveries(ctx context.Context, channelID int64, limit int) ([]domain.NotificationDelivery, error) {
+	_, span := r.tracer.Start(ctx, "notification-delivery-repo.list")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, channel_id, kind, status, attempts, next_attempt_at, last_error, created_at, sent_at
+		 FROM notification_deliveries
+		 WHERE channel_id = $1
+		 ORDER BY id DESC
+		 LIMIT $2`,
+		channelID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]domain.NotificationDelivery, 0)
+	for rows.Next() {
+		var d domain.NotificationDelivery
+		var kind, status string
+		if err := rows.Scan(&d.ID, &d.ChannelID, &kind, &status, &d.Attempts, &d.NextAttemptAt,
+			&d.LastError, &d.CreatedAt, &d.SentAt); err != nil {
+			return nil, err
+		}
+		d.Kind = domain.AlertDeliveryKind(kind)
+		d.Status = domain.AlertDeliveryStatus(status)
+		d.NextAttemptAt = d.NextAttemptAt.UTC()
+		d.CreatedAt = d.CreatedAt.UTC()
+		if d.SentAt != nil {
+			sent := d.SentAt.UTC()
+			d.SentAt = &sent
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNotificationDeliveryEnqueueAndClaim(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	pool := &runStubPool{rowsData: [][]any{
+		{int64(8), int64(2), "price_alert", `{"kind":"price_alert"}`, "sending", 2, now.Add(time.Minute), "timeout", now},
+		{int64(5), int64(1), "signal", `{"kind":"signal"}`, "sending", 1, now.Add(time.Minute), "", now},
+	}}
+	repo := NewNotificationDeliveryRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	if _, err := repo.Enqueue(context.Background(), []domain.AlertDelivery{
+		{ChatID: 1, Kind: domain.DeliveryKindSignal, Text: `{}`},
+	}); err != nil || pool.batchLen != 1 {
+		t.Fatalf("expected one queued insert, got len=%d err=%v", pool.batchLen, err)
+	}
+
+	got, err := repo.Claim(context.Background(), now, time.Minute, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != 5 || got[1].ID != 8 {
+		t.Fatalf("expected claims ordered by id, got %+v", got)
+	}
+	if got[1].ChatID != 2 || got[1].Kind != domain.DeliveryKindPriceAlert || got[1].Text != `{"kind":"price_alert"}` || got[1].LastError != "timeout" {
+		t.Fatalf("unexpected claim: %+v", got[1])
+	}
+}
+
+func TestNotificationDeliveryListAndMarkers(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	pool := &runStubPool{rowsData: [][]any{
+		{int64(9), int64(3), "signal", "sent", 1, now, "", now, now},
+		{int64(7), int64(3), "signal", "dead", 8, now, "http 404", now, nil},
+	}}
+	repo := NewNotificationDeliveryRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	got, err := repo.ListDeliveries(context.Background(), 3, 20)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0].SentAt == nil || got[1].SentAt != nil || got[1].Status != domain.DeliveryDead {
+		t.Fatalf("unexpected log: %+v", got)
+	}
+	if pool.queryArgs[0] != int64(3) || pool.queryArgs[1] != 20 {
+		t.Fatalf("unexpected args: %v", pool.queryArgs)
+	}
+
+	if err := repo.MarkRetry(context.Background(), 7, now, "503"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(pool.execSQL, "UPDATE notification_deliveries SET status = 'pending'") {
+		t.Fatalf("unexpected retry update: %q", pool.execSQL)
+	}
+	if _, err := repo.PurgeSent(context.Background(), now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(pool.execSQL, "DELETE FROM notification_deliveries WHERE status = 'sent'") {
+		t.Fatalf("unexpected purge: %q", pool.execSQL)
+	}
+}
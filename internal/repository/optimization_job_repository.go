@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"bug-free-umbrella/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// OptimizationJobRepository persists optimisation jobs, so any replica can
+// report on or promote a job that another replica ran.
+type OptimizationJobRepository struct {
+	pool   PgxPool
+	tracer trace.Tracer
+}
+
+func NewOptimizationJobRepository(pool PgxPool, tracer trace.Tracer) *OptimizationJobRepository {
+	return &OptimizationJobRepository{pool: pool, tracer: tracer}
+}
+
+const optimizationJobColumns = `id, status, request_json, done, total, error, promoted_set,
+	        created_at, started_at, finished_at`
+
+// CreateJob inserts a queued job and returns its id.
+func (r *OptimizationJobRepository) CreateJob(ctx context.Context, job domain.OptimizationJob) (int64, error) {
+	_, span := r.tracer.Start(ctx, "optimization-job-repo.create-job")
+	defer span.End()
+
+	req, err := json.Marshal(job.Request)
+	if err != nil {
+		return 0, fmt.Errorf("encode optimization request: %w", err)
+	}
+	var id int64
+	err = r.pool.QueryRow(ctx,
+		`INSERT INTO optimization_jobs (status, request_json, total, created_at)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id`,
+		job.Status, string(req), job.Total, job.CreatedAt,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// SaveJob writes a job's progress, outcome and promotion.
+func (r *OptimizationJobRepository) SaveJob(ctx context.Context, job domain.OptimizationJob) error {
+	_, span := r.tracer.Start(ctx, "optimization-job-repo.save-job")
+	defer span.End()
+
+	var result *string
+	if job.Result != nil {
+		raw, err := json.Marshal(job.Result)
+		if err != nil {
+			return fmt.Errorf("encode optimization result: %w", err)
+		}
+		s := string(raw)
+		result = &s
+	}
+	_, err := r.pool.Exec(ctx,
+		`UPDATE optimization_jobs
+		 SET status = $2, done = $3, total = $4, error = $5, result_json = $6,
+		     promoted_set = $7, started_at = $8, finished_at = $9
+		 WHERE id = $1`,
+		job.ID, job.Status, job.Done, job.Total, job.Error, result,
+		job.PromotedSet, job.StartedAt, job.FinishedAt,
+	)
+	return err
+}
+
+// GetJob loads a job with its result. It returns nil when there is none.
+func (r *OptimizationJobRepository) GetJob(ctx context.Context, id int64) (*domain.OptimizationJob, error) {
+	_, span := r.tracer.Start(ctx, "optimization-job-repo.get-job")
+	defer span.End()
+
+	var result *string
+	job, err := scanOptimizationJob(r.pool.QueryRow(ctx,
+		`SELECT `+optimizationJobColumns+`, result_json
+		 FROM optimization_jobs
+		 WHERE id = $1`,
+		id,
+	), &result)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if result != nil {
+		job.Result = &domain.OptimizeResult{}
+		if err := json.Unmarshal([]byte(*result), job.Result); err != nil {
+			return nil, fmt.Errorf("decode optimization result %d: %w", id, err)
+		}
+	}
+	return &job, nil
+}
+
+// ListJobs returns the most recent jobs, newest first, without results.
+func (r *OptimizationJobRepository) ListJobs(ctx context.Context, limit int) ([]domain.OptimizationJob, error) {
+	_, span := r.tracer.Start(ctx, "optimization-job-repo.list-jobs")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+optimizationJobColumns+`
+		 FROM optimization_jobs
+		 ORDER BY id DESC
+		 LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]domain.OptimizationJob, 0)
+	for rows.Next() {
+		job, err := scanOptimizationJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, job)
+	}
+	return out, rows.Err()
+}
+
+func scanOptimizationJob(row pgx.Row, extra ...any) (domain.OptimizationJob, error) {
+	var job domain.OptimizationJob
+	var req string
+	dest := append([]any{
+		&job.ID, &job.Status, &req, &job.Done, &job.Total, &job.Error, &job.PromotedSet,
+		&job.CreatedAt, &job.StartedAt, &job.FinishedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return job, err
+	}
+	if err := json.Unmarshal([]byte(req), &job.Request); err != nil {
+		return job, fmt.Errorf("decode optimization request %d: %w", job.ID, err)
+	}
+	job.CreatedAt = job.CreatedAt.UTC()
+	if job.StartedAt != nil {
+		started := job.StartedAt.UTC()
+		job.StartedAt = &started
+	}
+	if job.FinishedAt != nil {
+		finished := job.FinishedAt.UTC()
+		job.FinishedAt = &finished
+	}
+	return job, nil
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestOptimizationJobCreateJobStoresRequest(t *testing.T) {
+	pool := &runStubPool{row: []any{int64(7)}}
+	repo := NewOptimizationJobRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	id, err := repo.CreateJob(context.Background(), domain.OptimizationJob{
+		Status:  domain.JobStatusQueued,
+		Request: domain.OptimizeRequest{Mode: "grid", Folds: 3, Objective: "sharpe"},
+		Total:   12,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != 7 {
+		t.Fatalf("expected id 7, got %d", id)
+	}
+	if !strings.Contains(pool.rowSQL, "INSERT INTO optimization_jobs") {
+		t.Fatalf("expected job insert, got %q", pool.rowSQL)
+	}
+	if req := pool.rowArgs[1].(string); !strings.Contains(req, `"objective":"sharpe"`) {
+		t.Fatalf("expected request json, got %s", req)
+	}
+}
+
+func TestOptimizationJobSaveJobEncodesResult(t *testing.T) {
+	pool := &runStubPool{}
+	repo := NewOptimizationJobRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	err := repo.SaveJob(context.Background(), domain.OptimizationJob{
+		ID:          7,
+		Status:      domain.JobStatusSucceeded,
+		Done:        12,
+		Total:       12,
+		Result:      &domain.OptimizeResult{Objective: "sharpe", Candidates: 12},
+		PromotedSet: 3,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(pool.execSQL, "UPDATE optimization_jobs") {
+		t.Fatalf("expected job update, got %q", pool.execSQL)
+	}
+	result := pool.execArgs[5].(*string)
+	if result == nil || !strings.Contains(*result, `"candidates":12`) {
+		t.Fatalf("expected result json, got %v", result)
+	}
+	if pool.execArgs[6] != int64(3) {
+		t.Fatalf("expected promoted set 3, got %v", pool.execArgs[6])
+	}
+}
+
+func TestOptimizationJobGetJobDecodesResult(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	pool := &runStubPool{row: []any{
+		int64(7), domain.JobStatusSucceeded, `{"mode":"grid","folds":3}`, 12, 12, "", int64(0),
+		created, created, created.Add(time.Minute), `{"objective":"sharpe","candidates":12}`,
+	}}
+	repo := NewOptimizationJobRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	job, err := repo.GetJob(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if job == nil || job.Request.Folds != 3 {
+		t.Fatalf("expected decoded request, got %+v", job)
+	}
+	if job.Result == nil || job.Result.Candidates != 12 {
+		t.Fatalf("expected decoded result, got %+v", job.Result)
+	}
+	if job.FinishedAt == nil || !job.FinishedAt.Equal(created.Add(time.Minute)) {
+		t.Fatalf("expected finished time, got %v", job.FinishedAt)
+	}
+}
+
+func TestOptimizationJobGetJobReturnsNilWhenMissing(t *testing.T) {
+	pool := &runStubPool{rowErr: pgx.ErrNoRows}
+	repo := NewOptimizationJobRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	job, err := repo.GetJob(context.Background(), 9)
+	if err != nil || job != nil {
+		t.Fatalf("expected nil job and error, got %+v, %v", job, err)
+	}
+}
+
+func TestOptimizationJobListJobsNewestFirst(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	pool := &runStubPool{rowsData: [][]any{
+		{int64(8), domain.JobStatusRunning, `{"mode":"random"}`, 3, 10, "", int64(0), created, created, nil},
+		{int64(7), domain.JobStatusFailed, `{"mode":"grid"}`, 1, 10, "boom", int64(0), created, nil, created},
+	}}
+	repo := NewOptimizationJobRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	jobs, err := repo.ListJobs(context.Background(), 20)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(jobs) != 2 || jobs[0].ID != 8 || jobs[1].Error != "boom" {
+		t.Fatalf("unexpected jobs: %+v", jobs)
+	}
+	if jobs[0].FinishedAt != nil || jobs[1].StartedAt != nil {
+		t.Fatalf("expected unset times to stay nil: %+v", jobs)
+	}
+	if pool.queryArgs[0] != 20 {
+		t.Fatalf("expected limit 20, got %v", pool.queryArgs)
+	}
+}
@@ -0,0 +1,326 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"bug-free-umbrella/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// PaperRepository persists paper trading accounts, their positions, order
+// history and signal subscriptions.
+type PaperRepository struct {
+	pool   PgxTxPool
+	tracer trace.Tracer
+}
+
+func NewPaperRepository(pool PgxTxPool, tracer trace.Tracer) *PaperRepository {
+	return &PaperRepository{pool: pool, tracer: tracer}
+}
+
+// paperFlatQuantity is the holding below which a sold-down position is
+// treated as closed.
+const paperFlatQuantity = 1e-9
+
+const paperAccountColumns = `id, name, owner, starting_balance, cash, slippage_bps, created_at`
+
+// CreateAccount inserts an account funded with its starting balance and
+// returns it with its id.
+func (r *PaperRepository) CreateAccount(ctx context.Context, acct domain.PaperAccount) (*domain.PaperAccount, error) {
+	_, span := r.tracer.Start(ctx, "paper-repo.create-account")
+	defer span.End()
+
+	out, err := scanPaperAccount(r.pool.QueryRow(ctx,
+		`INSERT INTO paper_accounts (name, owner, starting_balance, cash, slippage_bps)
+		 VALUES ($1, $2, $3, $3, $4)
+		 RETURNING `+paperAccountColumns,
+		acct.Name, acct.Owner, acct.StartingBalance, acct.SlippageBps,
+	))
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAccount returns nil when the account does not exist.
+func (r *PaperRepository) GetAccount(ctx context.Context, id int64) (*domain.PaperAccount, error) {
+	_, span := r.tracer.Start(ctx, "paper-repo.get-account")
+	defer span.End()
+
+	return r.getAccount(ctx, `SELECT `+paperAccountColumns+` FROM paper_accounts WHERE id = $1`, id)
+}
+
+// GetAccountByOwner returns nil when owner has no account.
+func (r *PaperRepository) GetAccountByOwner(ctx context.Context, owner string) (*domain.PaperAccount, error) {
+	_, span := r.tracer.Start(ctx, "paper-repo.get-account-by-owner")
+	defer span.End()
+
+	return r.getAccount(ctx, `SELECT `+paperAccountColumns+` FROM paper_accounts WHERE owner = $1`, owner)
+}
+
+func (r *PaperRepository) getAccount(ctx context.Context, sql string, arg any) (*domain.PaperAccount, error) {
+	acct, err := scanPaperAccount(r.pool.QueryRow(ctx, sql, arg))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &acct, nil
+}
+
+func (r *PaperRepository) ListAccounts(ctx context.Context) ([]domain.PaperAccount, error) {
+	_, span := r.tracer.Start(ctx, "paper-repo.list-accounts")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx, `SELECT `+paperAccountColumns+` FROM paper_accounts ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]domain.PaperAccount, 0)
+	for rows.Next() {
+		acct, err := scanPaperAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, acct)
+	}
+	return out, rows.Err()
+}
+
+func scanPaperAccount(row pgx.Row) (domain.PaperAccount, error) {
+	var a domain.PaperAccount
+	err := row.Scan(&a.ID, &a.Name, &a.Owner, &a.StartingBalance, &a.Cash, &a.SlippageBps, &a.CreatedAt)
+	a.CreatedAt = a.CreatedAt.UTC()
+	return a, err
+}
+
+// ListPositions returns every position row of an account, including flat
+// ones that only carry realised PnL.
+func (r *PaperRepository) ListPositions(ctx context.Context, accountID int64) ([]domain.PaperPosition, error) {
+	_, span := r.tracer.Start(ctx, "paper-repo.list-positions")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT account_id, symbol, quantity, avg_price, realized_pnl, updated_at
+		 FROM paper_positions
+		 WHERE account_id = $1
+		 ORDER BY symbol`,
+		accountID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]domain.PaperPosition, 0)
+	for rows.Next() {
+		var p domain.PaperPosition
+		if err := rows.Scan(&p.AccountID, &p.Symbol, &p.Quantity, &p.AvgPrice, &p.RealizedPnL, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		p.UpdatedAt = p.UpdatedAt.UTC()
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// ListOrders returns an account's most recent orders first.
+func (r *PaperRepository) ListOrders(ctx context.Context, accountID int64, limit int) ([]domain.PaperOrder, error) {
+	_, span := r.tracer.Start(ctx, "paper-repo.list-orders")
+	defer span.End()
+
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 500 {
+		limit = 500
+	}
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, account_id, symbol, side, quantity, market_price, fill_price,
+		        notional, realized_pnl, source, signal_id, created_at
+		 FROM paper_orders
+		 WHERE account_id = $1
+		 ORDER BY created_at DESC, id DESC
+		 LIMIT $2`,
+		accountID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]domain.PaperOrder, 0)
+	for rows.Next() {
+		var o domain.PaperOrder
+		var side string
+		if err := rows.Scan(
+			&o.ID, &o.AccountID, &o.Symbol, &side, &o.Quantity, &o.MarketPrice, &o.FillPrice,
+			&o.Notional, &o.RealizedPnL, &o.Source, &o.SignalID, &o.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		o.Side = domain.PaperSide(side)
+		o.CreatedAt = o.CreatedAt.UTC()
+		out = append(out, o)
+	}
+	return out, rows.Err()
+}
+
+// RecordFill applies a filled order in one transaction. Cash and the
+// position move by the order's amounts instead of being overwritten, and each
+// change is guarded: a buy cannot spend more cash than the account has and a
+// sell cannot exceed the units held, even if another process filled an order
+// on the account in the meantime. A sell's realised PnL is taken against the
+// locked average price. It returns nil when a guard fails, otherwise the
+// order with its id, creation time and realised PnL.
+func (r *PaperRepository) RecordFill(ctx context.Context, order domain.PaperOrder) (*domain.PaperOrder, error) {
+	_, span := r.tracer.Start(ctx, "paper-repo.record-fill")
+	defer span.End()
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	cash := order.Notional
+	if order.Side == domain.PaperBuy {
+		cash = -cash
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO paper_positions (account_id, symbol, quantity, avg_price, realized_pnl, updated_at)
+			 VALUES ($1, $2, $3, $4, 0, NOW())
+			 ON CONFLICT (account_id, symbol) DO UPDATE
+			 SET avg_price = (paper_positions.quantity * paper_positions.avg_price + EXCLUDED.quantity * EXCLUDED.avg_price)
+			                 / (paper_positions.quantity + EXCLUDED.quantity),
+			     quantity = paper_positions.quantity + EXCLUDED.quantity,
+			     updated_at = EXCLUDED.updated_at`,
+			order.AccountID, order.Symbol, order.Quantity, order.FillPrice,
+		); err != nil {
+			return nil, err
+		}
+	} else {
+		err := tx.QueryRow(ctx,
+			`WITH held AS (
+			     SELECT quantity, avg_price FROM paper_positions
+			     WHERE account_id = $1 AND symbol = $2 AND quantity >= $3
+			     FOR UPDATE
+			 )
+			 UPDATE paper_positions p
+			 SET quantity = CASE WHEN held.quantity - $3 <= $5 THEN 0 ELSE held.quantity - $3 END,
+			     avg_price = CASE WHEN held.quantity - $3 <= $5 THEN 0 ELSE held.avg_price END,
+			     realized_pnl = p.realized_pnl + ($4 - held.avg_price) * $3,
+			     updated_at = NOW()
+			 FROM held
+			 WHERE p.account_id = $1 AND p.symbol = $2
+			 RETURNING ($4 - held.avg_price) * $3`,
+			order.AccountID, order.Symbol, order.Quantity, order.FillPrice, paperFlatQuantity,
+		).Scan(&order.RealizedPnL)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE paper_accounts SET cash = cash + $2 WHERE id = $1 AND cash + $2 >= 0`,
+		order.AccountID, cash,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, nil
+	}
+
+	err = tx.QueryRow(ctx,
+		`INSERT INTO paper_orders (
+		     account_id, symbol, side, quantity, market_price, fill_price,
+		     notional, realized_pnl, source, signal_id)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		 RETURNING id, created_at`,
+		order.AccountID, order.Symbol, string(order.Side), order.Quantity, order.MarketPrice,
+		order.FillPrice, order.Notional, order.RealizedPnL, order.Source, order.SignalID,
+	).Scan(&order.ID, &order.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	order.CreatedAt = order.CreatedAt.UTC()
+	return &order, nil
+}
+
+// CreateSubscription stores sub and returns it with its id.
+func (r *PaperRepository) CreateSubscription(ctx context.Context, sub domain.PaperSubscription) (*domain.PaperSubscription, error) {
+	_, span := r.tracer.Start(ctx, "paper-repo.create-subscription")
+	defer span.End()
+
+	err := r.pool.QueryRow(ctx,
+		`INSERT INTO paper_subscriptions (account_id, symbol, indicator, max_risk, trade_notional)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING id, created_at`,
+		sub.AccountID, sub.Symbol, sub.Indicator, int16(sub.MaxRisk), sub.TradeNotional,
+	).Scan(&sub.ID, &sub.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	sub.CreatedAt = sub.CreatedAt.UTC()
+	return &sub, nil
+}
+
+// ListSubscriptions returns an account's subscriptions; accountID 0 returns
+// every account's.
+func (r *PaperRepository) ListSubscriptions(ctx context.Context, accountID int64) ([]domain.PaperSubscription, error) {
+	_, span := r.tracer.Start(ctx, "paper-repo.list-subscriptions")
+	defer span.End()
+
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, account_id, symbol, indicator, max_risk, trade_notional, created_at
+		 FROM paper_subscriptions
+		 WHERE $1::BIGINT = 0 OR account_id = $1
+		 ORDER BY id`,
+		accountID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]domain.PaperSubscription, 0)
+	for rows.Next() {
+		var s domain.PaperSubscription
+		var risk int16
+		if err := rows.Scan(&s.ID, &s.AccountID, &s.Symbol, &s.Indicator, &risk, &s.TradeNotional, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		s.MaxRisk = domain.RiskLevel(risk)
+		s.CreatedAt = s.CreatedAt.UTC()
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// DeleteSubscription reports whether the subscription existed on the account.
+func (r *PaperRepository) DeleteSubscription(ctx context.Context, accountID, id int64) (bool, error) {
+	_, span := r.tracer.Start(ctx, "paper-repo.delete-subscription")
+	defer span.End()
+
+	tag, err := r.pool.Exec(ctx,
+		`DELETE FROM paper_subscriptions WHERE id = $1 AND account_id = $2`,
+		id, accountID,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestPaperCreateAccountFundsCash(t *testing.T) {
+	created := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	pool := &runStubPool{row: []any{int64(4), "alpha", "", 5000.0, 5000.0, 10.0, created}}
+	repo := NewPaperRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	acct, err := repo.CreateAccount(context.Background(), domain.PaperAccount{Name: "alpha", StartingBalance: 5000, SlippageBps: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if acct.ID != 4 || acct.Cash != 5000 || acct.SlippageBps != 10 {
+		t.Fatalf("unexpected account: %+v", acct)
+	}
+	if !strings.Contains(pool.rowSQL, "VALUES ($1, $2, $3, $3, $4)") {
+		t.Fatalf("expected cash to start at the starting balance, got %q", pool.rowSQL)
+	}
+}
+
+func TestPaperGetAccountByOwnerNotFound(t *testing.T) {
+	pool := &runStubPool{rowErr: pgx.ErrNoRows}
+	repo := NewPaperRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	acct, err := repo.GetAccountByOwner(context.Background(), "telegram:1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if acct != nil {
+		t.Fatalf("expected nil account, got %+v", acct)
+	}
+	if pool.rowArgs[0] != "telegram:1" {
+		t.Fatalf("unexpected args: %v", pool.rowArgs)
+	}
+}
+
+func TestPaperRecordFillAppliesGuardedDeltas(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	pool := &runStubPool{row: []any{int64(9), created}, execTag: pgconn.NewCommandTag("UPDATE 1")}
+	repo := NewPaperRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	signalID := int64(77)
+	order, err := repo.RecordFill(context.Background(),
+		domain.PaperOrder{AccountID: 2, Symbol: "BTC", Side: domain.PaperBuy, Quantity: 0.1, FillPrice: 50025, Notional: 5002.5, Source: domain.PaperSourceSignal, SignalID: &signalID},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if order == nil || order.ID != 9 || !order.CreatedAt.Equal(created) {
+		t.Fatalf("unexpected order: %+v", order)
+	}
+	if !pool.tx.committed || len(pool.tx.sqls) != 3 {
+		t.Fatalf("expected three statements in a committed transaction, got %+v", pool.tx)
+	}
+	for i, want := range []string{"paper_positions.quantity + EXCLUDED.quantity", "cash = cash + $2 WHERE id = $1 AND cash + $2 >= 0", "INSERT INTO paper_orders"} {
+		if !strings.Contains(pool.tx.sqls[i], want) {
+			t.Fatalf("expected %q in statement %d, got %s", want, i, pool.tx.sqls[i])
+		}
+	}
+	if pool.rowArgs[2].(string) != "buy" || *pool.rowArgs[9].(*int64) != 77 {
+		t.Fatalf("unexpected order args: %v", pool.rowArgs)
+	}
+}
+
+func TestPaperRecordFillRefusesWhenGuardsFail(t *testing.T) {
+	tracer := trace.NewNoopTracerProvider().Tracer("test")
+
+	// Another fill spent the cash first.
+	pool := &runStubPool{execTag: pgconn.NewCommandTag("UPDATE 0")}
+	order, err := NewPaperRepository(pool, tracer).RecordFill(context.Background(),
+		domain.PaperOrder{AccountID: 2, Symbol: "BTC", Side: domain.PaperBuy, Quantity: 0.1, FillPrice: 50000, Notional: 5000},
+	)
+	if err != nil || order != nil {
+		t.Fatalf("expected nil, nil; got %+v, %v", order, err)
+	}
+	if pool.tx.committed || !pool.tx.rolledBack || pool.execArgs[1].(float64) != -5000 {
+		t.Fatalf("expected a rolled back buy debiting 5000, got %+v %v", pool.tx, pool.execArgs)
+	}
+
+	// Another fill sold the units first.
+	pool = &runStubPool{rowErr: pgx.ErrNoRows}
+	order, err = NewPaperRepository(pool, tracer).RecordFill(context.Background(),
+		domain.PaperOrder{AccountID: 2, Symbol: "BTC", Side: domain.PaperSell, Quantity: 0.1, FillPrice: 50000, Notional: 5000},
+	)
+	if err != nil || order != nil || len(pool.tx.sqls) != 1 || !pool.tx.rolledBack {
+		t.Fatalf("expected the sell to stop at the position guard, got %+v, %v, %+v", order, err, pool.tx)
+	}
+	if !strings.Contains(pool.tx.sqls[0], "quantity >= $3") || !strings.Contains(pool.tx.sqls[0], "FOR UPDATE") {
+		t.Fatalf("expected a guarded, locking sell: %s", pool.tx.sqls[0])
+	}
+}
+
+func TestPaperRecordFillRealisesAgainstLockedAverage(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	pool := &runStubPool{rowQueue: [][]any{{250.0}, {int64(10), created}}, execTag: pgconn.NewCommandTag("UPDATE 1")}
+	repo := NewPaperRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	order, err := repo.RecordFill(context.Background(),
+		domain.PaperOrder{AccountID: 2, Symbol: "BTC", Side: domain.PaperSell, Quantity: 0.1, FillPrice: 52500, Notional: 5250, RealizedPnL: 1},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if order == nil || order.ID != 10 || order.RealizedPnL != 250 || pool.rowArgs[7].(float64) != 250 {
+		t.Fatalf("expected the order to carry the locked realised PnL, got %+v %v", order, pool.rowArgs)
+	}
+	if pool.execArgs[1].(float64) != 5250 || !pool.tx.committed {
+		t.Fatalf("expected a committed 5250 credit, got %v %+v", pool.execArgs, pool.tx)
+	}
+}
+
+func TestPaperListOrdersScansSignalID(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	pool := &runStubPool{rowsData: [][]any{
+		{int64(2), int64(1), "ETH", "sell", 1.5, 3000.0, 2998.5, 4497.75, 120.0, "signal", int64(5), created},
+		{int64(1), int64(1), "ETH", "buy", 1.5, 2900.0, 2901.45, 4352.18, 0.0, "manual", nil, created},
+	}}
+	repo := NewPaperRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	orders, err := repo.ListOrders(context.Background(), 1, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(orders) != 2 || orders[0].Side != domain.PaperSell || orders[0].SignalID == nil || *orders[0].SignalID != 5 || orders[1].SignalID != nil {
+		t.Fatalf("unexpected orders: %+v", orders)
+	}
+	if pool.queryArgs[1].(int) != 50 {
+		t.Fatalf("expected default limit 50, got %v", pool.queryArgs[1])
+	}
+}
+
+func TestPaperListSubscriptions(t *testing.T) {
+	created := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	pool := &runStubPool{rowsData: [][]any{
+		{int64(1), int64(3), "BTC", "rsi", 2, 250.0, created},
+	}}
+	repo := NewPaperRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	subs, err := repo.ListSubscriptions(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(subs) != 1 || subs[0].MaxRisk != domain.RiskLevel2 || subs[0].TradeNotional != 250 {
+		t.Fatalf("unexpected subscriptions: %+v", subs)
+	}
+}
+
+func TestPaperDeleteSubscription(t *testing.T) {
+	pool := &runStubPool{execTag: pgconn.NewCommandTag("DELETE 1")}
+	repo := NewPaperRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	ok, err := repo.DeleteSubscription(context.Background(), 3, 8)
+	if err != nil || !ok {
+		t.Fatalf("expected delete, got %v %v", ok, err)
+	}
+	if pool.execArgs[0].(int64) != 8 || pool.execArgs[1].(int64) != 3 {
+		t.Fatalf("unexpected args: %v", pool.execArgs)
+	}
+
+	pool.execTag = pgconn.NewCommandTag("DELETE 0")
+	if ok, _ := repo.DeleteSubscription(context.Background(), 3, 8); ok {
+		t.Fatal("expected missing subscription to report false")
+	}
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+
+	"bug-free-umbrella/internal/domain"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// PriceAlertRepository persists users' price alert rules and their firing
+// state.
+type PriceAlertRepository struct {
+	pool   PgxPool
+	tracer trace.Tracer
+}
+
+func NewPriceAlertRepository(pool PgxPool, tracer trace.Tracer) *PriceAlertRepository {
+	return &PriceAlertRepository{pool: pool, tracer: tracer}
+}
+
+const priceAlertColumns = `id, chat_id, symbol, type, direction, threshold, window_mins, hysteresis,
+	recurring, active, armed, trigger_count, last_triggered_at, created_at`
+
+// CreateRule inserts a rule and returns it with its id.
+func (r *PriceAlertRepository) CreateRule(ctx context.Context, rule domain.PriceAlertRule) (*domain.PriceAlertRule, error) {
+	_, span := r.tracer.Start(ctx, "price-alert-repo.create-rule")
+	defer span.End()
+
+	err := r.pool.QueryRow(ctx,
+		`INSERT INTO price_alert_rules (chat_id, symbol, type, direction, threshold, window_mins, hysteresis, recurring, active, armed)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		 RETURNING id, created_at`,
+		rule.ChatID, rule.Symbol, string(rule.Type), string(rule.Direction), rule.Threshold, rule.WindowMins,
+		rule.Hysteresis, rule.Recurring, rule.Active, rule.Armed,
+	).Scan(&rule.ID, &rule.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	rule.CreatedAt = rule.CreatedAt.UTC()
+	return &rule, nil
+}
+
+// ListRules returns all of a user's rules, including fired one-shot rules,
+// oldest first.
+func (r *PriceAlertRepository) ListRules(ctx context.Context, chatID int64) ([]domain.PriceAlertRule, error) {
+	_, span := r.tracer.Start(ctx, "price-alert-repo.list-rules")
+	defer span.End()
+
+	return r.query(ctx,
+		`SELECT `+priceAlertColumns+` FROM price_alert_rules WHERE chat_id = $1 ORDER BY id`,
+		chatID,
+	)
+}
+
+// ListActiveRules returns every active rule across users.
+func (r *PriceAlertRepository) ListActiveRules(ctx context.Context) ([]domain.PriceAlertRule, error) {
+	_, span := r.tracer.Start(ctx, "price-alert-repo.list-active-rules")
+	defer span.End()
+
+	return r.query(ctx, `SELECT `+priceAlertColumns+` FROM price_alert_rules WHERE active ORDER BY id`)
+}
+
+func (r *PriceAlertRepository) query(ctx context.Context, sql string, args ...any) ([]domain.PriceAlertRule, error) {
+	rows, err := r.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]domain.PriceAlertRule, 0)
+	for rows.Next() {
+		var rule domain.PriceAlertRule
+		var kind, direction string
+		if err := rows.Scan(&rule.ID, &rule.ChatID, &rule.Symbol, &kind, &direction, &rule.Threshold,
+			&rule.WindowMins, &rule.Hysteresis, &rule.Recurring, &rule.Active, &rule.Armed,
+			&rule.TriggerCount, &rule.LastTriggeredAt, &rule.CreatedAt); err != nil {
+			return nil, err
+		}
+		rule.Type = domain.PriceAlertType(kind)
+		rule.Direction = domain.PriceAlertDirection(direction)
+		if rule.LastTriggeredAt != nil {
+			at := rule.LastTriggeredAt.UTC()
+			rule.LastTriggeredAt = &at
+		}
+		rule.CreatedAt = rule.CreatedAt.UTC()
+		out = append(out, rule)
+	}
+	return out, rows.Err()
+}
+
+// DeleteRule reports whether the user had a rule with that id.
+func (r *PriceAlertRepository) DeleteRule(ctx context.Context, chatID, id int64) (bool, error) {
+	_, span := r.tracer.Start(ctx, "price-alert-repo.delete-rule")
+	defer span.End()
+
+	tag, err := r.pool.Exec(ctx, `DELETE FROM price_alert_rules WHERE chat_id = $1 AND id = $2`, chatID, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// UpdateRuleState stores a rule's firing state after evaluation. Every
+// transition flips armed, so the update only applies while the stored rule
+// is still active and in the opposite state; it reports false when another
+// evaluator got there first, and the caller must not notify.
+func (r *PriceAlertRepository) UpdateRuleState(ctx context.Context, rule domain.PriceAlertRule) (bool, error) {
+	_, span := r.tracer.Start(ctx, "price-alert-repo.update-rule-state")
+	defer span.End()
+
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE price_alert_rules
+		 SET active = $2, armed = $3, trigger_count = $4, last_triggered_at = $5
+		 WHERE id = $1 AND active AND armed <> $3`,
+		rule.ID, rule.Active, rule.Armed, rule.TriggerCount, rule.LastTriggeredAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
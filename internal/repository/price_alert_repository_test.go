@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestPriceAlertCreateRuleReturnsID(t *testing.T) {
+	created := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	pool := &runStubPool{row: []any{int64(4), created}}
+	repo := NewPriceAlertRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	rule, err := repo.CreateRule(context.Background(), domain.PriceAlertRule{
+		ChatID: 7, Symbol: "ETH", Type: domain.PriceAlertCross, Direction: domain.PriceAlertUp,
+		Threshold: 4000, Hysteresis: 0.01, Active: true, Armed: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rule.ID != 4 || !rule.CreatedAt.Equal(created) {
+		t.Fatalf("unexpected rule: %+v", rule)
+	}
+	if pool.rowArgs[2] != "cross" || pool.rowArgs[3] != "up" {
+		t.Fatalf("expected type and direction stored as text, got %v", pool.rowArgs)
+	}
+}
+
+func TestPriceAlertListScansNullableTrigger(t *testing.T) {
+	at := time.Date(2026, 5, 2, 9, 0, 0, 0, time.UTC)
+	fired := at.Add(time.Hour)
+	pool := &runStubPool{rowsData: [][]any{
+		{int64(1), int64(7), "SOL", "move", "down", 5.0, 60, 0.01, true, true, false, 2, fired, at},
+		{int64(2), int64(7), "BTC", "cross", "up", 90000.0, 0, 0.01, false, true, true, 0, nil, at},
+	}}
+	repo := NewPriceAlertRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	rules, err := repo.ListRules(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rules) != 2 || rules[0].Type != domain.PriceAlertMove || rules[0].Direction != domain.PriceAlertDown {
+		t.Fatalf("unexpected rules: %+v", rules)
+	}
+	if rules[0].LastTriggeredAt == nil || !rules[0].LastTriggeredAt.Equal(fired) || rules[0].TriggerCount != 2 {
+		t.Fatalf("unexpected trigger state: %+v", rules[0])
+	}
+	if rules[1].LastTriggeredAt != nil || !rules[1].Armed {
+		t.Fatalf("unexpected rule: %+v", rules[1])
+	}
+	if pool.queryArgs[0] != int64(7) {
+		t.Fatalf("unexpected args: %v", pool.queryArgs)
+	}
+
+	if _, err := repo.ListActiveRules(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pool.queryArgs) != 0 {
+		t.Fatalf("expected no args for active rules, got %v", pool.queryArgs)
+	}
+}
+
+func TestPriceAlertDeleteAndUpdate(t *testing.T) {
+	pool := &runStubPool{execTag: pgconn.NewCommandTag("DELETE 1")}
+	repo := NewPriceAlertRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	ok, err := repo.DeleteRule(context.Background(), 7, 3)
+	if err != nil || !ok {
+		t.Fatalf("expected delete, got %v %v", ok, err)
+	}
+	if !strings.Contains(pool.execSQL, "chat_id = $1 AND id = $2") {
+		t.Fatalf("expected delete scoped to the user, got %q", pool.execSQL)
+	}
+
+	fired := time.Now().UTC()
+	pool.execTag = pgconn.NewCommandTag("UPDATE 1")
+	ok, err = repo.UpdateRuleState(context.Background(), domain.PriceAlertRule{ID: 3, Active: false, Armed: false, TriggerCount: 1, LastTriggeredAt: &fired})
+	if err != nil || !ok {
+		t.Fatalf("expected the transition applied, got %v %v", ok, err)
+	}
+	if pool.execArgs[0] != int64(3) || pool.execArgs[3] != 1 {
+		t.Fatalf("unexpected update args: %v", pool.execArgs)
+	}
+	if !strings.Contains(pool.execSQL, "WHERE id = $1 AND active AND armed <> $3") {
+		t.Fatalf("expected a conditional state update, got %q", pool.execSQL)
+	}
+
+	pool.execTag = pgconn.NewCommandTag("UPDATE 0")
+	if ok, err := repo.UpdateRuleState(context.Background(), domain.PriceAlertRule{ID: 3}); err != nil || ok {
+		t.Fatalf("expected a lost race to report false, got %v %v", ok, err)
+	}
+}
@@ -127,7 +127,9 @@ func (r *SignalImageRepository) ListRetryCandidates(
 	}
 
 	rows, err := r.pool.Query(ctx, `
-SELECT s.id, s.symbol, s.interval, s.indicator, s.direction, s.risk, s.timestamp, s.details
+SELECT s.id, s.symbol, s.interval, s.indicator, s.direction, s.risk, s.timestamp, s.details,
+       COALESCE(s.entry_price, 0), COALESCE(s.stop_price, 0), s.targets_json,
+       COALESCE(s.reward_risk, 0), s.level_basis
 FROM signal_images si
 JOIN signals s ON s.id = si.signal_id
 WHERE si.render_status = 'failed'
@@ -146,6 +148,8 @@ LIMIT $2
 		var s domain.Signal
 		var direction string
 		var risk int16
+		var entry, stop, rewardRisk float64
+		var targetsJSON, basis string
 		if err := rows.Scan(
 			&s.ID,
 			&s.Symbol,
@@ -155,12 +159,18 @@ LIMIT $2
 			&risk,
 			&s.Timestamp,
 			&s.Details,
+			&entry,
+			&stop,
+			&targetsJSON,
+			&rewardRisk,
+			&basis,
 		); err != nil {
 			return nil, err
 		}
 		s.Direction = domain.SignalDirection(direction)
 		s.Risk = domain.RiskLevel(risk)
 		s.Timestamp = s.Timestamp.UTC()
+		s.Levels = decodeSignalLevels(entry, stop, targetsJSON, rewardRisk, basis)
 		out = append(out, s)
 	}
 	return out, rows.Err()
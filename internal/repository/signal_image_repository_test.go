@@ -49,6 +49,7 @@ func TestSignalImageRepositoryListRetryCandidates(t *testing.T) {
 	pool := &imageRepoStubPool{
 		rowsData: [][]any{{
 			int64(31), "BTC", "1h", domain.IndicatorRSI, string(domain.DirectionLong), int16(domain.RiskLevel2), now, "retry me",
+			float64(100), float64(95), "[110]", float64(2), domain.LevelBasisSwing,
 		}},
 	}
 	repo := NewSignalImageRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
@@ -60,6 +61,9 @@ func TestSignalImageRepositoryListRetryCandidates(t *testing.T) {
 	if len(list) != 1 || list[0].ID != 31 || list[0].Symbol != "BTC" {
 		t.Fatalf("unexpected retry candidates: %+v", list)
 	}
+	if list[0].Levels == nil || list[0].Levels.Stop != 95 {
+		t.Fatalf("expected levels on retry candidate, got %+v", list[0].Levels)
+	}
 }
 
 func TestSignalImageRepositoryDeleteExpired(t *testing.T) {
@@ -134,6 +138,8 @@ func (r *imageRepoStubRow) Scan(dest ...any) error {
 			default:
 				return fmt.Errorf("unexpected int type %T", r.values[i])
 			}
+		case *float64:
+			*ptr = r.values[i].(float64)
 		case *time.Time:
 			*ptr = r.values[i].(time.Time)
 		case *[]byte:
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"bug-free-umbrella/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SignalParamsRepository stores signal parameter sets promoted into the live
+// engine. At most one set is active; promoting a new one retires the old.
+type SignalParamsRepository struct {
+	pool   PgxTxPool
+	tracer trace.Tracer
+}
+
+func NewSignalParamsRepository(pool PgxTxPool, tracer trace.Tracer) *SignalParamsRepository {
+	return &SignalParamsRepository{pool: pool, tracer: tracer}
+}
+
+// SaveActive retires the active set and inserts set as the new active one in
+// a single transaction.
+func (r *SignalParamsRepository) SaveActive(ctx context.Context, set domain.SignalParamSet) (int64, error) {
+	_, span := r.tracer.Start(ctx, "signal-params-repo.save-active")
+	defer span.End()
+
+	params, err := json.Marshal(set.Params)
+	if err != nil {
+		return 0, fmt.Errorf("encode signal params: %w", err)
+	}
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `UPDATE signal_param_sets SET active = FALSE WHERE active`); err != nil {
+		return 0, err
+	}
+	var id int64
+	err = tx.QueryRow(ctx,
+		`INSERT INTO signal_param_sets (params_json, source, active)
+		 VALUES ($1, $2, TRUE)
+		 RETURNING id`,
+		string(params), set.Source,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *SignalParamsRepository) GetActive(ctx context.Context) (*domain.SignalParamSet, error) {
+	_, span := r.tracer.Start(ctx, "signal-params-repo.get-active")
+	defer span.End()
+
+	var (
+		set    domain.SignalParamSet
+		params string
+	)
+	err := r.pool.QueryRow(ctx,
+		`SELECT id, params_json, source, promoted_at
+		 FROM signal_param_sets
+		 WHERE active
+		 ORDER BY promoted_at DESC
+		 LIMIT 1`,
+	).Scan(&set.ID, &params, &set.Source, &set.PromotedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(params), &set.Params); err != nil {
+		return nil, fmt.Errorf("decode signal params %d: %w", set.ID, err)
+	}
+	return &set, nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSignalParamsSaveActiveRetiresPrevious(t *testing.T) {
+	pool := &runStubPool{row: []any{int64(4)}}
+	repo := NewSignalParamsRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	id, err := repo.SaveActive(context.Background(), domain.SignalParamSet{
+		Params: domain.SignalParams{RSIPeriod: 10, MACDFast: 8},
+		Source: "optimization job 2",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != 4 {
+		t.Fatalf("expected id 4, got %d", id)
+	}
+	if params := pool.rowArgs[0].(string); !strings.Contains(params, `"rsi_period":10`) {
+		t.Fatalf("expected params json, got %s", params)
+	}
+}
+
+func TestSignalParamsSaveActiveTwiceRetiresBeforeEachInsert(t *testing.T) {
+	pool := &runStubPool{rowQueue: [][]any{{int64(4)}, {int64(5)}}}
+	repo := NewSignalParamsRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	for i, want := range []int64{4, 5} {
+		id, err := repo.SaveActive(context.Background(), domain.SignalParamSet{Source: "optimization job"})
+		if err != nil || id != want {
+			t.Fatalf("promotion %d: expected id %d, got %d, %v", i+1, want, id, err)
+		}
+		// The retirement must be its own statement ahead of the insert: a CTE
+		// that the insert does not reference runs after it and trips the
+		// unique index on the active set.
+		if !pool.tx.committed || len(pool.tx.sqls) != 2 ||
+			!strings.HasPrefix(pool.tx.sqls[0], "UPDATE signal_param_sets SET active = FALSE") ||
+			!strings.Contains(pool.tx.sqls[1], "INSERT INTO signal_param_sets") || strings.Contains(pool.tx.sqls[1], "UPDATE") {
+			t.Fatalf("promotion %d: expected retire then insert in one committed transaction, got %+v", i+1, pool.tx)
+		}
+	}
+}
+
+func TestSignalParamsGetActive(t *testing.T) {
+	promoted := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	pool := &runStubPool{row: []any{int64(4), `{"rsi_period":10,"rsi_oversold":25}`, "optimization job 2", promoted}}
+	repo := NewSignalParamsRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	set, err := repo.GetActive(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if set == nil || set.ID != 4 || set.Params.RSIPeriod != 10 || set.Params.RSIOversold != 25 || !set.PromotedAt.Equal(promoted) {
+		t.Fatalf("unexpected set: %+v", set)
+	}
+}
+
+func TestSignalParamsGetActiveNone(t *testing.T) {
+	pool := &runStubPool{rowErr: pgx.ErrNoRows}
+	repo := NewSignalParamsRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	set, err := repo.GetActive(context.Background())
+	if err != nil || set != nil {
+		t.Fatalf("expected nil, nil; got %+v, %v", set, err)
+	}
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -31,12 +32,19 @@ func (r *SignalRepository) InsertSignals(ctx context.Context, signals []domain.S
 
 	batch := &pgx.Batch{}
 	for _, s := range signals {
+		entry, stop, rewardRisk, targetsJSON, basis := encodeSignalLevels(s.Levels)
 		batch.Queue(
-			`INSERT INTO signals (symbol, interval, indicator, direction, risk, timestamp, details)
-			 VALUES ($1, $2, $3, $4, $5, $6, $7)
+			`INSERT INTO signals (symbol, interval, indicator, direction, risk, timestamp, details,
+			                      entry_price, stop_price, targets_json, reward_risk, level_basis)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 			 ON CONFLICT (symbol, interval, indicator, timestamp, direction) DO UPDATE SET
 			     risk = EXCLUDED.risk,
-			     details = EXCLUDED.details
+			     details = EXCLUDED.details,
+			     entry_price = EXCLUDED.entry_price,
+			     stop_price = EXCLUDED.stop_price,
+			     targets_json = EXCLUDED.targets_json,
+			     reward_risk = EXCLUDED.reward_risk,
+			     level_basis = EXCLUDED.level_basis
 			 RETURNING id`,
 			s.Symbol,
 			s.Interval,
@@ -45,6 +53,11 @@ func (r *SignalRepository) InsertSignals(ctx context.Context, signals []domain.S
 			int16(s.Risk),
 			s.Timestamp.UTC(),
 			s.Details,
+			entry,
+			stop,
+			targetsJSON,
+			rewardRisk,
+			basis,
 		)
 	}
 
@@ -71,6 +84,8 @@ func (r *SignalRepository) ListSignals(ctx context.Context, filter domain.Signal
 	args := make([]any, 0, 4)
 	var sb strings.Builder
 	sb.WriteString(`SELECT s.id, s.symbol, s.interval, s.indicator, s.direction, s.risk, s.timestamp, s.details,
+               COALESCE(s.entry_price, 0), COALESCE(s.stop_price, 0), s.targets_json,
+               COALESCE(s.reward_risk, 0), s.level_basis,
                COALESCE(si.id, 0), COALESCE(si.mime_type, ''), COALESCE(si.width, 0), COALESCE(si.height, 0),
                COALESCE(si.expires_at, to_timestamp(0))
 		FROM signals s
@@ -92,6 +107,14 @@ func (r *SignalRepository) ListSignals(ctx context.Context, filter domain.Signal
 		args = append(args, strings.ToLower(filter.Indicator))
 		sb.WriteString(fmt.Sprintf(" AND s.indicator = $%d", len(args)))
 	}
+	if filter.Interval != "" {
+		args = append(args, strings.ToLower(filter.Interval))
+		sb.WriteString(fmt.Sprintf(" AND s.interval = $%d", len(args)))
+	}
+	if !filter.Since.IsZero() {
+		args = append(args, filter.Since.UTC())
+		sb.WriteString(fmt.Sprintf(" AND s.timestamp >= $%d", len(args)))
+	}
 
 	limit := filter.Limit
 	if limit <= 0 {
@@ -102,6 +125,10 @@ func (r *SignalRepository) ListSignals(ctx context.Context, filter domain.Signal
 	}
 	args = append(args, limit)
 	sb.WriteString(fmt.Sprintf(" ORDER BY s.timestamp DESC LIMIT $%d", len(args)))
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		sb.WriteString(fmt.Sprintf(" OFFSET $%d", len(args)))
+	}
 
 	rows, err := r.pool.Query(ctx, sb.String(), args...)
 	if err != nil {
@@ -120,6 +147,8 @@ func (r *SignalRepository) ListSignals(ctx context.Context, filter domain.Signal
 		var width int
 		var height int
 		var expiresAt time.Time
+		var entry, stop, rewardRisk float64
+		var targetsJSON, basis string
 
 		if err := rows.Scan(
 			&s.ID,
@@ -130,6 +159,11 @@ func (r *SignalRepository) ListSignals(ctx context.Context, filter domain.Signal
 			&risk,
 			&ts,
 			&s.Details,
+			&entry,
+			&stop,
+			&targetsJSON,
+			&rewardRisk,
+			&basis,
 			&imageID,
 			&mimeType,
 			&width,
@@ -141,6 +175,7 @@ func (r *SignalRepository) ListSignals(ctx context.Context, filter domain.Signal
 		s.Direction = domain.SignalDirection(direction)
 		s.Risk = domain.RiskLevel(risk)
 		s.Timestamp = ts.UTC()
+		s.Levels = decodeSignalLevels(entry, stop, targetsJSON, rewardRisk, basis)
 		if imageID > 0 {
 			s.Image = &domain.SignalImageRef{
 				ImageID:   imageID,
@@ -155,3 +190,104 @@ func (r *SignalRepository) ListSignals(ctx context.Context, filter domain.Signal
 
 	return signals, rows.Err()
 }
+
+func (r *SignalRepository) RiskDistribution(ctx context.Context, since time.Time, indicator string) ([]domain.RiskDistributionBucket, error) {
+	_, span := r.tracer.Start(ctx, "signal-repo.risk-distribution")
+	defer span.End()
+
+	args := []any{since.UTC()}
+	query := `SELECT indicator, interval, risk, COUNT(*)::INT
+		FROM signals
+		WHERE timestamp >= $1`
+	if indicator != "" {
+		args = append(args, strings.ToLower(indicator))
+		query += fmt.Sprintf(" AND indicator = $%d", len(args))
+	}
+	query += " GROUP BY indicator, interval, risk ORDER BY indicator, interval, risk"
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []domain.RiskDistributionBucket
+	for rows.Next() {
+		var b domain.RiskDistributionBucket
+		var risk int16
+		if err := rows.Scan(&b.Indicator, &b.Interval, &risk, &b.Count); err != nil {
+			return nil, err
+		}
+		b.Risk = domain.RiskLevel(risk)
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+// GetSignalLevels returns a signal's direction and levels. Levels are nil
+// when the signal has none; a missing signal returns pgx.ErrNoRows.
+func (r *SignalRepository) GetSignalLevels(ctx context.Context, signalID int64) (domain.SignalDirection, *domain.SignalLevels, error) {
+	_, span := r.tracer.Start(ctx, "signal-repo.get-signal-levels")
+	defer span.End()
+
+	var direction, targetsJSON, basis string
+	var entry, stop, rewardRisk float64
+	err := r.pool.QueryRow(ctx,
+		`SELECT direction, COALESCE(entry_price, 0), COALESCE(stop_price, 0), targets_json,
+		        COALESCE(reward_risk, 0), level_basis
+		 FROM signals WHERE id = $1`,
+		signalID,
+	).Scan(&direction, &entry, &stop, &targetsJSON, &rewardRisk, &basis)
+	if err != nil {
+		return "", nil, err
+	}
+	return domain.SignalDirection(direction), decodeSignalLevels(entry, stop, targetsJSON, rewardRisk, basis), nil
+}
+
+// RecordLevelOutcome stores whether a signal's stop or first target was hit
+// first, and at what price. A signal that touched neither by the end of its
+// horizon records LevelOutcomeOpen with the closing price.
+func (r *SignalRepository) RecordLevelOutcome(ctx context.Context, signalID int64, outcome domain.LevelOutcome, exitPrice float64) error {
+	_, span := r.tracer.Start(ctx, "signal-repo.record-level-outcome")
+	defer span.End()
+
+	_, err := r.pool.Exec(ctx,
+		`UPDATE signals SET level_outcome = $2, level_exit_price = $3 WHERE id = $1`,
+		signalID, string(outcome), exitPrice,
+	)
+	return err
+}
+
+// encodeSignalLevels maps levels onto their nullable columns; signals without
+// levels store NULL prices and an empty target list.
+func encodeSignalLevels(l *domain.SignalLevels) (entry, stop, rewardRisk *float64, targetsJSON, basis string) {
+	if l == nil {
+		return nil, nil, nil, "[]", ""
+	}
+	targets := l.Targets
+	if targets == nil {
+		targets = []float64{}
+	}
+	raw, err := json.Marshal(targets)
+	if err != nil {
+		raw = []byte("[]")
+	}
+	return &l.Entry, &l.Stop, &l.RewardRisk, string(raw), l.Basis
+}
+
+func decodeSignalLevels(entry, stop float64, targetsJSON string, rewardRisk float64, basis string) *domain.SignalLevels {
+	if entry <= 0 || stop <= 0 {
+		return nil
+	}
+	var targets []float64
+	if targetsJSON != "" {
+		_ = json.Unmarshal([]byte(targetsJSON), &targets)
+	}
+	return &domain.SignalLevels{
+		Entry:      entry,
+		Stop:       stop,
+		Targets:    targets,
+		RewardRisk: rewardRisk,
+		Basis:      basis,
+	}
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -51,6 +52,7 @@ func TestSignalListSignalsReturnsRows(t *testing.T) {
 	now := time.Now().UTC().Truncate(time.Second)
 	rows := [][]any{{
 		int64(10), "BTC", "1h", domain.IndicatorRSI, string(domain.DirectionLong), int16(domain.RiskLevel2), now, "rsi crossed below 30",
+		float64(100), float64(96), "[104,108]", float64(1), domain.LevelBasisATR,
 		int64(0), "", int32(0), int32(0), time.Unix(0, 0).UTC(),
 	}}
 	pool := &signalStubPool{rowsData: rows}
@@ -74,12 +76,103 @@ func TestSignalListSignalsReturnsRows(t *testing.T) {
 	if signals[0].Symbol != "BTC" || signals[0].Direction != domain.DirectionLong || signals[0].Risk != domain.RiskLevel2 {
 		t.Fatalf("unexpected signal payload: %+v", signals[0])
 	}
+	levels := signals[0].Levels
+	if levels == nil || levels.Entry != 100 || levels.Stop != 96 || len(levels.Targets) != 2 || levels.Basis != domain.LevelBasisATR {
+		t.Fatalf("unexpected signal levels: %+v", levels)
+	}
+}
+
+func TestSignalListSignalsFiltersSince(t *testing.T) {
+	pool := &signalStubPool{}
+	repo := NewSignalRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	since := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := repo.ListSignals(context.Background(), domain.SignalFilter{Since: since}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(pool.querySQL, "s.timestamp >= $1") || pool.queryArgs[0] != since {
+		t.Fatalf("expected since filter, got %q %v", pool.querySQL, pool.queryArgs)
+	}
+}
+
+func TestSignalListSignalsFiltersIntervalAndPages(t *testing.T) {
+	pool := &signalStubPool{}
+	repo := NewSignalRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	if _, err := repo.ListSignals(context.Background(), domain.SignalFilter{Interval: "4H", Limit: 6, Offset: 10}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(pool.querySQL, "s.interval = $1") || !strings.HasSuffix(pool.querySQL, "LIMIT $2 OFFSET $3") {
+		t.Fatalf("expected interval filter and offset, got %q", pool.querySQL)
+	}
+	if pool.queryArgs[0] != "4h" || pool.queryArgs[1] != 6 || pool.queryArgs[2] != 10 {
+		t.Fatalf("unexpected args: %v", pool.queryArgs)
+	}
+}
+
+func TestSignalLevelsRoundTrip(t *testing.T) {
+	entry, stop, rr, targetsJSON, basis := encodeSignalLevels(nil)
+	if entry != nil || stop != nil || rr != nil || targetsJSON != "[]" || basis != "" {
+		t.Fatalf("expected empty encoding for nil levels")
+	}
+	if decodeSignalLevels(0, 0, targetsJSON, 0, basis) != nil {
+		t.Fatal("expected nil levels for empty columns")
+	}
+
+	in := &domain.SignalLevels{Entry: 50, Stop: 48, Targets: []float64{53, 55}, RewardRisk: 1.5, Basis: domain.LevelBasisBands}
+	entry, stop, rr, targetsJSON, basis = encodeSignalLevels(in)
+	out := decodeSignalLevels(*entry, *stop, targetsJSON, *rr, basis)
+	if out == nil || out.Entry != 50 || out.Stop != 48 || len(out.Targets) != 2 || out.Targets[1] != 55 || out.RewardRisk != 1.5 || out.Basis != domain.LevelBasisBands {
+		t.Fatalf("unexpected round trip: %+v", out)
+	}
+}
+
+func TestSignalLevelOutcome(t *testing.T) {
+	pool := &runStubPool{row: []any{"long", 100.0, 95.0, "[110,120]", 2.0, domain.LevelBasisSwing}}
+	repo := NewSignalRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	direction, levels, err := repo.GetSignalLevels(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if direction != domain.DirectionLong || levels == nil || levels.Stop != 95 || len(levels.Targets) != 2 || pool.rowArgs[0] != int64(7) {
+		t.Fatalf("unexpected levels: %s %+v", direction, levels)
+	}
+
+	if err := repo.RecordLevelOutcome(context.Background(), 7, domain.LevelOutcomeTarget, 110); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(pool.execSQL, "level_outcome = $2") || pool.execArgs[1] != "target" || pool.execArgs[2] != 110.0 {
+		t.Fatalf("unexpected update: %q %v", pool.execSQL, pool.execArgs)
+	}
+}
+
+func TestSignalRiskDistributionReturnsBuckets(t *testing.T) {
+	rows := [][]any{
+		{domain.IndicatorRSI, "1h", int16(domain.RiskLevel2), int32(7)},
+		{domain.IndicatorRSI, "1h", int16(domain.RiskLevel3), int32(3)},
+	}
+	pool := &signalStubPool{rowsData: rows}
+	repo := NewSignalRepository(pool, trace.NewNoopTracerProvider().Tracer("test"))
+
+	buckets, err := repo.RiskDistribution(context.Background(), time.Now().Add(-24*time.Hour), domain.IndicatorRSI)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(buckets) != 2 {
+		t.Fatalf("expected 2 buckets, got %d", len(buckets))
+	}
+	if buckets[0].Risk != domain.RiskLevel2 || buckets[0].Count != 7 {
+		t.Fatalf("unexpected first bucket: %+v", buckets[0])
+	}
 }
 
 type signalStubPool struct {
 	batchResults pgx.BatchResults
 	queuedBatch  *pgx.Batch
 	rowsData     [][]any
+	querySQL     string
+	queryArgs    []any
 }
 
 func (s *signalStubPool) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
@@ -95,6 +188,8 @@ func (s *signalStubPool) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchR
 }
 
 func (s *signalStubPool) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	s.querySQL = sql
+	s.queryArgs = args
 	if s.rowsData == nil {
 		return &signalStubRows{}, nil
 	}
@@ -173,6 +268,8 @@ func (r *signalStubRows) Scan(dest ...any) error {
 			default:
 				return fmt.Errorf("unsupported int source type %T", row[i])
 			}
+		case *float64:
+			*ptr = row[i].(float64)
 		case *time.Time:
 			*ptr = row[i].(time.Time)
 		default:
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// inviteTTL is how long an unused invite code stays valid.
+const inviteTTL = 7 * 24 * time.Hour
+
+var (
+	// ErrInvalidRole is returned for roles other than viewer, trader and admin.
+	ErrInvalidRole = errors.New("role must be viewer, trader or admin")
+	// ErrInviteInvalid is returned for unknown, used or expired invite codes.
+	ErrInviteInvalid = errors.New("invite code is invalid, used or expired")
+	// ErrProtectedAdmin is returned when changing an admin set by
+	// TELEGRAM_ADMIN_IDS, so the bot cannot lock out its operators.
+	ErrProtectedAdmin = errors.New("configured admins cannot be changed")
+)
+
+type AccessStore interface {
+	GetTelegramUser(ctx context.Context, chatID int64) (*domain.TelegramUser, error)
+	ListTelegramUsers(ctx context.Context) ([]domain.TelegramUser, error)
+	SaveTelegramUser(ctx context.Context, u domain.TelegramUser) error
+	DeleteTelegramUser(ctx context.Context, chatID int64) (bool, error)
+	CreateInvite(ctx context.Context, inv domain.TelegramInvite) error
+	RedeemInvite(ctx context.Context, code string, chatID int64, now time.Time) (*domain.TelegramInvite, error)
+}
+
+// AccessService decides which Telegram chats may use the bot and with which
+// role. Access control is on when at least one admin is configured; until
+// then every chat is treated as a trader, as before roles existed.
+type AccessService struct {
+	tracer  trace.Tracer
+	store   AccessStore
+	admins  map[int64]bool
+	now     func() time.Time
+	newCode func() string
+}
+
+func NewAccessService(tracer trace.Tracer, store AccessStore, adminIDs []int64) *AccessService {
+	admins := make(map[int64]bool, len(adminIDs))
+	for _, id := range adminIDs {
+		admins[id] = true
+	}
+	return &AccessService{
+		tracer:  tracer,
+		store:   store,
+		admins:  admins,
+		now:     time.Now,
+		newCode: randomInviteCode,
+	}
+}
+
+// Enabled reports whether chats need a role to use the bot.
+func (s *AccessService) Enabled() bool {
+	return len(s.admins) > 0
+}
+
+// Role returns a chat's role, or "" when it has none.
+func (s *AccessService) Role(ctx context.Context, chatID int64) (domain.UserRole, error) {
+	ctx, span := s.tracer.Start(ctx, "access-service.role")
+	defer span.End()
+
+	if s.admins[chatID] {
+		return domain.RoleAdmin, nil
+	}
+	if !s.Enabled() {
+		return domain.RoleTrader, nil
+	}
+	u, err := s.store.GetTelegramUser(ctx, chatID)
+	if err != nil || u == nil {
+		return "", err
+	}
+	return u.Role, nil
+}
+
+// EnsureAdmins stores the configured admins so they are listed and reached
+// by broadcasts.
+func (s *AccessService) EnsureAdmins(ctx context.Context) error {
+	ctx, span := s.tracer.Start(ctx, "access-service.ensure-admins")
+	defer span.End()
+
+	for id := range s.admins {
+		if err := s.store.SaveTelegramUser(ctx, domain.TelegramUser{ChatID: id, Role: domain.RoleAdmin}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateInvite issues a single-use code granting role.
+func (s *AccessService) CreateInvite(ctx context.Context, createdBy int64, role domain.UserRole) (*domain.TelegramInvite, error) {
+	ctx, span := s.tracer.Start(ctx, "access-service.create-invite")
+	defer span.End()
+
+	if !role.IsValid() {
+		return nil, ErrInvalidRole
+	}
+	now := s.now().UTC()
+	inv := domain.TelegramInvite{
+		Code:      s.newCode(),
+		Role:      role,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		ExpiresAt: now.Add(inviteTTL),
+	}
+	if err := s.store.CreateInvite(ctx, inv); err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// Redeem admits chatID with the invite's role. A chat that already has a
+// higher role keeps it.
+func (s *AccessService) Redeem(ctx context.Context, chatID int64, username, code string) (*domain.TelegramUser, error) {
+	ctx, span := s.tracer.Start(ctx, "access-service.redeem")
+	defer span.End()
+	span.SetAttributes(attribute.Int64("chat.id", chatID))
+
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return nil, ErrInviteInvalid
+	}
+	inv, err := s.store.RedeemInvite(ctx, code, chatID, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if inv == nil {
+		return nil, ErrInviteInvalid
+	}
+
+	user := domain.TelegramUser{ChatID: chatID, Username: username, Role: inv.Role, InvitedBy: inv.CreatedBy}
+	existing, err := s.store.GetTelegramUser(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.Role.Rank() >= inv.Role.Rank() {
+		user.Role = existing.Role
+	}
+	if s.admins[chatID] {
+		user.Role = domain.RoleAdmin
+	}
+	if err := s.store.SaveTelegramUser(ctx, user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *AccessService) ListUsers(ctx context.Context) ([]domain.TelegramUser, error) {
+	ctx, span := s.tracer.Start(ctx, "access-service.list-users")
+	defer span.End()
+
+	return s.store.ListTelegramUsers(ctx)
+}
+
+// SetRole grants chatID a role directly, adding it if needed.
+func (s *AccessService) SetRole(ctx context.Context, actor, chatID int64, role domain.UserRole) (*domain.TelegramUser, error) {
+	ctx, span := s.tracer.Start(ctx, "access-service.set-role")
+	defer span.End()
+
+	if !role.IsValid() {
+		return nil, ErrInvalidRole
+	}
+	if s.admins[chatID] && role != domain.RoleAdmin {
+		return nil, fmt.Errorf("%w: %d", ErrProtectedAdmin, chatID)
+	}
+	user := domain.TelegramUser{ChatID: chatID, Role: role, InvitedBy: actor}
+	if err := s.store.SaveTelegramUser(ctx, user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// RemoveUser revokes chatID's access. It reports whether it had any.
+func (s *AccessService) RemoveUser(ctx context.Context, chatID int64) (bool, error) {
+	ctx, span := s.tracer.Start(ctx, "access-service.remove-user")
+	defer span.End()
+
+	if s.admins[chatID] {
+		return false, fmt.Errorf("%w: %d", ErrProtectedAdmin, chatID)
+	}
+	return s.store.DeleteTelegramUser(ctx, chatID)
+}
+
+// randomInviteCode returns 10 characters that are easy to type on a phone.
+func randomInviteCode() string {
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return strings.ToUpper(fmt.Sprintf("%x", time.Now().UnixNano()))[:10]
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf)[:10]
+}
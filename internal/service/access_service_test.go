@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+type memAccessStore struct {
+	users   map[int64]domain.TelegramUser
+	invites map[string]domain.TelegramInvite
+}
+
+func newMemAccessStore() *memAccessStore {
+	return &memAccessStore{users: map[int64]domain.TelegramUser{}, invites: map[string]domain.TelegramInvite{}}
+}
+
+func (m *memAccessStore) GetTelegramUser(ctx context.Context, chatID int64) (*domain.TelegramUser, error) {
+	u, ok := m.users[chatID]
+	if !ok {
+		return nil, nil
+	}
+	return &u, nil
+}
+
+func (m *memAccessStore) ListTelegramUsers(ctx context.Context) ([]domain.TelegramUser, error) {
+	out := make([]domain.TelegramUser, 0, len(m.users))
+	for _, u := range m.users {
+		out = append(out, u)
+	}
+	return out, nil
+}
+
+func (m *memAccessStore) SaveTelegramUser(ctx context.Context, u domain.TelegramUser) error {
+	m.users[u.ChatID] = u
+	return nil
+}
+
+func (m *memAccessStore) DeleteTelegramUser(ctx context.Context, chatID int64) (bool, error) {
+	_, ok := m.users[chatID]
+	delete(m.users, chatID)
+	return ok, nil
+}
+
+func (m *memAccessStore) CreateInvite(ctx context.Context, inv domain.TelegramInvite) error {
+	m.invites[inv.Code] = inv
+	return nil
+}
+
+func (m *memAccessStore) RedeemInvite(ctx context.Context, code string, chatID int64, now time.Time) (*domain.TelegramInvite, error) {
+	inv, ok := m.invites[code]
+	if !ok || inv.UsedAt != nil || !inv.ExpiresAt.After(now) {
+		return nil, nil
+	}
+	inv.UsedBy, inv.UsedAt = &chatID, &now
+	m.invites[code] = inv
+	return &inv, nil
+}
+
+func TestAccessServiceDisabledWithoutAdmins(t *testing.T) {
+	svc := NewAccessService(testTracer, newMemAccessStore(), nil)
+	role, err := svc.Role(context.Background(), 42)
+	if svc.Enabled() || err != nil || role != domain.RoleTrader {
+		t.Fatalf("expected open access as trader, got %q %v", role, err)
+	}
+}
+
+func TestAccessServiceInviteFlow(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 6, 10, 9, 0, 0, 0, time.UTC)
+	store := newMemAccessStore()
+	svc := NewAccessService(testTracer, store, []int64{1})
+	svc.now = func() time.Time { return now }
+	svc.newCode = func() string { return "ABCDEFGHJK" }
+
+	if role, _ := svc.Role(ctx, 42); role != "" {
+		t.Fatalf("expected no role before redeeming, got %q", role)
+	}
+	if _, err := svc.CreateInvite(ctx, 1, "owner"); !errors.Is(err, ErrInvalidRole) {
+		t.Fatalf("expected ErrInvalidRole, got %v", err)
+	}
+	inv, err := svc.CreateInvite(ctx, 1, domain.RoleViewer)
+	if err != nil || !inv.ExpiresAt.Equal(now.Add(inviteTTL)) {
+		t.Fatalf("unexpected invite: %+v %v", inv, err)
+	}
+
+	user, err := svc.Redeem(ctx, 42, "bob", " abcdefghjk ")
+	if err != nil || user.Role != domain.RoleViewer || user.InvitedBy != 1 {
+		t.Fatalf("unexpected redeemed user: %+v %v", user, err)
+	}
+	if role, _ := svc.Role(ctx, 42); role != domain.RoleViewer {
+		t.Fatalf("expected viewer, got %q", role)
+	}
+	if _, err := svc.Redeem(ctx, 43, "", "ABCDEFGHJK"); !errors.Is(err, ErrInviteInvalid) {
+		t.Fatalf("expected a used code to be refused, got %v", err)
+	}
+
+	// A lower invite does not downgrade an existing trader.
+	store.users[44] = domain.TelegramUser{ChatID: 44, Role: domain.RoleTrader}
+	svc.newCode = func() string { return "LOWERCODE2" }
+	if _, err := svc.CreateInvite(ctx, 1, domain.RoleViewer); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user, err := svc.Redeem(ctx, 44, "", "LOWERCODE2"); err != nil || user.Role != domain.RoleTrader {
+		t.Fatalf("expected trader kept, got %+v %v", user, err)
+	}
+
+	svc.now = func() time.Time { return now.Add(inviteTTL + time.Minute) }
+	svc.newCode = func() string { return "EXPIRED123" }
+	store.invites["EXPIRED123"] = domain.TelegramInvite{Code: "EXPIRED123", Role: domain.RoleAdmin, ExpiresAt: now}
+	if _, err := svc.Redeem(ctx, 45, "", "EXPIRED123"); !errors.Is(err, ErrInviteInvalid) {
+		t.Fatalf("expected an expired code to be refused, got %v", err)
+	}
+}
+
+func TestAccessServiceProtectsConfiguredAdmins(t *testing.T) {
+	ctx := context.Background()
+	store := newMemAccessStore()
+	svc := NewAccessService(testTracer, store, []int64{1})
+
+	if err := svc.EnsureAdmins(ctx); err != nil || store.users[1].Role != domain.RoleAdmin {
+		t.Fatalf("expected configured admin stored, got %+v %v", store.users, err)
+	}
+	if _, err := svc.SetRole(ctx, 1, 1, domain.RoleViewer); !errors.Is(err, ErrProtectedAdmin) {
+		t.Fatalf("expected ErrProtectedAdmin, got %v", err)
+	}
+	if _, err := svc.RemoveUser(ctx, 1); !errors.Is(err, ErrProtectedAdmin) {
+		t.Fatalf("expected ErrProtectedAdmin, got %v", err)
+	}
+	if user, err := svc.SetRole(ctx, 1, -1001, domain.RoleViewer); err != nil || user.Role != domain.RoleViewer {
+		t.Fatalf("expected group added as viewer, got %+v %v", user, err)
+	}
+	if removed, err := svc.RemoveUser(ctx, -1001); err != nil || !removed {
+		t.Fatalf("expected group removed, got %v %v", removed, err)
+	}
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultChartCandles = 120
+	minChartCandles     = 20
+	maxChartCandles     = 500
+	// chartWarmup is how many extra candles are loaded so indicators are
+	// settled at the left edge of the chart.
+	chartWarmup = 60
+	// chartCacheTTL matches the short-candle poll, so a cached chart is at
+	// most one refresh behind.
+	chartCacheTTL = 5 * time.Minute
+)
+
+// ErrInvalidChart wraps validation failures for chart requests.
+var ErrInvalidChart = errors.New("invalid chart request")
+
+// ErrChartNoData is returned when there are too few stored candles to draw.
+var ErrChartNoData = errors.New("not enough candles to chart")
+
+type ChartCandleSource interface {
+	GetCandles(ctx context.Context, symbol, interval string, limit int) ([]*domain.Candle, error)
+}
+
+type ChartImageRenderer interface {
+	RenderChart(candles []*domain.Candle, req domain.ChartRequest) ([]byte, error)
+}
+
+// ChartService renders on-demand candle charts and caches the PNGs in Redis
+// by their parameters.
+type ChartService struct {
+	tracer   trace.Tracer
+	candles  ChartCandleSource
+	renderer ChartImageRenderer
+	redis    RedisClient
+}
+
+func NewChartService(tracer trace.Tracer, candles ChartCandleSource, renderer ChartImageRenderer, redisClient RedisClient) *ChartService {
+	return &ChartService{
+		tracer:   tracer,
+		candles:  candles,
+		renderer: renderer,
+		redis:    redisClient,
+	}
+}
+
+// RenderChart returns the PNG for req, normalised by NormalizeChartRequest.
+func (s *ChartService) RenderChart(ctx context.Context, req domain.ChartRequest) ([]byte, error) {
+	ctx, span := s.tracer.Start(ctx, "chart-service.render")
+	defer span.End()
+
+	req, err := NormalizeChartRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	key := chartCacheKey(req)
+	span.SetAttributes(attribute.String("chart.key", key))
+
+	if s.redis != nil {
+		data, err := s.redis.Get(ctx, key).Bytes()
+		if err == nil && len(data) > 0 {
+			span.SetAttributes(attribute.Bool("cache.hit", true))
+			return data, nil
+		}
+		if err != nil && err != redis.Nil {
+			log.Printf("redis chart cache read error: %v", err)
+		}
+	}
+
+	candles, err := s.candles.GetCandles(ctx, req.Symbol, req.Interval, req.Candles+chartWarmup)
+	if err != nil {
+		return nil, err
+	}
+	if len(candles) < 2 {
+		return nil, fmt.Errorf("%w: %s %s", ErrChartNoData, req.Symbol, req.Interval)
+	}
+	img, err := s.renderer.RenderChart(candles, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.redis != nil {
+		if err := s.redis.Set(ctx, key, img, chartCacheTTL).Err(); err != nil {
+			log.Printf("redis chart cache write error: %v", err)
+		}
+	}
+	return img, nil
+}
+
+// NormalizeChartRequest validates req and fills in defaults: 1h candles, a
+// lookback of 120 candles, and overlays and panes deduplicated into drawing
+// order.
+func NormalizeChartRequest(req domain.ChartRequest) (domain.ChartRequest, error) {
+	req.Symbol = strings.ToUpper(strings.TrimSpace(req.Symbol))
+	if _, ok := domain.CoinGeckoID[req.Symbol]; !ok {
+		return req, fmt.Errorf("%w: unsupported symbol %q", ErrInvalidChart, req.Symbol)
+	}
+	req.Interval = strings.ToLower(strings.TrimSpace(req.Interval))
+	if req.Interval == "" {
+		req.Interval = "1h"
+	}
+	if !slices.Contains(domain.SupportedIntervals, req.Interval) {
+		return req, fmt.Errorf("%w: unsupported interval %q", ErrInvalidChart, req.Interval)
+	}
+	switch {
+	case req.Candles == 0:
+		req.Candles = defaultChartCandles
+	case req.Candles < minChartCandles || req.Candles > maxChartCandles:
+		return req, fmt.Errorf("%w: lookback must be %d to %d candles", ErrInvalidChart, minChartCandles, maxChartCandles)
+	}
+
+	var err error
+	req.Overlays, err = chartOptions(req.Overlays, domain.ChartOverlays, "overlay")
+	if err != nil {
+		return req, err
+	}
+	req.Panes, err = chartOptions(req.Panes, domain.ChartPanes, "pane")
+	if err != nil {
+		return req, err
+	}
+	return req, nil
+}
+
+// chartOptions checks values against supported and returns them in
+// supported's order without duplicates.
+func chartOptions(values, supported []string, kind string) ([]string, error) {
+	seen := make(map[string]bool, len(values))
+	for _, v := range values {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v == "" {
+			continue
+		}
+		if !slices.Contains(supported, v) {
+			return nil, fmt.Errorf("%w: unknown %s %q (supported: %s)", ErrInvalidChart, kind, v, strings.Join(supported, ", "))
+		}
+		seen[v] = true
+	}
+	out := make([]string, 0, len(seen))
+	for _, v := range supported {
+		if seen[v] {
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+// ChartLookbackCandles is domain.ChartLookbackCandles with failures wrapped
+// in ErrInvalidChart.
+func ChartLookbackCandles(interval, lookback string) (int, error) {
+	n, ok := domain.ChartLookbackCandles(interval, lookback)
+	if !ok {
+		return 0, fmt.Errorf("%w: lookback %q must be a candle count or a duration such as 7d", ErrInvalidChart, lookback)
+	}
+	return n, nil
+}
+
+func chartCacheKey(req domain.ChartRequest) string {
+	return fmt.Sprintf("chart:%s:%s:%d:%s:%s", req.Symbol, req.Interval, req.Candles,
+		strings.Join(req.Overlays, ","), strings.Join(req.Panes, ","))
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+type countingChartRenderer struct {
+	calls   int
+	lastReq domain.ChartRequest
+}
+
+func (r *countingChartRenderer) RenderChart(candles []*domain.Candle, req domain.ChartRequest) ([]byte, error) {
+	r.calls++
+	r.lastReq = req
+	return []byte("png"), nil
+}
+
+func TestChartServiceCachesByParameters(t *testing.T) {
+	candles := &mockCandleRepo{getResp: []*domain.Candle{
+		{Close: 1, OpenTime: time.Unix(0, 0)},
+		{Close: 2, OpenTime: time.Unix(3600, 0)},
+	}}
+	renderer := &countingChartRenderer{}
+	redis := newFakeRedis()
+	svc := NewChartService(testTracer, candles, renderer, redis)
+	ctx := context.Background()
+
+	req := domain.ChartRequest{Symbol: "btc", Interval: "4H", Overlays: []string{"vwap", "EMA", "vwap"}, Panes: []string{"rsi"}}
+	img, err := svc.RenderChart(ctx, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(img) != "png" {
+		t.Fatalf("unexpected image %q", img)
+	}
+	if candles.lastGetSymbol != "BTC" || candles.lastGetInterval != "4h" || candles.lastGetLimit != defaultChartCandles+chartWarmup {
+		t.Fatalf("unexpected candle query: %s %s %d", candles.lastGetSymbol, candles.lastGetInterval, candles.lastGetLimit)
+	}
+	if got := renderer.lastReq.Overlays; len(got) != 2 || got[0] != "ema" || got[1] != "vwap" {
+		t.Fatalf("expected overlays in drawing order, got %v", got)
+	}
+	if _, ok := redis.data["chart:BTC:4h:120:ema,vwap:rsi"]; !ok {
+		t.Fatalf("expected cached image, got keys %v", redis.data)
+	}
+
+	if _, err := svc.RenderChart(ctx, domain.ChartRequest{Symbol: "BTC", Interval: "4h", Overlays: []string{"ema", "vwap"}, Panes: []string{"rsi"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if renderer.calls != 1 {
+		t.Fatalf("expected the equivalent request to hit the cache, rendered %d times", renderer.calls)
+	}
+}
+
+func TestChartServiceValidates(t *testing.T) {
+	svc := NewChartService(testTracer, &mockCandleRepo{}, &countingChartRenderer{}, nil)
+	ctx := context.Background()
+
+	bad := []domain.ChartRequest{
+		{Symbol: "SHIB"},
+		{Symbol: "BTC", Interval: "2h"},
+		{Symbol: "BTC", Candles: 5},
+		{Symbol: "BTC", Overlays: []string{"ichimoku"}},
+		{Symbol: "BTC", Panes: []string{"obv"}},
+	}
+	for _, req := range bad {
+		if _, err := svc.RenderChart(ctx, req); !errors.Is(err, ErrInvalidChart) {
+			t.Fatalf("expected ErrInvalidChart for %+v, got %v", req, err)
+		}
+	}
+	if _, err := svc.RenderChart(ctx, domain.ChartRequest{Symbol: "BTC"}); !errors.Is(err, ErrChartNoData) {
+		t.Fatalf("expected ErrChartNoData, got %v", err)
+	}
+}
+
+func TestChartLookbackCandles(t *testing.T) {
+	cases := []struct {
+		interval, lookback string
+		want               int
+	}{
+		{"4h", "", 0},
+		{"4h", "200", 200},
+		{"4h", "7d", 42},
+		{"", "48h", 48},
+		{"1d", "2w", 14},
+	}
+	for _, tc := range cases {
+		got, err := ChartLookbackCandles(tc.interval, tc.lookback)
+		if err != nil || got != tc.want {
+			t.Fatalf("ChartLookbackCandles(%q, %q) = %d, %v; want %d", tc.interval, tc.lookback, got, err, tc.want)
+		}
+	}
+	for _, lookback := range []string{"-5", "7y", "d", "0h"} {
+		if _, err := ChartLookbackCandles("1h", lookback); !errors.Is(err, ErrInvalidChart) {
+			t.Fatalf("expected ErrInvalidChart for %q, got %v", lookback, err)
+		}
+	}
+}
@@ -0,0 +1,544 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// digestCacheTTL lets every subscriber due in the same few minutes share
+	// one build.
+	digestCacheTTL = 10 * time.Minute
+	// digestCatchUp is how late a scheduled digest may still go out, e.g.
+	// after a restart. Older misses are skipped until the next slot.
+	digestCatchUp   = 2 * time.Hour
+	digestTopN      = 5
+	digestSignalCap = 20
+)
+
+// ErrInvalidDigest wraps validation failures for digest schedules.
+var ErrInvalidDigest = errors.New("invalid digest schedule")
+
+type DigestStore interface {
+	ListDigestSubscriptions(ctx context.Context) ([]domain.DigestSubscription, error)
+	GetDigestSubscription(ctx context.Context, chatID int64) (*domain.DigestSubscription, error)
+	SaveDigestSubscription(ctx context.Context, sub domain.DigestSubscription) error
+	DeleteDigestSubscription(ctx context.Context, chatID int64) (bool, error)
+	// ClaimDigest marks a chat's digest due at due as sent at at, and
+	// reports false when it already was.
+	ClaimDigest(ctx context.Context, chatID int64, at, due time.Time) (bool, error)
+	// ReleaseDigest undoes a claim made at at so the digest is retried.
+	ReleaseDigest(ctx context.Context, chatID int64, at time.Time, previous *time.Time) error
+}
+
+type DigestPriceSource interface {
+	GetCurrentPrices(ctx context.Context) ([]*domain.PriceSnapshot, error)
+}
+
+type DigestCandleSource interface {
+	GetCandlesInRange(ctx context.Context, symbol, interval string, from, to time.Time) ([]*domain.Candle, error)
+}
+
+type DigestSignalSource interface {
+	ListSignals(ctx context.Context, filter domain.SignalFilter) ([]domain.Signal, error)
+}
+
+type DigestMLSource interface {
+	Analyze(ctx context.Context, q domain.MLAnalyticsQuery) (*domain.MLAnalyticsReport, error)
+}
+
+type DigestMarketIntelSource interface {
+	ListCompositeScores(ctx context.Context, interval string, from, to time.Time) ([]domain.MarketCompositeSnapshot, error)
+	ListTopHeadlines(ctx context.Context, from, to time.Time, limit int) ([]domain.MarketIntelItem, error)
+}
+
+type DigestChartRenderer interface {
+	RenderPerformanceChart(prices []domain.DigestPriceChange) ([]byte, error)
+}
+
+// DigestSummarizer writes a short overview of a digest from its facts.
+type DigestSummarizer interface {
+	SummarizeDigest(ctx context.Context, facts string) (string, error)
+}
+
+// DigestSender delivers a digest to one chat.
+type DigestSender interface {
+	SendDigest(ctx context.Context, chatID int64, d *domain.Digest) error
+}
+
+// DigestSources are the read-only inputs a digest is built from. Any of them
+// may be nil; that section is then left out.
+type DigestSources struct {
+	Prices      DigestPriceSource
+	Candles     DigestCandleSource
+	Signals     DigestSignalSource
+	ML          DigestMLSource
+	MarketIntel DigestMarketIntelSource
+	Chart       DigestChartRenderer
+}
+
+type cachedDigest struct {
+	digest  *domain.Digest
+	builtAt time.Time
+}
+
+// DigestService builds daily and weekly market digests and sends them to
+// subscribers at their chosen local time.
+type DigestService struct {
+	tracer     trace.Tracer
+	store      DigestStore
+	src        DigestSources
+	summarizer DigestSummarizer
+	sender     DigestSender
+	now        func() time.Time
+
+	mu    sync.Mutex
+	cache map[domain.DigestPeriod]cachedDigest
+}
+
+func NewDigestService(tracer trace.Tracer, store DigestStore, src DigestSources) *DigestService {
+	return &DigestService{
+		tracer: tracer,
+		store:  store,
+		src:    src,
+		now:    time.Now,
+		cache:  make(map[domain.DigestPeriod]cachedDigest),
+	}
+}
+
+// SetSummarizer lets the advisor LLM write the summary. Without one the
+// template summary is used.
+func (s *DigestService) SetSummarizer(summarizer DigestSummarizer) {
+	s.summarizer = summarizer
+}
+
+// SetSender sets where scheduled digests are delivered. Without one SendDue
+// does nothing.
+func (s *DigestService) SetSender(sender DigestSender) {
+	s.sender = sender
+}
+
+// GetDigestSubscription returns a chat's schedule, or nil when it has none.
+func (s *DigestService) GetDigestSubscription(ctx context.Context, chatID int64) (*domain.DigestSubscription, error) {
+	ctx, span := s.tracer.Start(ctx, "digest-service.get-subscription")
+	defer span.End()
+
+	return s.store.GetDigestSubscription(ctx, chatID)
+}
+
+// SubscribeDigest validates and stores a chat's schedule, replacing any
+// previous one. The first digest goes out at the next scheduled time.
+func (s *DigestService) SubscribeDigest(ctx context.Context, sub domain.DigestSubscription) (*domain.DigestSubscription, error) {
+	ctx, span := s.tracer.Start(ctx, "digest-service.subscribe")
+	defer span.End()
+
+	if sub.ChatID == 0 {
+		return nil, fmt.Errorf("%w: chat id is required", ErrInvalidDigest)
+	}
+	if !sub.Period.IsValid() {
+		return nil, fmt.Errorf("%w: period must be daily or weekly", ErrInvalidDigest)
+	}
+	if sub.Minute < 0 || sub.Minute >= 24*60 {
+		return nil, fmt.Errorf("%w: time must be between 00:00 and 23:59", ErrInvalidDigest)
+	}
+	if sub.Weekday < time.Sunday || sub.Weekday > time.Saturday {
+		return nil, fmt.Errorf("%w: unknown weekday", ErrInvalidDigest)
+	}
+	if sub.Period == domain.DigestDaily {
+		sub.Weekday = time.Sunday
+	}
+	sub.Timezone = strings.TrimSpace(sub.Timezone)
+	if sub.Timezone == "" {
+		sub.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(sub.Timezone); err != nil {
+		return nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidDigest, sub.Timezone)
+	}
+	now := s.now().UTC()
+	sub.LastSentAt = &now
+	span.SetAttributes(attribute.String("period", string(sub.Period)))
+
+	if err := s.store.SaveDigestSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// UnsubscribeDigest stops a chat's digest. It reports whether there was one.
+func (s *DigestService) UnsubscribeDigest(ctx context.Context, chatID int64) (bool, error) {
+	ctx, span := s.tracer.Start(ctx, "digest-service.unsubscribe")
+	defer span.End()
+
+	return s.store.DeleteDigestSubscription(ctx, chatID)
+}
+
+// SendDue sends every digest whose scheduled time has passed since it was
+// last sent. Subscribers due together share one build per period.
+func (s *DigestService) SendDue(ctx context.Context) error {
+	ctx, span := s.tracer.Start(ctx, "digest-service.send-due")
+	defer span.End()
+
+	if s.sender == nil {
+		return nil
+	}
+	subs, err := s.store.ListDigestSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := s.now().UTC()
+	var errs []error
+	sent := 0
+	for _, sub := range subs {
+		due := sub.LastDue(now)
+		if sub.LastSentAt != nil && !sub.LastSentAt.Before(due) {
+			continue
+		}
+		if now.Sub(due) > digestCatchUp {
+			continue
+		}
+		// Claim the send first so another replica running the same
+		// schedule skips it.
+		claimed, err := s.store.ClaimDigest(ctx, sub.ChatID, now, due)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("claim digest for chat %d: %w", sub.ChatID, err))
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		if err := s.sendDigest(ctx, sub); err != nil {
+			errs = append(errs, err)
+			if err := s.store.ReleaseDigest(ctx, sub.ChatID, now, sub.LastSentAt); err != nil {
+				errs = append(errs, fmt.Errorf("release digest for chat %d: %w", sub.ChatID, err))
+			}
+			continue
+		}
+		sent++
+	}
+	span.SetAttributes(
+		attribute.Int("subscriptions", len(subs)),
+		attribute.Int("sent", sent),
+	)
+	return errors.Join(errs...)
+}
+
+func (s *DigestService) sendDigest(ctx context.Context, sub domain.DigestSubscription) error {
+	digest, err := s.BuildDigest(ctx, sub.Period)
+	if err != nil {
+		return fmt.Errorf("build %s digest: %w", sub.Period, err)
+	}
+	if err := s.sender.SendDigest(ctx, sub.ChatID, digest); err != nil {
+		return fmt.Errorf("send digest to chat %d: %w", sub.ChatID, err)
+	}
+	return nil
+}
+
+// BuildDigest composes the digest for the period ending now. Each section is
+// best effort: a failing source is logged and its section left empty.
+func (s *DigestService) BuildDigest(ctx context.Context, period domain.DigestPeriod) (*domain.Digest, error) {
+	ctx, span := s.tracer.Start(ctx, "digest-service.build")
+	defer span.End()
+
+	if !period.IsValid() {
+		return nil, fmt.Errorf("%w: period must be daily or weekly", ErrInvalidDigest)
+	}
+	span.SetAttributes(attribute.String("period", string(period)))
+
+	now := s.now().UTC()
+	s.mu.Lock()
+	cached, ok := s.cache[period]
+	s.mu.Unlock()
+	if ok && now.Sub(cached.builtAt) < digestCacheTTL {
+		return cached.digest, nil
+	}
+
+	d := &domain.Digest{
+		Period: period,
+		From:   now.AddDate(0, 0, -period.Days()),
+		To:     now,
+	}
+	d.Prices = s.priceChanges(ctx, d)
+	d.TopSignals = s.topSignals(ctx, d)
+	d.ML = s.mlMetrics(ctx, d)
+	d.SentimentMoves, d.Headlines = s.marketIntel(ctx, d)
+
+	if s.src.Chart != nil && len(d.Prices) > 0 {
+		img, err := s.src.Chart.RenderPerformanceChart(d.Prices)
+		if err != nil {
+			log.Printf("digest chart failed: %v", err)
+		} else {
+			d.Chart = img
+		}
+	}
+
+	d.Summary = templateDigestSummary(d)
+	if s.summarizer != nil {
+		summary, err := s.summarizer.SummarizeDigest(ctx, digestFacts(d))
+		if err != nil {
+			log.Printf("digest summary failed, using template: %v", err)
+		} else if summary = strings.TrimSpace(summary); summary != "" {
+			d.Summary = summary
+			d.SummaryByLLM = true
+		}
+	}
+
+	s.mu.Lock()
+	s.cache[period] = cachedDigest{digest: d, builtAt: now}
+	s.mu.Unlock()
+	return d, nil
+}
+
+func (s *DigestService) priceChanges(ctx context.Context, d *domain.Digest) []domain.DigestPriceChange {
+	if s.src.Candles == nil {
+		return nil
+	}
+	interval := "1h"
+	if d.Period == domain.DigestWeekly {
+		interval = "4h"
+	}
+	latest := make(map[string]float64)
+	if s.src.Prices != nil {
+		snaps, err := s.src.Prices.GetCurrentPrices(ctx)
+		if err != nil {
+			log.Printf("digest prices failed: %v", err)
+		}
+		for _, snap := range snaps {
+			if snap != nil && snap.PriceUSD > 0 {
+				latest[snap.Symbol] = snap.PriceUSD
+			}
+		}
+	}
+
+	out := make([]domain.DigestPriceChange, 0, len(domain.SupportedSymbols))
+	for _, symbol := range domain.SupportedSymbols {
+		candles, err := s.src.Candles.GetCandlesInRange(ctx, symbol, interval, d.From, d.To)
+		if err != nil {
+			log.Printf("digest candles for %s failed: %v", symbol, err)
+			continue
+		}
+		closes := make([]*domain.Candle, 0, len(candles))
+		for _, c := range candles {
+			if c != nil && c.Open > 0 && c.Close > 0 {
+				closes = append(closes, c)
+			}
+		}
+		if len(closes) == 0 {
+			continue
+		}
+		sort.Slice(closes, func(i, j int) bool { return closes[i].OpenTime.Before(closes[j].OpenTime) })
+
+		open := closes[0].Open
+		path := make([]float64, 0, len(closes)+2)
+		path = append(path, 0)
+		for _, c := range closes {
+			path = append(path, pctChange(open, c.Close))
+		}
+		last := closes[len(closes)-1].Close
+		if price, ok := latest[symbol]; ok {
+			last = price
+			path = append(path, pctChange(open, last))
+		}
+		out = append(out, domain.DigestPriceChange{
+			Symbol:    symbol,
+			Open:      open,
+			Close:     last,
+			ChangePct: pctChange(open, last),
+			Path:      path,
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].ChangePct > out[j].ChangePct })
+	return out
+}
+
+// topSignals picks the period's lowest-risk directional signals, newest
+// first within each risk level.
+func (s *DigestService) topSignals(ctx context.Context, d *domain.Digest) []domain.Signal {
+	if s.src.Signals == nil {
+		return nil
+	}
+	out := make([]domain.Signal, 0, digestTopN)
+	for risk := domain.RiskLevel1; risk <= domain.RiskLevel5 && len(out) < digestTopN; risk++ {
+		r := risk
+		signals, err := s.src.Signals.ListSignals(ctx, domain.SignalFilter{Risk: &r, Since: d.From, Limit: digestSignalCap})
+		if err != nil {
+			log.Printf("digest signals failed: %v", err)
+			return out
+		}
+		for _, sig := range signals {
+			if sig.Direction == domain.DirectionHold {
+				continue
+			}
+			out = append(out, sig)
+			if len(out) == digestTopN {
+				break
+			}
+		}
+	}
+	return out
+}
+
+func (s *DigestService) mlMetrics(ctx context.Context, d *domain.Digest) *domain.MLMetrics {
+	if s.src.ML == nil {
+		return nil
+	}
+	report, err := s.src.ML.Analyze(ctx, domain.MLAnalyticsQuery{From: d.From, To: d.To})
+	if err != nil {
+		log.Printf("digest ml analytics failed: %v", err)
+		return nil
+	}
+	if report == nil || report.Overall.Metrics.Predictions == 0 {
+		return nil
+	}
+	m := report.Overall.Metrics
+	return &m
+}
+
+func (s *DigestService) marketIntel(ctx context.Context, d *domain.Digest) ([]domain.DigestSentimentMove, []domain.DigestHeadline) {
+	if s.src.MarketIntel == nil {
+		return nil, nil
+	}
+
+	var moves []domain.DigestSentimentMove
+	snaps, err := s.src.MarketIntel.ListCompositeScores(ctx, "4h", d.From, d.To)
+	if err != nil {
+		log.Printf("digest composite scores failed: %v", err)
+	} else {
+		moves = sentimentMoves(snaps)
+	}
+
+	var headlines []domain.DigestHeadline
+	items, err := s.src.MarketIntel.ListTopHeadlines(ctx, d.From, d.To, digestTopN)
+	if err != nil {
+		log.Printf("digest headlines failed: %v", err)
+	} else {
+		headlines = make([]domain.DigestHeadline, 0, len(items))
+		for _, item := range items {
+			headlines = append(headlines, domain.DigestHeadline{
+				Title:       item.Title,
+				URL:         item.URL,
+				Source:      item.Source,
+				Sentiment:   item.SentimentScore,
+				PublishedAt: item.PublishedAt,
+			})
+		}
+	}
+	return moves, headlines
+}
+
+// sentimentMoves compares each symbol's first and last composite score,
+// keeping the largest moves. snaps must be oldest first.
+func sentimentMoves(snaps []domain.MarketCompositeSnapshot) []domain.DigestSentimentMove {
+	bySymbol := make(map[string]*domain.DigestSentimentMove)
+	order := make([]string, 0)
+	for _, snap := range snaps {
+		m, ok := bySymbol[snap.Symbol]
+		if !ok {
+			m = &domain.DigestSentimentMove{Symbol: snap.Symbol, From: snap.CompositeScore}
+			bySymbol[snap.Symbol] = m
+			order = append(order, snap.Symbol)
+		}
+		m.To = snap.CompositeScore
+		m.Direction = snap.Direction
+	}
+	out := make([]domain.DigestSentimentMove, 0, len(order))
+	for _, symbol := range order {
+		m := bySymbol[symbol]
+		m.Change = m.To - m.From
+		if m.Change != 0 {
+			out = append(out, *m)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return math.Abs(out[i].Change) > math.Abs(out[j].Change) })
+	if len(out) > digestTopN {
+		out = out[:digestTopN]
+	}
+	return out
+}
+
+// templateDigestSummary is the deterministic summary used without an LLM.
+func templateDigestSummary(d *domain.Digest) string {
+	label := "today"
+	if d.Period == domain.DigestWeekly {
+		label = "this week"
+	}
+	if len(d.Prices) == 0 {
+		return fmt.Sprintf("No price data was available %s.", label)
+	}
+
+	up := 0
+	for _, p := range d.Prices {
+		if p.ChangePct > 0 {
+			up++
+		}
+	}
+	best, worst := d.Prices[0], d.Prices[len(d.Prices)-1]
+	parts := []string{fmt.Sprintf("%d of %d tracked assets rose %s.", up, len(d.Prices), label)}
+	if len(d.Prices) > 1 {
+		parts = append(parts, fmt.Sprintf("%s led at %+.2f%% and %s lagged at %+.2f%%.", best.Symbol, best.ChangePct, worst.Symbol, worst.ChangePct))
+	} else {
+		parts = append(parts, fmt.Sprintf("%s moved %+.2f%%.", best.Symbol, best.ChangePct))
+	}
+	if d.ML != nil {
+		parts = append(parts, fmt.Sprintf("ML predictions were %.0f%% accurate over %d calls.", d.ML.Accuracy*100, d.ML.Predictions))
+	}
+	if len(d.SentimentMoves) > 0 {
+		m := d.SentimentMoves[0]
+		parts = append(parts, fmt.Sprintf("The biggest sentiment shift was %s (%+.2f).", m.Symbol, m.Change))
+	}
+	return strings.Join(parts, " ")
+}
+
+// digestFacts renders the digest as plain lines for the summarizer, so it
+// only states numbers the digest itself shows.
+func digestFacts(d *domain.Digest) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Period: %s, %s to %s UTC\n", d.Period, d.From.Format("2006-01-02 15:04"), d.To.Format("2006-01-02 15:04"))
+	if len(d.Prices) > 0 {
+		sb.WriteString("Price changes:\n")
+		for _, p := range d.Prices {
+			fmt.Fprintf(&sb, "- %s %+.2f%% (%.4f -> %.4f)\n", p.Symbol, p.ChangePct, p.Open, p.Close)
+		}
+	}
+	if len(d.TopSignals) > 0 {
+		sb.WriteString("Top signals:\n")
+		for _, sig := range d.TopSignals {
+			fmt.Fprintf(&sb, "- %s %s %s %s risk %d\n", sig.Symbol, sig.Interval, sig.Indicator, sig.Direction, sig.Risk)
+		}
+	}
+	if d.ML != nil {
+		fmt.Fprintf(&sb, "ML accuracy: %.1f%% over %d predictions\n", d.ML.Accuracy*100, d.ML.Predictions)
+	}
+	if len(d.SentimentMoves) > 0 {
+		sb.WriteString("Sentiment moves:\n")
+		for _, m := range d.SentimentMoves {
+			fmt.Fprintf(&sb, "- %s composite %+.2f -> %+.2f (%s)\n", m.Symbol, m.From, m.To, m.Direction)
+		}
+	}
+	if len(d.Headlines) > 0 {
+		sb.WriteString("Headlines:\n")
+		for _, h := range d.Headlines {
+			fmt.Fprintf(&sb, "- %s (%s)\n", h.Title, h.Source)
+		}
+	}
+	return sb.String()
+}
+
+func pctChange(from, to float64) float64 {
+	if from == 0 {
+		return 0
+	}
+	return (to - from) / from * 100
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type memDigestStore struct {
+	subs map[int64]domain.DigestSubscription
+	sent map[int64]time.Time
+}
+
+func newMemDigestStore() *memDigestStore {
+	return &memDigestStore{subs: map[int64]domain.DigestSubscription{}, sent: map[int64]time.Time{}}
+}
+
+func (m *memDigestStore) ListDigestSubscriptions(ctx context.Context) ([]domain.DigestSubscription, error) {
+	out := make([]domain.DigestSubscription, 0, len(m.subs))
+	for _, sub := range m.subs {
+		out = append(out, sub)
+	}
+	return out, nil
+}
+
+func (m *memDigestStore) GetDigestSubscription(ctx context.Context, chatID int64) (*domain.DigestSubscription, error) {
+	sub, ok := m.subs[chatID]
+	if !ok {
+		return nil, nil
+	}
+	return &sub, nil
+}
+
+func (m *memDigestStore) SaveDigestSubscription(ctx context.Context, sub domain.DigestSubscription) error {
+	m.subs[sub.ChatID] = sub
+	return nil
+}
+
+func (m *memDigestStore) DeleteDigestSubscription(ctx context.Context, chatID int64) (bool, error) {
+	_, ok := m.subs[chatID]
+	delete(m.subs, chatID)
+	return ok, nil
+}
+
+func (m *memDigestStore) ClaimDigest(ctx context.Context, chatID int64, at, due time.Time) (bool, error) {
+	sub, ok := m.subs[chatID]
+	if !ok || (sub.LastSentAt != nil && !sub.LastSentAt.Before(due)) {
+		return false, nil
+	}
+	m.sent[chatID] = at
+	sub.LastSentAt = &at
+	m.subs[chatID] = sub
+	return true, nil
+}
+
+func (m *memDigestStore) ReleaseDigest(ctx context.Context, chatID int64, at time.Time, previous *time.Time) error {
+	sub, ok := m.subs[chatID]
+	if !ok || sub.LastSentAt == nil || !sub.LastSentAt.Equal(at) {
+		return nil
+	}
+	delete(m.sent, chatID)
+	sub.LastSentAt = previous
+	m.subs[chatID] = sub
+	return nil
+}
+
+type digestCandles struct {
+	bySymbol map[string][]*domain.Candle
+	calls    int
+}
+
+func (d *digestCandles) GetCandlesInRange(ctx context.Context, symbol, interval string, from, to time.Time) ([]*domain.Candle, error) {
+	d.calls++
+	return d.bySymbol[symbol], nil
+}
+
+type digestSignals struct {
+	byRisk map[domain.RiskLevel][]domain.Signal
+}
+
+func (d *digestSignals) ListSignals(ctx context.Context, filter domain.SignalFilter) ([]domain.Signal, error) {
+	if filter.Since.IsZero() {
+		return nil, errors.New("expected since filter")
+	}
+	return d.byRisk[*filter.Risk], nil
+}
+
+type digestIntel struct{}
+
+func (digestIntel) ListCompositeScores(ctx context.Context, interval string, from, to time.Time) ([]domain.MarketCompositeSnapshot, error) {
+	return []domain.MarketCompositeSnapshot{
+		{Symbol: "BTC", CompositeScore: 0.1},
+		{Symbol: "ETH", CompositeScore: 0.2},
+		{Symbol: "BTC", CompositeScore: 0.5, Direction: domain.DirectionLong},
+		{Symbol: "ETH", CompositeScore: 0.1, Direction: domain.DirectionHold},
+	}, nil
+}
+
+func (digestIntel) ListTopHeadlines(ctx context.Context, from, to time.Time, limit int) ([]domain.MarketIntelItem, error) {
+	return nil, errors.New("intel down")
+}
+
+type digestSummarizer struct {
+	facts string
+	err   error
+}
+
+func (s *digestSummarizer) SummarizeDigest(ctx context.Context, facts string) (string, error) {
+	s.facts = facts
+	return "LLM summary.", s.err
+}
+
+type recordingDigestSender struct {
+	chats []int64
+	err   error
+}
+
+func (r *recordingDigestSender) SendDigest(ctx context.Context, chatID int64, d *domain.Digest) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.chats = append(r.chats, chatID)
+	return nil
+}
+
+func digestCandle(open time.Time, o, c float64) *domain.Candle {
+	return &domain.Candle{OpenTime: open, Open: o, Close: c}
+}
+
+func newTestDigestService(now time.Time) (*DigestService, *memDigestStore, *digestCandles) {
+	store := newMemDigestStore()
+	candles := &digestCandles{bySymbol: map[string][]*domain.Candle{
+		// Newest first, as the candle repository returns them.
+		"BTC": {digestCandle(now.Add(-time.Hour), 105, 110), digestCandle(now.Add(-2*time.Hour), 100, 105)},
+		"ETH": {digestCandle(now.Add(-time.Hour), 98, 95), digestCandle(now.Add(-2*time.Hour), 100, 98)},
+	}}
+	svc := NewDigestService(trace.NewNoopTracerProvider().Tracer("test"), store, DigestSources{
+		Prices:  &mutablePrices{prices: []*domain.PriceSnapshot{{Symbol: "BTC", PriceUSD: 120}}},
+		Candles: candles,
+		Signals: &digestSignals{byRisk: map[domain.RiskLevel][]domain.Signal{
+			domain.RiskLevel1: {{Symbol: "BTC", Direction: domain.DirectionHold, Risk: domain.RiskLevel1}},
+			domain.RiskLevel2: {{Symbol: "ETH", Direction: domain.DirectionShort, Risk: domain.RiskLevel2}},
+		}},
+		MarketIntel: digestIntel{},
+	})
+	svc.now = func() time.Time { return now }
+	return svc, store, candles
+}
+
+func TestDigestBuildComposesSections(t *testing.T) {
+	now := time.Date(2026, 6, 10, 9, 0, 0, 0, time.UTC)
+	svc, _, _ := newTestDigestService(now)
+
+	d, err := svc.BuildDigest(context.Background(), domain.DigestDaily)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(d.Prices) != 2 || d.Prices[0].Symbol != "BTC" || d.Prices[0].ChangePct != 20 || d.Prices[1].ChangePct != -5 {
+		t.Fatalf("unexpected prices: %+v", d.Prices)
+	}
+	if got := d.Prices[0].Path; len(got) != 4 || got[0] != 0 || got[1] != 5 || got[3] != 20 {
+		t.Fatalf("expected path from open to live price, got %v", got)
+	}
+	if len(d.TopSignals) != 1 || d.TopSignals[0].Symbol != "ETH" {
+		t.Fatalf("expected hold signals skipped, got %+v", d.TopSignals)
+	}
+	if len(d.SentimentMoves) != 2 || d.SentimentMoves[0].Symbol != "BTC" || d.SentimentMoves[0].Direction != domain.DirectionLong {
+		t.Fatalf("unexpected sentiment moves: %+v", d.SentimentMoves)
+	}
+	if d.Headlines != nil {
+		t.Fatalf("expected failed headlines to be skipped, got %+v", d.Headlines)
+	}
+	if d.SummaryByLLM || !strings.Contains(d.Summary, "1 of 2 tracked assets rose today") {
+		t.Fatalf("unexpected template summary: %q", d.Summary)
+	}
+}
+
+func TestDigestBuildUsesSummarizerAndCache(t *testing.T) {
+	now := time.Date(2026, 6, 10, 9, 0, 0, 0, time.UTC)
+	svc, _, candles := newTestDigestService(now)
+	summarizer := &digestSummarizer{}
+	svc.SetSummarizer(summarizer)
+
+	d, err := svc.BuildDigest(context.Background(), domain.DigestDaily)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !d.SummaryByLLM || d.Summary != "LLM summary." || !strings.Contains(summarizer.facts, "BTC +20.00%") {
+		t.Fatalf("unexpected summary %q from facts %q", d.Summary, summarizer.facts)
+	}
+
+	calls := candles.calls
+	if _, err := svc.BuildDigest(context.Background(), domain.DigestDaily); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if candles.calls != calls {
+		t.Fatal("expected cached digest to be reused")
+	}
+
+	svc.now = func() time.Time { return now.Add(digestCacheTTL) }
+	summarizer.err = errors.New("llm down")
+	d, err = svc.BuildDigest(context.Background(), domain.DigestDaily)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.SummaryByLLM || candles.calls == calls {
+		t.Fatalf("expected rebuild with template fallback, got %+v", d)
+	}
+}
+
+func TestDigestSubscribeValidates(t *testing.T) {
+	now := time.Date(2026, 6, 10, 9, 0, 0, 0, time.UTC)
+	svc, store, _ := newTestDigestService(now)
+	ctx := context.Background()
+
+	bad := []domain.DigestSubscription{
+		{ChatID: 7, Period: "hourly"},
+		{ChatID: 7, Period: domain.DigestDaily, Minute: 24 * 60},
+		{ChatID: 7, Period: domain.DigestDaily, Timezone: "Mars/Olympus"},
+	}
+	for _, sub := range bad {
+		if _, err := svc.SubscribeDigest(ctx, sub); !errors.Is(err, ErrInvalidDigest) {
+			t.Fatalf("expected ErrInvalidDigest for %+v, got %v", sub, err)
+		}
+	}
+
+	sub, err := svc.SubscribeDigest(ctx, domain.DigestSubscription{ChatID: 7, Period: domain.DigestDaily, Minute: 480, Weekday: time.Friday})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sub.Timezone != "UTC" || sub.Weekday != time.Sunday || sub.LastSentAt == nil || !sub.LastSentAt.Equal(now) {
+		t.Fatalf("unexpected normalized schedule: %+v", sub)
+	}
+	if _, ok := store.subs[7]; !ok {
+		t.Fatal("expected schedule to be stored")
+	}
+}
+
+func TestDigestSendDueHonoursSchedule(t *testing.T) {
+	now := time.Date(2026, 6, 10, 9, 0, 0, 0, time.UTC)
+	svc, store, _ := newTestDigestService(now)
+	sender := &recordingDigestSender{}
+	svc.SetSender(sender)
+
+	yesterday := now.Add(-24 * time.Hour)
+	justSent := now.Add(-30 * time.Minute)
+	store.subs[1] = domain.DigestSubscription{ChatID: 1, Period: domain.DigestDaily, Minute: 8 * 60, Timezone: "UTC", LastSentAt: &yesterday}
+	store.subs[2] = domain.DigestSubscription{ChatID: 2, Period: domain.DigestDaily, Minute: 8 * 60, Timezone: "UTC", LastSentAt: &justSent}
+	store.subs[3] = domain.DigestSubscription{ChatID: 3, Period: domain.DigestDaily, Minute: 10 * 60, Timezone: "UTC", LastSentAt: &yesterday}
+	// Due at 03:00 UTC, too long ago to catch up.
+	store.subs[4] = domain.DigestSubscription{ChatID: 4, Period: domain.DigestDaily, Minute: 3 * 60, Timezone: "UTC", LastSentAt: &yesterday}
+
+	if err := svc.SendDue(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sender.chats) != 1 || sender.chats[0] != 1 {
+		t.Fatalf("expected only chat 1 to be sent, got %v", sender.chats)
+	}
+	if at, ok := store.sent[1]; !ok || !at.Equal(now) {
+		t.Fatalf("expected chat 1 marked sent, got %v", store.sent)
+	}
+
+	if err := svc.SendDue(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sender.chats) != 1 {
+		t.Fatalf("expected no resend, got %v", sender.chats)
+	}
+}
+
+func TestDigestSendDueClaimsBeforeSending(t *testing.T) {
+	now := time.Date(2026, 6, 10, 9, 0, 0, 0, time.UTC)
+	svc, store, _ := newTestDigestService(now)
+	sender := &recordingDigestSender{err: errors.New("telegram down")}
+	svc.SetSender(sender)
+	yesterday := now.Add(-24 * time.Hour)
+	store.subs[1] = domain.DigestSubscription{ChatID: 1, Period: domain.DigestDaily, Minute: 8 * 60, Timezone: "UTC", LastSentAt: &yesterday}
+
+	if err := svc.SendDue(context.Background()); err == nil {
+		t.Fatal("expected the send failure reported")
+	}
+	if sub := store.subs[1]; sub.LastSentAt == nil || !sub.LastSentAt.Equal(yesterday) {
+		t.Fatalf("expected the claim released after a failed send, got %v", sub.LastSentAt)
+	}
+
+	// A replica that listed the schedule before this one sent it must not
+	// send it again.
+	replica, _, _ := newTestDigestService(now)
+	replica.store = &staleDigestStore{memDigestStore: store, snapshot: []domain.DigestSubscription{store.subs[1]}}
+	replicaSender := &recordingDigestSender{}
+	replica.SetSender(replicaSender)
+	sender.err = nil
+	if err := svc.SendDue(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := replica.SendDue(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sender.chats) != 1 || len(replicaSender.chats) != 0 {
+		t.Fatalf("expected one send across replicas, got %v and %v", sender.chats, replicaSender.chats)
+	}
+}
+
+// staleDigestStore lists schedules as they were before another replica
+// sent them.
+type staleDigestStore struct {
+	*memDigestStore
+	snapshot []domain.DigestSubscription
+}
+
+func (s *staleDigestStore) ListDigestSubscriptions(ctx context.Context) ([]domain.DigestSubscription, error) {
+	return s.snapshot, nil
+}
@@ -0,0 +1,518 @@
+package service
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	maxHoldingsImportRows   = 10_000
+	maxHoldingsImportErrors = 10
+
+	// holdingQuantityEpsilon treats float dust left by partial sells as a
+	// closed lot.
+	holdingQuantityEpsilon = 1e-9
+)
+
+// ErrInvalidHoldingTrade wraps validation failures for trades and imports.
+var ErrInvalidHoldingTrade = errors.New("invalid holding trade")
+
+// ErrHoldingTradeNotFound is returned when deleting a trade the user does not
+// have.
+var ErrHoldingTradeNotFound = errors.New("holding trade not found")
+
+type HoldingsStore interface {
+	AddTrade(ctx context.Context, t domain.HoldingTrade) (*domain.HoldingTrade, error)
+	InsertTrades(ctx context.Context, trades []domain.HoldingTrade) (int, error)
+	ListTrades(ctx context.Context, chatID int64) ([]domain.HoldingTrade, error)
+	DeleteTrade(ctx context.Context, chatID, id int64) (bool, error)
+	ShareWithAdvisor(ctx context.Context, chatID int64) (bool, error)
+	SetShareWithAdvisor(ctx context.Context, chatID int64, share bool) error
+}
+
+type HoldingsPriceSource interface {
+	GetCurrentPrices(ctx context.Context) ([]*domain.PriceSnapshot, error)
+}
+
+// HoldingsService tracks users' real portfolios from their trade history and
+// values them at current prices. Cost basis follows FIFO lots.
+type HoldingsService struct {
+	tracer trace.Tracer
+	store  HoldingsStore
+	prices HoldingsPriceSource
+}
+
+func NewHoldingsService(tracer trace.Tracer, store HoldingsStore, prices HoldingsPriceSource) *HoldingsService {
+	return &HoldingsService{tracer: tracer, store: store, prices: prices}
+}
+
+// AddTrade records a manual trade. A zero ExecutedAt means now.
+func (s *HoldingsService) AddTrade(ctx context.Context, t domain.HoldingTrade) (*domain.HoldingTrade, error) {
+	ctx, span := s.tracer.Start(ctx, "holdings-service.add-trade")
+	defer span.End()
+
+	t.Source = domain.HoldingSourceManual
+	t.ExternalID = ""
+	if t.ExecutedAt.IsZero() {
+		t.ExecutedAt = time.Now().UTC()
+	}
+	if err := validateHoldingTrade(&t); err != nil {
+		return nil, err
+	}
+	return s.store.AddTrade(ctx, t)
+}
+
+// ImportCSV records the trades in an exchange trade-history export. Columns
+// are matched by header name, so exports from most exchanges work as long
+// as they have a time, symbol or pair, side, quantity and price column.
+// Rows that are not buys or sells of a tracked symbol are skipped, and
+// importing the same file twice adds nothing the second time.
+func (s *HoldingsService) ImportCSV(ctx context.Context, chatID int64, r io.Reader) (*domain.HoldingsImportResult, error) {
+	ctx, span := s.tracer.Start(ctx, "holdings-service.import-csv")
+	defer span.End()
+
+	if chatID == 0 {
+		return nil, fmt.Errorf("%w: chat id is required", ErrInvalidHoldingTrade)
+	}
+	trades, result, err := parseHoldingsCSV(chatID, r)
+	if err != nil {
+		return nil, err
+	}
+	inserted, err := s.store.InsertTrades(ctx, trades)
+	if err != nil {
+		return nil, err
+	}
+	result.Imported = inserted
+	result.Duplicates = len(trades) - inserted
+	span.SetAttributes(
+		attribute.Int("holdings.imported", result.Imported),
+		attribute.Int("holdings.skipped", result.Skipped),
+	)
+	return result, nil
+}
+
+func (s *HoldingsService) Trades(ctx context.Context, chatID int64) ([]domain.HoldingTrade, error) {
+	ctx, span := s.tracer.Start(ctx, "holdings-service.trades")
+	defer span.End()
+
+	return s.store.ListTrades(ctx, chatID)
+}
+
+func (s *HoldingsService) DeleteTrade(ctx context.Context, chatID, id int64) error {
+	ctx, span := s.tracer.Start(ctx, "holdings-service.delete-trade")
+	defer span.End()
+
+	ok, err := s.store.DeleteTrade(ctx, chatID, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrHoldingTradeNotFound
+	}
+	return nil
+}
+
+// SetShareWithAdvisor opts the user in or out of having their holdings
+// included in advisor prompts.
+func (s *HoldingsService) SetShareWithAdvisor(ctx context.Context, chatID int64, share bool) error {
+	ctx, span := s.tracer.Start(ctx, "holdings-service.set-share-with-advisor")
+	defer span.End()
+
+	return s.store.SetShareWithAdvisor(ctx, chatID, share)
+}
+
+// Valuation builds the user's open holdings from their trades and values
+// them at current prices. Symbols without a current price are valued at
+// cost and marked unpriced.
+func (s *HoldingsService) Valuation(ctx context.Context, chatID int64) (*domain.HoldingsValuation, error) {
+	ctx, span := s.tracer.Start(ctx, "holdings-service.valuation")
+	defer span.End()
+
+	trades, err := s.store.ListTrades(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+	share, err := s.store.ShareWithAdvisor(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make(map[string]float64)
+	if len(trades) > 0 {
+		snaps, err := s.prices.GetCurrentPrices(ctx)
+		if err != nil {
+			log.Printf("holdings: current prices unavailable, valuing at cost: %v", err)
+		}
+		for _, p := range snaps {
+			if p != nil && p.PriceUSD > 0 {
+				prices[p.Symbol] = p.PriceUSD
+			}
+		}
+	}
+
+	v := valueHoldings(trades, prices)
+	v.ChatID = chatID
+	v.ShareAdvisor = share
+	span.SetAttributes(attribute.Int("holdings.count", len(v.Holdings)))
+	return v, nil
+}
+
+// AdvisorHoldings returns the valuation for the advisor prompt, or nil when
+// the user has not opted in or holds nothing.
+func (s *HoldingsService) AdvisorHoldings(ctx context.Context, chatID int64) (*domain.HoldingsValuation, error) {
+	share, err := s.store.ShareWithAdvisor(ctx, chatID)
+	if err != nil || !share {
+		return nil, err
+	}
+	v, err := s.Valuation(ctx, chatID)
+	if err != nil || len(v.Holdings) == 0 {
+		return nil, err
+	}
+	return v, nil
+}
+
+func validateHoldingTrade(t *domain.HoldingTrade) error {
+	t.Symbol = strings.ToUpper(strings.TrimSpace(t.Symbol))
+	switch {
+	case t.ChatID == 0:
+		return fmt.Errorf("%w: chat id is required", ErrInvalidHoldingTrade)
+	case t.Symbol == "":
+		return fmt.Errorf("%w: symbol is required", ErrInvalidHoldingTrade)
+	case t.Side != domain.PaperBuy && t.Side != domain.PaperSell:
+		return fmt.Errorf("%w: side must be buy or sell", ErrInvalidHoldingTrade)
+	case !(t.Quantity > 0) || math.IsInf(t.Quantity, 0):
+		return fmt.Errorf("%w: quantity must be positive", ErrInvalidHoldingTrade)
+	case !(t.Price > 0) || math.IsInf(t.Price, 0):
+		return fmt.Errorf("%w: price must be positive", ErrInvalidHoldingTrade)
+	case t.Fee < 0 || math.IsNaN(t.Fee) || math.IsInf(t.Fee, 0):
+		return fmt.Errorf("%w: fee cannot be negative", ErrInvalidHoldingTrade)
+	}
+	if _, ok := domain.CoinGeckoID[t.Symbol]; !ok {
+		return fmt.Errorf("%w: unsupported symbol %s", ErrInvalidHoldingTrade, t.Symbol)
+	}
+	t.ExecutedAt = t.ExecutedAt.UTC()
+	return nil
+}
+
+// valueHoldings replays trades oldest first. Buys open lots costed at price
+// plus fee; sells consume the oldest lots and realise proceeds minus fee
+// against their cost. Selling more than is held, which happens when an
+// import misses older history, only realises PnL on the units that were
+// held.
+func valueHoldings(trades []domain.HoldingTrade, prices map[string]float64) *domain.HoldingsValuation {
+	sorted := append([]domain.HoldingTrade(nil), trades...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].ExecutedAt.Equal(sorted[j].ExecutedAt) {
+			return sorted[i].ExecutedAt.Before(sorted[j].ExecutedAt)
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	lots := make(map[string][]domain.HoldingLot)
+	realized := make(map[string]float64)
+	for _, t := range sorted {
+		if t.Quantity <= 0 {
+			continue
+		}
+		switch t.Side {
+		case domain.PaperBuy:
+			lots[t.Symbol] = append(lots[t.Symbol], domain.HoldingLot{
+				Quantity:   t.Quantity,
+				Price:      t.Price + t.Fee/t.Quantity,
+				AcquiredAt: t.ExecutedAt,
+			})
+		case domain.PaperSell:
+			remaining := t.Quantity
+			open := lots[t.Symbol]
+			var matched, cost float64
+			for len(open) > 0 && remaining > holdingQuantityEpsilon {
+				take := math.Min(open[0].Quantity, remaining)
+				matched += take
+				cost += take * open[0].Price
+				remaining -= take
+				open[0].Quantity -= take
+				if open[0].Quantity <= holdingQuantityEpsilon {
+					open = open[1:]
+				}
+			}
+			lots[t.Symbol] = open
+			if matched > 0 {
+				proceeds := matched*t.Price - t.Fee*matched/t.Quantity
+				realized[t.Symbol] += proceeds - cost
+			}
+		}
+	}
+
+	v := &domain.HoldingsValuation{Holdings: make([]domain.Holding, 0, len(lots)), AsOf: time.Now().UTC()}
+	for _, pnl := range realized {
+		v.RealizedPnL += pnl
+	}
+	for symbol, open := range lots {
+		if len(open) == 0 {
+			continue
+		}
+		h := domain.Holding{Symbol: symbol, RealizedPnL: realized[symbol], Lots: open}
+		for _, lot := range open {
+			h.Quantity += lot.Quantity
+			h.CostBasis += lot.Quantity * lot.Price
+		}
+		h.AvgCost = h.CostBasis / h.Quantity
+		h.Price, h.Priced = prices[symbol]
+		if !h.Priced {
+			h.Price = h.AvgCost
+		}
+		h.MarketValue = h.Quantity * h.Price
+		h.UnrealizedPnL = h.MarketValue - h.CostBasis
+		v.Holdings = append(v.Holdings, h)
+		v.CostBasis += h.CostBasis
+		v.MarketValue += h.MarketValue
+		v.UnrealizedPnL += h.UnrealizedPnL
+	}
+	for i := range v.Holdings {
+		if v.MarketValue > 0 {
+			v.Holdings[i].Allocation = v.Holdings[i].MarketValue / v.MarketValue
+		}
+	}
+	sort.Slice(v.Holdings, func(i, j int) bool {
+		if v.Holdings[i].MarketValue != v.Holdings[j].MarketValue {
+			return v.Holdings[i].MarketValue > v.Holdings[j].MarketValue
+		}
+		return v.Holdings[i].Symbol < v.Holdings[j].Symbol
+	})
+	return v
+}
+
+// CSV header aliases, compared after lower-casing and dropping everything
+// but letters and digits.
+var holdingsCSVColumns = map[string][]string{
+	"time":     {"time", "date", "timestamp", "datetime", "dateutc", "utctime", "executedat", "createdat"},
+	"symbol":   {"symbol", "asset", "coin", "pair", "market", "product", "currency"},
+	"side":     {"side", "type", "transactiontype"},
+	"quantity": {"quantity", "qty", "amount", "size", "executed", "filled", "quantitytransacted"},
+	"price":    {"price", "priceusd", "avgprice", "averageprice", "spotpriceattransaction"},
+	"fee":      {"fee", "fees", "commission", "feeusd", "feesandorspread"},
+}
+
+var holdingsCSVTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"01/02/2006 15:04:05",
+	"01/02/2006 15:04",
+	"01/02/2006",
+}
+
+// Quote currencies stripped from pairs such as BTC-USD or ETHUSDT. Prices
+// are stored in USD, so only dollar quotes are accepted.
+var holdingsQuoteSuffixes = []string{"USDT", "USDC", "BUSD", "USD"}
+
+// Fiat quotes that are recognised but refused, since their prices would be
+// read as USD.
+var holdingsForeignQuotes = []string{"EUR", "GBP"}
+
+var leadingNumber = regexp.MustCompile(`^-?[0-9]*\.?[0-9]+([eE][-+]?[0-9]+)?`)
+
+func parseHoldingsCSV(chatID int64, r io.Reader) ([]domain.HoldingTrade, *domain.HoldingsImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: reading csv header: %v", ErrInvalidHoldingTrade, err)
+	}
+	cols, err := holdingsCSVColumnIndex(header)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := &domain.HoldingsImportResult{}
+	var trades []domain.HoldingTrade
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: line %d: %v", ErrInvalidHoldingTrade, line, err)
+		}
+		if line-1 > maxHoldingsImportRows {
+			return nil, nil, fmt.Errorf("%w: more than %d rows", ErrInvalidHoldingTrade, maxHoldingsImportRows)
+		}
+
+		t, skip, err := parseHoldingsCSVRecord(record, cols)
+		if err != nil {
+			result.Skipped++
+			if len(result.Errors) < maxHoldingsImportErrors {
+				result.Errors = append(result.Errors, fmt.Sprintf("line %d: %v", line, err))
+			}
+			continue
+		}
+		if skip {
+			result.Skipped++
+			continue
+		}
+		t.ChatID = chatID
+		t.Source = domain.HoldingSourceCSV
+		sum := sha1.Sum([]byte(strings.Join(record, "\x1f")))
+		t.ExternalID = hex.EncodeToString(sum[:])
+		trades = append(trades, t)
+	}
+	return trades, result, nil
+}
+
+func holdingsCSVColumnIndex(header []string) (map[string]int, error) {
+	normalized := make([]string, len(header))
+	for i, h := range header {
+		normalized[i] = strings.Map(func(r rune) rune {
+			if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+				return r
+			}
+			return -1
+		}, strings.ToLower(h))
+	}
+
+	cols := make(map[string]int, len(holdingsCSVColumns))
+	for field, aliases := range holdingsCSVColumns {
+	search:
+		for _, alias := range aliases {
+			for i, name := range normalized {
+				if name == alias {
+					cols[field] = i
+					break search
+				}
+			}
+		}
+	}
+
+	var missing []string
+	for _, field := range []string{"time", "symbol", "side", "quantity", "price"} {
+		if _, ok := cols[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: csv has no %s column", ErrInvalidHoldingTrade, strings.Join(missing, ", "))
+	}
+	return cols, nil
+}
+
+// parseHoldingsCSVRecord reports skip for rows that are valid but not a buy
+// or sell of a tracked symbol, such as deposits or unsupported assets.
+func parseHoldingsCSVRecord(record []string, cols map[string]int) (domain.HoldingTrade, bool, error) {
+	field := func(name string) string {
+		i, ok := cols[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var t domain.HoldingTrade
+	switch strings.ToLower(field("side")) {
+	case "buy", "bought", "purchase", "advanced trade buy":
+		t.Side = domain.PaperBuy
+	case "sell", "sold", "sale", "advanced trade sell":
+		t.Side = domain.PaperSell
+	default:
+		return t, true, nil
+	}
+
+	symbol, ok := holdingsCSVSymbol(field("symbol"))
+	if !ok {
+		if quote := holdingsCSVForeignQuote(field("symbol")); quote != "" {
+			return t, false, fmt.Errorf("%s is priced in %s; only USD-quoted trades can be imported", field("symbol"), quote)
+		}
+		return t, true, nil
+	}
+	t.Symbol = symbol
+
+	var err error
+	if t.ExecutedAt, err = holdingsCSVTime(field("time")); err != nil {
+		return t, false, err
+	}
+	if t.Quantity, err = holdingsCSVNumber(field("quantity")); err != nil || t.Quantity == 0 {
+		return t, false, fmt.Errorf("invalid quantity %q", field("quantity"))
+	}
+	t.Quantity = math.Abs(t.Quantity)
+	if t.Price, err = holdingsCSVNumber(field("price")); err != nil || t.Price <= 0 {
+		return t, false, fmt.Errorf("invalid price %q", field("price"))
+	}
+	if fee := field("fee"); fee != "" {
+		if t.Fee, err = holdingsCSVNumber(fee); err != nil {
+			return t, false, fmt.Errorf("invalid fee %q", fee)
+		}
+		t.Fee = math.Abs(t.Fee)
+	}
+	return t, false, nil
+}
+
+func holdingsCSVSymbol(raw string) (string, bool) {
+	symbol := strings.NewReplacer("-", "", "/", "", "_", "", " ", "").Replace(strings.ToUpper(raw))
+	if _, ok := domain.CoinGeckoID[symbol]; ok {
+		return symbol, true
+	}
+	for _, quote := range holdingsQuoteSuffixes {
+		base, found := strings.CutSuffix(symbol, quote)
+		if !found {
+			continue
+		}
+		if _, ok := domain.CoinGeckoID[base]; ok {
+			return base, true
+		}
+	}
+	return "", false
+}
+
+// holdingsCSVForeignQuote returns the quote currency of a known asset
+// quoted in a currency other than USD, or "".
+func holdingsCSVForeignQuote(raw string) string {
+	symbol := strings.NewReplacer("-", "", "/", "", "_", "", " ", "").Replace(strings.ToUpper(raw))
+	for _, quote := range holdingsForeignQuotes {
+		if base, found := strings.CutSuffix(symbol, quote); found {
+			if _, ok := domain.CoinGeckoID[base]; ok {
+				return quote
+			}
+		}
+	}
+	return ""
+}
+
+func holdingsCSVTime(raw string) (time.Time, error) {
+	for _, layout := range holdingsCSVTimeLayouts {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", raw)
+}
+
+// holdingsCSVNumber reads values such as "1,234.5", "$30000" or "0.5BTC".
+func holdingsCSVNumber(raw string) (float64, error) {
+	cleaned := strings.NewReplacer(",", "", "$", "", " ", "").Replace(raw)
+	match := leadingNumber.FindString(cleaned)
+	if match == "" {
+		return 0, fmt.Errorf("not a number: %q", raw)
+	}
+	return strconv.ParseFloat(match, 64)
+}
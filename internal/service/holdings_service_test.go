@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type memHoldingsStore struct {
+	trades []domain.HoldingTrade
+	share  map[int64]bool
+	nextID int64
+}
+
+func newMemHoldingsStore() *memHoldingsStore {
+	return &memHoldingsStore{share: map[int64]bool{}}
+}
+
+func (m *memHoldingsStore) AddTrade(ctx context.Context, t domain.HoldingTrade) (*domain.HoldingTrade, error) {
+	m.nextID++
+	t.ID = m.nextID
+	m.trades = append(m.trades, t)
+	return &t, nil
+}
+
+func (m *memHoldingsStore) InsertTrades(ctx context.Context, trades []domain.HoldingTrade) (int, error) {
+	n := 0
+	for _, t := range trades {
+		dup := false
+		for _, existing := range m.trades {
+			if existing.ChatID == t.ChatID && existing.ExternalID != "" && existing.ExternalID == t.ExternalID {
+				dup = true
+				break
+			}
+		}
+		if dup {
+			continue
+		}
+		m.nextID++
+		t.ID = m.nextID
+		m.trades = append(m.trades, t)
+		n++
+	}
+	return n, nil
+}
+
+func (m *memHoldingsStore) ListTrades(ctx context.Context, chatID int64) ([]domain.HoldingTrade, error) {
+	out := make([]domain.HoldingTrade, 0)
+	for _, t := range m.trades {
+		if t.ChatID == chatID {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (m *memHoldingsStore) DeleteTrade(ctx context.Context, chatID, id int64) (bool, error) {
+	for i, t := range m.trades {
+		if t.ChatID == chatID && t.ID == id {
+			m.trades = append(m.trades[:i], m.trades[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memHoldingsStore) ShareWithAdvisor(ctx context.Context, chatID int64) (bool, error) {
+	return m.share[chatID], nil
+}
+
+func (m *memHoldingsStore) SetShareWithAdvisor(ctx context.Context, chatID int64, share bool) error {
+	m.share[chatID] = share
+	return nil
+}
+
+type stubHoldingsPrices struct {
+	prices []*domain.PriceSnapshot
+	err    error
+}
+
+func (s stubHoldingsPrices) GetCurrentPrices(ctx context.Context) ([]*domain.PriceSnapshot, error) {
+	return s.prices, s.err
+}
+
+func newTestHoldingsService(store *memHoldingsStore, prices ...*domain.PriceSnapshot) *HoldingsService {
+	return NewHoldingsService(trace.NewNoopTracerProvider().Tracer("test"), store, stubHoldingsPrices{prices: prices})
+}
+
+func TestHoldingsValuationUsesFIFOLots(t *testing.T) {
+	store := newMemHoldingsStore()
+	svc := newTestHoldingsService(store,
+		&domain.PriceSnapshot{Symbol: "BTC", PriceUSD: 40000},
+		&domain.PriceSnapshot{Symbol: "ETH", PriceUSD: 2000},
+	)
+	ctx := context.Background()
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC) }
+
+	for _, tr := range []domain.HoldingTrade{
+		{ChatID: 1, Symbol: "btc", Side: domain.PaperBuy, Quantity: 1, Price: 20000, ExecutedAt: day(1)},
+		{ChatID: 1, Symbol: "BTC", Side: domain.PaperBuy, Quantity: 1, Price: 30000, Fee: 100, ExecutedAt: day(2)},
+		{ChatID: 1, Symbol: "BTC", Side: domain.PaperSell, Quantity: 1.5, Price: 35000, Fee: 150, ExecutedAt: day(3)},
+		{ChatID: 1, Symbol: "ETH", Side: domain.PaperBuy, Quantity: 10, Price: 1500, ExecutedAt: day(4)},
+	} {
+		if _, err := svc.AddTrade(ctx, tr); err != nil {
+			t.Fatalf("add trade: %v", err)
+		}
+	}
+
+	v, err := svc.Valuation(ctx, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(v.Holdings) != 2 || v.Holdings[0].Symbol != "BTC" {
+		t.Fatalf("unexpected holdings: %+v", v.Holdings)
+	}
+
+	btc := v.Holdings[0]
+	// The whole first lot and half of the second are sold, leaving 0.5 at
+	// 30100 per unit including the buy fee.
+	if math.Abs(btc.Quantity-0.5) > 1e-9 || math.Abs(btc.CostBasis-15050) > 1e-6 || len(btc.Lots) != 1 {
+		t.Fatalf("unexpected BTC holding: %+v", btc)
+	}
+	// Proceeds 52500 - 150 fee against 20000 + 15050 cost.
+	if math.Abs(btc.RealizedPnL-17300) > 1e-6 || math.Abs(v.RealizedPnL-17300) > 1e-6 {
+		t.Fatalf("unexpected realised PnL: %v / %v", btc.RealizedPnL, v.RealizedPnL)
+	}
+	if math.Abs(btc.UnrealizedPnL-(20000-15050)) > 1e-6 {
+		t.Fatalf("unexpected unrealised PnL: %v", btc.UnrealizedPnL)
+	}
+	if math.Abs(v.MarketValue-40000) > 1e-6 || math.Abs(btc.Allocation-0.5) > 1e-9 {
+		t.Fatalf("unexpected allocation: value %v alloc %v", v.MarketValue, btc.Allocation)
+	}
+}
+
+func TestHoldingsValuationFallsBackToCostWithoutPrice(t *testing.T) {
+	store := newMemHoldingsStore()
+	svc := NewHoldingsService(trace.NewNoopTracerProvider().Tracer("test"), store, stubHoldingsPrices{err: errors.New("down")})
+	ctx := context.Background()
+	if _, err := svc.AddTrade(ctx, domain.HoldingTrade{ChatID: 1, Symbol: "SOL", Side: domain.PaperBuy, Quantity: 2, Price: 100}); err != nil {
+		t.Fatalf("add trade: %v", err)
+	}
+
+	v, err := svc.Valuation(ctx, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(v.Holdings) != 1 || v.Holdings[0].Priced || v.Holdings[0].Price != 100 || v.UnrealizedPnL != 0 {
+		t.Fatalf("expected holding valued at cost, got %+v", v.Holdings)
+	}
+}
+
+func TestHoldingsSellBeyondHistoryRealisesOnlyHeldUnits(t *testing.T) {
+	v := valueHoldings([]domain.HoldingTrade{
+		{ID: 1, Symbol: "ETH", Side: domain.PaperBuy, Quantity: 1, Price: 1000, ExecutedAt: time.Unix(1, 0)},
+		{ID: 2, Symbol: "ETH", Side: domain.PaperSell, Quantity: 2, Price: 1500, Fee: 20, ExecutedAt: time.Unix(2, 0)},
+	}, nil)
+	if len(v.Holdings) != 0 {
+		t.Fatalf("expected no open holdings, got %+v", v.Holdings)
+	}
+	if math.Abs(v.RealizedPnL-490) > 1e-9 {
+		t.Fatalf("expected PnL on the held unit only, got %v", v.RealizedPnL)
+	}
+}
+
+func TestHoldingsAddTradeValidates(t *testing.T) {
+	svc := newTestHoldingsService(newMemHoldingsStore())
+	cases := []domain.HoldingTrade{
+		{Symbol: "BTC", Side: domain.PaperBuy, Quantity: 1, Price: 1},
+		{ChatID: 1, Symbol: "FAKE", Side: domain.PaperBuy, Quantity: 1, Price: 1},
+		{ChatID: 1, Symbol: "BTC", Side: "hold", Quantity: 1, Price: 1},
+		{ChatID: 1, Symbol: "BTC", Side: domain.PaperBuy, Quantity: 0, Price: 1},
+		{ChatID: 1, Symbol: "BTC", Side: domain.PaperBuy, Quantity: 1, Price: math.NaN()},
+		{ChatID: 1, Symbol: "BTC", Side: domain.PaperBuy, Quantity: 1, Price: 1, Fee: -1},
+	}
+	for i, tc := range cases {
+		if _, err := svc.AddTrade(context.Background(), tc); !errors.Is(err, ErrInvalidHoldingTrade) {
+			t.Fatalf("case %d: expected validation error, got %v", i, err)
+		}
+	}
+}
+
+func TestHoldingsImportCSV(t *testing.T) {
+	store := newMemHoldingsStore()
+	svc := newTestHoldingsService(store, &domain.PriceSnapshot{Symbol: "BTC", PriceUSD: 50000})
+	ctx := context.Background()
+
+	csvData := `Date(UTC),Pair,Side,Price,Executed,Fee
+2026-01-01 10:00:00,BTCUSDT,BUY,"30,000",0.5BTC,0.0005
+2026-01-02 10:00:00,BTC-USD,SELL,$40000,0.25,5
+2026-01-03 10:00:00,PEPEUSDT,BUY,0.00001,1000000,0
+2026-01-04 10:00:00,ETHUSDT,DEPOSIT,0,1,0
+not a date,ETHUSDT,BUY,2000,1,0
+2026-01-05 10:00:00,BTC-EUR,BUY,35000,0.1,0
+`
+	res, err := svc.ImportCSV(ctx, 9, strings.NewReader(csvData))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Imported != 2 || res.Skipped != 4 || len(res.Errors) != 2 || !strings.Contains(res.Errors[0], "line 6") ||
+		!strings.Contains(res.Errors[1], "line 7: BTC-EUR is priced in EUR") {
+		t.Fatalf("unexpected import result: %+v", res)
+	}
+	if store.trades[0].Symbol != "BTC" || store.trades[0].Quantity != 0.5 || store.trades[0].Price != 30000 || store.trades[0].Source != domain.HoldingSourceCSV {
+		t.Fatalf("unexpected first trade: %+v", store.trades[0])
+	}
+
+	res, err = svc.ImportCSV(ctx, 9, strings.NewReader(csvData))
+	if err != nil {
+		t.Fatalf("unexpected error on re-import: %v", err)
+	}
+	if res.Imported != 0 || res.Duplicates != 2 {
+		t.Fatalf("expected re-import to add nothing, got %+v", res)
+	}
+
+	v, err := svc.Valuation(ctx, 9)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(v.Holdings) != 1 || math.Abs(v.Holdings[0].Quantity-0.25) > 1e-9 {
+		t.Fatalf("unexpected holdings after import: %+v", v.Holdings)
+	}
+}
+
+func TestHoldingsImportCSVRequiresColumns(t *testing.T) {
+	svc := newTestHoldingsService(newMemHoldingsStore())
+	_, err := svc.ImportCSV(context.Background(), 9, strings.NewReader("date,symbol,amount\n2026-01-01,BTC,1\n"))
+	if !errors.Is(err, ErrInvalidHoldingTrade) || !strings.Contains(err.Error(), "side, price") {
+		t.Fatalf("expected missing column error, got %v", err)
+	}
+}
+
+func TestHoldingsAdvisorRequiresOptIn(t *testing.T) {
+	store := newMemHoldingsStore()
+	svc := newTestHoldingsService(store, &domain.PriceSnapshot{Symbol: "BTC", PriceUSD: 50000})
+	ctx := context.Background()
+	if _, err := svc.AddTrade(ctx, domain.HoldingTrade{ChatID: 3, Symbol: "BTC", Side: domain.PaperBuy, Quantity: 1, Price: 40000}); err != nil {
+		t.Fatalf("add trade: %v", err)
+	}
+
+	v, err := svc.AdvisorHoldings(ctx, 3)
+	if err != nil || v != nil {
+		t.Fatalf("expected nothing shared before opt-in, got %+v %v", v, err)
+	}
+	if err := svc.SetShareWithAdvisor(ctx, 3, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	v, err = svc.AdvisorHoldings(ctx, 3)
+	if err != nil || v == nil || !v.ShareAdvisor || len(v.Holdings) != 1 {
+		t.Fatalf("expected holdings after opt-in, got %+v %v", v, err)
+	}
+}
+
+func TestHoldingsDeleteTradeNotFound(t *testing.T) {
+	svc := newTestHoldingsService(newMemHoldingsStore())
+	if err := svc.DeleteTrade(context.Background(), 1, 99); !errors.Is(err, ErrHoldingTradeNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
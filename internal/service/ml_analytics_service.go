@@ -0,0 +1,301 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultMLAnalyticsDays = 90
+	maxMLAnalyticsDays     = 730
+
+	// MaxMLAnalyticsPredictions bounds how many resolved predictions one
+	// report reads; the most recent ones are kept.
+	MaxMLAnalyticsPredictions = 50_000
+
+	calibrationBins       = 10
+	confidenceBucketWidth = 0.2
+	maxReturnPoints       = 200
+	logLossEpsilon        = 1e-6
+)
+
+// ErrInvalidMLAnalyticsQuery wraps validation failures from Analyze.
+var ErrInvalidMLAnalyticsQuery = errors.New("invalid ml analytics query")
+
+type MLPredictionHistory interface {
+	ListResolvedPredictions(ctx context.Context, q domain.MLAnalyticsQuery, limit int) ([]domain.MLPrediction, error)
+}
+
+// MLAnalyticsService scores resolved ML predictions beyond daily accuracy:
+// classification metrics, calibration and realised return, sliced by any
+// combination of model, symbol, interval, direction, risk and confidence.
+type MLAnalyticsService struct {
+	tracer  trace.Tracer
+	history MLPredictionHistory
+	now     func() time.Time
+}
+
+func NewMLAnalyticsService(tracer trace.Tracer, history MLPredictionHistory) *MLAnalyticsService {
+	return &MLAnalyticsService{tracer: tracer, history: history, now: time.Now}
+}
+
+func (s *MLAnalyticsService) Analyze(ctx context.Context, q domain.MLAnalyticsQuery) (*domain.MLAnalyticsReport, error) {
+	ctx, span := s.tracer.Start(ctx, "ml-analytics-service.analyze")
+	defer span.End()
+
+	if s.history == nil {
+		return nil, fmt.Errorf("ml analytics service unavailable")
+	}
+	q, err := normalizeMLAnalyticsQuery(q, s.now())
+	if err != nil {
+		return nil, err
+	}
+	preds, err := s.history.ListResolvedPredictions(ctx, q, MaxMLAnalyticsPredictions)
+	if err != nil {
+		return nil, fmt.Errorf("load predictions: %w", err)
+	}
+	return buildMLAnalyticsReport(q, preds, len(preds) >= MaxMLAnalyticsPredictions), nil
+}
+
+func normalizeMLAnalyticsQuery(q domain.MLAnalyticsQuery, now time.Time) (domain.MLAnalyticsQuery, error) {
+	groupBy := make([]string, 0, len(q.GroupBy))
+	seen := make(map[string]bool, len(q.GroupBy))
+	for _, dim := range q.GroupBy {
+		dim = strings.ToLower(strings.TrimSpace(dim))
+		if dim == "" || seen[dim] {
+			continue
+		}
+		if !containsDimension(dim) {
+			return q, fmt.Errorf("%w: unsupported group_by %q (use %s)", ErrInvalidMLAnalyticsQuery, dim, strings.Join(domain.MLGroupDimensions, ", "))
+		}
+		seen[dim] = true
+		groupBy = append(groupBy, dim)
+	}
+	if len(groupBy) == 0 {
+		groupBy = []string{domain.MLGroupModel}
+	}
+	q.GroupBy = groupBy
+	q.ModelKey = strings.TrimSpace(q.ModelKey)
+	q.Symbol = strings.ToUpper(strings.TrimSpace(q.Symbol))
+	q.Interval = strings.TrimSpace(q.Interval)
+
+	if q.To.IsZero() {
+		q.To = now.UTC()
+	}
+	if q.From.IsZero() {
+		q.From = q.To.AddDate(0, 0, -defaultMLAnalyticsDays)
+	}
+	q.From, q.To = q.From.UTC(), q.To.UTC()
+	if !q.From.Before(q.To) {
+		return q, fmt.Errorf("%w: from must be before to", ErrInvalidMLAnalyticsQuery)
+	}
+	if q.To.Sub(q.From) > maxMLAnalyticsDays*24*time.Hour {
+		return q, fmt.Errorf("%w: window must be at most %d days", ErrInvalidMLAnalyticsQuery, maxMLAnalyticsDays)
+	}
+	return q, nil
+}
+
+func containsDimension(dim string) bool {
+	for _, d := range domain.MLGroupDimensions {
+		if d == dim {
+			return true
+		}
+	}
+	return false
+}
+
+// buildMLAnalyticsReport scores preds overall and per group. Groups are
+// ordered by prediction count, largest first.
+func buildMLAnalyticsReport(q domain.MLAnalyticsQuery, preds []domain.MLPrediction, truncated bool) *domain.MLAnalyticsReport {
+	resolved := make([]domain.MLPrediction, 0, len(preds))
+	for _, p := range preds {
+		if p.ActualUp != nil {
+			resolved = append(resolved, p)
+		}
+	}
+	sort.SliceStable(resolved, func(i, j int) bool { return resolved[i].TargetTime.Before(resolved[j].TargetTime) })
+
+	buckets := make(map[string][]domain.MLPrediction)
+	values := make(map[string][]string)
+	for _, p := range resolved {
+		vals := make([]string, len(q.GroupBy))
+		for i, dim := range q.GroupBy {
+			vals[i] = mlDimensionValue(p, dim)
+		}
+		key := strings.Join(vals, " / ")
+		buckets[key] = append(buckets[key], p)
+		values[key] = vals
+	}
+
+	report := &domain.MLAnalyticsReport{
+		Query:     q,
+		Overall:   scoreMLPredictions("all", nil, resolved),
+		Groups:    make([]domain.MLAnalyticsGroup, 0, len(buckets)),
+		Truncated: truncated,
+	}
+	for key, group := range buckets {
+		report.Groups = append(report.Groups, scoreMLPredictions(key, values[key], group))
+	}
+	sort.Slice(report.Groups, func(i, j int) bool {
+		a, b := report.Groups[i], report.Groups[j]
+		if a.Metrics.Predictions != b.Metrics.Predictions {
+			return a.Metrics.Predictions > b.Metrics.Predictions
+		}
+		return a.Key < b.Key
+	})
+	return report
+}
+
+func mlDimensionValue(p domain.MLPrediction, dim string) string {
+	switch dim {
+	case domain.MLGroupModel:
+		return p.ModelKey
+	case domain.MLGroupSymbol:
+		return p.Symbol
+	case domain.MLGroupInterval:
+		return p.Interval
+	case domain.MLGroupDirection:
+		return string(p.Direction)
+	case domain.MLGroupRisk:
+		return fmt.Sprintf("%d", p.Risk)
+	case domain.MLGroupConfidence:
+		return confidenceBucket(p.Confidence)
+	}
+	return ""
+}
+
+// confidenceBucket labels confidence in steps of confidenceBucketWidth, with
+// 1.0 folded into the top bucket.
+func confidenceBucket(c float64) string {
+	n := int(1 / confidenceBucketWidth)
+	i := int(math.Floor(c / confidenceBucketWidth))
+	if i < 0 {
+		i = 0
+	}
+	if i >= n {
+		i = n - 1
+	}
+	lo := float64(i) * confidenceBucketWidth
+	return fmt.Sprintf("%.1f-%.1f", lo, lo+confidenceBucketWidth)
+}
+
+// predictedUp mirrors how ResolveOutcomes judges a prediction: an explicit
+// direction wins, otherwise prob_up decides.
+func predictedUp(p domain.MLPrediction) bool {
+	switch p.Direction {
+	case domain.DirectionLong:
+		return true
+	case domain.DirectionShort:
+		return false
+	}
+	return p.ProbUp >= 0.5
+}
+
+// scoreMLPredictions computes metrics, a reliability curve and the
+// cumulative return path for preds, which must be sorted by target time and
+// all have ActualUp set.
+func scoreMLPredictions(key string, values []string, preds []domain.MLPrediction) domain.MLAnalyticsGroup {
+	g := domain.MLAnalyticsGroup{Key: key, Values: values, Calibration: []domain.CalibrationBin{}, Returns: []domain.ReturnPoint{}}
+	m := &g.Metrics
+	m.Predictions = len(preds)
+	if len(preds) == 0 {
+		return g
+	}
+
+	var tp, predUp, actualUp int
+	var brier, logLoss float64
+	bins := make([]domain.CalibrationBin, calibrationBins)
+	var cumulative float64
+	returns := make([]domain.ReturnPoint, 0, len(preds))
+	for _, p := range preds {
+		up := *p.ActualUp
+		y := 0.0
+		if up {
+			y = 1
+			actualUp++
+		}
+		if p.IsCorrect != nil && *p.IsCorrect {
+			m.Correct++
+		}
+		if predictedUp(p) {
+			predUp++
+			if up {
+				tp++
+			}
+		}
+
+		prob := math.Min(math.Max(p.ProbUp, 0), 1)
+		brier += (prob - y) * (prob - y)
+		clipped := math.Min(math.Max(prob, logLossEpsilon), 1-logLossEpsilon)
+		logLoss -= y*math.Log(clipped) + (1-y)*math.Log(1-clipped)
+
+		b := int(prob * calibrationBins)
+		if b >= calibrationBins {
+			b = calibrationBins - 1
+		}
+		bins[b].Count++
+		bins[b].MeanPredicted += prob
+		bins[b].ObservedUp += y
+
+		if p.RealizedReturn != nil && p.Direction != domain.DirectionHold {
+			sign := 1.0
+			if p.Direction == domain.DirectionShort {
+				sign = -1
+			}
+			cumulative += sign * *p.RealizedReturn
+			m.Positions++
+			returns = append(returns, domain.ReturnPoint{Time: p.TargetTime, Cumulative: cumulative})
+		}
+	}
+
+	n := float64(len(preds))
+	m.Accuracy = float64(m.Correct) / n
+	if predUp > 0 {
+		m.Precision = float64(tp) / float64(predUp)
+	}
+	if actualUp > 0 {
+		m.Recall = float64(tp) / float64(actualUp)
+	}
+	m.Brier = brier / n
+	m.LogLoss = logLoss / n
+	m.CumulativeReturn = cumulative
+	if m.Positions > 0 {
+		m.AvgReturn = cumulative / float64(m.Positions)
+	}
+
+	for i, b := range bins {
+		if b.Count == 0 {
+			continue
+		}
+		b.Lower = float64(i) / calibrationBins
+		b.Upper = float64(i+1) / calibrationBins
+		b.MeanPredicted /= float64(b.Count)
+		b.ObservedUp /= float64(b.Count)
+		g.Calibration = append(g.Calibration, b)
+	}
+	g.Returns = downsampleReturns(returns, maxReturnPoints)
+	return g
+}
+
+// downsampleReturns keeps at most max evenly spaced points, always including
+// the last.
+func downsampleReturns(points []domain.ReturnPoint, max int) []domain.ReturnPoint {
+	if len(points) <= max {
+		return points
+	}
+	out := make([]domain.ReturnPoint, 0, max)
+	step := float64(len(points)-1) / float64(max-1)
+	for i := 0; i < max; i++ {
+		out = append(out, points[int(math.Round(float64(i)*step))])
+	}
+	return out
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type stubMLPredictionHistory struct {
+	preds     []domain.MLPrediction
+	lastQuery domain.MLAnalyticsQuery
+	lastLimit int
+	err       error
+}
+
+func (s *stubMLPredictionHistory) ListResolvedPredictions(ctx context.Context, q domain.MLAnalyticsQuery, limit int) ([]domain.MLPrediction, error) {
+	s.lastQuery = q
+	s.lastLimit = limit
+	return s.preds, s.err
+}
+
+func resolvedPrediction(model, symbol string, at time.Time, probUp float64, dir domain.SignalDirection, up bool, ret float64) domain.MLPrediction {
+	correct := (dir == domain.DirectionLong) == up
+	if dir == domain.DirectionHold {
+		correct = (probUp >= 0.5) == up
+	}
+	return domain.MLPrediction{
+		Symbol: symbol, Interval: "1h", ModelKey: model, TargetTime: at,
+		ProbUp: probUp, Confidence: math.Abs(probUp-0.5) * 2, Direction: dir, Risk: domain.RiskLevel3,
+		ActualUp: &up, IsCorrect: &correct, RealizedReturn: &ret,
+	}
+}
+
+func TestMLAnalyticsServiceGroupsByModelAndSymbol(t *testing.T) {
+	base := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	at := func(h int) time.Time { return base.Add(time.Duration(h) * time.Hour) }
+	history := &stubMLPredictionHistory{preds: []domain.MLPrediction{
+		// xgboost calls BTC right every time ...
+		resolvedPrediction("ml_xgboost_up4h", "BTC", at(3), 0.8, domain.DirectionLong, true, 0.02),
+		resolvedPrediction("ml_xgboost_up4h", "BTC", at(1), 0.2, domain.DirectionShort, false, -0.01),
+		// ... and DOGE wrong every time.
+		resolvedPrediction("ml_xgboost_up4h", "DOGE", at(2), 0.8, domain.DirectionLong, false, -0.03),
+		resolvedPrediction("ml_xgboost_up4h", "DOGE", at(4), 0.3, domain.DirectionShort, true, 0.01),
+		{ModelKey: "ml_xgboost_up4h", Symbol: "BTC"},
+	}}
+	svc := NewMLAnalyticsService(trace.NewNoopTracerProvider().Tracer("test"), history)
+	svc.now = func() time.Time { return base.AddDate(0, 0, 10) }
+
+	report, err := svc.Analyze(context.Background(), domain.MLAnalyticsQuery{GroupBy: []string{" Model ", "symbol", "model"}, Symbol: " btc"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	q := history.lastQuery
+	if len(q.GroupBy) != 2 || q.Symbol != "BTC" || q.To.Sub(q.From) != defaultMLAnalyticsDays*24*time.Hour {
+		t.Fatalf("unexpected normalized query: %+v", q)
+	}
+	if history.lastLimit != MaxMLAnalyticsPredictions || report.Truncated {
+		t.Fatalf("unexpected limit handling: %d truncated=%v", history.lastLimit, report.Truncated)
+	}
+
+	if report.Overall.Metrics.Predictions != 4 || report.Overall.Metrics.Accuracy != 0.5 {
+		t.Fatalf("expected unresolved rows skipped and 50%% accuracy, got %+v", report.Overall.Metrics)
+	}
+	if len(report.Groups) != 2 {
+		t.Fatalf("expected 2 groups, got %+v", report.Groups)
+	}
+	groups := map[string]domain.MLAnalyticsGroup{}
+	for _, g := range report.Groups {
+		groups[g.Key] = g
+	}
+	btc, doge := groups["ml_xgboost_up4h / BTC"], groups["ml_xgboost_up4h / DOGE"]
+	if len(btc.Values) != 2 || btc.Values[1] != "BTC" {
+		t.Fatalf("unexpected group values: %+v", btc.Values)
+	}
+	if btc.Metrics.Accuracy != 1 || btc.Metrics.Precision != 1 || btc.Metrics.Recall != 1 {
+		t.Fatalf("unexpected BTC metrics: %+v", btc.Metrics)
+	}
+	if doge.Metrics.Accuracy != 0 || doge.Metrics.Precision != 0 || doge.Metrics.Recall != 0 {
+		t.Fatalf("unexpected DOGE metrics: %+v", doge.Metrics)
+	}
+	if math.Abs(btc.Metrics.Brier-0.04) > 1e-9 || math.Abs(doge.Metrics.Brier-(0.64+0.49)/2) > 1e-9 {
+		t.Fatalf("unexpected brier scores: %.4f %.4f", btc.Metrics.Brier, doge.Metrics.Brier)
+	}
+	if want := -math.Log(0.8); math.Abs(btc.Metrics.LogLoss-want) > 1e-9 {
+		t.Fatalf("expected log loss %.4f, got %.4f", want, btc.Metrics.LogLoss)
+	}
+
+	// Short on a falling price earns, long on a rising one earns.
+	if math.Abs(btc.Metrics.CumulativeReturn-0.03) > 1e-9 || btc.Metrics.Positions != 2 || math.Abs(btc.Metrics.AvgReturn-0.015) > 1e-9 {
+		t.Fatalf("unexpected BTC returns: %+v", btc.Metrics)
+	}
+	if len(btc.Returns) != 2 || !btc.Returns[0].Time.Equal(at(1)) || math.Abs(btc.Returns[0].Cumulative-0.01) > 1e-9 {
+		t.Fatalf("expected return path in target time order, got %+v", btc.Returns)
+	}
+	if math.Abs(doge.Metrics.CumulativeReturn+0.04) > 1e-9 {
+		t.Fatalf("unexpected DOGE return: %.4f", doge.Metrics.CumulativeReturn)
+	}
+
+	if len(btc.Calibration) != 2 || btc.Calibration[0].Lower != 0.2 || btc.Calibration[0].ObservedUp != 0 || btc.Calibration[1].Upper != 0.9 || btc.Calibration[1].ObservedUp != 1 {
+		t.Fatalf("unexpected calibration: %+v", btc.Calibration)
+	}
+}
+
+func TestMLAnalyticsServiceDimensions(t *testing.T) {
+	at := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	hold := resolvedPrediction("ml_logreg_up4h", "ETH", at, 0.55, domain.DirectionHold, true, 0.02)
+	hold.Risk = domain.RiskLevel5
+	history := &stubMLPredictionHistory{preds: []domain.MLPrediction{
+		hold,
+		resolvedPrediction("ml_logreg_up4h", "ETH", at, 1, domain.DirectionLong, true, 0.01),
+	}}
+	svc := NewMLAnalyticsService(trace.NewNoopTracerProvider().Tracer("test"), history)
+
+	report, err := svc.Analyze(context.Background(), domain.MLAnalyticsQuery{GroupBy: []string{"direction", "risk", "confidence"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	keys := map[string]domain.MLMetrics{}
+	for _, g := range report.Groups {
+		keys[g.Key] = g.Metrics
+	}
+	if m, ok := keys["hold / 5 / 0.0-0.2"]; !ok || m.Positions != 0 || m.CumulativeReturn != 0 {
+		t.Fatalf("expected hold predictions to take no position, got %+v", keys)
+	}
+	if _, ok := keys["long / 3 / 0.8-1.0"]; !ok {
+		t.Fatalf("expected full confidence in the top bucket, got %+v", keys)
+	}
+	if report.Overall.Calibration[len(report.Overall.Calibration)-1].Upper != 1 {
+		t.Fatalf("expected prob_up of 1 in the last calibration bin, got %+v", report.Overall.Calibration)
+	}
+}
+
+func TestMLAnalyticsServiceValidation(t *testing.T) {
+	svc := NewMLAnalyticsService(trace.NewNoopTracerProvider().Tracer("test"), &stubMLPredictionHistory{})
+	now := time.Now().UTC()
+	cases := []domain.MLAnalyticsQuery{
+		{GroupBy: []string{"weather"}},
+		{From: now, To: now.Add(-time.Hour)},
+		{From: now.AddDate(-3, 0, 0), To: now},
+	}
+	for i, q := range cases {
+		if _, err := svc.Analyze(context.Background(), q); !errors.Is(err, ErrInvalidMLAnalyticsQuery) {
+			t.Fatalf("case %d: expected invalid query error, got %v", i, err)
+		}
+	}
+
+	report, err := svc.Analyze(context.Background(), domain.MLAnalyticsQuery{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Query.GroupBy) != 1 || report.Query.GroupBy[0] != domain.MLGroupModel || len(report.Groups) != 0 {
+		t.Fatalf("expected default model grouping and empty report, got %+v", report)
+	}
+
+	if _, err := NewMLAnalyticsService(trace.NewNoopTracerProvider().Tracer("test"), nil).Analyze(context.Background(), domain.MLAnalyticsQuery{}); err == nil {
+		t.Fatal("expected error without history")
+	}
+}
+
+func TestDownsampleReturns(t *testing.T) {
+	points := make([]domain.ReturnPoint, 1000)
+	for i := range points {
+		points[i].Cumulative = float64(i)
+	}
+	out := downsampleReturns(points, 200)
+	if len(out) != 200 || out[0].Cumulative != 0 || out[199].Cumulative != 999 {
+		t.Fatalf("unexpected downsample: len %d first %.0f last %.0f", len(out), out[0].Cumulative, out[len(out)-1].Cumulative)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"sort"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"bug-free-umbrella/internal/ml/inference"
 	"bug-free-umbrella/internal/ml/predictions"
 	"bug-free-umbrella/internal/ml/training"
+	"bug-free-umbrella/internal/signal"
 
 	"github.com/jackc/pgx/v5"
 	"go.opentelemetry.io/otel/trace"
@@ -23,6 +25,13 @@ type MLCandleRepository interface {
 	GetCandlesInRange(ctx context.Context, symbol, interval string, from, to time.Time) ([]*domain.Candle, error)
 }
 
+// SignalLevelOutcomes looks up the stop and targets a prediction's signal
+// was published with and records which was reached first.
+type SignalLevelOutcomes interface {
+	GetSignalLevels(ctx context.Context, signalID int64) (domain.SignalDirection, *domain.SignalLevels, error)
+	RecordLevelOutcome(ctx context.Context, signalID int64, outcome domain.LevelOutcome, exitPrice float64) error
+}
+
 type MLSignalService struct {
 	tracer         trace.Tracer
 	candleRepo     MLCandleRepository
@@ -31,6 +40,7 @@ type MLSignalService struct {
 	trainingSvc    *training.Service
 	inferenceSvc   *inference.Service
 	predictionRepo *predictions.Repository
+	levelOutcomes  SignalLevelOutcomes
 
 	intervals       []string
 	targetHours     int
@@ -83,6 +93,12 @@ func NewMLSignalService(
 	}
 }
 
+// SetLevelOutcomes makes ResolveOutcomes also resolve the stop and target
+// of each prediction's signal over the prediction horizon.
+func (s *MLSignalService) SetLevelOutcomes(store SignalLevelOutcomes) {
+	s.levelOutcomes = store
+}
+
 func (s *MLSignalService) RefreshFeatures(ctx context.Context) (int, error) {
 	_, span := s.tracer.Start(ctx, "ml-signal-service.refresh-features")
 	defer span.End()
@@ -181,10 +197,49 @@ func (s *MLSignalService) ResolveOutcomes(ctx context.Context, limit int) (int,
 			return resolved, err
 		}
 		resolved++
+		if pred.SignalID != nil && s.levelOutcomes != nil {
+			s.resolveSignalLevels(ctx, *pred.SignalID, candles, pred.OpenTime, pred.TargetTime)
+		}
 	}
 	return resolved, nil
 }
 
+// resolveSignalLevels records whether the signal's stop or first target was
+// touched first within the horizon. Failures are logged: the prediction
+// itself is already resolved.
+func (s *MLSignalService) resolveSignalLevels(ctx context.Context, signalID int64, candles []*domain.Candle, openTime, targetTime time.Time) {
+	direction, levels, err := s.levelOutcomes.GetSignalLevels(ctx, signalID)
+	if err != nil {
+		log.Printf("ml outcome: levels for signal %d: %v", signalID, err)
+		return
+	}
+	if levels == nil {
+		return
+	}
+	outcome, exit := signal.ResolveLevels(direction, levels, candlesAfter(candles, openTime, targetTime))
+	if exit == 0 {
+		return
+	}
+	if err := s.levelOutcomes.RecordLevelOutcome(ctx, signalID, outcome, exit); err != nil {
+		log.Printf("ml outcome: record level outcome for signal %d: %v", signalID, err)
+	}
+}
+
+// candlesAfter returns the candles that opened after openTime and before
+// targetTime, oldest first. The candle opening at targetTime trades after
+// the horizon, so it is left out.
+func candlesAfter(candles []*domain.Candle, openTime, targetTime time.Time) []domain.Candle {
+	out := make([]domain.Candle, 0, len(candles))
+	for _, c := range candles {
+		if c == nil || !c.OpenTime.After(openTime) || !c.OpenTime.Before(targetTime) {
+			continue
+		}
+		out = append(out, *c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].OpenTime.Before(out[j].OpenTime) })
+	return out
+}
+
 func uniqueIntervals(intervals []string, fallback string) []string {
 	if fallback == "" {
 		fallback = "1h"
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/signal"
 )
 
 func TestExtractOpenAndTargetClose(t *testing.T) {
@@ -24,6 +25,44 @@ func TestExtractOpenAndTargetClose(t *testing.T) {
 	}
 }
 
+func TestCandlesAfterResolvesLevels(t *testing.T) {
+	open := time.Date(2026, 2, 1, 10, 0, 0, 0, time.UTC)
+	target := open.Add(3 * time.Hour)
+	candles := []*domain.Candle{
+		{OpenTime: open.Add(4 * time.Hour), High: 200, Low: 90, Close: 150},
+		{OpenTime: open.Add(2 * time.Hour), High: 112, Low: 101, Close: 108},
+		{OpenTime: open, High: 130, Low: 80, Close: 100},
+		{OpenTime: open.Add(time.Hour), High: 104, Low: 98, Close: 102},
+	}
+	after := candlesAfter(candles, open, target)
+	if len(after) != 2 || !after[0].OpenTime.Equal(open.Add(time.Hour)) {
+		t.Fatalf("expected the two candles inside the horizon, oldest first, got %+v", after)
+	}
+	levels := &domain.SignalLevels{Entry: 100, Stop: 95, Targets: []float64{110}}
+	outcome, exit := signal.ResolveLevels(domain.DirectionLong, levels, after)
+	if outcome != domain.LevelOutcomeTarget || exit != 110 {
+		t.Fatalf("expected the target hit inside the horizon, got %s at %v", outcome, exit)
+	}
+}
+
+func TestCandlesAfterExcludesCandleOpeningAtTarget(t *testing.T) {
+	open := time.Date(2026, 2, 1, 10, 0, 0, 0, time.UTC)
+	target := open.Add(2 * time.Hour)
+	candles := []*domain.Candle{
+		{OpenTime: open, High: 101, Low: 99, Close: 100},
+		{OpenTime: open.Add(time.Hour), High: 104, Low: 98, Close: 102},
+		{OpenTime: target, High: 103, Low: 90, Close: 92},
+	}
+	after := candlesAfter(candles, open, target)
+	if len(after) != 1 || !after[0].OpenTime.Equal(open.Add(time.Hour)) {
+		t.Fatalf("expected only the candle inside the horizon, got %+v", after)
+	}
+	levels := &domain.SignalLevels{Entry: 100, Stop: 95, Targets: []float64{110}}
+	if outcome, exit := signal.ResolveLevels(domain.DirectionLong, levels, after); outcome != domain.LevelOutcomeOpen || exit != 102 {
+		t.Fatalf("expected the stop hit after the horizon ignored, got %s at %v", outcome, exit)
+	}
+}
+
 func TestUniqueIntervals(t *testing.T) {
 	got := uniqueIntervals([]string{"1h", "4h", "1h"}, "1h")
 	if len(got) != 2 || got[0] != "1h" || got[1] != "4h" {
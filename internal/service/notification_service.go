@@ -0,0 +1,347 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultDeliveryLogLimit = 50
+	maxDeliveryLogLimit     = 200
+)
+
+var (
+	// ErrInvalidChannel wraps validation failures for notification channels.
+	ErrInvalidChannel = errors.New("invalid notification channel")
+	// ErrChannelNotFound is returned for unknown channel IDs.
+	ErrChannelNotFound = errors.New("notification channel not found")
+	// ErrChannelTestFailed wraps the error from a failed test notification.
+	ErrChannelTestFailed = errors.New("test notification failed")
+)
+
+type NotificationChannelStore interface {
+	CreateChannel(ctx context.Context, ch domain.NotificationChannel) (*domain.NotificationChannel, error)
+	ListChannels(ctx context.Context) ([]domain.NotificationChannel, error)
+	GetChannel(ctx context.Context, id int64) (*domain.NotificationChannel, error)
+	UpdateChannel(ctx context.Context, ch domain.NotificationChannel) (bool, error)
+	DeleteChannel(ctx context.Context, id int64) (bool, error)
+}
+
+// NotificationOutbox queues notifications for the delivery worker and keeps
+// their log. Queued rows carry the channel ID in ChatID and the JSON
+// notification in Text.
+type NotificationOutbox interface {
+	Enqueue(ctx context.Context, deliveries []domain.AlertDelivery) (int, error)
+	ListDeliveries(ctx context.Context, channelID int64, limit int) ([]domain.NotificationDelivery, error)
+}
+
+// NotificationSender sends straight to a channel, bypassing the outbox.
+type NotificationSender interface {
+	Send(ctx context.Context, ch domain.NotificationChannel, n domain.Notification) error
+	Supports(t domain.NotificationChannelType) bool
+}
+
+// NotificationService manages notification channels and fans signals and
+// price alerts out to them. It is a signal alert sink and a price alert
+// notifier, so it works with or without the Telegram bot.
+type NotificationService struct {
+	tracer trace.Tracer
+	store  NotificationChannelStore
+	outbox NotificationOutbox
+	sender NotificationSender
+	now    func() time.Time
+}
+
+func NewNotificationService(tracer trace.Tracer, store NotificationChannelStore, outbox NotificationOutbox, sender NotificationSender) *NotificationService {
+	return &NotificationService{
+		tracer: tracer,
+		store:  store,
+		outbox: outbox,
+		sender: sender,
+		now:    time.Now,
+	}
+}
+
+// CreateChannel validates and stores a new channel. Channels without kinds
+// receive signals only.
+func (s *NotificationService) CreateChannel(ctx context.Context, ch domain.NotificationChannel) (*domain.NotificationChannel, error) {
+	ctx, span := s.tracer.Start(ctx, "notification-service.create-channel")
+	defer span.End()
+
+	ch, err := s.normalizeChannel(ch)
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(attribute.String("type", string(ch.Type)))
+	return s.store.CreateChannel(ctx, ch)
+}
+
+func (s *NotificationService) ListChannels(ctx context.Context) ([]domain.NotificationChannel, error) {
+	ctx, span := s.tracer.Start(ctx, "notification-service.list-channels")
+	defer span.End()
+
+	return s.store.ListChannels(ctx)
+}
+
+func (s *NotificationService) GetChannel(ctx context.Context, id int64) (*domain.NotificationChannel, error) {
+	ctx, span := s.tracer.Start(ctx, "notification-service.get-channel")
+	defer span.End()
+
+	ch, err := s.store.GetChannel(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if ch == nil {
+		return nil, fmt.Errorf("%w: #%d", ErrChannelNotFound, id)
+	}
+	return ch, nil
+}
+
+// UpdateChannel replaces a channel's settings. An empty target or secret
+// keeps the current one, since neither is returned by the API.
+func (s *NotificationService) UpdateChannel(ctx context.Context, ch domain.NotificationChannel) (*domain.NotificationChannel, error) {
+	ctx, span := s.tracer.Start(ctx, "notification-service.update-channel")
+	defer span.End()
+
+	current, err := s.GetChannel(ctx, ch.ID)
+	if err != nil {
+		return nil, err
+	}
+	if ch.Target == "" {
+		ch.Target = current.Target
+	}
+	if ch.Secret == "" {
+		ch.Secret = current.Secret
+	}
+	ch, err = s.normalizeChannel(ch)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.store.UpdateChannel(ctx, ch)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: #%d", ErrChannelNotFound, ch.ID)
+	}
+	return s.GetChannel(ctx, ch.ID)
+}
+
+// DeleteChannel removes a channel along with its queued notifications and
+// delivery log.
+func (s *NotificationService) DeleteChannel(ctx context.Context, id int64) error {
+	ctx, span := s.tracer.Start(ctx, "notification-service.delete-channel")
+	defer span.End()
+
+	ok, err := s.store.DeleteChannel(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: #%d", ErrChannelNotFound, id)
+	}
+	return nil
+}
+
+// ListDeliveries returns a channel's delivery log, newest first.
+func (s *NotificationService) ListDeliveries(ctx context.Context, channelID int64, limit int) ([]domain.NotificationDelivery, error) {
+	ctx, span := s.tracer.Start(ctx, "notification-service.list-deliveries")
+	defer span.End()
+
+	if _, err := s.GetChannel(ctx, channelID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultDeliveryLogLimit
+	}
+	return s.outbox.ListDeliveries(ctx, channelID, min(limit, maxDeliveryLogLimit))
+}
+
+// TestChannel sends a test notification right away, so a bad URL or
+// address shows up when the channel is set up rather than at the next
+// signal.
+func (s *NotificationService) TestChannel(ctx context.Context, id int64) error {
+	ctx, span := s.tracer.Start(ctx, "notification-service.test-channel")
+	defer span.End()
+
+	ch, err := s.GetChannel(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = s.sender.Send(ctx, *ch, domain.Notification{
+		Kind:      domain.DeliveryKindTest,
+		Text:      fmt.Sprintf("Test notification for channel %q. Signals and alerts will arrive here.", ch.Name),
+		CreatedAt: s.now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrChannelTestFailed, err)
+	}
+	return nil
+}
+
+// NotifySignals queues one notification per channel with the fresh signals
+// that pass its filters.
+func (s *NotificationService) NotifySignals(ctx context.Context, signals []domain.Signal) error {
+	ctx, span := s.tracer.Start(ctx, "notification-service.notify-signals")
+	defer span.End()
+
+	channels, err := s.store.ListChannels(ctx)
+	if err != nil {
+		return err
+	}
+	deliveries := make([]domain.AlertDelivery, 0)
+	for _, ch := range channels {
+		if !ch.Wants(domain.DeliveryKindSignal) {
+			continue
+		}
+		matched := make([]domain.Signal, 0)
+		for _, sig := range signals {
+			if ch.MatchesSignal(sig) {
+				matched = append(matched, sig)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		d, err := s.delivery(ch.ID, domain.Notification{Kind: domain.DeliveryKindSignal, Signals: matched})
+		if err != nil {
+			return err
+		}
+		deliveries = append(deliveries, d)
+	}
+	span.SetAttributes(attribute.Int("deliveries", len(deliveries)))
+	_, err = s.outbox.Enqueue(ctx, deliveries)
+	return err
+}
+
+// NotifyPriceAlerts queues each fired rule for the channels that take price
+// alerts for its symbol.
+func (s *NotificationService) NotifyPriceAlerts(ctx context.Context, triggers []domain.PriceAlertTrigger) error {
+	ctx, span := s.tracer.Start(ctx, "notification-service.notify-price-alerts")
+	defer span.End()
+
+	channels, err := s.store.ListChannels(ctx)
+	if err != nil {
+		return err
+	}
+	deliveries := make([]domain.AlertDelivery, 0)
+	for _, ch := range channels {
+		if !ch.Wants(domain.DeliveryKindPriceAlert) {
+			continue
+		}
+		for _, t := range triggers {
+			if !ch.MatchesPriceAlert(t) {
+				continue
+			}
+			d, err := s.delivery(ch.ID, domain.Notification{Kind: domain.DeliveryKindPriceAlert, PriceAlert: &t})
+			if err != nil {
+				return err
+			}
+			deliveries = append(deliveries, d)
+		}
+	}
+	span.SetAttributes(attribute.Int("deliveries", len(deliveries)))
+	_, err = s.outbox.Enqueue(ctx, deliveries)
+	return err
+}
+
+func (s *NotificationService) delivery(channelID int64, n domain.Notification) (domain.AlertDelivery, error) {
+	n.CreatedAt = s.now().UTC()
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return domain.AlertDelivery{}, err
+	}
+	return domain.AlertDelivery{ChatID: channelID, Kind: n.Kind, Text: string(payload)}, nil
+}
+
+// normalizeChannel validates a channel and fills its defaults.
+func (s *NotificationService) normalizeChannel(ch domain.NotificationChannel) (domain.NotificationChannel, error) {
+	ch.Name = strings.TrimSpace(ch.Name)
+	ch.Target = strings.TrimSpace(ch.Target)
+	if ch.Name == "" || len(ch.Name) > 100 {
+		return ch, fmt.Errorf("%w: name is required and at most 100 characters", ErrInvalidChannel)
+	}
+	if !ch.Type.IsValid() {
+		return ch, fmt.Errorf("%w: type must be discord, slack, email or webhook", ErrInvalidChannel)
+	}
+	if !s.sender.Supports(ch.Type) {
+		return ch, fmt.Errorf("%w: %s channels are not configured on this server", ErrInvalidChannel, ch.Type)
+	}
+	if err := validateChannelTarget(ch.Type, ch.Target); err != nil {
+		return ch, fmt.Errorf("%w: %v", ErrInvalidChannel, err)
+	}
+	if ch.Secret != "" && ch.Type != domain.ChannelWebhook {
+		return ch, fmt.Errorf("%w: only webhook channels take a secret", ErrInvalidChannel)
+	}
+
+	if len(ch.Kinds) == 0 {
+		ch.Kinds = []domain.AlertDeliveryKind{domain.DeliveryKindSignal}
+	}
+	kinds := make([]domain.AlertDeliveryKind, 0, len(ch.Kinds))
+	for _, k := range ch.Kinds {
+		if k != domain.DeliveryKindSignal && k != domain.DeliveryKindPriceAlert {
+			return ch, fmt.Errorf("%w: kinds must be signal or price_alert", ErrInvalidChannel)
+		}
+		if !slices.Contains(kinds, k) {
+			kinds = append(kinds, k)
+		}
+	}
+	ch.Kinds = kinds
+
+	for i, sym := range ch.Symbols {
+		ch.Symbols[i] = strings.ToUpper(strings.TrimSpace(sym))
+	}
+	if (ch.MinRisk != 0 && !ch.MinRisk.IsValid()) || (ch.MaxRisk != 0 && !ch.MaxRisk.IsValid()) {
+		return ch, fmt.Errorf("%w: risk filters must be between 1 and 5", ErrInvalidChannel)
+	}
+	if ch.MinRisk != 0 && ch.MaxRisk != 0 && ch.MinRisk > ch.MaxRisk {
+		return ch, fmt.Errorf("%w: min_risk is above max_risk", ErrInvalidChannel)
+	}
+	if ch.Direction != "" && ch.Direction != domain.DirectionLong && ch.Direction != domain.DirectionShort {
+		return ch, fmt.Errorf("%w: direction must be long or short", ErrInvalidChannel)
+	}
+	return ch, nil
+}
+
+// validateChannelTarget checks a webhook URL, or the addresses of an email
+// channel. Only generic webhooks may use plain http, for receivers on the
+// same network.
+func validateChannelTarget(t domain.NotificationChannelType, target string) error {
+	if t == domain.ChannelEmail {
+		addrs := strings.Split(target, ",")
+		n := 0
+		for _, addr := range addrs {
+			if addr = strings.TrimSpace(addr); addr == "" {
+				continue
+			}
+			if _, err := mail.ParseAddress(addr); err != nil {
+				return fmt.Errorf("invalid email address %q", addr)
+			}
+			n++
+		}
+		if n == 0 {
+			return errors.New("target must list at least one email address")
+		}
+		return nil
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return errors.New("target must be a URL")
+	}
+	if u.Scheme != "https" && (u.Scheme != "http" || t != domain.ChannelWebhook) {
+		return errors.New("target must be an https URL")
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+type memNotificationStore struct {
+	channels map[int64]domain.NotificationChannel
+	nextID   int64
+}
+
+func newMemNotificationStore(channels ...domain.NotificationChannel) *memNotificationStore {
+	m := &memNotificationStore{channels: map[int64]domain.NotificationChannel{}}
+	for _, ch := range channels {
+		m.channels[ch.ID] = ch
+		m.nextID = max(m.nextID, ch.ID)
+	}
+	return m
+}
+
+func (m *memNotificationStore) CreateChannel(ctx context.Context, ch domain.NotificationChannel) (*domain.NotificationChannel, error) {
+	m.nextID++
+	ch.ID = m.nextID
+	m.channels[ch.ID] = ch
+	return &ch, nil
+}
+
+func (m *memNotificationStore) ListChannels(ctx context.Context) ([]domain.NotificationChannel, error) {
+	out := make([]domain.NotificationChannel, 0, len(m.channels))
+	for id := int64(1); id <= m.nextID; id++ {
+		if ch, ok := m.channels[id]; ok {
+			out = append(out, ch)
+		}
+	}
+	return out, nil
+}
+
+func (m *memNotificationStore) GetChannel(ctx context.Context, id int64) (*domain.NotificationChannel, error) {
+	ch, ok := m.channels[id]
+	if !ok {
+		return nil, nil
+	}
+	return &ch, nil
+}
+
+func (m *memNotificationStore) UpdateChannel(ctx context.Context, ch domain.NotificationChannel) (bool, error) {
+	if _, ok := m.channels[ch.ID]; !ok {
+		return false, nil
+	}
+	m.channels[ch.ID] = ch
+	return true, nil
+}
+
+func (m *memNotificationStore) DeleteChannel(ctx context.Context, id int64) (bool, error) {
+	_, ok := m.channels[id]
+	delete(m.channels, id)
+	return ok, nil
+}
+
+type memNotificationOutbox struct {
+	queued    []domain.AlertDelivery
+	listLimit int
+}
+
+func (m *memNotificationOutbox) Enqueue(ctx context.Context, deliveries []domain.AlertDelivery) (int, error) {
+	m.queued = append(m.queued, deliveries...)
+	return len(deliveries), nil
+}
+
+func (m *memNotificationOutbox) ListDeliveries(ctx context.Context, channelID int64, limit int) ([]domain.NotificationDelivery, error) {
+	m.listLimit = limit
+	return []domain.NotificationDelivery{{ID: 1, ChannelID: channelID}}, nil
+}
+
+type stubNotificationSender struct {
+	err  error
+	sent []domain.Notification
+}
+
+func (s *stubNotificationSender) Send(ctx context.Context, ch domain.NotificationChannel, n domain.Notification) error {
+	s.sent = append(s.sent, n)
+	return s.err
+}
+
+func (s *stubNotificationSender) Supports(t domain.NotificationChannelType) bool {
+	return t != domain.ChannelEmail
+}
+
+func TestNotificationServiceValidatesChannels(t *testing.T) {
+	svc := NewNotificationService(testTracer, newMemNotificationStore(), &memNotificationOutbox{}, &stubNotificationSender{})
+	ctx := context.Background()
+
+	ch, err := svc.CreateChannel(ctx, domain.NotificationChannel{
+		Name: " team ", Type: domain.ChannelSlack, Target: "https://hooks.slack.com/services/x",
+		Symbols: []string{" btc"}, Enabled: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ch.Name != "team" || len(ch.Kinds) != 1 || ch.Kinds[0] != domain.DeliveryKindSignal || ch.Symbols[0] != "BTC" {
+		t.Fatalf("unexpected channel: %+v", ch)
+	}
+
+	bad := []domain.NotificationChannel{
+		{Type: domain.ChannelSlack, Target: "https://hooks.slack.com/x"},
+		{Name: "x", Type: "sms", Target: "https://example.com"},
+		{Name: "x", Type: domain.ChannelEmail, Target: "a@example.com"},
+		{Name: "x", Type: domain.ChannelDiscord, Target: "http://discord.com/api/webhooks/1"},
+		{Name: "x", Type: domain.ChannelSlack, Target: "not a url"},
+		{Name: "x", Type: domain.ChannelSlack, Target: "https://hooks.slack.com/x", Secret: "k"},
+		{Name: "x", Type: domain.ChannelSlack, Target: "https://hooks.slack.com/x", Kinds: []domain.AlertDeliveryKind{domain.DeliveryKindDigest}},
+		{Name: "x", Type: domain.ChannelSlack, Target: "https://hooks.slack.com/x", MinRisk: 4, MaxRisk: 2},
+		{Name: "x", Type: domain.ChannelSlack, Target: "https://hooks.slack.com/x", Direction: "sideways"},
+	}
+	for _, ch := range bad {
+		if _, err := svc.CreateChannel(ctx, ch); !errors.Is(err, ErrInvalidChannel) {
+			t.Fatalf("expected %+v to be rejected, got %v", ch, err)
+		}
+	}
+	if _, err := svc.CreateChannel(ctx, domain.NotificationChannel{Name: "ci", Type: domain.ChannelWebhook, Target: "http://ci.internal/hook", Secret: "k"}); err != nil {
+		t.Fatalf("expected plain http webhooks to be allowed, got %v", err)
+	}
+	if err := validateChannelTarget(domain.ChannelEmail, "a@example.com, b@example.com"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := validateChannelTarget(domain.ChannelEmail, "a@example.com, nope"); err == nil {
+		t.Fatal("expected a bad address to be rejected")
+	}
+}
+
+func TestNotificationServiceUpdateKeepsSecret(t *testing.T) {
+	store := newMemNotificationStore(domain.NotificationChannel{ID: 1, Name: "ci", Type: domain.ChannelWebhook, Target: "https://ci.example.com", Secret: "k", Enabled: true})
+	svc := NewNotificationService(testTracer, store, &memNotificationOutbox{}, &stubNotificationSender{})
+	ctx := context.Background()
+
+	ch, err := svc.UpdateChannel(ctx, domain.NotificationChannel{ID: 1, Name: "ci2", Type: domain.ChannelWebhook, Target: "https://ci.example.com/v2"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ch.Name != "ci2" || ch.Secret != "k" || ch.Enabled {
+		t.Fatalf("unexpected channel: %+v", ch)
+	}
+	ch, err = svc.UpdateChannel(ctx, domain.NotificationChannel{ID: 1, Name: "ci3", Type: domain.ChannelWebhook})
+	if err != nil || ch.Target != "https://ci.example.com/v2" || ch.Secret != "k" {
+		t.Fatalf("expected an empty target to keep the stored one, got %+v, %v", ch, err)
+	}
+	if _, err := svc.UpdateChannel(ctx, domain.NotificationChannel{ID: 9, Name: "x", Type: domain.ChannelSlack, Target: "https://x.example.com"}); !errors.Is(err, ErrChannelNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if err := svc.DeleteChannel(ctx, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := svc.DeleteChannel(ctx, 1); !errors.Is(err, ErrChannelNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestNotificationServiceFansOutSignals(t *testing.T) {
+	store := newMemNotificationStore(
+		domain.NotificationChannel{ID: 1, Enabled: true, Kinds: []domain.AlertDeliveryKind{domain.DeliveryKindSignal}, Symbols: []string{"BTC"}},
+		domain.NotificationChannel{ID: 2, Enabled: true, Kinds: []domain.AlertDeliveryKind{domain.DeliveryKindSignal, domain.DeliveryKindPriceAlert}},
+		domain.NotificationChannel{ID: 3, Enabled: false, Kinds: []domain.AlertDeliveryKind{domain.DeliveryKindSignal}},
+		domain.NotificationChannel{ID: 4, Enabled: true, Kinds: []domain.AlertDeliveryKind{domain.DeliveryKindPriceAlert}, Symbols: []string{"ETH"}},
+	)
+	outbox := &memNotificationOutbox{}
+	svc := NewNotificationService(testTracer, store, outbox, &stubNotificationSender{})
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if err := svc.NotifySignals(ctx, []domain.Signal{{ID: 1, Symbol: "BTC"}, {ID: 2, Symbol: "SOL"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(outbox.queued) != 2 || outbox.queued[0].ChatID != 1 || outbox.queued[1].ChatID != 2 {
+		t.Fatalf("unexpected deliveries: %+v", outbox.queued)
+	}
+	var n domain.Notification
+	if err := json.Unmarshal([]byte(outbox.queued[0].Text), &n); err != nil {
+		t.Fatalf("unexpected payload: %v", err)
+	}
+	if n.Kind != domain.DeliveryKindSignal || len(n.Signals) != 1 || n.Signals[0].Symbol != "BTC" || !n.CreatedAt.Equal(now) {
+		t.Fatalf("unexpected notification: %+v", n)
+	}
+
+	outbox.queued = nil
+	err := svc.NotifyPriceAlerts(ctx, []domain.PriceAlertTrigger{
+		{Rule: domain.PriceAlertRule{ID: 5, Symbol: "BTC"}},
+		{Rule: domain.PriceAlertRule{ID: 6, Symbol: "ETH"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(outbox.queued) != 3 || outbox.queued[2].ChatID != 4 || outbox.queued[2].Kind != domain.DeliveryKindPriceAlert {
+		t.Fatalf("unexpected deliveries: %+v", outbox.queued)
+	}
+	if err := json.Unmarshal([]byte(outbox.queued[2].Text), &n); err != nil || n.PriceAlert == nil || n.PriceAlert.Rule.ID != 6 {
+		t.Fatalf("unexpected notification: %+v %v", n, err)
+	}
+}
+
+func TestNotificationServiceTestAndLog(t *testing.T) {
+	store := newMemNotificationStore(domain.NotificationChannel{ID: 1, Name: "team", Type: domain.ChannelSlack})
+	outbox := &memNotificationOutbox{}
+	sender := &stubNotificationSender{}
+	svc := NewNotificationService(testTracer, store, outbox, sender)
+	ctx := context.Background()
+
+	if err := svc.TestChannel(ctx, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sender.sent) != 1 || sender.sent[0].Kind != domain.DeliveryKindTest {
+		t.Fatalf("unexpected test send: %+v", sender.sent)
+	}
+	sender.err = errors.New("http 404: no_service")
+	if err := svc.TestChannel(ctx, 1); !errors.Is(err, ErrChannelTestFailed) {
+		t.Fatalf("expected test failure, got %v", err)
+	}
+	if err := svc.TestChannel(ctx, 2); !errors.Is(err, ErrChannelNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+
+	if _, err := svc.ListDeliveries(ctx, 1, 0); err != nil || outbox.listLimit != defaultDeliveryLogLimit {
+		t.Fatalf("expected the default limit, got %d %v", outbox.listLimit, err)
+	}
+	if _, err := svc.ListDeliveries(ctx, 1, 1000); err != nil || outbox.listLimit != maxDeliveryLogLimit {
+		t.Fatalf("expected the limit capped, got %d %v", outbox.listLimit, err)
+	}
+	if _, err := svc.ListDeliveries(ctx, 2, 10); !errors.Is(err, ErrChannelNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
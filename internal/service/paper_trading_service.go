@@ -0,0 +1,443 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+
+	"bug-free-umbrella/internal/domain"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	DefaultPaperBalance       = 10_000.0
+	DefaultPaperSlippageBps   = 5.0
+	DefaultPaperTradeNotional = 500.0
+
+	maxPaperBalance     = 1e9
+	maxPaperSlippageBps = 500.0
+
+	// paperQuantityEpsilon absorbs float error when selling a whole
+	// position by notional.
+	paperQuantityEpsilon = 1e-9
+)
+
+// ErrInvalidPaperOrder wraps validation failures for accounts, orders and
+// subscriptions.
+var ErrInvalidPaperOrder = errors.New("invalid paper order")
+
+// ErrPaperAccountNotFound is returned for unknown account ids.
+var ErrPaperAccountNotFound = errors.New("paper account not found")
+
+// ErrPaperSubscriptionNotFound is returned when removing a subscription the
+// account does not have.
+var ErrPaperSubscriptionNotFound = errors.New("paper subscription not found")
+
+// ErrInsufficientPaperFunds is returned when an order needs more cash, or
+// more units of the symbol, than the account holds.
+var ErrInsufficientPaperFunds = errors.New("insufficient paper funds")
+
+type PaperStore interface {
+	CreateAccount(ctx context.Context, acct domain.PaperAccount) (*domain.PaperAccount, error)
+	GetAccount(ctx context.Context, id int64) (*domain.PaperAccount, error)
+	GetAccountByOwner(ctx context.Context, owner string) (*domain.PaperAccount, error)
+	ListAccounts(ctx context.Context) ([]domain.PaperAccount, error)
+	ListPositions(ctx context.Context, accountID int64) ([]domain.PaperPosition, error)
+	ListOrders(ctx context.Context, accountID int64, limit int) ([]domain.PaperOrder, error)
+	// RecordFill applies the order's cash and position change and returns nil
+	// when the account no longer has the cash or units to cover it.
+	RecordFill(ctx context.Context, order domain.PaperOrder) (*domain.PaperOrder, error)
+	CreateSubscription(ctx context.Context, sub domain.PaperSubscription) (*domain.PaperSubscription, error)
+	ListSubscriptions(ctx context.Context, accountID int64) ([]domain.PaperSubscription, error)
+	DeleteSubscription(ctx context.Context, accountID, id int64) (bool, error)
+}
+
+type PaperPriceSource interface {
+	GetCurrentPrice(ctx context.Context, symbol string) (*domain.PriceSnapshot, error)
+}
+
+// PaperTradingService runs virtual spot accounts. Market orders fill at the
+// current price moved against the trader by the account's slippage, and
+// accounts can follow fresh signals through their subscriptions. Fills are
+// serialised here and the store applies them as guarded changes, so
+// concurrent orders, on this process or another, cannot spend the same cash
+// twice.
+type PaperTradingService struct {
+	tracer trace.Tracer
+	store  PaperStore
+	prices PaperPriceSource
+
+	mu sync.Mutex
+}
+
+func NewPaperTradingService(tracer trace.Tracer, store PaperStore, prices PaperPriceSource) *PaperTradingService {
+	return &PaperTradingService{tracer: tracer, store: store, prices: prices}
+}
+
+// CreateAccount opens an account. A zero starting balance or slippage takes
+// the defaults.
+func (s *PaperTradingService) CreateAccount(ctx context.Context, acct domain.PaperAccount) (*domain.PaperAccount, error) {
+	ctx, span := s.tracer.Start(ctx, "paper-trading-service.create-account")
+	defer span.End()
+
+	if s.store == nil {
+		return nil, fmt.Errorf("paper trading unavailable")
+	}
+	acct.Name = strings.TrimSpace(acct.Name)
+	acct.Owner = strings.TrimSpace(acct.Owner)
+	if acct.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidPaperOrder)
+	}
+	if acct.StartingBalance == 0 {
+		acct.StartingBalance = DefaultPaperBalance
+	}
+	if acct.StartingBalance < 0 || acct.StartingBalance > maxPaperBalance {
+		return nil, fmt.Errorf("%w: starting_balance must be between 0 and %.0f", ErrInvalidPaperOrder, maxPaperBalance)
+	}
+	if acct.SlippageBps == 0 {
+		acct.SlippageBps = DefaultPaperSlippageBps
+	}
+	if acct.SlippageBps < 0 || acct.SlippageBps > maxPaperSlippageBps {
+		return nil, fmt.Errorf("%w: slippage_bps must be between 0 and %.0f", ErrInvalidPaperOrder, maxPaperSlippageBps)
+	}
+	return s.store.CreateAccount(ctx, acct)
+}
+
+// AccountForOwner returns owner's account, opening one with the defaults on
+// first use.
+func (s *PaperTradingService) AccountForOwner(ctx context.Context, owner, name string) (*domain.PaperAccount, error) {
+	ctx, span := s.tracer.Start(ctx, "paper-trading-service.account-for-owner")
+	defer span.End()
+
+	if s.store == nil {
+		return nil, fmt.Errorf("paper trading unavailable")
+	}
+	owner = strings.TrimSpace(owner)
+	if owner == "" {
+		return nil, fmt.Errorf("%w: owner is required", ErrInvalidPaperOrder)
+	}
+	acct, err := s.store.GetAccountByOwner(ctx, owner)
+	if err != nil || acct != nil {
+		return acct, err
+	}
+	return s.CreateAccount(ctx, domain.PaperAccount{Name: name, Owner: owner})
+}
+
+func (s *PaperTradingService) ListAccounts(ctx context.Context) ([]domain.PaperAccount, error) {
+	ctx, span := s.tracer.Start(ctx, "paper-trading-service.list-accounts")
+	defer span.End()
+
+	if s.store == nil {
+		return nil, fmt.Errorf("paper trading unavailable")
+	}
+	return s.store.ListAccounts(ctx)
+}
+
+// Portfolio values an account at current prices. A symbol whose price cannot
+// be fetched is marked at its average entry price.
+func (s *PaperTradingService) Portfolio(ctx context.Context, accountID int64) (*domain.PaperPortfolio, error) {
+	ctx, span := s.tracer.Start(ctx, "paper-trading-service.portfolio")
+	defer span.End()
+
+	acct, err := s.account(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	positions, err := s.store.ListPositions(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("load positions: %w", err)
+	}
+
+	p := &domain.PaperPortfolio{Account: *acct, Holdings: make([]domain.PaperHolding, 0, len(positions)), Equity: acct.Cash}
+	for _, pos := range positions {
+		p.RealizedPnL += pos.RealizedPnL
+		if pos.Quantity <= 0 {
+			continue
+		}
+		mark := pos.AvgPrice
+		if snap, err := s.prices.GetCurrentPrice(ctx, pos.Symbol); err != nil {
+			log.Printf("paper portfolio %d: price for %s unavailable, marking at entry: %v", accountID, pos.Symbol, err)
+		} else if snap != nil && snap.PriceUSD > 0 {
+			mark = snap.PriceUSD
+		}
+		h := domain.PaperHolding{
+			PaperPosition: pos,
+			MarkPrice:     mark,
+			MarketValue:   pos.Quantity * mark,
+			UnrealizedPnL: (mark - pos.AvgPrice) * pos.Quantity,
+		}
+		p.Holdings = append(p.Holdings, h)
+		p.Equity += h.MarketValue
+		p.UnrealizedPnL += h.UnrealizedPnL
+	}
+	if acct.StartingBalance > 0 {
+		p.Return = p.Equity/acct.StartingBalance - 1
+	}
+	return p, nil
+}
+
+// PlaceOrder fills a market order against the current price.
+func (s *PaperTradingService) PlaceOrder(ctx context.Context, req domain.PaperOrderRequest) (*domain.PaperOrder, error) {
+	ctx, span := s.tracer.Start(ctx, "paper-trading-service.place-order")
+	defer span.End()
+
+	req, err := normalizePaperOrder(req)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fill(ctx, req)
+}
+
+func normalizePaperOrder(req domain.PaperOrderRequest) (domain.PaperOrderRequest, error) {
+	req.Symbol = strings.ToUpper(strings.TrimSpace(req.Symbol))
+	if _, ok := domain.CoinGeckoID[req.Symbol]; !ok {
+		return req, fmt.Errorf("%w: unsupported symbol %q", ErrInvalidPaperOrder, req.Symbol)
+	}
+	req.Side = domain.PaperSide(strings.ToLower(strings.TrimSpace(string(req.Side))))
+	if req.Side != domain.PaperBuy && req.Side != domain.PaperSell {
+		return req, fmt.Errorf("%w: side must be buy or sell", ErrInvalidPaperOrder)
+	}
+	if req.Quantity < 0 || req.Notional < 0 || math.IsNaN(req.Quantity) || math.IsNaN(req.Notional) {
+		return req, fmt.Errorf("%w: quantity and notional must be positive", ErrInvalidPaperOrder)
+	}
+	if req.Quantity > 0 && req.Notional > 0 {
+		return req, fmt.Errorf("%w: give quantity or notional, not both", ErrInvalidPaperOrder)
+	}
+	if req.Side == domain.PaperBuy && req.Quantity == 0 && req.Notional == 0 {
+		return req, fmt.Errorf("%w: buy orders need a quantity or notional", ErrInvalidPaperOrder)
+	}
+	if req.Source == "" {
+		req.Source = domain.PaperSourceManual
+	}
+	return req, nil
+}
+
+// fill executes a normalised order. Callers hold s.mu.
+func (s *PaperTradingService) fill(ctx context.Context, req domain.PaperOrderRequest) (*domain.PaperOrder, error) {
+	acct, err := s.account(ctx, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	pos, err := s.position(ctx, req.AccountID, req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	if s.prices == nil {
+		return nil, fmt.Errorf("paper trading prices unavailable")
+	}
+	snap, err := s.prices.GetCurrentPrice(ctx, req.Symbol)
+	if err != nil {
+		return nil, fmt.Errorf("price %s: %w", req.Symbol, err)
+	}
+	if snap == nil || snap.PriceUSD <= 0 {
+		return nil, fmt.Errorf("price not available for %s", req.Symbol)
+	}
+
+	slip := acct.SlippageBps / 10_000
+	order := domain.PaperOrder{
+		AccountID:   acct.ID,
+		Symbol:      req.Symbol,
+		Side:        req.Side,
+		MarketPrice: snap.PriceUSD,
+		Source:      req.Source,
+		SignalID:    req.SignalID,
+	}
+	cash := acct.Cash
+
+	if req.Side == domain.PaperBuy {
+		order.FillPrice = snap.PriceUSD * (1 + slip)
+		order.Quantity = req.Quantity
+		if order.Quantity == 0 {
+			order.Quantity = req.Notional / order.FillPrice
+		}
+		order.Notional = order.Quantity * order.FillPrice
+		if order.Notional > cash+paperQuantityEpsilon {
+			return nil, fmt.Errorf("%w: order costs $%.2f, cash is $%.2f", ErrInsufficientPaperFunds, order.Notional, cash)
+		}
+		order.Notional = math.Min(order.Notional, cash)
+	} else {
+		order.FillPrice = snap.PriceUSD * (1 - slip)
+		order.Quantity = req.Quantity
+		if req.Notional > 0 {
+			order.Quantity = req.Notional / order.FillPrice
+		}
+		if order.Quantity == 0 {
+			order.Quantity = pos.Quantity
+		}
+		if pos.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: no %s position to sell", ErrInsufficientPaperFunds, req.Symbol)
+		}
+		if order.Quantity > pos.Quantity*(1+paperQuantityEpsilon) {
+			return nil, fmt.Errorf("%w: selling %.8g %s, holding %.8g", ErrInsufficientPaperFunds, order.Quantity, req.Symbol, pos.Quantity)
+		}
+		order.Quantity = math.Min(order.Quantity, pos.Quantity)
+		order.Notional = order.Quantity * order.FillPrice
+		order.RealizedPnL = (order.FillPrice - pos.AvgPrice) * order.Quantity
+	}
+	if order.Quantity <= 0 {
+		return nil, fmt.Errorf("%w: order quantity rounds to zero", ErrInvalidPaperOrder)
+	}
+	filled, err := s.store.RecordFill(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+	if filled == nil {
+		return nil, fmt.Errorf("%w: cash or %s holding changed during the fill", ErrInsufficientPaperFunds, req.Symbol)
+	}
+	return filled, nil
+}
+
+func (s *PaperTradingService) account(ctx context.Context, id int64) (*domain.PaperAccount, error) {
+	if s.store == nil {
+		return nil, fmt.Errorf("paper trading unavailable")
+	}
+	acct, err := s.store.GetAccount(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("load paper account: %w", err)
+	}
+	if acct == nil {
+		return nil, ErrPaperAccountNotFound
+	}
+	return acct, nil
+}
+
+// position returns the account's position in symbol, zero valued when it
+// has never traded it.
+func (s *PaperTradingService) position(ctx context.Context, accountID int64, symbol string) (domain.PaperPosition, error) {
+	positions, err := s.store.ListPositions(ctx, accountID)
+	if err != nil {
+		return domain.PaperPosition{}, fmt.Errorf("load positions: %w", err)
+	}
+	for _, p := range positions {
+		if p.Symbol == symbol {
+			return p, nil
+		}
+	}
+	return domain.PaperPosition{AccountID: accountID, Symbol: symbol}, nil
+}
+
+// Orders returns an account's most recent orders first.
+func (s *PaperTradingService) Orders(ctx context.Context, accountID int64, limit int) ([]domain.PaperOrder, error) {
+	ctx, span := s.tracer.Start(ctx, "paper-trading-service.orders")
+	defer span.End()
+
+	if _, err := s.account(ctx, accountID); err != nil {
+		return nil, err
+	}
+	return s.store.ListOrders(ctx, accountID, limit)
+}
+
+// Subscribe makes an account trade fresh signals matching sub. A zero
+// TradeNotional takes DefaultPaperTradeNotional.
+func (s *PaperTradingService) Subscribe(ctx context.Context, sub domain.PaperSubscription) (*domain.PaperSubscription, error) {
+	ctx, span := s.tracer.Start(ctx, "paper-trading-service.subscribe")
+	defer span.End()
+
+	sub.Symbol = strings.ToUpper(strings.TrimSpace(sub.Symbol))
+	if _, ok := domain.CoinGeckoID[sub.Symbol]; sub.Symbol != "" && !ok {
+		return nil, fmt.Errorf("%w: unsupported symbol %q", ErrInvalidPaperOrder, sub.Symbol)
+	}
+	sub.Indicator = strings.ToLower(strings.TrimSpace(sub.Indicator))
+	if sub.MaxRisk < 0 || sub.MaxRisk > domain.RiskLevel5 {
+		return nil, fmt.Errorf("%w: max_risk must be between 1 and 5", ErrInvalidPaperOrder)
+	}
+	if sub.TradeNotional == 0 {
+		sub.TradeNotional = DefaultPaperTradeNotional
+	}
+	if sub.TradeNotional < 0 || math.IsNaN(sub.TradeNotional) {
+		return nil, fmt.Errorf("%w: trade_notional must be positive", ErrInvalidPaperOrder)
+	}
+	if _, err := s.account(ctx, sub.AccountID); err != nil {
+		return nil, err
+	}
+	return s.store.CreateSubscription(ctx, sub)
+}
+
+func (s *PaperTradingService) Unsubscribe(ctx context.Context, accountID, subscriptionID int64) error {
+	ctx, span := s.tracer.Start(ctx, "paper-trading-service.unsubscribe")
+	defer span.End()
+
+	if s.store == nil {
+		return fmt.Errorf("paper trading unavailable")
+	}
+	ok, err := s.store.DeleteSubscription(ctx, accountID, subscriptionID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrPaperSubscriptionNotFound
+	}
+	return nil
+}
+
+func (s *PaperTradingService) Subscriptions(ctx context.Context, accountID int64) ([]domain.PaperSubscription, error) {
+	ctx, span := s.tracer.Start(ctx, "paper-trading-service.subscriptions")
+	defer span.End()
+
+	if _, err := s.account(ctx, accountID); err != nil {
+		return nil, err
+	}
+	return s.store.ListSubscriptions(ctx, accountID)
+}
+
+// NotifySignals trades fresh signals for every matching subscription, so the
+// service can sit behind the signal poller as an alert sink. A long signal
+// opens a position when the account is flat in the symbol; a short signal
+// closes it. Orders that cannot fill are logged and skipped.
+func (s *PaperTradingService) NotifySignals(ctx context.Context, signals []domain.Signal) error {
+	ctx, span := s.tracer.Start(ctx, "paper-trading-service.notify-signals")
+	defer span.End()
+
+	if s.store == nil || len(signals) == 0 {
+		return nil
+	}
+	subs, err := s.store.ListSubscriptions(ctx, 0)
+	if err != nil {
+		return fmt.Errorf("load paper subscriptions: %w", err)
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sig := range signals {
+		if sig.Direction != domain.DirectionLong && sig.Direction != domain.DirectionShort {
+			continue
+		}
+		for _, sub := range subs {
+			if !sub.Matches(sig) {
+				continue
+			}
+			pos, err := s.position(ctx, sub.AccountID, sig.Symbol)
+			if err != nil {
+				log.Printf("paper account %d: %v", sub.AccountID, err)
+				continue
+			}
+			req := domain.PaperOrderRequest{AccountID: sub.AccountID, Symbol: sig.Symbol, Source: domain.PaperSourceSignal}
+			if sig.ID > 0 {
+				id := sig.ID
+				req.SignalID = &id
+			}
+			switch {
+			case sig.Direction == domain.DirectionLong && pos.Quantity <= 0:
+				req.Side, req.Notional = domain.PaperBuy, sub.TradeNotional
+			case sig.Direction == domain.DirectionShort && pos.Quantity > 0:
+				req.Side = domain.PaperSell
+			default:
+				continue
+			}
+			if _, err := s.fill(ctx, req); err != nil {
+				log.Printf("paper account %d: %s %s on %s signal: %v", sub.AccountID, req.Side, sig.Symbol, sig.Indicator, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,348 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"bug-free-umbrella/internal/domain"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type memPaperStore struct {
+	accounts  map[int64]*domain.PaperAccount
+	positions map[int64]map[string]domain.PaperPosition
+	orders    []domain.PaperOrder
+	subs      []domain.PaperSubscription
+	nextID    int64
+}
+
+func newMemPaperStore() *memPaperStore {
+	return &memPaperStore{accounts: map[int64]*domain.PaperAccount{}, positions: map[int64]map[string]domain.PaperPosition{}}
+}
+
+func (m *memPaperStore) id() int64 {
+	m.nextID++
+	return m.nextID
+}
+
+func (m *memPaperStore) CreateAccount(ctx context.Context, acct domain.PaperAccount) (*domain.PaperAccount, error) {
+	acct.ID = m.id()
+	acct.Cash = acct.StartingBalance
+	m.accounts[acct.ID] = &acct
+	out := acct
+	return &out, nil
+}
+
+func (m *memPaperStore) GetAccount(ctx context.Context, id int64) (*domain.PaperAccount, error) {
+	if a, ok := m.accounts[id]; ok {
+		out := *a
+		return &out, nil
+	}
+	return nil, nil
+}
+
+func (m *memPaperStore) GetAccountByOwner(ctx context.Context, owner string) (*domain.PaperAccount, error) {
+	for _, a := range m.accounts {
+		if a.Owner == owner {
+			out := *a
+			return &out, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memPaperStore) ListAccounts(ctx context.Context) ([]domain.PaperAccount, error) {
+	out := make([]domain.PaperAccount, 0, len(m.accounts))
+	for _, a := range m.accounts {
+		out = append(out, *a)
+	}
+	return out, nil
+}
+
+func (m *memPaperStore) ListPositions(ctx context.Context, accountID int64) ([]domain.PaperPosition, error) {
+	out := make([]domain.PaperPosition, 0)
+	for _, p := range m.positions[accountID] {
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func (m *memPaperStore) ListOrders(ctx context.Context, accountID int64, limit int) ([]domain.PaperOrder, error) {
+	out := make([]domain.PaperOrder, 0)
+	for i := len(m.orders) - 1; i >= 0; i-- {
+		if m.orders[i].AccountID == accountID {
+			out = append(out, m.orders[i])
+		}
+	}
+	return out, nil
+}
+
+func (m *memPaperStore) RecordFill(ctx context.Context, order domain.PaperOrder) (*domain.PaperOrder, error) {
+	acct := m.accounts[order.AccountID]
+	if m.positions[order.AccountID] == nil {
+		m.positions[order.AccountID] = map[string]domain.PaperPosition{}
+	}
+	pos := m.positions[order.AccountID][order.Symbol]
+	pos.AccountID, pos.Symbol = order.AccountID, order.Symbol
+	if order.Side == domain.PaperBuy {
+		if acct.Cash < order.Notional {
+			return nil, nil
+		}
+		acct.Cash -= order.Notional
+		pos.AvgPrice = (pos.Quantity*pos.AvgPrice + order.Quantity*order.FillPrice) / (pos.Quantity + order.Quantity)
+		pos.Quantity += order.Quantity
+	} else {
+		if pos.Quantity < order.Quantity {
+			return nil, nil
+		}
+		acct.Cash += order.Notional
+		order.RealizedPnL = (order.FillPrice - pos.AvgPrice) * order.Quantity
+		pos.RealizedPnL += order.RealizedPnL
+		pos.Quantity -= order.Quantity
+		if pos.Quantity <= paperQuantityEpsilon {
+			pos.Quantity, pos.AvgPrice = 0, 0
+		}
+	}
+	m.positions[order.AccountID][order.Symbol] = pos
+	order.ID = m.id()
+	m.orders = append(m.orders, order)
+	return &order, nil
+}
+
+// stalePaperStore serves the account as it was when first read, like another
+// replica whose view predates a fill.
+type stalePaperStore struct {
+	*memPaperStore
+	seen map[int64]domain.PaperAccount
+}
+
+func (s *stalePaperStore) GetAccount(ctx context.Context, id int64) (*domain.PaperAccount, error) {
+	if a, ok := s.seen[id]; ok {
+		return &a, nil
+	}
+	a, err := s.memPaperStore.GetAccount(ctx, id)
+	if a != nil {
+		s.seen[id] = *a
+	}
+	return a, err
+}
+
+func (m *memPaperStore) CreateSubscription(ctx context.Context, sub domain.PaperSubscription) (*domain.PaperSubscription, error) {
+	sub.ID = m.id()
+	m.subs = append(m.subs, sub)
+	return &sub, nil
+}
+
+func (m *memPaperStore) ListSubscriptions(ctx context.Context, accountID int64) ([]domain.PaperSubscription, error) {
+	out := make([]domain.PaperSubscription, 0)
+	for _, s := range m.subs {
+		if accountID == 0 || s.AccountID == accountID {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func (m *memPaperStore) DeleteSubscription(ctx context.Context, accountID, id int64) (bool, error) {
+	for i, s := range m.subs {
+		if s.ID == id && s.AccountID == accountID {
+			m.subs = append(m.subs[:i], m.subs[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type stubPaperPrices map[string]float64
+
+func (s stubPaperPrices) GetCurrentPrice(ctx context.Context, symbol string) (*domain.PriceSnapshot, error) {
+	p, ok := s[symbol]
+	if !ok {
+		return nil, fmt.Errorf("no price for %s", symbol)
+	}
+	return &domain.PriceSnapshot{Symbol: symbol, PriceUSD: p}, nil
+}
+
+func newTestPaperService(prices stubPaperPrices) (*PaperTradingService, *memPaperStore) {
+	store := newMemPaperStore()
+	return NewPaperTradingService(trace.NewNoopTracerProvider().Tracer("test"), store, prices), store
+}
+
+func TestPaperTradingBuySellRealisesPnL(t *testing.T) {
+	prices := stubPaperPrices{"BTC": 100}
+	svc, _ := newTestPaperService(prices)
+	ctx := context.Background()
+
+	acct, err := svc.CreateAccount(ctx, domain.PaperAccount{Name: " desk ", StartingBalance: 1000, SlippageBps: 100})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if acct.Name != "desk" || acct.Cash != 1000 {
+		t.Fatalf("unexpected account: %+v", acct)
+	}
+
+	buy, err := svc.PlaceOrder(ctx, domain.PaperOrderRequest{AccountID: acct.ID, Symbol: "btc", Side: "BUY", Notional: 505})
+	if err != nil {
+		t.Fatalf("unexpected buy error: %v", err)
+	}
+	if buy.FillPrice != 101 || math.Abs(buy.Quantity-5) > 1e-9 || buy.Source != domain.PaperSourceManual {
+		t.Fatalf("expected 5 units filled 1%% above market, got %+v", buy)
+	}
+
+	prices["BTC"] = 120
+	p, err := svc.Portfolio(ctx, acct.ID)
+	if err != nil {
+		t.Fatalf("unexpected portfolio error: %v", err)
+	}
+	if len(p.Holdings) != 1 || math.Abs(p.UnrealizedPnL-95) > 1e-9 || math.Abs(p.Equity-1095) > 1e-9 || math.Abs(p.Return-0.095) > 1e-9 {
+		t.Fatalf("unexpected portfolio: %+v", p)
+	}
+
+	sell, err := svc.PlaceOrder(ctx, domain.PaperOrderRequest{AccountID: acct.ID, Symbol: "BTC", Side: domain.PaperSell})
+	if err != nil {
+		t.Fatalf("unexpected sell error: %v", err)
+	}
+	// Sold 5 at 118.8 against an average of 101.
+	if math.Abs(sell.Quantity-5) > 1e-9 || math.Abs(sell.RealizedPnL-89) > 1e-9 {
+		t.Fatalf("expected whole position closed with 89 realised, got %+v", sell)
+	}
+	p, _ = svc.Portfolio(ctx, acct.ID)
+	if len(p.Holdings) != 0 || math.Abs(p.RealizedPnL-89) > 1e-9 || math.Abs(p.Account.Cash-1089) > 1e-9 {
+		t.Fatalf("unexpected portfolio after sell: %+v", p)
+	}
+}
+
+func TestPaperTradingRejectsBadOrders(t *testing.T) {
+	svc, _ := newTestPaperService(stubPaperPrices{"ETH": 2000})
+	ctx := context.Background()
+	acct, _ := svc.CreateAccount(ctx, domain.PaperAccount{Name: "a", StartingBalance: 1000})
+
+	cases := []struct {
+		req  domain.PaperOrderRequest
+		want error
+	}{
+		{domain.PaperOrderRequest{AccountID: acct.ID, Symbol: "NOPE", Side: domain.PaperBuy, Quantity: 1}, ErrInvalidPaperOrder},
+		{domain.PaperOrderRequest{AccountID: acct.ID, Symbol: "ETH", Side: "hold", Quantity: 1}, ErrInvalidPaperOrder},
+		{domain.PaperOrderRequest{AccountID: acct.ID, Symbol: "ETH", Side: domain.PaperBuy}, ErrInvalidPaperOrder},
+		{domain.PaperOrderRequest{AccountID: acct.ID, Symbol: "ETH", Side: domain.PaperBuy, Quantity: 1, Notional: 5}, ErrInvalidPaperOrder},
+		{domain.PaperOrderRequest{AccountID: acct.ID, Symbol: "ETH", Side: domain.PaperBuy, Quantity: 1}, ErrInsufficientPaperFunds},
+		{domain.PaperOrderRequest{AccountID: acct.ID, Symbol: "ETH", Side: domain.PaperSell}, ErrInsufficientPaperFunds},
+		{domain.PaperOrderRequest{AccountID: 999, Symbol: "ETH", Side: domain.PaperBuy, Quantity: 0.1}, ErrPaperAccountNotFound},
+	}
+	for i, tc := range cases {
+		if _, err := svc.PlaceOrder(ctx, tc.req); !errors.Is(err, tc.want) {
+			t.Fatalf("case %d: expected %v, got %v", i, tc.want, err)
+		}
+	}
+
+	if _, err := svc.PlaceOrder(ctx, domain.PaperOrderRequest{AccountID: acct.ID, Symbol: "ETH", Side: domain.PaperBuy, Quantity: 0.1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.PlaceOrder(ctx, domain.PaperOrderRequest{AccountID: acct.ID, Symbol: "ETH", Side: domain.PaperSell, Quantity: 0.2}); !errors.Is(err, ErrInsufficientPaperFunds) {
+		t.Fatalf("expected oversell to fail, got %v", err)
+	}
+}
+
+func TestPaperTradingFillsCannotOverspendAcrossReplicas(t *testing.T) {
+	prices := stubPaperPrices{"BTC": 100}
+	svc, store := newTestPaperService(prices)
+	stale := &stalePaperStore{memPaperStore: store, seen: map[int64]domain.PaperAccount{}}
+	other := NewPaperTradingService(trace.NewNoopTracerProvider().Tracer("test"), stale, prices)
+	ctx := context.Background()
+
+	acct, _ := svc.CreateAccount(ctx, domain.PaperAccount{Name: "a", StartingBalance: 1000})
+	if _, err := stale.GetAccount(ctx, acct.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.PlaceOrder(ctx, domain.PaperOrderRequest{AccountID: acct.ID, Symbol: "BTC", Side: domain.PaperBuy, Notional: 800}); err != nil {
+		t.Fatalf("unexpected buy error: %v", err)
+	}
+	if _, err := other.PlaceOrder(ctx, domain.PaperOrderRequest{AccountID: acct.ID, Symbol: "BTC", Side: domain.PaperBuy, Notional: 800}); !errors.Is(err, ErrInsufficientPaperFunds) {
+		t.Fatalf("expected the stale replica's buy to be refused, got %v", err)
+	}
+	if cash := store.accounts[acct.ID].Cash; math.Abs(cash-200) > 1e-9 {
+		t.Fatalf("expected cash 200 after one buy, got %v", cash)
+	}
+}
+
+func TestPaperTradingAccountForOwnerIsStable(t *testing.T) {
+	svc, store := newTestPaperService(stubPaperPrices{})
+	ctx := context.Background()
+
+	first, err := svc.AccountForOwner(ctx, "telegram:42", "telegram 42")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, _ := svc.AccountForOwner(ctx, "telegram:42", "telegram 42")
+	if first.ID != second.ID || len(store.accounts) != 1 {
+		t.Fatalf("expected one account per owner, got %d", len(store.accounts))
+	}
+	if first.StartingBalance != DefaultPaperBalance || first.SlippageBps != DefaultPaperSlippageBps {
+		t.Fatalf("expected defaults, got %+v", first)
+	}
+}
+
+func TestPaperTradingFollowsSignals(t *testing.T) {
+	svc, store := newTestPaperService(stubPaperPrices{"BTC": 100, "ETH": 50})
+	ctx := context.Background()
+	acct, _ := svc.CreateAccount(ctx, domain.PaperAccount{Name: "bot", StartingBalance: 1000})
+	if _, err := svc.Subscribe(ctx, domain.PaperSubscription{AccountID: acct.ID, Indicator: "RSI", MaxRisk: domain.RiskLevel3, TradeNotional: 200}); err != nil {
+		t.Fatalf("unexpected subscribe error: %v", err)
+	}
+
+	svc.NotifySignals(ctx, []domain.Signal{
+		{ID: 1, Symbol: "BTC", Indicator: domain.IndicatorRSI, Risk: domain.RiskLevel2, Direction: domain.DirectionLong},
+		// Already long BTC: no second entry.
+		{ID: 2, Symbol: "BTC", Indicator: domain.IndicatorRSI, Risk: domain.RiskLevel1, Direction: domain.DirectionLong},
+		// Filtered out by indicator and risk.
+		{ID: 3, Symbol: "ETH", Indicator: domain.IndicatorMACD, Risk: domain.RiskLevel1, Direction: domain.DirectionLong},
+		{ID: 4, Symbol: "ETH", Indicator: domain.IndicatorRSI, Risk: domain.RiskLevel5, Direction: domain.DirectionLong},
+		// Nothing to close.
+		{ID: 5, Symbol: "ETH", Indicator: domain.IndicatorRSI, Risk: domain.RiskLevel1, Direction: domain.DirectionShort},
+	})
+	if len(store.orders) != 1 {
+		t.Fatalf("expected a single entry, got %+v", store.orders)
+	}
+	entry := store.orders[0]
+	if entry.Side != domain.PaperBuy || entry.Source != domain.PaperSourceSignal || entry.SignalID == nil || *entry.SignalID != 1 {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+	if math.Abs(entry.Notional-200) > 1e-9 {
+		t.Fatalf("expected trade notional of 200, got %.4f", entry.Notional)
+	}
+
+	svc.NotifySignals(ctx, []domain.Signal{{ID: 6, Symbol: "BTC", Indicator: domain.IndicatorRSI, Risk: domain.RiskLevel2, Direction: domain.DirectionShort}})
+	if len(store.orders) != 2 || store.orders[1].Side != domain.PaperSell || store.positions[acct.ID]["BTC"].Quantity != 0 {
+		t.Fatalf("expected short signal to close the position, got %+v", store.orders)
+	}
+}
+
+func TestPaperTradingSubscriptionValidation(t *testing.T) {
+	svc, _ := newTestPaperService(stubPaperPrices{})
+	ctx := context.Background()
+	acct, _ := svc.CreateAccount(ctx, domain.PaperAccount{Name: "a"})
+
+	if _, err := svc.Subscribe(ctx, domain.PaperSubscription{AccountID: acct.ID, Symbol: "NOPE"}); !errors.Is(err, ErrInvalidPaperOrder) {
+		t.Fatalf("expected invalid symbol, got %v", err)
+	}
+	if _, err := svc.Subscribe(ctx, domain.PaperSubscription{AccountID: 99}); !errors.Is(err, ErrPaperAccountNotFound) {
+		t.Fatalf("expected missing account, got %v", err)
+	}
+	sub, err := svc.Subscribe(ctx, domain.PaperSubscription{AccountID: acct.ID, Symbol: "sol"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sub.Symbol != "SOL" || sub.TradeNotional != DefaultPaperTradeNotional {
+		t.Fatalf("unexpected subscription: %+v", sub)
+	}
+	if err := svc.Unsubscribe(ctx, acct.ID, sub.ID); err != nil {
+		t.Fatalf("unexpected unsubscribe error: %v", err)
+	}
+	if err := svc.Unsubscribe(ctx, acct.ID, sub.ID); !errors.Is(err, ErrPaperSubscriptionNotFound) {
+		t.Fatalf("expected missing subscription, got %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/signal"
+	"bug-free-umbrella/internal/sizing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// sizingSignalLookback is how many recent signals are searched for one
+	// with levels.
+	sizingSignalLookback = 20
+	// sizingFallbackInterval supplies the ATR stop when a symbol has no
+	// recent directional signal.
+	sizingFallbackInterval = "4h"
+)
+
+var ErrInvalidSizingRequest = errors.New("invalid sizing request")
+
+type SizingSignalSource interface {
+	ListSignals(ctx context.Context, filter domain.SignalFilter) ([]domain.Signal, error)
+}
+
+type SizingPriceSource interface {
+	GetCurrentPrice(ctx context.Context, symbol string) (*domain.PriceSnapshot, error)
+}
+
+// PositionSizingService sizes trades on a symbol from its latest directional
+// signal, falling back to the live price and an ATR stop when there is none.
+type PositionSizingService struct {
+	tracer  trace.Tracer
+	cfg     domain.SizingConfig
+	signals SizingSignalSource
+	candles SignalCandleRepository
+	prices  SizingPriceSource
+}
+
+func NewPositionSizingService(
+	tracer trace.Tracer,
+	cfg domain.SizingConfig,
+	signals SizingSignalSource,
+	candles SignalCandleRepository,
+	prices SizingPriceSource,
+) *PositionSizingService {
+	return &PositionSizingService{
+		tracer:  tracer,
+		cfg:     sizing.WithDefaults(cfg),
+		signals: signals,
+		candles: candles,
+		prices:  prices,
+	}
+}
+
+// Config returns the sizing rules in effect.
+func (s *PositionSizingService) Config() domain.SizingConfig {
+	return s.cfg
+}
+
+// SizeSymbol sizes a trade on req.Symbol. With no recent directional signal
+// the trade is sized long at the current price with an ATR stop, at risk
+// level 3.
+func (s *PositionSizingService) SizeSymbol(ctx context.Context, req domain.SizingRequest) (*domain.PositionSize, error) {
+	ctx, span := s.tracer.Start(ctx, "position-sizing-service.size-symbol")
+	defer span.End()
+
+	symbol := strings.ToUpper(strings.TrimSpace(req.Symbol))
+	if _, ok := domain.CoinGeckoID[symbol]; !ok {
+		return nil, fmt.Errorf("%w: unsupported symbol %q", ErrInvalidSizingRequest, req.Symbol)
+	}
+	if !(req.Equity > 0) || math.IsInf(req.Equity, 0) {
+		return nil, fmt.Errorf("%w: equity must be positive", ErrInvalidSizingRequest)
+	}
+	cfg := s.cfg
+	if req.RiskPerTrade != 0 {
+		if !(req.RiskPerTrade > 0 && req.RiskPerTrade < 1) {
+			return nil, fmt.Errorf("%w: risk per trade must be between 0 and 1", ErrInvalidSizingRequest)
+		}
+		cfg.RiskPerTrade = req.RiskPerTrade
+	}
+	if req.Method != "" {
+		if !req.Method.IsValid() {
+			return nil, fmt.Errorf("%w: unknown sizing method %q", ErrInvalidSizingRequest, req.Method)
+		}
+		cfg.Method = req.Method
+	}
+
+	sig, err := s.latestSignal(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	in := domain.SizingInput{Symbol: symbol, Equity: req.Equity}
+	interval := sizingFallbackInterval
+	note := ""
+	if sig != nil {
+		in.Direction = sig.Direction
+		in.Entry = sig.Levels.Entry
+		in.Stop = sig.Levels.Stop
+		in.Risk = sig.Risk
+		in.RewardRisk = sig.Levels.RewardRisk
+		interval = sig.Interval
+	} else {
+		if s.prices == nil {
+			return nil, fmt.Errorf("no recent %s signal and no price source", symbol)
+		}
+		snap, err := s.prices.GetCurrentPrice(ctx, symbol)
+		if err != nil {
+			return nil, fmt.Errorf("get price for %s: %w", symbol, err)
+		}
+		in.Entry = snap.PriceUSD
+		in.Risk = domain.RiskLevel3
+		note = fmt.Sprintf("No recent %s signal; sized long from the %s ATR at risk level 3.", symbol, interval)
+	}
+	if sig == nil || cfg.Method == domain.SizingVolatilityParity {
+		in.ATR = s.latestATR(ctx, symbol, interval)
+	}
+
+	out, err := sizing.Size(cfg, in)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSizingRequest, err)
+	}
+	if sig != nil {
+		out.SignalID = sig.ID
+	}
+	if note != "" {
+		out.Note = strings.TrimSpace(note + " " + out.Note)
+	}
+	return out, nil
+}
+
+// latestSignal returns the newest directional signal on symbol that carries
+// levels, or nil.
+func (s *PositionSizingService) latestSignal(ctx context.Context, symbol string) (*domain.Signal, error) {
+	if s.signals == nil {
+		return nil, nil
+	}
+	signals, err := s.signals.ListSignals(ctx, domain.SignalFilter{Symbol: symbol, Limit: sizingSignalLookback})
+	if err != nil {
+		return nil, fmt.Errorf("list signals for %s: %w", symbol, err)
+	}
+	for i := range signals {
+		if signals[i].Levels != nil && signals[i].Direction != domain.DirectionHold {
+			return &signals[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *PositionSizingService) latestATR(ctx context.Context, symbol, interval string) float64 {
+	if s.candles == nil {
+		return 0
+	}
+	candles, err := s.candles.GetCandles(ctx, symbol, interval, signalLevelLookbackCandles)
+	if err != nil {
+		log.Printf("position sizing candles %s %s: %v", symbol, interval, err)
+		return 0
+	}
+	return signal.LatestATR(candlesUpTo(candles, domain.Signal{Timestamp: time.Now().UTC()}))
+}
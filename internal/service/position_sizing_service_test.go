@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func flatSizingCandles(n int, price float64) []*domain.Candle {
+	base := time.Now().UTC().Add(-time.Duration(n) * 4 * time.Hour)
+	out := make([]*domain.Candle, 0, n)
+	for i := 0; i < n; i++ {
+		out = append(out, &domain.Candle{
+			Symbol: "ETH", Interval: "4h", OpenTime: base.Add(time.Duration(i) * 4 * time.Hour),
+			Open: price, High: price + 10, Low: price - 10, Close: price,
+		})
+	}
+	return out
+}
+
+func TestPositionSizingUsesLatestSignalLevels(t *testing.T) {
+	signals := &stubSignalRepo{listResp: []domain.Signal{
+		{ID: 9, Symbol: "BTC", Direction: domain.DirectionHold, Risk: domain.RiskLevel1},
+		{ID: 8, Symbol: "BTC", Interval: "1h", Direction: domain.DirectionShort, Risk: domain.RiskLevel4,
+			Levels: &domain.SignalLevels{Entry: 50000, Stop: 51000, Targets: []float64{48000}, RewardRisk: 2}},
+	}}
+	svc := NewPositionSizingService(trace.NewNoopTracerProvider().Tracer("test"),
+		domain.SizingConfig{MaxPositionPct: 1}, signals, &stubSignalCandleRepo{}, stubPaperPrices{})
+
+	out, err := svc.SizeSymbol(context.Background(), domain.SizingRequest{Symbol: "btc", Equity: 10000})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if signals.lastFilter.Symbol != "BTC" || out.SignalID != 8 || out.Direction != domain.DirectionShort {
+		t.Fatalf("expected the short signal to be sized, got %+v", out)
+	}
+	// Risk 4 halves the 1% budget: 50 over a 1000 stop.
+	if math.Abs(out.Quantity-0.05) > 1e-9 || math.Abs(out.Notional-2500) > 1e-6 {
+		t.Fatalf("unexpected size: %+v", out)
+	}
+
+	out, err = svc.SizeSymbol(context.Background(), domain.SizingRequest{Symbol: "BTC", Equity: 10000, RiskPerTrade: 0.02})
+	if err != nil || math.Abs(out.RiskAmount-100) > 1e-6 {
+		t.Fatalf("expected the risk override to apply, got %+v %v", out, err)
+	}
+}
+
+func TestPositionSizingFallsBackToATR(t *testing.T) {
+	candles := &stubSignalCandleRepo{candles: map[string][]*domain.Candle{"4h": flatSizingCandles(60, 2000)}}
+	svc := NewPositionSizingService(trace.NewNoopTracerProvider().Tracer("test"),
+		domain.SizingConfig{MaxPositionPct: 1}, &stubSignalRepo{}, candles, stubPaperPrices{"ETH": 2000})
+
+	out, err := svc.SizeSymbol(context.Background(), domain.SizingRequest{Symbol: "ETH", Equity: 10000})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// ATR 20 puts the stop 30 below 2000; risk level 3 risks 75.
+	if out.SignalID != 0 || math.Abs(out.Stop-1970) > 1e-6 || math.Abs(out.RiskAmount-75) > 1e-6 {
+		t.Fatalf("unexpected fallback size: %+v", out)
+	}
+	if !strings.Contains(out.Note, "No recent ETH signal") {
+		t.Fatalf("expected fallback note, got %q", out.Note)
+	}
+}
+
+func TestPositionSizingValidatesRequest(t *testing.T) {
+	svc := NewPositionSizingService(trace.NewNoopTracerProvider().Tracer("test"),
+		domain.SizingConfig{}, &stubSignalRepo{}, &stubSignalCandleRepo{}, stubPaperPrices{"BTC": 100})
+	cases := []domain.SizingRequest{
+		{Symbol: "FAKE", Equity: 1000},
+		{Symbol: "BTC", Equity: 0},
+		{Symbol: "BTC", Equity: 1000, RiskPerTrade: 1.5},
+		{Symbol: "BTC", Equity: 1000, Method: "martingale"},
+		// No candles for an ATR stop and no signal.
+		{Symbol: "BTC", Equity: 1000},
+	}
+	for i, req := range cases {
+		if _, err := svc.SizeSymbol(context.Background(), req); !errors.Is(err, ErrInvalidSizingRequest) {
+			t.Fatalf("case %d: expected invalid request, got %v", i, err)
+		}
+	}
+}
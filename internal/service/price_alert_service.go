@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/pricealert"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxPriceAlertsPerChat bounds how many rules one user can keep, fired
+// one-shot rules included.
+const maxPriceAlertsPerChat = 50
+
+// ErrInvalidPriceAlert wraps validation failures for new rules.
+var ErrInvalidPriceAlert = errors.New("invalid price alert")
+
+// ErrPriceAlertNotFound is returned when deleting a rule the user does not
+// have.
+var ErrPriceAlertNotFound = errors.New("price alert not found")
+
+type PriceAlertStore interface {
+	CreateRule(ctx context.Context, rule domain.PriceAlertRule) (*domain.PriceAlertRule, error)
+	ListRules(ctx context.Context, chatID int64) ([]domain.PriceAlertRule, error)
+	ListActiveRules(ctx context.Context) ([]domain.PriceAlertRule, error)
+	DeleteRule(ctx context.Context, chatID, id int64) (bool, error)
+	// UpdateRuleState applies a state transition unless another evaluator
+	// already has, and reports whether it did.
+	UpdateRuleState(ctx context.Context, rule domain.PriceAlertRule) (bool, error)
+}
+
+type PriceAlertPriceSource interface {
+	GetCurrentPrices(ctx context.Context) ([]*domain.PriceSnapshot, error)
+}
+
+// PriceAlertNotifier delivers fired rules to their owners.
+type PriceAlertNotifier interface {
+	NotifyPriceAlerts(ctx context.Context, triggers []domain.PriceAlertTrigger) error
+}
+
+// PriceAlertService manages users' price alert rules and evaluates them each
+// time the price poller refreshes prices.
+type PriceAlertService struct {
+	tracer    trace.Tracer
+	store     PriceAlertStore
+	prices    PriceAlertPriceSource
+	history   *pricealert.History
+	notifiers []PriceAlertNotifier
+	now       func() time.Time
+
+	// evalMu keeps this process's evaluations from overlapping; the store's
+	// conditional update does the same across replicas.
+	evalMu sync.Mutex
+}
+
+func NewPriceAlertService(tracer trace.Tracer, store PriceAlertStore, prices PriceAlertPriceSource) *PriceAlertService {
+	return &PriceAlertService{
+		tracer:  tracer,
+		store:   store,
+		prices:  prices,
+		history: pricealert.NewHistory(),
+		now:     time.Now,
+	}
+}
+
+// AddNotifier registers another receiver of fired rules. Every notifier is
+// told about every fire; without any, rules still advance their state but
+// nobody is told.
+func (s *PriceAlertService) AddNotifier(n PriceAlertNotifier) {
+	if n == nil {
+		return
+	}
+	s.notifiers = append(s.notifiers, n)
+}
+
+// CreateRule validates and stores a new rule. A cross rule whose condition
+// already holds starts disarmed, so it fires on the next real cross rather
+// than immediately.
+func (s *PriceAlertService) CreateRule(ctx context.Context, rule domain.PriceAlertRule) (*domain.PriceAlertRule, error) {
+	ctx, span := s.tracer.Start(ctx, "price-alert-service.create-rule")
+	defer span.End()
+
+	if rule.ChatID == 0 {
+		return nil, fmt.Errorf("%w: chat id is required", ErrInvalidPriceAlert)
+	}
+	rule, err := pricealert.Normalize(rule)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPriceAlert, err)
+	}
+	existing, err := s.store.ListRules(ctx, rule.ChatID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxPriceAlertsPerChat {
+		return nil, fmt.Errorf("%w: limit of %d alerts reached, remove one first", ErrInvalidPriceAlert, maxPriceAlertsPerChat)
+	}
+
+	rule.ID = 0
+	rule.Active = true
+	rule.Armed = true
+	rule.TriggerCount = 0
+	rule.LastTriggeredAt = nil
+	if rule.Type == domain.PriceAlertCross {
+		if price, ok := s.currentPrice(ctx, rule.Symbol); ok && pricealert.Met(rule, price) {
+			rule.Armed = false
+		}
+	}
+	span.SetAttributes(
+		attribute.String("symbol", rule.Symbol),
+		attribute.String("type", string(rule.Type)),
+	)
+	return s.store.CreateRule(ctx, rule)
+}
+
+func (s *PriceAlertService) ListRules(ctx context.Context, chatID int64) ([]domain.PriceAlertRule, error) {
+	ctx, span := s.tracer.Start(ctx, "price-alert-service.list-rules")
+	defer span.End()
+
+	return s.store.ListRules(ctx, chatID)
+}
+
+func (s *PriceAlertService) DeleteRule(ctx context.Context, chatID, id int64) error {
+	ctx, span := s.tracer.Start(ctx, "price-alert-service.delete-rule")
+	defer span.End()
+
+	ok, err := s.store.DeleteRule(ctx, chatID, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: #%d", ErrPriceAlertNotFound, id)
+	}
+	return nil
+}
+
+// OnPricesRefreshed records the latest prices and evaluates every active
+// rule against them, storing state changes and notifying fired rules.
+func (s *PriceAlertService) OnPricesRefreshed(ctx context.Context) error {
+	ctx, span := s.tracer.Start(ctx, "price-alert-service.evaluate")
+	defer span.End()
+
+	s.evalMu.Lock()
+	defer s.evalMu.Unlock()
+
+	snaps, err := s.prices.GetCurrentPrices(ctx)
+	if err != nil {
+		return err
+	}
+	now := s.now().UTC()
+	s.history.Record(snaps, now)
+
+	rules, err := s.store.ListActiveRules(ctx)
+	if err != nil {
+		return err
+	}
+	bySymbol := make(map[string]domain.PriceSnapshot, len(snaps))
+	for _, snap := range snaps {
+		if snap != nil {
+			bySymbol[snap.Symbol] = *snap
+		}
+	}
+
+	triggers := make([]domain.PriceAlertTrigger, 0)
+	for i := range rules {
+		rule := &rules[i]
+		snap, ok := bySymbol[rule.Symbol]
+		if !ok {
+			continue
+		}
+		value, ok := pricealert.Measure(*rule, snap, s.history, now)
+		if !ok {
+			continue
+		}
+		fired, changed := pricealert.Step(rule, value, now)
+		if !changed {
+			continue
+		}
+		applied, err := s.store.UpdateRuleState(ctx, *rule)
+		if err != nil {
+			// Skip the notification too: an unsaved fire would repeat on
+			// the next refresh.
+			log.Printf("price alert #%d state update failed: %v", rule.ID, err)
+			continue
+		}
+		// Another replica evaluated the same prices and already notified.
+		if fired && applied {
+			triggers = append(triggers, domain.PriceAlertTrigger{Rule: *rule, Price: snap.PriceUSD, Value: value, TriggeredAt: now})
+		}
+	}
+	span.SetAttributes(
+		attribute.Int("rules", len(rules)),
+		attribute.Int("triggers", len(triggers)),
+	)
+
+	if len(triggers) == 0 {
+		return nil
+	}
+	var errs []error
+	for _, n := range s.notifiers {
+		if err := n.NotifyPriceAlerts(ctx, triggers); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *PriceAlertService) currentPrice(ctx context.Context, symbol string) (float64, bool) {
+	snaps, err := s.prices.GetCurrentPrices(ctx)
+	if err != nil {
+		return 0, false
+	}
+	for _, snap := range snaps {
+		if snap != nil && snap.Symbol == symbol && snap.PriceUSD > 0 {
+			return snap.PriceUSD, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type memPriceAlertStore struct {
+	rules   []domain.PriceAlertRule
+	nextID  int64
+	updates int
+}
+
+func (m *memPriceAlertStore) CreateRule(ctx context.Context, rule domain.PriceAlertRule) (*domain.PriceAlertRule, error) {
+	m.nextID++
+	rule.ID = m.nextID
+	m.rules = append(m.rules, rule)
+	return &rule, nil
+}
+
+func (m *memPriceAlertStore) ListRules(ctx context.Context, chatID int64) ([]domain.PriceAlertRule, error) {
+	out := make([]domain.PriceAlertRule, 0)
+	for _, r := range m.rules {
+		if r.ChatID == chatID {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (m *memPriceAlertStore) ListActiveRules(ctx context.Context) ([]domain.PriceAlertRule, error) {
+	out := make([]domain.PriceAlertRule, 0)
+	for _, r := range m.rules {
+		if r.Active {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (m *memPriceAlertStore) DeleteRule(ctx context.Context, chatID, id int64) (bool, error) {
+	for i, r := range m.rules {
+		if r.ChatID == chatID && r.ID == id {
+			m.rules = append(m.rules[:i], m.rules[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memPriceAlertStore) UpdateRuleState(ctx context.Context, rule domain.PriceAlertRule) (bool, error) {
+	m.updates++
+	for i, r := range m.rules {
+		if r.ID == rule.ID && r.Active && r.Armed != rule.Armed {
+			m.rules[i] = rule
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// staleRuleStore lists the rules as they were before another replica
+// evaluated them.
+type staleRuleStore struct {
+	*memPriceAlertStore
+	snapshot []domain.PriceAlertRule
+}
+
+func (s *staleRuleStore) ListActiveRules(ctx context.Context) ([]domain.PriceAlertRule, error) {
+	return append([]domain.PriceAlertRule(nil), s.snapshot...), nil
+}
+
+type recordingPriceAlertNotifier struct {
+	triggers []domain.PriceAlertTrigger
+}
+
+func (n *recordingPriceAlertNotifier) NotifyPriceAlerts(ctx context.Context, triggers []domain.PriceAlertTrigger) error {
+	n.triggers = append(n.triggers, triggers...)
+	return nil
+}
+
+type mutablePrices struct {
+	prices []*domain.PriceSnapshot
+}
+
+func (m *mutablePrices) GetCurrentPrices(ctx context.Context) ([]*domain.PriceSnapshot, error) {
+	return m.prices, nil
+}
+
+func (m *mutablePrices) set(symbol string, price, volume float64) {
+	m.prices = []*domain.PriceSnapshot{{Symbol: symbol, PriceUSD: price, Volume24h: volume}}
+}
+
+func newTestPriceAlertService() (*PriceAlertService, *memPriceAlertStore, *mutablePrices, *recordingPriceAlertNotifier) {
+	store := &memPriceAlertStore{}
+	prices := &mutablePrices{}
+	notifier := &recordingPriceAlertNotifier{}
+	svc := NewPriceAlertService(trace.NewNoopTracerProvider().Tracer("test"), store, prices)
+	svc.AddNotifier(notifier)
+	return svc, store, prices, notifier
+}
+
+func TestPriceAlertCreateValidatesAndArms(t *testing.T) {
+	svc, store, prices, _ := newTestPriceAlertService()
+	ctx := context.Background()
+
+	if _, err := svc.CreateRule(ctx, domain.PriceAlertRule{ChatID: 7, Symbol: "NOPE", Type: domain.PriceAlertCross, Direction: domain.PriceAlertUp, Threshold: 1}); !errors.Is(err, ErrInvalidPriceAlert) {
+		t.Fatalf("expected invalid alert, got %v", err)
+	}
+	if _, err := svc.CreateRule(ctx, domain.PriceAlertRule{Symbol: "BTC", Type: domain.PriceAlertCross, Direction: domain.PriceAlertUp, Threshold: 1}); !errors.Is(err, ErrInvalidPriceAlert) {
+		t.Fatalf("expected chat id to be required, got %v", err)
+	}
+
+	prices.set("BTC", 100000, 0)
+	above, err := svc.CreateRule(ctx, domain.PriceAlertRule{ChatID: 7, Symbol: "btc", Type: domain.PriceAlertCross, Direction: domain.PriceAlertUp, Threshold: 90000})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !above.Active || above.Armed || above.Hysteresis == 0 {
+		t.Fatalf("expected rule already past its line to start disarmed, got %+v", above)
+	}
+	below, err := svc.CreateRule(ctx, domain.PriceAlertRule{ChatID: 7, Symbol: "BTC", Type: domain.PriceAlertCross, Direction: domain.PriceAlertDown, Threshold: 90000})
+	if err != nil || !below.Armed {
+		t.Fatalf("expected armed rule, got %+v %v", below, err)
+	}
+
+	for len(store.rules) < maxPriceAlertsPerChat {
+		store.rules = append(store.rules, domain.PriceAlertRule{ChatID: 7})
+	}
+	if _, err := svc.CreateRule(ctx, domain.PriceAlertRule{ChatID: 7, Symbol: "BTC", Type: domain.PriceAlertCross, Direction: domain.PriceAlertUp, Threshold: 1}); !errors.Is(err, ErrInvalidPriceAlert) {
+		t.Fatalf("expected limit error, got %v", err)
+	}
+}
+
+func TestPriceAlertDeleteScopedToUser(t *testing.T) {
+	svc, _, _, _ := newTestPriceAlertService()
+	ctx := context.Background()
+	rule, err := svc.CreateRule(ctx, domain.PriceAlertRule{ChatID: 7, Symbol: "ETH", Type: domain.PriceAlertCross, Direction: domain.PriceAlertUp, Threshold: 4000})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := svc.DeleteRule(ctx, 8, rule.ID); !errors.Is(err, ErrPriceAlertNotFound) {
+		t.Fatalf("expected not found for another user, got %v", err)
+	}
+	if err := svc.DeleteRule(ctx, 7, rule.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rules, _ := svc.ListRules(ctx, 7); len(rules) != 0 {
+		t.Fatalf("expected rule removed, got %+v", rules)
+	}
+}
+
+func TestPriceAlertEvaluationFiresWithHysteresis(t *testing.T) {
+	svc, store, prices, notifier := newTestPriceAlertService()
+	ctx := context.Background()
+	prices.set("ETH", 3900, 0)
+	if _, err := svc.CreateRule(ctx, domain.PriceAlertRule{ChatID: 7, Symbol: "ETH", Type: domain.PriceAlertCross, Direction: domain.PriceAlertUp, Threshold: 4000, Recurring: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.CreateRule(ctx, domain.PriceAlertRule{ChatID: 9, Symbol: "ETH", Type: domain.PriceAlertCross, Direction: domain.PriceAlertUp, Threshold: 4050}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	steps := []struct {
+		price float64
+		fired int
+	}{
+		{3990, 0},
+		{4010, 1}, // recurring rule fires
+		{3995, 1}, // within hysteresis, no re-arm
+		{4060, 2}, // one-shot rule fires
+		{3950, 2}, // recurring rule re-arms
+		{4001, 3}, // and fires again
+		{4100, 3},
+	}
+	for i, step := range steps {
+		prices.set("ETH", step.price, 0)
+		if err := svc.OnPricesRefreshed(ctx); err != nil {
+			t.Fatalf("step %d: unexpected error: %v", i, err)
+		}
+		if len(notifier.triggers) != step.fired {
+			t.Fatalf("step %d at %.0f: expected %d triggers, got %d", i, step.price, step.fired, len(notifier.triggers))
+		}
+	}
+	if notifier.triggers[1].Rule.ChatID != 9 || notifier.triggers[1].Price != 4060 {
+		t.Fatalf("unexpected trigger: %+v", notifier.triggers[1])
+	}
+	if store.rules[0].TriggerCount != 2 || !store.rules[0].Active || store.rules[1].Active {
+		t.Fatalf("unexpected stored state: %+v", store.rules)
+	}
+}
+
+func TestPriceAlertNotifiesEveryNotifier(t *testing.T) {
+	svc, _, prices, first := newTestPriceAlertService()
+	second := &recordingPriceAlertNotifier{}
+	svc.AddNotifier(nil)
+	svc.AddNotifier(second)
+	ctx := context.Background()
+	prices.set("BTC", 90000, 0)
+	if _, err := svc.CreateRule(ctx, domain.PriceAlertRule{ChatID: 7, Symbol: "BTC", Type: domain.PriceAlertCross, Direction: domain.PriceAlertUp, Threshold: 95000}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	prices.set("BTC", 96000, 0)
+	if err := svc.OnPricesRefreshed(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(first.triggers) != 1 || len(second.triggers) != 1 {
+		t.Fatalf("expected both notifiers told, got %d and %d", len(first.triggers), len(second.triggers))
+	}
+}
+
+func TestPriceAlertFiresOnceAcrossReplicas(t *testing.T) {
+	svc, store, prices, first := newTestPriceAlertService()
+	ctx := context.Background()
+	prices.set("BTC", 90000, 0)
+	if _, err := svc.CreateRule(ctx, domain.PriceAlertRule{ChatID: 7, Symbol: "BTC", Type: domain.PriceAlertCross, Direction: domain.PriceAlertUp, Threshold: 95000, Recurring: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stale := &staleRuleStore{memPriceAlertStore: store, snapshot: append([]domain.PriceAlertRule(nil), store.rules...)}
+	replica := NewPriceAlertService(trace.NewNoopTracerProvider().Tracer("test"), stale, prices)
+	second := &recordingPriceAlertNotifier{}
+	replica.AddNotifier(second)
+
+	prices.set("BTC", 96000, 0)
+	if err := svc.OnPricesRefreshed(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := replica.OnPricesRefreshed(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(first.triggers) != 1 || len(second.triggers) != 0 {
+		t.Fatalf("expected one notification across replicas, got %d and %d", len(first.triggers), len(second.triggers))
+	}
+	if store.rules[0].TriggerCount != 1 {
+		t.Fatalf("expected the rule fired once, got %+v", store.rules[0])
+	}
+}
+
+func TestPriceAlertEvaluationMovesOverWindow(t *testing.T) {
+	svc, _, prices, notifier := newTestPriceAlertService()
+	ctx := context.Background()
+	clock := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return clock }
+
+	if _, err := svc.CreateRule(ctx, domain.PriceAlertRule{ChatID: 7, Symbol: "SOL", Type: domain.PriceAlertMove, Direction: domain.PriceAlertDown, Threshold: 5, WindowMins: 60}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.CreateRule(ctx, domain.PriceAlertRule{ChatID: 7, Symbol: "SOL", Type: domain.PriceAlertVolume, Threshold: 50, WindowMins: 60}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, p := range []struct{ price, volume float64 }{{200, 1000}, {198, 1200}, {189, 1600}} {
+		prices.set("SOL", p.price, p.volume)
+		if err := svc.OnPricesRefreshed(ctx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		clock = clock.Add(10 * time.Minute)
+	}
+	if len(notifier.triggers) != 2 {
+		t.Fatalf("expected move and volume triggers, got %+v", notifier.triggers)
+	}
+	if notifier.triggers[0].Value < 5 || notifier.triggers[1].Value < 50 {
+		t.Fatalf("unexpected trigger values: %+v", notifier.triggers)
+	}
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sort"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/signal"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const signalLevelLookbackCandles = 120
+
+// LevelingSignalStore fills in stop/target levels for signals whose producer
+// works from features rather than candles (ML inference, sentiment
+// composites) before handing them to the underlying store. Signals that
+// already carry levels pass through untouched.
+type LevelingSignalStore struct {
+	tracer     trace.Tracer
+	candleRepo SignalCandleRepository
+	signalRepo SignalRepository
+}
+
+func NewLevelingSignalStore(tracer trace.Tracer, candleRepo SignalCandleRepository, signalRepo SignalRepository) *LevelingSignalStore {
+	return &LevelingSignalStore{tracer: tracer, candleRepo: candleRepo, signalRepo: signalRepo}
+}
+
+func (s *LevelingSignalStore) InsertSignals(ctx context.Context, signals []domain.Signal) ([]domain.Signal, error) {
+	ctx, span := s.tracer.Start(ctx, "leveling-signal-store.insert-signals")
+	defer span.End()
+
+	out := append([]domain.Signal(nil), signals...)
+	if s.candleRepo != nil {
+		for i := range out {
+			if out[i].Levels != nil || out[i].Direction == domain.DirectionHold {
+				continue
+			}
+			candles, err := s.candleRepo.GetCandles(ctx, out[i].Symbol, out[i].Interval, signalLevelLookbackCandles)
+			if err != nil {
+				log.Printf("signal levels %s %s: %v", out[i].Symbol, out[i].Interval, err)
+				continue
+			}
+			out[i].Levels = signal.ComputeLevels(candlesUpTo(candles, out[i]), out[i].Indicator, out[i].Direction)
+		}
+	}
+	return s.signalRepo.InsertSignals(ctx, out)
+}
+
+func (s *LevelingSignalStore) ListSignals(ctx context.Context, filter domain.SignalFilter) ([]domain.Signal, error) {
+	return s.signalRepo.ListSignals(ctx, filter)
+}
+
+// candlesUpTo returns the candles that had opened by the signal's timestamp,
+// oldest first, so levels never use bars from after the signal fired.
+func candlesUpTo(candles []*domain.Candle, sig domain.Signal) []domain.Candle {
+	out := make([]domain.Candle, 0, len(candles))
+	for _, c := range candles {
+		if c == nil || c.OpenTime.After(sig.Timestamp) {
+			continue
+		}
+		out = append(out, *c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].OpenTime.Before(out[j].OpenTime) })
+	return out
+}
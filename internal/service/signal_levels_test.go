@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestLevelingSignalStoreFillsMissingLevels(t *testing.T) {
+	base := time.Unix(0, 0).UTC()
+	candles := make([]*domain.Candle, 0, 50)
+	for i := 0; i < 50; i++ {
+		c := 100 + float64(i%4)
+		candles = append(candles, &domain.Candle{
+			Symbol: "BTC", Interval: "1h", OpenTime: base.Add(time.Duration(i) * time.Hour),
+			Open: c, High: c + 1, Low: c - 1, Close: c, Volume: 100,
+		})
+	}
+	candleRepo := &stubSignalCandleRepo{candles: map[string][]*domain.Candle{"1h": candles}}
+	signalRepo := &stubSignalRepo{}
+	store := NewLevelingSignalStore(trace.NewNoopTracerProvider().Tracer("test"), candleRepo, signalRepo)
+
+	preset := &domain.SignalLevels{Entry: 1, Stop: 0.9, Targets: []float64{1.2}}
+	firedAt := candles[39].OpenTime
+	_, err := store.InsertSignals(context.Background(), []domain.Signal{
+		{Symbol: "BTC", Interval: "1h", Indicator: domain.IndicatorMLEnsembleUp4H, Direction: domain.DirectionLong, Timestamp: firedAt},
+		{Symbol: "BTC", Interval: "1h", Indicator: domain.IndicatorRSI, Direction: domain.DirectionShort, Timestamp: firedAt, Levels: preset},
+		{Symbol: "BTC", Interval: "1h", Indicator: domain.IndicatorVolumeZ, Direction: domain.DirectionHold, Timestamp: firedAt},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(signalRepo.inserted) != 3 {
+		t.Fatalf("expected 3 inserted signals, got %d", len(signalRepo.inserted))
+	}
+
+	filled := signalRepo.inserted[0].Levels
+	if filled == nil {
+		t.Fatal("expected levels to be filled for ML signal")
+	}
+	if filled.Entry != candles[39].Close {
+		t.Fatalf("expected entry from candle at signal time (%.2f), got %.2f", candles[39].Close, filled.Entry)
+	}
+	if signalRepo.inserted[1].Levels != preset {
+		t.Fatal("expected existing levels to pass through untouched")
+	}
+	if signalRepo.inserted[2].Levels != nil {
+		t.Fatal("expected no levels for hold signal")
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"slices"
 	"strings"
 	"time"
 
 	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/sizing"
 
 	"go.opentelemetry.io/otel/trace"
 )
@@ -28,6 +30,12 @@ type SignalRepository interface {
 	ListSignals(ctx context.Context, filter domain.SignalFilter) ([]domain.Signal, error)
 }
 
+// SignalRiskReporter is implemented by signal stores that can aggregate the
+// persisted risk levels for calibration.
+type SignalRiskReporter interface {
+	RiskDistribution(ctx context.Context, since time.Time, indicator string) ([]domain.RiskDistributionBucket, error)
+}
+
 type SignalEngine interface {
 	Generate(candles []*domain.Candle) []domain.Signal
 }
@@ -65,6 +73,7 @@ type SignalService struct {
 	imageRepo     SignalImageRepository
 	chartRender   SignalChartRenderer
 	maxImageRetry int
+	sizing        *domain.SizingConfig
 }
 
 func NewSignalService(
@@ -95,6 +104,17 @@ func NewSignalServiceWithImages(
 	}
 }
 
+// SetSizing attaches a position size for cfg.ReferenceEquity to every listed
+// signal that carries levels.
+func (s *SignalService) SetSizing(cfg domain.SizingConfig) {
+	if cfg.ReferenceEquity <= 0 {
+		s.sizing = nil
+		return
+	}
+	cfg = sizing.WithDefaults(cfg)
+	s.sizing = &cfg
+}
+
 func (s *SignalService) GenerateForSymbol(ctx context.Context, symbol string, intervals []string) ([]domain.Signal, error) {
 	_, span := s.tracer.Start(ctx, "signal-service.generate-for-symbol")
 	defer span.End()
@@ -150,6 +170,7 @@ func (s *SignalService) ListSignals(ctx context.Context, filter domain.SignalFil
 
 	filter.Symbol = strings.ToUpper(strings.TrimSpace(filter.Symbol))
 	filter.Indicator = strings.ToLower(strings.TrimSpace(filter.Indicator))
+	filter.Interval = strings.ToLower(strings.TrimSpace(filter.Interval))
 
 	if filter.Symbol != "" {
 		if _, ok := domain.CoinGeckoID[filter.Symbol]; !ok {
@@ -159,11 +180,67 @@ func (s *SignalService) ListSignals(ctx context.Context, filter domain.SignalFil
 	if filter.Risk != nil && !filter.Risk.IsValid() {
 		return nil, fmt.Errorf("invalid risk level: %d", *filter.Risk)
 	}
+	if filter.Interval != "" && !slices.Contains(domain.SupportedIntervals, filter.Interval) {
+		return nil, fmt.Errorf("unsupported interval: %s", filter.Interval)
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
 	if filter.Limit <= 0 {
 		filter.Limit = 50
 	}
 
-	return s.signalRepo.ListSignals(ctx, filter)
+	signals, err := s.signalRepo.ListSignals(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	s.attachSizing(signals)
+	return signals, nil
+}
+
+// attachSizing sizes each signal with levels against the reference equity.
+// Signals that cannot be sized are left without one.
+func (s *SignalService) attachSizing(signals []domain.Signal) {
+	if s.sizing == nil {
+		return
+	}
+	for i := range signals {
+		l := signals[i].Levels
+		if l == nil || signals[i].Direction == domain.DirectionHold {
+			continue
+		}
+		size, err := sizing.Size(*s.sizing, domain.SizingInput{
+			Symbol:     signals[i].Symbol,
+			Direction:  signals[i].Direction,
+			Equity:     s.sizing.ReferenceEquity,
+			Entry:      l.Entry,
+			Stop:       l.Stop,
+			Risk:       signals[i].Risk,
+			RewardRisk: l.RewardRisk,
+		})
+		if err != nil {
+			continue
+		}
+		size.SignalID = signals[i].ID
+		signals[i].Sizing = size
+	}
+}
+
+// RiskDistribution reports how many signals landed on each risk level over the
+// last `days` days, optionally restricted to one indicator.
+func (s *SignalService) RiskDistribution(ctx context.Context, days int, indicator string) ([]domain.RiskDistributionBucket, error) {
+	_, span := s.tracer.Start(ctx, "signal-service.risk-distribution")
+	defer span.End()
+
+	reporter, ok := s.signalRepo.(SignalRiskReporter)
+	if !ok {
+		return nil, fmt.Errorf("risk distribution unavailable")
+	}
+	if days <= 0 {
+		days = 30
+	}
+	since := time.Now().UTC().Add(-time.Duration(days) * 24 * time.Hour)
+	return reporter.RiskDistribution(ctx, since, strings.ToLower(strings.TrimSpace(indicator)))
 }
 
 func (s *SignalService) GetSignalImage(ctx context.Context, signalID int64) (*domain.SignalImageData, error) {
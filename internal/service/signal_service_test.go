@@ -74,6 +74,9 @@ func TestSignalServiceListSignalsValidatesFilter(t *testing.T) {
 	if _, err := svc.ListSignals(context.Background(), domain.SignalFilter{Risk: &invalid}); err == nil {
 		t.Fatal("expected invalid risk error")
 	}
+	if _, err := svc.ListSignals(context.Background(), domain.SignalFilter{Interval: "2h"}); err == nil {
+		t.Fatal("expected unsupported interval error")
+	}
 
 	risk := domain.RiskLevel3
 	_, err := svc.ListSignals(context.Background(), domain.SignalFilter{
@@ -95,6 +98,33 @@ func TestSignalServiceListSignalsValidatesFilter(t *testing.T) {
 	}
 }
 
+func TestSignalServiceListSignalsAttachesSizing(t *testing.T) {
+	tracer := trace.NewNoopTracerProvider().Tracer("test")
+	signalRepo := &stubSignalRepo{listResp: []domain.Signal{
+		{ID: 1, Symbol: "BTC", Direction: domain.DirectionLong, Risk: domain.RiskLevel2,
+			Levels: &domain.SignalLevels{Entry: 100, Stop: 95, Targets: []float64{110}, RewardRisk: 2}},
+		{ID: 2, Symbol: "BTC", Direction: domain.DirectionLong, Risk: domain.RiskLevel2},
+	}}
+	svc := NewSignalService(tracer, &stubSignalCandleRepo{}, signalRepo, &stubSignalEngine{})
+
+	got, err := svc.ListSignals(context.Background(), domain.SignalFilter{})
+	if err != nil || got[0].Sizing != nil {
+		t.Fatalf("expected no sizing before it is configured, got %+v %v", got[0].Sizing, err)
+	}
+
+	svc.SetSizing(domain.SizingConfig{ReferenceEquity: 10000})
+	got, err = svc.ListSignals(context.Background(), domain.SignalFilter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got[0].Sizing == nil || got[0].Sizing.SignalID != 1 || got[0].Sizing.RiskAmount != 100 {
+		t.Fatalf("expected sizing on the signal with levels, got %+v", got[0].Sizing)
+	}
+	if got[1].Sizing != nil {
+		t.Fatalf("expected no sizing without levels, got %+v", got[1].Sizing)
+	}
+}
+
 func TestSignalServiceGenerateForSymbolImageFailureIsNonBlocking(t *testing.T) {
 	tracer := trace.NewNoopTracerProvider().Tracer("test")
 	candleRepo := &stubSignalCandleRepo{
@@ -138,6 +168,28 @@ func TestSignalServiceGenerateForSymbolImageFailureIsNonBlocking(t *testing.T) {
 	}
 }
 
+func TestSignalServiceRiskDistribution(t *testing.T) {
+	tracer := trace.NewNoopTracerProvider().Tracer("test")
+	repo := &stubSignalRepo{riskResp: []domain.RiskDistributionBucket{{
+		Indicator: domain.IndicatorRSI, Interval: "1h", Risk: domain.RiskLevel2, Count: 4,
+	}}}
+	svc := NewSignalService(tracer, &stubSignalCandleRepo{}, repo, &stubSignalEngine{})
+
+	buckets, err := svc.RiskDistribution(context.Background(), 7, " RSI ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(buckets) != 1 || buckets[0].Count != 4 {
+		t.Fatalf("unexpected buckets: %+v", buckets)
+	}
+	if repo.lastRiskIndicator != domain.IndicatorRSI {
+		t.Fatalf("expected normalized indicator, got %q", repo.lastRiskIndicator)
+	}
+	if age := time.Since(repo.lastRiskSince); age < 7*24*time.Hour-time.Minute || age > 7*24*time.Hour+time.Minute {
+		t.Fatalf("expected since ~7 days ago, got %s", age)
+	}
+}
+
 type stubSignalCandleRepo struct {
 	candles      map[string][]*domain.Candle
 	lastSymbol   string
@@ -160,6 +212,16 @@ type stubSignalRepo struct {
 	inserted    []domain.Signal
 	lastFilter  domain.SignalFilter
 	listResp    []domain.Signal
+
+	riskResp          []domain.RiskDistributionBucket
+	lastRiskSince     time.Time
+	lastRiskIndicator string
+}
+
+func (s *stubSignalRepo) RiskDistribution(ctx context.Context, since time.Time, indicator string) ([]domain.RiskDistributionBucket, error) {
+	s.lastRiskSince = since
+	s.lastRiskIndicator = indicator
+	return append([]domain.RiskDistributionBucket(nil), s.riskResp...), nil
 }
 
 func (s *stubSignalRepo) InsertSignals(ctx context.Context, signals []domain.Signal) ([]domain.Signal, error) {
@@ -0,0 +1,397 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"bug-free-umbrella/internal/backtest"
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/signal"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultBacktestDays = 90
+	maxBacktestDays     = 730
+
+	// Backtests run inside a request are held to a shorter window and a
+	// cap on candles replayed across symbols; longer searches belong in an
+	// optimisation job.
+	maxSyncBacktestDays    = 365
+	maxSyncBacktestCandles = 30000
+
+	// DefaultBacktestFeeBps and DefaultBacktestSlippageBps are the costs
+	// callers should assume when a request does not set them.
+	DefaultBacktestFeeBps      = 10
+	DefaultBacktestSlippageBps = 5
+)
+
+// ErrInvalidBacktestConfig wraps every validation failure from RunStrategy so
+// callers can tell bad input from infrastructure errors.
+var ErrInvalidBacktestConfig = errors.New("invalid backtest config")
+
+// ErrBacktestRunNotFound is returned when a stored run id does not exist.
+var ErrBacktestRunNotFound = errors.New("backtest run not found")
+
+// ErrMonteCarloNotFound is returned when a run has no stored Monte Carlo
+// analysis yet.
+var ErrMonteCarloNotFound = errors.New("monte carlo analysis not found")
+
+type StrategyCandleRepository interface {
+	GetCandlesInRange(ctx context.Context, symbol, interval string, from, to time.Time) ([]*domain.Candle, error)
+}
+
+// signalParamsSource is implemented by generators with tunable rule
+// parameters, so runs can record the parameters they were produced with.
+type signalParamsSource interface {
+	Params() domain.SignalParams
+}
+
+type BacktestRunStore interface {
+	InsertRun(ctx context.Context, run domain.BacktestRun) (int64, error)
+	ListRuns(ctx context.Context, limit int) ([]domain.BacktestRun, error)
+	GetRun(ctx context.Context, id int64) (*domain.BacktestRun, error)
+	SaveMonteCarlo(ctx context.Context, res domain.MonteCarloResult) error
+	GetMonteCarlo(ctx context.Context, runID int64) (*domain.MonteCarloResult, error)
+}
+
+type BacktestChartRenderer interface {
+	RenderMonteCarloFan(res domain.MonteCarloResult) ([]byte, error)
+}
+
+// StrategyBacktestService replays stored candles through the signal engine to
+// evaluate the classic signals as a trading strategy.
+type StrategyBacktestService struct {
+	tracer     trace.Tracer
+	candleRepo StrategyCandleRepository
+	runStore   BacktestRunStore
+	renderer   BacktestChartRenderer
+	engine     backtest.SignalGenerator
+	runner     *backtest.Runner
+	now        func() time.Time
+}
+
+func NewStrategyBacktestService(
+	tracer trace.Tracer,
+	candleRepo StrategyCandleRepository,
+	runStore BacktestRunStore,
+	renderer BacktestChartRenderer,
+	engine backtest.SignalGenerator,
+) *StrategyBacktestService {
+	return &StrategyBacktestService{
+		tracer:     tracer,
+		candleRepo: candleRepo,
+		runStore:   runStore,
+		renderer:   renderer,
+		engine:     engine,
+		runner:     backtest.NewRunner(engine, backtest.DefaultLookback),
+		now:        time.Now,
+	}
+}
+
+func (s *StrategyBacktestService) RunStrategy(ctx context.Context, cfg domain.BacktestConfig) (*domain.BacktestResult, error) {
+	ctx, span := s.tracer.Start(ctx, "strategy-backtest-service.run-strategy")
+	defer span.End()
+
+	if s.candleRepo == nil {
+		return nil, fmt.Errorf("strategy backtest service unavailable")
+	}
+	cfg, err := normalizeBacktestConfig(cfg, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSyncBacktestSize(cfg, 1); err != nil {
+		return nil, err
+	}
+	runner := s.runner
+	if cfg.Params != nil {
+		// An explicit parameter set runs on its own engine so the live one
+		// is left untouched.
+		runner = backtest.NewRunner(signal.NewEngineWithParams(nil, *cfg.Params), backtest.DefaultLookback)
+	} else if src, ok := s.engine.(signalParamsSource); ok {
+		params := src.Params()
+		cfg.Params = &params
+	}
+
+	warmup := time.Duration(backtest.DefaultLookback) * domain.IntervalDuration(cfg.Interval)
+	candles, err := s.candleRepo.GetCandlesInRange(ctx, cfg.Symbol, cfg.Interval, cfg.From.Add(-warmup), cfg.To)
+	if err != nil {
+		return nil, fmt.Errorf("load candles: %w", err)
+	}
+	if len(candles) == 0 {
+		return nil, fmt.Errorf("%w: no candles stored for %s %s in window", ErrInvalidBacktestConfig, cfg.Symbol, cfg.Interval)
+	}
+	result, err := runner.Run(candles, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.runStore != nil {
+		id, err := s.runStore.InsertRun(ctx, domain.BacktestRun{
+			EngineVersion: result.EngineVersion,
+			Config:        result.Config,
+			Metrics:       result.Metrics,
+			Equity:        result.Equity,
+			Trades:        result.Trades,
+		})
+		if err != nil {
+			log.Printf("persist backtest run %s %s: %v", cfg.Symbol, cfg.Interval, err)
+		} else {
+			result.RunID = id
+		}
+	}
+	return result, nil
+}
+
+func (s *StrategyBacktestService) ListRuns(ctx context.Context, limit int) ([]domain.BacktestRun, error) {
+	ctx, span := s.tracer.Start(ctx, "strategy-backtest-service.list-runs")
+	defer span.End()
+
+	if s.runStore == nil {
+		return nil, fmt.Errorf("backtest run store unavailable")
+	}
+	return s.runStore.ListRuns(ctx, limit)
+}
+
+func (s *StrategyBacktestService) GetRun(ctx context.Context, id int64) (*domain.BacktestRun, error) {
+	ctx, span := s.tracer.Start(ctx, "strategy-backtest-service.get-run")
+	defer span.End()
+
+	if s.runStore == nil {
+		return nil, fmt.Errorf("backtest run store unavailable")
+	}
+	run, err := s.runStore.GetRun(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if run == nil {
+		return nil, fmt.Errorf("%w: %d", ErrBacktestRunNotFound, id)
+	}
+	return run, nil
+}
+
+// CompareRuns diffs two stored runs metric by metric and lists the
+// parameters that changed between them.
+func (s *StrategyBacktestService) CompareRuns(ctx context.Context, a, b int64) (*domain.BacktestRunComparison, error) {
+	ctx, span := s.tracer.Start(ctx, "strategy-backtest-service.compare-runs")
+	defer span.End()
+
+	runA, err := s.GetRun(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+	runB, err := s.GetRun(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	cmp := backtest.CompareRuns(*runA, *runB)
+	return &cmp, nil
+}
+
+// RunMonteCarlo resamples a stored run's trades and stores the resulting
+// distributions with the run.
+func (s *StrategyBacktestService) RunMonteCarlo(ctx context.Context, runID int64, cfg domain.MonteCarloConfig) (*domain.MonteCarloResult, error) {
+	ctx, span := s.tracer.Start(ctx, "strategy-backtest-service.run-monte-carlo")
+	defer span.End()
+
+	run, err := s.GetRun(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Seed == 0 {
+		cfg.Seed = s.now().UnixNano()
+	}
+	res, err := backtest.MonteCarlo(run.Trades, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBacktestConfig, err)
+	}
+	res.RunID = runID
+	res.CreatedAt = s.now().UTC()
+	if err := s.runStore.SaveMonteCarlo(ctx, *res); err != nil {
+		return nil, fmt.Errorf("save monte carlo result: %w", err)
+	}
+	return res, nil
+}
+
+// GetMonteCarlo returns the stored analysis of a run.
+func (s *StrategyBacktestService) GetMonteCarlo(ctx context.Context, runID int64) (*domain.MonteCarloResult, error) {
+	ctx, span := s.tracer.Start(ctx, "strategy-backtest-service.get-monte-carlo")
+	defer span.End()
+
+	if s.runStore == nil {
+		return nil, fmt.Errorf("backtest run store unavailable")
+	}
+	res, err := s.runStore.GetMonteCarlo(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, fmt.Errorf("%w: run %d", ErrMonteCarloNotFound, runID)
+	}
+	return res, nil
+}
+
+// MonteCarloChart renders the stored analysis of a run as a fan chart PNG.
+func (s *StrategyBacktestService) MonteCarloChart(ctx context.Context, runID int64) ([]byte, error) {
+	ctx, span := s.tracer.Start(ctx, "strategy-backtest-service.monte-carlo-chart")
+	defer span.End()
+
+	if s.renderer == nil {
+		return nil, fmt.Errorf("chart renderer unavailable")
+	}
+	res, err := s.GetMonteCarlo(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+	return s.renderer.RenderMonteCarloFan(*res)
+}
+
+// RunPortfolio simulates following signals across several assets from one
+// shared account. Results are returned, not stored: portfolio runs do not fit
+// the single-symbol run history.
+func (s *StrategyBacktestService) RunPortfolio(ctx context.Context, cfg domain.PortfolioConfig) (*domain.PortfolioResult, error) {
+	ctx, span := s.tracer.Start(ctx, "strategy-backtest-service.run-portfolio")
+	defer span.End()
+
+	if s.candleRepo == nil {
+		return nil, fmt.Errorf("strategy backtest service unavailable")
+	}
+	cfg, err := normalizePortfolioConfig(cfg, s.now())
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSyncBacktestSize(cfg.Base, len(cfg.Symbols)); err != nil {
+		return nil, err
+	}
+	var generator backtest.SignalGenerator = s.engine
+	if cfg.Base.Params != nil {
+		generator = signal.NewEngineWithParams(nil, *cfg.Base.Params)
+	} else if src, ok := s.engine.(signalParamsSource); ok {
+		params := src.Params()
+		cfg.Base.Params = &params
+	}
+
+	warmup := time.Duration(backtest.DefaultLookback) * domain.IntervalDuration(cfg.Base.Interval)
+	candles := make(map[string][]*domain.Candle, len(cfg.Symbols))
+	for _, symbol := range cfg.Symbols {
+		series, err := s.candleRepo.GetCandlesInRange(ctx, symbol, cfg.Base.Interval, cfg.Base.From.Add(-warmup), cfg.Base.To)
+		if err != nil {
+			return nil, fmt.Errorf("load candles for %s: %w", symbol, err)
+		}
+		if len(series) > 0 {
+			candles[symbol] = series
+		}
+	}
+	if len(candles) == 0 {
+		return nil, fmt.Errorf("%w: no candles stored for any symbol at %s in window", ErrInvalidBacktestConfig, cfg.Base.Interval)
+	}
+	return backtest.NewPortfolioRunner(generator, backtest.DefaultLookback).Run(candles, cfg)
+}
+
+// checkSyncBacktestSize rejects a normalized config too large to replay
+// within a request over the given number of symbols.
+func checkSyncBacktestSize(cfg domain.BacktestConfig, symbols int) error {
+	window := cfg.To.Sub(cfg.From)
+	if window > maxSyncBacktestDays*24*time.Hour {
+		return fmt.Errorf("%w: window must be at most %d days", ErrInvalidBacktestConfig, maxSyncBacktestDays)
+	}
+	if candles := int(window/domain.IntervalDuration(cfg.Interval)) * symbols; candles > maxSyncBacktestCandles {
+		return fmt.Errorf("%w: window covers %d candles, at most %d per run; use a shorter window, a larger interval or fewer symbols",
+			ErrInvalidBacktestConfig, candles, maxSyncBacktestCandles)
+	}
+	return nil
+}
+
+// normalizePortfolioConfig validates cfg, defaulting Symbols to every
+// supported symbol and checking Base as a single-symbol run would be.
+func normalizePortfolioConfig(cfg domain.PortfolioConfig, now time.Time) (domain.PortfolioConfig, error) {
+	symbols := cfg.Symbols
+	if len(symbols) == 0 {
+		symbols = domain.SupportedSymbols
+	}
+	cfg.Symbols = make([]string, 0, len(symbols))
+	seen := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		base := cfg.Base
+		base.Symbol = symbol
+		base, err := normalizeBacktestConfig(base, now)
+		if err != nil {
+			return cfg, err
+		}
+		if !seen[base.Symbol] {
+			seen[base.Symbol] = true
+			cfg.Symbols = append(cfg.Symbols, base.Symbol)
+		}
+		cfg.Base = base
+	}
+	cfg.Base.Symbol = ""
+
+	if cfg.RiskPerTrade < 0 || cfg.RiskPerTrade > 0.1 {
+		return cfg, fmt.Errorf("%w: risk_per_trade must be between 0 and 0.1", ErrInvalidBacktestConfig)
+	}
+	if cfg.TargetVolatility < 0 || cfg.TargetVolatility > 5 {
+		return cfg, fmt.Errorf("%w: target_volatility must be between 0 and 5", ErrInvalidBacktestConfig)
+	}
+	if cfg.MaxAssetExposure < 0 || cfg.MaxAssetExposure > 1 {
+		return cfg, fmt.Errorf("%w: max_asset_exposure must be between 0 and 1", ErrInvalidBacktestConfig)
+	}
+	if cfg.MaxGrossExposure < 0 || cfg.MaxGrossExposure > 3 {
+		return cfg, fmt.Errorf("%w: max_gross_exposure must be between 0 and 3", ErrInvalidBacktestConfig)
+	}
+	return cfg, nil
+}
+
+// normalizeBacktestConfig validates cfg and fills defaults, with now as the
+// end of the window when none is given.
+func normalizeBacktestConfig(cfg domain.BacktestConfig, now time.Time) (domain.BacktestConfig, error) {
+	cfg.Symbol = strings.ToUpper(strings.TrimSpace(cfg.Symbol))
+	if _, ok := domain.CoinGeckoID[cfg.Symbol]; !ok {
+		return cfg, fmt.Errorf("%w: unsupported symbol %q", ErrInvalidBacktestConfig, cfg.Symbol)
+	}
+	cfg.Interval = strings.TrimSpace(cfg.Interval)
+	if cfg.Interval == "" {
+		cfg.Interval = "1h"
+	}
+	if domain.IntervalDuration(cfg.Interval) == 0 {
+		return cfg, fmt.Errorf("%w: unsupported interval %q", ErrInvalidBacktestConfig, cfg.Interval)
+	}
+
+	if cfg.To.IsZero() {
+		cfg.To = now.UTC()
+	}
+	if cfg.From.IsZero() {
+		cfg.From = cfg.To.AddDate(0, 0, -defaultBacktestDays)
+	}
+	cfg.From, cfg.To = cfg.From.UTC(), cfg.To.UTC()
+	if !cfg.From.Before(cfg.To) {
+		return cfg, fmt.Errorf("%w: from must be before to", ErrInvalidBacktestConfig)
+	}
+	if cfg.To.Sub(cfg.From) > maxBacktestDays*24*time.Hour {
+		return cfg, fmt.Errorf("%w: window must be at most %d days", ErrInvalidBacktestConfig, maxBacktestDays)
+	}
+
+	indicators := make([]string, 0, len(cfg.Indicators))
+	for _, indicator := range cfg.Indicators {
+		if indicator = strings.ToLower(strings.TrimSpace(indicator)); indicator != "" {
+			indicators = append(indicators, indicator)
+		}
+	}
+	cfg.Indicators = indicators
+	if cfg.MaxRisk < 0 || cfg.MaxRisk > domain.RiskLevel5 {
+		return cfg, fmt.Errorf("%w: max_risk must be between 1 and 5", ErrInvalidBacktestConfig)
+	}
+	if cfg.FeeBps < 0 || cfg.SlippageBps < 0 || cfg.HoldBars < 0 || cfg.InitialEquity < 0 {
+		return cfg, fmt.Errorf("%w: fees, slippage, hold_bars and initial_equity must not be negative", ErrInvalidBacktestConfig)
+	}
+	if cfg.Params != nil {
+		if err := cfg.Params.Validate(); err != nil {
+			return cfg, fmt.Errorf("%w: %v", ErrInvalidBacktestConfig, err)
+		}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/signal"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type stubStrategyCandleRepo struct {
+	candles    []*domain.Candle
+	lastSymbol string
+	lastFrom   time.Time
+	lastTo     time.Time
+}
+
+func (s *stubStrategyCandleRepo) GetCandlesInRange(ctx context.Context, symbol, interval string, from, to time.Time) ([]*domain.Candle, error) {
+	s.lastSymbol = symbol
+	s.lastFrom = from
+	s.lastTo = to
+	return s.candles, nil
+}
+
+type stubBacktestGenerator struct{}
+
+func (stubBacktestGenerator) Generate(candles []*domain.Candle) []domain.Signal { return nil }
+
+func TestStrategyBacktestServiceRunStrategy(t *testing.T) {
+	to := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	from := to.Add(-48 * time.Hour)
+	candles := make([]*domain.Candle, 0, 48)
+	for i := 0; i < 48; i++ {
+		candles = append(candles, &domain.Candle{
+			Symbol: "BTC", Interval: "1h", OpenTime: from.Add(time.Duration(i) * time.Hour),
+			Open: 100, High: 101, Low: 99, Close: 100,
+		})
+	}
+	repo := &stubStrategyCandleRepo{candles: candles}
+	svc := NewStrategyBacktestService(trace.NewNoopTracerProvider().Tracer("test"), repo, nil, nil, stubBacktestGenerator{})
+
+	res, err := svc.RunStrategy(context.Background(), domain.BacktestConfig{
+		Symbol: " btc ", Interval: "1h", From: from, To: to, Indicators: []string{" RSI "},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.lastSymbol != "BTC" {
+		t.Fatalf("expected normalized symbol, got %q", repo.lastSymbol)
+	}
+	if !repo.lastFrom.Before(from) || !repo.lastTo.Equal(to) {
+		t.Fatalf("expected warm-up before from, got %s..%s", repo.lastFrom, repo.lastTo)
+	}
+	if res.Config.Indicators[0] != domain.IndicatorRSI {
+		t.Fatalf("expected normalized indicators, got %+v", res.Config.Indicators)
+	}
+	if len(res.Equity) != len(candles) {
+		t.Fatalf("expected %d equity points, got %d", len(candles), len(res.Equity))
+	}
+}
+
+type paramsBacktestGenerator struct {
+	stubBacktestGenerator
+	params domain.SignalParams
+}
+
+func (g paramsBacktestGenerator) Params() domain.SignalParams { return g.params }
+
+func TestStrategyBacktestServiceRecordsSignalParams(t *testing.T) {
+	to := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	from := to.Add(-24 * time.Hour)
+	candles := make([]*domain.Candle, 0, 24)
+	for i := 0; i < 24; i++ {
+		candles = append(candles, &domain.Candle{
+			Symbol: "BTC", Interval: "1h", OpenTime: from.Add(time.Duration(i) * time.Hour),
+			Open: 100, High: 101, Low: 99, Close: 100,
+		})
+	}
+	live := signal.DefaultParams()
+	live.RSIPeriod = 9
+	svc := NewStrategyBacktestService(trace.NewNoopTracerProvider().Tracer("test"), &stubStrategyCandleRepo{candles: candles}, nil, nil, paramsBacktestGenerator{params: live})
+
+	res, err := svc.RunStrategy(context.Background(), domain.BacktestConfig{Symbol: "BTC", From: from, To: to})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Config.Params == nil || res.Config.Params.RSIPeriod != 9 {
+		t.Fatalf("expected live params recorded, got %+v", res.Config.Params)
+	}
+
+	explicit := signal.DefaultParams()
+	explicit.RSIPeriod = 21
+	res, err = svc.RunStrategy(context.Background(), domain.BacktestConfig{Symbol: "BTC", From: from, To: to, Params: &explicit})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Config.Params.RSIPeriod != 21 {
+		t.Fatalf("expected explicit params kept, got %+v", res.Config.Params)
+	}
+}
+
+func TestStrategyBacktestServiceValidation(t *testing.T) {
+	svc := NewStrategyBacktestService(trace.NewNoopTracerProvider().Tracer("test"), &stubStrategyCandleRepo{}, nil, nil, stubBacktestGenerator{})
+	now := time.Now().UTC()
+	cases := []domain.BacktestConfig{
+		{Symbol: "FAKE"},
+		{Symbol: "BTC", Interval: "2h"},
+		{Symbol: "BTC", From: now, To: now.Add(-time.Hour)},
+		{Symbol: "BTC", From: now.AddDate(-3, 0, 0), To: now},
+		{Symbol: "BTC", FeeBps: -1},
+		{Symbol: "BTC", Params: &domain.SignalParams{}},
+		{Symbol: "BTC"}, // no candles stored
+	}
+	for i, cfg := range cases {
+		if _, err := svc.RunStrategy(context.Background(), cfg); !errors.Is(err, ErrInvalidBacktestConfig) {
+			t.Fatalf("case %d: expected ErrInvalidBacktestConfig, got %v", i, err)
+		}
+	}
+}
+
+func TestStrategyBacktestServiceCapsSynchronousRuns(t *testing.T) {
+	now := time.Now().UTC()
+	repo := &stubStrategyCandleRepo{candles: []*domain.Candle{{Symbol: "BTC", Interval: "1h", OpenTime: now}}}
+	svc := NewStrategyBacktestService(trace.NewNoopTracerProvider().Tracer("test"), repo, nil, nil, stubBacktestGenerator{})
+
+	cases := []domain.BacktestConfig{
+		{Symbol: "BTC", From: now.AddDate(0, 0, -400), To: now},
+		{Symbol: "BTC", Interval: "5m", From: now.AddDate(0, 0, -120), To: now},
+	}
+	for i, cfg := range cases {
+		if _, err := svc.RunStrategy(context.Background(), cfg); !errors.Is(err, ErrInvalidBacktestConfig) || !strings.Contains(err.Error(), "at most") {
+			t.Fatalf("case %d: expected the synchronous cap, got %v", i, err)
+		}
+	}
+	if repo.lastSymbol != "" {
+		t.Fatalf("expected no candles loaded for oversized runs, got a load for %s", repo.lastSymbol)
+	}
+
+	_, err := svc.RunPortfolio(context.Background(), domain.PortfolioConfig{
+		Base: domain.BacktestConfig{Interval: "1h", From: now.AddDate(0, 0, -200), To: now},
+	})
+	if !errors.Is(err, ErrInvalidBacktestConfig) || !strings.Contains(err.Error(), "candles") {
+		t.Fatalf("expected the candle cap across all symbols, got %v", err)
+	}
+}
+
+type stubBacktestRunStore struct {
+	inserted   []domain.BacktestRun
+	runs       map[int64]*domain.BacktestRun
+	monteCarlo map[int64]*domain.MonteCarloResult
+	err        error
+}
+
+func (s *stubBacktestRunStore) InsertRun(ctx context.Context, run domain.BacktestRun) (int64, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	s.inserted = append(s.inserted, run)
+	return int64(len(s.inserted)), nil
+}
+
+func (s *stubBacktestRunStore) ListRuns(ctx context.Context, limit int) ([]domain.BacktestRun, error) {
+	out := make([]domain.BacktestRun, 0, len(s.runs))
+	for _, r := range s.runs {
+		out = append(out, *r)
+	}
+	return out, nil
+}
+
+func (s *stubBacktestRunStore) GetRun(ctx context.Context, id int64) (*domain.BacktestRun, error) {
+	return s.runs[id], nil
+}
+
+func (s *stubBacktestRunStore) SaveMonteCarlo(ctx context.Context, res domain.MonteCarloResult) error {
+	if s.monteCarlo == nil {
+		s.monteCarlo = make(map[int64]*domain.MonteCarloResult)
+	}
+	s.monteCarlo[res.RunID] = &res
+	return nil
+}
+
+func (s *stubBacktestRunStore) GetMonteCarlo(ctx context.Context, runID int64) (*domain.MonteCarloResult, error) {
+	return s.monteCarlo[runID], nil
+}
+
+type stubBacktestChartRenderer struct{ rendered int }
+
+func (r *stubBacktestChartRenderer) RenderMonteCarloFan(res domain.MonteCarloResult) ([]byte, error) {
+	r.rendered++
+	return []byte("png"), nil
+}
+
+func TestStrategyBacktestServicePersistsRun(t *testing.T) {
+	to := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	from := to.Add(-24 * time.Hour)
+	candles := make([]*domain.Candle, 0, 24)
+	for i := 0; i < 24; i++ {
+		candles = append(candles, &domain.Candle{
+			Symbol: "ETH", Interval: "1h", OpenTime: from.Add(time.Duration(i) * time.Hour),
+			Open: 100, High: 101, Low: 99, Close: 100,
+		})
+	}
+	store := &stubBacktestRunStore{}
+	svc := NewStrategyBacktestService(trace.NewNoopTracerProvider().Tracer("test"), &stubStrategyCandleRepo{candles: candles}, store, nil, stubBacktestGenerator{})
+
+	res, err := svc.RunStrategy(context.Background(), domain.BacktestConfig{Symbol: "ETH", From: from, To: to})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.RunID != 1 || len(store.inserted) != 1 {
+		t.Fatalf("expected run persisted with id 1, got id %d (%d inserts)", res.RunID, len(store.inserted))
+	}
+	run := store.inserted[0]
+	if run.EngineVersion == "" || run.Config.Symbol != "ETH" || len(run.Equity) != len(candles) {
+		t.Fatalf("unexpected persisted run: %+v", run)
+	}
+
+	store.err = errors.New("db down")
+	res, err = svc.RunStrategy(context.Background(), domain.BacktestConfig{Symbol: "ETH", From: from, To: to})
+	if err != nil {
+		t.Fatalf("persistence failure should not fail the run: %v", err)
+	}
+	if res.RunID != 0 {
+		t.Fatalf("expected no run id when persistence fails, got %d", res.RunID)
+	}
+}
+
+func TestStrategyBacktestServiceCompareRuns(t *testing.T) {
+	store := &stubBacktestRunStore{runs: map[int64]*domain.BacktestRun{
+		1: {ID: 1, Config: domain.BacktestConfig{Symbol: "BTC", HoldBars: 24}, Metrics: domain.BacktestMetrics{TotalReturn: 0.1}},
+		2: {ID: 2, Config: domain.BacktestConfig{Symbol: "BTC", HoldBars: 12}, Metrics: domain.BacktestMetrics{TotalReturn: 0.15}},
+	}}
+	svc := NewStrategyBacktestService(trace.NewNoopTracerProvider().Tracer("test"), &stubStrategyCandleRepo{}, store, nil, stubBacktestGenerator{})
+
+	cmp, err := svc.CompareRuns(context.Background(), 1, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cmp.A.ID != 1 || cmp.B.ID != 2 || len(cmp.Params) != 1 {
+		t.Fatalf("unexpected comparison: %+v", cmp)
+	}
+
+	if _, err := svc.CompareRuns(context.Background(), 1, 3); !errors.Is(err, ErrBacktestRunNotFound) {
+		t.Fatalf("expected ErrBacktestRunNotFound, got %v", err)
+	}
+	if _, err := svc.GetRun(context.Background(), 9); !errors.Is(err, ErrBacktestRunNotFound) {
+		t.Fatalf("expected ErrBacktestRunNotFound, got %v", err)
+	}
+
+	noStore := NewStrategyBacktestService(trace.NewNoopTracerProvider().Tracer("test"), &stubStrategyCandleRepo{}, nil, nil, stubBacktestGenerator{})
+	if _, err := noStore.ListRuns(context.Background(), 10); err == nil {
+		t.Fatal("expected error without run store")
+	}
+}
+
+func TestStrategyBacktestServiceMonteCarlo(t *testing.T) {
+	trades := []domain.BacktestTrade{{ReturnPct: 0.02}, {ReturnPct: -0.01}, {ReturnPct: 0.03}, {ReturnPct: -0.02}}
+	store := &stubBacktestRunStore{runs: map[int64]*domain.BacktestRun{
+		1: {ID: 1, Trades: trades},
+		2: {ID: 2, Trades: trades[:1]},
+	}}
+	renderer := &stubBacktestChartRenderer{}
+	svc := NewStrategyBacktestService(trace.NewNoopTracerProvider().Tracer("test"), &stubStrategyCandleRepo{}, store, renderer, stubBacktestGenerator{})
+
+	if _, err := svc.GetMonteCarlo(context.Background(), 1); !errors.Is(err, ErrMonteCarloNotFound) {
+		t.Fatalf("expected ErrMonteCarloNotFound before a run, got %v", err)
+	}
+	res, err := svc.RunMonteCarlo(context.Background(), 1, domain.MonteCarloConfig{Simulations: 100, Seed: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.RunID != 1 || res.Trades != 4 || res.CreatedAt.IsZero() {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if stored, err := svc.GetMonteCarlo(context.Background(), 1); err != nil || stored.Config.Simulations != 100 {
+		t.Fatalf("expected stored result, got %+v (%v)", stored, err)
+	}
+	if png, err := svc.MonteCarloChart(context.Background(), 1); err != nil || string(png) != "png" || renderer.rendered != 1 {
+		t.Fatalf("expected rendered chart, got %q (%v)", png, err)
+	}
+
+	if _, err := svc.RunMonteCarlo(context.Background(), 2, domain.MonteCarloConfig{}); !errors.Is(err, ErrInvalidBacktestConfig) {
+		t.Fatalf("expected ErrInvalidBacktestConfig for a single trade, got %v", err)
+	}
+	if _, err := svc.RunMonteCarlo(context.Background(), 9, domain.MonteCarloConfig{}); !errors.Is(err, ErrBacktestRunNotFound) {
+		t.Fatalf("expected ErrBacktestRunNotFound, got %v", err)
+	}
+}
+
+type symbolStrategyCandleRepo struct {
+	candles map[string][]*domain.Candle
+	symbols []string
+}
+
+func (s *symbolStrategyCandleRepo) GetCandlesInRange(ctx context.Context, symbol, interval string, from, to time.Time) ([]*domain.Candle, error) {
+	s.symbols = append(s.symbols, symbol)
+	return s.candles[symbol], nil
+}
+
+func TestStrategyBacktestServiceRunPortfolio(t *testing.T) {
+	to := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	from := to.Add(-24 * time.Hour)
+	candles := make([]*domain.Candle, 0, 24)
+	for i := 0; i < 24; i++ {
+		candles = append(candles, &domain.Candle{
+			Symbol: "BTC", Interval: "1h", OpenTime: from.Add(time.Duration(i) * time.Hour),
+			Open: 100, High: 101, Low: 99, Close: 100,
+		})
+	}
+	repo := &symbolStrategyCandleRepo{candles: map[string][]*domain.Candle{"BTC": candles, "ETH": candles}}
+	params := signal.DefaultParams()
+	svc := NewStrategyBacktestService(trace.NewNoopTracerProvider().Tracer("test"), repo, nil, nil, paramsBacktestGenerator{params: params})
+
+	res, err := svc.RunPortfolio(context.Background(), domain.PortfolioConfig{
+		Base:    domain.BacktestConfig{Interval: "1h", From: from, To: to},
+		Symbols: []string{" btc ", "ETH", "BTC", "SOL"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.symbols) != 3 || repo.symbols[0] != "BTC" || repo.symbols[1] != "ETH" || repo.symbols[2] != "SOL" {
+		t.Fatalf("expected each normalized symbol loaded once, got %+v", repo.symbols)
+	}
+	if len(res.Assets) != 2 {
+		t.Fatalf("expected symbols without candles to be dropped, got %+v", res.Assets)
+	}
+	if res.Config.Base.Params == nil || *res.Config.Base.Params != params {
+		t.Fatalf("expected live params recorded, got %+v", res.Config.Base.Params)
+	}
+	if res.Config.RiskPerTrade != 0.01 || len(res.Equity) != len(candles) {
+		t.Fatalf("unexpected result: risk %.4f, %d equity points", res.Config.RiskPerTrade, len(res.Equity))
+	}
+
+	repo.symbols = nil
+	if _, err := svc.RunPortfolio(context.Background(), domain.PortfolioConfig{
+		Base: domain.BacktestConfig{Interval: "1h", From: from, To: to},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repo.symbols) != len(domain.SupportedSymbols) {
+		t.Fatalf("expected all supported symbols by default, got %+v", repo.symbols)
+	}
+}
+
+func TestStrategyBacktestServiceRunPortfolioValidation(t *testing.T) {
+	svc := NewStrategyBacktestService(trace.NewNoopTracerProvider().Tracer("test"), &symbolStrategyCandleRepo{}, nil, nil, stubBacktestGenerator{})
+	cases := []domain.PortfolioConfig{
+		{Symbols: []string{"DOGE"}},
+		{Symbols: []string{"BTC"}, RiskPerTrade: 0.5},
+		{Symbols: []string{"BTC"}, MaxAssetExposure: 2},
+		{Symbols: []string{"BTC"}, MaxGrossExposure: -1},
+		{Symbols: []string{"BTC"}, TargetVolatility: -0.1},
+		{Symbols: []string{"BTC"}},
+	}
+	for i, cfg := range cases {
+		if _, err := svc.RunPortfolio(context.Background(), cfg); !errors.Is(err, ErrInvalidBacktestConfig) {
+			t.Fatalf("case %d: expected invalid config error, got %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,477 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"bug-free-umbrella/internal/backtest"
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/signal"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxOptimizationJobs bounds how many jobs are kept in memory; the oldest
+// finished jobs are dropped first.
+const maxOptimizationJobs = 20
+
+// maxPendingOptimizations bounds how many jobs may be queued or running at
+// once; further submissions are refused.
+const maxPendingOptimizations = 4
+
+// optimizationSaveEvery throttles how often a running job's progress is
+// written to the job store; status changes are always written.
+const optimizationSaveEvery = 2 * time.Second
+
+// optimizationSaveTimeout bounds a job store write. Writes do not use the
+// job's context, so a job cancelled at shutdown is still recorded as failed.
+const optimizationSaveTimeout = 5 * time.Second
+
+// ErrOptimizationNotFound is returned for unknown optimisation job ids.
+var ErrOptimizationNotFound = errors.New("optimization job not found")
+
+// ErrOptimizationNotReady is returned when promoting a job that has not
+// finished successfully with a recommended parameter set.
+var ErrOptimizationNotReady = errors.New("optimization job has no result to promote")
+
+// ErrOptimizationQueueFull is returned when maxPendingOptimizations jobs are
+// already queued or running.
+var ErrOptimizationQueueFull = errors.New("too many optimization jobs pending")
+
+type SignalParamsStore interface {
+	SaveActive(ctx context.Context, set domain.SignalParamSet) (int64, error)
+	GetActive(ctx context.Context) (*domain.SignalParamSet, error)
+}
+
+// OptimizationJobStore persists jobs so every replica can list, read and
+// promote them, whichever replica ran them.
+type OptimizationJobStore interface {
+	CreateJob(ctx context.Context, job domain.OptimizationJob) (int64, error)
+	SaveJob(ctx context.Context, job domain.OptimizationJob) error
+	GetJob(ctx context.Context, id int64) (*domain.OptimizationJob, error)
+	ListJobs(ctx context.Context, limit int) ([]domain.OptimizationJob, error)
+}
+
+// LiveSignalEngine is the engine behind live signal generation whose
+// parameters can be swapped at runtime.
+type LiveSignalEngine interface {
+	Params() domain.SignalParams
+	SetParams(params domain.SignalParams) error
+}
+
+// StrategyOptimizerService runs walk-forward parameter optimisations as
+// background jobs and promotes winning parameter sets into the live engine.
+// Only one optimisation runs at a time per process; others wait queued. Jobs
+// stop when the context given to SetContext is cancelled, and Wait blocks
+// until they have. With a job store, jobs are read from it, so any replica
+// can report on or promote them; without one they live in this process.
+type StrategyOptimizerService struct {
+	tracer      trace.Tracer
+	candleRepo  StrategyCandleRepository
+	paramsStore SignalParamsStore
+	live        LiveSignalEngine
+	jobStore    OptimizationJobStore
+	now         func() time.Time
+
+	slot    chan struct{}
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	ctx     context.Context
+	pending int
+	jobs    map[int64]*domain.OptimizationJob
+	nextID  int64
+	savedAt time.Time
+}
+
+func NewStrategyOptimizerService(tracer trace.Tracer, candleRepo StrategyCandleRepository, paramsStore SignalParamsStore, live LiveSignalEngine) *StrategyOptimizerService {
+	return &StrategyOptimizerService{
+		tracer:      tracer,
+		candleRepo:  candleRepo,
+		paramsStore: paramsStore,
+		live:        live,
+		now:         time.Now,
+		slot:        make(chan struct{}, 1),
+		ctx:         context.Background(),
+		jobs:        make(map[int64]*domain.OptimizationJob),
+	}
+}
+
+// SetContext sets the context jobs run under. Once it is cancelled, running
+// and queued jobs fail and new submissions are refused.
+func (s *StrategyOptimizerService) SetContext(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ctx = ctx
+}
+
+// SetJobStore persists jobs in store and serves reads from it.
+func (s *StrategyOptimizerService) SetJobStore(store OptimizationJobStore) {
+	s.jobStore = store
+}
+
+// Wait blocks until every submitted job has finished or been cancelled.
+func (s *StrategyOptimizerService) Wait() {
+	s.wg.Wait()
+}
+
+// StartOptimization validates req, queues it and returns the queued job.
+func (s *StrategyOptimizerService) StartOptimization(ctx context.Context, req domain.OptimizeRequest) (*domain.OptimizationJob, error) {
+	_, span := s.tracer.Start(ctx, "strategy-optimizer-service.start-optimization")
+	defer span.End()
+
+	if s.candleRepo == nil {
+		return nil, fmt.Errorf("strategy optimizer unavailable")
+	}
+	req, total, err := s.normalizeRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if err := s.ctx.Err(); err != nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("strategy optimizer stopped: %w", err)
+	}
+	if s.pending >= maxPendingOptimizations {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("%w: %d queued or running", ErrOptimizationQueueFull, s.pending)
+	}
+	s.pending++
+	s.wg.Add(1)
+	runCtx := s.ctx
+	s.mu.Unlock()
+
+	job := &domain.OptimizationJob{
+		Status:    domain.JobStatusQueued,
+		Request:   req,
+		Total:     total,
+		CreatedAt: s.now().UTC(),
+	}
+	if s.jobStore != nil {
+		if job.ID, err = s.jobStore.CreateJob(ctx, *job); err != nil {
+			s.mu.Lock()
+			s.pending--
+			s.mu.Unlock()
+			s.wg.Done()
+			return nil, fmt.Errorf("store optimization job: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	if s.jobStore == nil {
+		s.nextID++
+		job.ID = s.nextID
+	}
+	s.jobs[job.ID] = job
+	s.evictLocked()
+	snapshot := *job
+	s.mu.Unlock()
+
+	go s.run(runCtx, job.ID, req)
+	return &snapshot, nil
+}
+
+func (s *StrategyOptimizerService) GetOptimization(ctx context.Context, id int64) (*domain.OptimizationJob, error) {
+	_, span := s.tracer.Start(ctx, "strategy-optimizer-service.get-optimization")
+	defer span.End()
+
+	if s.jobStore != nil {
+		job, err := s.jobStore.GetJob(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if job == nil {
+			return nil, fmt.Errorf("%w: %d", ErrOptimizationNotFound, id)
+		}
+		return job, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrOptimizationNotFound, id)
+	}
+	snapshot := *job
+	return &snapshot, nil
+}
+
+// ListOptimizations returns the retained jobs newest first, without results.
+func (s *StrategyOptimizerService) ListOptimizations(ctx context.Context) []domain.OptimizationJob {
+	ctx, span := s.tracer.Start(ctx, "strategy-optimizer-service.list-optimizations")
+	defer span.End()
+
+	if s.jobStore != nil {
+		jobs, err := s.jobStore.ListJobs(ctx, maxOptimizationJobs)
+		if err == nil {
+			return jobs
+		}
+		span.RecordError(err)
+		log.Printf("failed to list stored optimization jobs, showing this replica's: %v", err)
+	}
+
+	s.mu.Lock()
+	out := make([]domain.OptimizationJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		snapshot := *job
+		snapshot.Result = nil
+		out = append(out, snapshot)
+	}
+	s.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].ID > out[j].ID })
+	return out
+}
+
+// PromoteOptimization makes the recommended parameter set of a finished job
+// the live engine configuration and persists it so it survives restarts.
+func (s *StrategyOptimizerService) PromoteOptimization(ctx context.Context, id int64) (*domain.SignalParamSet, error) {
+	ctx, span := s.tracer.Start(ctx, "strategy-optimizer-service.promote-optimization")
+	defer span.End()
+
+	if s.live == nil {
+		return nil, fmt.Errorf("live signal engine unavailable")
+	}
+	job, err := s.GetOptimization(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != domain.JobStatusSucceeded || job.Result == nil || job.Result.Recommended == nil {
+		return nil, fmt.Errorf("%w: job %d is %s", ErrOptimizationNotReady, id, job.Status)
+	}
+
+	set := domain.SignalParamSet{
+		Params:     job.Result.Recommended.Params,
+		Source:     fmt.Sprintf("optimization job %d", id),
+		PromotedAt: s.now().UTC(),
+	}
+	if s.paramsStore != nil {
+		setID, err := s.paramsStore.SaveActive(ctx, set)
+		if err != nil {
+			return nil, fmt.Errorf("save signal params: %w", err)
+		}
+		set.ID = setID
+	}
+	if err := s.live.SetParams(set.Params); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if stored, ok := s.jobs[id]; ok {
+		stored.PromotedSet = set.ID
+	}
+	s.mu.Unlock()
+	if s.jobStore != nil {
+		job.PromotedSet = set.ID
+		if err := s.jobStore.SaveJob(ctx, *job); err != nil {
+			log.Printf("failed to record promotion of optimization job %d: %v", id, err)
+		}
+	}
+	return &set, nil
+}
+
+// ActiveSignalParams reports the parameters the live engine is using. When
+// nothing has been promoted the source is "default".
+func (s *StrategyOptimizerService) ActiveSignalParams(ctx context.Context) (*domain.SignalParamSet, error) {
+	ctx, span := s.tracer.Start(ctx, "strategy-optimizer-service.active-signal-params")
+	defer span.End()
+
+	if s.paramsStore != nil {
+		set, err := s.paramsStore.GetActive(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if set != nil {
+			return set, nil
+		}
+	}
+	params := signal.DefaultParams()
+	if s.live != nil {
+		params = s.live.Params()
+	}
+	return &domain.SignalParamSet{Params: params, Source: "default"}, nil
+}
+
+// RestoreLiveParams loads the last promoted parameter set into the live
+// engine; it is a no-op when nothing has been promoted or the engine already
+// runs that set. It runs at startup and then periodically, so promotions
+// made on another replica are picked up.
+func (s *StrategyOptimizerService) RestoreLiveParams(ctx context.Context) error {
+	ctx, span := s.tracer.Start(ctx, "strategy-optimizer-service.restore-live-params")
+	defer span.End()
+
+	if s.paramsStore == nil || s.live == nil {
+		return nil
+	}
+	set, err := s.paramsStore.GetActive(ctx)
+	if err != nil || set == nil {
+		return err
+	}
+	if s.live.Params() == set.Params {
+		return nil
+	}
+	if err := s.live.SetParams(set.Params); err != nil {
+		return fmt.Errorf("restore signal params %d: %w", set.ID, err)
+	}
+	log.Printf("live signal engine now uses promoted param set %d", set.ID)
+	return nil
+}
+
+func (s *StrategyOptimizerService) normalizeRequest(req domain.OptimizeRequest) (domain.OptimizeRequest, int, error) {
+	base, err := normalizeBacktestConfig(req.Base, s.now())
+	if err != nil {
+		return req, 0, err
+	}
+	if base.Params == nil {
+		params := signal.DefaultParams()
+		if s.live != nil {
+			params = s.live.Params()
+		}
+		base.Params = &params
+	}
+	req.Base = base
+
+	if req.Folds == 0 {
+		req.Folds = backtest.DefaultFolds
+	}
+	switch req.Mode {
+	case "":
+		req.Mode = domain.OptimizeModeGrid
+	case domain.OptimizeModeGrid, domain.OptimizeModeRandom:
+	default:
+		return req, 0, fmt.Errorf("%w: mode must be grid or random", ErrInvalidBacktestConfig)
+	}
+	switch req.Objective {
+	case "":
+		req.Objective = domain.ObjectiveSharpe
+	case domain.ObjectiveSharpe, domain.ObjectiveSortino, domain.ObjectiveTotalReturn, domain.ObjectiveProfitFactor:
+	default:
+		return req, 0, fmt.Errorf("%w: unsupported objective %q", ErrInvalidBacktestConfig, req.Objective)
+	}
+	if len(req.Grid) == 0 {
+		return req, 0, fmt.Errorf("%w: grid must name at least one parameter", ErrInvalidBacktestConfig)
+	}
+	total, err := backtest.Evaluations(req)
+	if err != nil {
+		return req, 0, fmt.Errorf("%w: %v", ErrInvalidBacktestConfig, err)
+	}
+	return req, total, nil
+}
+
+func (s *StrategyOptimizerService) run(ctx context.Context, id int64, req domain.OptimizeRequest) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		s.pending--
+		s.mu.Unlock()
+	}()
+	select {
+	case s.slot <- struct{}{}:
+		defer func() { <-s.slot }()
+	case <-ctx.Done():
+		finished := s.now().UTC()
+		s.update(id, false, func(job *domain.OptimizationJob) {
+			job.FinishedAt = &finished
+			job.Status = domain.JobStatusFailed
+			job.Error = fmt.Sprintf("cancelled before starting: %v", ctx.Err())
+		})
+		return
+	}
+
+	ctx, span := s.tracer.Start(ctx, "strategy-optimizer-service.run")
+	defer span.End()
+
+	started := s.now().UTC()
+	s.update(id, false, func(job *domain.OptimizationJob) {
+		job.Status = domain.JobStatusRunning
+		job.StartedAt = &started
+	})
+
+	result, err := s.optimize(ctx, id, req)
+	finished := s.now().UTC()
+	s.update(id, false, func(job *domain.OptimizationJob) {
+		job.FinishedAt = &finished
+		if err != nil {
+			job.Status = domain.JobStatusFailed
+			job.Error = err.Error()
+			return
+		}
+		job.Status = domain.JobStatusSucceeded
+		job.Result = result
+	})
+	if err != nil {
+		log.Printf("optimization job %d failed: %v", id, err)
+	}
+}
+
+func (s *StrategyOptimizerService) optimize(ctx context.Context, id int64, req domain.OptimizeRequest) (*domain.OptimizeResult, error) {
+	cfg := req.Base
+	warmup := time.Duration(backtest.DefaultLookback) * domain.IntervalDuration(cfg.Interval)
+	candles, err := s.candleRepo.GetCandlesInRange(ctx, cfg.Symbol, cfg.Interval, cfg.From.Add(-warmup), cfg.To)
+	if err != nil {
+		return nil, fmt.Errorf("load candles: %w", err)
+	}
+	if len(candles) == 0 {
+		return nil, fmt.Errorf("no candles stored for %s %s in window", cfg.Symbol, cfg.Interval)
+	}
+
+	optimizer := backtest.NewOptimizer(func(params domain.SignalParams) backtest.SignalGenerator {
+		return signal.NewEngineWithParams(nil, params)
+	}, backtest.DefaultLookback)
+	return optimizer.Run(ctx, candles, req, func(done, total int) {
+		s.update(id, true, func(job *domain.OptimizationJob) {
+			job.Done, job.Total = done, total
+		})
+	})
+}
+
+// update applies fn to a job and writes it to the job store. Progress
+// writes are throttled to one per optimizationSaveEvery.
+func (s *StrategyOptimizerService) update(id int64, progress bool, fn func(job *domain.OptimizationJob)) {
+	s.mu.Lock()
+	job, ok := s.jobs[id]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	fn(job)
+	now := s.now()
+	if s.jobStore == nil || (progress && now.Sub(s.savedAt) < optimizationSaveEvery) {
+		s.mu.Unlock()
+		return
+	}
+	s.savedAt = now
+	snapshot := *job
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), optimizationSaveTimeout)
+	defer cancel()
+	if err := s.jobStore.SaveJob(ctx, snapshot); err != nil {
+		log.Printf("failed to save optimization job %d: %v", id, err)
+	}
+}
+
+// evictLocked drops the oldest finished jobs beyond maxOptimizationJobs.
+func (s *StrategyOptimizerService) evictLocked() {
+	if len(s.jobs) <= maxOptimizationJobs {
+		return
+	}
+	ids := make([]int64, 0, len(s.jobs))
+	for id := range s.jobs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		if len(s.jobs) <= maxOptimizationJobs {
+			return
+		}
+		switch s.jobs[id].Status {
+		case domain.JobStatusSucceeded, domain.JobStatusFailed:
+			delete(s.jobs, id)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/signal"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type stubSignalParamsStore struct {
+	saved  []domain.SignalParamSet
+	active *domain.SignalParamSet
+}
+
+func (s *stubSignalParamsStore) SaveActive(ctx context.Context, set domain.SignalParamSet) (int64, error) {
+	s.saved = append(s.saved, set)
+	set.ID = int64(len(s.saved))
+	s.active = &set
+	return set.ID, nil
+}
+
+func (s *stubSignalParamsStore) GetActive(ctx context.Context) (*domain.SignalParamSet, error) {
+	return s.active, nil
+}
+
+func optimizerTestCandles(from time.Time, n int) []*domain.Candle {
+	candles := make([]*domain.Candle, 0, n)
+	for i := 0; i < n; i++ {
+		p := 100 + float64(i%7) - float64(i%3)
+		candles = append(candles, &domain.Candle{
+			Symbol: "BTC", Interval: "1h", OpenTime: from.Add(time.Duration(i) * time.Hour),
+			Open: p, High: p + 1, Low: p - 1, Close: p + 0.5, Volume: 100 + float64(i%5),
+		})
+	}
+	return candles
+}
+
+func TestStrategyOptimizerServiceRunsAndPromotes(t *testing.T) {
+	to := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	from := to.Add(-96 * time.Hour)
+	live := signal.NewEngine(nil)
+	store := &stubSignalParamsStore{}
+	svc := NewStrategyOptimizerService(trace.NewNoopTracerProvider().Tracer("test"),
+		&stubStrategyCandleRepo{candles: optimizerTestCandles(from.Add(-24*time.Hour), 120)}, store, live)
+
+	job, err := svc.StartOptimization(context.Background(), domain.OptimizeRequest{
+		Base:  domain.BacktestConfig{Symbol: "btc", Interval: "1h", From: from, To: to},
+		Grid:  map[string][]float64{"rsi_period": {7, 14}},
+		Folds: 2,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if job.Status != domain.JobStatusQueued || job.Total != 6 {
+		t.Fatalf("expected queued job with 6 evaluations, got %+v", job)
+	}
+	if job.Request.Mode != domain.OptimizeModeGrid || job.Request.Objective != domain.ObjectiveSharpe || job.Request.Base.Params == nil {
+		t.Fatalf("expected defaults filled, got %+v", job.Request)
+	}
+	svc.wg.Wait()
+
+	got, err := svc.GetOptimization(context.Background(), job.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Status != domain.JobStatusSucceeded || got.Done != got.Total || got.Result == nil || got.Result.Recommended == nil {
+		t.Fatalf("expected finished job, got %+v", got)
+	}
+	if list := svc.ListOptimizations(context.Background()); len(list) != 1 || list[0].Result != nil {
+		t.Fatalf("expected one job without result in list, got %+v", list)
+	}
+
+	set, err := svc.PromoteOptimization(context.Background(), job.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if set.ID != 1 || len(store.saved) != 1 || live.Params() != got.Result.Recommended.Params {
+		t.Fatalf("expected params saved and applied, got set %+v live %+v", set, live.Params())
+	}
+	if got, _ := svc.GetOptimization(context.Background(), job.ID); got.PromotedSet != 1 {
+		t.Fatalf("expected job to record promoted set, got %d", got.PromotedSet)
+	}
+	active, err := svc.ActiveSignalParams(context.Background())
+	if err != nil || active.Source != "optimization job 1" {
+		t.Fatalf("expected promoted set active, got %+v (%v)", active, err)
+	}
+}
+
+func TestStrategyOptimizerServiceFailedJobCannotBePromoted(t *testing.T) {
+	svc := NewStrategyOptimizerService(trace.NewNoopTracerProvider().Tracer("test"), &stubStrategyCandleRepo{}, nil, signal.NewEngine(nil))
+
+	job, err := svc.StartOptimization(context.Background(), domain.OptimizeRequest{
+		Base: domain.BacktestConfig{Symbol: "BTC"},
+		Grid: map[string][]float64{"rsi_period": {7}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	svc.wg.Wait()
+	got, _ := svc.GetOptimization(context.Background(), job.ID)
+	if got.Status != domain.JobStatusFailed || got.Error == "" {
+		t.Fatalf("expected failed job without candles, got %+v", got)
+	}
+	if _, err := svc.PromoteOptimization(context.Background(), job.ID); !errors.Is(err, ErrOptimizationNotReady) {
+		t.Fatalf("expected ErrOptimizationNotReady, got %v", err)
+	}
+	if _, err := svc.PromoteOptimization(context.Background(), 99); !errors.Is(err, ErrOptimizationNotFound) {
+		t.Fatalf("expected ErrOptimizationNotFound, got %v", err)
+	}
+}
+
+// blockingCandleRepo holds every load until its context is cancelled.
+type blockingCandleRepo struct{}
+
+func (blockingCandleRepo) GetCandlesInRange(ctx context.Context, symbol, interval string, from, to time.Time) ([]*domain.Candle, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestStrategyOptimizerServiceBoundsPendingAndStopsWithContext(t *testing.T) {
+	svc := NewStrategyOptimizerService(trace.NewNoopTracerProvider().Tracer("test"), blockingCandleRepo{}, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	svc.SetContext(ctx)
+	req := domain.OptimizeRequest{
+		Base: domain.BacktestConfig{Symbol: "BTC"},
+		Grid: map[string][]float64{"rsi_period": {7}},
+	}
+
+	for i := 0; i < maxPendingOptimizations; i++ {
+		if _, err := svc.StartOptimization(context.Background(), req); err != nil {
+			t.Fatalf("job %d: unexpected error: %v", i, err)
+		}
+	}
+	if _, err := svc.StartOptimization(context.Background(), req); !errors.Is(err, ErrOptimizationQueueFull) {
+		t.Fatalf("expected ErrOptimizationQueueFull, got %v", err)
+	}
+
+	cancel()
+	svc.Wait()
+	for _, job := range svc.ListOptimizations(context.Background()) {
+		if job.Status != domain.JobStatusFailed || job.FinishedAt == nil {
+			t.Fatalf("expected every job stopped by cancellation, got %+v", job)
+		}
+	}
+	if _, err := svc.StartOptimization(context.Background(), req); err == nil {
+		t.Fatal("expected submissions refused after the context is cancelled")
+	}
+}
+
+func TestStrategyOptimizerServiceValidation(t *testing.T) {
+	svc := NewStrategyOptimizerService(trace.NewNoopTracerProvider().Tracer("test"), &stubStrategyCandleRepo{}, nil, nil)
+	grid := map[string][]float64{"rsi_period": {7}}
+	cases := []domain.OptimizeRequest{
+		{Base: domain.BacktestConfig{Symbol: "FAKE"}, Grid: grid},
+		{Base: domain.BacktestConfig{Symbol: "BTC"}},
+		{Base: domain.BacktestConfig{Symbol: "BTC"}, Grid: grid, Mode: "anneal"},
+		{Base: domain.BacktestConfig{Symbol: "BTC"}, Grid: grid, Objective: "luck"},
+		{Base: domain.BacktestConfig{Symbol: "BTC"}, Grid: grid, Folds: 50},
+		{Base: domain.BacktestConfig{Symbol: "BTC"}, Grid: map[string][]float64{"bogus": {1}}},
+	}
+	for i, req := range cases {
+		if _, err := svc.StartOptimization(context.Background(), req); !errors.Is(err, ErrInvalidBacktestConfig) {
+			t.Fatalf("case %d: expected ErrInvalidBacktestConfig, got %v", i, err)
+		}
+	}
+}
+
+func TestStrategyOptimizerServiceRestoreLiveParams(t *testing.T) {
+	promoted := signal.DefaultParams()
+	promoted.RSIPeriod = 9
+	store := &stubSignalParamsStore{active: &domain.SignalParamSet{ID: 3, Params: promoted}}
+	live := signal.NewEngine(nil)
+	svc := NewStrategyOptimizerService(trace.NewNoopTracerProvider().Tracer("test"), nil, store, live)
+
+	if err := svc.RestoreLiveParams(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if live.Params().RSIPeriod != 9 {
+		t.Fatalf("expected restored params, got %+v", live.Params())
+	}
+
+	empty := NewStrategyOptimizerService(trace.NewNoopTracerProvider().Tracer("test"), nil, &stubSignalParamsStore{}, live)
+	if err := empty.RestoreLiveParams(context.Background()); err != nil {
+		t.Fatalf("expected no-op without a promoted set, got %v", err)
+	}
+	active, _ := empty.ActiveSignalParams(context.Background())
+	if active.Source != "default" || active.Params.RSIPeriod != 9 {
+		t.Fatalf("expected live params reported as default, got %+v", active)
+	}
+}
+
+// memOptimizationJobStore keeps jobs in memory, shared between services the
+// way replicas share the optimization_jobs table.
+type memOptimizationJobStore struct {
+	mu   sync.Mutex
+	jobs map[int64]domain.OptimizationJob
+}
+
+func (s *memOptimizationJobStore) CreateJob(ctx context.Context, job domain.OptimizationJob) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job.ID = int64(len(s.jobs) + 1)
+	s.jobs[job.ID] = job
+	return job.ID, nil
+}
+
+func (s *memOptimizationJobStore) SaveJob(ctx context.Context, job domain.OptimizationJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = job
+	return nil
+}
+
+func (s *memOptimizationJobStore) GetJob(ctx context.Context, id int64) (*domain.OptimizationJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, nil
+	}
+	return &job, nil
+}
+
+func (s *memOptimizationJobStore) ListJobs(ctx context.Context, limit int) ([]domain.OptimizationJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]domain.OptimizationJob, 0, len(s.jobs))
+	for id := int64(len(s.jobs)); id > 0 && len(out) < limit; id-- {
+		job := s.jobs[id]
+		job.Result = nil
+		out = append(out, job)
+	}
+	return out, nil
+}
+
+func TestStrategyOptimizerServiceJobsVisibleAcrossReplicas(t *testing.T) {
+	to := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	from := to.Add(-96 * time.Hour)
+	tracer := trace.NewNoopTracerProvider().Tracer("test")
+	jobs := &memOptimizationJobStore{jobs: map[int64]domain.OptimizationJob{}}
+	params := &stubSignalParamsStore{}
+
+	runner := NewStrategyOptimizerService(tracer,
+		&stubStrategyCandleRepo{candles: optimizerTestCandles(from.Add(-24*time.Hour), 120)}, params, signal.NewEngine(nil))
+	runner.SetJobStore(jobs)
+	pollerEngine := signal.NewEngine(nil)
+	poller := NewStrategyOptimizerService(tracer, nil, params, pollerEngine)
+	poller.SetJobStore(jobs)
+	other := NewStrategyOptimizerService(tracer, nil, params, signal.NewEngine(nil))
+	other.SetJobStore(jobs)
+
+	job, err := runner.StartOptimization(context.Background(), domain.OptimizeRequest{
+		Base:  domain.BacktestConfig{Symbol: "BTC", Interval: "1h", From: from, To: to},
+		Grid:  map[string][]float64{"rsi_period": {7, 14}},
+		Folds: 2,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	runner.Wait()
+
+	got, err := other.GetOptimization(context.Background(), job.ID)
+	if err != nil {
+		t.Fatalf("expected the job readable on another replica, got %v", err)
+	}
+	if got.Status != domain.JobStatusSucceeded || got.Done != got.Total || got.Result == nil {
+		t.Fatalf("expected the finished job stored, got %+v", got)
+	}
+	if list := other.ListOptimizations(context.Background()); len(list) != 1 || list[0].ID != job.ID {
+		t.Fatalf("expected the job listed on another replica, got %+v", list)
+	}
+
+	set, err := other.PromoteOptimization(context.Background(), job.ID)
+	if err != nil {
+		t.Fatalf("expected promotion from another replica, got %v", err)
+	}
+	if stored, _ := runner.GetOptimization(context.Background(), job.ID); stored.PromotedSet != set.ID {
+		t.Fatalf("expected the promotion stored, got %d", stored.PromotedSet)
+	}
+
+	if err := poller.RestoreLiveParams(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pollerEngine.Params() != got.Result.Recommended.Params {
+		t.Fatalf("expected the poller replica to pick up the promoted params, got %+v", pollerEngine.Params())
+	}
+}
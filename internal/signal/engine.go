@@ -5,6 +5,7 @@ import (
 	"math"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"bug-free-umbrella/internal/domain"
@@ -12,6 +13,8 @@ import (
 
 const (
 	rsiPeriod        = 14
+	rsiOversold      = 30.0
+	rsiOverbought    = 70.0
 	macdFastPeriod   = 12
 	macdSlowPeriod   = 26
 	macdSignalPeriod = 9
@@ -24,6 +27,9 @@ const (
 
 type Engine struct {
 	now func() time.Time
+
+	mu     sync.RWMutex
+	params domain.SignalParams
 }
 
 type event struct {
@@ -32,10 +38,51 @@ type event struct {
 }
 
 func NewEngine(now func() time.Time) *Engine {
+	return NewEngineWithParams(now, DefaultParams())
+}
+
+// NewEngineWithParams builds an engine with explicit rule parameters, e.g.
+// for a backtest of a candidate parameter set.
+func NewEngineWithParams(now func() time.Time, params domain.SignalParams) *Engine {
 	if now == nil {
 		now = time.Now
 	}
-	return &Engine{now: now}
+	return &Engine{now: now, params: params}
+}
+
+// DefaultParams are the rule thresholds the engine ships with.
+func DefaultParams() domain.SignalParams {
+	return domain.SignalParams{
+		RSIPeriod:        rsiPeriod,
+		RSIOversold:      rsiOversold,
+		RSIOverbought:    rsiOverbought,
+		MACDFast:         macdFastPeriod,
+		MACDSlow:         macdSlowPeriod,
+		MACDSignal:       macdSignalPeriod,
+		BollingerPeriod:  bollingerPeriod,
+		BollingerStdDevs: bollingerStdDevs,
+		SqueezeThreshold: squeezeThreshold,
+		VolumeWindow:     volumeWindow,
+		VolumeZThreshold: volumeZThreshold,
+	}
+}
+
+// Params returns the rule parameters currently in use.
+func (e *Engine) Params() domain.SignalParams {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.params
+}
+
+// SetParams swaps the rule parameters; the next Generate call uses them.
+func (e *Engine) SetParams(params domain.SignalParams) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	e.mu.Lock()
+	e.params = params
+	e.mu.Unlock()
+	return nil
 }
 
 // Generate produces deterministic signals using the most recent completed candle.
@@ -44,27 +91,50 @@ func (e *Engine) Generate(candles []*domain.Candle) []domain.Signal {
 	if len(normalized) < 2 {
 		return nil
 	}
+	params := e.Params()
 
 	latest := normalized[len(normalized)-1]
-	result := make([]domain.Signal, 0, 4)
 
-	if ev, ok := detectRSI(normalized); ok {
-		result = append(result, e.newSignal(latest, domain.IndicatorRSI, ev))
-	}
-	if ev, ok := detectMACD(normalized); ok {
-		result = append(result, e.newSignal(latest, domain.IndicatorMACD, ev))
-	}
-	if ev, ok := detectBollinger(normalized); ok {
-		result = append(result, e.newSignal(latest, domain.IndicatorBollinger, ev))
+	type detected struct {
+		indicator string
+		ev        event
+	}
+	found := make([]detected, 0, 4)
+	for _, indicator := range []string{
+		domain.IndicatorRSI,
+		domain.IndicatorMACD,
+		domain.IndicatorBollinger,
+		domain.IndicatorVolumeZ,
+	} {
+		if ev, ok := detectors[indicator](normalized, params); ok {
+			found = append(found, detected{indicator: indicator, ev: ev})
+		}
 	}
-	if ev, ok := detectVolumeAnomaly(normalized); ok {
-		result = append(result, e.newSignal(latest, domain.IndicatorVolumeZ, ev))
+
+	result := make([]domain.Signal, 0, len(found))
+	for i, d := range found {
+		peers := make([]event, 0, len(found)-1)
+		for j := range found {
+			if j != i {
+				peers = append(peers, found[j].ev)
+			}
+		}
+		risk, breakdown := assessRisk(normalized, d.indicator, d.ev, peers, params)
+		levels := ComputeLevels(normalized, d.indicator, d.ev.direction)
+		result = append(result, e.newSignal(latest, d.indicator, d.ev, risk, breakdown, levels))
 	}
 
 	return result
 }
 
-func (e *Engine) newSignal(candle domain.Candle, indicator string, ev event) domain.Signal {
+func (e *Engine) newSignal(
+	candle domain.Candle,
+	indicator string,
+	ev event,
+	risk domain.RiskLevel,
+	breakdown RiskBreakdown,
+	levels *domain.SignalLevels,
+) domain.Signal {
 	ts := candle.OpenTime.UTC()
 	if ts.IsZero() {
 		ts = e.now().UTC()
@@ -75,9 +145,10 @@ func (e *Engine) newSignal(candle domain.Candle, indicator string, ev event) dom
 		Interval:  candle.Interval,
 		Indicator: indicator,
 		Timestamp: ts,
-		Risk:      riskFor(indicator, candle.Interval),
+		Risk:      risk,
 		Direction: ev.direction,
-		Details:   ev.details,
+		Details:   ev.details + ";" + breakdown.String(),
+		Levels:    levels,
 	}
 }
 
@@ -95,9 +166,9 @@ func normalizeCandles(in []*domain.Candle) []domain.Candle {
 	return out
 }
 
-func detectRSI(candles []domain.Candle) (event, bool) {
+func detectRSI(candles []domain.Candle, p domain.SignalParams) (event, bool) {
 	closes := extractCloses(candles)
-	series := rsiSeries(closes, rsiPeriod)
+	series := rsiSeries(closes, p.RSIPeriod)
 	if len(series) < 2 {
 		return event{}, false
 	}
@@ -107,21 +178,21 @@ func detectRSI(candles []domain.Candle) (event, bool) {
 		return event{}, false
 	}
 
-	if prev >= 30 && curr < 30 {
-		return event{direction: domain.DirectionLong, details: fmt.Sprintf("rsi %.2f crossed below 30", curr)}, true
+	if prev >= p.RSIOversold && curr < p.RSIOversold {
+		return event{direction: domain.DirectionLong, details: fmt.Sprintf("rsi %.2f crossed below %g", curr, p.RSIOversold)}, true
 	}
-	if prev <= 70 && curr > 70 {
-		return event{direction: domain.DirectionShort, details: fmt.Sprintf("rsi %.2f crossed above 70", curr)}, true
+	if prev <= p.RSIOverbought && curr > p.RSIOverbought {
+		return event{direction: domain.DirectionShort, details: fmt.Sprintf("rsi %.2f crossed above %g", curr, p.RSIOverbought)}, true
 	}
 	return event{}, false
 }
 
-func detectMACD(candles []domain.Candle) (event, bool) {
+func detectMACD(candles []domain.Candle, p domain.SignalParams) (event, bool) {
 	closes := extractCloses(candles)
-	if len(closes) < macdSlowPeriod+macdSignalPeriod {
+	if len(closes) < p.MACDSlow+p.MACDSignal {
 		return event{}, false
 	}
-	macdLine, signalLine := macdSeries(closes, macdFastPeriod, macdSlowPeriod, macdSignalPeriod)
+	macdLine, signalLine := macdSeries(closes, p.MACDFast, p.MACDSlow, p.MACDSignal)
 	if len(macdLine) < 2 || len(signalLine) < 2 {
 		return event{}, false
 	}
@@ -138,28 +209,28 @@ func detectMACD(candles []domain.Candle) (event, bool) {
 	return event{}, false
 }
 
-func detectBollinger(candles []domain.Candle) (event, bool) {
+func detectBollinger(candles []domain.Candle, p domain.SignalParams) (event, bool) {
 	closes := extractCloses(candles)
-	if len(closes) < bollingerPeriod+1 {
+	if len(closes) < p.BollingerPeriod+1 {
 		return event{}, false
 	}
 
 	prevIdx := len(closes) - 2
 	currIdx := len(closes) - 1
 
-	prevMean, prevStd := meanStd(closes[prevIdx-bollingerPeriod+1 : prevIdx+1])
-	currMean, currStd := meanStd(closes[currIdx-bollingerPeriod+1 : currIdx+1])
+	prevMean, prevStd := meanStd(closes[prevIdx-p.BollingerPeriod+1 : prevIdx+1])
+	currMean, currStd := meanStd(closes[currIdx-p.BollingerPeriod+1 : currIdx+1])
 	if prevMean == 0 || currMean == 0 {
 		return event{}, false
 	}
 
-	prevUpper := prevMean + bollingerStdDevs*prevStd
-	prevLower := prevMean - bollingerStdDevs*prevStd
-	currUpper := currMean + bollingerStdDevs*currStd
-	currLower := currMean - bollingerStdDevs*currStd
+	prevUpper := prevMean + p.BollingerStdDevs*prevStd
+	prevLower := prevMean - p.BollingerStdDevs*prevStd
+	currUpper := currMean + p.BollingerStdDevs*currStd
+	currLower := currMean - p.BollingerStdDevs*currStd
 	prevWidth := (prevUpper - prevLower) / prevMean
 
-	if prevWidth > squeezeThreshold {
+	if prevWidth > p.SqueezeThreshold {
 		return event{}, false
 	}
 
@@ -175,12 +246,12 @@ func detectBollinger(candles []domain.Candle) (event, bool) {
 	return event{}, false
 }
 
-func detectVolumeAnomaly(candles []domain.Candle) (event, bool) {
-	if len(candles) < volumeWindow+1 {
+func detectVolumeAnomaly(candles []domain.Candle, p domain.SignalParams) (event, bool) {
+	if len(candles) < p.VolumeWindow+1 {
 		return event{}, false
 	}
 	volumes := extractVolumes(candles)
-	window := volumes[len(volumes)-1-volumeWindow : len(volumes)-1]
+	window := volumes[len(volumes)-1-p.VolumeWindow : len(volumes)-1]
 	mean, std := meanStd(window)
 	if std == 0 {
 		return event{}, false
@@ -188,7 +259,7 @@ func detectVolumeAnomaly(candles []domain.Candle) (event, bool) {
 
 	currVolume := volumes[len(volumes)-1]
 	z := (currVolume - mean) / std
-	if z < volumeZThreshold {
+	if z < p.VolumeZThreshold {
 		return event{}, false
 	}
 
@@ -308,6 +379,8 @@ func meanStd(values []float64) (mean, std float64) {
 	return mean, std
 }
 
+// riskFor is the static (indicator, interval) risk table. It is only used when
+// there is not enough history for the dynamic model in assessRisk.
 func riskFor(indicator, interval string) domain.RiskLevel {
 	switch indicator {
 	case domain.IndicatorRSI:
@@ -72,7 +72,7 @@ func TestDetectBollingerBreakout(t *testing.T) {
 		Volume:   110,
 	})
 
-	ev, ok := detectBollinger(candles)
+	ev, ok := detectBollinger(candles, DefaultParams())
 	if !ok {
 		t.Fatal("expected bollinger signal")
 	}
@@ -100,3 +100,48 @@ func TestGenerateReturnsNilForInsufficientCandles(t *testing.T) {
 		t.Fatalf("expected no signals, got %d", len(got))
 	}
 }
+
+func TestEngineSetParams(t *testing.T) {
+	engine := NewEngine(nil)
+	if engine.Params() != DefaultParams() {
+		t.Fatal("expected default params")
+	}
+
+	bad := DefaultParams()
+	bad.MACDFast = bad.MACDSlow
+	if err := engine.SetParams(bad); err == nil {
+		t.Fatal("expected invalid params to be rejected")
+	}
+	if engine.Params() != DefaultParams() {
+		t.Fatal("expected rejected params to leave the engine unchanged")
+	}
+
+	custom := DefaultParams()
+	custom.VolumeZThreshold = 50
+	if err := engine.SetParams(custom); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if engine.Params() != custom {
+		t.Fatalf("expected custom params, got %+v", engine.Params())
+	}
+}
+
+func TestParamsChangeDetection(t *testing.T) {
+	candles := make([]domain.Candle, 0, 25)
+	base := time.Unix(0, 0).UTC()
+	for i := 0; i < 25; i++ {
+		vol := 100.0 + float64(i%5)
+		if i == 24 {
+			vol = 1000
+		}
+		candles = append(candles, domain.Candle{OpenTime: base.Add(time.Duration(i) * time.Minute), Close: 100, Volume: vol})
+	}
+	if _, ok := detectVolumeAnomaly(candles, DefaultParams()); !ok {
+		t.Fatal("expected anomaly with default threshold")
+	}
+	strict := DefaultParams()
+	strict.VolumeZThreshold = 1000
+	if _, ok := detectVolumeAnomaly(candles, strict); ok {
+		t.Fatal("expected no anomaly with a strict threshold")
+	}
+}
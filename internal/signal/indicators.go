@@ -0,0 +1,66 @@
+package signal
+
+import (
+	"math"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+// IndicatorReading is the value of each engine indicator at one candle.
+// Readings are nil until the indicator has enough history.
+type IndicatorReading struct {
+	OpenTime       time.Time `json:"open_time"`
+	Close          float64   `json:"close"`
+	RSI            *float64  `json:"rsi,omitempty"`
+	MACD           *float64  `json:"macd,omitempty"`
+	MACDSignal     *float64  `json:"macd_signal,omitempty"`
+	BollingerUpper *float64  `json:"bollinger_upper,omitempty"`
+	BollingerLower *float64  `json:"bollinger_lower,omitempty"`
+	VolumeZ        *float64  `json:"volume_z,omitempty"`
+}
+
+// Indicators computes the readings the engine's rules look at for every
+// candle, oldest first, using params p.
+func Indicators(candles []*domain.Candle, p domain.SignalParams) []IndicatorReading {
+	sorted := normalizeCandles(candles)
+	closes := extractCloses(sorted)
+	volumes := extractVolumes(sorted)
+	rsi := rsiSeries(closes, p.RSIPeriod)
+	var macdLine, signalLine []float64
+	if len(closes) >= p.MACDSlow+p.MACDSignal {
+		macdLine, signalLine = macdSeries(closes, p.MACDFast, p.MACDSlow, p.MACDSignal)
+	}
+
+	out := make([]IndicatorReading, len(sorted))
+	for i, c := range sorted {
+		r := IndicatorReading{OpenTime: c.OpenTime, Close: c.Close}
+		if i < len(rsi) {
+			r.RSI = reading(rsi[i])
+		}
+		if macdLine != nil && i >= p.MACDSlow+p.MACDSignal-1 {
+			r.MACD = reading(macdLine[i])
+			r.MACDSignal = reading(signalLine[i])
+		}
+		if p.BollingerPeriod > 0 && i >= p.BollingerPeriod-1 {
+			mean, std := meanStd(closes[i-p.BollingerPeriod+1 : i+1])
+			r.BollingerUpper = reading(mean + p.BollingerStdDevs*std)
+			r.BollingerLower = reading(mean - p.BollingerStdDevs*std)
+		}
+		if p.VolumeWindow > 0 && i >= p.VolumeWindow {
+			mean, std := meanStd(volumes[i-p.VolumeWindow : i])
+			if std > 0 {
+				r.VolumeZ = reading((volumes[i] - mean) / std)
+			}
+		}
+		out[i] = r
+	}
+	return out
+}
+
+func reading(v float64) *float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,62 @@
+package signal
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+func TestIndicatorsWarmUpAndMatchEngineSeries(t *testing.T) {
+	params := DefaultParams()
+	base := time.Unix(0, 0).UTC()
+	candles := make([]*domain.Candle, 0, 60)
+	for i := 59; i >= 0; i-- { // out of order on purpose
+		c := 100 + 5*math.Sin(float64(i)/3)
+		candles = append(candles, &domain.Candle{
+			OpenTime: base.Add(time.Duration(i) * time.Hour),
+			Close:    c,
+			Volume:   100 + float64(i%5),
+		})
+	}
+
+	readings := Indicators(candles, params)
+	if len(readings) != 60 {
+		t.Fatalf("expected 60 readings, got %d", len(readings))
+	}
+	if !readings[0].OpenTime.Equal(base) || !readings[59].OpenTime.After(readings[58].OpenTime) {
+		t.Fatal("expected readings oldest first")
+	}
+	if readings[params.RSIPeriod-1].RSI != nil || readings[params.RSIPeriod].RSI == nil {
+		t.Fatal("expected RSI to start after its period")
+	}
+	if readings[params.MACDSlow+params.MACDSignal-2].MACD != nil || readings[params.MACDSlow+params.MACDSignal-1].MACD == nil {
+		t.Fatal("expected MACD to start once slow and signal EMAs are warm")
+	}
+	if readings[params.BollingerPeriod-2].BollingerUpper != nil || readings[params.BollingerPeriod-1].BollingerUpper == nil {
+		t.Fatal("expected Bollinger bands to start after their period")
+	}
+
+	closes := make([]float64, len(readings))
+	for i, r := range readings {
+		closes[i] = r.Close
+	}
+	rsi := rsiSeries(closes, params.RSIPeriod)
+	if got := *readings[59].RSI; math.Abs(got-rsi[59]) > 1e-9 {
+		t.Fatalf("expected RSI %.4f, got %.4f", rsi[59], got)
+	}
+	last := readings[59]
+	if *last.BollingerLower >= *last.BollingerUpper {
+		t.Fatalf("expected lower band below upper, got %.2f/%.2f", *last.BollingerLower, *last.BollingerUpper)
+	}
+	if last.VolumeZ == nil {
+		t.Fatal("expected a volume z-score once the window is full")
+	}
+}
+
+func TestIndicatorsEmpty(t *testing.T) {
+	if got := Indicators(nil, DefaultParams()); len(got) != 0 {
+		t.Fatalf("expected no readings, got %d", len(got))
+	}
+}
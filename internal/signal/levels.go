@@ -0,0 +1,180 @@
+package signal
+
+import (
+	"math"
+	"sort"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+const (
+	swingLookback    = 20
+	atrStopMultiple  = 1.5
+	minStopATR       = 0.5
+	maxStopATR       = 3.0
+	swingStopBuffer  = 0.1
+	maxSignalTargets = 3
+)
+
+// atrTargetMultiples are the fallback profit targets, in ATRs from entry.
+var atrTargetMultiples = []float64{2.0, 3.5}
+
+// ComputeLevels derives entry, stop and targets for a directional signal from
+// the candles it fired on. The stop is placed at the indicator's natural
+// invalidation point (middle band for Bollinger breakouts, the prior swing for
+// everything else) when that sits a sensible distance away, and at a fixed ATR
+// multiple otherwise. It returns nil for hold signals or too little history.
+func ComputeLevels(candles []domain.Candle, indicator string, direction domain.SignalDirection) *domain.SignalLevels {
+	if direction != domain.DirectionLong && direction != domain.DirectionShort {
+		return nil
+	}
+	atrPct := atrPctSeries(candles, atrPeriod)
+	if len(atrPct) == 0 {
+		return nil
+	}
+	entry := candles[len(candles)-1].Close
+	atr := atrPct[len(atrPct)-1] * entry
+	if entry <= 0 || atr <= 0 || math.IsNaN(atr) {
+		return nil
+	}
+
+	sign := 1.0
+	if direction == domain.DirectionShort {
+		sign = -1
+	}
+
+	levels := &domain.SignalLevels{
+		Entry: entry,
+		Stop:  entry - sign*atrStopMultiple*atr,
+		Basis: domain.LevelBasisATR,
+	}
+	targets := make([]float64, 0, len(atrTargetMultiples)+1)
+	for _, m := range atrTargetMultiples {
+		targets = append(targets, entry+sign*m*atr)
+	}
+
+	usableStop := func(stop float64) bool {
+		dist := sign * (entry - stop)
+		return dist >= minStopATR*atr && dist <= maxStopATR*atr
+	}
+
+	closes := extractCloses(candles)
+	if indicator == domain.IndicatorBollinger && len(closes) >= bollingerPeriod {
+		mean, std := meanStd(closes[len(closes)-bollingerPeriod:])
+		if usableStop(mean) {
+			levels.Stop = mean
+			levels.Basis = domain.LevelBasisBands
+		}
+		// Measured move: project the full band width from the breakout.
+		if width := 2 * bollingerStdDevs * std; width > atr {
+			targets = append(targets, entry+sign*width)
+		}
+	} else if low, high, ok := priorSwing(candles, swingLookback); ok {
+		stop, target := low-swingStopBuffer*atr, high
+		if direction == domain.DirectionShort {
+			stop, target = high+swingStopBuffer*atr, low
+		}
+		if usableStop(stop) {
+			levels.Stop = stop
+			levels.Basis = domain.LevelBasisSwing
+		}
+		if sign*(target-entry) >= atr {
+			targets = append(targets, target)
+		}
+	}
+
+	levels.Targets = orderTargets(entry, sign, atr, targets)
+	if risk := math.Abs(entry - levels.Stop); risk > 0 && len(levels.Targets) > 0 {
+		levels.RewardRisk = math.Abs(levels.Targets[0]-entry) / risk
+	}
+	return levels
+}
+
+// LatestATR is the Wilder average true range at the last candle, in price
+// units, or zero when there is too little history.
+func LatestATR(candles []domain.Candle) float64 {
+	atrPct := atrPctSeries(candles, atrPeriod)
+	if len(atrPct) == 0 {
+		return 0
+	}
+	return atrPct[len(atrPct)-1] * candles[len(candles)-1].Close
+}
+
+// ResolveLevels walks the candles that closed after a signal and reports
+// whether its stop or first target was touched first, with the exit price.
+// When both fall inside one candle the stop is assumed to have filled first.
+// Unresolved signals report the last close.
+func ResolveLevels(direction domain.SignalDirection, levels *domain.SignalLevels, after []domain.Candle) (domain.LevelOutcome, float64) {
+	if levels == nil || len(levels.Targets) == 0 || len(after) == 0 {
+		return domain.LevelOutcomeOpen, 0
+	}
+	target := levels.Targets[0]
+	for _, c := range after {
+		high, low := candleRange(c)
+		switch direction {
+		case domain.DirectionLong:
+			if low <= levels.Stop {
+				return domain.LevelOutcomeStop, levels.Stop
+			}
+			if high >= target {
+				return domain.LevelOutcomeTarget, target
+			}
+		case domain.DirectionShort:
+			if high >= levels.Stop {
+				return domain.LevelOutcomeStop, levels.Stop
+			}
+			if low <= target {
+				return domain.LevelOutcomeTarget, target
+			}
+		default:
+			return domain.LevelOutcomeOpen, 0
+		}
+	}
+	return domain.LevelOutcomeOpen, after[len(after)-1].Close
+}
+
+// priorSwing returns the lowest low and highest high of the lookback window
+// ending just before the latest candle.
+func priorSwing(candles []domain.Candle, lookback int) (float64, float64, bool) {
+	if len(candles) < lookback+1 {
+		return 0, 0, false
+	}
+	window := candles[len(candles)-1-lookback : len(candles)-1]
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, c := range window {
+		h, l := candleRange(c)
+		low = math.Min(low, l)
+		high = math.Max(high, h)
+	}
+	return low, high, low > 0 && high >= low
+}
+
+// orderTargets sorts targets nearest-first, drops any that are not beyond
+// entry in the trade direction or sit within a quarter ATR of a nearer one,
+// and keeps at most maxSignalTargets.
+func orderTargets(entry, sign, atr float64, targets []float64) []float64 {
+	sort.Slice(targets, func(i, j int) bool {
+		return sign*(targets[i]-entry) < sign*(targets[j]-entry)
+	})
+	out := make([]float64, 0, maxSignalTargets)
+	for _, t := range targets {
+		if sign*(t-entry) <= 0 {
+			continue
+		}
+		if len(out) > 0 && math.Abs(t-out[len(out)-1]) < 0.25*atr {
+			continue
+		}
+		out = append(out, t)
+		if len(out) == maxSignalTargets {
+			break
+		}
+	}
+	return out
+}
+
+func candleRange(c domain.Candle) (float64, float64) {
+	if c.High <= 0 || c.Low <= 0 || c.High < c.Low {
+		return c.Close, c.Close
+	}
+	return c.High, c.Low
+}
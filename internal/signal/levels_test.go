@@ -0,0 +1,132 @@
+package signal
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+func buildLevelCandles(n int, closeAt func(i int) float64) []domain.Candle {
+	base := time.Unix(0, 0).UTC()
+	out := make([]domain.Candle, 0, n)
+	for i := 0; i < n; i++ {
+		c := closeAt(i)
+		out = append(out, domain.Candle{
+			Symbol:   "BTC",
+			Interval: "1h",
+			OpenTime: base.Add(time.Duration(i) * time.Hour),
+			Open:     c,
+			High:     c + 1,
+			Low:      c - 1,
+			Close:    c,
+			Volume:   100,
+		})
+	}
+	return out
+}
+
+func TestComputeLevelsLongUsesSwingLow(t *testing.T) {
+	candles := buildLevelCandles(40, func(i int) float64 { return 100 + float64(i%4) })
+	levels := ComputeLevels(candles, domain.IndicatorRSI, domain.DirectionLong)
+	if levels == nil {
+		t.Fatal("expected levels")
+	}
+	if levels.Entry != candles[len(candles)-1].Close {
+		t.Fatalf("expected entry at last close, got %.2f", levels.Entry)
+	}
+	if levels.Stop >= levels.Entry {
+		t.Fatalf("expected long stop below entry, got %.2f", levels.Stop)
+	}
+	if levels.Basis != domain.LevelBasisSwing {
+		t.Fatalf("expected swing basis, got %s", levels.Basis)
+	}
+	if len(levels.Targets) == 0 || levels.Targets[0] <= levels.Entry {
+		t.Fatalf("expected targets above entry, got %+v", levels.Targets)
+	}
+	for i := 1; i < len(levels.Targets); i++ {
+		if levels.Targets[i] <= levels.Targets[i-1] {
+			t.Fatalf("expected targets ordered nearest-first, got %+v", levels.Targets)
+		}
+	}
+	want := (levels.Targets[0] - levels.Entry) / (levels.Entry - levels.Stop)
+	if math.Abs(levels.RewardRisk-want) > 1e-9 {
+		t.Fatalf("expected reward/risk %.4f, got %.4f", want, levels.RewardRisk)
+	}
+}
+
+func TestComputeLevelsShortMirrorsLong(t *testing.T) {
+	candles := buildLevelCandles(40, func(i int) float64 { return 100 + float64(i%4) })
+	levels := ComputeLevels(candles, domain.IndicatorMACD, domain.DirectionShort)
+	if levels == nil {
+		t.Fatal("expected levels")
+	}
+	if levels.Stop <= levels.Entry {
+		t.Fatalf("expected short stop above entry, got %.2f", levels.Stop)
+	}
+	for _, target := range levels.Targets {
+		if target >= levels.Entry {
+			t.Fatalf("expected short targets below entry, got %+v", levels.Targets)
+		}
+	}
+}
+
+func TestComputeLevelsFallsBackToATRWhenSwingTooFar(t *testing.T) {
+	// A single deep wick 15 bars back puts the swing low far outside 3 ATR.
+	candles := buildLevelCandles(40, func(i int) float64 { return 100 })
+	candles[len(candles)-15].Low = 50
+	levels := ComputeLevels(candles, domain.IndicatorRSI, domain.DirectionLong)
+	if levels == nil {
+		t.Fatal("expected levels")
+	}
+	if levels.Basis != domain.LevelBasisATR {
+		t.Fatalf("expected atr basis, got %s (stop %.2f)", levels.Basis, levels.Stop)
+	}
+}
+
+func TestComputeLevelsNilForHoldOrShortHistory(t *testing.T) {
+	candles := buildLevelCandles(40, func(i int) float64 { return 100 + float64(i%3) })
+	if ComputeLevels(candles, domain.IndicatorVolumeZ, domain.DirectionHold) != nil {
+		t.Fatal("expected no levels for hold")
+	}
+	if ComputeLevels(candles[:5], domain.IndicatorRSI, domain.DirectionLong) != nil {
+		t.Fatal("expected no levels for short history")
+	}
+}
+
+func TestResolveLevels(t *testing.T) {
+	levels := &domain.SignalLevels{Entry: 100, Stop: 95, Targets: []float64{110}}
+
+	hitTarget := []domain.Candle{{High: 104, Low: 98, Close: 103}, {High: 111, Low: 102, Close: 109}}
+	if outcome, exit := ResolveLevels(domain.DirectionLong, levels, hitTarget); outcome != domain.LevelOutcomeTarget || exit != 110 {
+		t.Fatalf("expected target at 110, got %s %.2f", outcome, exit)
+	}
+
+	// Both touched in one bar: the stop is assumed to fill first.
+	both := []domain.Candle{{High: 112, Low: 94, Close: 100}}
+	if outcome, exit := ResolveLevels(domain.DirectionLong, levels, both); outcome != domain.LevelOutcomeStop || exit != 95 {
+		t.Fatalf("expected stop at 95, got %s %.2f", outcome, exit)
+	}
+
+	open := []domain.Candle{{High: 104, Low: 97, Close: 101}}
+	if outcome, exit := ResolveLevels(domain.DirectionLong, levels, open); outcome != domain.LevelOutcomeOpen || exit != 101 {
+		t.Fatalf("expected open at last close, got %s %.2f", outcome, exit)
+	}
+
+	short := &domain.SignalLevels{Entry: 100, Stop: 105, Targets: []float64{90}}
+	if outcome, _ := ResolveLevels(domain.DirectionShort, short, []domain.Candle{{High: 101, Low: 89, Close: 90}}); outcome != domain.LevelOutcomeTarget {
+		t.Fatalf("expected short target, got %s", outcome)
+	}
+}
+
+func TestLatestATR(t *testing.T) {
+	if got := LatestATR(buildLevelCandles(atrPeriod, func(int) float64 { return 100 })); got != 0 {
+		t.Fatalf("expected zero ATR with too little history, got %v", got)
+	}
+	// Flat closes with a two-point high/low range give a true range of 2.
+	got := LatestATR(buildLevelCandles(60, func(int) float64 { return 100 }))
+	if math.Abs(got-2) > 1e-9 {
+		t.Fatalf("expected ATR of 2, got %v", got)
+	}
+}
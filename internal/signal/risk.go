@@ -0,0 +1,250 @@
+package signal
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+const (
+	atrPeriod         = 14
+	riskMinCandles    = 60
+	hitRateHorizon    = 6
+	hitRateMaxSamples = 120
+
+	riskWeightVolatility = 0.35
+	riskWeightLiquidity  = 0.15
+	riskWeightHitRate    = 0.30
+	riskWeightAgreement  = 0.20
+
+	riskModelDynamic = "dynamic"
+	riskModelStatic  = "static"
+)
+
+// referenceATRPct is the ATR (as a fraction of price) considered "normal" for
+// each interval. It anchors the absolute half of the volatility component so
+// a calm asset is not scored as volatile just because its own history is calm.
+var referenceATRPct = map[string]float64{
+	"5m":  0.004,
+	"15m": 0.007,
+	"1h":  0.012,
+	"4h":  0.025,
+	"1d":  0.050,
+}
+
+// RiskBreakdown records how a signal's risk level was derived. Each component
+// is normalised to 0..1 where higher means riskier.
+type RiskBreakdown struct {
+	Model      string
+	Score      float64
+	Volatility float64
+	Liquidity  float64
+	HitRate    float64
+	HitSamples int
+	Agreement  float64
+}
+
+// String renders the breakdown in the key=value;... form used for signal details.
+func (b RiskBreakdown) String() string {
+	if b.Model != riskModelDynamic {
+		return "risk_model=" + riskModelStatic
+	}
+	return fmt.Sprintf(
+		"risk_model=%s;risk_score=%.4f;risk_vol=%.4f;risk_liq=%.4f;risk_hit=%.4f;risk_hit_n=%d;risk_agree=%.4f",
+		b.Model, b.Score, b.Volatility, b.Liquidity, b.HitRate, b.HitSamples, b.Agreement,
+	)
+}
+
+type detector func([]domain.Candle, domain.SignalParams) (event, bool)
+
+var detectors = map[string]detector{
+	domain.IndicatorRSI:       detectRSI,
+	domain.IndicatorMACD:      detectMACD,
+	domain.IndicatorBollinger: detectBollinger,
+	domain.IndicatorVolumeZ:   detectVolumeAnomaly,
+}
+
+// assessRisk scores a detected event from realised volatility, liquidity, the
+// indicator's hit rate over the supplied history and agreement with the other
+// events on the same candle. It falls back to the static table when there is
+// not enough history to measure volatility.
+func assessRisk(candles []domain.Candle, indicator string, ev event, peers []event, params domain.SignalParams) (domain.RiskLevel, RiskBreakdown) {
+	interval := ""
+	if len(candles) > 0 {
+		interval = candles[len(candles)-1].Interval
+	}
+	if len(candles) < riskMinCandles {
+		return riskFor(indicator, interval), RiskBreakdown{Model: riskModelStatic}
+	}
+
+	atrPct := atrPctSeries(candles, atrPeriod)
+	if len(atrPct) == 0 {
+		return riskFor(indicator, interval), RiskBreakdown{Model: riskModelStatic}
+	}
+
+	b := RiskBreakdown{Model: riskModelDynamic}
+	b.Volatility = volatilityRisk(atrPct, interval)
+	b.Liquidity = liquidityRisk(candles)
+	b.HitRate, b.HitSamples = hitRateRisk(candles, indicator, params)
+	b.Agreement = agreementRisk(ev, peers)
+	b.Score = clamp01(riskWeightVolatility*b.Volatility +
+		riskWeightLiquidity*b.Liquidity +
+		riskWeightHitRate*b.HitRate +
+		riskWeightAgreement*b.Agreement)
+
+	return riskLevelFromScore(b.Score), b
+}
+
+func riskLevelFromScore(score float64) domain.RiskLevel {
+	switch {
+	case score < 0.2:
+		return domain.RiskLevel1
+	case score < 0.4:
+		return domain.RiskLevel2
+	case score < 0.6:
+		return domain.RiskLevel3
+	case score < 0.8:
+		return domain.RiskLevel4
+	default:
+		return domain.RiskLevel5
+	}
+}
+
+// volatilityRisk blends the percentile of the latest ATR% within its own
+// history with its size relative to the interval reference.
+func volatilityRisk(atrPct []float64, interval string) float64 {
+	curr := atrPct[len(atrPct)-1]
+	below := 0
+	for _, v := range atrPct {
+		if v <= curr {
+			below++
+		}
+	}
+	percentile := float64(below) / float64(len(atrPct))
+
+	ref, ok := referenceATRPct[interval]
+	if !ok {
+		ref = referenceATRPct["1h"]
+	}
+	absolute := clamp01(curr / (2 * ref))
+
+	return clamp01(0.5*percentile + 0.5*absolute)
+}
+
+// liquidityRisk compares the latest volume with the median of the prior
+// window; thin volume means wider effective spreads and more slippage.
+func liquidityRisk(candles []domain.Candle) float64 {
+	if len(candles) < volumeWindow+1 {
+		return 0.5
+	}
+	volumes := extractVolumes(candles)
+	window := append([]float64(nil), volumes[len(volumes)-1-volumeWindow:len(volumes)-1]...)
+	sort.Float64s(window)
+	median := window[len(window)/2]
+	if median <= 0 {
+		return 0.5
+	}
+	ratio := volumes[len(volumes)-1] / median
+	// ratio 0 -> 1.0 risk, ratio 1 -> 0.5, ratio >= 2 -> 0.0
+	return clamp01(1 - ratio/2)
+}
+
+// hitRateRisk replays the indicator over the supplied candles and measures how
+// often its direction was right after hitRateHorizon bars. Only candles that
+// already closed before the evaluated bar are used, so there is no lookahead.
+// The rate is shrunk towards 0.5 when there are few samples.
+func hitRateRisk(candles []domain.Candle, indicator string, params domain.SignalParams) (float64, int) {
+	detect, ok := detectors[indicator]
+	if !ok {
+		return 0.5, 0
+	}
+
+	start := len(candles) - 1 - hitRateHorizon - hitRateMaxSamples
+	if start < 2 {
+		start = 2
+	}
+
+	hits, samples := 0, 0
+	for t := start; t < len(candles)-hitRateHorizon; t++ {
+		ev, fired := detect(candles[:t+1], params)
+		if !fired || ev.direction == domain.DirectionHold {
+			continue
+		}
+		entry := candles[t].Close
+		exit := candles[t+hitRateHorizon].Close
+		if entry <= 0 {
+			continue
+		}
+		samples++
+		if (ev.direction == domain.DirectionLong && exit > entry) ||
+			(ev.direction == domain.DirectionShort && exit < entry) {
+			hits++
+		}
+	}
+
+	rate := (float64(hits) + 2) / (float64(samples) + 4)
+	return clamp01(1 - rate), samples
+}
+
+// agreementRisk lowers risk when other indicators on the same candle point
+// the same way and raises it when they conflict.
+func agreementRisk(ev event, peers []event) float64 {
+	if ev.direction == domain.DirectionHold {
+		return 0.5
+	}
+	same, opposing := 0, 0
+	for _, p := range peers {
+		switch {
+		case p.direction == domain.DirectionHold:
+		case p.direction == ev.direction:
+			same++
+		default:
+			opposing++
+		}
+	}
+	return clamp01(0.5 - 0.2*float64(same) + 0.25*float64(opposing))
+}
+
+// atrPctSeries returns Wilder ATR divided by close for every candle after the
+// warm-up period. Candles without a usable high/low fall back to their close.
+func atrPctSeries(candles []domain.Candle, period int) []float64 {
+	if len(candles) <= period {
+		return nil
+	}
+	tr := make([]float64, len(candles))
+	for i := 1; i < len(candles); i++ {
+		high, low := candles[i].High, candles[i].Low
+		if high <= 0 || low <= 0 || high < low {
+			high, low = candles[i].Close, candles[i].Close
+		}
+		prevClose := candles[i-1].Close
+		tr[i] = math.Max(high-low, math.Max(math.Abs(high-prevClose), math.Abs(low-prevClose)))
+	}
+
+	var sum float64
+	for i := 1; i <= period; i++ {
+		sum += tr[i]
+	}
+	atr := sum / float64(period)
+
+	out := make([]float64, 0, len(candles)-period)
+	for i := period; i < len(candles); i++ {
+		if i > period {
+			atr = (atr*float64(period-1) + tr[i]) / float64(period)
+		}
+		if candles[i].Close <= 0 {
+			continue
+		}
+		out = append(out, atr/candles[i].Close)
+	}
+	return out
+}
+
+func clamp01(v float64) float64 {
+	if math.IsNaN(v) {
+		return 0.5
+	}
+	return math.Max(0, math.Min(1, v))
+}
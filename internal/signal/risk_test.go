@@ -0,0 +1,106 @@
+package signal
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+func TestGenerateUsesDynamicRiskWithEnoughHistory(t *testing.T) {
+	engine := NewEngine(func() time.Time { return time.Unix(0, 0).UTC() })
+
+	candles := make([]*domain.Candle, 0, 80)
+	base := time.Unix(0, 0).UTC()
+	for i := 0; i < 80; i++ {
+		closeVal := 100 + float64(i%7)*0.3
+		vol := 100.0 + float64(i%5)
+		if i == 79 {
+			vol = 1000
+		}
+		candles = append(candles, &domain.Candle{
+			Symbol:   "BTC",
+			Interval: "1h",
+			OpenTime: base.Add(time.Duration(i) * time.Hour),
+			High:     closeVal + 0.5,
+			Low:      closeVal - 0.5,
+			Close:    closeVal,
+			Volume:   vol,
+		})
+	}
+
+	signals := engine.Generate(candles)
+	found := false
+	for _, s := range signals {
+		if s.Indicator != domain.IndicatorVolumeZ {
+			continue
+		}
+		found = true
+		if !strings.Contains(s.Details, "risk_model=dynamic") {
+			t.Fatalf("expected dynamic risk breakdown in details, got %q", s.Details)
+		}
+		if !strings.Contains(s.Details, "risk_vol=") || !strings.Contains(s.Details, "risk_hit_n=") {
+			t.Fatalf("expected component scores in details, got %q", s.Details)
+		}
+		if s.Risk < domain.RiskLevel1 || s.Risk > domain.RiskLevel5 {
+			t.Fatalf("risk out of range: %d", s.Risk)
+		}
+	}
+	if !found {
+		t.Fatal("expected volume anomaly signal")
+	}
+}
+
+func TestAssessRiskFallsBackToStaticForShortHistory(t *testing.T) {
+	candles := make([]domain.Candle, 30)
+	for i := range candles {
+		candles[i] = domain.Candle{Interval: "5m", Close: 100, Volume: 100}
+	}
+	risk, breakdown := assessRisk(candles, domain.IndicatorBollinger, event{direction: domain.DirectionLong}, nil, DefaultParams())
+	if risk != riskFor(domain.IndicatorBollinger, "5m") {
+		t.Fatalf("expected static risk, got %d", risk)
+	}
+	if breakdown.String() != "risk_model=static" {
+		t.Fatalf("unexpected breakdown: %q", breakdown.String())
+	}
+}
+
+func TestVolatilityRiskRisesWithATR(t *testing.T) {
+	calm := []float64{0.010, 0.011, 0.012, 0.010, 0.004}
+	wild := []float64{0.010, 0.011, 0.012, 0.010, 0.030}
+	if low, high := volatilityRisk(calm, "1h"), volatilityRisk(wild, "1h"); low >= high {
+		t.Fatalf("expected wider ATR to score riskier: calm=%.3f wild=%.3f", low, high)
+	}
+}
+
+func TestAgreementRisk(t *testing.T) {
+	long := event{direction: domain.DirectionLong}
+	short := event{direction: domain.DirectionShort}
+
+	alone := agreementRisk(long, nil)
+	agreed := agreementRisk(long, []event{long, long})
+	conflicted := agreementRisk(long, []event{short})
+
+	if !(agreed < alone && alone < conflicted) {
+		t.Fatalf("expected agreed < alone < conflicted, got %.2f %.2f %.2f", agreed, alone, conflicted)
+	}
+	if got := agreementRisk(event{direction: domain.DirectionHold}, []event{long}); got != 0.5 {
+		t.Fatalf("expected neutral score for hold, got %.2f", got)
+	}
+}
+
+func TestRiskLevelFromScore(t *testing.T) {
+	cases := map[float64]domain.RiskLevel{
+		0.05: domain.RiskLevel1,
+		0.25: domain.RiskLevel2,
+		0.50: domain.RiskLevel3,
+		0.70: domain.RiskLevel4,
+		0.95: domain.RiskLevel5,
+	}
+	for score, want := range cases {
+		if got := riskLevelFromScore(score); got != want {
+			t.Fatalf("score %.2f: expected %d, got %d", score, want, got)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Package sizing turns an account's risk budget and a trade's stop into a
+// position size.
+package sizing
+
+import (
+	"fmt"
+	"math"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+const (
+	DefaultRiskPerTrade     = 0.01
+	DefaultTargetVolatility = 0.005
+	DefaultKellyFraction    = 0.25
+	DefaultKellyWinRate     = 0.5
+	DefaultMaxPositionPct   = 0.25
+
+	// atrStopMultiple places the fallback stop where signal levels put
+	// their ATR stop.
+	atrStopMultiple = 1.5
+)
+
+// WithDefaults fills the unset fields of cfg.
+func WithDefaults(cfg domain.SizingConfig) domain.SizingConfig {
+	if cfg.Method == "" {
+		cfg.Method = domain.SizingFixedFractional
+	}
+	if cfg.RiskPerTrade <= 0 {
+		cfg.RiskPerTrade = DefaultRiskPerTrade
+	}
+	if cfg.TargetVolatility <= 0 {
+		cfg.TargetVolatility = DefaultTargetVolatility
+	}
+	if cfg.KellyFraction <= 0 {
+		cfg.KellyFraction = DefaultKellyFraction
+	}
+	if cfg.KellyWinRate <= 0 || cfg.KellyWinRate >= 1 {
+		cfg.KellyWinRate = DefaultKellyWinRate
+	}
+	if cfg.MaxPositionPct <= 0 {
+		cfg.MaxPositionPct = DefaultMaxPositionPct
+	}
+	return cfg
+}
+
+// Size sizes one trade under cfg. The loss at the stop never exceeds
+// RiskPerTrade of equity scaled by the risk level, and notional never exceeds
+// MaxPositionPct of equity. A Kelly size with no edge is returned as zero
+// quantity with a note rather than an error.
+func Size(cfg domain.SizingConfig, in domain.SizingInput) (*domain.PositionSize, error) {
+	cfg = WithDefaults(cfg)
+	if !cfg.Method.IsValid() {
+		return nil, fmt.Errorf("unknown sizing method %q", cfg.Method)
+	}
+	if !(in.Equity > 0) || math.IsInf(in.Equity, 0) {
+		return nil, fmt.Errorf("equity must be positive")
+	}
+	if !(in.Entry > 0) || math.IsInf(in.Entry, 0) {
+		return nil, fmt.Errorf("entry price must be positive")
+	}
+	if !in.Risk.IsValid() {
+		return nil, fmt.Errorf("invalid risk level: %d", in.Risk)
+	}
+
+	direction, stop, stopDist, err := resolveStop(in)
+	if err != nil {
+		return nil, err
+	}
+	weight := in.Risk.SizingWeight()
+	budget := cfg.RiskPerTrade * weight
+	out := &domain.PositionSize{
+		Symbol:       in.Symbol,
+		Method:       cfg.Method,
+		Direction:    direction,
+		Risk:         in.Risk,
+		RiskWeight:   weight,
+		Equity:       in.Equity,
+		Entry:        in.Entry,
+		Stop:         stop,
+		StopDistance: stopDist,
+	}
+
+	// riskFrac is the share of equity the method wants to lose at the stop.
+	var riskFrac float64
+	switch cfg.Method {
+	case domain.SizingFixedFractional:
+		riskFrac = budget
+	case domain.SizingVolatilityParity:
+		unit := in.ATR
+		if unit <= 0 {
+			unit = stopDist / atrStopMultiple
+		}
+		riskFrac = cfg.TargetVolatility * weight / unit * stopDist
+	case domain.SizingKelly:
+		if in.RewardRisk <= 0 {
+			return nil, fmt.Errorf("kelly sizing needs a reward/risk ratio")
+		}
+		p := in.WinRate
+		if p <= 0 || p >= 1 {
+			p = cfg.KellyWinRate
+		}
+		full := p - (1-p)/in.RewardRisk
+		if full <= 0 {
+			out.Note = fmt.Sprintf("no edge at a %.0f%% win rate and %.2f reward/risk", p*100, in.RewardRisk)
+			return out, nil
+		}
+		riskFrac = cfg.KellyFraction * full
+	}
+	if riskFrac > budget {
+		riskFrac = budget
+		out.Capped = true
+	}
+
+	qty := in.Equity * riskFrac / stopDist
+	if maxNotional := cfg.MaxPositionPct * in.Equity; qty*in.Entry > maxNotional {
+		qty = maxNotional / in.Entry
+		out.Capped = true
+	}
+	out.Quantity = qty
+	out.Notional = qty * in.Entry
+	out.RiskAmount = qty * stopDist
+	out.RiskFraction = out.RiskAmount / in.Equity
+	out.EquityFraction = out.Notional / in.Equity
+	return out, nil
+}
+
+// resolveStop works out the trade direction and stop. An explicit stop sets
+// the direction when none is given; otherwise the stop is placed
+// atrStopMultiple ATRs from entry, long unless the input says short.
+func resolveStop(in domain.SizingInput) (domain.SignalDirection, float64, float64, error) {
+	direction := in.Direction
+	if direction == domain.DirectionHold {
+		return "", 0, 0, fmt.Errorf("hold signals have no position to size")
+	}
+	if in.Stop > 0 {
+		if in.Stop == in.Entry {
+			return "", 0, 0, fmt.Errorf("stop must differ from entry")
+		}
+		implied := domain.DirectionLong
+		if in.Stop > in.Entry {
+			implied = domain.DirectionShort
+		}
+		if direction != "" && direction != implied {
+			return "", 0, 0, fmt.Errorf("stop is on the wrong side of entry for a %s", direction)
+		}
+		return implied, in.Stop, math.Abs(in.Entry - in.Stop), nil
+	}
+	if !(in.ATR > 0) || math.IsInf(in.ATR, 0) {
+		return "", 0, 0, fmt.Errorf("a stop or ATR is required")
+	}
+	if direction == "" {
+		direction = domain.DirectionLong
+	}
+	dist := atrStopMultiple * in.ATR
+	stop := in.Entry - dist
+	if direction == domain.DirectionShort {
+		stop = in.Entry + dist
+	}
+	return direction, stop, dist, nil
+}
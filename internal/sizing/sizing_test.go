@@ -0,0 +1,142 @@
+package sizing
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestFixedFractionalRisksBudgetAtStop(t *testing.T) {
+	out, err := Size(domain.SizingConfig{MaxPositionPct: 1}, domain.SizingInput{
+		Symbol: "BTC", Equity: 10000, Entry: 100, Stop: 95, Risk: domain.RiskLevel2,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 1% of 10000 over a 5 point stop.
+	if out.Direction != domain.DirectionLong || !approx(out.Quantity, 20) || !approx(out.Notional, 2000) {
+		t.Fatalf("unexpected size: %+v", out)
+	}
+	if !approx(out.RiskAmount, 100) || !approx(out.RiskFraction, 0.01) || out.Capped {
+		t.Fatalf("unexpected risk: %+v", out)
+	}
+}
+
+func TestRiskLevelScalesBudget(t *testing.T) {
+	in := domain.SizingInput{Equity: 10000, Entry: 100, Stop: 105, Risk: domain.RiskLevel4}
+	out, err := Size(domain.SizingConfig{MaxPositionPct: 1}, in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Direction != domain.DirectionShort || !approx(out.RiskWeight, 0.5) || !approx(out.RiskAmount, 50) {
+		t.Fatalf("expected half the budget at risk 4, got %+v", out)
+	}
+}
+
+func TestMaxPositionCapsNotional(t *testing.T) {
+	out, err := Size(domain.SizingConfig{}, domain.SizingInput{
+		Equity: 10000, Entry: 100, Stop: 99.5, Risk: domain.RiskLevel1,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The 1% budget would buy 200 units; the 25% cap allows 2500 notional.
+	if !out.Capped || !approx(out.Notional, 2500) || !approx(out.RiskAmount, 12.5) {
+		t.Fatalf("expected capped position, got %+v", out)
+	}
+}
+
+func TestATRFallbackPlacesStop(t *testing.T) {
+	out, err := Size(domain.SizingConfig{MaxPositionPct: 1}, domain.SizingInput{
+		Equity: 10000, Entry: 100, ATR: 2, Direction: domain.DirectionShort, Risk: domain.RiskLevel1,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !approx(out.Stop, 103) || !approx(out.StopDistance, 3) || !approx(out.RiskAmount, 100) {
+		t.Fatalf("unexpected ATR stop: %+v", out)
+	}
+}
+
+func TestVolatilityParityTargetsATRCost(t *testing.T) {
+	cfg := domain.SizingConfig{Method: domain.SizingVolatilityParity, TargetVolatility: 0.002, MaxPositionPct: 1}
+	out, err := Size(cfg, domain.SizingInput{Equity: 10000, Entry: 100, Stop: 94, ATR: 4, Risk: domain.RiskLevel1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// One ATR move should cost 0.2% of equity: 20 / 4 = 5 units.
+	if !approx(out.Quantity, 5) || out.Capped {
+		t.Fatalf("unexpected volatility parity size: %+v", out)
+	}
+
+	cfg.TargetVolatility = 0.05
+	out, err = Size(cfg, domain.SizingInput{Equity: 10000, Entry: 100, Stop: 94, ATR: 4, Risk: domain.RiskLevel1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !out.Capped || !approx(out.RiskAmount, 100) {
+		t.Fatalf("expected the risk budget to cap volatility parity, got %+v", out)
+	}
+}
+
+func TestKellyCappedByRiskLevel(t *testing.T) {
+	cfg := domain.SizingConfig{Method: domain.SizingKelly, RiskPerTrade: 0.02, KellyFraction: 0.25, MaxPositionPct: 1}
+	in := domain.SizingInput{Equity: 10000, Entry: 100, Stop: 90, Risk: domain.RiskLevel1, RewardRisk: 2, WinRate: 0.4}
+
+	// Full Kelly is 0.4 - 0.6/2 = 0.1; a quarter of it risks 2.5%, over the 2% budget.
+	out, err := Size(cfg, in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !out.Capped || !approx(out.RiskFraction, 0.02) {
+		t.Fatalf("expected Kelly capped at the budget, got %+v", out)
+	}
+
+	in.Risk = domain.RiskLevel5
+	out, err = Size(cfg, in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !approx(out.RiskFraction, 0.005) {
+		t.Fatalf("expected risk level 5 to quarter the cap, got %+v", out)
+	}
+
+	in.Risk = domain.RiskLevel1
+	in.WinRate = 0.3
+	out, err = Size(cfg, in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Quantity != 0 || !strings.Contains(out.Note, "no edge") {
+		t.Fatalf("expected no position without an edge, got %+v", out)
+	}
+
+	in.RewardRisk = 0
+	if _, err := Size(cfg, in); err == nil {
+		t.Fatal("expected Kelly without reward/risk to fail")
+	}
+}
+
+func TestSizeRejectsBadInput(t *testing.T) {
+	cases := []domain.SizingInput{
+		{Equity: 0, Entry: 100, Stop: 95, Risk: 1},
+		{Equity: 1000, Entry: 0, Stop: 95, Risk: 1},
+		{Equity: 1000, Entry: 100, Stop: 95, Risk: 0},
+		{Equity: 1000, Entry: 100, Risk: 1},
+		{Equity: 1000, Entry: 100, Stop: 100, Risk: 1},
+		{Equity: 1000, Entry: 100, Stop: 95, Risk: 1, Direction: domain.DirectionShort},
+		{Equity: 1000, Entry: 100, ATR: 1, Risk: 1, Direction: domain.DirectionHold},
+	}
+	for i, in := range cases {
+		if _, err := Size(domain.SizingConfig{}, in); err == nil {
+			t.Fatalf("case %d: expected error", i)
+		}
+	}
+	if _, err := Size(domain.SizingConfig{Method: "martingale"}, domain.SizingInput{Equity: 1, Entry: 1, ATR: 1, Risk: 1}); err == nil {
+		t.Fatal("expected unknown method to fail")
+	}
+}
@@ -0,0 +1,242 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/pricealert"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Alerts message types.
+type priceAlertsMsg struct{ rules []domain.PriceAlertRule }
+type priceAlertsErrMsg struct{ err error }
+
+// AlertsModel is the Bubble Tea model for the SSH user's price alert rules.
+type AlertsModel struct {
+	services Services
+	rules    []domain.PriceAlertRule
+	cursor   int
+	input    textinput.Model
+	editing  bool
+	loading  bool
+	err      error
+	width    int
+	height   int
+}
+
+// NewAlertsModel creates a new alerts model.
+func NewAlertsModel(svc Services) AlertsModel {
+	ti := textinput.New()
+	ti.Placeholder = "ETH above 4000 | SOL move 5% 1h down | BTC volume 50% 1h recurring"
+	ti.CharLimit = 100
+	ti.Width = 70
+
+	return AlertsModel{
+		services: svc,
+		input:    ti,
+		loading:  true,
+	}
+}
+
+// Init fires the initial rule fetch.
+func (m AlertsModel) Init() tea.Cmd {
+	return m.fetchCmd()
+}
+
+// Update handles incoming messages.
+func (m AlertsModel) Update(msg tea.Msg) (AlertsModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case priceAlertsMsg:
+		m.rules = msg.rules
+		m.loading = false
+		m.err = nil
+		if m.cursor >= len(m.rules) {
+			m.cursor = max(len(m.rules)-1, 0)
+		}
+		return m, nil
+
+	case priceAlertsErrMsg:
+		m.err = msg.err
+		m.loading = false
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.editing {
+			return m.updateInput(msg)
+		}
+		switch {
+		case key.Matches(msg, DefaultKeyMap.AddAlert):
+			if m.services.Alerts == nil {
+				return m, nil
+			}
+			m.editing = true
+			m.err = nil
+			m.input.SetValue("")
+			return m, m.input.Focus()
+
+		case key.Matches(msg, DefaultKeyMap.DeleteAlert):
+			if m.cursor >= len(m.rules) {
+				return m, nil
+			}
+			m.loading = true
+			return m, m.deleteCmd(m.rules[m.cursor].ID)
+
+		case key.Matches(msg, DefaultKeyMap.Refresh):
+			m.loading = true
+			return m, m.fetchCmd()
+
+		case msg.String() == "j" || msg.String() == "down":
+			if m.cursor < len(m.rules)-1 {
+				m.cursor++
+			}
+			return m, nil
+
+		case msg.String() == "k" || msg.String() == "up":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+			return m, nil
+		}
+	}
+
+	return m, nil
+}
+
+func (m AlertsModel) updateInput(msg tea.KeyMsg) (AlertsModel, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.editing = false
+		m.input.Blur()
+		return m, nil
+	case tea.KeyEnter:
+		rule, err := pricealert.ParseRule(strings.Fields(m.input.Value()))
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.editing = false
+		m.input.Blur()
+		m.loading = true
+		return m, m.createCmd(rule)
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// View renders the alerts screen.
+func (m AlertsModel) View() string {
+	var sections []string
+	sections = append(sections, HeaderStyle.Render("  Price Alerts"))
+	sections = append(sections, "")
+
+	if m.services.Alerts == nil {
+		sections = append(sections, SubtextStyle.Render("  Price alerts not available"))
+		return strings.Join(sections, "\n")
+	}
+	if m.editing {
+		sections = append(sections, "  New alert: "+m.input.View())
+		if m.err != nil {
+			sections = append(sections, ErrorStyle.Render(fmt.Sprintf("  %v", m.err)))
+		}
+		sections = append(sections, SubtextStyle.Render("  [enter] add  [esc] cancel"))
+		return strings.Join(sections, "\n")
+	}
+	if m.loading {
+		sections = append(sections, SubtextStyle.Render("  Loading..."))
+		return strings.Join(sections, "\n")
+	}
+	if m.err != nil {
+		sections = append(sections, ErrorStyle.Render(fmt.Sprintf("  Error: %v", m.err)))
+		sections = append(sections, "")
+	}
+	if len(m.rules) == 0 {
+		sections = append(sections, SubtextStyle.Render("  No price alerts yet"))
+	} else {
+		sections = append(sections, SubtextStyle.Render(
+			fmt.Sprintf("     %-5s %-56s %-10s %6s  %s", "ID", "Rule", "State", "Fired", "Last"),
+		))
+		sections = append(sections, SubtextStyle.Render(strings.Repeat("─", max(m.width-2, 0))))
+		for i, r := range m.rules {
+			cursor := " "
+			if i == m.cursor {
+				cursor = ">"
+			}
+			last := "-"
+			if r.LastTriggeredAt != nil {
+				last = r.LastTriggeredAt.Local().Format("Jan 02 15:04")
+			}
+			sections = append(sections, fmt.Sprintf("  %s  %-5d %-56s %-10s %6d  %s",
+				cursor, r.ID, pricealert.Describe(r), priceAlertState(r), r.TriggerCount, last))
+		}
+	}
+
+	sections = append(sections, "")
+	sections = append(sections, SubtextStyle.Render("  [a] add  [d] delete  [j/k] select  [R] refresh"))
+	return strings.Join(sections, "\n")
+}
+
+func priceAlertState(r domain.PriceAlertRule) string {
+	switch {
+	case !r.Active:
+		return "done"
+	case !r.Armed:
+		return "re-arming"
+	}
+	return "armed"
+}
+
+// SetSize updates the model dimensions.
+func (m *AlertsModel) SetSize(w, h int) {
+	m.width = w
+	m.height = h
+}
+
+// Editing reports whether the new-rule input has focus, so the app leaves
+// keys like digits and q to it.
+func (m AlertsModel) Editing() bool { return m.editing }
+
+// Rules returns the displayed rules (for testing).
+func (m AlertsModel) Rules() []domain.PriceAlertRule { return m.rules }
+
+func (m AlertsModel) fetchCmd() tea.Cmd {
+	chatID := m.services.ChatID()
+	return func() tea.Msg {
+		if m.services.Alerts == nil {
+			return nil
+		}
+		rules, err := m.services.Alerts.ListRules(context.Background(), chatID)
+		if err != nil {
+			return priceAlertsErrMsg{err: err}
+		}
+		return priceAlertsMsg{rules: rules}
+	}
+}
+
+func (m AlertsModel) createCmd(rule domain.PriceAlertRule) tea.Cmd {
+	rule.ChatID = m.services.ChatID()
+	fetch := m.fetchCmd()
+	return func() tea.Msg {
+		if _, err := m.services.Alerts.CreateRule(context.Background(), rule); err != nil {
+			return priceAlertsErrMsg{err: err}
+		}
+		return fetch()
+	}
+}
+
+func (m AlertsModel) deleteCmd(id int64) tea.Cmd {
+	chatID := m.services.ChatID()
+	fetch := m.fetchCmd()
+	return func() tea.Msg {
+		if err := m.services.Alerts.DeleteRule(context.Background(), chatID, id); err != nil {
+			return priceAlertsErrMsg{err: err}
+		}
+		return fetch()
+	}
+}
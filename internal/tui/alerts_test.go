@@ -0,0 +1,117 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"bug-free-umbrella/internal/domain"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type stubPriceAlertQuerier struct {
+	rules   []domain.PriceAlertRule
+	chatIDs []int64
+	err     error
+}
+
+func (s *stubPriceAlertQuerier) ListRules(ctx context.Context, chatID int64) ([]domain.PriceAlertRule, error) {
+	s.chatIDs = append(s.chatIDs, chatID)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.rules, nil
+}
+
+func (s *stubPriceAlertQuerier) CreateRule(ctx context.Context, rule domain.PriceAlertRule) (*domain.PriceAlertRule, error) {
+	rule.ID = int64(len(s.rules) + 1)
+	rule.Active, rule.Armed = true, true
+	s.rules = append(s.rules, rule)
+	return &rule, nil
+}
+
+func (s *stubPriceAlertQuerier) DeleteRule(ctx context.Context, chatID, id int64) error {
+	for i, r := range s.rules {
+		if r.ID == id {
+			s.rules = append(s.rules[:i], s.rules[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("not found")
+}
+
+func typeRunes(m AlertsModel, text string) AlertsModel {
+	for _, r := range text {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return m
+}
+
+func TestAlertsModelAddListDelete(t *testing.T) {
+	alerts := &stubPriceAlertQuerier{}
+	svc := testServices()
+	svc.Alerts = alerts
+	m := NewAlertsModel(svc)
+	m.SetSize(140, 40)
+
+	m, _ = m.Update(m.Init()())
+	if !strings.Contains(m.View(), "No price alerts yet") || alerts.chatIDs[0] != svc.ChatID() {
+		t.Fatalf("expected empty list for chat %d, got:\n%s", svc.ChatID(), m.View())
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	if !m.Editing() {
+		t.Fatal("expected a to open the rule input")
+	}
+	m = typeRunes(m, "ETH sideways 3")
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd != nil || !m.Editing() || !strings.Contains(m.View(), "condition must be") {
+		t.Fatalf("expected parse error to keep the input open, got:\n%s", m.View())
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	m = typeRunes(m, "ETH above 4000 recurring")
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = m.Update(cmd())
+	if len(m.Rules()) != 1 || m.Rules()[0].ChatID != svc.ChatID() || !m.Rules()[0].Recurring {
+		t.Fatalf("unexpected rules: %+v", m.Rules())
+	}
+	if view := m.View(); !strings.Contains(view, "ETH crosses above $4000 (recurring)") || !strings.Contains(view, "armed") {
+		t.Fatalf("expected rule in view:\n%s", view)
+	}
+
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	m, _ = m.Update(cmd())
+	if len(m.Rules()) != 0 {
+		t.Fatalf("expected rule deleted, got %+v", m.Rules())
+	}
+}
+
+func TestAlertsModelUnavailable(t *testing.T) {
+	m := NewAlertsModel(testServices())
+	if !strings.Contains(m.View(), "not available") {
+		t.Fatalf("expected unavailable message, got:\n%s", m.View())
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	if m.Editing() {
+		t.Fatal("input should not open without an alert service")
+	}
+}
+
+func TestAppModelAlertInputKeepsDigits(t *testing.T) {
+	svc := testServices()
+	svc.Alerts = &stubPriceAlertQuerier{}
+	m := NewAppModel(svc)
+	m.SetSize(120, 40)
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'7'}})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'1'}})
+	app := updated.(AppModel)
+	if app.ActiveTab() != TabAlerts {
+		t.Fatalf("digits typed into the alert input should not switch tabs, got %d", app.ActiveTab())
+	}
+}
@@ -14,9 +14,12 @@ const (
 	TabChat
 	TabSignals
 	TabBacktest
+	TabPaper
+	TabHoldings
+	TabAlerts
 )
 
-var tabNames = []string{"1:Dashboard", "2:Chat", "3:Signals", "4:Backtest"}
+var tabNames = []string{"1:Dashboard", "2:Chat", "3:Signals", "4:Backtest", "5:Paper", "6:Holdings", "7:Alerts"}
 
 // AppModel is the root Bubble Tea model that manages tab navigation and child screens.
 type AppModel struct {
@@ -26,6 +29,9 @@ type AppModel struct {
 	chat      ChatModel
 	signals   SignalExplorerModel
 	backtest  BacktestModel
+	paper     PaperModel
+	holdings  HoldingsModel
+	alerts    AlertsModel
 	width     int
 	height    int
 	quitting  bool
@@ -40,6 +46,9 @@ func NewAppModel(svc Services) AppModel {
 		chat:      NewChatModel(svc),
 		signals:   NewSignalExplorerModel(svc),
 		backtest:  NewBacktestModel(svc),
+		paper:     NewPaperModel(svc),
+		holdings:  NewHoldingsModel(svc),
+		alerts:    NewAlertsModel(svc),
 	}
 }
 
@@ -50,6 +59,9 @@ func (m AppModel) Init() tea.Cmd {
 		m.chat.Init(),
 		m.signals.Init(),
 		m.backtest.Init(),
+		m.paper.Init(),
+		m.holdings.Init(),
+		m.alerts.Init(),
 	)
 }
 
@@ -63,9 +75,16 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
+		// The alert rule input takes every key but ctrl+c, digits included.
+		if m.activeTab == TabAlerts && m.alerts.Editing() && msg.String() != "ctrl+c" {
+			var cmd tea.Cmd
+			m.alerts, cmd = m.alerts.Update(msg)
+			return m, cmd
+		}
+
 		// Global key bindings (except in chat when input is focused)
 		if m.activeTab != TabChat || msg.Type == tea.KeyTab || msg.Type == tea.KeyShiftTab ||
-			msg.String() == "ctrl+c" || (msg.String() >= "1" && msg.String() <= "4") {
+			msg.String() == "ctrl+c" || (msg.String() >= "1" && msg.String() <= "7") {
 
 			switch {
 			case key.Matches(msg, DefaultKeyMap.Quit):
@@ -100,6 +119,15 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case msg.String() == "4":
 				m.switchTab(TabBacktest)
 				return m, nil
+			case msg.String() == "5":
+				m.switchTab(TabPaper)
+				return m, nil
+			case msg.String() == "6":
+				m.switchTab(TabHoldings)
+				return m, nil
+			case msg.String() == "7":
+				m.switchTab(TabAlerts)
+				return m, nil
 			}
 		}
 	}
@@ -118,12 +146,29 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.signals, cmd = m.signals.Update(msg)
 		cmds = append(cmds, cmd)
 
-	case backtestSummaryMsg, backtestDailyMsg, backtestPredictionsMsg, backtestErrMsg:
+	case backtestSummaryMsg, backtestDailyMsg, backtestPredictionsMsg, backtestErrMsg,
+		backtestRunsMsg, backtestRunDetailMsg, backtestRunErrMsg,
+		backtestAnalyticsMsg, backtestAnalyticsErrMsg:
 		var cmd tea.Cmd
 		m.backtest, cmd = m.backtest.Update(msg)
 		cmds = append(cmds, cmd)
 
-	case advisorReplyMsg, advisorErrMsg:
+	case paperAccountsMsg, paperPortfolioMsg, paperErrMsg:
+		var cmd tea.Cmd
+		m.paper, cmd = m.paper.Update(msg)
+		cmds = append(cmds, cmd)
+
+	case holdingsMsg, holdingsErrMsg:
+		var cmd tea.Cmd
+		m.holdings, cmd = m.holdings.Update(msg)
+		cmds = append(cmds, cmd)
+
+	case priceAlertsMsg, priceAlertsErrMsg:
+		var cmd tea.Cmd
+		m.alerts, cmd = m.alerts.Update(msg)
+		cmds = append(cmds, cmd)
+
+	case advisorDeltaMsg, advisorReplyMsg, advisorErrMsg:
 		var cmd tea.Cmd
 		m.chat, cmd = m.chat.Update(msg)
 		cmds = append(cmds, cmd)
@@ -147,6 +192,18 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			var cmd tea.Cmd
 			m.backtest, cmd = m.backtest.Update(msg)
 			cmds = append(cmds, cmd)
+		case TabPaper:
+			var cmd tea.Cmd
+			m.paper, cmd = m.paper.Update(msg)
+			cmds = append(cmds, cmd)
+		case TabHoldings:
+			var cmd tea.Cmd
+			m.holdings, cmd = m.holdings.Update(msg)
+			cmds = append(cmds, cmd)
+		case TabAlerts:
+			var cmd tea.Cmd
+			m.alerts, cmd = m.alerts.Update(msg)
+			cmds = append(cmds, cmd)
 		}
 	}
 
@@ -171,6 +228,12 @@ func (m AppModel) View() string {
 		content = m.signals.View()
 	case TabBacktest:
 		content = m.backtest.View()
+	case TabPaper:
+		content = m.paper.View()
+	case TabHoldings:
+		content = m.holdings.View()
+	case TabAlerts:
+		content = m.alerts.View()
 	}
 
 	return lipgloss.JoinVertical(lipgloss.Left, tabBar, content)
@@ -201,6 +264,9 @@ func (m *AppModel) propagateSize() {
 	m.chat.SetSize(m.width, contentHeight)
 	m.signals.SetSize(m.width, contentHeight)
 	m.backtest.SetSize(m.width, contentHeight)
+	m.paper.SetSize(m.width, contentHeight)
+	m.holdings.SetSize(m.width, contentHeight)
+	m.alerts.SetSize(m.width, contentHeight)
 }
 
 func (m AppModel) renderTabBar() string {
@@ -35,7 +35,10 @@ type stubAdvisorQuerier struct {
 	err   error
 }
 
-func (s *stubAdvisorQuerier) Ask(ctx context.Context, chatID int64, message string) (string, error) {
+func (s *stubAdvisorQuerier) AskStream(ctx context.Context, chatID int64, message string, onDelta func(string)) (string, error) {
+	if s.err == nil && onDelta != nil {
+		onDelta(s.reply)
+	}
 	return s.reply, s.err
 }
 
@@ -101,6 +104,27 @@ func TestAppModelTabSwitchByNumber(t *testing.T) {
 		t.Fatalf("expected TabBacktest after pressing 4, got %d", app.ActiveTab())
 	}
 
+	// Press '5' to switch to paper trading
+	updated, _ = app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'5'}})
+	app = updated.(AppModel)
+	if app.ActiveTab() != TabPaper {
+		t.Fatalf("expected TabPaper after pressing 5, got %d", app.ActiveTab())
+	}
+
+	// Press '6' to switch to holdings
+	updated, _ = app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'6'}})
+	app = updated.(AppModel)
+	if app.ActiveTab() != TabHoldings {
+		t.Fatalf("expected TabHoldings after pressing 6, got %d", app.ActiveTab())
+	}
+
+	// Press '7' to switch to alerts
+	updated, _ = app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'7'}})
+	app = updated.(AppModel)
+	if app.ActiveTab() != TabAlerts {
+		t.Fatalf("expected TabAlerts after pressing 7, got %d", app.ActiveTab())
+	}
+
 	// Press '1' to switch back to dashboard
 	updated, _ = app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'1'}})
 	app = updated.(AppModel)
@@ -143,7 +167,7 @@ func TestAppModelViewRendersWithoutPanic(t *testing.T) {
 	m.SetSize(120, 40)
 
 	// Render all tabs without panicking
-	for _, tab := range []Tab{TabDashboard, TabChat, TabSignals, TabBacktest} {
+	for _, tab := range []Tab{TabDashboard, TabChat, TabSignals, TabBacktest, TabPaper, TabHoldings} {
 		m.activeTab = tab
 		view := m.View()
 		if view == "" {
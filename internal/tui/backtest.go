@@ -10,6 +10,7 @@ import (
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
 // Backtest message types.
@@ -17,29 +18,62 @@ type backtestSummaryMsg []repository.DailyAccuracy
 type backtestDailyMsg []repository.DailyAccuracy
 type backtestPredictionsMsg []domain.MLPrediction
 type backtestErrMsg struct{ err error }
+type backtestRunsMsg []domain.BacktestRun
+type backtestRunDetailMsg struct{ run *domain.BacktestRun }
+type backtestRunErrMsg struct{ err error }
+type backtestAnalyticsMsg struct{ report *domain.MLAnalyticsReport }
+type backtestAnalyticsErrMsg struct{ err error }
 
 const (
 	backtestViewAccuracy    = 0
 	backtestViewPredictions = 1
+	backtestViewRuns        = 2
+	backtestViewAnalytics   = 3
 )
 
+const (
+	backtestRunListLimit = 50
+	maxOverlayRuns       = 4
+	maxAnalyticsCurves   = 4
+)
+
+// analyticsGroupings are the slices the analytics view cycles through.
+var analyticsGroupings = [][]string{
+	{domain.MLGroupModel},
+	{domain.MLGroupModel, domain.MLGroupSymbol},
+	{domain.MLGroupSymbol},
+	{domain.MLGroupInterval},
+	{domain.MLGroupDirection},
+	{domain.MLGroupRisk},
+	{domain.MLGroupConfidence},
+}
+
 // BacktestModel is the Bubble Tea model for the backtest viewer screen.
 type BacktestModel struct {
-	services    Services
-	summary     []repository.DailyAccuracy
-	daily       []repository.DailyAccuracy
-	predictions []domain.MLPrediction
-	activeView  int
-	loading     bool
-	err         error
-	width       int
-	height      int
+	services     Services
+	summary      []repository.DailyAccuracy
+	daily        []repository.DailyAccuracy
+	predictions  []domain.MLPrediction
+	runs         []domain.BacktestRun
+	runCursor    int
+	selected     []int64
+	curves       map[int64][]domain.EquityPoint
+	runsErr      error
+	analytics    *domain.MLAnalyticsReport
+	analyticsErr error
+	grouping     int
+	activeView   int
+	loading      bool
+	err          error
+	width        int
+	height       int
 }
 
 // NewBacktestModel creates a new backtest viewer model.
 func NewBacktestModel(svc Services) BacktestModel {
 	return BacktestModel{
 		services: svc,
+		curves:   make(map[int64][]domain.EquityPoint),
 		loading:  true,
 	}
 }
@@ -50,6 +84,8 @@ func (m BacktestModel) Init() tea.Cmd {
 		m.fetchSummaryCmd(),
 		m.fetchDailyCmd(),
 		m.fetchPredictionsCmd(),
+		m.fetchRunsCmd(),
+		m.fetchAnalyticsCmd(),
 	)
 }
 
@@ -74,10 +110,55 @@ func (m BacktestModel) Update(msg tea.Msg) (BacktestModel, tea.Cmd) {
 		m.loading = false
 		return m, nil
 
+	case backtestRunsMsg:
+		m.runs = []domain.BacktestRun(msg)
+		m.runsErr = nil
+		if m.runCursor >= len(m.runs) {
+			m.runCursor = 0
+		}
+		return m, nil
+
+	case backtestRunDetailMsg:
+		m.curves[msg.run.ID] = msg.run.Equity
+		return m, nil
+
+	case backtestRunErrMsg:
+		m.runsErr = msg.err
+		return m, nil
+
+	case backtestAnalyticsMsg:
+		m.analytics = msg.report
+		m.analyticsErr = nil
+		return m, nil
+
+	case backtestAnalyticsErrMsg:
+		m.analyticsErr = msg.err
+		return m, nil
+
 	case tea.KeyMsg:
 		switch {
 		case key.Matches(msg, DefaultKeyMap.ToggleView):
-			m.activeView = 1 - m.activeView
+			if m.activeView == backtestViewRuns || m.activeView == backtestViewAnalytics {
+				m.activeView = backtestViewAccuracy
+			} else {
+				m.activeView = 1 - m.activeView
+			}
+			return m, nil
+
+		case key.Matches(msg, DefaultKeyMap.RunsView):
+			if m.activeView == backtestViewRuns {
+				m.activeView = backtestViewAccuracy
+			} else {
+				m.activeView = backtestViewRuns
+			}
+			return m, nil
+
+		case key.Matches(msg, DefaultKeyMap.AnalyticsView):
+			if m.activeView == backtestViewAnalytics {
+				m.activeView = backtestViewAccuracy
+			} else {
+				m.activeView = backtestViewAnalytics
+			}
 			return m, nil
 
 		case key.Matches(msg, DefaultKeyMap.Refresh):
@@ -86,8 +167,32 @@ func (m BacktestModel) Update(msg tea.Msg) (BacktestModel, tea.Cmd) {
 				m.fetchSummaryCmd(),
 				m.fetchDailyCmd(),
 				m.fetchPredictionsCmd(),
+				m.fetchRunsCmd(),
+				m.fetchAnalyticsCmd(),
 			)
 		}
+
+		if m.activeView == backtestViewAnalytics && key.Matches(msg, DefaultKeyMap.AnalyticsGroup) {
+			m.grouping = (m.grouping + 1) % len(analyticsGroupings)
+			return m, m.fetchAnalyticsCmd()
+		}
+
+		if m.activeView == backtestViewRuns {
+			switch {
+			case msg.String() == "j" || msg.String() == "down":
+				if m.runCursor < len(m.runs)-1 {
+					m.runCursor++
+				}
+				return m, nil
+			case msg.String() == "k" || msg.String() == "up":
+				if m.runCursor > 0 {
+					m.runCursor--
+				}
+				return m, nil
+			case key.Matches(msg, DefaultKeyMap.SelectRun):
+				return m.toggleRunSelection()
+			}
+		}
 	}
 
 	return m, nil
@@ -98,31 +203,49 @@ func (m BacktestModel) View() string {
 	var sections []string
 
 	// Header with view toggle
-	viewLabel := "[Accuracy]  Predictions"
-	if m.activeView == backtestViewPredictions {
-		viewLabel = " Accuracy  [Predictions]"
+	viewLabel := "[Accuracy]  Predictions   Runs   Analytics "
+	switch m.activeView {
+	case backtestViewPredictions:
+		viewLabel = " Accuracy  [Predictions]  Runs   Analytics "
+	case backtestViewRuns:
+		viewLabel = " Accuracy   Predictions  [Runs]  Analytics "
+	case backtestViewAnalytics:
+		viewLabel = " Accuracy   Predictions   Runs  [Analytics]"
 	}
 	sections = append(sections, HeaderStyle.Render("  Backtest Viewer")+"  "+SubtextStyle.Render(viewLabel))
 	sections = append(sections, "")
 
-	if m.loading {
+	ownData := m.activeView == backtestViewRuns || m.activeView == backtestViewAnalytics
+	if m.loading && !ownData {
 		sections = append(sections, SubtextStyle.Render("  Loading backtest data..."))
 		return strings.Join(sections, "\n")
 	}
 
-	if m.err != nil {
+	if m.err != nil && !ownData {
 		sections = append(sections, ErrorStyle.Render(fmt.Sprintf("  Error: %v", m.err)))
 		return strings.Join(sections, "\n")
 	}
 
-	if m.activeView == backtestViewAccuracy {
+	switch m.activeView {
+	case backtestViewAccuracy:
 		sections = append(sections, m.renderAccuracyView()...)
-	} else {
+	case backtestViewPredictions:
 		sections = append(sections, m.renderPredictionsView()...)
+	case backtestViewRuns:
+		sections = append(sections, m.renderRunsView()...)
+	case backtestViewAnalytics:
+		sections = append(sections, m.renderAnalyticsView()...)
 	}
 
 	sections = append(sections, "")
-	sections = append(sections, SubtextStyle.Render("  [v] toggle view  [R] refresh"))
+	switch m.activeView {
+	case backtestViewRuns:
+		sections = append(sections, SubtextStyle.Render("  [j/k] move  [space] overlay run  [b] back  [R] refresh"))
+	case backtestViewAnalytics:
+		sections = append(sections, SubtextStyle.Render("  [g] cycle grouping  [a] back  [R] refresh"))
+	default:
+		sections = append(sections, SubtextStyle.Render("  [v] toggle view  [b] strategy runs  [a] analytics  [R] refresh"))
+	}
 
 	return strings.Join(sections, "\n")
 }
@@ -141,6 +264,35 @@ func (m BacktestModel) HasData() bool {
 	return len(m.summary) > 0 || len(m.daily) > 0 || len(m.predictions) > 0
 }
 
+// Grouping returns the analytics dimensions currently selected.
+func (m BacktestModel) Grouping() []string { return analyticsGroupings[m.grouping] }
+
+// SelectedRuns returns the run IDs picked for the equity overlay (for testing).
+func (m BacktestModel) SelectedRuns() []int64 { return m.selected }
+
+// toggleRunSelection adds or removes the run under the cursor from the
+// overlay, fetching its equity curve the first time it is picked.
+func (m BacktestModel) toggleRunSelection() (BacktestModel, tea.Cmd) {
+	if m.runCursor >= len(m.runs) {
+		return m, nil
+	}
+	id := m.runs[m.runCursor].ID
+	for i, sel := range m.selected {
+		if sel == id {
+			m.selected = append(append([]int64(nil), m.selected[:i]...), m.selected[i+1:]...)
+			return m, nil
+		}
+	}
+	if len(m.selected) >= maxOverlayRuns {
+		m.selected = m.selected[1:]
+	}
+	m.selected = append(append([]int64(nil), m.selected...), id)
+	if _, ok := m.curves[id]; ok {
+		return m, nil
+	}
+	return m, m.fetchRunCmd(id)
+}
+
 func (m BacktestModel) renderAccuracyView() []string {
 	var lines []string
 
@@ -276,6 +428,245 @@ func (m BacktestModel) renderPredictionsView() []string {
 	return lines
 }
 
+func (m BacktestModel) renderRunsView() []string {
+	var lines []string
+
+	if m.runsErr != nil {
+		lines = append(lines, ErrorStyle.Render(fmt.Sprintf("  Error: %v", m.runsErr)), "")
+	}
+	if len(m.runs) == 0 {
+		lines = append(lines, SubtextStyle.Render("  No stored strategy runs. Start one with POST /api/backtest/run or /backtest in the web console."))
+		return lines
+	}
+
+	lines = append(lines, HeaderStyle.Render("  Strategy Backtest Runs"))
+	lines = append(lines, "")
+	lines = append(lines, SubtextStyle.Render(
+		fmt.Sprintf("     %-5s %-6s %-4s %-23s %6s %9s %8s %7s  %s",
+			"ID", "Symbol", "Int", "Window", "Trades", "Return", "MaxDD", "Sharpe", "Engine"),
+	))
+	lines = append(lines, SubtextStyle.Render("  "+strings.Repeat("─", 90)))
+
+	maxRows := m.height/2 - 6
+	if maxRows < 5 {
+		maxRows = 5
+	}
+	first := 0
+	if m.runCursor >= maxRows {
+		first = m.runCursor - maxRows + 1
+	}
+	last := first + maxRows
+	if last > len(m.runs) {
+		last = len(m.runs)
+	}
+
+	for i := first; i < last; i++ {
+		r := m.runs[i]
+		cursor := " "
+		if i == m.runCursor {
+			cursor = ">"
+		}
+		mark := "[ ]"
+		if idx := m.selectedIndex(r.ID); idx >= 0 {
+			mark = lipgloss.NewStyle().Foreground(OverlayColors[idx%len(OverlayColors)]).Render("[●]")
+		}
+		returnStyle := PriceUpStyle
+		if r.Metrics.TotalReturn < 0 {
+			returnStyle = PriceDownStyle
+		}
+		lines = append(lines, fmt.Sprintf("  %s%s %-5d %-6s %-4s %-23s %6d %s %7.2f%% %7.2f  %s",
+			cursor, mark,
+			r.ID,
+			r.Config.Symbol,
+			r.Config.Interval,
+			r.Config.From.Format("2006-01-02")+" → "+r.Config.To.Format("2006-01-02"),
+			r.Metrics.Trades,
+			returnStyle.Render(fmt.Sprintf("%+8.2f%%", r.Metrics.TotalReturn*100)),
+			r.Metrics.MaxDrawdown*100,
+			r.Metrics.Sharpe,
+			r.EngineVersion,
+		))
+	}
+
+	curves := make([]EquityCurve, 0, len(m.selected))
+	for _, id := range m.selected {
+		if points, ok := m.curves[id]; ok {
+			curves = append(curves, EquityCurve{Label: m.runLabel(id), Points: points})
+		}
+	}
+	if len(curves) == 0 {
+		lines = append(lines, "", SubtextStyle.Render("  Select runs with [space] to overlay their equity curves."))
+		return lines
+	}
+
+	chartHeight := m.height - len(lines) - 10
+	if chartHeight < 6 {
+		chartHeight = 6
+	}
+	if chartHeight > 16 {
+		chartHeight = 16
+	}
+	lines = append(lines, "", HeaderStyle.Render("  Equity Overlay (% return)"), "")
+	for _, l := range RenderEquityOverlay(curves, m.width-2, chartHeight) {
+		lines = append(lines, "  "+l)
+	}
+	return lines
+}
+
+func (m BacktestModel) renderAnalyticsView() []string {
+	var lines []string
+
+	if m.analyticsErr != nil {
+		lines = append(lines, ErrorStyle.Render(fmt.Sprintf("  Error: %v", m.analyticsErr)), "")
+	}
+	grouping := strings.Join(m.Grouping(), " / ")
+	if m.analytics == nil || len(m.analytics.Groups) == 0 {
+		lines = append(lines, SubtextStyle.Render("  No resolved predictions to analyse by "+grouping+"."))
+		return lines
+	}
+	r := m.analytics
+
+	lines = append(lines, HeaderStyle.Render(fmt.Sprintf("  Prediction Analytics by %s (%s → %s)",
+		grouping, r.Query.From.Format("2006-01-02"), r.Query.To.Format("2006-01-02"))))
+	if r.Truncated {
+		lines = append(lines, SubtextStyle.Render("  Limited to the most recent predictions."))
+	}
+	lines = append(lines, "")
+	lines = append(lines, SubtextStyle.Render(
+		fmt.Sprintf("  %-32s %6s %6s %6s %6s %6s %7s %9s",
+			"Group", "N", "Acc", "Prec", "Recall", "Brier", "LogLoss", "CumRet"),
+	))
+	lines = append(lines, SubtextStyle.Render("  "+strings.Repeat("─", 86)))
+
+	maxRows := m.height/2 - 8
+	if maxRows < 5 {
+		maxRows = 5
+	}
+	rows := append([]domain.MLAnalyticsGroup{r.Overall}, r.Groups...)
+	if len(rows) > maxRows {
+		rows = rows[:maxRows]
+	}
+	for _, g := range rows {
+		mt := g.Metrics
+		accStyle := AccuracyGoodStyle
+		if mt.Accuracy < 0.5 {
+			accStyle = AccuracyBadStyle
+		} else if mt.Accuracy < 0.55 {
+			accStyle = AccuracyOkStyle
+		}
+		returnStyle := PriceUpStyle
+		if mt.CumulativeReturn < 0 {
+			returnStyle = PriceDownStyle
+		}
+		key := g.Key
+		if len(key) > 32 {
+			key = key[:31] + "…"
+		}
+		lines = append(lines, fmt.Sprintf("  %-32s %6d %s %5.1f%% %5.1f%% %6.3f %7.3f %s",
+			key,
+			mt.Predictions,
+			accStyle.Render(fmt.Sprintf("%5.1f%%", mt.Accuracy*100)),
+			mt.Precision*100,
+			mt.Recall*100,
+			mt.Brier,
+			mt.LogLoss,
+			returnStyle.Render(fmt.Sprintf("%+8.2f%%", mt.CumulativeReturn*100)),
+		))
+	}
+
+	if len(r.Overall.Calibration) > 0 {
+		lines = append(lines, "", HeaderStyle.Render("  Reliability (all predictions): observed up-rate per prob_up bucket"), "")
+		for _, b := range r.Overall.Calibration {
+			label := fmt.Sprintf("%.1f-%.1f (%.2f)", b.Lower, b.Upper, b.MeanPredicted)
+			lines = append(lines, fmt.Sprintf("  %s  (%d)", RenderBarChart(label, b.ObservedUp, 20), b.Count))
+		}
+	}
+
+	curves := make([]EquityCurve, 0, maxAnalyticsCurves)
+	for _, g := range r.Groups {
+		if len(curves) == maxAnalyticsCurves {
+			break
+		}
+		if len(g.Returns) < 2 {
+			continue
+		}
+		points := make([]domain.EquityPoint, 0, len(g.Returns)+1)
+		points = append(points, domain.EquityPoint{Equity: 1})
+		for _, p := range g.Returns {
+			points = append(points, domain.EquityPoint{Time: p.Time, Equity: 1 + p.Cumulative})
+		}
+		curves = append(curves, EquityCurve{Label: g.Key, Points: points})
+	}
+	if len(curves) > 0 {
+		lines = append(lines, "", HeaderStyle.Render("  Cumulative Directional Return (largest groups)"), "")
+		for _, l := range RenderEquityOverlay(curves, m.width-2, 8) {
+			lines = append(lines, "  "+l)
+		}
+	}
+	return lines
+}
+
+func (m BacktestModel) selectedIndex(id int64) int {
+	for i, sel := range m.selected {
+		if sel == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m BacktestModel) runLabel(id int64) string {
+	for _, r := range m.runs {
+		if r.ID == id {
+			return fmt.Sprintf("#%d %s %s", r.ID, r.Config.Symbol, r.Config.Interval)
+		}
+	}
+	return fmt.Sprintf("#%d", id)
+}
+
+func (m BacktestModel) fetchRunsCmd() tea.Cmd {
+	return func() tea.Msg {
+		if m.services.Runs == nil {
+			return nil
+		}
+		runs, err := m.services.Runs.ListRuns(context.Background(), backtestRunListLimit)
+		if err != nil {
+			return backtestRunErrMsg{err: err}
+		}
+		return backtestRunsMsg(runs)
+	}
+}
+
+func (m BacktestModel) fetchRunCmd(id int64) tea.Cmd {
+	return func() tea.Msg {
+		if m.services.Runs == nil {
+			return nil
+		}
+		run, err := m.services.Runs.GetRun(context.Background(), id)
+		if err != nil {
+			return backtestRunErrMsg{err: err}
+		}
+		if run == nil {
+			return backtestRunErrMsg{err: fmt.Errorf("run %d not found", id)}
+		}
+		return backtestRunDetailMsg{run: run}
+	}
+}
+
+func (m BacktestModel) fetchAnalyticsCmd() tea.Cmd {
+	groupBy := analyticsGroupings[m.grouping]
+	return func() tea.Msg {
+		if m.services.Analytics == nil {
+			return nil
+		}
+		report, err := m.services.Analytics.Analyze(context.Background(), domain.MLAnalyticsQuery{GroupBy: groupBy})
+		if err != nil {
+			return backtestAnalyticsErrMsg{err: err}
+		}
+		return backtestAnalyticsMsg{report: report}
+	}
+}
+
 func (m BacktestModel) fetchSummaryCmd() tea.Cmd {
 	return func() tea.Msg {
 		if m.services.Backtest == nil {
@@ -1,9 +1,12 @@
 package tui
 
 import (
+	"context"
+	"strings"
 	"testing"
 	"time"
 
+	"bug-free-umbrella/internal/domain"
 	"bug-free-umbrella/internal/repository"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -88,3 +91,164 @@ func TestBacktestModelViewWithData(t *testing.T) {
 		t.Fatal("expected non-empty view with data")
 	}
 }
+
+type stubRunQuerier struct {
+	runs    []domain.BacktestRun
+	fetched []int64
+}
+
+func (s *stubRunQuerier) ListRuns(ctx context.Context, limit int) ([]domain.BacktestRun, error) {
+	return s.runs, nil
+}
+
+func (s *stubRunQuerier) GetRun(ctx context.Context, id int64) (*domain.BacktestRun, error) {
+	s.fetched = append(s.fetched, id)
+	for _, r := range s.runs {
+		if r.ID == id {
+			r.Equity = []domain.EquityPoint{{Equity: 10000}, {Equity: 10000 + float64(id)*100}}
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
+
+func testRunServices() (Services, *stubRunQuerier) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	runs := &stubRunQuerier{runs: []domain.BacktestRun{
+		{ID: 7, EngineVersion: "engine/1", Config: domain.BacktestConfig{Symbol: "BTC", Interval: "1h", From: from, To: from.AddDate(0, 1, 0)}},
+		{ID: 5, EngineVersion: "engine/1", Config: domain.BacktestConfig{Symbol: "ETH", Interval: "4h", From: from, To: from.AddDate(0, 1, 0)}},
+	}}
+	svc := testServices()
+	svc.Runs = runs
+	return svc, runs
+}
+
+func TestBacktestModelRunsViewToggle(t *testing.T) {
+	m := NewBacktestModel(testServices())
+	m.SetSize(120, 40)
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'b'}})
+	if updated.ActiveView() != backtestViewRuns {
+		t.Fatalf("expected runs view, got %d", updated.ActiveView())
+	}
+	updated, _ = updated.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}})
+	if updated.ActiveView() != backtestViewAccuracy {
+		t.Fatalf("expected accuracy view after v, got %d", updated.ActiveView())
+	}
+}
+
+func TestBacktestModelSelectRunsForOverlay(t *testing.T) {
+	svc, runs := testRunServices()
+	m := NewBacktestModel(svc)
+	m.SetSize(120, 40)
+	m.loading = false
+
+	m, _ = m.Update(backtestRunsMsg(runs.runs))
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'b'}})
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	if cmd == nil {
+		t.Fatal("expected fetch command for first selection")
+	}
+	m, _ = m.Update(cmd())
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	m, _ = m.Update(cmd())
+
+	if got := m.SelectedRuns(); len(got) != 2 || got[0] != 7 || got[1] != 5 {
+		t.Fatalf("expected runs 7 and 5 selected, got %v", got)
+	}
+	if len(runs.fetched) != 2 {
+		t.Fatalf("expected 2 detail fetches, got %v", runs.fetched)
+	}
+
+	view := m.View()
+	for _, want := range []string{"Strategy Backtest Runs", "Equity Overlay", "#7 BTC 1h", "#5 ETH 4h"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("expected %q in view", want)
+		}
+	}
+
+	// Deselect the second run; its cached curve is kept for reuse.
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	if cmd != nil {
+		t.Fatal("expected no fetch when deselecting")
+	}
+	if got := m.SelectedRuns(); len(got) != 1 || got[0] != 7 {
+		t.Fatalf("expected only run 7 selected, got %v", got)
+	}
+}
+
+func TestRenderEquityOverlay(t *testing.T) {
+	lines := RenderEquityOverlay([]EquityCurve{
+		{Label: "up", Points: []domain.EquityPoint{{Equity: 100}, {Equity: 110}, {Equity: 120}}},
+		{Label: "down", Points: []domain.EquityPoint{{Equity: 50}, {Equity: 45}}},
+	}, 60, 8)
+	if len(lines) != 9 {
+		t.Fatalf("expected 8 plot rows and a legend, got %d", len(lines))
+	}
+	if !strings.Contains(lines[0], "+20.0%") || !strings.Contains(lines[7], "-10.0%") {
+		t.Fatalf("expected axis bounds from percent returns:\n%s", strings.Join(lines, "\n"))
+	}
+	if !strings.Contains(lines[8], "up") || !strings.Contains(lines[8], "down") {
+		t.Fatalf("expected legend, got %q", lines[8])
+	}
+}
+
+type stubAnalyticsQuerier struct {
+	queries []domain.MLAnalyticsQuery
+}
+
+func (s *stubAnalyticsQuerier) Analyze(ctx context.Context, q domain.MLAnalyticsQuery) (*domain.MLAnalyticsReport, error) {
+	s.queries = append(s.queries, q)
+	to := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	returns := []domain.ReturnPoint{{Time: to.Add(-2 * time.Hour), Cumulative: 0.01}, {Time: to, Cumulative: 0.03}}
+	return &domain.MLAnalyticsReport{
+		Query:   domain.MLAnalyticsQuery{GroupBy: q.GroupBy, From: to.AddDate(0, 0, -90), To: to},
+		Overall: domain.MLAnalyticsGroup{Key: "all", Metrics: domain.MLMetrics{Predictions: 20, Accuracy: 0.6}, Calibration: []domain.CalibrationBin{{Lower: 0.7, Upper: 0.8, Count: 20, MeanPredicted: 0.74, ObservedUp: 0.7}}},
+		Groups: []domain.MLAnalyticsGroup{
+			{Key: "ml_xgboost_up4h / BTC", Metrics: domain.MLMetrics{Predictions: 12, Accuracy: 0.75, CumulativeReturn: 0.03}, Returns: returns},
+			{Key: "ml_xgboost_up4h / DOGE", Metrics: domain.MLMetrics{Predictions: 8, Accuracy: 0.4, CumulativeReturn: -0.02}},
+		},
+	}, nil
+}
+
+func TestBacktestModelAnalyticsView(t *testing.T) {
+	analytics := &stubAnalyticsQuerier{}
+	svc := testServices()
+	svc.Analytics = analytics
+	m := NewBacktestModel(svc)
+	m.SetSize(140, 60)
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	if m.ActiveView() != backtestViewAnalytics {
+		t.Fatalf("expected analytics view, got %d", m.ActiveView())
+	}
+	if view := m.View(); !strings.Contains(view, "No resolved predictions") {
+		t.Fatalf("expected empty analytics message before data, got:\n%s", view)
+	}
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'g'}})
+	if cmd == nil {
+		t.Fatal("expected fetch command when cycling grouping")
+	}
+	if got := m.Grouping(); len(got) != 2 || got[1] != domain.MLGroupSymbol {
+		t.Fatalf("expected model / symbol grouping, got %v", got)
+	}
+	m, _ = m.Update(cmd())
+	if len(analytics.queries) != 1 || len(analytics.queries[0].GroupBy) != 2 {
+		t.Fatalf("expected query with new grouping, got %+v", analytics.queries)
+	}
+
+	view := m.View()
+	for _, want := range []string{"Prediction Analytics by model / symbol", "ml_xgboost_up4h / BTC", "ml_xgboost_up4h / DOGE", "Reliability", "Cumulative Directional Return"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("expected %q in view:\n%s", want, view)
+		}
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	if m.ActiveView() != backtestViewAccuracy {
+		t.Fatalf("expected accuracy view after a, got %d", m.ActiveView())
+	}
+}
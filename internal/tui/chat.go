@@ -13,9 +13,20 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
-// Chat message types.
-type advisorReplyMsg string
-type advisorErrMsg struct{ err error }
+// Chat message types. id ties each message to one question, so events
+// from a cancelled answer are dropped.
+type advisorDeltaMsg struct {
+	id   int
+	text string
+}
+type advisorReplyMsg struct {
+	id    int
+	reply string
+}
+type advisorErrMsg struct {
+	id  int
+	err error
+}
 
 type chatMessage struct {
 	Role    string
@@ -32,9 +43,16 @@ type ChatModel struct {
 	spinner  spinner.Model
 	waiting  bool
 	err      error
+	notice   string
 	width    int
 	height   int
 	ready    bool
+
+	// askID, events, cancel and partial track the answer being streamed.
+	askID   int
+	events  <-chan tea.Msg
+	cancel  context.CancelFunc
+	partial string
 }
 
 // NewChatModel creates a new chat model.
@@ -65,24 +83,55 @@ func (m ChatModel) Update(msg tea.Msg) (ChatModel, tea.Cmd) {
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
+	case advisorDeltaMsg:
+		if msg.id != m.askID || !m.waiting {
+			return m, nil
+		}
+		m.partial += msg.text
+		m.viewport.SetContent(m.renderMessages())
+		m.viewport.GotoBottom()
+		return m, waitForAdvisor(m.events)
+
 	case advisorReplyMsg:
+		if msg.id != m.askID {
+			return m, nil
+		}
 		m.messages = append(m.messages, chatMessage{
 			Role:    "assistant",
-			Content: string(msg),
+			Content: msg.reply,
 			Time:    time.Now(),
 		})
-		m.waiting = false
+		m.finishAsk()
 		m.err = nil
 		m.viewport.SetContent(m.renderMessages())
 		m.viewport.GotoBottom()
 		return m, nil
 
 	case advisorErrMsg:
-		m.waiting = false
+		if msg.id != m.askID {
+			return m, nil
+		}
+		m.finishAsk()
 		m.err = msg.err
+		m.viewport.SetContent(m.renderMessages())
 		return m, nil
 
 	case tea.KeyMsg:
+		if msg.Type == tea.KeyEsc && m.waiting {
+			if m.partial != "" {
+				m.messages = append(m.messages, chatMessage{
+					Role:    "assistant",
+					Content: m.partial,
+					Time:    time.Now(),
+				})
+			}
+			m.finishAsk()
+			m.askID++ // drop whatever the cancelled answer still sends
+			m.notice = "Answer cancelled."
+			m.viewport.SetContent(m.renderMessages())
+			m.viewport.GotoBottom()
+			return m, nil
+		}
 		if msg.Type == tea.KeyEnter && !m.waiting {
 			text := strings.TrimSpace(m.input.Value())
 			if text != "" {
@@ -93,10 +142,15 @@ func (m ChatModel) Update(msg tea.Msg) (ChatModel, tea.Cmd) {
 				})
 				m.input.SetValue("")
 				m.waiting = true
+				m.notice = ""
+				m.askID++
+				ctx, cancel := context.WithCancel(context.Background())
+				m.cancel = cancel
+				m.events = m.streamAdvisor(ctx, m.askID, text)
 				m.viewport.SetContent(m.renderMessages())
 				m.viewport.GotoBottom()
 				return m, tea.Batch(
-					m.askAdvisorCmd(text),
+					waitForAdvisor(m.events),
 					m.spinner.Tick,
 				)
 			}
@@ -132,7 +186,7 @@ func (m ChatModel) View() string {
 			"",
 			HeaderStyle.Render("  Chat with Trading Advisor"),
 			"",
-			SubtextStyle.Render("  Advisor not available. Set OPENAI_API_KEY to enable."),
+			SubtextStyle.Render("  Advisor not available. Configure an LLM backend to enable."),
 		)
 	}
 
@@ -151,10 +205,16 @@ func (m ChatModel) View() string {
 
 	// Input bar
 	if m.waiting {
-		sections = append(sections, fmt.Sprintf("  %s Thinking...", m.spinner.View()))
+		status := "Thinking..."
+		if m.partial != "" {
+			status = "Answering..."
+		}
+		sections = append(sections, fmt.Sprintf("  %s %s %s", m.spinner.View(), status, SubtextStyle.Render("(esc to cancel)")))
 	} else {
 		if m.err != nil {
 			sections = append(sections, ErrorStyle.Render(fmt.Sprintf("  Error: %v", m.err)))
+		} else if m.notice != "" {
+			sections = append(sections, SubtextStyle.Render("  "+m.notice))
 		}
 		sections = append(sections, "  "+m.input.View())
 	}
@@ -190,6 +250,20 @@ func (m ChatModel) IsWaiting() bool { return m.waiting }
 // MessageCount returns the number of messages (for testing).
 func (m ChatModel) MessageCount() int { return len(m.messages) }
 
+// Partial returns the answer streamed so far (for testing).
+func (m ChatModel) Partial() string { return m.partial }
+
+// finishAsk ends the current answer, cancelling it if it is still running.
+func (m *ChatModel) finishAsk() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.cancel = nil
+	m.events = nil
+	m.waiting = false
+	m.partial = ""
+}
+
 func (m *ChatModel) initViewport() {
 	vpHeight := m.height - 6
 	if vpHeight < 3 {
@@ -232,7 +306,15 @@ func (m ChatModel) renderMessages() string {
 		lines = append(lines, "")
 	}
 
-	if m.waiting {
+	if m.waiting && m.partial != "" {
+		lines = append(lines, fmt.Sprintf("  %s  %s",
+			SubtextStyle.Render(time.Now().Format("15:04")),
+			AssistantMsgStyle.Render("Advisor:"),
+		))
+		for _, line := range strings.Split(m.partial, "\n") {
+			lines = append(lines, "         "+line)
+		}
+	} else if m.waiting {
 		lines = append(lines, fmt.Sprintf("  %s  %s",
 			SubtextStyle.Render(time.Now().Format("15:04")),
 			SubtextStyle.Render("Advisor is thinking..."),
@@ -242,16 +324,47 @@ func (m ChatModel) renderMessages() string {
 	return strings.Join(lines, "\n")
 }
 
-func (m ChatModel) askAdvisorCmd(question string) tea.Cmd {
+// streamAdvisor asks the advisor in the background and returns the
+// channel its deltas and final reply arrive on. The channel is closed when
+// the answer ends, or once ctx is cancelled.
+func (m ChatModel) streamAdvisor(ctx context.Context, id int, question string) <-chan tea.Msg {
+	events := make(chan tea.Msg)
+	send := func(msg tea.Msg) {
+		select {
+		case events <- msg:
+		case <-ctx.Done():
+		}
+	}
 	chatID := m.services.ChatID()
-	return func() tea.Msg {
-		if m.services.Advisor == nil {
-			return advisorErrMsg{err: fmt.Errorf("advisor not available")}
+	advisor := m.services.Advisor
+	go func() {
+		defer close(events)
+		if advisor == nil {
+			send(advisorErrMsg{id: id, err: fmt.Errorf("advisor not available")})
+			return
 		}
-		reply, err := m.services.Advisor.Ask(context.Background(), chatID, question)
+		reply, err := advisor.AskStream(ctx, chatID, question, func(delta string) {
+			send(advisorDeltaMsg{id: id, text: delta})
+		})
 		if err != nil {
-			return advisorErrMsg{err: err}
+			send(advisorErrMsg{id: id, err: err})
+			return
+		}
+		send(advisorReplyMsg{id: id, reply: reply})
+	}()
+	return events
+}
+
+// waitForAdvisor delivers the next event of a streamed answer.
+func waitForAdvisor(events <-chan tea.Msg) tea.Cmd {
+	if events == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		msg, ok := <-events
+		if !ok {
+			return nil
 		}
-		return advisorReplyMsg(reply)
+		return msg
 	}
 }
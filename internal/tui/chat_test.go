@@ -1,7 +1,10 @@
 package tui
 
 import (
+	"context"
+	"strings"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -42,7 +45,7 @@ func TestChatModelReceiveReply(t *testing.T) {
 	m.waiting = true
 	m.messages = append(m.messages, chatMessage{Role: "user", Content: "test"})
 
-	updated, _ := m.Update(advisorReplyMsg("BTC looks bullish"))
+	updated, _ := m.Update(advisorReplyMsg{reply: "BTC looks bullish"})
 	if updated.IsWaiting() {
 		t.Fatal("expected not waiting after receiving reply")
 	}
@@ -76,3 +79,71 @@ func TestChatModelEmptyMessageIgnored(t *testing.T) {
 		t.Fatalf("expected 0 messages, got %d", updated.MessageCount())
 	}
 }
+
+func TestChatModelStreamsReply(t *testing.T) {
+	svc := testServices()
+	svc.Advisor = &stubAdvisorQuerier{reply: "BTC looks bullish"}
+	m := NewChatModel(svc)
+	m.SetSize(120, 40)
+	m.input.SetValue("What about BTC?")
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	for i := 0; i < 2; i++ {
+		msg := waitForAdvisor(m.events)()
+		m, _ = m.Update(msg)
+		if _, ok := msg.(advisorDeltaMsg); ok && m.Partial() != "BTC looks bullish" {
+			t.Fatalf("expected the partial reply after a delta, got %q", m.Partial())
+		}
+	}
+	if m.IsWaiting() || m.Partial() != "" || m.MessageCount() != 2 {
+		t.Fatalf("expected the final reply, got waiting=%t partial=%q messages=%d", m.IsWaiting(), m.Partial(), m.MessageCount())
+	}
+}
+
+func TestChatModelEscCancelsAnswer(t *testing.T) {
+	advisor := &blockingAdvisor{started: make(chan struct{}), done: make(chan error, 1)}
+	svc := testServices()
+	svc.Advisor = advisor
+	m := NewChatModel(svc)
+	m.SetSize(120, 40)
+	m.input.SetValue("What about BTC?")
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	<-advisor.started
+	stream, staleID := m.events, m.askID
+	m, _ = m.Update(advisorDeltaMsg{id: staleID, text: "BTC is "})
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.IsWaiting() || m.MessageCount() != 2 || !strings.Contains(m.View(), "cancelled") {
+		t.Fatalf("expected the partial answer kept and the request cancelled, got waiting=%t messages=%d", m.IsWaiting(), m.MessageCount())
+	}
+	select {
+	case err := <-advisor.done:
+		if err != context.Canceled {
+			t.Fatalf("expected the advisor context to be cancelled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("advisor was not cancelled")
+	}
+	if msg := waitForAdvisor(stream)(); msg != nil {
+		t.Fatalf("expected the stream to close after cancelling, got %#v", msg)
+	}
+
+	m, _ = m.Update(advisorReplyMsg{id: staleID, reply: "late"})
+	if m.MessageCount() != 2 {
+		t.Fatalf("expected a late reply to be dropped, got %d messages", m.MessageCount())
+	}
+}
+
+// blockingAdvisor waits until its context ends and reports why.
+type blockingAdvisor struct {
+	started chan struct{}
+	done    chan error
+}
+
+func (b *blockingAdvisor) AskStream(ctx context.Context, chatID int64, message string, onDelta func(string)) (string, error) {
+	close(b.started)
+	<-ctx.Done()
+	b.done <- ctx.Err()
+	return "", ctx.Err()
+}
@@ -61,6 +61,20 @@ func FormatSignal(s domain.Signal) string {
 	)
 }
 
+// FormatSignalLevels renders a signal's stop, first target and reward/risk
+// as a compact column for the signal explorer.
+func FormatSignalLevels(s domain.Signal) string {
+	l := s.Levels
+	if l == nil || len(l.Targets) == 0 {
+		return SubtextStyle.Render("—")
+	}
+	return fmt.Sprintf("SL %s  TP %s  %s",
+		formatUSD(l.Stop),
+		formatUSD(l.Targets[0]),
+		SubtextStyle.Render(fmt.Sprintf("R:R %.1f", l.RewardRisk)),
+	)
+}
+
 // RenderHeatMap renders a colored grid showing 24h change for each symbol.
 func RenderHeatMap(prices []*domain.PriceSnapshot, width int) string {
 	if len(prices) == 0 {
@@ -123,6 +137,93 @@ func RenderBarChart(label string, accuracy float64, barWidth int) string {
 	return fmt.Sprintf("%-20s %s %.1f%%", label, bar, accuracy*100)
 }
 
+// EquityCurve is one labelled series for RenderEquityOverlay.
+type EquityCurve struct {
+	Label  string
+	Points []domain.EquityPoint
+}
+
+// RenderEquityOverlay plots equity curves on a shared percent-return axis so
+// runs with different starting equity or windows line up. Each curve is
+// resampled to the chart width; later curves draw over earlier ones.
+func RenderEquityOverlay(curves []EquityCurve, width, height int) []string {
+	const axisWidth = 9
+	plotWidth := width - axisWidth - 2
+	if plotWidth < 10 {
+		plotWidth = 10
+	}
+	if height < 3 {
+		height = 3
+	}
+
+	series := make([][]float64, len(curves))
+	lo, hi := 0.0, 0.0
+	for i, c := range curves {
+		if len(c.Points) == 0 || c.Points[0].Equity <= 0 {
+			continue
+		}
+		base := c.Points[0].Equity
+		values := make([]float64, plotWidth)
+		for col := range values {
+			idx := 0
+			if plotWidth > 1 {
+				idx = col * (len(c.Points) - 1) / (plotWidth - 1)
+			}
+			values[col] = c.Points[idx].Equity/base - 1
+			lo = math.Min(lo, values[col])
+			hi = math.Max(hi, values[col])
+		}
+		series[i] = values
+	}
+	if hi-lo < 1e-9 {
+		hi += 0.01
+		lo -= 0.01
+	}
+	rowOf := func(v float64) int {
+		return int(math.Round((hi - v) / (hi - lo) * float64(height-1)))
+	}
+
+	cells := make([][]string, height)
+	for r := range cells {
+		cells[r] = make([]string, plotWidth)
+		for col := range cells[r] {
+			cells[r][col] = " "
+		}
+	}
+	zero := rowOf(0)
+	for col := range cells[zero] {
+		cells[zero][col] = SubtextStyle.Render("┈")
+	}
+	for i, values := range series {
+		style := lipgloss.NewStyle().Foreground(OverlayColors[i%len(OverlayColors)])
+		for col, v := range values {
+			cells[rowOf(v)][col] = style.Render("•")
+		}
+	}
+
+	lines := make([]string, 0, height+2)
+	for r := range cells {
+		axis := ""
+		switch r {
+		case 0:
+			axis = fmt.Sprintf("%+.1f%%", hi*100)
+		case zero:
+			axis = "0.0%"
+		case height - 1:
+			axis = fmt.Sprintf("%+.1f%%", lo*100)
+		}
+		lines = append(lines, SubtextStyle.Render(fmt.Sprintf("%*s", axisWidth, axis))+" │"+strings.Join(cells[r], ""))
+	}
+
+	legend := make([]string, 0, len(curves))
+	for i, c := range curves {
+		style := lipgloss.NewStyle().Foreground(OverlayColors[i%len(OverlayColors)])
+		legend = append(legend, style.Render("━━ ")+c.Label)
+	}
+	lines = append(lines, strings.Repeat(" ", axisWidth+2)+strings.Join(legend, "   "))
+	return lines
+}
+
 // heatColorScale produces a color scaled by magnitude.
 func heatColorScale(magnitude, maxMagnitude float64, baseColor lipgloss.Color) lipgloss.Color {
 	intensity := magnitude / maxMagnitude
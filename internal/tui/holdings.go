@@ -0,0 +1,157 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"bug-free-umbrella/internal/domain"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Holdings message types.
+type holdingsMsg struct{ valuation *domain.HoldingsValuation }
+type holdingsErrMsg struct{ err error }
+
+// HoldingsModel is the Bubble Tea model for the SSH user's own portfolio.
+type HoldingsModel struct {
+	services  Services
+	valuation *domain.HoldingsValuation
+	loading   bool
+	err       error
+	width     int
+	height    int
+}
+
+// NewHoldingsModel creates a new holdings model.
+func NewHoldingsModel(svc Services) HoldingsModel {
+	return HoldingsModel{
+		services: svc,
+		loading:  true,
+	}
+}
+
+// Init fires the initial valuation fetch.
+func (m HoldingsModel) Init() tea.Cmd {
+	return m.fetchCmd()
+}
+
+// Update handles incoming messages.
+func (m HoldingsModel) Update(msg tea.Msg) (HoldingsModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case holdingsMsg:
+		m.valuation = msg.valuation
+		m.loading = false
+		m.err = nil
+		return m, nil
+
+	case holdingsErrMsg:
+		m.err = msg.err
+		m.loading = false
+		return m, nil
+
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, DefaultKeyMap.ShareAdvisor):
+			if m.valuation == nil {
+				return m, nil
+			}
+			m.loading = true
+			return m, m.toggleShareCmd(!m.valuation.ShareAdvisor)
+
+		case key.Matches(msg, DefaultKeyMap.Refresh):
+			m.loading = true
+			return m, m.fetchCmd()
+		}
+	}
+
+	return m, nil
+}
+
+// View renders the holdings screen.
+func (m HoldingsModel) View() string {
+	var sections []string
+	sections = append(sections, HeaderStyle.Render("  My Holdings"))
+	sections = append(sections, "")
+
+	if m.services.Holdings == nil {
+		sections = append(sections, SubtextStyle.Render("  Portfolio tracking not available"))
+		return strings.Join(sections, "\n")
+	}
+	if m.loading {
+		sections = append(sections, SubtextStyle.Render("  Loading..."))
+		return strings.Join(sections, "\n")
+	}
+	if m.err != nil {
+		sections = append(sections, ErrorStyle.Render(fmt.Sprintf("  Error: %v", m.err)))
+		return strings.Join(sections, "\n")
+	}
+	v := m.valuation
+	if v == nil || len(v.Holdings) == 0 {
+		sections = append(sections, SubtextStyle.Render(fmt.Sprintf(
+			"  No holdings yet. Add trades with POST /api/holdings/%d/trades or import a CSV", m.services.ChatID())))
+		return strings.Join(sections, "\n")
+	}
+
+	sections = append(sections, fmt.Sprintf("  Value %s  Cost %s  Unrealised %s  Realised %s",
+		formatUSD(v.MarketValue), formatUSD(v.CostBasis),
+		signedStyle(v.UnrealizedPnL).Render(fmt.Sprintf("%+.2f", v.UnrealizedPnL)),
+		signedStyle(v.RealizedPnL).Render(fmt.Sprintf("%+.2f", v.RealizedPnL))))
+	sections = append(sections, SubtextStyle.Render(strings.Repeat("─", max(m.width-2, 0))))
+	sections = append(sections, SubtextStyle.Render(
+		fmt.Sprintf("  %-6s %12s %12s %12s %12s %7s %12s", "Symbol", "Qty", "Avg Cost", "Price", "Value", "Alloc", "PnL"),
+	))
+	for _, h := range v.Holdings {
+		price := fmt.Sprintf("%12.4f", h.Price)
+		if !h.Priced {
+			price = fmt.Sprintf("%12s", "n/a")
+		}
+		sections = append(sections, fmt.Sprintf("  %-6s %12.6g %12.4f %s %12.2f %6.1f%% %s",
+			h.Symbol, h.Quantity, h.AvgCost, price, h.MarketValue, h.Allocation*100,
+			signedStyle(h.UnrealizedPnL).Render(fmt.Sprintf("%12.2f", h.UnrealizedPnL))))
+	}
+
+	sections = append(sections, "")
+	share := "off"
+	if v.ShareAdvisor {
+		share = "on"
+	}
+	sections = append(sections, SubtextStyle.Render(fmt.Sprintf("  Advisor sharing: %s   [o] toggle  [R] refresh", share)))
+	return strings.Join(sections, "\n")
+}
+
+// SetSize updates the model dimensions.
+func (m *HoldingsModel) SetSize(w, h int) {
+	m.width = w
+	m.height = h
+}
+
+// Valuation returns the displayed valuation (for testing).
+func (m HoldingsModel) Valuation() *domain.HoldingsValuation { return m.valuation }
+
+func (m HoldingsModel) fetchCmd() tea.Cmd {
+	chatID := m.services.ChatID()
+	return func() tea.Msg {
+		if m.services.Holdings == nil {
+			return nil
+		}
+		v, err := m.services.Holdings.Valuation(context.Background(), chatID)
+		if err != nil {
+			return holdingsErrMsg{err: err}
+		}
+		return holdingsMsg{valuation: v}
+	}
+}
+
+func (m HoldingsModel) toggleShareCmd(share bool) tea.Cmd {
+	chatID := m.services.ChatID()
+	fetch := m.fetchCmd()
+	return func() tea.Msg {
+		if err := m.services.Holdings.SetShareWithAdvisor(context.Background(), chatID, share); err != nil {
+			return holdingsErrMsg{err: err}
+		}
+		return fetch()
+	}
+}
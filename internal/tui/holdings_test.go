@@ -0,0 +1,78 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"bug-free-umbrella/internal/domain"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type stubHoldingsQuerier struct {
+	share     bool
+	requested []int64
+	err       error
+}
+
+func (s *stubHoldingsQuerier) Valuation(ctx context.Context, chatID int64) (*domain.HoldingsValuation, error) {
+	s.requested = append(s.requested, chatID)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &domain.HoldingsValuation{
+		ChatID: chatID,
+		Holdings: []domain.Holding{
+			{Symbol: "ETH", Quantity: 2, AvgCost: 1500, Price: 2000, Priced: true, MarketValue: 4000, UnrealizedPnL: 1000, Allocation: 1},
+		},
+		MarketValue: 4000, CostBasis: 3000, UnrealizedPnL: 1000,
+		ShareAdvisor: s.share,
+	}, nil
+}
+
+func (s *stubHoldingsQuerier) SetShareWithAdvisor(ctx context.Context, chatID int64, share bool) error {
+	s.share = share
+	return nil
+}
+
+func TestHoldingsModelLoadsForSSHUser(t *testing.T) {
+	holdings := &stubHoldingsQuerier{}
+	svc := testServices()
+	svc.Holdings = holdings
+	m := NewHoldingsModel(svc)
+	m.SetSize(120, 40)
+
+	m, _ = m.Update(m.Init()())
+	if m.Valuation() == nil || holdings.requested[0] != svc.ChatID() {
+		t.Fatalf("expected valuation for chat %d, got %+v (%v)", svc.ChatID(), m.Valuation(), holdings.requested)
+	}
+	view := m.View()
+	for _, want := range []string{"ETH", "100.0%", "Advisor sharing: off"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("expected %q in view:\n%s", want, view)
+		}
+	}
+
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'o'}})
+	m, _ = m.Update(cmd())
+	if !holdings.share || !strings.Contains(m.View(), "Advisor sharing: on") {
+		t.Fatalf("expected sharing toggled on, got:\n%s", m.View())
+	}
+}
+
+func TestHoldingsModelUnavailableAndErrors(t *testing.T) {
+	m := NewHoldingsModel(testServices())
+	if !strings.Contains(m.View(), "not available") {
+		t.Fatalf("expected unavailable message, got:\n%s", m.View())
+	}
+
+	svc := testServices()
+	svc.Holdings = &stubHoldingsQuerier{err: errors.New("db down")}
+	m = NewHoldingsModel(svc)
+	m, _ = m.Update(m.Init()())
+	if !strings.Contains(m.View(), "db down") {
+		t.Fatalf("expected error in view, got:\n%s", m.View())
+	}
+}
@@ -17,9 +17,10 @@ type SignalQuerier interface {
 	ListSignals(ctx context.Context, filter domain.SignalFilter) ([]domain.Signal, error)
 }
 
-// AdvisorQuerier provides LLM advisor access to the TUI.
+// AdvisorQuerier provides LLM advisor access to the TUI. AskStream passes
+// the reply text to onDelta as it is written.
 type AdvisorQuerier interface {
-	Ask(ctx context.Context, chatID int64, message string) (string, error)
+	AskStream(ctx context.Context, chatID int64, message string, onDelta func(string)) (string, error)
 }
 
 // BacktestQuerier provides ML backtest data to the TUI.
@@ -29,6 +30,36 @@ type BacktestQuerier interface {
 	ListRecentPredictions(ctx context.Context, limit int) ([]domain.MLPrediction, error)
 }
 
+// MLAnalyticsQuerier provides sliced ML prediction analytics to the TUI.
+type MLAnalyticsQuerier interface {
+	Analyze(ctx context.Context, q domain.MLAnalyticsQuery) (*domain.MLAnalyticsReport, error)
+}
+
+// BacktestRunQuerier provides persisted strategy backtest runs to the TUI.
+type BacktestRunQuerier interface {
+	ListRuns(ctx context.Context, limit int) ([]domain.BacktestRun, error)
+	GetRun(ctx context.Context, id int64) (*domain.BacktestRun, error)
+}
+
+// PaperQuerier provides paper trading accounts to the TUI.
+type PaperQuerier interface {
+	ListAccounts(ctx context.Context) ([]domain.PaperAccount, error)
+	Portfolio(ctx context.Context, accountID int64) (*domain.PaperPortfolio, error)
+}
+
+// HoldingsQuerier provides the SSH user's portfolio holdings to the TUI.
+type HoldingsQuerier interface {
+	Valuation(ctx context.Context, chatID int64) (*domain.HoldingsValuation, error)
+	SetShareWithAdvisor(ctx context.Context, chatID int64, share bool) error
+}
+
+// PriceAlertQuerier manages the SSH user's price alert rules from the TUI.
+type PriceAlertQuerier interface {
+	ListRules(ctx context.Context, chatID int64) ([]domain.PriceAlertRule, error)
+	CreateRule(ctx context.Context, rule domain.PriceAlertRule) (*domain.PriceAlertRule, error)
+	DeleteRule(ctx context.Context, chatID, id int64) error
+}
+
 // SSHChatIDOffset is the base offset for generating synthetic chat IDs
 // for SSH users. The final chat ID is SSHChatIDOffset - user.ID.
 // This avoids collisions with Telegram chat IDs.
@@ -36,12 +67,17 @@ const SSHChatIDOffset int64 = -1_000_000
 
 // Services bundles all service dependencies injected into the TUI.
 type Services struct {
-	Prices   PriceQuerier
-	Signals  SignalQuerier
-	Advisor  AdvisorQuerier
-	Backtest BacktestQuerier
-	UserID   int64
-	Username string
+	Prices    PriceQuerier
+	Signals   SignalQuerier
+	Advisor   AdvisorQuerier
+	Backtest  BacktestQuerier
+	Analytics MLAnalyticsQuerier
+	Runs      BacktestRunQuerier
+	Paper     PaperQuerier
+	Holdings  HoldingsQuerier
+	Alerts    PriceAlertQuerier
+	UserID    int64
+	Username  string
 }
 
 // ChatID returns the synthetic chat ID for this SSH session.
@@ -15,7 +15,21 @@ type KeyMap struct {
 	FilterIndicator key.Binding
 
 	// Backtest view toggle
-	ToggleView key.Binding
+	ToggleView     key.Binding
+	RunsView       key.Binding
+	SelectRun      key.Binding
+	AnalyticsView  key.Binding
+	AnalyticsGroup key.Binding
+
+	// Paper trading
+	NextAccount key.Binding
+
+	// Holdings
+	ShareAdvisor key.Binding
+
+	// Price alerts
+	AddAlert    key.Binding
+	DeleteAlert key.Binding
 }
 
 // DefaultKeyMap provides the default key bindings for the TUI.
@@ -29,5 +43,16 @@ var DefaultKeyMap = KeyMap{
 	FilterRisk:      key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "cycle risk")),
 	FilterIndicator: key.NewBinding(key.WithKeys("i"), key.WithHelp("i", "cycle indicator")),
 
-	ToggleView: key.NewBinding(key.WithKeys("v"), key.WithHelp("v", "toggle view")),
+	ToggleView:     key.NewBinding(key.WithKeys("v"), key.WithHelp("v", "toggle view")),
+	RunsView:       key.NewBinding(key.WithKeys("b"), key.WithHelp("b", "strategy runs")),
+	SelectRun:      key.NewBinding(key.WithKeys(" ", "enter"), key.WithHelp("space", "overlay run")),
+	AnalyticsView:  key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "prediction analytics")),
+	AnalyticsGroup: key.NewBinding(key.WithKeys("g"), key.WithHelp("g", "cycle grouping")),
+
+	NextAccount: key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "next account")),
+
+	ShareAdvisor: key.NewBinding(key.WithKeys("o"), key.WithHelp("o", "share with advisor")),
+
+	AddAlert:    key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "add alert")),
+	DeleteAlert: key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "delete alert")),
 }
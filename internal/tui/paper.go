@@ -0,0 +1,183 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"bug-free-umbrella/internal/domain"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Paper trading message types.
+type paperAccountsMsg []domain.PaperAccount
+type paperPortfolioMsg struct{ portfolio *domain.PaperPortfolio }
+type paperErrMsg struct{ err error }
+
+// PaperModel is the Bubble Tea model for the paper trading screen. It shows
+// one account at a time, valued at current prices.
+type PaperModel struct {
+	services   Services
+	accounts   []domain.PaperAccount
+	accountIdx int
+	portfolio  *domain.PaperPortfolio
+	loading    bool
+	err        error
+	width      int
+	height     int
+}
+
+// NewPaperModel creates a new paper trading model.
+func NewPaperModel(svc Services) PaperModel {
+	return PaperModel{
+		services: svc,
+		loading:  true,
+	}
+}
+
+// Init fires the initial account fetch.
+func (m PaperModel) Init() tea.Cmd {
+	return m.fetchAccountsCmd()
+}
+
+// Update handles incoming messages.
+func (m PaperModel) Update(msg tea.Msg) (PaperModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case paperAccountsMsg:
+		m.accounts = []domain.PaperAccount(msg)
+		m.err = nil
+		if len(m.accounts) == 0 {
+			m.loading = false
+			m.portfolio = nil
+			return m, nil
+		}
+		if m.accountIdx >= len(m.accounts) {
+			m.accountIdx = 0
+		}
+		return m, m.fetchPortfolioCmd(m.accounts[m.accountIdx].ID)
+
+	case paperPortfolioMsg:
+		m.portfolio = msg.portfolio
+		m.loading = false
+		m.err = nil
+		return m, nil
+
+	case paperErrMsg:
+		m.err = msg.err
+		m.loading = false
+		return m, nil
+
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, DefaultKeyMap.NextAccount):
+			if len(m.accounts) < 2 {
+				return m, nil
+			}
+			m.accountIdx = (m.accountIdx + 1) % len(m.accounts)
+			m.loading = true
+			return m, m.fetchPortfolioCmd(m.accounts[m.accountIdx].ID)
+
+		case key.Matches(msg, DefaultKeyMap.Refresh):
+			m.loading = true
+			return m, m.fetchAccountsCmd()
+		}
+	}
+
+	return m, nil
+}
+
+// View renders the paper trading screen.
+func (m PaperModel) View() string {
+	var sections []string
+	sections = append(sections, HeaderStyle.Render("  Paper Trading"))
+	sections = append(sections, "")
+
+	if m.services.Paper == nil {
+		sections = append(sections, SubtextStyle.Render("  Paper trading not available"))
+		return strings.Join(sections, "\n")
+	}
+	if m.loading {
+		sections = append(sections, SubtextStyle.Render("  Loading..."))
+		return strings.Join(sections, "\n")
+	}
+	if m.err != nil {
+		sections = append(sections, ErrorStyle.Render(fmt.Sprintf("  Error: %v", m.err)))
+		return strings.Join(sections, "\n")
+	}
+	if len(m.accounts) == 0 || m.portfolio == nil {
+		sections = append(sections, SubtextStyle.Render("  No paper accounts yet. Open one with /buy in Telegram or POST /api/paper/accounts"))
+		return strings.Join(sections, "\n")
+	}
+
+	p := m.portfolio
+	sections = append(sections, fmt.Sprintf("  Account #%d %s  %s",
+		p.Account.ID, p.Account.Name, SubtextStyle.Render(fmt.Sprintf("(%d/%d)", m.accountIdx+1, len(m.accounts)))))
+	sections = append(sections, fmt.Sprintf("  Equity %s  %s  Cash %s",
+		formatUSD(p.Equity), signedStyle(p.Return).Render(fmt.Sprintf("%+.2f%%", p.Return*100)), formatUSD(p.Account.Cash)))
+	sections = append(sections, fmt.Sprintf("  Realised %s  Unrealised %s",
+		signedStyle(p.RealizedPnL).Render(fmt.Sprintf("%+.2f", p.RealizedPnL)),
+		signedStyle(p.UnrealizedPnL).Render(fmt.Sprintf("%+.2f", p.UnrealizedPnL))))
+	sections = append(sections, SubtextStyle.Render(strings.Repeat("─", max(m.width-2, 0))))
+
+	if len(p.Holdings) == 0 {
+		sections = append(sections, SubtextStyle.Render("  No open positions"))
+	} else {
+		sections = append(sections, SubtextStyle.Render(
+			fmt.Sprintf("  %-6s %12s %12s %12s %12s %12s", "Symbol", "Qty", "Avg", "Mark", "Value", "PnL"),
+		))
+		for _, h := range p.Holdings {
+			sections = append(sections, fmt.Sprintf("  %-6s %12.6g %12.4f %12.4f %12.2f %s",
+				h.Symbol, h.Quantity, h.AvgPrice, h.MarkPrice, h.MarketValue,
+				signedStyle(h.UnrealizedPnL).Render(fmt.Sprintf("%12.2f", h.UnrealizedPnL))))
+		}
+	}
+
+	sections = append(sections, "")
+	sections = append(sections, SubtextStyle.Render("  [n] next account  [R] refresh"))
+	return strings.Join(sections, "\n")
+}
+
+// SetSize updates the model dimensions.
+func (m *PaperModel) SetSize(w, h int) {
+	m.width = w
+	m.height = h
+}
+
+// Portfolio returns the displayed portfolio (for testing).
+func (m PaperModel) Portfolio() *domain.PaperPortfolio { return m.portfolio }
+
+func signedStyle(v float64) lipgloss.Style {
+	switch {
+	case v > 0:
+		return PriceUpStyle
+	case v < 0:
+		return PriceDownStyle
+	}
+	return PriceZeroStyle
+}
+
+func (m PaperModel) fetchAccountsCmd() tea.Cmd {
+	return func() tea.Msg {
+		if m.services.Paper == nil {
+			return nil
+		}
+		accounts, err := m.services.Paper.ListAccounts(context.Background())
+		if err != nil {
+			return paperErrMsg{err: err}
+		}
+		return paperAccountsMsg(accounts)
+	}
+}
+
+func (m PaperModel) fetchPortfolioCmd(id int64) tea.Cmd {
+	return func() tea.Msg {
+		p, err := m.services.Paper.Portfolio(context.Background(), id)
+		if err != nil {
+			return paperErrMsg{err: err}
+		}
+		return paperPortfolioMsg{portfolio: p}
+	}
+}
@@ -0,0 +1,91 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"bug-free-umbrella/internal/domain"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type stubPaperQuerier struct {
+	accounts  []domain.PaperAccount
+	requested []int64
+	err       error
+}
+
+func (s *stubPaperQuerier) ListAccounts(ctx context.Context) ([]domain.PaperAccount, error) {
+	return s.accounts, s.err
+}
+
+func (s *stubPaperQuerier) Portfolio(ctx context.Context, accountID int64) (*domain.PaperPortfolio, error) {
+	s.requested = append(s.requested, accountID)
+	p := &domain.PaperPortfolio{Account: domain.PaperAccount{ID: accountID, Name: "acct", Cash: 9000}, Equity: 10250, Return: 0.025}
+	if accountID == 1 {
+		p.Holdings = []domain.PaperHolding{{
+			PaperPosition: domain.PaperPosition{Symbol: "BTC", Quantity: 0.02, AvgPrice: 50000},
+			MarkPrice:     62500,
+			MarketValue:   1250,
+			UnrealizedPnL: 250,
+		}}
+		p.UnrealizedPnL = 250
+	}
+	return p, nil
+}
+
+func TestPaperModelLoadsAndCyclesAccounts(t *testing.T) {
+	paper := &stubPaperQuerier{accounts: []domain.PaperAccount{{ID: 1}, {ID: 2}}}
+	svc := testServices()
+	svc.Paper = paper
+	m := NewPaperModel(svc)
+	m.SetSize(120, 40)
+
+	m, cmd := m.Update(m.Init()())
+	if cmd == nil {
+		t.Fatal("expected portfolio fetch after accounts load")
+	}
+	m, _ = m.Update(cmd())
+	if m.Portfolio() == nil || m.Portfolio().Account.ID != 1 {
+		t.Fatalf("expected first account's portfolio, got %+v", m.Portfolio())
+	}
+	view := m.View()
+	for _, want := range []string{"Account #1", "(1/2)", "BTC", "+2.50%"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("expected %q in view:\n%s", want, view)
+		}
+	}
+
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	m, _ = m.Update(cmd())
+	if m.Portfolio().Account.ID != 2 || !strings.Contains(m.View(), "No open positions") {
+		t.Fatalf("expected second account with no positions, got:\n%s", m.View())
+	}
+	if len(paper.requested) != 2 || paper.requested[1] != 2 {
+		t.Fatalf("unexpected portfolio requests: %v", paper.requested)
+	}
+}
+
+func TestPaperModelEmptyAndErrors(t *testing.T) {
+	m := NewPaperModel(testServices())
+	if !strings.Contains(m.View(), "not available") {
+		t.Fatalf("expected unavailable message, got:\n%s", m.View())
+	}
+
+	svc := testServices()
+	svc.Paper = &stubPaperQuerier{}
+	m = NewPaperModel(svc)
+	m, _ = m.Update(m.Init()())
+	if !strings.Contains(m.View(), "No paper accounts yet") {
+		t.Fatalf("expected empty message, got:\n%s", m.View())
+	}
+
+	svc.Paper = &stubPaperQuerier{err: errors.New("db down")}
+	m = NewPaperModel(svc)
+	m, _ = m.Update(m.Init()())
+	if !strings.Contains(m.View(), "db down") {
+		t.Fatalf("expected error in view, got:\n%s", m.View())
+	}
+}
@@ -138,8 +138,8 @@ func (m SignalExplorerModel) View() string {
 
 	// Table header
 	sections = append(sections, SubtextStyle.Render(
-		fmt.Sprintf("  %-5s %-6s %-4s %-12s %-6s %-5s  %s",
-			"ID", "Symbol", "Int", "Indicator", "Dir", "Risk", "Time"),
+		fmt.Sprintf("  %-5s %-6s %-4s %-12s %-6s %-5s  %-18s  %s",
+			"ID", "Symbol", "Int", "Indicator", "Dir", "Risk", "Time", "Levels"),
 	))
 
 	// Table rows
@@ -150,7 +150,7 @@ func (m SignalExplorerModel) View() string {
 	}
 
 	for i := m.scrollOffset; i < end; i++ {
-		sections = append(sections, "  "+FormatSignal(m.signals[i]))
+		sections = append(sections, "  "+FormatSignal(m.signals[i])+"  "+FormatSignalLevels(m.signals[i]))
 	}
 
 	// Scroll indicator
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"strings"
 	"testing"
 
 	"bug-free-umbrella/internal/domain"
@@ -55,6 +56,25 @@ func TestSignalExplorerUpdateSignals(t *testing.T) {
 	}
 }
 
+func TestSignalExplorerViewShowsLevels(t *testing.T) {
+	m := NewSignalExplorerModel(testServices())
+	m.SetSize(160, 40)
+
+	signals := []domain.Signal{
+		{ID: 1, Symbol: "BTC", Interval: "1h", Indicator: "rsi", Direction: domain.DirectionLong, Risk: 2,
+			Levels: &domain.SignalLevels{Entry: 100, Stop: 95, Targets: []float64{110}, RewardRisk: 2}},
+		{ID: 2, Symbol: "ETH", Interval: "4h", Indicator: "macd", Direction: domain.DirectionShort, Risk: 3},
+	}
+	updated, _ := m.Update(filteredSignalsMsg(signals))
+
+	view := updated.View()
+	for _, want := range []string{"SL $95.00", "TP $110.00", "R:R 2.0"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("expected %q in view:\n%s", want, view)
+		}
+	}
+}
+
 func TestSignalExplorerViewEmpty(t *testing.T) {
 	m := NewSignalExplorerModel(testServices())
 	m.SetSize(120, 40)
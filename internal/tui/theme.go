@@ -46,4 +46,7 @@ var (
 	AccuracyGoodStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#00FF00"))
 	AccuracyOkStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFF00"))
 	AccuracyBadStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000"))
+
+	// Equity overlay series colors, assigned in selection order
+	OverlayColors = []lipgloss.Color{"#7D56F4", "#00BFFF", "#FFA500", "#FF69B4"}
 )
@@ -0,0 +1,152 @@
+package webconsole
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+	"bug-free-umbrella/internal/service"
+)
+
+const (
+	defaultStrategyBacktestDays = 90
+	maxBacktestTradeLines       = 10
+)
+
+type StrategyBacktestRunner interface {
+	RunStrategy(ctx context.Context, cfg domain.BacktestConfig) (*domain.BacktestResult, error)
+}
+
+func (s *Service) SetStrategyBacktest(runner StrategyBacktestRunner) {
+	s.strategy = runner
+}
+
+// RunBacktest runs a strategy backtest from a console command line such as
+// "BTC 4h --days 180 --hold 12 --fee 10 --slippage 5 --indicators rsi,macd
+// --max-risk 3 --short --no-stops" and returns a text report.
+func (s *Service) RunBacktest(ctx context.Context, args string) (string, error) {
+	if s.strategy == nil {
+		return "", fmt.Errorf("strategy backtest unavailable")
+	}
+	cfg, err := parseBacktestArgs(args, time.Now().UTC())
+	if err != nil {
+		return "", err
+	}
+	result, err := s.strategy.RunStrategy(ctx, cfg)
+	if err != nil {
+		return "", err
+	}
+	return formatBacktestResult(result), nil
+}
+
+func parseBacktestArgs(args string, now time.Time) (domain.BacktestConfig, error) {
+	cfg := domain.BacktestConfig{
+		Interval:    "1h",
+		FeeBps:      service.DefaultBacktestFeeBps,
+		SlippageBps: service.DefaultBacktestSlippageBps,
+		UseStops:    true,
+		To:          now,
+	}
+	days := defaultStrategyBacktestDays
+
+	tokens := strings.Fields(args)
+	positional := make([]string, 0, 2)
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if !strings.HasPrefix(tok, "--") {
+			positional = append(positional, tok)
+			continue
+		}
+		key := strings.ToLower(strings.TrimPrefix(tok, "--"))
+		value := ""
+		if k, v, ok := strings.Cut(key, "="); ok {
+			key, value = k, v
+		}
+		switch key {
+		case "short":
+			cfg.AllowShort = true
+			continue
+		case "no-stops":
+			cfg.UseStops = false
+			continue
+		}
+		if value == "" {
+			if i+1 >= len(tokens) {
+				return cfg, fmt.Errorf("--%s needs a value", key)
+			}
+			i++
+			value = tokens[i]
+		}
+		var err error
+		switch key {
+		case "days":
+			days, err = strconv.Atoi(value)
+			if err == nil && days <= 0 {
+				err = fmt.Errorf("must be positive")
+			}
+		case "hold":
+			cfg.HoldBars, err = strconv.Atoi(value)
+		case "fee":
+			cfg.FeeBps, err = strconv.ParseFloat(value, 64)
+		case "slippage":
+			cfg.SlippageBps, err = strconv.ParseFloat(value, 64)
+		case "max-risk":
+			var risk int
+			risk, err = strconv.Atoi(value)
+			cfg.MaxRisk = domain.RiskLevel(risk)
+		case "indicators", "indicator":
+			cfg.Indicators = strings.Split(value, ",")
+		default:
+			return cfg, fmt.Errorf("unknown flag --%s", key)
+		}
+		if err != nil {
+			return cfg, fmt.Errorf("invalid --%s %q: %v", key, value, err)
+		}
+	}
+
+	if len(positional) == 0 {
+		return cfg, fmt.Errorf("usage: backtest <symbol> [interval] [--days N] [--hold N] [--fee bps] [--slippage bps] [--indicators rsi,macd] [--max-risk N] [--short] [--no-stops]")
+	}
+	cfg.Symbol = strings.ToUpper(positional[0])
+	if len(positional) > 1 {
+		cfg.Interval = strings.ToLower(positional[1])
+	}
+	cfg.From = now.AddDate(0, 0, -days)
+	return cfg, nil
+}
+
+func formatBacktestResult(r *domain.BacktestResult) string {
+	m := r.Metrics
+	lines := []string{
+		fmt.Sprintf("Backtest %s %s %s → %s",
+			r.Config.Symbol, r.Config.Interval,
+			r.Config.From.Format("2006-01-02"), r.Config.To.Format("2006-01-02")),
+		fmt.Sprintf("fees %.1fbps slippage %.1fbps hold %d bars stops=%t shorts=%t",
+			r.Config.FeeBps, r.Config.SlippageBps, r.Config.HoldBars, r.Config.UseStops, r.Config.AllowShort),
+		fmt.Sprintf("trades %d  win rate %.1f%%  profit factor %.2f", m.Trades, m.WinRate*100, m.ProfitFactor),
+		fmt.Sprintf("return %+.2f%%  max drawdown %.2f%%  sharpe %.2f  sortino %.2f",
+			m.TotalReturn*100, m.MaxDrawdown*100, m.Sharpe, m.Sortino),
+		fmt.Sprintf("equity %.2f → %.2f", r.Config.InitialEquity, m.FinalEquity),
+	}
+	if r.RunID > 0 {
+		lines = append(lines, fmt.Sprintf("saved as run #%d (%s)", r.RunID, r.EngineVersion))
+	}
+	if len(r.Trades) > 0 {
+		lines = append(lines, "", "last trades:")
+		start := len(r.Trades) - maxBacktestTradeLines
+		if start < 0 {
+			start = 0
+		}
+		for _, t := range r.Trades[start:] {
+			lines = append(lines, fmt.Sprintf("  %s %-5s %-10s %.4f → %.4f %+.2f%% (%s, %d bars)",
+				t.EntryTime.UTC().Format("2006-01-02 15:04"),
+				strings.ToUpper(string(t.Direction)),
+				t.Indicator,
+				t.EntryPrice, t.ExitPrice, t.ReturnPct*100, t.ExitReason, t.Bars))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
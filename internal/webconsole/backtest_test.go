@@ -0,0 +1,109 @@
+package webconsole
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+type strategyRunnerStub struct {
+	cfg    domain.BacktestConfig
+	result *domain.BacktestResult
+	err    error
+}
+
+func (s *strategyRunnerStub) RunStrategy(ctx context.Context, cfg domain.BacktestConfig) (*domain.BacktestResult, error) {
+	s.cfg = cfg
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.result, nil
+}
+
+func TestParseBacktestArgs(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	cfg, err := parseBacktestArgs("eth 4h --days 30 --hold=12 --fee 0 --slippage 2.5 --indicators RSI,macd --max-risk 3 --short --no-stops", now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Symbol != "ETH" || cfg.Interval != "4h" {
+		t.Fatalf("unexpected symbol/interval: %s %s", cfg.Symbol, cfg.Interval)
+	}
+	if !cfg.From.Equal(now.AddDate(0, 0, -30)) || !cfg.To.Equal(now) {
+		t.Fatalf("unexpected window: %s - %s", cfg.From, cfg.To)
+	}
+	if cfg.HoldBars != 12 || cfg.FeeBps != 0 || cfg.SlippageBps != 2.5 || cfg.MaxRisk != domain.RiskLevel3 {
+		t.Fatalf("unexpected numeric flags: %+v", cfg)
+	}
+	if len(cfg.Indicators) != 2 || cfg.Indicators[0] != "RSI" {
+		t.Fatalf("unexpected indicators: %v", cfg.Indicators)
+	}
+	if !cfg.AllowShort || cfg.UseStops {
+		t.Fatalf("expected shorts on and stops off: %+v", cfg)
+	}
+
+	cfg, err = parseBacktestArgs("btc", now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Interval != "1h" || !cfg.UseStops || cfg.FeeBps != 10 || cfg.SlippageBps != 5 {
+		t.Fatalf("unexpected defaults: %+v", cfg)
+	}
+	if !cfg.From.Equal(now.AddDate(0, 0, -defaultStrategyBacktestDays)) {
+		t.Fatalf("unexpected default window start: %s", cfg.From)
+	}
+}
+
+func TestParseBacktestArgsErrors(t *testing.T) {
+	now := time.Now().UTC()
+	for _, args := range []string{"", "--days 30", "btc --days", "btc --days 0", "btc --hold x", "btc --bogus 1"} {
+		if _, err := parseBacktestArgs(args, now); err == nil {
+			t.Fatalf("expected error for %q", args)
+		}
+	}
+}
+
+func TestServiceRunBacktest(t *testing.T) {
+	svc := NewService(nil, nil, nil, nil)
+	if _, err := svc.RunBacktest(context.Background(), "btc"); err == nil {
+		t.Fatal("expected unavailable error")
+	}
+
+	entry := time.Date(2026, 2, 1, 8, 0, 0, 0, time.UTC)
+	stub := &strategyRunnerStub{result: &domain.BacktestResult{
+		Config: domain.BacktestConfig{Symbol: "BTC", Interval: "1h", InitialEquity: 10000},
+		Metrics: domain.BacktestMetrics{
+			Trades: 1, Wins: 1, WinRate: 1, ProfitFactor: 100, TotalReturn: 0.02, FinalEquity: 10200,
+		},
+		Trades: []domain.BacktestTrade{{
+			Indicator: "rsi", Direction: domain.DirectionLong, EntryTime: entry,
+			EntryPrice: 100, ExitPrice: 102, ReturnPct: 0.02, ExitReason: domain.ExitReasonTarget, Bars: 3,
+		}},
+	}}
+	svc.SetStrategyBacktest(stub)
+
+	report, err := svc.RunBacktest(context.Background(), "btc --days 7")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stub.cfg.Symbol != "BTC" {
+		t.Fatalf("expected parsed config passed to runner, got %+v", stub.cfg)
+	}
+	for _, want := range []string{"Backtest BTC 1h", "trades 1", "win rate 100.0%", "+2.00%", "LONG", "target"} {
+		if !strings.Contains(report, want) {
+			t.Fatalf("expected %q in report:\n%s", want, report)
+		}
+	}
+
+	stub.err = errors.New("boom")
+	if _, err := svc.RunBacktest(context.Background(), "btc"); err == nil {
+		t.Fatal("expected runner error")
+	}
+	if _, err := svc.RunBacktest(context.Background(), ""); err == nil {
+		t.Fatal("expected usage error")
+	}
+}
@@ -185,6 +185,10 @@ func (h *Handler) WebSocket(c *gin.Context) {
 		return conn.SetReadDeadline(time.Now().Add(2 * h.cfg.Heartbeat))
 	})
 
+	// Answers still streaming when the socket closes are cancelled.
+	askCtx, cancelAsks := context.WithCancel(ctx)
+	defer cancelAsks()
+
 	heartbeatDone := make(chan struct{})
 	go func() {
 		ticker := time.NewTicker(h.cfg.Heartbeat)
@@ -221,28 +225,61 @@ func (h *Handler) WebSocket(c *gin.Context) {
 				requestID = fmt.Sprintf("req_%d", time.Now().UnixNano())
 			}
 			command := strings.ToLower(strings.TrimSpace(msg.Command))
-			if command != "ask" {
-				_ = h.emitEvent(context.Background(), client, meta.SessionID, Event{Type: EventTypeUIError, RequestID: requestID, Code: "UNSUPPORTED_COMMAND", Message: "only ask command is supported"})
-				continue
-			}
-			question := strings.TrimSpace(msg.Message)
-			if question == "" {
-				_ = h.emitEvent(context.Background(), client, meta.SessionID, Event{Type: EventTypeUIError, RequestID: requestID, Code: "INVALID_COMMAND", Message: "message is required"})
-				continue
-			}
-			_ = h.sessions.PushHistory(ctx, meta.SessionID, question)
-			_ = h.emitEvent(context.Background(), client, meta.SessionID, Event{Type: EventTypeUIStatus, RequestID: requestID, State: "thinking", Message: "advisor is thinking"})
-
-			go func(reqID string, text string) {
-				reply, err := h.service.Ask(ctx, meta.SessionID, text)
-				if err != nil {
-					_ = h.emitEvent(context.Background(), client, meta.SessionID, Event{Type: EventTypeUIError, RequestID: reqID, Code: "ADVISOR_ERROR", Message: err.Error()})
-					_ = h.emitEvent(context.Background(), client, meta.SessionID, Event{Type: EventTypeUIStatus, RequestID: reqID, State: "idle", Message: "advisor error"})
-					return
+			switch command {
+			case "ask":
+				question := strings.TrimSpace(msg.Message)
+				if question == "" {
+					_ = h.emitEvent(context.Background(), client, meta.SessionID, Event{Type: EventTypeUIError, RequestID: requestID, Code: "INVALID_COMMAND", Message: "message is required"})
+					continue
 				}
-				_ = h.emitEvent(context.Background(), client, meta.SessionID, Event{Type: EventTypeUIChatReply, RequestID: reqID, State: "assistant", Message: reply})
-				_ = h.emitEvent(context.Background(), client, meta.SessionID, Event{Type: EventTypeUIStatus, RequestID: reqID, State: "idle", Message: "reply ready"})
-			}(requestID, question)
+				_ = h.sessions.PushHistory(ctx, meta.SessionID, question)
+				_ = h.emitEvent(context.Background(), client, meta.SessionID, Event{Type: EventTypeUIStatus, RequestID: requestID, State: "thinking", Message: "advisor is thinking"})
+
+				go func(reqID string, text string) {
+					reply, err := h.service.AskStream(askCtx, meta.SessionID, text, func(delta string) {
+						_ = h.sendTransient(client, meta.SessionID, Event{Type: EventTypeUIChatDelta, RequestID: reqID, State: "assistant", Message: delta})
+					})
+					if askCtx.Err() != nil {
+						return // the socket closed; nobody is waiting for the answer
+					}
+					if err != nil {
+						_ = h.emitEvent(context.Background(), client, meta.SessionID, Event{Type: EventTypeUIError, RequestID: reqID, Code: "ADVISOR_ERROR", Message: err.Error()})
+						_ = h.emitEvent(context.Background(), client, meta.SessionID, Event{Type: EventTypeUIStatus, RequestID: reqID, State: "idle", Message: "advisor error"})
+						return
+					}
+					_ = h.emitEvent(context.Background(), client, meta.SessionID, Event{Type: EventTypeUIChatReply, RequestID: reqID, State: "assistant", Message: reply})
+					_ = h.emitEvent(context.Background(), client, meta.SessionID, Event{Type: EventTypeUIStatus, RequestID: reqID, State: "idle", Message: "reply ready"})
+				}(requestID, question)
+			case "backtest":
+				args := strings.TrimSpace(msg.Message)
+				_ = h.sessions.PushHistory(ctx, meta.SessionID, "backtest "+args)
+				_ = h.emitEvent(context.Background(), client, meta.SessionID, Event{Type: EventTypeUIStatus, RequestID: requestID, State: "running", Message: "backtest running"})
+
+				go func(reqID string, args string) {
+					report, err := h.service.RunBacktest(ctx, args)
+					if err != nil {
+						_ = h.emitEvent(context.Background(), client, meta.SessionID, Event{Type: EventTypeUIError, RequestID: reqID, Code: "BACKTEST_ERROR", Message: err.Error()})
+						_ = h.emitEvent(context.Background(), client, meta.SessionID, Event{Type: EventTypeUIStatus, RequestID: reqID, State: "idle", Message: "backtest error"})
+						return
+					}
+					_ = h.emitEvent(context.Background(), client, meta.SessionID, Event{Type: EventTypeUIChatReply, RequestID: reqID, State: "backtest", Message: report})
+					_ = h.emitEvent(context.Background(), client, meta.SessionID, Event{Type: EventTypeUIStatus, RequestID: reqID, State: "idle", Message: "backtest ready"})
+				}(requestID, args)
+			case "paper":
+				args := strings.TrimSpace(msg.Message)
+				_ = h.sessions.PushHistory(ctx, meta.SessionID, strings.TrimSpace("paper "+args))
+
+				go func(reqID string, args string) {
+					report, err := h.service.Paper(ctx, args)
+					if err != nil {
+						_ = h.emitEvent(context.Background(), client, meta.SessionID, Event{Type: EventTypeUIError, RequestID: reqID, Code: "PAPER_ERROR", Message: err.Error()})
+						return
+					}
+					_ = h.emitEvent(context.Background(), client, meta.SessionID, Event{Type: EventTypeUIChatReply, RequestID: reqID, State: "paper", Message: report})
+				}(requestID, args)
+			default:
+				_ = h.emitEvent(context.Background(), client, meta.SessionID, Event{Type: EventTypeUIError, RequestID: requestID, Code: "UNSUPPORTED_COMMAND", Message: "supported commands: ask, backtest, paper"})
+			}
 		default:
 			_ = h.emitEvent(context.Background(), client, meta.SessionID, Event{Type: EventTypeUIError, RequestID: msg.RequestID, Code: "UNKNOWN_EVENT", Message: "unsupported client message type"})
 		}
@@ -257,6 +294,15 @@ func (h *Handler) emitEvent(ctx context.Context, client *wsClient, sessionID str
 	return client.send(persisted)
 }
 
+// sendTransient sends an event without storing it in the session, for
+// reply deltas: the full reply follows as a stored ui.chat.reply, which is
+// what a reconnecting client replays.
+func (h *Handler) sendTransient(client *wsClient, sessionID string, event Event) error {
+	event.SessionID = sessionID
+	event.Timestamp = time.Now().UTC()
+	return client.send(event)
+}
+
 func (h *Handler) requireAuth(c *gin.Context) error {
 	if h.auth == nil {
 		return fmt.Errorf("auth unavailable")
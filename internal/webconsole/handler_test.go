@@ -21,10 +21,13 @@ type advisorTestStub struct {
 	err   error
 }
 
-func (s *advisorTestStub) Ask(ctx context.Context, chatID int64, message string) (string, error) {
+func (s *advisorTestStub) AskStream(ctx context.Context, chatID int64, message string, onDelta func(string)) (string, error) {
 	if s.err != nil {
 		return "", s.err
 	}
+	for _, word := range strings.SplitAfter(s.reply, " ") {
+		onDelta(word)
+	}
 	return s.reply, nil
 }
 
@@ -81,11 +84,18 @@ func TestWebConsoleWebSocketAskFlow(t *testing.T) {
 		t.Fatalf("expected thinking message")
 	}
 
+	var streamed string
 	reply := waitForEvent(t, conn, func(e Event) bool {
+		if e.Type == EventTypeUIChatDelta && e.RequestID == "req-1" {
+			if e.Seq != 0 {
+				t.Fatalf("expected deltas not to be stored, got seq %d", e.Seq)
+			}
+			streamed += e.Message
+		}
 		return e.Type == EventTypeUIChatReply && e.RequestID == "req-1"
 	})
-	if reply.Message != "advisor reply" {
-		t.Fatalf("expected advisor reply, got %q", reply.Message)
+	if reply.Message != "advisor reply" || streamed != "advisor reply" {
+		t.Fatalf("expected advisor reply streamed then sent whole, got %q after %q", reply.Message, streamed)
 	}
 
 	idle := waitForEvent(t, conn, func(e Event) bool {
@@ -96,6 +106,70 @@ func TestWebConsoleWebSocketAskFlow(t *testing.T) {
 	}
 }
 
+func TestWebConsoleWebSocketBacktestUnavailable(t *testing.T) {
+	baseURL, sessionID, cookieHeader, shutdown := testWebConsoleServer(t, "expected", &advisorTestStub{reply: "ok"})
+	defer shutdown()
+
+	wsURL := "ws" + strings.TrimPrefix(baseURL, "http") + "/api/web-console/ws?session_id=" + sessionID
+	dialer := websocket.Dialer{HandshakeTimeout: 5 * time.Second}
+	conn, _, err := dialer.Dial(wsURL, http.Header{"Cookie": []string{cookieHeader}})
+	if err != nil {
+		t.Fatalf("ws dial failed: %v", err)
+	}
+	defer conn.Close()
+	_ = readEvent(t, conn)
+
+	if err := conn.WriteJSON(ClientMessage{
+		Type:      ClientTypeUICommand,
+		SessionID: sessionID,
+		RequestID: "req-bt",
+		Command:   "backtest",
+		Message:   "BTC 1h --days 30",
+	}); err != nil {
+		t.Fatalf("write message: %v", err)
+	}
+
+	waitForEvent(t, conn, func(e Event) bool {
+		return e.Type == EventTypeUIStatus && e.RequestID == "req-bt" && e.State == "running"
+	})
+	failure := waitForEvent(t, conn, func(e Event) bool {
+		return e.Type == EventTypeUIError && e.RequestID == "req-bt"
+	})
+	if failure.Code != "BACKTEST_ERROR" {
+		t.Fatalf("expected BACKTEST_ERROR, got %#v", failure)
+	}
+
+	if err := conn.WriteJSON(ClientMessage{
+		Type:      ClientTypeUICommand,
+		SessionID: sessionID,
+		RequestID: "req-paper",
+		Command:   "paper",
+	}); err != nil {
+		t.Fatalf("write message: %v", err)
+	}
+	paperFailure := waitForEvent(t, conn, func(e Event) bool {
+		return e.Type == EventTypeUIError && e.RequestID == "req-paper"
+	})
+	if paperFailure.Code != "PAPER_ERROR" {
+		t.Fatalf("expected PAPER_ERROR, got %#v", paperFailure)
+	}
+
+	if err := conn.WriteJSON(ClientMessage{
+		Type:      ClientTypeUICommand,
+		SessionID: sessionID,
+		RequestID: "req-x",
+		Command:   "dance",
+	}); err != nil {
+		t.Fatalf("write message: %v", err)
+	}
+	unsupported := waitForEvent(t, conn, func(e Event) bool {
+		return e.Type == EventTypeUIError && e.RequestID == "req-x"
+	})
+	if unsupported.Code != "UNSUPPORTED_COMMAND" {
+		t.Fatalf("expected UNSUPPORTED_COMMAND, got %#v", unsupported)
+	}
+}
+
 func testWebConsoleServer(t *testing.T, apiKey string, advisor AdvisorReader) (baseURL string, sessionID string, cookieHeader string, shutdown func()) {
 	t.Helper()
 	gin.SetMode(gin.TestMode)
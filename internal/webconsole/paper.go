@@ -0,0 +1,92 @@
+package webconsole
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+const maxPaperOrderLines = 10
+
+type PaperReader interface {
+	ListAccounts(ctx context.Context) ([]domain.PaperAccount, error)
+	Portfolio(ctx context.Context, accountID int64) (*domain.PaperPortfolio, error)
+	Orders(ctx context.Context, accountID int64, limit int) ([]domain.PaperOrder, error)
+}
+
+func (s *Service) SetPaperTrading(paper PaperReader) {
+	s.paper = paper
+}
+
+// Paper answers the "paper" console command. Without arguments it lists the
+// paper accounts; with an account id it shows that portfolio and its latest
+// orders.
+func (s *Service) Paper(ctx context.Context, args string) (string, error) {
+	if s.paper == nil {
+		return "", fmt.Errorf("paper trading unavailable")
+	}
+	args = strings.TrimPrefix(strings.TrimSpace(args), "#")
+	if args == "" {
+		accounts, err := s.paper.ListAccounts(ctx)
+		if err != nil {
+			return "", err
+		}
+		return formatPaperAccounts(accounts), nil
+	}
+	id, err := strconv.ParseInt(args, 10, 64)
+	if err != nil || id <= 0 {
+		return "", fmt.Errorf("usage: paper [account id]")
+	}
+	p, err := s.paper.Portfolio(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	orders, err := s.paper.Orders(ctx, id, maxPaperOrderLines)
+	if err != nil {
+		return "", err
+	}
+	return formatPaperPortfolio(p, orders), nil
+}
+
+func formatPaperAccounts(accounts []domain.PaperAccount) string {
+	if len(accounts) == 0 {
+		return "no paper accounts yet"
+	}
+	lines := []string{"paper accounts:"}
+	for _, a := range accounts {
+		lines = append(lines, fmt.Sprintf("  #%-4d %-24s cash %.2f  start %.2f  slippage %.1fbps",
+			a.ID, a.Name, a.Cash, a.StartingBalance, a.SlippageBps))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatPaperPortfolio(p *domain.PaperPortfolio, orders []domain.PaperOrder) string {
+	lines := []string{
+		fmt.Sprintf("Paper #%d %s", p.Account.ID, p.Account.Name),
+		fmt.Sprintf("equity %.2f (%+.2f%%)  cash %.2f", p.Equity, p.Return*100, p.Account.Cash),
+		fmt.Sprintf("realised %+.2f  unrealised %+.2f", p.RealizedPnL, p.UnrealizedPnL),
+	}
+	if len(p.Holdings) > 0 {
+		lines = append(lines, "", "holdings:")
+		for _, h := range p.Holdings {
+			lines = append(lines, fmt.Sprintf("  %-6s %.6g @ %.4f → %.4f  value %.2f  %+.2f",
+				h.Symbol, h.Quantity, h.AvgPrice, h.MarkPrice, h.MarketValue, h.UnrealizedPnL))
+		}
+	}
+	if len(orders) > 0 {
+		lines = append(lines, "", "recent orders:")
+		for _, o := range orders {
+			line := fmt.Sprintf("  %s %-4s %-6s %.6g @ %.4f (%s)",
+				o.CreatedAt.UTC().Format("2006-01-02 15:04"),
+				strings.ToUpper(string(o.Side)), o.Symbol, o.Quantity, o.FillPrice, o.Source)
+			if o.Side == domain.PaperSell {
+				line += fmt.Sprintf(" pnl %+.2f", o.RealizedPnL)
+			}
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
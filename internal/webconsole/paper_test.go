@@ -0,0 +1,79 @@
+package webconsole
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-free-umbrella/internal/domain"
+)
+
+type paperReaderStub struct {
+	portfolioID int64
+	ordersLimit int
+}
+
+func (s *paperReaderStub) ListAccounts(ctx context.Context) ([]domain.PaperAccount, error) {
+	return []domain.PaperAccount{{ID: 2, Name: "telegram 42", Cash: 9000, StartingBalance: 10000, SlippageBps: 5}}, nil
+}
+
+func (s *paperReaderStub) Portfolio(ctx context.Context, accountID int64) (*domain.PaperPortfolio, error) {
+	s.portfolioID = accountID
+	return &domain.PaperPortfolio{
+		Account:       domain.PaperAccount{ID: accountID, Name: "telegram 42", Cash: 9000},
+		Equity:        10200,
+		Return:        0.02,
+		UnrealizedPnL: 200,
+		Holdings: []domain.PaperHolding{{
+			PaperPosition: domain.PaperPosition{Symbol: "ETH", Quantity: 0.5, AvgPrice: 2000},
+			MarkPrice:     2400,
+			MarketValue:   1200,
+			UnrealizedPnL: 200,
+		}},
+	}, nil
+}
+
+func (s *paperReaderStub) Orders(ctx context.Context, accountID int64, limit int) ([]domain.PaperOrder, error) {
+	s.ordersLimit = limit
+	at := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	return []domain.PaperOrder{
+		{Symbol: "SOL", Side: domain.PaperSell, Quantity: 2, FillPrice: 150, RealizedPnL: 20, Source: domain.PaperSourceSignal, CreatedAt: at},
+		{Symbol: "ETH", Side: domain.PaperBuy, Quantity: 0.5, FillPrice: 2000, Source: domain.PaperSourceManual, CreatedAt: at},
+	}, nil
+}
+
+func TestServicePaper(t *testing.T) {
+	svc := NewService(nil, nil, nil, nil)
+	if _, err := svc.Paper(context.Background(), ""); err == nil {
+		t.Fatal("expected unavailable error")
+	}
+
+	stub := &paperReaderStub{}
+	svc.SetPaperTrading(stub)
+
+	list, err := svc.Paper(context.Background(), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(list, "#2") || !strings.Contains(list, "telegram 42") {
+		t.Fatalf("unexpected account list:\n%s", list)
+	}
+
+	report, err := svc.Paper(context.Background(), "#2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stub.portfolioID != 2 || stub.ordersLimit != maxPaperOrderLines {
+		t.Fatalf("unexpected lookups: id %d limit %d", stub.portfolioID, stub.ordersLimit)
+	}
+	for _, want := range []string{"equity 10200.00 (+2.00%)", "ETH", "+200.00", "SELL SOL", "pnl +20.00", "(signal)"} {
+		if !strings.Contains(report, want) {
+			t.Fatalf("expected %q in report:\n%s", want, report)
+		}
+	}
+
+	if _, err := svc.Paper(context.Background(), "abc"); err == nil {
+		t.Fatal("expected usage error")
+	}
+}
@@ -316,7 +316,7 @@ func formatPrice(price *domain.PriceSnapshot) string {
 }
 
 func formatSignal(signal domain.Signal) string {
-	return fmt.Sprintf("#%d %s %s %s %s risk=%d %s",
+	line := fmt.Sprintf("#%d %s %s %s %s risk=%d %s",
 		signal.ID,
 		signal.Symbol,
 		signal.Interval,
@@ -325,6 +325,10 @@ func formatSignal(signal domain.Signal) string {
 		signal.Risk,
 		signal.Timestamp.UTC().Format(time.RFC3339),
 	)
+	if l := signal.Levels; l != nil && len(l.Targets) > 0 {
+		line += fmt.Sprintf(" entry=%.4f stop=%.4f target=%.4f rr=%.2f", l.Entry, l.Stop, l.Targets[0], l.RewardRisk)
+	}
+	return line
 }
 
 func chatIDFromSession(sessionID string) int64 {
@@ -9,8 +9,10 @@ import (
 	"bug-free-umbrella/internal/repository"
 )
 
+// AdvisorReader answers chat questions, passing the reply text to onDelta
+// as it is written.
 type AdvisorReader interface {
-	Ask(ctx context.Context, chatID int64, message string) (string, error)
+	AskStream(ctx context.Context, chatID int64, message string, onDelta func(string)) (string, error)
 }
 
 type PriceReader interface {
@@ -43,6 +45,8 @@ type Service struct {
 	signals  SignalReader
 	backtest BacktestReader
 	advisor  AdvisorReader
+	strategy StrategyBacktestRunner
+	paper    PaperReader
 }
 
 func NewService(prices PriceReader, signals SignalReader, backtest BacktestReader, advisor AdvisorReader) *Service {
@@ -55,6 +59,11 @@ func NewService(prices PriceReader, signals SignalReader, backtest BacktestReade
 }
 
 func (s *Service) Ask(ctx context.Context, sessionID, message string) (string, error) {
+	return s.AskStream(ctx, sessionID, message, nil)
+}
+
+// AskStream is Ask with the reply text passed to onDelta as it arrives.
+func (s *Service) AskStream(ctx context.Context, sessionID, message string, onDelta func(string)) (string, error) {
 	if s.advisor == nil {
 		return "", fmt.Errorf("advisor unavailable")
 	}
@@ -63,7 +72,7 @@ func (s *Service) Ask(ctx context.Context, sessionID, message string) (string, e
 		return "", fmt.Errorf("message is required")
 	}
 	chatID := chatIDFromSession(sessionID)
-	return s.advisor.Ask(ctx, chatID, message)
+	return s.advisor.AskStream(ctx, chatID, message, onDelta)
 }
 
 func (s *Service) DefaultDashboardSignalLimit() int { return 10 }
@@ -16,12 +16,15 @@ type advisorStub struct {
 	msg   string
 }
 
-func (s *advisorStub) Ask(ctx context.Context, chatID int64, message string) (string, error) {
+func (s *advisorStub) AskStream(ctx context.Context, chatID int64, message string, onDelta func(string)) (string, error) {
 	s.chat = chatID
 	s.msg = message
 	if s.err != nil {
 		return "", s.err
 	}
+	if onDelta != nil {
+		onDelta(s.reply)
+	}
 	return s.reply, nil
 }
 
@@ -44,6 +47,20 @@ func TestServiceAsk(t *testing.T) {
 	}
 }
 
+func TestServiceAskStream(t *testing.T) {
+	stub := &advisorStub{reply: "ok"}
+	svc := NewService(nil, nil, nil, stub)
+
+	var deltas []string
+	reply, err := svc.AskStream(context.Background(), "abc", " hello ", func(d string) { deltas = append(deltas, d) })
+	if err != nil || reply != "ok" {
+		t.Fatalf("unexpected reply %q (%v)", reply, err)
+	}
+	if len(deltas) != 1 || deltas[0] != "ok" || stub.msg != "hello" {
+		t.Fatalf("expected the delta and a trimmed message, got %q %q", deltas, stub.msg)
+	}
+}
+
 func TestServiceAskErrors(t *testing.T) {
 	svc := NewService(nil, nil, nil, nil)
 	if _, err := svc.Ask(context.Background(), "abc", "hello"); err == nil {
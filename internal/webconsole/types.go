@@ -11,6 +11,7 @@ const (
 const (
 	EventTypeUIStatus    = "ui.status"
 	EventTypeUIChatReply = "ui.chat.reply"
+	EventTypeUIChatDelta = "ui.chat.delta"
 	EventTypeUIError     = "ui.error"
 	EventTypeUIHeartbeat = "ui.heartbeat"
 )
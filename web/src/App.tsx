@@ -10,6 +10,7 @@ import {
   login,
   logout,
 } from './lib/api'
+import { applyChatDelta, applyChatReply, streamLineID } from './lib/chat'
 import {
   SIGNAL_INDICATOR_OPTIONS,
   SIGNAL_RISK_OPTIONS,
@@ -279,6 +280,9 @@ export default function App() {
         if (state === 'thinking') {
           setChatWaiting(true)
           setStatusText('advisor thinking')
+        } else if (state === 'running') {
+          setChatWaiting(true)
+          setStatusText('backtest running')
         } else if (state === 'idle') {
           setChatWaiting(false)
           setStatusText('ready')
@@ -288,9 +292,15 @@ export default function App() {
         } else {
           setStatusText(state)
         }
+      } else if (event.type === 'ui.chat.delta') {
+        const requestID = event.request_id ?? ''
+        const at = new Date().toISOString()
+        setChatLines((prev) => applyChatDelta(prev, requestID, event.message ?? '', at))
       } else if (event.type === 'ui.chat.reply') {
         setChatWaiting(false)
-        appendChatLine({ role: 'assistant', text: event.message ?? '' })
+        const requestID = event.request_id ?? uid()
+        const at = new Date().toISOString()
+        setChatLines((prev) => applyChatReply(prev, requestID, event.message ?? '', at))
         activeRequestRef.current = null
       } else if (event.type === 'ui.error') {
         setChatWaiting(false)
@@ -521,12 +531,13 @@ export default function App() {
     const requestID = uid()
     activeRequestRef.current = requestID
     setChatWaiting(true)
+    const slash = text.match(/^\/(backtest|paper)\b\s*(.*)$/i)
     socket.send({
       type: 'ui.command',
       session_id: sessionId,
       request_id: requestID,
-      command: 'ask',
-      message: text,
+      command: slash ? slash[1].toLowerCase() : 'ask',
+      message: slash ? slash[2] : text,
     })
   }
 
@@ -667,7 +678,7 @@ export default function App() {
                     <p>{line.text}</p>
                   </article>
                 ))}
-                {chatWaiting ? (
+                {chatWaiting && !chatLines.some((line) => line.id === streamLineID(activeRequestRef.current ?? '')) ? (
                   <article className="chat-line chat-line--system">
                     <span>{new Date().toLocaleTimeString()}</span>
                     <p>Advisor is thinking...</p>
@@ -0,0 +1,27 @@
+import { describe, expect, it } from 'vitest'
+import { applyChatDelta, applyChatReply, streamLineID } from './chat'
+
+describe('chat streaming helpers', () => {
+  const at = '2025-03-07T12:00:00Z'
+
+  it('accumulates deltas into one line per request', () => {
+    let lines = applyChatDelta([], 'req-1', 'BTC ', at)
+    lines = applyChatDelta(lines, 'req-1', 'is up', at)
+    expect(lines).toHaveLength(1)
+    expect(lines[0]).toMatchObject({ id: streamLineID('req-1'), role: 'assistant', text: 'BTC is up' })
+  })
+
+  it('replaces streamed text with the final reply', () => {
+    const streamed = applyChatDelta([], 'req-1', 'Let me check. ', at)
+    const lines = applyChatReply(streamed, 'req-1', 'BTC is up 2%.', at)
+    expect(lines).toHaveLength(1)
+    expect(lines[0].text).toBe('BTC is up 2%.')
+    expect(lines[0].id).not.toBe(streamLineID('req-1'))
+  })
+
+  it('adds a reply that was not streamed', () => {
+    const lines = applyChatReply([], 'req-2', 'backtest report', at)
+    expect(lines).toHaveLength(1)
+    expect(lines[0]).toMatchObject({ role: 'assistant', text: 'backtest report' })
+  })
+})
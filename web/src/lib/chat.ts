@@ -0,0 +1,26 @@
+import type { ChatLine } from '../types/events'
+
+// streamLineID is the chat line id that an answer streams into.
+export function streamLineID(requestID: string): string {
+  return `stream-${requestID}`
+}
+
+// applyChatDelta appends streamed reply text to the request's line, adding
+// the line on the first delta.
+export function applyChatDelta(lines: ChatLine[], requestID: string, delta: string, at: string): ChatLine[] {
+  const id = streamLineID(requestID)
+  if (lines.some((line) => line.id === id)) {
+    return lines.map((line) => (line.id === id ? { ...line, text: line.text + delta } : line))
+  }
+  return [...lines, { id, role: 'assistant', text: delta, at }]
+}
+
+// applyChatReply settles a request's streamed line on the full reply, or
+// adds the reply when nothing was streamed.
+export function applyChatReply(lines: ChatLine[], requestID: string, text: string, at: string): ChatLine[] {
+  const id = streamLineID(requestID)
+  if (lines.some((line) => line.id === id)) {
+    return lines.map((line) => (line.id === id ? { ...line, id: `${id}-done`, text } : line))
+  }
+  return [...lines, { id: `${id}-done`, role: 'assistant', text, at }]
+}
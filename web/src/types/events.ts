@@ -66,7 +66,7 @@ export type ChatLine = {
 }
 
 export type ServerEvent = {
-  type: 'ui.status' | 'ui.chat.reply' | 'ui.error' | 'ui.heartbeat' | string
+  type: 'ui.status' | 'ui.chat.delta' | 'ui.chat.reply' | 'ui.error' | 'ui.heartbeat' | string
   session_id?: string
   request_id?: string
   seq?: number